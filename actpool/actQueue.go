@@ -94,6 +94,10 @@ func (q *actQueue) Overlaps(act *iproto.ActionPb) bool {
 		vote := &action.Vote{}
 		vote.ConvertFromVotePb(act.GetVote())
 		nonce = vote.Nonce
+	case act.GetExecution() != nil:
+		nonce = act.GetExecution().GetNonce()
+	case act.GetStake() != nil:
+		nonce = act.GetStake().GetNonce()
 	}
 	return q.items[nonce] != nil
 }
@@ -110,6 +114,10 @@ func (q *actQueue) Put(act *iproto.ActionPb) error {
 		vote := &action.Vote{}
 		vote.ConvertFromVotePb(act.GetVote())
 		nonce = vote.Nonce
+	case act.GetExecution() != nil:
+		nonce = act.GetExecution().GetNonce()
+	case act.GetStake() != nil:
+		nonce = act.GetStake().GetNonce()
 	}
 	if q.items[nonce] != nil {
 		return errors.Wrapf(ErrNonce, "duplicate nonce")
@@ -134,17 +142,13 @@ func (q *actQueue) FilterNonce(threshold uint64) []*iproto.ActionPb {
 // UpdateQueue updates the pending nonce and balance of the queue
 func (q *actQueue) UpdateQueue(nonce uint64) []*iproto.ActionPb {
 	// First, starting from the current pending nonce, incrementally find the next pending nonce
-	// while updating pending balance if transfers are payable
+	// while updating pending balance if actions are payable
 	for ; q.items[nonce] != nil; nonce++ {
-		if q.items[nonce].GetVote() != nil {
-			continue
-		}
-		tsf := &action.Transfer{}
-		tsf.ConvertFromTransferPb(q.items[nonce].GetTransfer())
-		if q.pendingBalance.Cmp(tsf.Amount) < 0 {
+		cost := actionCost(q.items[nonce])
+		if q.pendingBalance.Cmp(cost) < 0 {
 			break
 		}
-		q.pendingBalance.Sub(q.pendingBalance, tsf.Amount)
+		q.pendingBalance.Sub(q.pendingBalance, cost)
 	}
 	q.pendingNonce = nonce
 
@@ -156,24 +160,20 @@ func (q *actQueue) UpdateQueue(nonce uint64) []*iproto.ActionPb {
 			break
 		}
 	}
-	// Case I: An unpayable transfer has been found while updating pending nonce/balance
+	// Case I: An unpayable action has been found while updating pending nonce/balance
 	// Remove all the subsequent actions in the queue starting from the index of new pending nonce
 	if q.items[nonce] != nil {
 		return q.removeActs(i)
 	}
 
-	// Case II: All transfers are payable while updating pending nonce/balance
+	// Case II: All actions are payable while updating pending nonce/balance
 	// Check all the subsequent actions in the queue starting from the index of new pending nonce
-	// Find the nonce index of the first unpayable transfer
+	// Find the nonce index of the first unpayable action
 	// Remove all the subsequent actions in the queue starting from that index
 	for ; i < q.index.Len(); i++ {
 		nonce = q.index[i]
-		if transfer := q.items[nonce].GetTransfer(); transfer != nil {
-			tsf := &action.Transfer{}
-			tsf.ConvertFromTransferPb(transfer)
-			if q.pendingBalance.Cmp(tsf.Amount) < 0 {
-				break
-			}
+		if q.pendingBalance.Cmp(actionCost(q.items[nonce])) < 0 {
+			break
 		}
 	}
 	return q.removeActs(i)
@@ -256,3 +256,27 @@ func (q *actQueue) removeActs(idx int) []*iproto.ActionPb {
 	heap.Init(&q.index)
 	return removedFromQueue
 }
+
+// actionCost returns the balance an action consumes, including the fee
+func actionCost(pbAct *iproto.ActionPb) *big.Int {
+	act, err := action.NewActionFromProto(pbAct)
+	if err != nil {
+		return big.NewInt(0)
+	}
+	return act.Cost()
+}
+
+// actionGasPrice returns the gas price of an action
+func actionGasPrice(act *iproto.ActionPb) *big.Int {
+	switch {
+	case act.GetTransfer() != nil:
+		return new(big.Int).SetBytes(act.GetTransfer().GasPrice)
+	case act.GetVote() != nil:
+		return new(big.Int).SetBytes(act.GetVote().GasPrice)
+	case act.GetExecution() != nil:
+		return new(big.Int).SetBytes(act.GetExecution().GasPrice)
+	case act.GetStake() != nil:
+		return new(big.Int).SetBytes(act.GetStake().GasPrice)
+	}
+	return big.NewInt(0)
+}
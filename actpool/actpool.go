@@ -7,8 +7,11 @@
 package actpool
 
 import (
+	"container/heap"
 	"fmt"
+	"math/big"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -19,13 +22,18 @@ import (
 	"github.com/iotexproject/iotex-core/logger"
 	"github.com/iotexproject/iotex-core/pkg/hash"
 	"github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
 )
 
 const (
 	// TransferSizeLimit is the maximum size of transfer allowed
 	TransferSizeLimit = 32 * 1024
 	// VoteSizeLimit is the maximum size of vote allowed
-	VoteSizeLimit = 262
+	VoteSizeLimit = 302
+	// ExecutionSizeLimit is the maximum size of execution allowed
+	ExecutionSizeLimit = 32 * 1024
+	// StakeSizeLimit is the maximum size of stake allowed
+	StakeSizeLimit = 302
 )
 
 var (
@@ -41,18 +49,24 @@ var (
 	ErrBalance = errors.New("invalid balance")
 	// ErrVotee indicates the error of votee
 	ErrVotee = errors.New("votee is not a candidate")
+	// ErrGasPrice indicates the error of gas price
+	ErrGasPrice = errors.New("invalid gas price")
 )
 
 // ActPool is the interface of actpool
 type ActPool interface {
 	// Reset resets actpool state
 	Reset()
-	// PickActs returns all currently accepted transfers and votes in actpool
-	PickActs() ([]*action.Transfer, []*action.Vote)
+	// PickActs returns all currently accepted actions in actpool, ordered by gas price
+	PickActs() []action.Action
 	// AddTsf adds an transfer into the pool after passing validation
 	AddTsf(tsf *action.Transfer) error
 	// AddVote adds a vote into the pool after passing validation
 	AddVote(vote *action.Vote) error
+	// AddExecution adds an execution into the pool after passing validation
+	AddExecution(execution *action.Execution) error
+	// AddStake adds a stake or unstake into the pool after passing validation
+	AddStake(stake *action.Stake) error
 	// GetPendingNonce returns pending nonce in pool given an account address
 	GetPendingNonce(addr string) (uint64, error)
 	// GetUnconfirmedActs returns unconfirmed actions in pool given an account address
@@ -69,6 +83,8 @@ type actPool struct {
 	bc               blockchain.Blockchain
 	accountActs      map[string]ActQueue
 	allActions       map[hash.Hash32B]*iproto.ActionPb
+	// minGasPrice indicates the lowest gas price of the actions the pool accepts
+	minGasPrice *big.Int
 }
 
 // NewActPool constructs a new actpool
@@ -82,6 +98,7 @@ func NewActPool(bc blockchain.Blockchain, cfg config.ActPool) (ActPool, error) {
 		bc:               bc,
 		accountActs:      make(map[string]ActQueue),
 		allActions:       make(map[hash.Hash32B]*iproto.ActionPb),
+		minGasPrice:      new(big.Int).SetUint64(cfg.MinGasPrice),
 	}
 	return ap, nil
 }
@@ -122,28 +139,45 @@ func (ap *actPool) Reset() {
 	}
 }
 
-// PickActs returns all currently accepted transfers and votes for all accounts
-func (ap *actPool) PickActs() ([]*action.Transfer, []*action.Vote) {
+// PickActs returns all currently accepted actions for all accounts
+// Actions paying higher gas prices are picked first, while the actions of an account are kept in nonce order
+// Scheduled actions are held until they are eligible for the next block, along with the later actions of the account
+func (ap *actPool) PickActs() []action.Action {
 	ap.mutex.Lock()
 	defer ap.mutex.Unlock()
 
-	transfers := make([]*action.Transfer, 0)
-	votes := make([]*action.Vote, 0)
+	actions := make([]action.Action, 0)
+	tipHeight, err := ap.bc.TipHeight()
+	if err != nil {
+		logger.Error().Err(err).Msg("Error when picking actions")
+		return actions
+	}
+	timestamp := uint64(time.Now().Unix())
+	pending := make(actsByGasPrice, 0, len(ap.accountActs))
 	for _, queue := range ap.accountActs {
-		for _, act := range queue.PendingActs() {
-			switch {
-			case act.GetTransfer() != nil:
-				tsf := action.Transfer{}
-				tsf.ConvertFromTransferPb(act.GetTransfer())
-				transfers = append(transfers, &tsf)
-			case act.GetVote() != nil:
-				vote := action.Vote{}
-				vote.ConvertFromVotePb(act.GetVote())
-				votes = append(votes, &vote)
-			}
+		if acts := eligibleActs(queue.PendingActs(), tipHeight+1, timestamp); len(acts) > 0 {
+			pending = append(pending, acts)
 		}
 	}
-	return transfers, votes
+	heap.Init(&pending)
+	for pending.Len() > 0 {
+		acts := pending[0]
+		act, err := action.NewActionFromProto(acts[0])
+		if err != nil {
+			// Skip the later actions of the account as well, which would leave a nonce gap in the block
+			logger.Error().Err(err).Msg("Error when picking actions")
+			heap.Pop(&pending)
+			continue
+		}
+		actions = append(actions, act)
+		if len(acts) == 1 {
+			heap.Pop(&pending)
+			continue
+		}
+		pending[0] = acts[1:]
+		heap.Fix(&pending, 0)
+	}
+	return actions
 }
 
 // AddTsf inserts a new transfer into account queue if it passes validation
@@ -215,6 +249,68 @@ func (ap *actPool) AddVote(vote *action.Vote) error {
 	return ap.addAction(voter.RawAddress, action, hash, vote.Nonce)
 }
 
+// AddExecution inserts a new execution into account queue if it passes validation
+func (ap *actPool) AddExecution(execution *action.Execution) error {
+	ap.mutex.Lock()
+	defer ap.mutex.Unlock()
+
+	hash := execution.Hash()
+	// Reject execution if it already exists in pool
+	if ap.allActions[hash] != nil {
+		logger.Error().
+			Hex("hash", hash[:]).
+			Msg("Rejecting existed execution")
+		return fmt.Errorf("existed execution: %x", hash)
+	}
+	// Reject execution if it fails validation
+	if err := ap.validateExecution(execution); err != nil {
+		logger.Error().
+			Hex("hash", hash[:]).
+			Err(err).
+			Msg("Rejecting invalid execution")
+		return err
+	}
+	// Reject execution if pool space is full
+	if uint64(len(ap.allActions)) >= ap.maxNumActPerPool {
+		logger.Warn().
+			Hex("hash", hash[:]).
+			Msg("Rejecting execution due to insufficient space")
+		return errors.Wrapf(ErrActPool, "insufficient space for execution")
+	}
+	return ap.addAction(execution.Executor, execution.Proto(), hash, execution.Nonce)
+}
+
+// AddStake inserts a new stake or unstake into account queue if it passes validation
+func (ap *actPool) AddStake(stake *action.Stake) error {
+	ap.mutex.Lock()
+	defer ap.mutex.Unlock()
+
+	hash := stake.Hash()
+	// Reject stake if it already exists in pool
+	if ap.allActions[hash] != nil {
+		logger.Error().
+			Hex("hash", hash[:]).
+			Msg("Rejecting existed stake")
+		return fmt.Errorf("existed stake: %x", hash)
+	}
+	// Reject stake if it fails validation
+	if err := ap.validateStake(stake); err != nil {
+		logger.Error().
+			Hex("hash", hash[:]).
+			Err(err).
+			Msg("Rejecting invalid stake")
+		return err
+	}
+	// Reject stake if pool space is full
+	if uint64(len(ap.allActions)) >= ap.maxNumActPerPool {
+		logger.Warn().
+			Hex("hash", hash[:]).
+			Msg("Rejecting stake due to insufficient space")
+		return errors.Wrapf(ErrActPool, "insufficient space for stake")
+	}
+	return ap.addAction(stake.Staker, stake.Proto(), hash, stake.Nonce)
+}
+
 // GetPendingNonce returns pending nonce in pool or confirmed nonce given an account address
 func (ap *actPool) GetPendingNonce(addr string) (uint64, error) {
 	if queue, ok := ap.accountActs[addr]; ok {
@@ -248,26 +344,49 @@ func (ap *actPool) validateTsf(tsf *action.Transfer) error {
 		logger.Error().Msg("Error when validating transfer")
 		return errors.Wrapf(ErrActPool, "oversized data")
 	}
+	// Reject multisig or scheduled transfer before the features are enabled
+	if err := ap.validateFeature(tsf); err != nil {
+		logger.Error().Err(err).Msg("Error when validating transfer")
+		return err
+	}
 	// Reject transfer of negative amount
 	if tsf.Amount.Sign() < 0 {
 		logger.Error().Msg("Error when validating transfer")
 		return errors.Wrapf(ErrBalance, "negative value")
 	}
+	// Reject transfer whose gas limit cannot cover the intrinsic gas
+	if tsf.GasLimit < tsf.IntrinsicGas() {
+		logger.Error().Msg("Error when validating transfer")
+		return errors.Wrapf(action.ErrInsufficientGas, "gas limit is lower than %d", tsf.IntrinsicGas())
+	}
+	// Reject transfer of too low gas price
+	if tsf.GasPrice == nil || tsf.GasPrice.Cmp(ap.minGasPrice) < 0 {
+		logger.Error().Msg("Error when validating transfer")
+		return errors.Wrapf(ErrGasPrice, "gas price is lower than %d", ap.minGasPrice)
+	}
 	// check if sender's address is valid
 	pkhash := iotxaddress.GetPubkeyHash(tsf.Sender)
 	if pkhash == nil {
 		return ErrInvalidAddr
 	}
 
-	sender, err := iotxaddress.GetAddress(tsf.SenderPublicKey, iotxaddress.IsTestnet, iotxaddress.ChainID)
-	if err != nil {
-		logger.Error().Err(err).Msg("Error when validating transfer")
-		return errors.Wrapf(err, "invalid address")
-	}
-	// Verify transfer using sender's public key
-	if err := tsf.Verify(sender); err != nil {
-		logger.Error().Err(err).Msg("Error when validating transfer")
-		return errors.Wrapf(err, "failed to verify Transfer signature")
+	if tsf.IsMultisig() {
+		// Verify transfer reaches the threshold of the multisig sender
+		if err := tsf.VerifyMultisig(); err != nil {
+			logger.Error().Err(err).Msg("Error when validating transfer")
+			return errors.Wrapf(err, "failed to verify Transfer multisig signatures")
+		}
+	} else {
+		sender, err := iotxaddress.GetAddress(tsf.SenderPublicKey, iotxaddress.IsTestnet, iotxaddress.ChainID)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error when validating transfer")
+			return errors.Wrapf(err, "invalid address")
+		}
+		// Verify transfer using sender's public key
+		if err := tsf.Verify(sender); err != nil {
+			logger.Error().Err(err).Msg("Error when validating transfer")
+			return errors.Wrapf(err, "failed to verify Transfer signature")
+		}
 	}
 	// Reject transfer if nonce is too low
 	confirmedNonce, err := ap.bc.Nonce(tsf.Sender)
@@ -290,6 +409,16 @@ func (ap *actPool) validateVote(vote *action.Vote) error {
 		logger.Error().Msg("Error when validating vote")
 		return errors.Wrapf(ErrActPool, "oversized data")
 	}
+	// Reject vote whose gas limit cannot cover the intrinsic gas
+	if vote.GasLimit < vote.IntrinsicGas() {
+		logger.Error().Msg("Error when validating vote")
+		return errors.Wrapf(action.ErrInsufficientGas, "gas limit is lower than %d", vote.IntrinsicGas())
+	}
+	// Reject vote of too low gas price
+	if vote.Price().Cmp(ap.minGasPrice) < 0 {
+		logger.Error().Msg("Error when validating vote")
+		return errors.Wrapf(ErrGasPrice, "gas price is lower than %d", ap.minGasPrice)
+	}
 	selfPublicKey, err := vote.SelfPublicKey()
 	if err != nil {
 		return err
@@ -342,6 +471,139 @@ func (ap *actPool) validateVote(vote *action.Vote) error {
 	return nil
 }
 
+// validateExecution checks whether an execution is valid
+func (ap *actPool) validateExecution(execution *action.Execution) error {
+	// Reject oversized execution
+	if execution.TotalSize() > ExecutionSizeLimit {
+		logger.Error().Msg("Error when validating execution")
+		return errors.Wrapf(ErrActPool, "oversized data")
+	}
+	// Reject execution before the contracts are enabled
+	if err := ap.validateFeature(execution); err != nil {
+		logger.Error().Err(err).Msg("Error when validating execution")
+		return err
+	}
+	// Reject execution of negative amount
+	if execution.Amount != nil && execution.Amount.Sign() < 0 {
+		logger.Error().Msg("Error when validating execution")
+		return errors.Wrapf(ErrBalance, "negative value")
+	}
+	// Reject execution whose gas limit cannot cover the intrinsic gas
+	if execution.GasLimit < execution.IntrinsicGas() {
+		logger.Error().Msg("Error when validating execution")
+		return errors.Wrapf(action.ErrInsufficientGas, "gas limit is lower than %d", execution.IntrinsicGas())
+	}
+	// Reject execution of too low gas price
+	if execution.GasPrice == nil || execution.GasPrice.Cmp(ap.minGasPrice) < 0 {
+		logger.Error().Msg("Error when validating execution")
+		return errors.Wrapf(ErrGasPrice, "gas price is lower than %d", ap.minGasPrice)
+	}
+	// check if the addresses of executor and contract are valid
+	if iotxaddress.GetPubkeyHash(execution.Executor) == nil {
+		return ErrInvalidAddr
+	}
+	if !execution.IsDeployment() && iotxaddress.GetPubkeyHash(execution.Contract) == nil {
+		return errors.Wrapf(ErrInvalidAddr, "invalid contract address %s", execution.Contract)
+	}
+	// Verify execution using executor's public key
+	if err := action.Verify(execution); err != nil {
+		logger.Error().Err(err).Msg("Error when validating execution")
+		return errors.Wrapf(err, "failed to verify Execution signature")
+	}
+	// Reject execution if nonce is too low
+	confirmedNonce, err := ap.bc.Nonce(execution.Executor)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error when validating execution")
+		return errors.Wrapf(err, "invalid nonce value")
+	}
+	pendingNonce := confirmedNonce + 1
+	if pendingNonce > execution.Nonce {
+		logger.Error().Msg("Error when validating execution")
+		return errors.Wrapf(ErrNonce, "nonce too low")
+	}
+	return nil
+}
+
+// validateStake checks whether a stake or unstake is valid
+func (ap *actPool) validateStake(stake *action.Stake) error {
+	// Reject oversized stake
+	if stake.TotalSize() > StakeSizeLimit {
+		logger.Error().Msg("Error when validating stake")
+		return errors.Wrapf(ErrActPool, "oversized data")
+	}
+	// Reject stake before the staking is enabled
+	if err := ap.validateFeature(stake); err != nil {
+		logger.Error().Err(err).Msg("Error when validating stake")
+		return err
+	}
+	// Reject stake of negative amount
+	if stake.Amount == nil || stake.Amount.Sign() < 0 {
+		logger.Error().Msg("Error when validating stake")
+		return errors.Wrapf(ErrBalance, "negative value")
+	}
+	// Reject stake whose gas limit cannot cover the intrinsic gas
+	if stake.GasLimit < stake.IntrinsicGas() {
+		logger.Error().Msg("Error when validating stake")
+		return errors.Wrapf(action.ErrInsufficientGas, "gas limit is lower than %d", stake.IntrinsicGas())
+	}
+	// Reject stake of too low gas price
+	if stake.GasPrice == nil || stake.GasPrice.Cmp(ap.minGasPrice) < 0 {
+		logger.Error().Msg("Error when validating stake")
+		return errors.Wrapf(ErrGasPrice, "gas price is lower than %d", ap.minGasPrice)
+	}
+	// check if address of staker is valid
+	if iotxaddress.GetPubkeyHash(stake.Staker) == nil {
+		return ErrInvalidAddr
+	}
+	// Verify stake using staker's public key
+	if err := action.Verify(stake); err != nil {
+		logger.Error().Err(err).Msg("Error when validating stake")
+		return errors.Wrapf(err, "failed to verify Stake signature")
+	}
+	// Reject unstake of more than the bonded stake
+	if stake.Unstake {
+		staker, err := ap.bc.StateByAddr(stake.Staker)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error when validating stake")
+			return errors.Wrapf(err, "invalid staker")
+		}
+		if stake.Amount.Cmp(staker.StakedAmount()) > 0 {
+			logger.Error().Msg("Error when validating stake")
+			return errors.Wrapf(ErrBalance, "unstake amount is more than the bonded stake")
+		}
+	}
+	// Reject stake if nonce is too low
+	confirmedNonce, err := ap.bc.Nonce(stake.Staker)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error when validating stake")
+		return errors.Wrapf(err, "invalid nonce value")
+	}
+	pendingNonce := confirmedNonce + 1
+	if pendingNonce > stake.Nonce {
+		logger.Error().Msg("Error when validating stake")
+		return errors.Wrapf(ErrNonce, "nonce too low")
+	}
+	return nil
+}
+
+// validateFeature checks the protocol features the action requires are in effect for the next block
+func (ap *actPool) validateFeature(act action.Action) error {
+	features := action.RequiredFeatures(act)
+	if len(features) == 0 {
+		return nil
+	}
+	tipHeight, err := ap.bc.TipHeight()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the tip height")
+	}
+	for _, feature := range features {
+		if !ap.bc.Genesis().Upgrades.IsActive(feature, tipHeight+1) {
+			return errors.Wrapf(state.ErrInactiveFeature, "%s is not active at height %d", feature, tipHeight+1)
+		}
+	}
+	return nil
+}
+
 func (ap *actPool) addAction(sender string, act *iproto.ActionPb, hash hash.Hash32B, actNonce uint64) error {
 	queue := ap.accountActs[sender]
 	if queue == nil {
@@ -381,16 +643,12 @@ func (ap *actPool) addAction(sender string, act *iproto.ActionPb, hash hash.Hash
 		return errors.Wrapf(ErrNonce, "nonce too large")
 	}
 
-	if transfer := act.GetTransfer(); transfer != nil {
-		tsf := &action.Transfer{}
-		tsf.ConvertFromTransferPb(transfer)
-		if queue.PendingBalance().Cmp(tsf.Amount) < 0 {
-			// Pending balance is insufficient
-			logger.Warn().
-				Hex("hash", hash[:]).
-				Msg("Rejecting transfer due to insufficient balance")
-			return errors.Wrapf(ErrBalance, "insufficient balance for transfer")
-		}
+	if queue.PendingBalance().Cmp(actionCost(act)) < 0 {
+		// Pending balance is insufficient
+		logger.Warn().
+			Hex("hash", hash[:]).
+			Msg("Rejecting action due to insufficient balance")
+		return errors.Wrapf(ErrBalance, "insufficient balance for action")
 	}
 
 	err := queue.Put(act)
@@ -431,18 +689,12 @@ func (ap *actPool) removeConfirmedActs() {
 }
 
 func (ap *actPool) removeInvalidActs(acts []*iproto.ActionPb) {
-	for _, act := range acts {
-		var hash hash.Hash32B
-		switch {
-		case act.GetTransfer() != nil:
-			tsf := &action.Transfer{}
-			tsf.ConvertFromTransferPb(act.GetTransfer())
-			hash = tsf.Hash()
-		case act.GetVote() != nil:
-			vote := &action.Vote{}
-			vote.ConvertFromVotePb(act.GetVote())
-			hash = vote.Hash()
+	for _, pbAct := range acts {
+		act, err := action.NewActionFromProto(pbAct)
+		if err != nil {
+			continue
 		}
+		hash := act.Hash()
 		logger.Debug().
 			Hex("hash", hash[:]).
 			Msg("Removed invalidated action")
@@ -463,3 +715,35 @@ func (ap *actPool) updateAccount(sender string) {
 		delete(ap.accountActs, sender)
 	}
 }
+
+// eligibleActs returns the leading actions eligible for the block of the given height and timestamp
+func eligibleActs(acts []*iproto.ActionPb, height uint64, timestamp uint64) []*iproto.ActionPb {
+	for i, pbAct := range acts {
+		act, err := action.NewActionFromProto(pbAct)
+		if err != nil || !action.IsEligible(act, height, timestamp) {
+			return acts[:i]
+		}
+	}
+	return acts
+}
+
+// actsByGasPrice is a max heap of the pending actions of the accounts, ordered by the gas price of the first action
+type actsByGasPrice [][]*iproto.ActionPb
+
+func (h actsByGasPrice) Len() int { return len(h) }
+func (h actsByGasPrice) Less(i, j int) bool {
+	return actionGasPrice(h[i][0]).Cmp(actionGasPrice(h[j][0])) > 0
+}
+func (h actsByGasPrice) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *actsByGasPrice) Push(x interface{}) {
+	*h = append(*h, x.([]*iproto.ActionPb))
+}
+
+func (h *actsByGasPrice) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
@@ -21,8 +22,12 @@ import (
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/iotxaddress"
 	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/pkg/version"
 	pb "github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/test/mock/mock_blockchain"
+	ta "github.com/iotexproject/iotex-core/test/testaddress"
 	"github.com/iotexproject/iotex-core/testutil"
 )
 
@@ -59,7 +64,7 @@ func TestActPool_validateTsf(t *testing.T) {
 	bc := blockchain.NewBlockchain(&config.Default, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
 	_, err := bc.CreateState(addr1.RawAddress, uint64(100))
 	require.Nil(err)
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -87,12 +92,50 @@ func TestActPool_validateTsf(t *testing.T) {
 	prevTsf, _ := signedTransfer(addr1, addr1, uint64(1), big.NewInt(50))
 	err = ap.AddTsf(prevTsf)
 	require.NoError(err)
-	err = bc.CommitStateChanges(0, []*action.Transfer{prevTsf}, nil)
+	err = bc.CommitStateChanges(0, []action.Action{prevTsf})
 	require.Nil(err)
 	ap.Reset()
 	nTsf, _ := signedTransfer(addr1, addr1, uint64(1), big.NewInt(60))
 	err = ap.validateTsf(nTsf)
 	require.Equal(ErrNonce, errors.Cause(err))
+	// Case VI: Gas limit is lower than the intrinsic gas
+	gasTsf, err := action.NewTransfer(uint64(2), big.NewInt(1), addr1.RawAddress, addr1.RawAddress)
+	require.NoError(err)
+	gasTsf.GasLimit = action.TransferIntrinsicGas - 1
+	err = ap.validateTsf(gasTsf)
+	require.Equal(action.ErrInsufficientGas, errors.Cause(err))
+	// Case VII: Gas price is lower than the minimum
+	ap.minGasPrice = big.NewInt(2)
+	gasTsf.GasLimit = action.TransferIntrinsicGas
+	gasTsf.GasPrice = big.NewInt(1)
+	err = ap.validateTsf(gasTsf)
+	require.Equal(ErrGasPrice, errors.Cause(err))
+	// Case VIII: Multisig transfer below the threshold
+	ap.minGasPrice = big.NewInt(0)
+	msTsf, err := action.NewMultisigTransfer(uint64(1), big.NewInt(1),
+		[]keypair.PublicKey{addr2.PublicKey, addr3.PublicKey, addr4.PublicKey}, 2, addr1.RawAddress)
+	require.NoError(err)
+	require.NoError(msTsf.SignMultisig(addr2))
+	err = ap.validateTsf(msTsf)
+	require.Equal(action.ErrTransferError, errors.Cause(err))
+	require.NoError(msTsf.SignMultisig(addr4))
+	_, err = bc.CreateState(msTsf.Sender, uint64(100))
+	require.NoError(err)
+	require.NoError(ap.validateTsf(msTsf))
+	// Case IX: Multisig and scheduled transfers are not enabled for the next block
+	schTsf, err := action.NewTransfer(uint64(2), big.NewInt(1), addr1.RawAddress, addr1.RawAddress)
+	require.NoError(err)
+	schTsf.NotBeforeHeight = 5
+	schTsf, err = schTsf.Sign(addr1)
+	require.NoError(err)
+	require.NoError(ap.validateTsf(schTsf))
+	bc.Genesis().Upgrades = version.Schedule{{
+		Height:   2,
+		Version:  2,
+		Features: []string{version.FeatureMultisig, version.FeatureScheduledTransfer},
+	}}
+	require.Equal(state.ErrInactiveFeature, errors.Cause(ap.validateTsf(msTsf)))
+	require.Equal(state.ErrInactiveFeature, errors.Cause(ap.validateTsf(schTsf)))
 }
 
 func TestActPool_validateVote(t *testing.T) {
@@ -104,7 +147,7 @@ func TestActPool_validateVote(t *testing.T) {
 	require.Nil(err)
 	_, err = bc.CreateState(addr2.RawAddress, uint64(100))
 	require.Nil(err)
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -125,7 +168,7 @@ func TestActPool_validateVote(t *testing.T) {
 	prevTsf, _ := signedTransfer(addr1, addr1, uint64(1), big.NewInt(50))
 	err = ap.AddTsf(prevTsf)
 	require.NoError(err)
-	err = bc.CommitStateChanges(0, []*action.Transfer{prevTsf}, nil)
+	err = bc.CommitStateChanges(0, []action.Action{prevTsf})
 	require.Nil(err)
 	ap.Reset()
 	nVote, _ := signedVote(addr1, addr1, uint64(1))
@@ -137,6 +180,43 @@ func TestActPool_validateVote(t *testing.T) {
 	require.Equal(ErrVotee, errors.Cause(err))
 }
 
+func TestActPool_validateStake(t *testing.T) {
+	require := require.New(t)
+	bc := blockchain.NewBlockchain(&config.Default, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
+	_, err := bc.CreateState(addr1.RawAddress, uint64(100))
+	require.NoError(err)
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
+	Ap, err := NewActPool(bc, apConfig)
+	require.NoError(err)
+	ap, ok := Ap.(*actPool)
+	require.True(ok)
+	// Case I: Gas limit is lower than the intrinsic gas
+	stake, err := action.NewStake(1, big.NewInt(50), addr1.RawAddress, 1, nil)
+	require.NoError(err)
+	require.NoError(action.Sign(stake, addr1))
+	require.Equal(action.ErrInsufficientGas, errors.Cause(ap.validateStake(stake)))
+	// Case II: Signature verification fails
+	stake, err = action.NewStake(1, big.NewInt(50), addr1.RawAddress, action.StakeIntrinsicGas, nil)
+	require.NoError(err)
+	require.Equal(action.ErrAction, errors.Cause(ap.validateStake(stake)))
+	require.NoError(action.Sign(stake, addr1))
+	require.NoError(ap.validateStake(stake))
+	// Case III: Unstake of more than the bonded stake
+	unstake, err := action.NewUnstake(2, big.NewInt(60), addr1.RawAddress, action.StakeIntrinsicGas, nil)
+	require.NoError(err)
+	require.NoError(action.Sign(unstake, addr1))
+	require.Equal(ErrBalance, errors.Cause(ap.validateStake(unstake)))
+	require.NoError(bc.CommitStateChanges(0, []action.Action{stake}))
+	unstake.Amount = big.NewInt(50)
+	require.NoError(action.Sign(unstake, addr1))
+	require.NoError(ap.validateStake(unstake))
+	// Case IV: Nonce is too low
+	require.Equal(ErrNonce, errors.Cause(ap.validateStake(stake)))
+	// Case V: Staking is not enabled for the next block
+	bc.Genesis().Upgrades = version.Schedule{{Height: 2, Version: 2, Features: []string{version.FeatureStake}}}
+	require.Equal(state.ErrInactiveFeature, errors.Cause(ap.validateStake(unstake)))
+}
+
 func TestActPool_AddActs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -149,7 +229,7 @@ func TestActPool_AddActs(t *testing.T) {
 	_, err = bc.CreateState(addr2.RawAddress, uint64(10))
 	require.Nil(err)
 	// Create actpool
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -250,7 +330,7 @@ func TestActPool_PickActs(t *testing.T) {
 	_, err = bc.CreateState(addr2.RawAddress, uint64(10))
 	require.Nil(err)
 	// Create actpool
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -288,9 +368,90 @@ func TestActPool_PickActs(t *testing.T) {
 	err = ap.AddTsf(tsf10)
 	require.NoError(err)
 
-	pickedTsfs, pickedVotes := ap.PickActs()
-	require.Equal([]*action.Transfer{tsf1, tsf2, tsf3, tsf4}, pickedTsfs)
-	require.Equal([]*action.Vote{vote7}, pickedVotes)
+	pickedActs := ap.PickActs()
+	require.ElementsMatch([]action.Action{tsf1, tsf2, tsf3, tsf4, vote7}, pickedActs)
+}
+
+func TestActPool_PickScheduledActs(t *testing.T) {
+	require := require.New(t)
+	bc := blockchain.NewBlockchain(&config.Default, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
+	_, err := bc.CreateState(addr1.RawAddress, uint64(100))
+	require.Nil(err)
+	_, err = bc.CreateState(addr2.RawAddress, uint64(100))
+	require.Nil(err)
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
+	ap, err := NewActPool(bc, apConfig)
+	require.Nil(err)
+	tipHeight, err := bc.TipHeight()
+	require.Nil(err)
+
+	// the actions after a scheduled transfer of the same account are held as well
+	tsf1, _ := signedTransfer(addr1, addr1, uint64(1), big.NewInt(10))
+	tsf2, err := action.NewTransfer(uint64(2), big.NewInt(10), addr1.RawAddress, addr1.RawAddress)
+	require.NoError(err)
+	tsf2.NotBeforeHeight = tipHeight + 2
+	tsf2, _ = tsf2.Sign(addr1)
+	tsf3, _ := signedTransfer(addr1, addr1, uint64(3), big.NewInt(10))
+	tsf4, err := action.NewTransfer(uint64(1), big.NewInt(10), addr2.RawAddress, addr2.RawAddress)
+	require.NoError(err)
+	tsf4.NotBeforeTimestamp = uint64(time.Now().Add(-time.Minute).Unix())
+	tsf4, _ = tsf4.Sign(addr2)
+	for _, tsf := range []*action.Transfer{tsf1, tsf2, tsf3, tsf4} {
+		require.NoError(ap.AddTsf(tsf))
+	}
+	require.ElementsMatch([]action.Action{tsf1, tsf4}, ap.PickActs())
+	nonce, err := ap.GetPendingNonce(addr1.RawAddress)
+	require.NoError(err)
+	require.Equal(uint64(4), nonce)
+
+	// eligible for the block after the next one
+	blk, err := bc.MintNewBlock([]action.Action{}, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.NoError(bc.CommitBlock(blk))
+	require.ElementsMatch([]action.Action{tsf1, tsf2, tsf3, tsf4}, ap.PickActs())
+}
+
+func TestActPool_PickActsByGasPrice(t *testing.T) {
+	require := require.New(t)
+	l := logger.Logger().Level(zerolog.DebugLevel)
+	logger.SetLogger(&l)
+	bc := blockchain.NewBlockchain(&config.Default, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
+	for _, addr := range []*iotxaddress.Address{addr1, addr2, addr3} {
+		_, err := bc.CreateState(addr.RawAddress, uint64(100))
+		require.Nil(err)
+	}
+	_, err := bc.CreateState(addr4.RawAddress, uint64(10))
+	require.Nil(err)
+	// Create actpool
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct, MinGasPrice: 1}
+	Ap, err := NewActPool(bc, apConfig)
+	require.Nil(err)
+	ap, ok := Ap.(*actPool)
+	require.True(ok)
+
+	tsf1, _ := signedTransferWithGasPrice(addr1, addr1, uint64(1), big.NewInt(1), big.NewInt(1))
+	tsf2, _ := signedTransferWithGasPrice(addr1, addr1, uint64(2), big.NewInt(1), big.NewInt(5))
+	tsf3, _ := signedTransferWithGasPrice(addr2, addr2, uint64(1), big.NewInt(1), big.NewInt(3))
+	vote4, _ := action.NewVote(uint64(1), addr3.RawAddress, addr3.RawAddress)
+	vote4.GasPrice = big.NewInt(4).Bytes()
+	vote4, _ = vote4.Sign(addr3)
+	tsf5, _ := signedTransferWithGasPrice(addr3, addr3, uint64(2), big.NewInt(1), big.NewInt(2))
+	require.NoError(ap.AddTsf(tsf1))
+	require.NoError(ap.AddTsf(tsf2))
+	require.NoError(ap.AddTsf(tsf3))
+	require.NoError(ap.AddVote(vote4))
+	require.NoError(ap.AddTsf(tsf5))
+
+	// Gas price is too low
+	tsf6, _ := signedTransferWithGasPrice(addr4, addr4, uint64(1), big.NewInt(1), big.NewInt(0))
+	require.Equal(ErrGasPrice, errors.Cause(ap.AddTsf(tsf6)))
+	// Balance cannot cover both the amount and the fee
+	tsf7, _ := signedTransferWithGasPrice(addr4, addr4, uint64(1), big.NewInt(5), big.NewInt(1))
+	require.Equal(ErrBalance, errors.Cause(ap.AddTsf(tsf7)))
+
+	// Higher gas prices come first, while the actions of an account stay in nonce order
+	pickedActs := ap.PickActs()
+	require.Equal([]action.Action{vote4, tsf3, tsf5, tsf1, tsf2}, pickedActs)
 }
 
 func TestActPool_removeConfirmedActs(t *testing.T) {
@@ -301,7 +462,7 @@ func TestActPool_removeConfirmedActs(t *testing.T) {
 	_, err := bc.CreateState(addr1.RawAddress, uint64(100))
 	require.Nil(err)
 	// Create actpool
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -323,7 +484,7 @@ func TestActPool_removeConfirmedActs(t *testing.T) {
 
 	require.Equal(4, len(ap.allActions))
 	require.NotNil(ap.accountActs[addr1.RawAddress])
-	err = bc.CommitStateChanges(0, []*action.Transfer{tsf1, tsf2, tsf3}, []*action.Vote{vote4})
+	err = bc.CommitStateChanges(0, []action.Action{tsf1, tsf2, tsf3, vote4})
 	require.Nil(err)
 	ap.removeConfirmedActs()
 	require.Equal(0, len(ap.allActions))
@@ -343,7 +504,7 @@ func TestActPool_Reset(t *testing.T) {
 	_, err = bc.CreateState(addr3.RawAddress, uint64(300))
 	require.Nil(err)
 
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap1, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap1, ok := Ap1.(*actPool)
@@ -441,9 +602,9 @@ func TestActPool_Reset(t *testing.T) {
 	ap2PBalance3, _ := ap2.getPendingBalance(addr3.RawAddress)
 	require.Equal(big.NewInt(50).Uint64(), ap2PBalance3.Uint64())
 	// Let ap1 be BP's actpool
-	pickedTsfs, pickedVotes := ap1.PickActs()
+	pickedActs := ap1.PickActs()
 	// ap1 commits update of accounts to trie
-	err = bc.CommitStateChanges(0, pickedTsfs, pickedVotes)
+	err = bc.CommitStateChanges(0, pickedActs)
 	require.Nil(err)
 	//Reset
 	ap1.Reset()
@@ -537,9 +698,9 @@ func TestActPool_Reset(t *testing.T) {
 	ap2PBalance3, _ = ap2.getPendingBalance(addr3.RawAddress)
 	require.Equal(big.NewInt(180).Uint64(), ap2PBalance3.Uint64())
 	// Let ap2 be BP's actpool
-	pickedTsfs, pickedVotes = ap2.PickActs()
+	pickedActs = ap2.PickActs()
 	// ap2 commits update of accounts to trie
-	err = bc.CommitStateChanges(0, pickedTsfs, pickedVotes)
+	err = bc.CommitStateChanges(0, pickedActs)
 	require.Nil(err)
 	//Reset
 	ap1.Reset()
@@ -617,9 +778,9 @@ func TestActPool_Reset(t *testing.T) {
 	ap1PBalance5, _ := ap1.getPendingBalance(addr5.RawAddress)
 	require.Equal(big.NewInt(10).Uint64(), ap1PBalance5.Uint64())
 	// Let ap1 be BP's actpool
-	pickedTsfs, pickedVotes = ap1.PickActs()
+	pickedActs = ap1.PickActs()
 	// ap1 commits update of accounts to trie
-	err = bc.CommitStateChanges(0, pickedTsfs, pickedVotes)
+	err = bc.CommitStateChanges(0, pickedActs)
 	require.Nil(err)
 	//Reset
 	ap1.Reset()
@@ -645,7 +806,7 @@ func TestActPool_removeInvalidActs(t *testing.T) {
 	_, err := bc.CreateState(addr1.RawAddress, uint64(100))
 	require.Nil(err)
 	// Create actpool
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -687,7 +848,7 @@ func TestActPool_GetPendingNonce(t *testing.T) {
 	_, err = bc.CreateState(addr2.RawAddress, uint64(100))
 	require.Nil(err)
 	// Create actpool
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -723,7 +884,7 @@ func TestActPool_GetUnconfirmedActs(t *testing.T) {
 	_, err = bc.CreateState(addr2.RawAddress, uint64(100))
 	require.Nil(err)
 	// Create actpool
-	apConfig := config.ActPool{maxNumActPerPool, maxNumActPerAcct}
+	apConfig := config.ActPool{MaxNumActPerPool: maxNumActPerPool, MaxNumActPerAcct: maxNumActPerAcct}
 	Ap, err := NewActPool(bc, apConfig)
 	require.Nil(err)
 	ap, ok := Ap.(*actPool)
@@ -785,3 +946,13 @@ func signedVote(voter *iotxaddress.Address, votee *iotxaddress.Address, nonce ui
 	}
 	return vote.Sign(voter)
 }
+
+// Helper function to return a signed transfer paying the given gas price
+func signedTransferWithGasPrice(sender *iotxaddress.Address, recipient *iotxaddress.Address, nonce uint64, amount *big.Int, gasPrice *big.Int) (*action.Transfer, error) {
+	transfer, err := action.NewTransfer(nonce, amount, sender.RawAddress, recipient.RawAddress)
+	if err != nil {
+		return nil, err
+	}
+	transfer.GasPrice = gasPrice
+	return transfer.Sign(sender)
+}
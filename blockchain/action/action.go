@@ -0,0 +1,180 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	cp "github.com/iotexproject/iotex-core/crypto"
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/pkg/version"
+	"github.com/iotexproject/iotex-core/proto"
+)
+
+var (
+	// ErrAction indicates error for an action
+	ErrAction = errors.New("action error")
+	// ErrActionType indicates the type of an action is unknown
+	ErrActionType = errors.New("unknown action type")
+)
+
+// Action is the interface of the actions that can be packed into a block
+type Action interface {
+	// Hash returns the hash of the action, which is signed by the sender
+	Hash() hash.Hash32B
+	// ByteStream returns the raw byte stream of the action, excluding the signature
+	ByteStream() []byte
+	// TotalSize returns the size of the action in bytes
+	TotalSize() uint32
+	// SrcAddr returns the address of the sender, which is empty if the action has no sender
+	SrcAddr() string
+	// SrcPubkey returns the public key of the sender
+	SrcPubkey() (keypair.PublicKey, error)
+	// SetSrcPubkey sets the public key of the sender
+	SetSrcPubkey(pubkey keypair.PublicKey)
+	// GetNonce returns the nonce of the action
+	GetNonce() uint64
+	// GetSignature returns the signature of the action
+	GetSignature() []byte
+	// SetSignature sets the signature of the action
+	SetSignature(signature []byte)
+	// GetGasLimit returns the gas limit of the action
+	GetGasLimit() uint64
+	// IntrinsicGas returns the least gas the action takes, which the gas limit must be able to cover
+	IntrinsicGas() uint64
+	// Fee returns the fee the sender pays to the block producer, which is the gas price times the gas limit
+	Fee() *big.Int
+	// Cost returns the balance the action consumes from the sender, including the fee
+	Cost() *big.Int
+	// Proto converts the action to protobuf's ActionPb
+	Proto() *iproto.ActionPb
+}
+
+var (
+	_ Action = (*Transfer)(nil)
+	_ Action = (*Vote)(nil)
+	_ Action = (*Execution)(nil)
+	_ Action = (*Stake)(nil)
+)
+
+// NewActionFromProto converts a protobuf's ActionPb to Action
+func NewActionFromProto(pbAct *iproto.ActionPb) (Action, error) {
+	switch {
+	case pbAct.GetTransfer() != nil:
+		tsf := &Transfer{}
+		tsf.ConvertFromTransferPb(pbAct.GetTransfer())
+		return tsf, nil
+	case pbAct.GetVote() != nil:
+		vote := &Vote{}
+		vote.ConvertFromVotePb(pbAct.GetVote())
+		return vote, nil
+	case pbAct.GetExecution() != nil:
+		ex := &Execution{}
+		ex.ConvertFromExecutionPb(pbAct.GetExecution())
+		return ex, nil
+	case pbAct.GetStake() != nil:
+		st := &Stake{}
+		st.ConvertFromStakePb(pbAct.GetStake())
+		return st, nil
+	}
+	return nil, ErrActionType
+}
+
+// IsEligible returns true if the action can be included in a block of the given height and unix timestamp, which is
+// false for a scheduled transfer before its time
+func IsEligible(act Action, height uint64, timestamp uint64) bool {
+	if tsf, ok := act.(*Transfer); ok {
+		return tsf.IsEligible(height, timestamp)
+	}
+	return true
+}
+
+// RequiredFeatures returns the protocol features the action requires, which are none if the action is part of the
+// original protocol
+func RequiredFeatures(act Action) []string {
+	switch act := act.(type) {
+	case *Execution:
+		return []string{version.FeatureExecution}
+	case *Stake:
+		return []string{version.FeatureStake}
+	case *Transfer:
+		var features []string
+		if act.IsMultisig() {
+			features = append(features, version.FeatureMultisig)
+		}
+		if act.IsScheduled() {
+			features = append(features, version.FeatureScheduledTransfer)
+		}
+		return features
+	}
+	return nil
+}
+
+// IntrinsicFee returns the fee of the intrinsic gas of the action, which is the fee of transfers, votes and stakes
+// before FeatureGasLimitFee
+func IntrinsicFee(act Action) *big.Int {
+	switch act := act.(type) {
+	case *Transfer:
+		return calculateFee(act.GasPrice, act.IntrinsicGas())
+	case *Vote:
+		return calculateFee(act.Price(), act.IntrinsicGas())
+	case *Stake:
+		return calculateFee(act.GasPrice, act.IntrinsicGas())
+	}
+	return act.Fee()
+}
+
+// Sign signs the action using sender's private key
+func Sign(act Action, sender *iotxaddress.Address) error {
+	// check the sender is correct
+	if act.SrcAddr() != sender.RawAddress {
+		return errors.Wrapf(ErrAction, "signing addr %s does not match with action addr %s",
+			sender.RawAddress, act.SrcAddr())
+	}
+	// check the public key is actually owned by sender
+	pkhash := iotxaddress.GetPubkeyHash(sender.RawAddress)
+	if !bytes.Equal(pkhash, iotxaddress.HashPubKey(sender.PublicKey)) {
+		return errors.Wrapf(ErrAction, "signing addr %s does not own correct public key",
+			sender.RawAddress)
+	}
+	act.SetSrcPubkey(sender.PublicKey)
+	hash := act.Hash()
+	signature := cp.Sign(sender.PrivateKey, hash[:])
+	if signature == nil {
+		return errors.Wrapf(ErrAction, "Failed to sign action hash = %x", hash)
+	}
+	act.SetSignature(signature)
+	return nil
+}
+
+// Verify verifies the action using the public key of its sender, or the public keys of a multisig sender
+func Verify(act Action) error {
+	if tsf, ok := act.(*Transfer); ok && tsf.IsMultisig() {
+		return tsf.VerifyMultisig()
+	}
+	pubkey, err := act.SrcPubkey()
+	if err != nil {
+		return errors.Wrap(err, "invalid sender public key")
+	}
+	sender, err := iotxaddress.GetAddress(pubkey, iotxaddress.IsTestnet, iotxaddress.ChainID)
+	if err != nil {
+		return errors.Wrap(err, "invalid sender address")
+	}
+	if sender.RawAddress != act.SrcAddr() {
+		return errors.Wrapf(ErrAction, "public key does not belong to sender %s", act.SrcAddr())
+	}
+	hash := act.Hash()
+	if success := cp.Verify(sender.PublicKey, hash[:], act.GetSignature()); success {
+		return nil
+	}
+	return errors.Wrapf(ErrAction, "Failed to verify action signature = %x", act.GetSignature())
+}
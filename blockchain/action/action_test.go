@@ -0,0 +1,81 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/proto"
+)
+
+func TestActionSignVerify(t *testing.T) {
+	require := require.New(t)
+	sender, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+	recipient, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+
+	tsf, err := NewTransfer(1, big.NewInt(10), sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	vote, err := NewVote(2, sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	ex, err := NewExecution(3, big.NewInt(10), sender.RawAddress, recipient.RawAddress, []byte{0x01}, 1000, big.NewInt(1))
+	require.NoError(err)
+	stake, err := NewStake(4, big.NewInt(10), sender.RawAddress, 100, big.NewInt(1))
+	require.NoError(err)
+	for _, act := range []Action{tsf, vote, ex, stake} {
+		require.Equal(ErrAction, errors.Cause(Sign(act, recipient)))
+		require.NoError(Sign(act, sender))
+		require.NoError(Verify(act))
+
+		// the signature does not match with the action any more
+		act.SetSignature(recipient.PublicKey[:])
+		require.Equal(ErrAction, errors.Cause(Verify(act)))
+		// the public key does not belong to the sender
+		require.NoError(Sign(act, sender))
+		act.SetSrcPubkey(recipient.PublicKey)
+		require.Equal(ErrAction, errors.Cause(Verify(act)))
+	}
+}
+
+func TestNewActionFromProto(t *testing.T) {
+	require := require.New(t)
+	sender, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+	recipient, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+
+	tsf, err := NewTransfer(1, big.NewInt(10), sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	require.NoError(Sign(tsf, sender))
+	vote, err := NewVote(2, sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	require.NoError(Sign(vote, sender))
+	ex, err := NewExecution(3, big.NewInt(10), sender.RawAddress, "", []byte{0x01}, 1000, big.NewInt(1))
+	require.NoError(err)
+	require.NoError(Sign(ex, sender))
+	unstake, err := NewUnstake(4, big.NewInt(10), sender.RawAddress, 100, big.NewInt(1))
+	require.NoError(err)
+	require.NoError(Sign(unstake, sender))
+
+	for _, act := range []Action{tsf, vote, ex, unstake} {
+		newAct, err := NewActionFromProto(act.Proto())
+		require.NoError(err)
+		require.Equal(act.Hash(), newAct.Hash())
+		require.Equal(act.SrcAddr(), newAct.SrcAddr())
+		require.Equal(act.GetNonce(), newAct.GetNonce())
+		require.Equal(act.Cost(), newAct.Cost())
+		require.NoError(Verify(newAct))
+	}
+	_, err = NewActionFromProto(&iproto.ActionPb{})
+	require.Equal(ErrActionType, err)
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/pkg/enc"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/pkg/version"
+	"github.com/iotexproject/iotex-core/proto"
+)
+
+// ErrExecutionError indicates error for an execution action
+var ErrExecutionError = errors.New("execution error")
+
+const (
+	// ExecutionIntrinsicGas is the gas charged for an execution without data
+	ExecutionIntrinsicGas = uint64(100)
+	// ExecutionDataGas is the gas charged for each byte of the execution data
+	ExecutionDataGas = uint64(1)
+)
+
+type (
+	// Execution defines the struct of account-based contract execution, which deploys the bytecode in Data to a new
+	// contract if Contract is empty, or invokes the existing Contract with Data as the input otherwise
+	Execution struct {
+		Version uint32
+
+		Nonce             uint64
+		Amount            *big.Int
+		Executor          string
+		Contract          string
+		Data              []byte
+		GasLimit          uint64
+		GasPrice          *big.Int
+		ExecutorPublicKey keypair.PublicKey
+		Signature         []byte
+	}
+)
+
+// NewExecution returns an Execution instance, an empty contract address deploys the data as a new contract
+func NewExecution(nonce uint64, amount *big.Int, executor string, contract string, data []byte, gasLimit uint64,
+	gasPrice *big.Int) (*Execution, error) {
+	if len(executor) == 0 {
+		return nil, errors.Wrap(ErrAddr, "address of executor is empty")
+	}
+	if gasPrice == nil {
+		gasPrice = big.NewInt(0)
+	}
+
+	return &Execution{
+		Version: version.ProtocolVersion,
+
+		Nonce:    nonce,
+		Amount:   amount,
+		Executor: executor,
+		Contract: contract,
+		Data:     data,
+		// ExecutorPublicKey and Signature will be populated in Sign()
+		GasLimit: gasLimit,
+		GasPrice: gasPrice,
+	}, nil
+}
+
+// IsDeployment returns true if the execution deploys a new contract
+func (ex *Execution) IsDeployment() bool {
+	return ex.Contract == ""
+}
+
+// SrcAddr returns the address of the executor
+func (ex *Execution) SrcAddr() string {
+	return ex.Executor
+}
+
+// SrcPubkey returns the public key of the executor
+func (ex *Execution) SrcPubkey() (keypair.PublicKey, error) {
+	return ex.ExecutorPublicKey, nil
+}
+
+// SetSrcPubkey sets the public key of the executor
+func (ex *Execution) SetSrcPubkey(pubkey keypair.PublicKey) {
+	ex.ExecutorPublicKey = pubkey
+}
+
+// GetNonce returns the nonce of the execution
+func (ex *Execution) GetNonce() uint64 {
+	return ex.Nonce
+}
+
+// GetSignature returns the signature of the execution
+func (ex *Execution) GetSignature() []byte {
+	return ex.Signature
+}
+
+// SetSignature sets the signature of the execution
+func (ex *Execution) SetSignature(signature []byte) {
+	ex.Signature = signature
+}
+
+// GetGasLimit returns the gas limit of the execution
+func (ex *Execution) GetGasLimit() uint64 {
+	return ex.GasLimit
+}
+
+// IntrinsicGas returns the gas charged before running the contract, which the gas limit must be able to cover
+func (ex *Execution) IntrinsicGas() uint64 {
+	return ExecutionIntrinsicGas + uint64(len(ex.Data))*ExecutionDataGas
+}
+
+// Fee returns the fee the executor pays to the block producer for the execution. Since the gas used by the contract
+// is only known after running it, the whole gas limit is charged
+func (ex *Execution) Fee() *big.Int {
+	return calculateFee(ex.GasPrice, ex.GasLimit)
+}
+
+// Cost returns the amount plus the fee of the execution
+func (ex *Execution) Cost() *big.Int {
+	if ex.Amount == nil {
+		return ex.Fee()
+	}
+	return new(big.Int).Add(ex.Amount, ex.Fee())
+}
+
+// TotalSize returns the total size of this Execution
+func (ex *Execution) TotalSize() uint32 {
+	size := versionSizeInBytes
+	size += NonceSizeInBytes
+	if ex.Amount != nil && len(ex.Amount.Bytes()) > 0 {
+		size += len(ex.Amount.Bytes())
+	}
+	size += len(ex.Executor)
+	size += len(ex.Contract)
+	size += len(ex.Data)
+	size += len(ex.ExecutorPublicKey)
+	size += len(ex.Signature)
+	size += GasLimitSizeInBytes
+	if ex.GasPrice != nil && len(ex.GasPrice.Bytes()) > 0 {
+		size += len(ex.GasPrice.Bytes())
+	}
+	return uint32(size)
+}
+
+// ByteStream returns a raw byte stream of this Execution
+func (ex *Execution) ByteStream() []byte {
+	stream := make([]byte, 4)
+	enc.MachineEndian.PutUint32(stream, ex.Version)
+	temp := make([]byte, 8)
+	enc.MachineEndian.PutUint64(temp, ex.Nonce)
+	stream = append(stream, temp...)
+	if ex.Amount != nil && len(ex.Amount.Bytes()) > 0 {
+		stream = append(stream, ex.Amount.Bytes()...)
+	}
+	stream = append(stream, ex.Executor...)
+	stream = append(stream, ex.Contract...)
+	stream = append(stream, ex.Data...)
+	stream = append(stream, ex.ExecutorPublicKey[:]...)
+	// Signature = Sign(hash(ByteStream())), so not included
+	temp = make([]byte, 8)
+	enc.MachineEndian.PutUint64(temp, ex.GasLimit)
+	stream = append(stream, temp...)
+	if ex.GasPrice != nil {
+		stream = append(stream, ex.GasPrice.Bytes()...)
+	}
+	return stream
+}
+
+// ConvertToExecutionPb converts Execution to protobuf's ExecutionPb
+func (ex *Execution) ConvertToExecutionPb() *iproto.ExecutionPb {
+	e := &iproto.ExecutionPb{
+		Version:        ex.Version,
+		Nonce:          ex.Nonce,
+		Executor:       ex.Executor,
+		Contract:       ex.Contract,
+		Data:           ex.Data,
+		ExecutorPubKey: ex.ExecutorPublicKey[:],
+		Signature:      ex.Signature,
+		GasLimit:       ex.GasLimit,
+	}
+
+	if ex.Amount != nil && len(ex.Amount.Bytes()) > 0 {
+		e.Amount = ex.Amount.Bytes()
+	}
+	if ex.GasPrice != nil && len(ex.GasPrice.Bytes()) > 0 {
+		e.GasPrice = ex.GasPrice.Bytes()
+	}
+	return e
+}
+
+// Proto converts Execution to protobuf's ActionPb
+func (ex *Execution) Proto() *iproto.ActionPb {
+	return &iproto.ActionPb{Action: &iproto.ActionPb_Execution{Execution: ex.ConvertToExecutionPb()}}
+}
+
+// Serialize returns a serialized byte stream for the Execution
+func (ex *Execution) Serialize() ([]byte, error) {
+	return proto.Marshal(ex.ConvertToExecutionPb())
+}
+
+// ConvertFromExecutionPb converts a protobuf's ExecutionPb to Execution
+func (ex *Execution) ConvertFromExecutionPb(pbEx *iproto.ExecutionPb) {
+	ex.Version = pbEx.GetVersion()
+	ex.Nonce = pbEx.GetNonce()
+	ex.Amount = big.NewInt(0)
+	if len(pbEx.GetAmount()) > 0 {
+		ex.Amount.SetBytes(pbEx.GetAmount())
+	}
+	ex.Executor = pbEx.GetExecutor()
+	ex.Contract = pbEx.GetContract()
+	ex.Data = pbEx.GetData()
+	copy(ex.ExecutorPublicKey[:], pbEx.GetExecutorPubKey())
+	ex.Signature = pbEx.GetSignature()
+	ex.GasLimit = pbEx.GetGasLimit()
+	ex.GasPrice = big.NewInt(0)
+	if len(pbEx.GetGasPrice()) > 0 {
+		ex.GasPrice.SetBytes(pbEx.GetGasPrice())
+	}
+}
+
+// Deserialize parse the byte stream into Execution
+func (ex *Execution) Deserialize(buf []byte) error {
+	pbExecution := &iproto.ExecutionPb{}
+	if err := proto.Unmarshal(buf, pbExecution); err != nil {
+		return err
+	}
+	ex.ConvertFromExecutionPb(pbExecution)
+	return nil
+}
+
+// Hash returns the hash of the Execution
+func (ex *Execution) Hash() hash.Hash32B {
+	hash := blake2b.Sum256(ex.ByteStream())
+	return blake2b.Sum256(hash[:])
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/iotxaddress"
+)
+
+func TestExecutionSerializeDeserialize(t *testing.T) {
+	require := require.New(t)
+	executor, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+	contract, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+
+	ex, err := NewExecution(1, big.NewInt(10), executor.RawAddress, contract.RawAddress, []byte{0x01, 0x02}, 1000,
+		big.NewInt(3))
+	require.NoError(err)
+	require.False(ex.IsDeployment())
+	require.NoError(Sign(ex, executor))
+
+	s, err := ex.Serialize()
+	require.Nil(err)
+	newEx := &Execution{}
+	require.Nil(newEx.Deserialize(s))
+	require.Equal(ex.Hash(), newEx.Hash())
+	require.Equal(uint64(1), newEx.Nonce)
+	require.Equal(big.NewInt(10), newEx.Amount)
+	require.Equal(executor.RawAddress, newEx.Executor)
+	require.Equal(contract.RawAddress, newEx.Contract)
+	require.Equal([]byte{0x01, 0x02}, newEx.Data)
+	require.Equal(uint64(1000), newEx.GasLimit)
+	require.Equal(big.NewInt(3), newEx.GasPrice)
+	require.Equal(ex.Signature, newEx.Signature)
+	require.NoError(Verify(newEx))
+
+	// the whole gas limit is charged
+	require.Equal(ExecutionIntrinsicGas+2*ExecutionDataGas, ex.IntrinsicGas())
+	require.Equal(big.NewInt(3000), ex.Fee())
+	require.Equal(big.NewInt(3010), ex.Cost())
+
+	_, err = NewExecution(1, big.NewInt(10), "", contract.RawAddress, nil, 1000, big.NewInt(3))
+	require.Error(err)
+	deploy, err := NewExecution(1, big.NewInt(10), executor.RawAddress, "", []byte{0x01}, 1000, nil)
+	require.NoError(err)
+	require.True(deploy.IsDeployment())
+	require.Equal(0, deploy.Fee().Sign())
+}
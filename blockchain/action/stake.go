@@ -0,0 +1,242 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/pkg/enc"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/pkg/version"
+	"github.com/iotexproject/iotex-core/proto"
+)
+
+// StakeIntrinsicGas is the gas charged for a stake or unstake
+const StakeIntrinsicGas = uint64(10)
+
+type (
+	// Stake defines the struct of bonding an amount of the balance of the staker, which is locked from spending and
+	// counted as voting weight. With Unstake set, the amount is unbonded instead, and released to the balance after the
+	// unbonding period
+	Stake struct {
+		Version uint32
+
+		Nonce           uint64
+		Amount          *big.Int
+		Staker          string
+		Unstake         bool
+		GasLimit        uint64
+		GasPrice        *big.Int
+		StakerPublicKey keypair.PublicKey
+		Signature       []byte
+	}
+)
+
+// NewStake returns a Stake instance bonding the amount
+func NewStake(nonce uint64, amount *big.Int, staker string, gasLimit uint64, gasPrice *big.Int) (*Stake, error) {
+	return newStake(nonce, amount, staker, false, gasLimit, gasPrice)
+}
+
+// NewUnstake returns a Stake instance unbonding the amount
+func NewUnstake(nonce uint64, amount *big.Int, staker string, gasLimit uint64, gasPrice *big.Int) (*Stake, error) {
+	return newStake(nonce, amount, staker, true, gasLimit, gasPrice)
+}
+
+func newStake(nonce uint64, amount *big.Int, staker string, unstake bool, gasLimit uint64,
+	gasPrice *big.Int) (*Stake, error) {
+	if len(staker) == 0 {
+		return nil, errors.Wrap(ErrAddr, "address of staker is empty")
+	}
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	if gasPrice == nil {
+		gasPrice = big.NewInt(0)
+	}
+
+	return &Stake{
+		Version: version.ProtocolVersion,
+
+		Nonce:   nonce,
+		Amount:  amount,
+		Staker:  staker,
+		Unstake: unstake,
+		// StakerPublicKey and Signature will be populated in Sign()
+		GasLimit: gasLimit,
+		GasPrice: gasPrice,
+	}, nil
+}
+
+// SrcAddr returns the address of the staker
+func (st *Stake) SrcAddr() string {
+	return st.Staker
+}
+
+// SrcPubkey returns the public key of the staker
+func (st *Stake) SrcPubkey() (keypair.PublicKey, error) {
+	return st.StakerPublicKey, nil
+}
+
+// SetSrcPubkey sets the public key of the staker
+func (st *Stake) SetSrcPubkey(pubkey keypair.PublicKey) {
+	st.StakerPublicKey = pubkey
+}
+
+// GetNonce returns the nonce of the stake
+func (st *Stake) GetNonce() uint64 {
+	return st.Nonce
+}
+
+// GetSignature returns the signature of the stake
+func (st *Stake) GetSignature() []byte {
+	return st.Signature
+}
+
+// SetSignature sets the signature of the stake
+func (st *Stake) SetSignature(signature []byte) {
+	st.Signature = signature
+}
+
+// GetGasLimit returns the gas limit of the stake
+func (st *Stake) GetGasLimit() uint64 {
+	return st.GasLimit
+}
+
+// IntrinsicGas returns the gas the stake takes, which the gas limit must be able to cover
+func (st *Stake) IntrinsicGas() uint64 {
+	return StakeIntrinsicGas
+}
+
+// Fee returns the fee the staker pays to the block producer for the stake, which is charged against the gas limit
+func (st *Stake) Fee() *big.Int {
+	return calculateFee(st.GasPrice, st.GasLimit)
+}
+
+// Cost returns the balance the stake consumes from the staker, which is the bonded amount plus the fee, or only the fee
+// for an unstake since the unbonded amount does not come from the balance
+func (st *Stake) Cost() *big.Int {
+	if st.Unstake || st.Amount == nil {
+		return st.Fee()
+	}
+	return new(big.Int).Add(st.Amount, st.Fee())
+}
+
+// TotalSize returns the total size of this Stake
+func (st *Stake) TotalSize() uint32 {
+	size := versionSizeInBytes
+	size += NonceSizeInBytes
+	if st.Amount != nil && len(st.Amount.Bytes()) > 0 {
+		size += len(st.Amount.Bytes())
+	}
+	size += len(st.Staker)
+	size++ // unstake flag
+	size += len(st.StakerPublicKey)
+	size += len(st.Signature)
+	size += GasLimitSizeInBytes
+	if st.GasPrice != nil && len(st.GasPrice.Bytes()) > 0 {
+		size += len(st.GasPrice.Bytes())
+	}
+	return uint32(size)
+}
+
+// ByteStream returns a raw byte stream of this Stake
+func (st *Stake) ByteStream() []byte {
+	stream := make([]byte, 4)
+	enc.MachineEndian.PutUint32(stream, st.Version)
+	temp := make([]byte, 8)
+	enc.MachineEndian.PutUint64(temp, st.Nonce)
+	stream = append(stream, temp...)
+	if st.Amount != nil && len(st.Amount.Bytes()) > 0 {
+		stream = append(stream, st.Amount.Bytes()...)
+	}
+	stream = append(stream, st.Staker...)
+	if st.Unstake {
+		stream = append(stream, 1)
+	} else {
+		stream = append(stream, 0)
+	}
+	stream = append(stream, st.StakerPublicKey[:]...)
+	// Signature = Sign(hash(ByteStream())), so not included
+	temp = make([]byte, 8)
+	enc.MachineEndian.PutUint64(temp, st.GasLimit)
+	stream = append(stream, temp...)
+	if st.GasPrice != nil {
+		stream = append(stream, st.GasPrice.Bytes()...)
+	}
+	return stream
+}
+
+// ConvertToStakePb converts Stake to protobuf's StakePb
+func (st *Stake) ConvertToStakePb() *iproto.StakePb {
+	s := &iproto.StakePb{
+		Version:      st.Version,
+		Nonce:        st.Nonce,
+		Staker:       st.Staker,
+		StakerPubKey: st.StakerPublicKey[:],
+		Unstake:      st.Unstake,
+		Signature:    st.Signature,
+		GasLimit:     st.GasLimit,
+	}
+
+	if st.Amount != nil && len(st.Amount.Bytes()) > 0 {
+		s.Amount = st.Amount.Bytes()
+	}
+	if st.GasPrice != nil && len(st.GasPrice.Bytes()) > 0 {
+		s.GasPrice = st.GasPrice.Bytes()
+	}
+	return s
+}
+
+// Proto converts Stake to protobuf's ActionPb
+func (st *Stake) Proto() *iproto.ActionPb {
+	return &iproto.ActionPb{Action: &iproto.ActionPb_Stake{Stake: st.ConvertToStakePb()}}
+}
+
+// Serialize returns a serialized byte stream for the Stake
+func (st *Stake) Serialize() ([]byte, error) {
+	return proto.Marshal(st.ConvertToStakePb())
+}
+
+// ConvertFromStakePb converts a protobuf's StakePb to Stake
+func (st *Stake) ConvertFromStakePb(pbStake *iproto.StakePb) {
+	st.Version = pbStake.GetVersion()
+	st.Nonce = pbStake.GetNonce()
+	st.Amount = big.NewInt(0)
+	if len(pbStake.GetAmount()) > 0 {
+		st.Amount.SetBytes(pbStake.GetAmount())
+	}
+	st.Staker = pbStake.GetStaker()
+	st.Unstake = pbStake.GetUnstake()
+	copy(st.StakerPublicKey[:], pbStake.GetStakerPubKey())
+	st.Signature = pbStake.GetSignature()
+	st.GasLimit = pbStake.GetGasLimit()
+	st.GasPrice = big.NewInt(0)
+	if len(pbStake.GetGasPrice()) > 0 {
+		st.GasPrice.SetBytes(pbStake.GetGasPrice())
+	}
+}
+
+// Deserialize parse the byte stream into Stake
+func (st *Stake) Deserialize(buf []byte) error {
+	pbStake := &iproto.StakePb{}
+	if err := proto.Unmarshal(buf, pbStake); err != nil {
+		return err
+	}
+	st.ConvertFromStakePb(pbStake)
+	return nil
+}
+
+// Hash returns the hash of the Stake
+func (st *Stake) Hash() hash.Hash32B {
+	hash := blake2b.Sum256(st.ByteStream())
+	return blake2b.Sum256(hash[:])
+}
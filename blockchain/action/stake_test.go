@@ -0,0 +1,54 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package action
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/iotxaddress"
+)
+
+func TestStakeSerializeDeserialize(t *testing.T) {
+	require := require.New(t)
+	staker, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+
+	stake, err := NewStake(1, big.NewInt(10), staker.RawAddress, 100, big.NewInt(2))
+	require.NoError(err)
+	require.NoError(Sign(stake, staker))
+
+	s, err := stake.Serialize()
+	require.Nil(err)
+	newStake := &Stake{}
+	require.Nil(newStake.Deserialize(s))
+	require.Equal(stake.Hash(), newStake.Hash())
+	require.Equal(uint64(1), newStake.Nonce)
+	require.Equal(big.NewInt(10), newStake.Amount)
+	require.Equal(staker.RawAddress, newStake.Staker)
+	require.False(newStake.Unstake)
+	require.Equal(big.NewInt(2), newStake.GasPrice)
+	require.NoError(Verify(newStake))
+	// the bonded amount comes from the balance
+	require.Equal(big.NewInt(200), stake.Fee())
+	require.Equal(big.NewInt(210), stake.Cost())
+
+	unstake, err := NewUnstake(1, big.NewInt(10), staker.RawAddress, 100, big.NewInt(2))
+	require.NoError(err)
+	require.NotEqual(stake.Hash(), unstake.Hash())
+	newUnstake := &Stake{}
+	newUnstake.ConvertFromStakePb(unstake.ConvertToStakePb())
+	require.True(newUnstake.Unstake)
+	require.Equal(unstake.Hash(), newUnstake.Hash())
+	// the unbonded amount does not come from the balance
+	require.Equal(big.NewInt(200), unstake.Cost())
+
+	_, err = NewStake(1, big.NewInt(10), "", 100, nil)
+	require.Error(err)
+}
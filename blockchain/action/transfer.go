@@ -31,10 +31,30 @@ var (
 	ErrTransferError = errors.New("transfer error")
 	// ErrAddr indicates error of address
 	ErrAddr = errors.New("address error")
+	// ErrInsufficientGas indicates the gas limit of an action is lower than the intrinsic gas
+	ErrInsufficientGas = errors.New("insufficient gas")
+	// ErrGasPrice indicates error of gas price
+	ErrGasPrice = errors.New("gas price error")
 )
 
-// versionSizeInBytes defines the size of version in byte units
-const versionSizeInBytes = 4
+const (
+	// versionSizeInBytes defines the size of version in byte units
+	versionSizeInBytes = 4
+	// GasLimitSizeInBytes defines the size of gas limit in byte units
+	GasLimitSizeInBytes = 8
+	// TransferIntrinsicGas is the gas charged for a transfer without payload
+	TransferIntrinsicGas = uint64(10)
+	// TransferPayloadGas is the gas charged for each byte of the transfer payload
+	TransferPayloadGas = uint64(1)
+	// TransferMultisigGas is the gas charged for each public key of the multisig sender
+	TransferMultisigGas = uint64(5)
+
+	// the flags in the byte stream of a transfer, the multisig and scheduled flags tell the optional sections which
+	// follow the length-prefixed gas price
+	transferCoinbaseFlag  = byte(1)
+	transferMultisigFlag  = byte(1 << 1)
+	transferScheduledFlag = byte(1 << 2)
+)
 
 type (
 	// Transfer defines the struct of account-based transfer
@@ -46,10 +66,23 @@ type (
 		Sender          string
 		Recipient       string
 		Payload         []byte
+		GasLimit        uint64
+		GasPrice        *big.Int
 		SenderPublicKey keypair.PublicKey
 		Signature       []byte
 		IsCoinbase      bool
 		// Coinbase transfer is not expected to be received from the network but can only be generated by block producer
+
+		// A multisig sender is controlled by the public keys, and the transfer needs the signatures of at least
+		// threshold of them, where MultisigSignatures[i] is the signature of MultisigPubKeys[i] or empty
+		MultisigPubKeys    []keypair.PublicKey
+		MultisigThreshold  uint32
+		MultisigSignatures [][]byte
+
+		// A scheduled transfer cannot be included in a block below NotBeforeHeight or before NotBeforeTimestamp, zero
+		// means no such restriction
+		NotBeforeHeight    uint64
+		NotBeforeTimestamp uint64
 	}
 )
 
@@ -70,9 +103,29 @@ func NewTransfer(nonce uint64, amount *big.Int, sender string, recipient string)
 		Payload:    []byte{},
 		IsCoinbase: false,
 		// SenderPublicKey and Signature will be populated in Sign()
+		GasLimit: TransferIntrinsicGas,
+		GasPrice: big.NewInt(0),
 	}, nil
 }
 
+// NewMultisigTransfer returns a Transfer from the multisig address controlled by the public keys and the threshold
+func NewMultisigTransfer(nonce uint64, amount *big.Int, pubKeys []keypair.PublicKey, threshold uint32,
+	recipient string) (*Transfer, error) {
+	sender, err := iotxaddress.GetMultisigAddress(pubKeys, threshold, iotxaddress.IsTestnet, iotxaddress.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	tsf, err := NewTransfer(nonce, amount, sender, recipient)
+	if err != nil {
+		return nil, err
+	}
+	tsf.MultisigPubKeys = pubKeys
+	tsf.MultisigThreshold = threshold
+	tsf.MultisigSignatures = make([][]byte, len(pubKeys))
+	tsf.GasLimit = tsf.IntrinsicGas()
+	return tsf, nil
+}
+
 // NewCoinBaseTransfer returns a coinbase Transfer
 func NewCoinBaseTransfer(amount *big.Int, recipient string) *Transfer {
 	return &Transfer{
@@ -83,7 +136,80 @@ func NewCoinBaseTransfer(amount *big.Int, recipient string) *Transfer {
 		Payload:    []byte{},
 		IsCoinbase: true,
 		// SenderPublicKey and Signature will be populated in Sign()
+		GasPrice: big.NewInt(0),
+	}
+}
+
+// SrcAddr returns the address of the sender, which is empty for the coinbase transfer
+func (tsf *Transfer) SrcAddr() string {
+	return tsf.Sender
+}
+
+// SrcPubkey returns the public key of the sender
+func (tsf *Transfer) SrcPubkey() (keypair.PublicKey, error) {
+	return tsf.SenderPublicKey, nil
+}
+
+// SetSrcPubkey sets the public key of the sender
+func (tsf *Transfer) SetSrcPubkey(pubkey keypair.PublicKey) {
+	tsf.SenderPublicKey = pubkey
+}
+
+// GetNonce returns the nonce of the transfer
+func (tsf *Transfer) GetNonce() uint64 {
+	return tsf.Nonce
+}
+
+// GetSignature returns the signature of the transfer
+func (tsf *Transfer) GetSignature() []byte {
+	return tsf.Signature
+}
+
+// SetSignature sets the signature of the transfer
+func (tsf *Transfer) SetSignature(signature []byte) {
+	tsf.Signature = signature
+}
+
+// GetGasLimit returns the gas limit of the transfer
+func (tsf *Transfer) GetGasLimit() uint64 {
+	return tsf.GasLimit
+}
+
+// IntrinsicGas returns the gas charged for the transfer, which the gas limit must be able to cover
+func (tsf *Transfer) IntrinsicGas() uint64 {
+	if tsf.IsCoinbase {
+		return 0
+	}
+	return TransferIntrinsicGas + uint64(len(tsf.Payload))*TransferPayloadGas +
+		uint64(len(tsf.MultisigPubKeys))*TransferMultisigGas
+}
+
+// IsEligible returns true if the transfer can be included in a block of the given height and unix timestamp
+func (tsf *Transfer) IsEligible(height uint64, timestamp uint64) bool {
+	return height >= tsf.NotBeforeHeight && timestamp >= tsf.NotBeforeTimestamp
+}
+
+// IsScheduled returns true if the transfer is held until a height or a timestamp
+func (tsf *Transfer) IsScheduled() bool {
+	return tsf.NotBeforeHeight > 0 || tsf.NotBeforeTimestamp > 0
+}
+
+// IsMultisig returns true if the transfer is sent from a multisig address
+func (tsf *Transfer) IsMultisig() bool {
+	return len(tsf.MultisigPubKeys) > 0
+}
+
+// Fee returns the fee the sender pays to the block producer for the transfer, which is charged against the gas limit
+func (tsf *Transfer) Fee() *big.Int {
+	return calculateFee(tsf.GasPrice, tsf.GasLimit)
+}
+
+// Cost returns the amount plus the fee of the transfer
+func (tsf *Transfer) Cost() *big.Int {
+	if tsf.Amount == nil {
+		return tsf.Fee()
 	}
+	return new(big.Int).Add(tsf.Amount, tsf.Fee())
 }
 
 // TotalSize returns the total size of this Transfer
@@ -100,6 +226,22 @@ func (tsf *Transfer) TotalSize() uint32 {
 	size += len(tsf.Payload)
 	size += len(tsf.SenderPublicKey)
 	size += len(tsf.Signature)
+	size += GasLimitSizeInBytes
+	if tsf.GasPrice != nil && len(tsf.GasPrice.Bytes()) > 0 {
+		size += len(tsf.GasPrice.Bytes())
+	}
+	if tsf.IsScheduled() {
+		size += 2 * TimestampSizeInBytes
+	}
+	if tsf.IsMultisig() {
+		size += 4
+		for i, pubKey := range tsf.MultisigPubKeys {
+			size += len(pubKey)
+			if i < len(tsf.MultisigSignatures) {
+				size += len(tsf.MultisigSignatures[i])
+			}
+		}
+	}
 	return uint32(size)
 }
 
@@ -118,10 +260,60 @@ func (tsf *Transfer) ByteStream() []byte {
 	stream = append(stream, tsf.Payload...)
 	stream = append(stream, tsf.SenderPublicKey[:]...)
 	// Signature = Sign(hash(ByteStream())), so not included
+	var flags byte
 	if tsf.IsCoinbase {
-		stream = append(stream, 1)
-	} else {
-		stream = append(stream, 0)
+		flags |= transferCoinbaseFlag
+	}
+	if tsf.IsMultisig() {
+		flags |= transferMultisigFlag
+	}
+	if tsf.IsScheduled() {
+		flags |= transferScheduledFlag
+	}
+	stream = append(stream, flags)
+	if flags&^transferCoinbaseFlag == 0 {
+		// gas is only appended if set, so that the gas-free genesis transfers keep their signatures
+		if tsf.GasLimit > 0 || (tsf.GasPrice != nil && len(tsf.GasPrice.Bytes()) > 0) {
+			temp = make([]byte, 8)
+			enc.MachineEndian.PutUint64(temp, tsf.GasLimit)
+			stream = append(stream, temp...)
+			if tsf.GasPrice != nil {
+				stream = append(stream, tsf.GasPrice.Bytes()...)
+			}
+		}
+		return stream
+	}
+	// the gas price is length-prefixed, so that it cannot run into the optional sections
+	temp = make([]byte, 8)
+	enc.MachineEndian.PutUint64(temp, tsf.GasLimit)
+	stream = append(stream, temp...)
+	var gasPrice []byte
+	if tsf.GasPrice != nil {
+		gasPrice = tsf.GasPrice.Bytes()
+	}
+	temp = make([]byte, 4)
+	enc.MachineEndian.PutUint32(temp, uint32(len(gasPrice)))
+	stream = append(stream, temp...)
+	stream = append(stream, gasPrice...)
+	// the signatures of a multisig transfer are not included either
+	if tsf.IsMultisig() {
+		temp = make([]byte, 4)
+		enc.MachineEndian.PutUint32(temp, tsf.MultisigThreshold)
+		stream = append(stream, temp...)
+		temp = make([]byte, 4)
+		enc.MachineEndian.PutUint32(temp, uint32(len(tsf.MultisigPubKeys)))
+		stream = append(stream, temp...)
+		for _, pubKey := range tsf.MultisigPubKeys {
+			stream = append(stream, pubKey[:]...)
+		}
+	}
+	if tsf.IsScheduled() {
+		temp = make([]byte, 8)
+		enc.MachineEndian.PutUint64(temp, tsf.NotBeforeHeight)
+		stream = append(stream, temp...)
+		temp = make([]byte, 8)
+		enc.MachineEndian.PutUint64(temp, tsf.NotBeforeTimestamp)
+		stream = append(stream, temp...)
 	}
 	return stream
 }
@@ -138,14 +330,32 @@ func (tsf *Transfer) ConvertToTransferPb() *iproto.TransferPb {
 		SenderPubKey: tsf.SenderPublicKey[:],
 		Signature:    tsf.Signature,
 		IsCoinbase:   tsf.IsCoinbase,
+		GasLimit:     tsf.GasLimit,
 	}
 
 	if tsf.Amount != nil && len(tsf.Amount.Bytes()) > 0 {
 		t.Amount = tsf.Amount.Bytes()
 	}
+	if tsf.GasPrice != nil && len(tsf.GasPrice.Bytes()) > 0 {
+		t.GasPrice = tsf.GasPrice.Bytes()
+	}
+	if tsf.IsMultisig() {
+		for _, pubKey := range tsf.MultisigPubKeys {
+			t.MultisigPubKeys = append(t.MultisigPubKeys, pubKey[:])
+		}
+		t.MultisigThreshold = tsf.MultisigThreshold
+		t.MultisigSignatures = tsf.MultisigSignatures
+	}
+	t.NotBeforeHeight = tsf.NotBeforeHeight
+	t.NotBeforeTimestamp = tsf.NotBeforeTimestamp
 	return t
 }
 
+// Proto converts Transfer to protobuf's ActionPb
+func (tsf *Transfer) Proto() *iproto.ActionPb {
+	return &iproto.ActionPb{Action: &iproto.ActionPb_Transfer{Transfer: tsf.ConvertToTransferPb()}}
+}
+
 // ToJSON converts Transfer to TransferJSON
 func (tsf *Transfer) ToJSON() *explorer.Transfer {
 	// used by account-based model
@@ -158,11 +368,16 @@ func (tsf *Transfer) ToJSON() *explorer.Transfer {
 		SenderPubKey: keypair.EncodePublicKey(tsf.SenderPublicKey),
 		Signature:    hex.EncodeToString(tsf.Signature),
 		IsCoinbase:   tsf.IsCoinbase,
+		Fee:          tsf.Fee().Int64(),
+		GasLimit:     int64(tsf.GasLimit),
 	}
 
 	if tsf.Amount != nil && len(tsf.Amount.Bytes()) > 0 {
 		t.Amount = tsf.Amount.Int64()
 	}
+	if tsf.GasPrice != nil && len(tsf.GasPrice.Bytes()) > 0 {
+		t.GasPrice = tsf.GasPrice.Int64()
+	}
 	return t
 }
 
@@ -197,6 +412,21 @@ func (tsf *Transfer) ConvertFromTransferPb(pbTx *iproto.TransferPb) {
 	tsf.Signature = nil
 	tsf.Signature = pbTx.Signature
 	tsf.IsCoinbase = pbTx.IsCoinbase
+	tsf.GasLimit = pbTx.GasLimit
+	tsf.GasPrice = big.NewInt(0)
+	if len(pbTx.GasPrice) > 0 {
+		tsf.GasPrice.SetBytes(pbTx.GasPrice)
+	}
+	tsf.MultisigPubKeys = nil
+	for _, pubKey := range pbTx.GetMultisigPubKeys() {
+		var pk keypair.PublicKey
+		copy(pk[:], pubKey)
+		tsf.MultisigPubKeys = append(tsf.MultisigPubKeys, pk)
+	}
+	tsf.MultisigThreshold = pbTx.GetMultisigThreshold()
+	tsf.MultisigSignatures = pbTx.GetMultisigSignatures()
+	tsf.NotBeforeHeight = pbTx.GetNotBeforeHeight()
+	tsf.NotBeforeTimestamp = pbTx.GetNotBeforeTimestamp()
 }
 
 // NewTransferFromJSON creates a new Transfer from TransferJSON
@@ -227,6 +457,11 @@ func NewTransferFromJSON(jsonTsf *explorer.Transfer) (*Transfer, error) {
 	}
 	tsf.Signature = signature
 	tsf.IsCoinbase = jsonTsf.IsCoinbase
+	tsf.GasLimit = uint64(jsonTsf.GasLimit)
+	if jsonTsf.GasPrice < 0 {
+		return nil, errors.Wrapf(ErrGasPrice, "negative gas price %d", jsonTsf.GasPrice)
+	}
+	tsf.GasPrice = big.NewInt(jsonTsf.GasPrice)
 
 	return tsf, nil
 }
@@ -276,6 +511,60 @@ func (tsf *Transfer) Verify(sender *iotxaddress.Address) error {
 	return errors.Wrapf(ErrTransferError, "Failed to verify Transfer signature = %x", tsf.Signature)
 }
 
+// SignMultisig adds the signature of one of the public keys controlling the multisig sender
+func (tsf *Transfer) SignMultisig(signer *iotxaddress.Address) error {
+	for i, pubKey := range tsf.MultisigPubKeys {
+		if pubKey != signer.PublicKey {
+			continue
+		}
+		hash := tsf.Hash()
+		signature := cp.Sign(signer.PrivateKey, hash[:])
+		if signature == nil {
+			return errors.Wrapf(ErrTransferError, "Failed to sign Transfer hash = %x", hash)
+		}
+		if len(tsf.MultisigSignatures) != len(tsf.MultisigPubKeys) {
+			tsf.MultisigSignatures = make([][]byte, len(tsf.MultisigPubKeys))
+		}
+		tsf.MultisigSignatures[i] = signature
+		return nil
+	}
+	return errors.Wrapf(ErrTransferError, "signing addr %s is not a multisig owner of %s", signer.RawAddress,
+		tsf.Sender)
+}
+
+// VerifyMultisig verifies that the public keys and the threshold derive the sender address, and that at least
+// threshold of the public keys have signed the Transfer
+func (tsf *Transfer) VerifyMultisig() error {
+	sender, err := iotxaddress.GetMultisigAddress(tsf.MultisigPubKeys, tsf.MultisigThreshold, iotxaddress.IsTestnet,
+		iotxaddress.ChainID)
+	if err != nil {
+		return errors.Wrap(err, "invalid multisig sender")
+	}
+	if sender != tsf.Sender {
+		return errors.Wrapf(ErrTransferError, "multisig public keys do not belong to sender %s", tsf.Sender)
+	}
+	if len(tsf.MultisigSignatures) != len(tsf.MultisigPubKeys) {
+		return errors.Wrapf(ErrTransferError, "expecting %d multisig signatures, got %d", len(tsf.MultisigPubKeys),
+			len(tsf.MultisigSignatures))
+	}
+	hash := tsf.Hash()
+	signed := uint32(0)
+	for i, signature := range tsf.MultisigSignatures {
+		if len(signature) == 0 {
+			continue
+		}
+		if !cp.Verify(tsf.MultisigPubKeys[i], hash[:], signature) {
+			return errors.Wrapf(ErrTransferError, "Failed to verify multisig signature = %x", signature)
+		}
+		signed++
+	}
+	if signed < tsf.MultisigThreshold {
+		return errors.Wrapf(ErrTransferError, "%d of %d required multisig signatures", signed,
+			tsf.MultisigThreshold)
+	}
+	return nil
+}
+
 //======================================
 // private functions
 //======================================
@@ -287,3 +576,12 @@ func (tsf *Transfer) sign(sender *iotxaddress.Address) error {
 	}
 	return errors.Wrapf(ErrTransferError, "Failed to sign Transfer hash = %x", hash)
 }
+
+// calculateFee returns gasPrice * gas, a nil gas price is treated as zero
+func calculateFee(gasPrice *big.Int, gas uint64) *big.Int {
+	fee := big.NewInt(0)
+	if gasPrice == nil {
+		return fee
+	}
+	return fee.Mul(gasPrice, new(big.Int).SetUint64(gas))
+}
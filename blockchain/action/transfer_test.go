@@ -10,9 +10,13 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/pkg/enc"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/pkg/version"
 )
 
 var chainid = []byte{0x00, 0x00, 0x00, 0x01}
@@ -71,3 +75,138 @@ func TestTransferSerializeDeserialize(t *testing.T) {
 	require.Equal(tsf.Hash(), newtsf.Hash())
 	require.Equal(tsf.TotalSize(), newtsf.TotalSize())
 }
+
+func TestTransferGas(t *testing.T) {
+	require := require.New(t)
+	sender, err := iotxaddress.NewAddress(true, chainid)
+	require.Nil(err)
+	recipient, err := iotxaddress.NewAddress(true, chainid)
+	require.Nil(err)
+
+	tsf, err := NewTransfer(0, big.NewInt(10), sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	require.Equal(TransferIntrinsicGas, tsf.GasLimit)
+	require.Equal(uint64(0), tsf.Fee().Uint64())
+
+	tsf.Payload = []byte("payload")
+	tsf.GasLimit = 100
+	tsf.GasPrice = big.NewInt(3)
+	require.Equal(TransferIntrinsicGas+7*TransferPayloadGas, tsf.IntrinsicGas())
+	// the fee is charged against the gas limit, not the gas used
+	require.Equal(uint64(300), tsf.Fee().Uint64())
+	require.Equal(3*tsf.IntrinsicGas(), IntrinsicFee(tsf).Uint64())
+
+	// gas is covered by the signature
+	stsf, err := tsf.Sign(sender)
+	require.NoError(err)
+	hash := stsf.Hash()
+	stsf.GasPrice = big.NewInt(4)
+	require.NotEqual(hash, stsf.Hash())
+	require.NotNil(stsf.Verify(sender))
+	stsf.GasPrice = big.NewInt(3)
+	require.Nil(stsf.Verify(sender))
+
+	s, err := stsf.Serialize()
+	require.NoError(err)
+	newtsf := &Transfer{}
+	require.NoError(newtsf.Deserialize(s))
+	require.Equal(uint64(100), newtsf.GasLimit)
+	require.Equal(uint64(3), newtsf.GasPrice.Uint64())
+	require.Equal(stsf.Hash(), newtsf.Hash())
+	require.Equal(stsf.TotalSize(), newtsf.TotalSize())
+
+	// coinbase transfer is free
+	cb := NewCoinBaseTransfer(big.NewInt(5), recipient.RawAddress)
+	require.Equal(uint64(0), cb.IntrinsicGas())
+	require.Equal(uint64(0), cb.Fee().Uint64())
+}
+
+func TestMultisigTransfer(t *testing.T) {
+	require := require.New(t)
+	var owners []*iotxaddress.Address
+	var pubKeys []keypair.PublicKey
+	for i := 0; i < 3; i++ {
+		owner, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+		require.Nil(err)
+		owners = append(owners, owner)
+		pubKeys = append(pubKeys, owner.PublicKey)
+	}
+	recipient, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+
+	_, err = NewMultisigTransfer(1, big.NewInt(10), pubKeys, 4, recipient.RawAddress)
+	require.Equal(iotxaddress.ErrInvalidMultisig, err)
+	tsf, err := NewMultisigTransfer(1, big.NewInt(10), pubKeys, 2, recipient.RawAddress)
+	require.NoError(err)
+	require.True(tsf.IsMultisig())
+	require.Equal([]string{version.FeatureMultisig}, RequiredFeatures(tsf))
+	require.Equal(TransferIntrinsicGas+3*TransferMultisigGas, tsf.GasLimit)
+	sender, err := iotxaddress.GetMultisigAddress(pubKeys, 2, iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.NoError(err)
+	require.Equal(sender, tsf.Sender)
+
+	// signatures of non-owners are rejected, and the threshold has to be reached
+	require.Error(tsf.SignMultisig(recipient))
+	require.NoError(tsf.SignMultisig(owners[2]))
+	require.Equal(ErrTransferError, errors.Cause(Verify(tsf)))
+	require.NoError(tsf.SignMultisig(owners[0]))
+	require.NoError(Verify(tsf))
+
+	// the signatures survive the round trip, and still match the transfer
+	newTsf := &Transfer{}
+	newTsf.ConvertFromTransferPb(tsf.ConvertToTransferPb())
+	require.Equal(tsf.Hash(), newTsf.Hash())
+	require.Equal(tsf.TotalSize(), newTsf.TotalSize())
+	require.NoError(newTsf.VerifyMultisig())
+
+	// changing the threshold changes the sender, and a forged signature is rejected
+	newTsf.MultisigThreshold = 1
+	require.Equal(ErrTransferError, errors.Cause(newTsf.VerifyMultisig()))
+	newTsf.MultisigThreshold = 2
+	newTsf.MultisigSignatures[1] = newTsf.MultisigSignatures[0]
+	require.Equal(ErrTransferError, errors.Cause(newTsf.VerifyMultisig()))
+}
+
+func TestScheduledTransfer(t *testing.T) {
+	require := require.New(t)
+	sender, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+	recipient, err := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	require.Nil(err)
+
+	tsf, err := NewTransfer(1, big.NewInt(10), sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	require.True(tsf.IsEligible(0, 0))
+	require.Empty(RequiredFeatures(tsf))
+	hash := tsf.Hash()
+	size := tsf.TotalSize()
+
+	tsf.NotBeforeHeight = 10
+	tsf.NotBeforeTimestamp = 1000
+	require.NotEqual(hash, tsf.Hash())
+	require.Equal(size+16, tsf.TotalSize())
+	require.False(tsf.IsEligible(9, 1000))
+	require.False(tsf.IsEligible(10, 999))
+	require.True(tsf.IsEligible(10, 1000))
+	require.False(IsEligible(tsf, 9, 1000))
+	require.Equal([]string{version.FeatureScheduledTransfer}, RequiredFeatures(tsf))
+
+	require.NoError(Sign(tsf, sender))
+	newTsf := &Transfer{}
+	newTsf.ConvertFromTransferPb(tsf.ConvertToTransferPb())
+	require.Equal(uint64(10), newTsf.NotBeforeHeight)
+	require.Equal(uint64(1000), newTsf.NotBeforeTimestamp)
+	require.NoError(Verify(newTsf))
+
+	// the schedule is tagged and follows the length-prefixed gas price, so a transfer carrying the schedule in its gas
+	// price does not sign the same bytes
+	tsf.GasPrice = big.NewInt(1)
+	forged, err := NewTransfer(1, big.NewInt(10), sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	forged.SenderPublicKey = tsf.SenderPublicKey
+	schedule := make([]byte, 16)
+	enc.MachineEndian.PutUint64(schedule, tsf.NotBeforeHeight)
+	enc.MachineEndian.PutUint64(schedule[8:], tsf.NotBeforeTimestamp)
+	forged.GasPrice = new(big.Int).SetBytes(append(tsf.GasPrice.Bytes(), schedule...))
+	require.NotEqual(tsf.Hash(), forged.Hash())
+}
@@ -9,6 +9,7 @@ package action
 import (
 	"bytes"
 	"encoding/hex"
+	"math/big"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
@@ -37,6 +38,8 @@ const (
 	TimestampSizeInBytes = 8
 	// BooleanSizeInBytes defines the size of booleans
 	BooleanSizeInBytes = 1
+	// VoteIntrinsicGas is the gas charged for a vote
+	VoteIntrinsicGas = uint64(10)
 )
 
 // Vote defines the struct of account-based vote
@@ -55,6 +58,7 @@ func NewVote(nonce uint64, voterAddress string, voteeAddress string) (*Vote, err
 		Nonce:        nonce,
 		VoterAddress: voterAddress,
 		VoteeAddress: voteeAddress,
+		GasLimit:     VoteIntrinsicGas,
 	}
 	return &Vote{pbVote}, nil
 }
@@ -64,6 +68,46 @@ func (v *Vote) SelfPublicKey() (keypair.PublicKey, error) {
 	return keypair.BytesToPublicKey(v.SelfPubkey)
 }
 
+// SrcAddr returns the address of the voter
+func (v *Vote) SrcAddr() string {
+	return v.VoterAddress
+}
+
+// SrcPubkey returns the public key of the voter
+func (v *Vote) SrcPubkey() (keypair.PublicKey, error) {
+	return v.SelfPublicKey()
+}
+
+// SetSrcPubkey sets the public key of the voter
+func (v *Vote) SetSrcPubkey(pubkey keypair.PublicKey) {
+	v.SelfPubkey = pubkey[:]
+}
+
+// SetSignature sets the signature of the vote
+func (v *Vote) SetSignature(signature []byte) {
+	v.Signature = signature
+}
+
+// Price returns the gas price of the vote
+func (v *Vote) Price() *big.Int {
+	return big.NewInt(0).SetBytes(v.GasPrice)
+}
+
+// IntrinsicGas returns the gas the vote takes, which the gas limit must be able to cover
+func (v *Vote) IntrinsicGas() uint64 {
+	return VoteIntrinsicGas
+}
+
+// Fee returns the fee the voter pays to the block producer for the vote, which is charged against the gas limit
+func (v *Vote) Fee() *big.Int {
+	return calculateFee(v.Price(), v.GasLimit)
+}
+
+// Cost returns the fee of the vote, which is all the vote consumes from the voter's balance
+func (v *Vote) Cost() *big.Int {
+	return v.Fee()
+}
+
 // TotalSize returns the total size of this Vote
 func (v *Vote) TotalSize() uint32 {
 	size := TimestampSizeInBytes
@@ -73,6 +117,8 @@ func (v *Vote) TotalSize() uint32 {
 	size += len(v.VoterAddress)
 	size += len(v.VoteeAddress)
 	size += len(v.Signature)
+	size += GasLimitSizeInBytes
+	size += len(v.GasPrice)
 	return uint32(size)
 }
 
@@ -89,6 +135,13 @@ func (v *Vote) ByteStream() []byte {
 	temp = make([]byte, 4)
 	enc.MachineEndian.PutUint32(temp, v.Version)
 	stream = append(stream, temp...)
+	// gas is only appended if set, so that the gas-free genesis votes keep their signatures
+	if v.GasLimit > 0 || len(v.GasPrice) > 0 {
+		temp = make([]byte, 8)
+		enc.MachineEndian.PutUint64(temp, v.GasLimit)
+		stream = append(stream, temp...)
+		stream = append(stream, v.GasPrice...)
+	}
 	// Signature = Sign(hash(ByteStream())), so not included
 	return stream
 }
@@ -98,6 +151,11 @@ func (v *Vote) ConvertToVotePb() *iproto.VotePb {
 	return v.VotePb
 }
 
+// Proto converts Vote to protobuf's ActionPb
+func (v *Vote) Proto() *iproto.ActionPb {
+	return &iproto.ActionPb{Action: &iproto.ActionPb_Vote{Vote: v.ConvertToVotePb()}}
+}
+
 // ToJSON converts Vote to VoteJSON
 func (v *Vote) ToJSON() (*explorer.Vote, error) {
 	// used by account-based model
@@ -112,6 +170,8 @@ func (v *Vote) ToJSON() (*explorer.Vote, error) {
 		Voter:       v.VoterAddress,
 		Votee:       v.VoteeAddress,
 		Signature:   hex.EncodeToString(v.Signature),
+		GasLimit:    int64(v.GasLimit),
+		GasPrice:    v.Price().Int64(),
 	}
 	return vote, nil
 }
@@ -128,7 +188,7 @@ func (v *Vote) ConvertFromVotePb(pbVote *iproto.VotePb) {
 
 // NewVoteFromJSON creates a new Vote from VoteJSON
 func NewVoteFromJSON(jsonVote *explorer.Vote) (*Vote, error) {
-	v := &Vote{}
+	v := &Vote{&iproto.VotePb{}}
 	v.Version = uint32(jsonVote.Version)
 	// used by account-based model
 	v.Nonce = uint64(jsonVote.Nonce)
@@ -146,6 +206,11 @@ func NewVoteFromJSON(jsonVote *explorer.Vote) (*Vote, error) {
 		return nil, err
 	}
 	v.Signature = signature
+	v.GasLimit = uint64(jsonVote.GasLimit)
+	if jsonVote.GasPrice < 0 {
+		return nil, errors.Wrapf(ErrGasPrice, "negative gas price %d", jsonVote.GasPrice)
+	}
+	v.GasPrice = big.NewInt(jsonVote.GasPrice).Bytes()
 
 	return v, nil
 }
@@ -7,6 +7,7 @@
 package action
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -46,3 +47,37 @@ func TestVoteSerializedDeserialize(t *testing.T) {
 	require.Equal(v.Hash(), newv.Hash())
 	require.Equal(v.TotalSize(), newv.TotalSize())
 }
+
+func TestVoteGas(t *testing.T) {
+	require := require.New(t)
+	sender, err := iotxaddress.NewAddress(true, chainid)
+	require.Nil(err)
+	recipient, err := iotxaddress.NewAddress(true, chainid)
+	require.Nil(err)
+
+	v, err := NewVote(0, sender.RawAddress, recipient.RawAddress)
+	require.NoError(err)
+	require.Equal(VoteIntrinsicGas, v.GasLimit)
+	require.Equal(uint64(0), v.Fee().Uint64())
+
+	v.GasPrice = big.NewInt(2).Bytes()
+	require.Equal(2*VoteIntrinsicGas, v.Fee().Uint64())
+	v.GasLimit = 2 * VoteIntrinsicGas
+	require.Equal(4*VoteIntrinsicGas, v.Fee().Uint64())
+	v.GasLimit = VoteIntrinsicGas
+	signedv, err := v.Sign(sender)
+	require.NoError(err)
+
+	jsonVote, err := signedv.ToJSON()
+	require.NoError(err)
+	require.Equal(int64(VoteIntrinsicGas), jsonVote.GasLimit)
+	require.Equal(int64(2), jsonVote.GasPrice)
+	newv, err := NewVoteFromJSON(jsonVote)
+	require.NoError(err)
+	require.Equal(signedv.Hash(), newv.Hash())
+	require.Nil(newv.Verify(sender))
+
+	jsonVote.GasPrice = -1
+	_, err = NewVoteFromJSON(jsonVote)
+	require.Error(err)
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/pkg/enc"
+)
+
+// An archive is a sequence of blocks of consecutive heights, each stored as a record of the 4-byte length of the
+// serialized BlockPb followed by the serialized BlockPb
+
+// maxArchiveRecordSize is the largest record accepted when reading an archive, to reject a corrupted length
+const maxArchiveRecordSize = 1 << 28
+
+var (
+	// ErrEmptyArchive indicates that the archive does not contain any complete block
+	ErrEmptyArchive = errors.New("the archive does not contain any block")
+	// ErrArchiveMismatch indicates that the archive does not belong to the local chain
+	ErrArchiveMismatch = errors.New("the archive does not match the local chain")
+)
+
+// ExportBlocks writes the blocks of heights [start, end] of the chain to the archive. The progress is called with the
+// height of each block written, if not nil
+func ExportBlocks(bc Blockchain, w io.Writer, start uint64, end uint64, progress func(uint64)) error {
+	if start > end {
+		return errors.Errorf("invalid height range [%d, %d]", start, end)
+	}
+	tipHeight, err := bc.TipHeight()
+	if err != nil {
+		return err
+	}
+	if end > tipHeight {
+		return errors.Errorf("end height %d is higher than tip height %d", end, tipHeight)
+	}
+	for height := start; height <= end; height++ {
+		blk, err := bc.GetBlockByHeight(height)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get block of height %d", height)
+		}
+		if err := writeArchiveRecord(w, blk); err != nil {
+			return errors.Wrapf(err, "failed to write block of height %d", height)
+		}
+		if progress != nil {
+			progress(height)
+		}
+	}
+	return nil
+}
+
+// ImportBlocks validates and commits the blocks of the archive to the chain, and returns the height of the last block
+// read. Blocks not higher than the tip of the chain are not committed again but checked against the local ones, so an
+// interrupted import resumes by importing the same archive again. The progress is called with the height of each
+// block committed, if not nil
+func ImportBlocks(bc Blockchain, r io.Reader, progress func(uint64)) (uint64, error) {
+	var height uint64
+	read := false
+	for {
+		blk, _, err := readArchiveRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return height, err
+		}
+		if read && blk.Height() != height+1 {
+			return height, errors.Errorf("block of height %d follows block of height %d", blk.Height(), height)
+		}
+		height = blk.Height()
+		read = true
+
+		tipHeight, err := bc.TipHeight()
+		if err != nil {
+			return height, err
+		}
+		if height <= tipHeight {
+			localHash, err := bc.GetHashByHeight(height)
+			if err != nil {
+				return height, errors.Wrapf(err, "failed to get hash of height %d", height)
+			}
+			if localHash != blk.HashBlock() {
+				return height, errors.Wrapf(ErrArchiveMismatch, "block of height %d", height)
+			}
+			continue
+		}
+		if err := bc.CommitBlock(blk); err != nil {
+			return height, errors.Wrapf(err, "failed to commit block of height %d", height)
+		}
+		if progress != nil {
+			progress(height)
+		}
+	}
+	if !read {
+		return 0, ErrEmptyArchive
+	}
+	return height, nil
+}
+
+// LastArchivedBlock returns the height of the last complete block of the archive and the offset right after it. An
+// interrupted export resumes by truncating the archive to the offset and exporting from the next height
+func LastArchivedBlock(r io.Reader) (uint64, int64, error) {
+	var height uint64
+	var offset int64
+	read := false
+	for {
+		blk, size, err := readArchiveRecord(r)
+		if err == io.EOF || errors.Cause(err) == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		height = blk.Height()
+		offset += size
+		read = true
+	}
+	if !read {
+		return 0, 0, ErrEmptyArchive
+	}
+	return height, offset, nil
+}
+
+// writeArchiveRecord writes a block as a record of the archive
+func writeArchiveRecord(w io.Writer, blk *Block) error {
+	data, err := blk.Serialize()
+	if err != nil {
+		return err
+	}
+	record := make([]byte, 4, 4+len(data))
+	enc.MachineEndian.PutUint32(record, uint32(len(data)))
+	_, err = w.Write(append(record, data...))
+	return err
+}
+
+// readArchiveRecord reads a block from a record of the archive, and returns the size of the record. It returns io.EOF
+// at the end of the archive, and io.ErrUnexpectedEOF if the last record is incomplete
+func readArchiveRecord(r io.Reader) (*Block, int64, error) {
+	prefix := make([]byte, 4)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		if err == io.EOF {
+			return nil, 0, err
+		}
+		return nil, 0, errors.Wrap(err, "failed to read record length")
+	}
+	size := enc.MachineEndian.Uint32(prefix)
+	if size > maxArchiveRecordSize {
+		return nil, 0, errors.Errorf("record length %d exceeds the limit", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, errors.Wrap(err, "failed to read record")
+	}
+	blk := &Block{}
+	if err := blk.Deserialize(data); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to deserialize block")
+	}
+	return blk, int64(len(prefix)) + int64(size), nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/state"
+	ta "github.com/iotexproject/iotex-core/test/testaddress"
+)
+
+func TestExportImportBlocks(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Disable block reward to make bookkeeping easier
+	defer func(reward uint64) { Gen.BlockReward = reward }(Gen.BlockReward)
+	Gen.BlockReward = uint64(0)
+
+	cfg := config.Default
+	src := NewBlockchain(&cfg, PrecreatedStateFactoryOption(newTestingFactory(t, &cfg, state.InMemTrieOption())),
+		InMemDaoOption())
+	require.NotNil(src)
+	defer func() {
+		require.NoError(src.Stop(ctx))
+	}()
+	require.NoError(addTestingTsfBlocks(src))
+
+	var exported []uint64
+	var archive bytes.Buffer
+	require.NoError(ExportBlocks(src, &archive, 0, 4, func(height uint64) {
+		exported = append(exported, height)
+	}))
+	require.Equal([]uint64{0, 1, 2, 3, 4}, exported)
+	require.Error(ExportBlocks(src, &bytes.Buffer{}, 3, 5, nil))
+	require.Error(ExportBlocks(src, &bytes.Buffer{}, 3, 2, nil))
+
+	height, offset, err := LastArchivedBlock(bytes.NewReader(archive.Bytes()))
+	require.NoError(err)
+	require.Equal(uint64(4), height)
+	require.Equal(int64(archive.Len()), offset)
+
+	// an interrupted import leaves the blocks committed so far, and importing the archive again resumes after them
+	dst := NewBlockchain(&cfg, PrecreatedStateFactoryOption(newTestingFactory(t, &cfg, state.InMemTrieOption())),
+		InMemDaoOption())
+	require.NotNil(dst)
+	defer func() {
+		require.NoError(dst.Stop(ctx))
+	}()
+	truncated := archive.Bytes()[:archive.Len()-10]
+	var imported []uint64
+	height, err = ImportBlocks(dst, bytes.NewReader(truncated), func(height uint64) {
+		imported = append(imported, height)
+	})
+	require.Equal(uint64(3), height)
+	require.Error(err)
+	require.Equal(uint64(3), imported[len(imported)-1])
+	tipHeight, err := dst.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(3), tipHeight)
+
+	imported = nil
+	height, err = ImportBlocks(dst, bytes.NewReader(archive.Bytes()), func(height uint64) {
+		imported = append(imported, height)
+	})
+	require.NoError(err)
+	require.Equal(uint64(4), height)
+	require.Equal([]uint64{4}, imported)
+	for h := uint64(0); h <= 4; h++ {
+		srcHash, err := src.GetHashByHeight(h)
+		require.NoError(err)
+		dstHash, err := dst.GetHashByHeight(h)
+		require.NoError(err)
+		require.Equal(srcHash, dstHash)
+	}
+	s, err := dst.StateByAddr(ta.Addrinfo["foxtrot"].RawAddress)
+	require.NoError(err)
+	expected, err := src.StateByAddr(ta.Addrinfo["foxtrot"].RawAddress)
+	require.NoError(err)
+	require.Equal(expected.Balance, s.Balance)
+
+	// an interrupted export resumes from the last complete block
+	height, offset, err = LastArchivedBlock(bytes.NewReader(truncated))
+	require.NoError(err)
+	require.Equal(uint64(3), height)
+	resumed := bytes.NewBuffer(append([]byte{}, truncated[:offset]...))
+	require.NoError(ExportBlocks(src, resumed, height+1, 4, nil))
+	require.Equal(archive.Bytes(), resumed.Bytes())
+
+	_, _, err = LastArchivedBlock(bytes.NewReader(nil))
+	require.Equal(ErrEmptyArchive, errors.Cause(err))
+	_, err = ImportBlocks(dst, bytes.NewReader(nil), nil)
+	require.Equal(ErrEmptyArchive, errors.Cause(err))
+}
+
+func TestImportBlocksMismatch(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Disable block reward to make bookkeeping easier
+	defer func(reward uint64) { Gen.BlockReward = reward }(Gen.BlockReward)
+	Gen.BlockReward = uint64(0)
+
+	cfg := config.Default
+	src := NewBlockchain(&cfg, PrecreatedStateFactoryOption(newTestingFactory(t, &cfg, state.InMemTrieOption())),
+		InMemDaoOption())
+	require.NotNil(src)
+	defer func() {
+		require.NoError(src.Stop(ctx))
+	}()
+	require.NoError(addTestingTsfBlocks(src))
+	var archive bytes.Buffer
+	require.NoError(ExportBlocks(src, &archive, 1, 2, nil))
+
+	// the local chain has a different block at height 1
+	dst := NewBlockchain(&cfg, PrecreatedStateFactoryOption(newTestingFactory(t, &cfg, state.InMemTrieOption())),
+		InMemDaoOption())
+	require.NotNil(dst)
+	defer func() {
+		require.NoError(dst.Stop(ctx))
+	}()
+	blk, err := dst.MintNewBlock(nil, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.NoError(dst.CommitBlock(blk))
+
+	_, err = ImportBlocks(dst, bytes.NewReader(archive.Bytes()), nil)
+	require.Equal(ErrArchiveMismatch, errors.Cause(err))
+}
@@ -51,16 +51,26 @@ func (bh *BlockHeader) Timestamp() time.Time {
 	return time.Unix(int64(bh.timestamp), 0)
 }
 
+// Version returns the protocol version in the block header
+func (bh *BlockHeader) Version() uint32 { return bh.version }
+
+// ChainID returns the chain ID in the block header
+func (bh *BlockHeader) ChainID() uint32 { return bh.chainID }
+
+// TxRoot returns the merkle root of the actions in the block header
+func (bh *BlockHeader) TxRoot() hash.Hash32B { return bh.txRoot }
+
+// StateRoot returns the merkle root of the states in the block header
+func (bh *BlockHeader) StateRoot() hash.Hash32B { return bh.stateRoot }
+
 // Block defines the struct of block
 type Block struct {
-	Header    *BlockHeader
-	Transfers []*action.Transfer
-	Votes     []*action.Vote
+	Header  *BlockHeader
+	Actions []action.Action
 }
 
 // NewBlock returns a new block
-func NewBlock(chainID uint32, height uint64, prevBlockHash hash.Hash32B,
-	tsf []*action.Transfer, vote []*action.Vote) *Block {
+func NewBlock(chainID uint32, height uint64, prevBlockHash hash.Hash32B, acts []action.Action) *Block {
 	block := &Block{
 		Header: &BlockHeader{
 			version:       version.ProtocolVersion,
@@ -71,14 +81,35 @@ func NewBlock(chainID uint32, height uint64, prevBlockHash hash.Hash32B,
 			txRoot:        hash.ZeroHash32B,
 			stateRoot:     hash.ZeroHash32B,
 		},
-		Transfers: tsf,
-		Votes:     vote,
+		Actions: acts,
 	}
 
 	block.Header.txRoot = block.TxRoot()
 	return block
 }
 
+// Transfers returns the transfers in the block
+func (b *Block) Transfers() []*action.Transfer {
+	transfers := []*action.Transfer{}
+	for _, act := range b.Actions {
+		if tsf, ok := act.(*action.Transfer); ok {
+			transfers = append(transfers, tsf)
+		}
+	}
+	return transfers
+}
+
+// Votes returns the votes in the block
+func (b *Block) Votes() []*action.Vote {
+	votes := []*action.Vote{}
+	for _, act := range b.Actions {
+		if vote, ok := act.(*action.Vote); ok {
+			votes = append(votes, vote)
+		}
+	}
+	return votes
+}
+
 // Height returns the height of this block
 func (b *Block) Height() uint64 {
 	return b.Header.height
@@ -115,11 +146,8 @@ func (b *Block) ByteStream() []byte {
 	// Add the stream of blockSig
 	stream = append(stream, b.Header.blockSig[:]...)
 
-	for _, t := range b.Transfers {
-		stream = append(stream, t.ByteStream()...)
-	}
-	for _, v := range b.Votes {
-		stream = append(stream, v.ByteStream()...)
+	for _, act := range b.Actions {
+		stream = append(stream, act.ByteStream()...)
 	}
 
 	return stream
@@ -143,16 +171,13 @@ func (b *Block) ConvertToBlockHeaderPb() *iproto.BlockHeaderPb {
 
 // ConvertToBlockPb converts Block to BlockPb
 func (b *Block) ConvertToBlockPb() *iproto.BlockPb {
-	if len(b.Transfers)+len(b.Votes) == 0 {
+	if len(b.Actions) == 0 {
 		return nil
 	}
 
 	actions := []*iproto.ActionPb{}
-	for _, tsf := range b.Transfers {
-		actions = append(actions, &iproto.ActionPb{Action: &iproto.ActionPb_Transfer{tsf.ConvertToTransferPb()}})
-	}
-	for _, vote := range b.Votes {
-		actions = append(actions, &iproto.ActionPb{Action: &iproto.ActionPb_Vote{vote.ConvertToVotePb()}})
+	for _, act := range b.Actions {
+		actions = append(actions, act.Proto())
 	}
 	return &iproto.BlockPb{Header: b.ConvertToBlockHeaderPb(), Actions: actions}
 }
@@ -181,21 +206,14 @@ func (b *Block) ConvertFromBlockHeaderPb(pbBlock *iproto.BlockPb) {
 func (b *Block) ConvertFromBlockPb(pbBlock *iproto.BlockPb) {
 	b.ConvertFromBlockHeaderPb(pbBlock)
 
-	b.Transfers = []*action.Transfer{}
-	b.Votes = []*action.Vote{}
-
-	for _, act := range pbBlock.Actions {
-		if tfPb := act.GetTransfer(); tfPb != nil {
-			tf := &action.Transfer{}
-			tf.ConvertFromTransferPb(tfPb)
-			b.Transfers = append(b.Transfers, tf)
-		} else if votePb := act.GetVote(); votePb != nil {
-			vote := &action.Vote{}
-			vote.ConvertFromVotePb(votePb)
-			b.Votes = append(b.Votes, vote)
-		} else {
-			logger.Fatal().Msg("unexpected action")
+	b.Actions = []action.Action{}
+
+	for _, actPb := range pbBlock.Actions {
+		act, err := action.NewActionFromProto(actPb)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("unexpected action")
 		}
+		b.Actions = append(b.Actions, act)
 	}
 }
 
@@ -219,11 +237,8 @@ func (b *Block) Deserialize(buf []byte) error {
 // TxRoot returns the Merkle root of all txs and actions in this block.
 func (b *Block) TxRoot() hash.Hash32B {
 	var h []hash.Hash32B
-	for _, t := range b.Transfers {
-		h = append(h, t.Hash())
-	}
-	for _, v := range b.Votes {
-		h = append(h, v.Hash())
+	for _, act := range b.Actions {
+		h = append(h, act.Hash())
 	}
 
 	if len(h) == 0 {
@@ -232,6 +247,23 @@ func (b *Block) TxRoot() hash.Hash32B {
 	return cp.NewMerkleTree(h).HashTree()
 }
 
+// ActionProof returns the merkle proof of the action of the hash in the tx root of this block
+func (b *Block) ActionProof(h hash.Hash32B) (*cp.MerkleProof, error) {
+	var leaves []hash.Hash32B
+	index := -1
+	for i, act := range b.Actions {
+		actHash := act.Hash()
+		if actHash == h && index < 0 {
+			index = i
+		}
+		leaves = append(leaves, actHash)
+	}
+	if index < 0 {
+		return nil, errors.New("action is not in the block")
+	}
+	return cp.NewMerkleTree(leaves).Proof(index)
+}
+
 // HashBlock return the hash of this block (actually hash of block header)
 func (b *Block) HashBlock() hash.Hash32B {
 	hash := blake2b.Sum256(b.ByteStreamHeader())
@@ -239,6 +271,12 @@ func (b *Block) HashBlock() hash.Hash32B {
 	return hash
 }
 
+// VerifySignature checks the block is signed by the producer of the public key in the header
+func (b *Block) VerifySignature() bool {
+	blkHash := b.HashBlock()
+	return cp.Verify(b.Header.Pubkey, blkHash[:], b.Header.blockSig)
+}
+
 // SignBlock allows signer to sign the block b
 func (b *Block) SignBlock(signer *iotxaddress.Address) error {
 	if signer.PrivateKey == keypair.ZeroPrivateKey {
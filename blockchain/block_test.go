@@ -12,6 +12,7 @@ import (
 	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -115,7 +116,7 @@ func TestMerkle(t *testing.T) {
 	t.Logf("hash07 = %x", hash07)
 
 	// create block using above 5 tx and verify merkle
-	block := NewBlock(0, 0, hash.ZeroHash32B, []*action.Transfer{cbtsf0, cbtsf1, cbtsf2, cbtsf3, cbtsf4}, nil)
+	block := NewBlock(0, 0, hash.ZeroHash32B, []action.Action{cbtsf0, cbtsf1, cbtsf2, cbtsf3, cbtsf4})
 	hash := block.TxRoot()
 	require.Equal(hash07[:], hash[:])
 
@@ -167,16 +168,16 @@ func TestConvertFromBlockPb(t *testing.T) {
 
 	require.Equal(t, uint64(123456789), newblk.Header.height)
 
-	require.Equal(t, uint64(101), newblk.Transfers[0].Nonce)
-	require.Equal(t, uint64(102), newblk.Transfers[1].Nonce)
+	require.Equal(t, uint64(101), newblk.Transfers()[0].Nonce)
+	require.Equal(t, uint64(102), newblk.Transfers()[1].Nonce)
 
-	require.Equal(t, uint64(103), newblk.Votes[0].Nonce)
-	require.Equal(t, uint64(104), newblk.Votes[1].Nonce)
+	require.Equal(t, uint64(103), newblk.Votes()[0].Nonce)
+	require.Equal(t, uint64(104), newblk.Votes()[1].Nonce)
 }
 
 func TestWrongRootHash(t *testing.T) {
 	require := require.New(t)
-	val := validator{nil}
+	val := validator{}
 	tsf1, err := action.NewTransfer(1, big.NewInt(20), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["alfa"].RawAddress)
 	require.NoError(err)
 	tsf1, err = tsf1.Sign(ta.Addrinfo["producer"])
@@ -186,18 +187,18 @@ func TestWrongRootHash(t *testing.T) {
 	tsf2, err = tsf2.Sign(ta.Addrinfo["producer"])
 	require.Nil(err)
 	hash := tsf1.Hash()
-	blk := NewBlock(1, 1, hash, []*action.Transfer{tsf1, tsf2}, nil)
+	blk := NewBlock(1, 1, hash, []action.Action{tsf1, tsf2})
 	blk.Header.Pubkey = ta.Addrinfo["producer"].PublicKey
 	blkHash := blk.HashBlock()
 	blk.Header.blockSig = cp.Sign(ta.Addrinfo["producer"].PrivateKey, blkHash[:])
 	require.Nil(val.Validate(blk, 0, hash))
-	blk.Transfers[0], blk.Transfers[1] = blk.Transfers[1], blk.Transfers[0]
+	blk.Actions[0], blk.Actions[1] = blk.Actions[1], blk.Actions[0]
 	require.NotNil(val.Validate(blk, 0, hash))
 }
 
 func TestSignBlock(t *testing.T) {
 	require := require.New(t)
-	val := validator{nil}
+	val := validator{}
 	tsf1, err := action.NewTransfer(1, big.NewInt(20), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["alfa"].RawAddress)
 	require.NoError(err)
 	tsf1, err = tsf1.Sign(ta.Addrinfo["producer"])
@@ -207,7 +208,7 @@ func TestSignBlock(t *testing.T) {
 	tsf2, err = tsf2.Sign(ta.Addrinfo["producer"])
 	require.Nil(err)
 	hash := tsf1.Hash()
-	blk := NewBlock(1, 3, hash, []*action.Transfer{tsf1, tsf2}, nil)
+	blk := NewBlock(1, 3, hash, []action.Action{tsf1, tsf2})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.Nil(err)
 	require.Nil(val.Validate(blk, 2, hash))
@@ -224,7 +225,7 @@ func TestWrongNonce(t *testing.T) {
 	require.NoError(err)
 	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
 	assert.NoError(t, err)
-	val := validator{sf}
+	val := validator{sf: sf}
 
 	// correct nonce
 	coinbaseTsf := action.NewCoinBaseTransfer(big.NewInt(int64(Gen.BlockReward)), ta.Addrinfo["producer"].RawAddress)
@@ -233,11 +234,13 @@ func TestWrongNonce(t *testing.T) {
 	tsf1, err = tsf1.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash := tsf1.Hash()
-	blk := NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf, tsf1}, nil)
+	blk := NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf1})
+	blk.Header.stateRoot, err = sf.RunActions(3, blk.Actions)
+	require.NoError(err)
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	require.NoError(val.Validate(blk, 2, hash))
-	err = sf.CommitStateChanges(1, []*action.Transfer{tsf1}, nil)
+	err = sf.CommitStateChanges(1, []action.Action{tsf1})
 	require.NoError(err)
 
 	// low nonce
@@ -246,7 +249,7 @@ func TestWrongNonce(t *testing.T) {
 	tsf2, err = tsf2.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash = tsf1.Hash()
-	blk = NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf, tsf1, tsf2}, nil)
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf1, tsf2})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -257,7 +260,7 @@ func TestWrongNonce(t *testing.T) {
 	vote, err = vote.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash = tsf1.Hash()
-	blk = NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf}, []*action.Vote{vote})
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, vote})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -273,7 +276,7 @@ func TestWrongNonce(t *testing.T) {
 	tsf4, err = tsf4.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash = tsf1.Hash()
-	blk = NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf, tsf3, tsf4}, nil)
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf3, tsf4})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -288,7 +291,7 @@ func TestWrongNonce(t *testing.T) {
 	vote3, err = vote3.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash = tsf1.Hash()
-	blk = NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf}, []*action.Vote{vote2, vote3})
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, vote2, vote3})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -304,7 +307,7 @@ func TestWrongNonce(t *testing.T) {
 	tsf6, err = tsf6.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash = tsf1.Hash()
-	blk = NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf, tsf5, tsf6}, nil)
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf5, tsf6})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -319,7 +322,7 @@ func TestWrongNonce(t *testing.T) {
 	vote5, err = vote5.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash = tsf1.Hash()
-	blk = NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf}, []*action.Vote{vote4, vote5})
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, vote4, vote5})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -338,7 +341,7 @@ func TestWrongCoinbaseTsf(t *testing.T) {
 	require.NoError(err)
 	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
 	assert.NoError(t, err)
-	val := validator{sf}
+	val := validator{sf: sf}
 
 	// no coinbase tsf
 	coinbaseTsf := action.NewCoinBaseTransfer(big.NewInt(int64(Gen.BlockReward)), ta.Addrinfo["producer"].RawAddress)
@@ -347,7 +350,7 @@ func TestWrongCoinbaseTsf(t *testing.T) {
 	tsf1, err = tsf1.Sign(ta.Addrinfo["producer"])
 	require.NoError(err)
 	hash := tsf1.Hash()
-	blk := NewBlock(1, 3, hash, []*action.Transfer{tsf1}, nil)
+	blk := NewBlock(1, 3, hash, []action.Action{tsf1})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -357,7 +360,7 @@ func TestWrongCoinbaseTsf(t *testing.T) {
 	)
 
 	// extra coinbase transfer
-	blk = NewBlock(1, 3, hash, []*action.Transfer{coinbaseTsf, coinbaseTsf, tsf1}, nil)
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, coinbaseTsf, tsf1})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -367,7 +370,7 @@ func TestWrongCoinbaseTsf(t *testing.T) {
 	)
 
 	// no transfer
-	blk = NewBlock(1, 3, hash, []*action.Transfer{}, nil)
+	blk = NewBlock(1, 3, hash, []action.Action{})
 	err = blk.SignBlock(ta.Addrinfo["producer"])
 	require.NoError(err)
 	err = val.Validate(blk, 2, hash)
@@ -376,3 +379,90 @@ func TestWrongCoinbaseTsf(t *testing.T) {
 		strings.Contains(err.Error(), "Wrong number of coinbase transfers"),
 	)
 }
+
+func TestWrongStateRoot(t *testing.T) {
+	cfg := &config.Default
+	testutil.CleanupPath(t, cfg.Chain.TrieDBPath)
+	defer testutil.CleanupPath(t, cfg.Chain.TrieDBPath)
+	require := require.New(t)
+	sf, err := state.NewFactory(cfg, state.DefaultTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+	require.NoError(err)
+	val := validator{sf: sf}
+
+	coinbaseTsf := action.NewCoinBaseTransfer(big.NewInt(int64(Gen.BlockReward)), ta.Addrinfo["producer"].RawAddress)
+	tsf1, err := action.NewTransfer(1, big.NewInt(20), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["alfa"].RawAddress)
+	require.NoError(err)
+	tsf1, err = tsf1.Sign(ta.Addrinfo["producer"])
+	require.NoError(err)
+	hash := tsf1.Hash()
+
+	// state root left empty
+	blk := NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf1})
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	err = val.Validate(blk, 2, hash)
+	require.Error(err)
+	require.Equal(ErrInvalidStateRoot, errors.Cause(err))
+
+	// state root before running the actions
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf1})
+	blk.Header.stateRoot = sf.RootHash()
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	err = val.Validate(blk, 2, hash)
+	require.Error(err)
+	require.Equal(ErrInvalidStateRoot, errors.Cause(err))
+
+	// correct state root, and validation does not touch the committed states
+	root := sf.RootHash()
+	blk = NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf1})
+	blk.Header.stateRoot, err = sf.RunActions(3, blk.Actions)
+	require.NoError(err)
+	require.NotEqual(root, blk.Header.stateRoot)
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	require.NoError(val.Validate(blk, 2, hash))
+	require.Equal(root, sf.RootHash())
+	require.NoError(sf.CommitStateChanges(3, blk.Actions))
+	require.Equal(blk.Header.stateRoot, sf.RootHash())
+}
+
+func TestPrematureTsf(t *testing.T) {
+	cfg := &config.Default
+	testutil.CleanupPath(t, cfg.Chain.TrieDBPath)
+	defer testutil.CleanupPath(t, cfg.Chain.TrieDBPath)
+	require := require.New(t)
+	sf, err := state.NewFactory(cfg, state.DefaultTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+	require.NoError(err)
+	val := validator{sf: sf}
+
+	coinbaseTsf := action.NewCoinBaseTransfer(big.NewInt(int64(Gen.BlockReward)), ta.Addrinfo["producer"].RawAddress)
+	tsf1, err := action.NewTransfer(1, big.NewInt(20), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["alfa"].RawAddress)
+	require.NoError(err)
+	tsf1.NotBeforeHeight = 4
+	tsf1, err = tsf1.Sign(ta.Addrinfo["producer"])
+	require.NoError(err)
+	hash := tsf1.Hash()
+
+	// scheduled after the block height
+	blk := NewBlock(1, 3, hash, []action.Action{coinbaseTsf, tsf1})
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	err = val.Validate(blk, 2, hash)
+	require.Equal(ErrPrematureAction, errors.Cause(err))
+	blk = NewBlock(1, 4, hash, []action.Action{coinbaseTsf, tsf1})
+	blk.Header.stateRoot, err = sf.RunActions(4, blk.Actions)
+	require.NoError(err)
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	require.NoError(val.Validate(blk, 3, hash))
+
+	// scheduled after the block timestamp
+	tsf1.NotBeforeHeight = 0
+	tsf1.NotBeforeTimestamp = uint64(time.Now().Add(time.Hour).Unix())
+	tsf1, err = tsf1.Sign(ta.Addrinfo["producer"])
+	require.NoError(err)
+	blk = NewBlock(1, 4, hash, []action.Action{coinbaseTsf, tsf1})
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	err = val.Validate(blk, 3, hash)
+	require.Equal(ErrPrematureAction, errors.Cause(err))
+}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -22,7 +23,7 @@ import (
 	"github.com/iotexproject/iotex-core/pkg/hash"
 	"github.com/iotexproject/iotex-core/pkg/keypair"
 	"github.com/iotexproject/iotex-core/pkg/lifecycle"
-	"github.com/iotexproject/iotex-core/pkg/version"
+	"github.com/iotexproject/iotex-core/pkg/routine"
 	"github.com/iotexproject/iotex-core/state"
 )
 
@@ -37,7 +38,7 @@ type Blockchain interface {
 	// CreateState adds a new State with initial balance to the factory
 	CreateState(addr string, init uint64) (*state.State, error)
 	// CommitStateChanges updates a State from the given actions
-	CommitStateChanges(chainHeight uint64, tsf []*action.Transfer, vote []*action.Vote) error
+	CommitStateChanges(chainHeight uint64, acts []action.Action) error
 	// Candidates returns the candidate list
 	Candidates() (uint64, []*state.Candidate)
 	// CandidatesByHeight returns the candidate list by a given height
@@ -71,17 +72,36 @@ type Blockchain interface {
 	GetVoteByVoteHash(h hash.Hash32B) (*action.Vote, error)
 	// GetBlockHashByVoteHash returns Block hash by vote hash
 	GetBlockHashByVoteHash(h hash.Hash32B) (hash.Hash32B, error)
+	// GetReceiptByActionHash returns the receipt of an action on the canonical chain by the action hash
+	GetReceiptByActionHash(h hash.Hash32B) (*state.Receipt, error)
+	// GetActionProofByActionHash returns the height of the block of the transfer or vote, and the merkle proof of it in
+	// the tx root of the block
+	GetActionProofByActionHash(h hash.Hash32B) (uint64, *cp.MerkleProof, error)
+	// Genesis returns the genesis of the blockchain
+	Genesis() *Genesis
 	// TipHash returns tip block's hash
 	TipHash() (hash.Hash32B, error)
 	// TipHeight returns tip block's height
 	TipHeight() (uint64, error)
 	// StateByAddr returns state of a given address
 	StateByAddr(address string) (*state.State, error)
+	// StateByHeight returns state of a given address as of the block of the given height
+	StateByHeight(address string, height uint64) (*state.State, error)
+	// StateProof returns the proof of the state of a given address against the state root of the block of the given
+	// height
+	StateProof(address string, height uint64) ([][]byte, error)
+	// SnapshotManifest returns the manifest of the latest snapshot of the states
+	SnapshotManifest() (*SnapshotManifest, error)
+	// SnapshotChunk returns a chunk of the latest or the previous snapshot of the states by its hash
+	SnapshotChunk(h hash.Hash32B) ([]byte, error)
+	// RestoreSnapshot restores the states of a fresh chain from a snapshot whose block matches the verified header of
+	// its height, and whose chunks are returned by the function
+	RestoreSnapshot(manifest *SnapshotManifest, header *Block, chunk func(hash.Hash32B) ([]byte, error)) error
 
 	// For block operations
 	// MintNewBlock creates a new block with given actions
 	// Note: the coinbase transfer will be added to the given transfers when minting a new block
-	MintNewBlock(tsf []*action.Transfer, vote []*action.Vote, address *iotxaddress.Address, data string) (*Block, error)
+	MintNewBlock(acts []action.Action, address *iotxaddress.Address, data string) (*Block, error)
 	// MintNewDummyBlock creates a new dummy block with no transactions
 	MintNewDummyBlock() (*Block, error)
 	// CommitBlock validates and appends a block to the chain
@@ -110,6 +130,14 @@ type blockchain struct {
 
 	// used by account-based model
 	sf state.Factory
+
+	// the snapshots are exported one at a time off the commit path, and only the states of the latest block pinned
+	// for a snapshot wait for the export in progress
+	snapshotMu      sync.Mutex
+	snapshotPending *pendingSnapshot
+	snapshotRunning bool
+	snapshotStopped bool
+	snapshotWG      sync.WaitGroup
 }
 
 // Option sets blockchain construction parameter
@@ -118,12 +146,20 @@ type Option func(*blockchain, *config.Config) error
 var (
 	// ErrCandidates is the error returned when candidates cannot be returned
 	ErrCandidates = errors.New("error when getting candidates")
+	// ErrReorgTooDeep is the error returned when switching to a fork needs to revert too many blocks
+	ErrReorgTooDeep = errors.New("fork point is too deep")
+	// ErrBlockPruned is the error returned when the body of a block on the chain has been pruned
+	ErrBlockPruned = errors.New("block is pruned")
+	// ErrUnknownParent is the error returned when a block links to a block which is not stored
+	ErrUnknownParent = errors.New("parent block is unknown")
+	// ErrTooManySideBlocks is the error returned when no more block off the canonical chain can be stored
+	ErrTooManySideBlocks = errors.New("too many side blocks")
 )
 
 // DefaultStateFactoryOption sets blockchain's sf from config
 func DefaultStateFactoryOption() Option {
 	return func(bc *blockchain, cfg *config.Config) error {
-		sf, err := state.NewFactory(cfg, state.DefaultTrieOption())
+		sf, err := bc.genesis.newStateFactory(cfg, state.DefaultTrieOption())
 		if err != nil {
 			return errors.Wrapf(err, "Failed to create state factory")
 		}
@@ -145,7 +181,7 @@ func PrecreatedStateFactoryOption(sf state.Factory) Option {
 // InMemStateFactoryOption sets blockchain's state.Factory as in memory sf
 func InMemStateFactoryOption() Option {
 	return func(bc *blockchain, cfg *config.Config) error {
-		sf, err := state.NewFactory(cfg, state.InMemTrieOption())
+		sf, err := bc.genesis.newStateFactory(cfg, state.InMemTrieOption())
 		if err != nil {
 			return errors.Wrapf(err, "Failed to create state factory")
 		}
@@ -184,10 +220,17 @@ func InMemDaoOption() Option {
 
 // NewBlockchain creates a new blockchain and DB instance
 func NewBlockchain(cfg *config.Config, opts ...Option) Blockchain {
+	genesis, err := LoadGenesis(cfg)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load genesis")
+		return nil
+	}
+	// the consensus parameters of the genesis take effect before the state factory is created
+	genesis.ApplyConsensus(cfg)
 	// create the Blockchain
 	chain := &blockchain{
 		config:  cfg,
-		genesis: Gen,
+		genesis: genesis,
 	}
 	for _, opt := range opts {
 		if err := opt(chain, cfg); err != nil {
@@ -197,6 +240,7 @@ func NewBlockchain(cfg *config.Config, opts ...Option) Blockchain {
 	}
 	chain.initValidator()
 	chain.addDaoService()
+	chain.addPruner()
 	if err := chain.initStateFactory(); err != nil {
 		logger.Error().Err(err).Msg("Failed to initialize state.Factory")
 		return nil
@@ -214,20 +258,28 @@ func NewBlockchain(cfg *config.Config, opts ...Option) Blockchain {
 	if height > 0 {
 		return chain
 	}
-	genesis := NewGenesisBlock(cfg)
-	if genesis == nil {
+	genesisBlk := genesis.newBlock()
+	if genesisBlk == nil {
 		logger.Error().Msg("Cannot create genesis block.")
 		return nil
 	}
+	if chain.sf != nil {
+		root, err := chain.sf.RunActions(0, genesisBlk.Actions)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to compute state root of Genesis block")
+			return nil
+		}
+		genesisBlk.Header.stateRoot = root
+	}
 	// Genesis block has height 0
-	if genesis.Header.height != 0 {
+	if genesisBlk.Header.height != 0 {
 		logger.Error().
-			Uint64("Genesis block has height", genesis.Height()).
+			Uint64("Genesis block has height", genesisBlk.Height()).
 			Msg("Expecting 0")
 		return nil
 	}
 	// add Genesis block as very first block
-	if err := chain.CommitBlock(genesis); err != nil {
+	if err := chain.CommitBlock(genesisBlk); err != nil {
 		logger.Error().Err(err).Msg("Failed to commit Genesis block")
 		return nil
 	}
@@ -236,13 +288,25 @@ func NewBlockchain(cfg *config.Config, opts ...Option) Blockchain {
 
 func (bc *blockchain) addDaoService() { bc.lifecycle.Add(bc.dao) }
 
-func (bc *blockchain) initValidator() { bc.validator = &validator{sf: bc.sf} }
+func (bc *blockchain) addPruner() {
+	if bc.config.Chain.EnablePruning {
+		bc.lifecycle.Add(routine.NewRecurringTask(bc.prune, bc.config.Chain.PruneInterval))
+	}
+}
+
+func (bc *blockchain) initValidator() {
+	bc.validator = &validator{sf: bc.sf, schedule: bc.genesis.Upgrades}
+}
 
 func (bc *blockchain) initStateFactory() error {
 	sf := bc.sf
 	if sf != nil {
+		if _, ok := sf.Height(); ok {
+			// the states persisted by the last run already have the producer
+			return nil
+		}
 		// add producer into Trie
-		if _, err := sf.CreateState(Gen.CreatorAddr, Gen.TotalSupply); err != nil {
+		if _, err := sf.CreateState(bc.genesis.CreatorAddr, bc.genesis.TotalSupply); err != nil {
 			logger.Error().Err(err).Msg("Failed to add Creator into StateFactory")
 			return err
 		}
@@ -256,6 +320,13 @@ func (bc *blockchain) Start(ctx context.Context) (err error) {
 	if err = bc.lifecycle.OnStart(ctx); err != nil {
 		return err
 	}
+	bc.snapshotMu.Lock()
+	bc.snapshotStopped = false
+	bc.snapshotMu.Unlock()
+
+	if height, ok := bc.dao.getReindexHeight(); ok {
+		return errors.Wrapf(ErrReindexInProgress, "next block to reindex %d", height)
+	}
 
 	// get blockchain tip height
 	bc.mu.Lock()
@@ -264,32 +335,51 @@ func (bc *blockchain) Start(ctx context.Context) (err error) {
 		return err
 	}
 	if bc.tipHeight == 0 {
-		return nil
+		// the genesis block links to the hash of the genesis, and the first block links to the genesis block
+		bc.tipHash, err = bc.dao.getBlockHash(0)
+		if errors.Cause(err) == db.ErrNotExist {
+			bc.tipHash = bc.genesis.Hash()
+			return nil
+		}
+		return err
 	}
 	// get blockchain tip hash
 	if bc.tipHash, err = bc.dao.getBlockHash(bc.tipHeight); err != nil {
 		return err
 	}
 
-	// populate state factory
-	for i := uint64(0); i <= bc.tipHeight; i++ {
+	// populate state factory, from the block following the states persisted by the last run if any
+	start := uint64(0)
+	if bc.sf != nil {
+		if height, ok := bc.sf.Height(); ok {
+			if height > bc.tipHeight {
+				return errors.Errorf("state height %d is above the tip height %d", height, bc.tipHeight)
+			}
+			start = height + 1
+		}
+	}
+	for i := start; i <= bc.tipHeight; i++ {
 		blk, err := bc.GetBlockByHeight(i)
 		if err != nil {
 			return err
 		}
-		if blk != nil {
-			if bc.sf != nil && blk.Transfers != nil {
-				if err := bc.sf.CommitStateChanges(blk.Height(), blk.Transfers, blk.Votes); err != nil {
-					return err
-				}
+		if blk != nil && bc.sf != nil {
+			if err := bc.sf.CommitStateChanges(blk.Height(), blk.Actions); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
-// Stop stops the blockchain.
-func (bc *blockchain) Stop(ctx context.Context) error { return bc.lifecycle.OnStop(ctx) }
+// Stop stops the blockchain, after the snapshot being exported is aborted as it writes to the chain DB
+func (bc *blockchain) Stop(ctx context.Context) error {
+	bc.snapshotMu.Lock()
+	bc.snapshotStopped = true
+	bc.snapshotMu.Unlock()
+	bc.snapshotWG.Wait()
+	return bc.lifecycle.OnStop(ctx)
+}
 
 // Balance returns balance of address
 func (bc *blockchain) Balance(addr string) (*big.Int, error) {
@@ -307,8 +397,8 @@ func (bc *blockchain) CreateState(addr string, init uint64) (*state.State, error
 }
 
 // CommitStateChanges updates a State from the given actions
-func (bc *blockchain) CommitStateChanges(blockHeight uint64, tsf []*action.Transfer, vote []*action.Vote) error {
-	return bc.sf.CommitStateChanges(blockHeight, tsf, vote)
+func (bc *blockchain) CommitStateChanges(blockHeight uint64, acts []action.Action) error {
+	return bc.sf.CommitStateChanges(blockHeight, acts)
 }
 
 // Candidates returns the candidate list
@@ -391,7 +481,7 @@ func (bc *blockchain) GetTransferByTransferHash(h hash.Hash32B) (*action.Transfe
 	if err != nil {
 		return nil, err
 	}
-	for _, transfer := range blk.Transfers {
+	for _, transfer := range blk.Transfers() {
 		if transfer.Hash() == h {
 			return transfer, nil
 		}
@@ -424,7 +514,7 @@ func (bc *blockchain) GetVoteByVoteHash(h hash.Hash32B) (*action.Vote, error) {
 	if err != nil {
 		return nil, err
 	}
-	for _, vote := range blk.Votes {
+	for _, vote := range blk.Votes() {
 		if vote.Hash() == h {
 			return vote, nil
 		}
@@ -437,6 +527,33 @@ func (bc *blockchain) GetBlockHashByVoteHash(h hash.Hash32B) (hash.Hash32B, erro
 	return bc.dao.getBlockHashByVoteHash(h)
 }
 
+// GetReceiptByActionHash returns the receipt of an action by action hash
+func (bc *blockchain) GetReceiptByActionHash(h hash.Hash32B) (*state.Receipt, error) {
+	return bc.dao.getReceiptByActionHash(h)
+}
+
+// GetActionProofByActionHash returns the height of the block of the transfer or vote, and the merkle proof of it
+func (bc *blockchain) GetActionProofByActionHash(h hash.Hash32B) (uint64, *cp.MerkleProof, error) {
+	blkHash, err := bc.dao.getBlockHashByTransferHash(h)
+	if err != nil {
+		if blkHash, err = bc.dao.getBlockHashByVoteHash(h); err != nil {
+			return 0, nil, errors.Wrapf(err, "failed to get block of action %x", h)
+		}
+	}
+	blk, err := bc.dao.getBlock(blkHash)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to get block %x", blkHash)
+	}
+	proof, err := blk.ActionProof(h)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to get proof of action %x", h)
+	}
+	return blk.Height(), proof, nil
+}
+
+// Genesis returns the genesis of the blockchain
+func (bc *blockchain) Genesis() *Genesis { return bc.genesis }
+
 // TipHash returns tip block's hash
 func (bc *blockchain) TipHash() (hash.Hash32B, error) {
 	bc.mu.RLock()
@@ -456,24 +573,27 @@ func (bc *blockchain) ValidateBlock(blk *Block) error {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
 
-	if bc.validator == nil {
-		panic("no block validator")
-	}
-
-	return bc.validator.Validate(blk, bc.tipHeight, bc.tipHash)
+	return bc.validateBlock(blk)
 }
 
 // MintNewBlock creates a new block with given actions
-// Note: the coinbase transfer will be added to the given transfers
+// Note: the coinbase transfer will be added to the given actions
 // when minting a new block
-func (bc *blockchain) MintNewBlock(tsf []*action.Transfer, vote []*action.Vote,
-	producer *iotxaddress.Address, data string) (*Block, error) {
+func (bc *blockchain) MintNewBlock(acts []action.Action, producer *iotxaddress.Address, data string) (*Block, error) {
 	bc.mu.RLock()
 	defer bc.mu.RUnlock()
 
-	tsf = append(tsf, action.NewCoinBaseTransfer(big.NewInt(int64(bc.genesis.BlockReward)), producer.RawAddress))
+	acts = append(acts, action.NewCoinBaseTransfer(big.NewInt(int64(bc.genesis.BlockReward)), producer.RawAddress))
 
-	blk := NewBlock(bc.chainID, bc.tipHeight+1, bc.tipHash, tsf, vote)
+	blk := NewBlock(bc.chainID, bc.tipHeight+1, bc.tipHash, acts)
+	blk.Header.version = bc.genesis.Upgrades.Version(blk.Header.height)
+	if bc.sf != nil {
+		root, err := bc.sf.RunActions(blk.Height(), blk.Actions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to compute state root of new block %d", blk.Height())
+		}
+		blk.Header.stateRoot = root
+	}
 	if producer.PrivateKey == keypair.ZeroPrivateKey {
 		logger.Warn().Msg("Unsigned block...")
 		return blk, nil
@@ -499,7 +619,7 @@ func (bc *blockchain) MintNewDummyBlock() (*Block, error) {
 
 	blk := &Block{
 		Header: &BlockHeader{
-			version:       version.ProtocolVersion,
+			version:       bc.genesis.Upgrades.Version(bc.tipHeight + 1),
 			chainID:       bc.chainID,
 			height:        bc.tipHeight + 1,
 			timestamp:     timestamp,
@@ -508,13 +628,33 @@ func (bc *blockchain) MintNewDummyBlock() (*Block, error) {
 			stateRoot:     hash.ZeroHash32B,
 			blockSig:      []byte{}},
 	}
+	// dummy block does not have any action, but may still settle the epoch reward
+	if bc.sf != nil {
+		root, err := bc.sf.RunActions(blk.Header.height, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to compute state root of dummy block %d", blk.Header.height)
+		}
+		blk.Header.stateRoot = root
+	}
 
 	return blk, nil
 }
 
-//  CommitBlock validates and appends a block to the chain
+// CommitBlock validates and appends a block to the chain
+// A block linked to a known block other than the tip is stored as a side block, and the chain switches to its fork
+// once the fork becomes longer than the current chain. A block linked to an unknown block fails with ErrUnknownParent
 func (bc *blockchain) CommitBlock(blk *Block) error {
-	if err := bc.ValidateBlock(blk); err != nil {
+	// the tip must not move between choosing where the block goes and putting it there
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if blk != nil && blk.Header.height > 0 && blk.Header.prevBlockHash != bc.tipHash {
+		if _, err := bc.dao.getBlockHeight(blk.Header.prevBlockHash); err != nil {
+			return errors.Wrapf(ErrUnknownParent, "parent %x of block %d", blk.Header.prevBlockHash, blk.Header.height)
+		}
+		return bc.commitSideBlock(blk)
+	}
+	if err := bc.validateBlock(blk); err != nil {
 		return err
 	}
 	return bc.commitBlock(blk)
@@ -535,6 +675,46 @@ func (bc *blockchain) StateByAddr(address string) (*state.State, error) {
 	return nil, errors.New("state factory is nil")
 }
 
+// StateByHeight returns the state of an address as of the block of a given height, which is opened at the state root
+// in the block header
+func (bc *blockchain) StateByHeight(address string, height uint64) (*state.State, error) {
+	if bc.sf == nil {
+		return nil, errors.New("state factory is nil")
+	}
+	tipHeight, err := bc.TipHeight()
+	if err != nil {
+		return nil, err
+	}
+	if height > tipHeight {
+		return nil, errors.Errorf("height %d is above the tip height %d", height, tipHeight)
+	}
+	blk, err := bc.GetBlockByHeight(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get block %d", height)
+	}
+	return bc.sf.StateByRoot(address, blk.Header.stateRoot)
+}
+
+// StateProof returns the proof of the state of an address against the state root in the header of the block of a
+// given height, which can be verified with state.VerifyStateProof
+func (bc *blockchain) StateProof(address string, height uint64) ([][]byte, error) {
+	if bc.sf == nil {
+		return nil, errors.New("state factory is nil")
+	}
+	tipHeight, err := bc.TipHeight()
+	if err != nil {
+		return nil, err
+	}
+	if height > tipHeight {
+		return nil, errors.Errorf("height %d is above the tip height %d", height, tipHeight)
+	}
+	blk, err := bc.GetBlockByHeight(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get block %d", height)
+	}
+	return bc.sf.StateProof(address, blk.Header.stateRoot)
+}
+
 // SetValidator sets the current validator object
 func (bc *blockchain) SetValidator(val Validator) {
 	bc.validator = val
@@ -548,22 +728,372 @@ func (bc *blockchain) Validator() Validator {
 //======================================
 // private functions
 //=====================================
+// The functions below that read or move the tip are called with bc.mu locked
+
+// validateBlock validates a block against the tip
+func (bc *blockchain) validateBlock(blk *Block) error {
+	if bc.validator == nil {
+		panic("no block validator")
+	}
+
+	if err := bc.validateTimestamp(blk); err != nil {
+		return err
+	}
+	return bc.validator.Validate(blk, bc.tipHeight, bc.tipHash)
+}
+
+// validateTimestamp checks the timestamp of a block is not before the timestamp of its parent, nor ahead of the local
+// clock by more than maxTimestampDrift
+func (bc *blockchain) validateTimestamp(blk *Block) error {
+	if blk == nil || blk.Header.height == 0 {
+		return nil
+	}
+	parent, err := bc.dao.getBlock(blk.Header.prevBlockHash)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get parent %x of block %d", blk.Header.prevBlockHash, blk.Header.height)
+	}
+	if blk.Header.timestamp < parent.Header.timestamp {
+		return errors.Wrapf(
+			ErrInvalidTimestamp,
+			"block %d is timestamped %d before its parent at %d",
+			blk.Header.height,
+			blk.Header.timestamp,
+			parent.Header.timestamp)
+	}
+	if limit := time.Now().Add(maxTimestampDrift); blk.Header.Timestamp().After(limit) {
+		return errors.Wrapf(
+			ErrInvalidTimestamp,
+			"block %d is timestamped %d after %d",
+			blk.Header.height,
+			blk.Header.timestamp,
+			limit.Unix())
+	}
+	return nil
+}
+
 // commitBlock commits a block to the chain
 func (bc *blockchain) commitBlock(blk *Block) error {
-	if err := bc.dao.putBlock(blk); err != nil {
+	receipts, err := bc.receipts(blk)
+	if err != nil {
 		return err
 	}
+	if err := bc.dao.putBlock(blk, receipts); err != nil {
+		return err
+	}
+	return bc.updateTip(blk)
+}
+
+// receipts returns the receipts of the actions of a block extending the tip, which are put along with the block so
+// the block is never on the chain without them
+func (bc *blockchain) receipts(blk *Block) ([]*state.Receipt, error) {
+	if bc.sf == nil || blk.Actions == nil {
+		return nil, nil
+	}
+	receipts, err := bc.sf.ActionReceipts(blk.Height(), blk.Actions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run actions of block %d", blk.Height())
+	}
+	return receipts, nil
+}
+
+// updateTip moves the tip to a block just put onto the chain, and applies the block's actions to the states
+func (bc *blockchain) updateTip(blk *Block) error {
 	// update tip hash and height
-	bc.mu.Lock()
-	defer bc.mu.Unlock()
 	bc.tipHeight = blk.Header.height
 	bc.tipHash = blk.HashBlock()
 
-	// update state factory
-	if bc.sf == nil || (blk.Transfers == nil && blk.Votes == nil) {
+	// update state factory, also for the blocks without actions, whose state roots may settle the epoch reward
+	if bc.sf == nil {
 		return nil
 	}
-	err := bc.sf.CommitStateChanges(blk.Height(), blk.Transfers, blk.Votes)
+	if err := bc.sf.CommitStateChanges(blk.Height(), blk.Actions); err != nil {
+		return err
+	}
 	logger.Info().Uint64("height", blk.Header.height).Msg("committed a block")
-	return err
+	if interval := bc.config.Chain.SnapshotInterval; interval > 0 && blk.Height() > 0 && blk.Height()%interval == 0 {
+		bc.startSnapshot(blk)
+	}
+	return nil
+}
+
+// commitSideBlock stores a block which does not extend the tip, and switches to its fork if the fork is longer
+func (bc *blockchain) commitSideBlock(blk *Block) error {
+	hash := blk.HashBlock()
+	if _, err := bc.dao.getBlockHeight(hash); err == nil {
+		// the block is stored already
+		return nil
+	}
+	prevHeight, err := bc.dao.getBlockHeight(blk.Header.prevBlockHash)
+	if err != nil {
+		return err
+	}
+	forkPoint, err := bc.forkPoint(blk)
+	if err != nil {
+		return err
+	}
+	// the states are not at the fork point, so only the stateless checks can be done here, along with the producer if
+	// the delegates of its epoch are elected on the canonical chain. The actions are verified when the fork is switched to
+	if err := bc.validateTimestamp(blk); err != nil {
+		return err
+	}
+	if err := (&validator{schedule: bc.genesis.Upgrades}).Validate(blk, prevHeight, blk.Header.prevBlockHash); err != nil {
+		return err
+	}
+	if epochLen := bc.epochLen(); epochLen > 0 && candidatesHeight(blk.Height(), epochLen) <= forkPoint {
+		if err := bc.validateProducer(blk); err != nil {
+			return err
+		}
+	}
+	if err := bc.makeRoomForSideBlock(); err != nil {
+		return err
+	}
+	if err := bc.dao.putSideBlock(blk); err != nil {
+		return errors.Wrapf(err, "failed to put side block %x", hash)
+	}
+
+	// fork choice rule: the longest chain wins, and the current chain is kept when a fork is as long as it
+	if blk.Height() <= bc.tipHeight {
+		logger.Info().
+			Uint64("height", blk.Height()).
+			Hex("hash", hash[:]).
+			Msg("stored a side block")
+		return nil
+	}
+	return bc.reorg(blk)
+}
+
+// forkPoint returns the height of the block on the canonical chain which the fork of a side block branches off, by
+// walking back through the stored side blocks. It fails with ErrReorgTooDeep as soon as switching to the fork would
+// revert more than MaxReorgDepth blocks
+func (bc *blockchain) forkPoint(blk *Block) (uint64, error) {
+	maxDepth := uint64(bc.config.Chain.MaxReorgDepth)
+	height, prevHash := blk.Height()-1, blk.Header.prevBlockHash
+	for {
+		if bc.tipHeight > height && bc.tipHeight-height > maxDepth {
+			return 0, errors.Wrapf(ErrReorgTooDeep, "reverting more than %d blocks", maxDepth)
+		}
+		if hash, err := bc.dao.getBlockHash(height); err == nil && hash == prevHash {
+			return height, nil
+		}
+		if height == 0 {
+			return 0, errors.Wrap(ErrInvalidBlock, "fork does not link to the genesis block")
+		}
+		prev, err := bc.dao.getBlock(prevHash)
+		if err != nil {
+			return 0, err
+		}
+		if prev.Height() != height {
+			return 0, errors.Wrapf(ErrInvalidBlock, "block %x of height %d, expecting %d", prevHash, prev.Height(), height)
+		}
+		height, prevHash = height-1, prev.Header.prevBlockHash
+	}
+}
+
+// makeRoomForSideBlock drops the side blocks too far below the tip to be switched to once the side blocks reach
+// MaxSideBlocks, and fails with ErrTooManySideBlocks if they are still at the limit
+func (bc *blockchain) makeRoomForSideBlock() error {
+	limit := int(bc.config.Chain.MaxSideBlocks)
+	sides, err := bc.dao.getSideBlocks()
+	if err != nil {
+		return err
+	}
+	if len(sides) < limit {
+		return nil
+	}
+	// switching to the fork of a side block reverts the blocks from its height up to the tip
+	if depth := uint64(bc.config.Chain.MaxReorgDepth); bc.tipHeight+1 > depth {
+		if err := bc.dao.deleteSideBlocks(bc.tipHeight + 1 - depth); err != nil {
+			return errors.Wrap(err, "failed to delete side blocks")
+		}
+		if sides, err = bc.dao.getSideBlocks(); err != nil {
+			return err
+		}
+	}
+	if len(sides) >= limit {
+		return errors.Wrapf(ErrTooManySideBlocks, "%d side blocks stored", len(sides))
+	}
+	return nil
+}
+
+// epochLen returns the number of the blocks produced by the delegates of an epoch, or 0 if the delegates are not
+// rolled by epochs
+func (bc *blockchain) epochLen() uint64 {
+	if bc.config.Consensus.Scheme != config.RollDPoSScheme {
+		return 0
+	}
+	numSubEpochs := bc.config.Consensus.RollDPoS.NumSubEpochs
+	if numSubEpochs == 0 {
+		numSubEpochs = 1
+	}
+	return uint64(bc.config.Consensus.RollDPoS.NumDelegates) * uint64(numSubEpochs)
+}
+
+// candidatesHeight returns the height of the block whose candidates the delegates of the epoch of the given height are
+// elected from, which is the last height of the previous epoch
+func candidatesHeight(height uint64, epochLen uint64) uint64 {
+	if height == 0 {
+		return 0
+	}
+	return (height - 1) / epochLen * epochLen
+}
+
+// validateProducer checks the producer of a block is one of the delegates of its epoch, which are the top candidates
+// as of the last block of the previous epoch. Nothing is checked unless the delegates are rolled by epochs
+func (bc *blockchain) validateProducer(blk *Block) error {
+	epochLen := bc.epochLen()
+	if epochLen == 0 || bc.sf == nil {
+		return nil
+	}
+	height := candidatesHeight(blk.Height(), epochLen)
+	candidates, ok := bc.sf.CandidatesByHeight(height)
+	if !ok {
+		return errors.Wrapf(ErrCandidates, "candidates of block %d as of height %d", blk.Height(), height)
+	}
+	if numDelegates := int(bc.config.Consensus.RollDPoS.NumDelegates); len(candidates) > numDelegates {
+		candidates = candidates[:numDelegates]
+	}
+	producer, err := iotxaddress.GetAddress(blk.Header.Pubkey, iotxaddress.IsTestnet, iotxaddress.ChainID)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidBlock, "fail to get address of public key %x: %v", blk.Header.Pubkey, err)
+	}
+	for _, candidate := range candidates {
+		if candidate.Address == producer.RawAddress {
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrInvalidBlock, "producer %s is not a delegate of block %d", producer.RawAddress, blk.Height())
+}
+
+// reorg switches the canonical chain to the fork ending at the given side block
+func (bc *blockchain) reorg(newTip *Block) error {
+	tipHeight := bc.tipHeight
+	// walk back to the common ancestor on the canonical chain
+	fork := []*Block{newTip}
+	for {
+		first := fork[0]
+		if first.Height() == 0 {
+			return errors.Wrap(ErrInvalidBlock, "fork does not link to the genesis block")
+		}
+		hash, err := bc.dao.getBlockHash(first.Height() - 1)
+		if err != nil {
+			return err
+		}
+		if hash == first.Header.prevBlockHash {
+			break
+		}
+		if uint64(len(fork)) > uint64(bc.config.Chain.MaxReorgDepth) {
+			return errors.Wrapf(ErrReorgTooDeep, "reverting more than %d blocks", bc.config.Chain.MaxReorgDepth)
+		}
+		prev, err := bc.dao.getBlock(first.Header.prevBlockHash)
+		if err != nil {
+			return err
+		}
+		fork = append([]*Block{prev}, fork...)
+	}
+	forkPoint := fork[0].Height() - 1
+	if tipHeight-forkPoint > uint64(bc.config.Chain.MaxReorgDepth) {
+		return errors.Wrapf(ErrReorgTooDeep, "reverting more than %d blocks", bc.config.Chain.MaxReorgDepth)
+	}
+
+	// keep the blocks being reverted, so the current chain can be restored if the fork turns out to be invalid
+	var reverted []*Block
+	for h := forkPoint + 1; h <= tipHeight; h++ {
+		blk, err := bc.GetBlockByHeight(h)
+		if err != nil {
+			return err
+		}
+		reverted = append(reverted, blk)
+	}
+	if err := bc.rollback(forkPoint); err != nil {
+		return errors.Wrapf(err, "failed to rollback to height %d", forkPoint)
+	}
+	if err := bc.extend(fork, true); err != nil {
+		logger.Error().Err(err).Uint64("forkPoint", forkPoint).Msg("Invalid fork, restoring the previous chain")
+		if err := bc.rollback(forkPoint); err != nil {
+			return errors.Wrapf(err, "failed to rollback to height %d", forkPoint)
+		}
+		if err := bc.extend(reverted, false); err != nil {
+			return errors.Wrap(err, "failed to restore the previous chain")
+		}
+		return err
+	}
+	logger.Info().
+		Uint64("forkPoint", forkPoint).
+		Int("reverted", len(reverted)).
+		Uint64("height", newTip.Height()).
+		Msg("switched to a longer fork")
+	return nil
+}
+
+// rollback reverts the canonical chain and the states to the given height
+func (bc *blockchain) rollback(height uint64) error {
+	// revert the states first, which fails without side effect if the undo history is not long enough
+	if bc.sf != nil {
+		if err := bc.sf.Rollback(height); err != nil {
+			return err
+		}
+	}
+	for bc.tipHeight > height {
+		if err := bc.dao.deleteTipBlock(); err != nil {
+			return err
+		}
+		bc.tipHeight--
+	}
+	tipHash, err := bc.dao.getBlockHash(height)
+	if err != nil {
+		return err
+	}
+	bc.tipHash = tipHash
+	return nil
+}
+
+// extend validates the stored side blocks and puts them onto the canonical chain one by one. The producers are checked
+// as well for the blocks of a fork, whose delegates may be elected on the fork
+func (bc *blockchain) extend(blks []*Block, fork bool) error {
+	for _, blk := range blks {
+		if fork {
+			if err := bc.validateProducer(blk); err != nil {
+				return err
+			}
+		}
+		if err := bc.validateBlock(blk); err != nil {
+			return err
+		}
+		receipts, err := bc.receipts(blk)
+		if err != nil {
+			return err
+		}
+		if err := bc.dao.indexBlock(blk, receipts); err != nil {
+			return err
+		}
+		if err := bc.updateTip(blk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prune drops the block bodies and the states before the most recent PruneDepth blocks. The chain keeps committing
+// blocks meanwhile, which never touch the bodies and the states being dropped
+func (bc *blockchain) prune() {
+	tipHeight, err := bc.TipHeight()
+	if err != nil {
+		return
+	}
+	depth := uint64(bc.config.Chain.PruneDepth)
+	if tipHeight < depth {
+		return
+	}
+	height := tipHeight - depth + 1
+	if err := bc.dao.pruneBlocks(height); err != nil {
+		logger.Error().Err(err).Uint64("height", height).Msg("Failed to prune blocks")
+		return
+	}
+	if bc.sf != nil {
+		if err := bc.sf.Prune(height); err != nil {
+			logger.Error().Err(err).Uint64("height", height).Msg("Failed to prune states")
+			return
+		}
+	}
+	logger.Info().Uint64("height", height).Msg("pruned blocks and states")
 }
@@ -9,17 +9,24 @@ package blockchain
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"strconv"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/iotex-core/blockchain/action"
 	"github.com/iotexproject/iotex-core/config"
+	cp "github.com/iotexproject/iotex-core/crypto"
+	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/iotxaddress"
 	_hash "github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
 	"github.com/iotexproject/iotex-core/state"
 	ta "github.com/iotexproject/iotex-core/test/testaddress"
 	"github.com/iotexproject/iotex-core/testutil"
@@ -46,7 +53,7 @@ func addTestingTsfBlocks(bc Blockchain) error {
 	tsf6, _ := action.NewTransfer(6, big.NewInt(50<<20), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["foxtrot"].RawAddress)
 	tsf6, _ = tsf6.Sign(ta.Addrinfo["producer"])
 
-	blk, err := bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6}, nil, ta.Addrinfo["producer"], "")
+	blk, err := bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -66,7 +73,7 @@ func addTestingTsfBlocks(bc Blockchain) error {
 	tsf4, _ = tsf4.Sign(ta.Addrinfo["charlie"])
 	tsf5, _ = action.NewTransfer(5, big.NewInt(1), ta.Addrinfo["charlie"].RawAddress, ta.Addrinfo["producer"].RawAddress)
 	tsf5, _ = tsf5.Sign(ta.Addrinfo["charlie"])
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4, tsf5}, nil, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4, tsf5}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -84,7 +91,7 @@ func addTestingTsfBlocks(bc Blockchain) error {
 	tsf3, _ = tsf3.Sign(ta.Addrinfo["delta"])
 	tsf4, _ = action.NewTransfer(4, big.NewInt(1), ta.Addrinfo["delta"].RawAddress, ta.Addrinfo["producer"].RawAddress)
 	tsf4, _ = tsf4.Sign(ta.Addrinfo["delta"])
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4}, nil, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -118,7 +125,7 @@ func addTestingTsfBlocks(bc Blockchain) error {
 		return err
 	}
 
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6}, []*action.Vote{vote1, vote2}, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6, vote1, vote2}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -129,6 +136,16 @@ func addTestingTsfBlocks(bc Blockchain) error {
 	return nil
 }
 
+// newTestingFactory creates a state factory in which the producer holds the total supply, which addTestingTsfBlocks
+// spends
+func newTestingFactory(t *testing.T, cfg *config.Config, opts ...state.FactoryOption) state.Factory {
+	sf, err := state.NewFactory(cfg, opts...)
+	require.NoError(t, err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+	require.NoError(t, err)
+	return sf
+}
+
 func TestCreateBlockchain(t *testing.T) {
 	assert := assert.New(t)
 	ctx := context.Background()
@@ -158,8 +175,8 @@ func TestCreateBlockchain(t *testing.T) {
 	data, err := genesis.Serialize()
 	assert.Nil(err)
 
-	assert.Equal(10, len(genesis.Transfers))
-	assert.Equal(21, len(genesis.Votes))
+	assert.Equal(10, len(genesis.Transfers()))
+	assert.Equal(21, len(genesis.Votes()))
 
 	fmt.Printf("Block size match pass\n")
 	fmt.Printf("Marshaling Block pass\n")
@@ -296,7 +313,7 @@ func TestLoadBlockchainfromDB(t *testing.T) {
 	// add block with wrong height
 	cbTsf := action.NewCoinBaseTransfer(big.NewInt(50), ta.Addrinfo["bravo"].RawAddress)
 	require.NotNil(cbTsf)
-	blk = NewBlock(0, h+2, hash, []*action.Transfer{cbTsf}, nil)
+	blk = NewBlock(0, h+2, hash, []action.Action{cbTsf})
 	err = bc.ValidateBlock(blk)
 	require.NotNil(err)
 	fmt.Printf("Cannot validate block %d: %v\n", blk.Height(), err)
@@ -304,7 +321,7 @@ func TestLoadBlockchainfromDB(t *testing.T) {
 	// add block with zero prev hash
 	cbTsf2 := action.NewCoinBaseTransfer(big.NewInt(50), ta.Addrinfo["bravo"].RawAddress)
 	require.NotNil(cbTsf2)
-	blk = NewBlock(0, h+1, _hash.ZeroHash32B, []*action.Transfer{cbTsf2}, nil)
+	blk = NewBlock(0, h+1, _hash.ZeroHash32B, []action.Action{cbTsf2})
 	err = bc.ValidateBlock(blk)
 	require.NotNil(err)
 	fmt.Printf("Cannot validate block %d: %v\n", blk.Height(), err)
@@ -321,7 +338,7 @@ func TestLoadBlockchainfromDB(t *testing.T) {
 	blk, err = bc.GetBlockByHeight(4)
 	require.Nil(err)
 	require.Equal(hash4, blk.HashBlock())
-	for _, transfer := range blk.Transfers {
+	for _, transfer := range blk.Transfers() {
 		transferHash := transfer.Hash()
 		hash, err := bc.GetBlockHashByTransferHash(transferHash)
 		require.Nil(err)
@@ -331,7 +348,7 @@ func TestLoadBlockchainfromDB(t *testing.T) {
 		require.Equal(transfer1.Hash(), transferHash)
 	}
 
-	for _, vote := range blk.Votes {
+	for _, vote := range blk.Votes() {
 		voteHash := vote.Hash()
 		hash, err := bc.GetBlockHashByVoteHash(voteHash)
 		require.Nil(err)
@@ -418,6 +435,96 @@ func TestBlockchain_MintNewDummyBlock(t *testing.T) {
 	assert.Equal(t, uint64(1), blk.Height())
 }
 
+func TestBlockchain_DummyBlockEpochReward(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// the producer creates the genesis, and the epoch of two blocks is rewarded from the first block
+	producer := ta.Addrinfo["producer"]
+	tsf, err := action.NewTransfer(0, big.NewInt(10), producer.RawAddress, ta.Addrinfo["alfa"].RawAddress)
+	require.NoError(err)
+	tsf.GasLimit = 0
+	require.NoError(action.Sign(tsf, producer))
+	doc := fmt.Sprintf(`
+blockReward: 0
+epochReward: 100
+creatorAddr: %s
+creatorPubKey: %s
+transfers:
+    - amount: 10
+      recipient: %s
+      signature: %x
+consensus:
+    numDelegates: 2
+    numSubEpochs: 1
+upgrades:
+    - height: 1
+      version: 2
+      features: ["epochreward"]
+`, producer.RawAddress, keypair.EncodePublicKey(producer.PublicKey), ta.Addrinfo["alfa"].RawAddress, tsf.Signature)
+	require.NoError(ioutil.WriteFile(testGenesisPath, []byte(doc), 0644))
+	defer os.Remove(testGenesisPath)
+	cfg := config.Default
+	cfg.Chain.GenesisPath = testGenesisPath
+	bc := NewBlockchain(&cfg, InMemStateFactoryOption(), InMemDaoOption())
+	require.NotNil(bc)
+	defer func() {
+		require.NoError(bc.Stop(ctx))
+	}()
+
+	blk, err := bc.MintNewBlock(nil, producer, "")
+	require.NoError(err)
+	require.NoError(bc.CommitBlock(blk))
+	balance, err := bc.Balance(producer.RawAddress)
+	require.NoError(err)
+
+	// the dummy block ending the epoch settles the reward, which the states take along with its state root. It has
+	// no actions to be stored with, so only the tip is moved to it
+	blk, err = bc.MintNewDummyBlock()
+	require.NoError(err)
+	require.Nil(blk.Actions)
+	require.NoError(bc.(*blockchain).updateTip(blk))
+	rewarded, err := bc.Balance(producer.RawAddress)
+	require.NoError(err)
+	require.Equal(new(big.Int).Add(balance, big.NewInt(100)), rewarded)
+	require.Equal(blk.Header.stateRoot, bc.(*blockchain).sf.RootHash())
+}
+
+func TestBlockchain_ValidateTimestamp(t *testing.T) {
+	require := require.New(t)
+	cfg := config.Default
+	// disable account-based testing
+	cfg.Chain.TrieDBPath = ""
+
+	ctx := context.Background()
+	bc := NewBlockchain(&cfg, InMemDaoOption(), InMemStateFactoryOption())
+	require.NotNil(bc)
+	defer func() {
+		require.NoError(bc.Stop(ctx))
+	}()
+	genesis, err := bc.GetBlockByHeight(0)
+	require.NoError(err)
+
+	// the first block links to the genesis block, also after a restart
+	require.NoError(bc.Stop(ctx))
+	require.NoError(bc.Start(ctx))
+	blk, err := bc.MintNewBlock(nil, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.Equal(genesis.HashBlock(), blk.PrevHash())
+	require.NoError(bc.ValidateBlock(blk))
+
+	// timestamped before the parent
+	blk.Header.timestamp = genesis.Header.timestamp - 1
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	require.Equal(ErrInvalidTimestamp, errors.Cause(bc.ValidateBlock(blk)))
+	require.Equal(ErrInvalidTimestamp, errors.Cause(bc.CommitBlock(blk)))
+
+	// timestamped too far ahead of the local clock
+	blk.Header.timestamp = uint64(time.Now().Add(maxTimestampDrift + time.Minute).Unix())
+	require.NoError(blk.SignBlock(ta.Addrinfo["producer"]))
+	require.Equal(ErrInvalidTimestamp, errors.Cause(bc.ValidateBlock(blk)))
+}
+
 func TestBlockchainInitialCandidate(t *testing.T) {
 	require := require.New(t)
 
@@ -472,8 +579,7 @@ func TestCoinbaseTransfer(t *testing.T) {
 	require.Nil(err)
 	require.Equal(0, int(height))
 
-	transfers := []*action.Transfer{}
-	blk, err := bc.MintNewBlock(transfers, nil, ta.Addrinfo["producer"], "")
+	blk, err := bc.MintNewBlock(nil, ta.Addrinfo["producer"], "")
 	require.Nil(err)
 	s, err := bc.StateByAddr(ta.Addrinfo["producer"].RawAddress)
 	require.Nil(err)
@@ -484,11 +590,247 @@ func TestCoinbaseTransfer(t *testing.T) {
 	height, err = bc.TipHeight()
 	require.Nil(err)
 	require.True(height == 1)
-	require.True(len(blk.Transfers) == 1)
+	require.True(len(blk.Transfers()) == 1)
 	s, err = bc.StateByAddr(ta.Addrinfo["producer"].RawAddress)
 	require.Nil(err)
 	b = s.Balance
 	require.True(b.String() == strconv.Itoa(int(Gen.TotalSupply)+int(Gen.BlockReward)))
+
+	// the receipt of the coinbase transfer records the reward paid to the producer
+	receipt, err := bc.GetReceiptByActionHash(blk.Transfers()[0].Hash())
+	require.Nil(err)
+	require.Equal(state.ReceiptStatusSuccess, receipt.Status)
+	require.Equal(1, len(receipt.BalanceDeltas))
+	require.Equal(ta.Addrinfo["producer"].RawAddress, receipt.BalanceDeltas[0].Address)
+	require.Equal(big.NewInt(int64(Gen.BlockReward)), receipt.BalanceDeltas[0].Amount)
+}
+
+func TestBlockchainReorg(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	cfg := config.Default
+	// Disable block reward to make bookkeeping easier
+	defer func(reward uint64) { Gen.BlockReward = reward }(Gen.BlockReward)
+	Gen.BlockReward = uint64(0)
+
+	newChain := func() Blockchain {
+		sf, err := state.NewFactory(&cfg, state.InMemTrieOption())
+		require.NoError(err)
+		_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+		require.NoError(err)
+		bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), InMemDaoOption())
+		require.NotNil(bc)
+		return bc
+	}
+	mintAndCommit := func(bc Blockchain, nonce uint64, recipient string) *Block {
+		tsf, err := action.NewTransfer(nonce, big.NewInt(10), ta.Addrinfo["producer"].RawAddress, recipient)
+		require.NoError(err)
+		tsf, err = tsf.Sign(ta.Addrinfo["producer"])
+		require.NoError(err)
+		blk, err := bc.MintNewBlock([]action.Action{tsf}, ta.Addrinfo["producer"], "")
+		require.NoError(err)
+		require.NoError(bc.CommitBlock(blk))
+		return blk
+	}
+	alfa := ta.Addrinfo["alfa"].RawAddress
+	bravo := ta.Addrinfo["bravo"].RawAddress
+
+	// two nodes grow different chains on top of the same genesis block during a partition
+	bc1 := newChain()
+	defer func() {
+		require.NoError(bc1.Stop(ctx))
+	}()
+	bc2 := newChain()
+	defer func() {
+		require.NoError(bc2.Stop(ctx))
+	}()
+	a1 := mintAndCommit(bc1, 1, alfa)
+	a2 := mintAndCommit(bc1, 2, alfa)
+	b1 := mintAndCommit(bc2, 1, bravo)
+	b2 := mintAndCommit(bc2, 2, bravo)
+	b3 := mintAndCommit(bc2, 3, bravo)
+
+	// a fork not longer than the current chain is kept as side blocks
+	require.NoError(bc1.CommitBlock(b1))
+	require.NoError(bc1.CommitBlock(b2))
+	tipHash, err := bc1.TipHash()
+	require.NoError(err)
+	require.Equal(a2.HashBlock(), tipHash)
+	hash, err := bc1.GetHashByHeight(2)
+	require.NoError(err)
+	require.Equal(a2.HashBlock(), hash)
+	blk, err := bc1.GetBlockByHash(b2.HashBlock())
+	require.NoError(err)
+	require.Equal(b2.HashBlock(), blk.HashBlock())
+	_, err = bc1.GetBlockHashByTransferHash(b1.Transfers()[0].Hash())
+	require.Error(err)
+
+	// the longer fork replaces the current chain
+	require.NoError(bc1.CommitBlock(b3))
+	height, err := bc1.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(3), height)
+	tipHash, err = bc1.TipHash()
+	require.NoError(err)
+	require.Equal(b3.HashBlock(), tipHash)
+	for i, blk := range []*Block{b1, b2, b3} {
+		hash, err := bc1.GetHashByHeight(uint64(i + 1))
+		require.NoError(err)
+		require.Equal(blk.HashBlock(), hash)
+	}
+
+	// states and indexes follow the new chain
+	_, err = bc1.Balance(alfa)
+	require.Error(err)
+	for _, addr := range []string{ta.Addrinfo["producer"].RawAddress, bravo} {
+		balance1, err := bc1.Balance(addr)
+		require.NoError(err)
+		balance2, err := bc2.Balance(addr)
+		require.NoError(err)
+		require.Equal(balance2, balance1)
+	}
+	_, err = bc1.GetBlockHashByTransferHash(a1.Transfers()[0].Hash())
+	require.Error(err)
+	blkHash, err := bc1.GetBlockHashByTransferHash(b1.Transfers()[0].Hash())
+	require.NoError(err)
+	require.Equal(b1.HashBlock(), blkHash)
+	transfers, err := bc1.GetTransfersToAddress(alfa)
+	require.NoError(err)
+	require.Empty(transfers)
+	transfers, err = bc1.GetTransfersToAddress(bravo)
+	require.NoError(err)
+	require.Equal(3, len(transfers))
+	total1, err := bc1.GetTotalTransfers()
+	require.NoError(err)
+	total2, err := bc2.GetTotalTransfers()
+	require.NoError(err)
+	require.Equal(total2, total1)
+
+	// the reverted blocks are kept as side blocks
+	blk, err = bc1.GetBlockByHash(a1.HashBlock())
+	require.NoError(err)
+	require.Equal(a1.HashBlock(), blk.HashBlock())
+
+	// a fork branching off too far below the tip is refused before it is stored
+	cfg.Chain.MaxReorgDepth = 2
+	err = bc2.CommitBlock(a1)
+	require.Equal(ErrReorgTooDeep, errors.Cause(err))
+	_, err = bc2.GetBlockByHash(a1.HashBlock())
+	require.Error(err)
+	tipHash, err = bc2.TipHash()
+	require.NoError(err)
+	require.Equal(b3.HashBlock(), tipHash)
+
+	// a block linked to an unknown block is not stored
+	err = bc2.CommitBlock(a2)
+	require.Equal(ErrUnknownParent, errors.Cause(err))
+}
+
+func TestBlockchainSideBlocks(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// the producer creates a genesis without candidates, so that it is the only delegate once it nominates itself
+	producer := ta.Addrinfo["producer"]
+	tsf, err := action.NewTransfer(0, big.NewInt(10), producer.RawAddress, ta.Addrinfo["alfa"].RawAddress)
+	require.NoError(err)
+	tsf.GasLimit = 0
+	require.NoError(action.Sign(tsf, producer))
+	doc := fmt.Sprintf(`
+blockReward: 0
+creatorAddr: %s
+creatorPubKey: %s
+transfers:
+    - amount: 10
+      recipient: %s
+      signature: %x
+`, producer.RawAddress, keypair.EncodePublicKey(producer.PublicKey), ta.Addrinfo["alfa"].RawAddress, tsf.Signature)
+	require.NoError(ioutil.WriteFile(testGenesisPath, []byte(doc), 0644))
+	defer os.Remove(testGenesisPath)
+
+	cfg := config.Default
+	cfg.Chain.GenesisPath = testGenesisPath
+	cfg.Chain.MaxReorgDepth = 2
+	cfg.Chain.MaxSideBlocks = 1
+	// the delegate of each epoch of one block is the first candidate as of the previous block
+	cfg.Consensus.Scheme = config.RollDPoSScheme
+	cfg.Consensus.RollDPoS.NumDelegates = 1
+	cfg.Consensus.RollDPoS.NumSubEpochs = 1
+
+	newChain := func() Blockchain {
+		bc := NewBlockchain(&cfg, InMemStateFactoryOption(), InMemDaoOption())
+		require.NotNil(bc)
+		return bc
+	}
+	mint := func(bc Blockchain, nonce uint64, recipient string, signer *iotxaddress.Address) *Block {
+		tsf, err := action.NewTransfer(nonce, big.NewInt(10), producer.RawAddress, recipient)
+		require.NoError(err)
+		tsf, err = tsf.Sign(producer)
+		require.NoError(err)
+		blk, err := bc.MintNewBlock([]action.Action{tsf}, signer, "")
+		require.NoError(err)
+		return blk
+	}
+
+	// the producer nominates itself in the first block, and becomes the delegate of the following epochs
+	bc1 := newChain()
+	defer func() {
+		require.NoError(bc1.Stop(ctx))
+	}()
+	bc2 := newChain()
+	defer func() {
+		require.NoError(bc2.Stop(ctx))
+	}()
+	vote, err := action.NewVote(1, producer.RawAddress, producer.RawAddress)
+	require.NoError(err)
+	vote, err = vote.Sign(producer)
+	require.NoError(err)
+	blk1, err := bc1.MintNewBlock([]action.Action{vote}, producer, "")
+	require.NoError(err)
+	require.NoError(bc1.CommitBlock(blk1))
+	require.NoError(bc2.CommitBlock(blk1))
+	a2 := mint(bc1, 2, ta.Addrinfo["alfa"].RawAddress, producer)
+	require.NoError(bc1.CommitBlock(a2))
+	b2 := mint(bc2, 2, ta.Addrinfo["bravo"].RawAddress, producer)
+	c2 := mint(bc2, 2, ta.Addrinfo["charlie"].RawAddress, ta.Addrinfo["alfa"])
+	require.NoError(bc2.CommitBlock(b2))
+	b3 := mint(bc2, 3, ta.Addrinfo["bravo"].RawAddress, producer)
+
+	// a side block not produced by the delegate of its epoch is refused
+	err = bc1.CommitBlock(c2)
+	require.Equal(ErrInvalidBlock, errors.Cause(err))
+	_, err = bc1.GetBlockByHash(c2.HashBlock())
+	require.Error(err)
+	require.NoError(bc1.CommitBlock(b2))
+	_, err = bc1.GetBlockByHash(b2.HashBlock())
+	require.NoError(err)
+	// storing the same block again is a no-op
+	require.NoError(bc1.CommitBlock(b2))
+
+	// the side blocks are capped
+	a3 := mint(bc1, 3, ta.Addrinfo["alfa"].RawAddress, producer)
+	bc3 := newChain()
+	defer func() {
+		require.NoError(bc3.Stop(ctx))
+	}()
+	require.NoError(bc3.CommitBlock(blk1))
+	require.NoError(bc3.CommitBlock(a2))
+	d3 := mint(bc3, 3, ta.Addrinfo["delta"].RawAddress, producer)
+	require.NoError(bc1.CommitBlock(a3))
+	err = bc1.CommitBlock(d3)
+	require.Equal(ErrTooManySideBlocks, errors.Cause(err))
+
+	// the side blocks too far below the tip to be switched to make room for the new ones
+	a4 := mint(bc1, 4, ta.Addrinfo["alfa"].RawAddress, producer)
+	require.NoError(bc1.CommitBlock(a4))
+	require.NoError(bc1.CommitBlock(d3))
+	_, err = bc1.GetBlockByHash(b2.HashBlock())
+	require.Error(err)
+	_, err = bc1.GetBlockByHash(d3.HashBlock())
+	require.NoError(err)
+	err = bc1.CommitBlock(b3)
+	require.Equal(ErrUnknownParent, errors.Cause(err))
 }
 
 func TestBlockchain_StateByAddr(t *testing.T) {
@@ -509,6 +851,83 @@ func TestBlockchain_StateByAddr(t *testing.T) {
 	require.Equal(map[string]*big.Int(map[string]*big.Int(nil)), s.Voters)
 }
 
+func TestBlockchain_StateByHeight(t *testing.T) {
+	require := require.New(t)
+
+	// Disable block reward to make bookkeeping easier
+	Gen.BlockReward = uint64(0)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	sf, err := state.NewFactory(&cfg, state.InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+	require.NoError(err)
+	bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), InMemDaoOption())
+	require.NotNil(bc)
+	require.NoError(addTestingTsfBlocks(bc))
+
+	// charlie receives 50 in block 1 and transfers 5 out in block 2
+	_, err = bc.StateByHeight(ta.Addrinfo["charlie"].RawAddress, 0)
+	require.Equal(state.ErrAccountNotExist, errors.Cause(err))
+	s, err := bc.StateByHeight(ta.Addrinfo["charlie"].RawAddress, 1)
+	require.NoError(err)
+	require.Equal(big.NewInt(50), s.Balance)
+	require.Equal(uint64(0), s.Nonce)
+	s, err = bc.StateByHeight(ta.Addrinfo["charlie"].RawAddress, 2)
+	require.NoError(err)
+	require.Equal(big.NewInt(45), s.Balance)
+	require.Equal(uint64(5), s.Nonce)
+
+	// the states of the tip are the current states
+	s, err = bc.StateByHeight(ta.Addrinfo["foxtrot"].RawAddress, 4)
+	require.NoError(err)
+	current, err := bc.StateByAddr(ta.Addrinfo["foxtrot"].RawAddress)
+	require.NoError(err)
+	require.Equal(current, s)
+
+	_, err = bc.StateByHeight(ta.Addrinfo["foxtrot"].RawAddress, 5)
+	require.Error(err)
+
+	// the proof of the state verifies against the state root in the header
+	blk, err := bc.GetBlockByHeight(1)
+	require.NoError(err)
+	proof, err := bc.StateProof(ta.Addrinfo["charlie"].RawAddress, 1)
+	require.NoError(err)
+	s, err = state.VerifyStateProof(blk.Header.StateRoot(), ta.Addrinfo["charlie"].RawAddress, proof, true)
+	require.NoError(err)
+	require.Equal(big.NewInt(50), s.Balance)
+	_, err = bc.StateProof(ta.Addrinfo["charlie"].RawAddress, 5)
+	require.Error(err)
+}
+
+func TestBlockchain_GetActionProofByActionHash(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.Default
+	sf, err := state.NewFactory(&cfg, state.InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+	require.NoError(err)
+	bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), InMemDaoOption())
+	require.NotNil(bc)
+	require.NoError(addTestingTsfBlocks(bc))
+
+	// the transfers and votes in block 4 are proven against its tx root
+	blk, err := bc.GetBlockByHeight(4)
+	require.NoError(err)
+	for _, act := range blk.Actions {
+		h := act.Hash()
+		height, proof, err := bc.GetActionProofByActionHash(h)
+		require.NoError(err)
+		require.Equal(uint64(4), height)
+		require.True(cp.VerifyMerkleProof(blk.Header.TxRoot(), h, proof))
+		require.False(cp.VerifyMerkleProof(blk.Header.StateRoot(), h, proof))
+	}
+	_, _, err = bc.GetActionProofByActionHash(_hash.ZeroHash32B)
+	require.Error(err)
+}
+
 func TestBlocks(t *testing.T) {
 	// This test is used for committing block verify benchmark purpose
 	t.Skip()
@@ -534,14 +953,14 @@ func TestBlocks(t *testing.T) {
 	sf.CreateState(c.RawAddress, uint64(100000))
 
 	for i := 0; i < 10; i++ {
-		tsfs := []*action.Transfer{}
+		tsfs := []action.Action{}
 		for i := 0; i < 1000; i++ {
 			tsf, err := action.NewTransfer(1, big.NewInt(2), a.RawAddress, c.RawAddress)
 			require.NoError(err)
 			tsf, _ = tsf.Sign(a)
 			tsfs = append(tsfs, tsf)
 		}
-		blk, _ := bc.MintNewBlock(tsfs, nil, ta.Addrinfo["producer"], "")
+		blk, _ := bc.MintNewBlock(tsfs, ta.Addrinfo["producer"], "")
 		err := bc.CommitBlock(blk)
 		require.Nil(err)
 	}
@@ -571,20 +990,128 @@ func TestActions(t *testing.T) {
 	sf.CreateState(a.RawAddress, uint64(100000))
 	sf.CreateState(c.RawAddress, uint64(100000))
 
-	val := validator{sf}
-	tsfs := []*action.Transfer{}
-	votes := []*action.Vote{}
+	val := validator{sf: sf}
+	acts := []action.Action{}
 	for i := 0; i < 5000; i++ {
 		tsf, err := action.NewTransfer(1, big.NewInt(2), a.RawAddress, c.RawAddress)
 		require.NoError(err)
 		tsf, _ = tsf.Sign(a)
-		tsfs = append(tsfs, tsf)
+		acts = append(acts, tsf)
 
 		vote, err := action.NewVote(1, a.RawAddress, a.RawAddress)
 		require.NoError(err)
 		vote, _ = vote.Sign(a)
-		votes = append(votes, vote)
+		acts = append(acts, vote)
 	}
-	blk, _ := bc.MintNewBlock(tsfs, votes, ta.Addrinfo["producer"], "")
+	blk, _ := bc.MintNewBlock(acts, ta.Addrinfo["producer"], "")
 	require.Nil(val.Validate(blk, 0, blk.PrevHash()))
 }
+
+func TestBlockchainPruning(t *testing.T) {
+	require := require.New(t)
+
+	testutil.CleanupPath(t, testTriePath)
+	defer testutil.CleanupPath(t, testTriePath)
+	testutil.CleanupPath(t, testDBPath)
+	defer testutil.CleanupPath(t, testDBPath)
+
+	// Disable block reward to make bookkeeping easier
+	defer func(reward uint64) { Gen.BlockReward = reward }(Gen.BlockReward)
+	Gen.BlockReward = uint64(0)
+
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTriePath
+	cfg.Chain.ChainDBPath = testDBPath
+	cfg.Chain.EnablePruning = true
+	cfg.Chain.PruneDepth = 2
+	cfg.Chain.MaxReorgDepth = 1
+
+	sf, err := state.NewFactory(&cfg, state.DefaultTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+	require.NoError(err)
+	bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), BoltDBDaoOption())
+	require.NotNil(bc)
+	require.NoError(addTestingTsfBlocks(bc))
+	root := sf.RootHash()
+	balance, err := bc.Balance(ta.Addrinfo["foxtrot"].RawAddress)
+	require.NoError(err)
+
+	// only the genesis block and the most recent 2 blocks are kept
+	bc.(*blockchain).prune()
+	_, err = bc.GetBlockByHeight(0)
+	require.NoError(err)
+	for i := uint64(1); i <= 2; i++ {
+		_, err = bc.GetBlockByHeight(i)
+		require.Equal(ErrBlockPruned, errors.Cause(err))
+		_, err = bc.GetHashByHeight(i)
+		require.NoError(err)
+	}
+	_, err = bc.GetBlockByHash(_hash.ZeroHash32B)
+	require.Equal(db.ErrNotExist, errors.Cause(err))
+	for i := uint64(3); i <= 4; i++ {
+		_, err = bc.GetBlockByHeight(i)
+		require.NoError(err)
+	}
+	require.NoError(bc.Stop(context.Background()))
+
+	// restart on top of the committed states without replaying the pruned blocks
+	bc = NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), BoltDBDaoOption())
+	require.NotNil(bc)
+	defer func() {
+		require.NoError(bc.Stop(context.Background()))
+	}()
+	require.Equal(root, sf.RootHash())
+	height, err := bc.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(4), height)
+	restored, err := bc.Balance(ta.Addrinfo["foxtrot"].RawAddress)
+	require.NoError(err)
+	require.Equal(balance, restored)
+}
+
+func TestProtocolUpgrade(t *testing.T) {
+	require := require.New(t)
+	producer := ta.Addrinfo["producer"]
+	// the genesis actions are signed by the creator, so the allocation is taken from the testnet genesis
+	doc := `
+blockReward: 10
+transfers:
+    - amount: 10000000
+      recipient: "io1qyqsyqcy5cwwjdgautt98dqutc6ppq7y23j30nxqmszpqw"
+      signature: "d5fd492595e9db5e4aa8dc6d3794b5e2db51310de25d3215a57cdaebe9c917e21d7f3b00f4d148654b3ef8e8f8506d003e210c2fac1ec3c3bf1c1d7f5a90e039347e24b535321701"
+upgrades:
+    - height: 2
+      version: 2
+      features: ["stake"]
+`
+	require.NoError(ioutil.WriteFile(testGenesisPath, []byte(doc), 0644))
+	defer os.Remove(testGenesisPath)
+	cfg := config.Default
+	cfg.Chain.GenesisPath = testGenesisPath
+	bc := NewBlockchain(&cfg, InMemStateFactoryOption(), InMemDaoOption())
+	require.NotNil(bc)
+	defer func() {
+		require.NoError(bc.Stop(context.Background()))
+	}()
+	stake, err := action.NewStake(1, big.NewInt(1), producer.RawAddress, action.StakeIntrinsicGas, nil)
+	require.NoError(err)
+	require.NoError(action.Sign(stake, producer))
+
+	// the stake is rejected before the upgrade
+	_, err = bc.MintNewBlock([]action.Action{stake}, producer, "")
+	require.Equal(state.ErrInactiveFeature, errors.Cause(err))
+	blk, err := bc.MintNewBlock(nil, producer, "")
+	require.NoError(err)
+	require.Equal(uint32(1), blk.Header.version)
+	require.NoError(bc.CommitBlock(blk))
+
+	// the blocks since the upgrade are of the upgraded version
+	blk, err = bc.MintNewBlock([]action.Action{stake}, producer, "")
+	require.NoError(err)
+	require.Equal(uint32(2), blk.Header.version)
+	blk.Header.version = 1
+	require.Equal(ErrInvalidBlock, errors.Cause(bc.ValidateBlock(blk)))
+	blk.Header.version = 2
+	require.NoError(bc.ValidateBlock(blk))
+}
@@ -8,7 +8,9 @@ package blockchain
 
 import (
 	"context"
+	"sync"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 
 	"github.com/iotexproject/iotex-core/db"
@@ -16,6 +18,8 @@ import (
 	"github.com/iotexproject/iotex-core/pkg/hash"
 	"github.com/iotexproject/iotex-core/pkg/lifecycle"
 	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	iproto "github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
 )
 
 const (
@@ -27,18 +31,28 @@ const (
 	blockAddressTransferCountMappingNS = "address<->transfercount"
 	blockAddressVoteMappingNS          = "address<->vote"
 	blockAddressVoteCountMappingNS     = "address<->votecount"
+	blockActionReceiptMappingNS        = "action<->receipt"
+	blockActionBlockMappingNS          = "action<->block"
+	snapshotNS                         = "snapshot"
 )
 
 var (
 	hashPrefix     = []byte("hash.")
 	transferPrefix = []byte("transfer.")
 	votePrefix     = []byte("vote.")
+	receiptPrefix  = []byte("receipt.")
+	actionPrefix   = []byte("action.")
 	heightPrefix   = []byte("height.")
 	// mutate this field is not thread safe, pls only mutate it in putBlock!
 	topHeightKey = []byte("top-height")
 	// mutate this field is not thread safe, pls only mutate it in putBlock!
 	totalTransfersKey  = []byte("total-transfers")
 	totalVotesKey      = []byte("total-votes")
+	prunedHeightKey    = []byte("pruned-height")
+	reindexHeightKey   = []byte("reindex-height")
+	snapshotKey        = []byte("manifest")
+	prevSnapshotKey    = []byte("previous-manifest")
+	sideBlocksKey      = []byte("side-blocks")
 	transferFromPrefix = []byte("transfer-from.")
 	transferToPrefix   = []byte("transfer-to.")
 	voteFromPrefix     = []byte("vote-from.")
@@ -50,6 +64,14 @@ var _ lifecycle.StartStopper = (*blockDAO)(nil)
 type blockDAO struct {
 	kvstore   db.KVStore
 	lifecycle lifecycle.Lifecycle
+	// guards the list of the side blocks, which the pruner updates along with the chain
+	sideMu sync.Mutex
+}
+
+// sideBlock is a block stored off the canonical chain, which is only retrievable by hash
+type sideBlock struct {
+	height uint64
+	hash   hash.Hash32B
 }
 
 // newBlockDAO instantiates a block DAO
@@ -125,6 +147,12 @@ func (dao *blockDAO) getBlockHeight(hash hash.Hash32B) (uint64, error) {
 // getBlock returns a block
 func (dao *blockDAO) getBlock(hash hash.Hash32B) (*Block, error) {
 	value, err := dao.kvstore.Get(blockNS, hash[:])
+	if errors.Cause(err) == db.ErrNotExist {
+		// the indexes of a pruned block are kept, so the block is told apart from an unknown one
+		if height, herr := dao.getBlockHeight(hash); herr == nil && height > 0 && height < dao.getPrunedHeight() {
+			return nil, errors.Wrapf(ErrBlockPruned, "block %x of height %d", hash, height)
+		}
+	}
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get block %x", hash)
 	}
@@ -138,6 +166,15 @@ func (dao *blockDAO) getBlock(hash hash.Hash32B) (*Block, error) {
 	return &blk, nil
 }
 
+// getBlockByHeight returns the block of the given height on the canonical chain
+func (dao *blockDAO) getBlockByHeight(height uint64) (*Block, error) {
+	hash, err := dao.getBlockHash(height)
+	if err != nil {
+		return nil, err
+	}
+	return dao.getBlock(hash)
+}
+
 func (dao *blockDAO) getBlockHashByTransferHash(h hash.Hash32B) (hash.Hash32B, error) {
 	blkHash := hash.ZeroHash32B
 	key := append(transferPrefix, h[:]...)
@@ -321,6 +358,23 @@ func (dao *blockDAO) getVoteCountByRecipientAddress(address string) (uint64, err
 	return enc.MachineEndian.Uint64(value), nil
 }
 
+// getReceiptByActionHash returns the receipt of an action by the action hash
+func (dao *blockDAO) getReceiptByActionHash(h hash.Hash32B) (*state.Receipt, error) {
+	key := append(receiptPrefix, h[:]...)
+	value, err := dao.kvstore.Get(blockActionReceiptMappingNS, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get receipt of action %x", h)
+	}
+	if len(value) == 0 {
+		return nil, errors.Wrapf(db.ErrNotExist, "receipt of action %x missing", h)
+	}
+	receipt := &state.Receipt{}
+	if err := receipt.Deserialize(value); err != nil {
+		return nil, errors.Wrap(err, "failed to deserialize receipt")
+	}
+	return receipt, nil
+}
+
 // getBlockchainHeight returns the blockchain height
 func (dao *blockDAO) getBlockchainHeight() (uint64, error) {
 	value, err := dao.kvstore.Get(blockNS, topHeightKey)
@@ -357,12 +411,445 @@ func (dao *blockDAO) getTotalVotes() (uint64, error) {
 	return enc.MachineEndian.Uint64(value), nil
 }
 
-// putBlock puts a block
-func (dao *blockDAO) putBlock(blk *Block) error {
+// putBlock puts a block onto the canonical chain, along with the receipts of its actions
+func (dao *blockDAO) putBlock(blk *Block, receipts []*state.Receipt) error {
 	batch := dao.kvstore.Batch()
+	if err := dao.putBlockBody(blk, batch); err != nil {
+		return err
+	}
+	if err := dao.putBlockIndex(blk, batch); err != nil {
+		return err
+	}
+	if err := putReceiptBatch(receipts, batch); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
 
-	height := byteutil.Uint64ToBytes(blk.Height())
+// putSideBlock puts a block which is not on the canonical chain, so it is only retrievable by hash
+func (dao *blockDAO) putSideBlock(blk *Block) error {
+	dao.sideMu.Lock()
+	defer dao.sideMu.Unlock()
+
+	batch := dao.kvstore.Batch()
+	if err := dao.putBlockBody(blk, batch); err != nil {
+		return err
+	}
+	sides, err := dao.getSideBlocks()
+	if err != nil {
+		return err
+	}
+	putSideBlocks(append(sides, sideBlock{height: blk.Height(), hash: blk.HashBlock()}), batch)
+	return batch.Commit()
+}
+
+// indexBlock puts a stored side block onto the canonical chain, along with the receipts of its actions
+func (dao *blockDAO) indexBlock(blk *Block, receipts []*state.Receipt) error {
+	dao.sideMu.Lock()
+	defer dao.sideMu.Unlock()
+
+	batch := dao.kvstore.Batch()
+	if err := dao.putBlockIndex(blk, batch); err != nil {
+		return err
+	}
+	if err := putReceiptBatch(receipts, batch); err != nil {
+		return err
+	}
+	sides, err := dao.getSideBlocks()
+	if err != nil {
+		return err
+	}
+	hash := blk.HashBlock()
+	kept := sides[:0]
+	for _, side := range sides {
+		if side.hash != hash {
+			kept = append(kept, side)
+		}
+	}
+	putSideBlocks(kept, batch)
+	return batch.Commit()
+}
+
+// getSideBlocks returns the blocks stored off the canonical chain
+func (dao *blockDAO) getSideBlocks() ([]sideBlock, error) {
+	value, err := dao.kvstore.Get(blockNS, sideBlocksKey)
+	if errors.Cause(err) == db.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get side blocks")
+	}
+	const entryLen = 8 + hash.HashSize
+	if len(value)%entryLen != 0 {
+		return nil, errors.Errorf("invalid side blocks of %d bytes", len(value))
+	}
+	sides := make([]sideBlock, len(value)/entryLen)
+	for i := range sides {
+		entry := value[i*entryLen : (i+1)*entryLen]
+		sides[i].height = enc.MachineEndian.Uint64(entry[:8])
+		copy(sides[i].hash[:], entry[8:])
+	}
+	return sides, nil
+}
+
+// deleteSideBlocks deletes the side blocks below the given height, which include the reverted blocks, along with their
+// hash -> height mappings and the receipts of their actions not on the canonical chain
+func (dao *blockDAO) deleteSideBlocks(height uint64) error {
+	dao.sideMu.Lock()
+	defer dao.sideMu.Unlock()
+
+	sides, err := dao.getSideBlocks()
+	if err != nil {
+		return err
+	}
+	batch := dao.kvstore.Batch()
+	kept := sides[:0]
+	for _, side := range sides {
+		if side.height >= height {
+			kept = append(kept, side)
+			continue
+		}
+		blk, err := dao.getBlock(side.hash)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get side block %x", side.hash)
+		}
+		for _, act := range blk.Actions {
+			actHash := act.Hash()
+			// the receipt of an action put onto the canonical chain by another block is kept
+			canonical, err := dao.hasKey(blockActionBlockMappingNS, append(actionPrefix, actHash[:]...))
+			if err != nil {
+				return err
+			}
+			receiptKey := append(receiptPrefix, actHash[:]...)
+			exist, err := dao.hasKey(blockActionReceiptMappingNS, receiptKey)
+			if err != nil {
+				return err
+			}
+			if !canonical && exist {
+				batch.Delete(blockActionReceiptMappingNS, receiptKey, "failed to delete receipt of action %x", actHash)
+			}
+		}
+		batch.Delete(blockNS, side.hash[:], "failed to delete side block %x", side.hash)
+		hashKey := append(hashPrefix, side.hash[:]...)
+		batch.Delete(blockHashHeightMappingNS, hashKey, "failed to delete hash -> height mapping of %x", side.hash)
+	}
+	if len(kept) == len(sides) {
+		return nil
+	}
+	putSideBlocks(kept, batch)
+	return batch.Commit()
+}
+
+// deleteTipBlock removes the tip block from the canonical chain, the block itself is kept as a side block
+func (dao *blockDAO) deleteTipBlock() error {
+	dao.sideMu.Lock()
+	defer dao.sideMu.Unlock()
+
+	batch := dao.kvstore.Batch()
+
+	topHeight, err := dao.getBlockchainHeight()
+	if err != nil {
+		return err
+	}
+	if topHeight == 0 {
+		return errors.New("cannot delete genesis block")
+	}
+	hash, err := dao.getBlockHash(topHeight)
+	if err != nil {
+		return err
+	}
+	blk, err := dao.getBlock(hash)
+	if err != nil {
+		return err
+	}
+
+	heightKey := append(heightPrefix, byteutil.Uint64ToBytes(topHeight)...)
+	batch.Delete(blockHashHeightMappingNS, heightKey, "failed to delete height -> hash mapping")
+	batch.Put(blockNS, topHeightKey, byteutil.Uint64ToBytes(topHeight-1), "failed to put top height")
+
+	totalTransfers, err := dao.getTotalTransfers()
+	if err != nil {
+		return err
+	}
+	totalTransfers -= uint64(len(blk.Transfers()))
+	batch.Put(blockNS, totalTransfersKey, byteutil.Uint64ToBytes(totalTransfers), "failed to put total transfers")
+
+	totalVotes, err := dao.getTotalVotes()
+	if err != nil {
+		return err
+	}
+	totalVotes -= uint64(len(blk.Votes()))
+	batch.Put(blockNS, totalVotesKey, byteutil.Uint64ToBytes(totalVotes), "failed to put total votes")
+
+	for _, transfer := range blk.Transfers() {
+		transferHash := transfer.Hash()
+		hashKey := append(transferPrefix, transferHash[:]...)
+		batch.Delete(blockTransferBlockMappingNS, hashKey, "failed to delete transfer hash %x", transferHash)
+	}
+	for _, vote := range blk.Votes() {
+		voteHash := vote.Hash()
+		hashKey := append(votePrefix, voteHash[:]...)
+		batch.Delete(blockVoteBlockMappingNS, hashKey, "failed to delete vote hash %x", voteHash)
+	}
+	for _, act := range blk.Actions {
+		actHash := act.Hash()
+		receiptKey := append(receiptPrefix, actHash[:]...)
+		batch.Delete(blockActionReceiptMappingNS, receiptKey, "failed to delete receipt of action %x", actHash)
+	}
+	if err := dao.deleteActionMappings(blk, batch); err != nil {
+		return err
+	}
+
+	if err := deleteTransfers(dao, blk, batch); err != nil {
+		return err
+	}
+	if err := deleteVotes(dao, blk, batch); err != nil {
+		return err
+	}
+	sides, err := dao.getSideBlocks()
+	if err != nil {
+		return err
+	}
+	putSideBlocks(append(sides, sideBlock{height: topHeight, hash: hash}), batch)
+
+	return batch.Commit()
+}
+
+// pruneBlocks deletes the bodies of the blocks below the given height except the genesis block, the mappings and
+// indexes of the pruned blocks on the canonical chain are kept. The side blocks below the height are deleted entirely
+func (dao *blockDAO) pruneBlocks(height uint64) error {
+	if err := dao.deleteSideBlocks(height); err != nil {
+		return err
+	}
+	start := uint64(1)
+	if pruned := dao.getPrunedHeight(); pruned > 0 {
+		start = pruned
+	}
+	if start >= height {
+		return nil
+	}
+	batch := dao.kvstore.Batch()
+	for i := start; i < height; i++ {
+		hash, err := dao.getBlockHash(i)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get hash of block %d", i)
+		}
+		batch.Delete(blockNS, hash[:], "failed to delete block %d", i)
+	}
+	batch.Put(blockNS, prunedHeightKey, byteutil.Uint64ToBytes(height), "failed to put pruned height")
+	return batch.Commit()
+}
 
+// getSnapshotManifest returns the manifest of the latest snapshot
+func (dao *blockDAO) getSnapshotManifest() (*SnapshotManifest, error) {
+	return dao.getManifest(snapshotKey)
+}
+
+// getPrevSnapshotManifest returns the manifest of the snapshot before the latest one, whose chunks are kept for the
+// fast syncs started before the latest snapshot
+func (dao *blockDAO) getPrevSnapshotManifest() (*SnapshotManifest, error) {
+	return dao.getManifest(prevSnapshotKey)
+}
+
+// getSnapshotChunk returns a chunk of the latest or the previous snapshot
+func (dao *blockDAO) getSnapshotChunk(h hash.Hash32B) ([]byte, error) {
+	value, err := dao.kvstore.Get(snapshotNS, h[:])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get snapshot chunk %x", h)
+	}
+	return value, nil
+}
+
+// putSnapshotChunk puts a chunk of the snapshot being taken or restored
+func (dao *blockDAO) putSnapshotChunk(h hash.Hash32B, data []byte) error {
+	return dao.kvstore.Put(snapshotNS, h[:], data)
+}
+
+// putSnapshotManifest puts the manifest of a snapshot whose chunks are put as the latest one, and keeps the latest one
+// as the previous one. The chunks of the snapshot before them are deleted, unless they are shared with either
+func (dao *blockDAO) putSnapshotManifest(m *SnapshotManifest) error {
+	value, err := proto.Marshal(m.ConvertToSnapshotManifestPb())
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal snapshot manifest")
+	}
+	batch := dao.kvstore.Batch()
+	latest, err := dao.getSnapshotManifest()
+	if err == nil {
+		latestValue, err := proto.Marshal(latest.ConvertToSnapshotManifestPb())
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal snapshot manifest")
+		}
+		batch.Put(snapshotNS, prevSnapshotKey, latestValue, "failed to put previous snapshot manifest")
+	}
+	if prev, err := dao.getPrevSnapshotManifest(); err == nil {
+		kept := []*SnapshotManifest{m}
+		if latest != nil {
+			kept = append(kept, latest)
+		}
+		dao.deleteSnapshotChunks(batch, prev.Chunks, kept...)
+	}
+	batch.Put(snapshotNS, snapshotKey, value, "failed to put snapshot manifest")
+	return batch.Commit()
+}
+
+// discardSnapshotChunks deletes the chunks of a snapshot which is not published, unless they are shared with the latest
+// or the previous snapshot
+func (dao *blockDAO) discardSnapshotChunks(chunks []hash.Hash32B) error {
+	var kept []*SnapshotManifest
+	if latest, err := dao.getSnapshotManifest(); err == nil {
+		kept = append(kept, latest)
+	}
+	if prev, err := dao.getPrevSnapshotManifest(); err == nil {
+		kept = append(kept, prev)
+	}
+	batch := dao.kvstore.Batch()
+	dao.deleteSnapshotChunks(batch, chunks, kept...)
+	return batch.Commit()
+}
+
+// putSnapshotBlock puts the block of a restored snapshot onto the chain, the blocks below it are regarded as pruned
+func (dao *blockDAO) putSnapshotBlock(blk *Block) error {
+	batch := dao.kvstore.Batch()
+	if err := dao.putBlockBody(blk, batch); err != nil {
+		return err
+	}
+	if err := dao.putBlockIndex(blk, batch); err != nil {
+		return err
+	}
+	batch.Put(blockNS, prunedHeightKey, byteutil.Uint64ToBytes(blk.Height()), "failed to put pruned height")
+	return batch.Commit()
+}
+
+// getPrunedHeight returns the height below which the block bodies are pruned, 0 if none is pruned
+func (dao *blockDAO) getPrunedHeight() uint64 {
+	value, err := dao.kvstore.Get(blockNS, prunedHeightKey)
+	if err != nil || len(value) != 8 {
+		return 0
+	}
+	return enc.MachineEndian.Uint64(value)
+}
+
+// getReindexHeight returns the height of the next block to reindex, and false if no reindex is in progress
+func (dao *blockDAO) getReindexHeight() (uint64, bool) {
+	value, err := dao.kvstore.Get(blockNS, reindexHeightKey)
+	if err != nil || len(value) != 8 {
+		return 0, false
+	}
+	return enc.MachineEndian.Uint64(value), true
+}
+
+// startReindex marks a reindex from the genesis block as in progress, before anything is deleted, so the chain DB is not
+// used until the reindex finishes even if it is interrupted
+func (dao *blockDAO) startReindex() error {
+	if pruned := dao.getPrunedHeight(); pruned > 0 {
+		return errors.Wrapf(ErrBlockPruned, "blocks below height %d are pruned", pruned)
+	}
+	return dao.kvstore.Put(blockNS, reindexHeightKey, make([]byte, 8))
+}
+
+// deleteIndexes removes the action indexes, the receipts and the chain totals of all the blocks on the canonical chain.
+// The reindex has to be started beforehand, and the deletion can be repeated if it is interrupted
+func (dao *blockDAO) deleteIndexes() error {
+	if next, ok := dao.getReindexHeight(); !ok || next != 0 {
+		return errors.New("reindex from the genesis block is not started")
+	}
+	topHeight, err := dao.getBlockchainHeight()
+	if err != nil {
+		return err
+	}
+	transferSenders := map[string]bool{}
+	transferRecipients := map[string]bool{}
+	voteSenders := map[string]bool{}
+	voteRecipients := map[string]bool{}
+	for height := uint64(0); height <= topHeight; height++ {
+		blk, err := dao.getBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		batch := dao.kvstore.Batch()
+		for _, transfer := range blk.Transfers() {
+			transferHash := transfer.Hash()
+			hashKey := append(transferPrefix, transferHash[:]...)
+			batch.Delete(blockTransferBlockMappingNS, hashKey, "failed to delete transfer hash %x", transferHash)
+			transferSenders[transfer.Sender] = true
+			transferRecipients[transfer.Recipient] = true
+		}
+		for _, vote := range blk.Votes() {
+			voteHash := vote.Hash()
+			hashKey := append(votePrefix, voteHash[:]...)
+			batch.Delete(blockVoteBlockMappingNS, hashKey, "failed to delete vote hash %x", voteHash)
+			voteSenders[vote.VoterAddress] = true
+			voteRecipients[vote.VoteeAddress] = true
+		}
+		for _, act := range blk.Actions {
+			actHash := act.Hash()
+			receiptKey := append(receiptPrefix, actHash[:]...)
+			batch.Delete(blockActionReceiptMappingNS, receiptKey, "failed to delete receipt of action %x", actHash)
+		}
+		if err := dao.deleteActionMappings(blk, batch); err != nil {
+			return err
+		}
+		if err := batch.Commit(); err != nil {
+			return errors.Wrapf(err, "failed to delete indexes of block %d", height)
+		}
+	}
+
+	batch := dao.kvstore.Batch()
+	deleteAll := func(addresses map[string]bool, indexNS string, countNS string, keyPrefix []byte,
+		getCount func(string) (uint64, error)) error {
+		for address := range addresses {
+			count, err := getCount(address)
+			if err != nil {
+				return errors.Wrapf(err, "for %x", address)
+			}
+			if err := deleteAddressIndex(batch, indexNS, countNS, keyPrefix, address, count, count); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := deleteAll(transferSenders, blockAddressTransferMappingNS, blockAddressTransferCountMappingNS,
+		transferFromPrefix, dao.getTransferCountBySenderAddress); err != nil {
+		return err
+	}
+	if err := deleteAll(transferRecipients, blockAddressTransferMappingNS, blockAddressTransferCountMappingNS,
+		transferToPrefix, dao.getTransferCountByRecipientAddress); err != nil {
+		return err
+	}
+	if err := deleteAll(voteSenders, blockAddressVoteMappingNS, blockAddressVoteCountMappingNS,
+		voteFromPrefix, dao.getVoteCountBySenderAddress); err != nil {
+		return err
+	}
+	if err := deleteAll(voteRecipients, blockAddressVoteMappingNS, blockAddressVoteCountMappingNS,
+		voteToPrefix, dao.getVoteCountByRecipientAddress); err != nil {
+		return err
+	}
+	batch.Put(blockNS, totalTransfersKey, make([]byte, 8), "failed to reset total transfers")
+	batch.Put(blockNS, totalVotesKey, make([]byte, 8), "failed to reset total votes")
+	return batch.Commit()
+}
+
+// reindexBlock puts the action indexes and the receipts of a block on the canonical chain, and moves the reindex
+// height past it
+func (dao *blockDAO) reindexBlock(blk *Block, receipts []*state.Receipt) error {
+	batch := dao.kvstore.Batch()
+	if err := dao.putBlockIndex(blk, batch); err != nil {
+		return err
+	}
+	if err := putReceiptBatch(receipts, batch); err != nil {
+		return err
+	}
+	batch.Put(blockNS, reindexHeightKey, byteutil.Uint64ToBytes(blk.Height()+1), "failed to put reindex height")
+	return batch.Commit()
+}
+
+// finishReindex marks the reindex as completed
+func (dao *blockDAO) finishReindex() error {
+	return dao.kvstore.Delete(blockNS, reindexHeightKey)
+}
+
+// putBlockBody puts the serialized block and its hash -> height mapping
+func (dao *blockDAO) putBlockBody(blk *Block, batch db.KVStoreBatch) error {
 	serialized, err := blk.Serialize()
 	if err != nil {
 		return errors.Wrap(err, "failed to serialize block")
@@ -371,7 +858,14 @@ func (dao *blockDAO) putBlock(blk *Block) error {
 	batch.PutIfNotExists(blockNS, hash[:], serialized, "failed to put block")
 
 	hashKey := append(hashPrefix, hash[:]...)
-	batch.Put(blockHashHeightMappingNS, hashKey, height, "failed to put hash -> height mapping")
+	batch.Put(blockHashHeightMappingNS, hashKey, byteutil.Uint64ToBytes(blk.Height()), "failed to put hash -> height mapping")
+	return nil
+}
+
+// putBlockIndex puts the height -> hash mapping, the chain totals and the action indexes of a block
+func (dao *blockDAO) putBlockIndex(blk *Block, batch db.KVStoreBatch) error {
+	height := byteutil.Uint64ToBytes(blk.Height())
+	hash := blk.HashBlock()
 
 	heightKey := append(heightPrefix, height...)
 	batch.Put(blockHashHeightMappingNS, heightKey, hash[:], "failed to put height -> hash mapping")
@@ -390,7 +884,7 @@ func (dao *blockDAO) putBlock(blk *Block) error {
 		return errors.Wrap(err, "failed to get total transfers")
 	}
 	totalTransfers := enc.MachineEndian.Uint64(value)
-	totalTransfers += uint64(len(blk.Transfers))
+	totalTransfers += uint64(len(blk.Transfers()))
 	totalTransfersBytes := byteutil.Uint64ToBytes(totalTransfers)
 	batch.Put(blockNS, totalTransfersKey, totalTransfersBytes, "failed to put total transfers")
 
@@ -399,39 +893,36 @@ func (dao *blockDAO) putBlock(blk *Block) error {
 		return errors.Wrap(err, "failed to get total votes")
 	}
 	totalVotes := enc.MachineEndian.Uint64(value)
-	totalVotes += uint64(len(blk.Votes))
+	totalVotes += uint64(len(blk.Votes()))
 	totalVotesBytes := byteutil.Uint64ToBytes(totalVotes)
 	batch.Put(blockNS, totalVotesKey, totalVotesBytes, "failed to put total votes")
 
 	// map Transfer hash to block hash
-	for _, transfer := range blk.Transfers {
+	for _, transfer := range blk.Transfers() {
 		transferHash := transfer.Hash()
 		hashKey := append(transferPrefix, transferHash[:]...)
 		batch.Put(blockTransferBlockMappingNS, hashKey, hash[:], "failed to put transfer hash %x", transferHash)
 	}
 
 	// map Vote hash to block hash
-	for _, vote := range blk.Votes {
+	for _, vote := range blk.Votes() {
 		voteHash := vote.Hash()
 		hashKey := append(votePrefix, voteHash[:]...)
 		batch.Put(blockVoteBlockMappingNS, hashKey, hash[:], "failed to put vote hash %x", voteHash)
 	}
 
-	err = putTransfers(dao, blk, batch)
-	if err != nil {
-		return err
+	// map action hash to block hash, which tells the actions on the canonical chain
+	for _, act := range blk.Actions {
+		actHash := act.Hash()
+		hashKey := append(actionPrefix, actHash[:]...)
+		batch.Put(blockActionBlockMappingNS, hashKey, hash[:], "failed to put action hash %x", actHash)
 	}
 
-	err = putVotes(dao, blk, batch)
-	if err != nil {
+	if err := putTransfers(dao, blk, batch); err != nil {
 		return err
 	}
 
-	if err = batch.Commit(); err != nil {
-		return err
-	}
-
-	return nil
+	return putVotes(dao, blk, batch)
 }
 
 // putTransfers store transfer information into db
@@ -439,7 +930,7 @@ func putTransfers(dao *blockDAO, blk *Block, batch db.KVStoreBatch) error {
 	senderDelta := map[string]uint64{}
 	recipientDelta := map[string]uint64{}
 
-	for _, transfer := range blk.Transfers {
+	for _, transfer := range blk.Transfers() {
 		transferHash := transfer.Hash()
 
 		// get transfers count for sender
@@ -494,12 +985,64 @@ func putTransfers(dao *blockDAO, blk *Block, batch db.KVStoreBatch) error {
 	return nil
 }
 
+// deleteActionMappings deletes the action -> block mappings of a block. The blocks indexed before the mappings were
+// introduced have none, and deleting from a namespace nothing is ever put into fails
+func (dao *blockDAO) deleteActionMappings(blk *Block, batch db.KVStoreBatch) error {
+	for _, act := range blk.Actions {
+		actHash := act.Hash()
+		hashKey := append(actionPrefix, actHash[:]...)
+		exist, err := dao.hasKey(blockActionBlockMappingNS, hashKey)
+		if err != nil {
+			return err
+		}
+		if exist {
+			batch.Delete(blockActionBlockMappingNS, hashKey, "failed to delete action hash %x", actHash)
+		}
+	}
+	return nil
+}
+
+// hasKey tells whether the key exists in the namespace
+func (dao *blockDAO) hasKey(namespace string, key []byte) (bool, error) {
+	_, err := dao.kvstore.Get(namespace, key)
+	if errors.Cause(err) == db.ErrNotExist {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get %x in %s", key, namespace)
+	}
+	return true, nil
+}
+
+// putSideBlocks puts the list of the side blocks into the batch
+func putSideBlocks(sides []sideBlock, batch db.KVStoreBatch) {
+	value := make([]byte, 0, len(sides)*(8+hash.HashSize))
+	for _, side := range sides {
+		value = append(value, byteutil.Uint64ToBytes(side.height)...)
+		value = append(value, side.hash[:]...)
+	}
+	batch.Put(blockNS, sideBlocksKey, value, "failed to put side blocks")
+}
+
+// putReceiptBatch puts the receipts keyed by the action hashes into the batch
+func putReceiptBatch(receipts []*state.Receipt, batch db.KVStoreBatch) error {
+	for _, receipt := range receipts {
+		serialized, err := receipt.Serialize()
+		if err != nil {
+			return errors.Wrapf(err, "failed to serialize receipt of action %x", receipt.ActionHash)
+		}
+		key := append(receiptPrefix, receipt.ActionHash[:]...)
+		batch.Put(blockActionReceiptMappingNS, key, serialized, "failed to put receipt of action %x", receipt.ActionHash)
+	}
+	return nil
+}
+
 // putVotes store vote information into db
 func putVotes(dao *blockDAO, blk *Block, batch db.KVStoreBatch) error {
 	senderDelta := map[string]uint64{}
 	recipientDelta := map[string]uint64{}
 
-	for _, vote := range blk.Votes {
+	for _, vote := range blk.Votes() {
 		voteHash := vote.Hash()
 
 		Sender := vote.VoterAddress
@@ -556,3 +1099,119 @@ func putVotes(dao *blockDAO, blk *Block, batch db.KVStoreBatch) error {
 
 	return nil
 }
+
+// deleteTransfers removes the transfer information of a block from db
+func deleteTransfers(dao *blockDAO, blk *Block, batch db.KVStoreBatch) error {
+	senderDelta := map[string]uint64{}
+	recipientDelta := map[string]uint64{}
+	for _, transfer := range blk.Transfers() {
+		senderDelta[transfer.Sender]++
+		recipientDelta[transfer.Recipient]++
+	}
+
+	for sender, delta := range senderDelta {
+		count, err := dao.getTransferCountBySenderAddress(sender)
+		if err != nil {
+			return errors.Wrapf(err, "for sender %x", sender)
+		}
+		if err := deleteAddressIndex(batch, blockAddressTransferMappingNS, blockAddressTransferCountMappingNS,
+			transferFromPrefix, sender, count, delta); err != nil {
+			return err
+		}
+	}
+
+	for recipient, delta := range recipientDelta {
+		count, err := dao.getTransferCountByRecipientAddress(recipient)
+		if err != nil {
+			return errors.Wrapf(err, "for recipient %x", recipient)
+		}
+		if err := deleteAddressIndex(batch, blockAddressTransferMappingNS, blockAddressTransferCountMappingNS,
+			transferToPrefix, recipient, count, delta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteVotes removes the vote information of a block from db
+func deleteVotes(dao *blockDAO, blk *Block, batch db.KVStoreBatch) error {
+	senderDelta := map[string]uint64{}
+	recipientDelta := map[string]uint64{}
+	for _, vote := range blk.Votes() {
+		senderDelta[vote.VoterAddress]++
+		recipientDelta[vote.VoteeAddress]++
+	}
+
+	for sender, delta := range senderDelta {
+		count, err := dao.getVoteCountBySenderAddress(sender)
+		if err != nil {
+			return errors.Wrapf(err, "for sender %x", sender)
+		}
+		if err := deleteAddressIndex(batch, blockAddressVoteMappingNS, blockAddressVoteCountMappingNS,
+			voteFromPrefix, sender, count, delta); err != nil {
+			return err
+		}
+	}
+
+	for recipient, delta := range recipientDelta {
+		count, err := dao.getVoteCountByRecipientAddress(recipient)
+		if err != nil {
+			return errors.Wrapf(err, "for recipient %x", recipient)
+		}
+		if err := deleteAddressIndex(batch, blockAddressVoteMappingNS, blockAddressVoteCountMappingNS,
+			voteToPrefix, recipient, count, delta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteAddressIndex removes the last delta entries of an address index, and decreases the index count accordingly
+func deleteAddressIndex(batch db.KVStoreBatch, indexNS string, countNS string, keyPrefix []byte, address string,
+	count uint64, delta uint64) error {
+	if delta > count {
+		return errors.Errorf("index of %x has %d entries, cannot delete %d", address, count, delta)
+	}
+	for i := count - delta; i < count; i++ {
+		key := append(keyPrefix, address...)
+		key = append(key, byteutil.Uint64ToBytes(i)...)
+		batch.Delete(indexNS, key, "failed to delete index %d of %x", i, address)
+	}
+	countKey := append(keyPrefix, address...)
+	batch.Put(countNS, countKey, byteutil.Uint64ToBytes(count-delta), "failed to update index count of %x", address)
+	return nil
+}
+
+// getManifest returns the snapshot manifest of the key
+func (dao *blockDAO) getManifest(key []byte) (*SnapshotManifest, error) {
+	value, err := dao.kvstore.Get(snapshotNS, key)
+	if err != nil || len(value) == 0 {
+		return nil, errors.Wrap(ErrNoSnapshot, "snapshot manifest missing")
+	}
+	pbManifest := &iproto.SnapshotManifestPb{}
+	if err := proto.Unmarshal(value, pbManifest); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal snapshot manifest")
+	}
+	m := &SnapshotManifest{}
+	if err := m.ConvertFromSnapshotManifestPb(pbManifest); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// deleteSnapshotChunks puts the deletes of the chunks not in the kept snapshots into the batch
+func (dao *blockDAO) deleteSnapshotChunks(batch db.KVStoreBatch, chunks []hash.Hash32B, kept ...*SnapshotManifest) {
+	keep := make(map[hash.Hash32B]struct{})
+	for _, m := range kept {
+		for _, h := range m.Chunks {
+			keep[h] = struct{}{}
+		}
+	}
+	for _, h := range chunks {
+		if _, ok := keep[h]; !ok {
+			batch.Delete(snapshotNS, h[:], "failed to delete snapshot chunk %x", h)
+		}
+	}
+}
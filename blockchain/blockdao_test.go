@@ -11,13 +11,17 @@ import (
 	"hash/fnv"
 	"math/big"
 	"math/rand"
+	"strconv"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/iotex-core/blockchain/action"
 	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/test/testaddress"
 	"github.com/iotexproject/iotex-core/testutil"
 )
@@ -35,13 +39,13 @@ func TestBlockDAO(t *testing.T) {
 
 		hash1 := hash.Hash32B{}
 		fnv.New32().Sum(hash1[:])
-		blk1 := NewBlock(0, 1, hash1, []*action.Transfer{cbTsf1}, nil)
+		blk1 := NewBlock(0, 1, hash1, []action.Action{cbTsf1})
 		hash2 := hash.Hash32B{}
 		fnv.New32().Sum(hash2[:])
-		blk2 := NewBlock(0, 2, hash2, []*action.Transfer{cbTsf2}, nil)
+		blk2 := NewBlock(0, 2, hash2, []action.Action{cbTsf2})
 		hash3 := hash.Hash32B{}
 		fnv.New32().Sum(hash3[:])
-		blk3 := NewBlock(0, 3, hash3, []*action.Transfer{cbTsf3}, nil)
+		blk3 := NewBlock(0, 3, hash3, []action.Action{cbTsf3})
 		return []*Block{blk1, blk2, blk3}
 	}
 
@@ -63,32 +67,37 @@ func TestBlockDAO(t *testing.T) {
 		assert.Equal(t, uint64(0), height)
 
 		// block put order is 0 2 1
-		err = dao.putBlock(blks[0])
+		err = dao.putBlock(blks[0], nil)
 		assert.Nil(t, err)
 		blk, err := dao.getBlock(blks[0].HashBlock())
 		assert.Nil(t, err)
 		assert.NotNil(t, blk)
-		assert.Equal(t, blks[0].Transfers[0].Hash(), blk.Transfers[0].Hash())
+		assert.Equal(t, blks[0].Transfers()[0].Hash(), blk.Transfers()[0].Hash())
 		height, err = dao.getBlockchainHeight()
 		assert.Nil(t, err)
 		assert.Equal(t, uint64(1), height)
 
-		err = dao.putBlock(blks[2])
+		receipt := &state.Receipt{
+			ActionHash:    blks[2].Transfers()[0].Hash(),
+			Status:        state.ReceiptStatusSuccess,
+			BalanceDeltas: []*state.Delta{{Address: testaddress.Addrinfo["charlie"].RawAddress, Amount: big.NewInt(7)}},
+		}
+		err = dao.putBlock(blks[2], []*state.Receipt{receipt})
 		assert.Nil(t, err)
 		blk, err = dao.getBlock(blks[2].HashBlock())
 		assert.Nil(t, err)
 		assert.NotNil(t, blk)
-		assert.Equal(t, blks[2].Transfers[0].Hash(), blk.Transfers[0].Hash())
+		assert.Equal(t, blks[2].Transfers()[0].Hash(), blk.Transfers()[0].Hash())
 		height, err = dao.getBlockchainHeight()
 		assert.Nil(t, err)
 		assert.Equal(t, uint64(3), height)
 
-		err = dao.putBlock(blks[1])
+		err = dao.putBlock(blks[1], nil)
 		assert.Nil(t, err)
 		blk, err = dao.getBlock(blks[1].HashBlock())
 		assert.Nil(t, err)
 		assert.NotNil(t, blk)
-		assert.Equal(t, blks[1].Transfers[0].Hash(), blk.Transfers[0].Hash())
+		assert.Equal(t, blks[1].Transfers()[0].Hash(), blk.Transfers()[0].Hash())
 		height, err = dao.getBlockchainHeight()
 		assert.Nil(t, err)
 		assert.Equal(t, uint64(3), height)
@@ -118,6 +127,89 @@ func TestBlockDAO(t *testing.T) {
 		height, err = dao.getBlockHeight(blks[2].HashBlock())
 		assert.Nil(t, err)
 		assert.Equal(t, blks[2].Height(), height)
+
+		// test getting receipt by action hash
+		r, err := dao.getReceiptByActionHash(receipt.ActionHash)
+		assert.Nil(t, err)
+		assert.Equal(t, receipt, r)
+
+		// delete the tip block, which is kept as a side block
+		err = dao.deleteTipBlock()
+		assert.Nil(t, err)
+		height, err = dao.getBlockchainHeight()
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2), height)
+		_, err = dao.getBlockHash(3)
+		assert.NotNil(t, err)
+		blk, err = dao.getBlock(blks[2].HashBlock())
+		assert.Nil(t, err)
+		assert.Equal(t, blks[2].HashBlock(), blk.HashBlock())
+		_, err = dao.getBlockHashByTransferHash(blks[2].Transfers()[0].Hash())
+		assert.NotNil(t, err)
+		_, err = dao.getReceiptByActionHash(receipt.ActionHash)
+		assert.NotNil(t, err)
+		transfers, err := dao.getTransfersByRecipientAddress(testaddress.Addrinfo["charlie"].RawAddress)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(transfers))
+		totalTransfers, err := dao.getTotalTransfers()
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2), totalTransfers)
+
+		// put the side block back onto the canonical chain
+		err = dao.indexBlock(blks[2], []*state.Receipt{receipt})
+		assert.Nil(t, err)
+		r, err = dao.getReceiptByActionHash(receipt.ActionHash)
+		assert.Nil(t, err)
+		assert.Equal(t, receipt, r)
+		height, err = dao.getBlockchainHeight()
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(3), height)
+		hash, err = dao.getBlockHash(3)
+		assert.Nil(t, err)
+		assert.Equal(t, blks[2].HashBlock(), hash)
+		hash, err = dao.getBlockHashByTransferHash(blks[2].Transfers()[0].Hash())
+		assert.Nil(t, err)
+		assert.Equal(t, blks[2].HashBlock(), hash)
+		transfers, err = dao.getTransfersByRecipientAddress(testaddress.Addrinfo["charlie"].RawAddress)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(transfers))
+
+		// a side block is only retrievable by hash
+		cbTsf := action.NewCoinBaseTransfer(big.NewInt(1), testaddress.Addrinfo["delta"].RawAddress)
+		sideBlk := NewBlock(0, 3, blks[1].HashBlock(), []action.Action{cbTsf})
+		err = dao.putSideBlock(sideBlk)
+		assert.Nil(t, err)
+		blk, err = dao.getBlock(sideBlk.HashBlock())
+		assert.Nil(t, err)
+		assert.Equal(t, sideBlk.HashBlock(), blk.HashBlock())
+		height, err = dao.getBlockHeight(sideBlk.HashBlock())
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(3), height)
+		hash, err = dao.getBlockHash(3)
+		assert.Nil(t, err)
+		assert.Equal(t, blks[2].HashBlock(), hash)
+		_, err = dao.getBlockHashByTransferHash(cbTsf.Hash())
+		assert.NotNil(t, err)
+
+		// prune the bodies of the blocks below height 3, the mappings are kept
+		err = dao.pruneBlocks(3)
+		assert.Nil(t, err)
+		_, err = dao.getBlock(blks[0].HashBlock())
+		assert.NotNil(t, err)
+		_, err = dao.getBlock(blks[1].HashBlock())
+		assert.NotNil(t, err)
+		blk, err = dao.getBlock(blks[2].HashBlock())
+		assert.Nil(t, err)
+		assert.Equal(t, blks[2].HashBlock(), blk.HashBlock())
+		hash, err = dao.getBlockHash(1)
+		assert.Nil(t, err)
+		assert.Equal(t, blks[0].HashBlock(), hash)
+		height, err = dao.getBlockHeight(blks[1].HashBlock())
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2), height)
+		// pruning again up to a lower height is a no-op
+		err = dao.pruneBlocks(2)
+		assert.Nil(t, err)
 	}
 
 	t.Run("In-memory KV Store", func(t *testing.T) {
@@ -132,3 +224,79 @@ func TestBlockDAO(t *testing.T) {
 	})
 
 }
+
+func TestBlockDAO_PruneSideBlocks(t *testing.T) {
+	testPrune := func(kvstore db.KVStore, t *testing.T) {
+		require := require.New(t)
+		ctx := context.Background()
+		dao := newBlockDAO(kvstore)
+		require.NoError(dao.Start(ctx))
+		defer func() {
+			require.NoError(dao.Stop(ctx))
+		}()
+
+		coinbase := func(name string) action.Action {
+			return action.NewCoinBaseTransfer(big.NewInt(1), testaddress.Addrinfo[name].RawAddress)
+		}
+		receipt := func(act action.Action) *state.Receipt {
+			return &state.Receipt{ActionHash: act.Hash(), Status: state.ReceiptStatusSuccess}
+		}
+		cbTsf1, cbTsf2, cbTsf3, cbTsf4 := coinbase("alfa"), coinbase("bravo"), coinbase("charlie"), coinbase("delta")
+		blk1 := NewBlock(0, 1, hash.Hash32B{}, []action.Action{cbTsf1})
+		blk2 := NewBlock(0, 2, blk1.HashBlock(), []action.Action{cbTsf2})
+		for _, blk := range []*Block{blk1, blk2} {
+			require.NoError(dao.putBlock(blk, []*state.Receipt{receipt(blk.Actions[0])}))
+		}
+
+		// a block reverted from the canonical chain is kept as a side block
+		reverted := NewBlock(0, 3, blk2.HashBlock(), []action.Action{cbTsf3})
+		require.NoError(dao.putBlock(reverted, []*state.Receipt{receipt(cbTsf3)}))
+		require.NoError(dao.deleteTipBlock())
+		blk3 := NewBlock(0, 3, blk2.HashBlock(), []action.Action{coinbase("echo")})
+		blk4 := NewBlock(0, 4, blk3.HashBlock(), []action.Action{coinbase("foxtrot")})
+		for _, blk := range []*Block{blk3, blk4} {
+			require.NoError(dao.putBlock(blk, []*state.Receipt{receipt(blk.Actions[0])}))
+		}
+		// a side block shares an action with the canonical chain, and the receipt of its other action is left over
+		side := NewBlock(0, 2, blk1.HashBlock(), []action.Action{cbTsf2, cbTsf4})
+		require.NoError(dao.putSideBlock(side))
+		batch := kvstore.Batch()
+		require.NoError(putReceiptBatch([]*state.Receipt{receipt(cbTsf4)}, batch))
+		require.NoError(batch.Commit())
+
+		// the side blocks below the height are deleted entirely, while the canonical blocks keep their mappings
+		require.NoError(dao.pruneBlocks(4))
+		for _, blk := range []*Block{reverted, side} {
+			_, err := dao.getBlock(blk.HashBlock())
+			require.Error(err)
+			_, err = dao.getBlockHeight(blk.HashBlock())
+			require.Error(err)
+		}
+		sides, err := dao.getSideBlocks()
+		require.NoError(err)
+		require.Empty(sides)
+		for _, act := range []action.Action{cbTsf3, cbTsf4} {
+			_, err = dao.getReceiptByActionHash(act.Hash())
+			require.Error(err)
+		}
+		r, err := dao.getReceiptByActionHash(cbTsf2.Hash())
+		require.NoError(err)
+		require.Equal(receipt(cbTsf2), r)
+		height, err := dao.getBlockHeight(blk2.HashBlock())
+		require.NoError(err)
+		require.Equal(uint64(2), height)
+		_, err = dao.getBlock(blk2.HashBlock())
+		require.Equal(ErrBlockPruned, errors.Cause(err))
+	}
+
+	t.Run("In-memory KV Store", func(t *testing.T) {
+		testPrune(db.NewMemKVStore(), t)
+	})
+
+	path := "/tmp/test-prune-side-blocks-" + strconv.Itoa(rand.Int())
+	t.Run("Bolt DB", func(t *testing.T) {
+		testutil.CleanupPath(t, path)
+		defer testutil.CleanupPath(t, path)
+		testPrune(db.NewBoltDB(path, nil), t)
+	})
+}
@@ -11,13 +11,14 @@ import (
 	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/iotexproject/iotex-core/blockchain/action"
-	cp "github.com/iotexproject/iotex-core/crypto"
 	"github.com/iotexproject/iotex-core/iotxaddress"
 	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/version"
 	"github.com/iotexproject/iotex-core/state"
 )
 
@@ -28,7 +29,8 @@ type Validator interface {
 }
 
 type validator struct {
-	sf state.Factory
+	sf       state.Factory
+	schedule version.Schedule
 }
 
 var (
@@ -38,8 +40,18 @@ var (
 	ErrInvalidBlock = errors.New("failed to validate the block")
 	// ErrActionNonce is the error when the nonce of the action is wrong
 	ErrActionNonce = errors.New("invalid action nonce")
+	// ErrInvalidStateRoot is the error when the state root in block header does not match the actual states
+	ErrInvalidStateRoot = errors.New("invalid state root")
+	// ErrPrematureAction is the error when the action is scheduled after the block
+	ErrPrematureAction = errors.New("premature action")
+	// ErrInvalidTimestamp is the error when the block is timestamped before its parent or too far in the future
+	ErrInvalidTimestamp = errors.New("invalid block timestamp")
 )
 
+// maxTimestampDrift is how far the timestamp of a block may be ahead of the local clock. The scheduled actions are
+// eligible by the timestamp, so it must not be set at will by the producer
+const maxTimestampDrift = 10 * time.Second
+
 // Validate validates the given block's content
 func (v *validator) Validate(blk *Block, tipHeight uint64, tipHash hash.Hash32B) error {
 	if blk == nil {
@@ -62,10 +74,18 @@ func (v *validator) Validate(blk *Block, tipHeight uint64, tipHash hash.Hash32B)
 			tipHash)
 	}
 
+	// verify new block is of the protocol version at its height
+	if expected := v.schedule.Version(blk.Header.height); blk.Header.version != expected {
+		return errors.Wrapf(
+			ErrInvalidBlock,
+			"Wrong protocol version %d, expecting %d",
+			blk.Header.version,
+			expected)
+	}
+
 	if blk.Header.height > 0 {
 		// verify new block's signature is correct
-		blkHash := blk.HashBlock()
-		if !cp.Verify(blk.Header.Pubkey, blkHash[:], blk.Header.blockSig) {
+		if !blk.VerifySignature() {
 			return errors.Wrapf(
 				ErrInvalidBlock,
 				"Fail to verify block's signature with public key: %x",
@@ -85,38 +105,77 @@ func (v *validator) Validate(blk *Block, tipHeight uint64, tipHash hash.Hash32B)
 	}
 
 	if v.sf != nil {
-		return v.verifyActions(blk)
+		if err := v.verifyActions(blk); err != nil {
+			return err
+		}
+		return v.verifyStateRoot(blk)
 	}
 
 	return nil
 }
 
+// verifyStateRoot checks the state root in the block header matches the states after running the block's actions
+func (v *validator) verifyStateRoot(blk *Block) error {
+	root, err := v.sf.RunActions(blk.Header.height, blk.Actions)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to run actions of block %d", blk.Header.height)
+	}
+	if root != blk.Header.stateRoot {
+		return errors.Wrapf(
+			ErrInvalidStateRoot,
+			"Wrong state root %x, expecting %x",
+			blk.Header.stateRoot,
+			root)
+	}
+	return nil
+}
+
 func (v *validator) verifyActions(blk *Block) error {
-	// Verify transfers and votes (balance is checked in CommitStateChanges)
+	// Verify actions (balance is checked in CommitStateChanges)
 	confirmedNonceMap := make(map[string]uint64)
 	accountNonceMap := make(map[string][]uint64)
 	var wg sync.WaitGroup
-	wg.Add(len(blk.Transfers) + len(blk.Votes))
+	wg.Add(len(blk.Actions))
 	var correctAction uint64
 	var coinbaseCount uint64
-	for _, tsf := range blk.Transfers {
-		if blk.Header.height > 0 && !tsf.IsCoinbase {
+	for _, act := range blk.Actions {
+		tsf, isTsf := act.(*action.Transfer)
+		isCoinbase := isTsf && tsf.IsCoinbase
+		if blk.Header.height > 0 && !isCoinbase {
+			// Verify the gas limit covers the intrinsic gas, the genesis actions are not charged
+			if act.GetGasLimit() < act.IntrinsicGas() {
+				return errors.Wrapf(action.ErrInsufficientGas, "gas limit %d of action %x is lower than %d",
+					act.GetGasLimit(), act.Hash(), act.IntrinsicGas())
+			}
+			// Verify the features the action requires are in effect
+			for _, feature := range action.RequiredFeatures(act) {
+				if !v.schedule.IsActive(feature, blk.Header.height) {
+					return errors.Wrapf(state.ErrInactiveFeature, "action %x requires %s at height %d", act.Hash(),
+						feature, blk.Header.height)
+				}
+			}
+			// Verify the action is not scheduled after the block
+			if !action.IsEligible(act, blk.Header.height, blk.Header.timestamp) {
+				return errors.Wrapf(ErrPrematureAction, "action %x is not eligible at height %d", act.Hash(),
+					blk.Header.height)
+			}
 			// Store the nonce of the sender and verify later
-			if _, ok := confirmedNonceMap[tsf.Sender]; !ok {
-				accountNonce, err := v.sf.Nonce(tsf.Sender)
+			sender := act.SrcAddr()
+			if _, ok := confirmedNonceMap[sender]; !ok {
+				accountNonce, err := v.sf.Nonce(sender)
 				if err != nil {
-					return errors.Wrap(err, "Failed to get the nonce of transfer sender")
+					return errors.Wrap(err, "Failed to get the nonce of the action sender")
 				}
-				confirmedNonceMap[tsf.Sender] = accountNonce
-				accountNonceMap[tsf.Sender] = make([]uint64, 0)
+				confirmedNonceMap[sender] = accountNonce
+				accountNonceMap[sender] = make([]uint64, 0)
 			}
-			accountNonceMap[tsf.Sender] = append(accountNonceMap[tsf.Sender], tsf.Nonce)
+			accountNonceMap[sender] = append(accountNonceMap[sender], act.GetNonce())
 		}
 
-		go func(tsf *action.Transfer, correctTsf *uint64, correctCoinbase *uint64) {
+		go func(act action.Action, correctAct *uint64, correctCoinbase *uint64) {
 			defer wg.Done()
 			// Verify coinbase transfer
-			if tsf.IsCoinbase {
+			if tsf, ok := act.(*action.Transfer); ok && tsf.IsCoinbase {
 				address, err := iotxaddress.GetAddress(blk.Header.Pubkey, iotxaddress.IsTestnet, iotxaddress.ChainID)
 				if err != nil {
 					return
@@ -129,46 +188,11 @@ func (v *validator) verifyActions(blk *Block) error {
 			}
 
 			// Verify signature
-			address, err := iotxaddress.GetAddress(tsf.SenderPublicKey, iotxaddress.IsTestnet, iotxaddress.ChainID)
-			if err != nil {
-				return
-			}
-			if err := tsf.Verify(address); err != nil {
-				return
-			}
-			atomic.AddUint64(correctTsf, uint64(1))
-		}(tsf, &correctAction, &coinbaseCount)
-	}
-	for _, vote := range blk.Votes {
-		if blk.Header.height > 0 {
-			// Store the nonce of the voter and verify later
-			if _, ok := confirmedNonceMap[vote.VoterAddress]; !ok {
-				accountNonce, err := v.sf.Nonce(vote.VoterAddress)
-				if err != nil {
-					return errors.Wrap(err, "Failed to get the nonce of the voter")
-				}
-				confirmedNonceMap[vote.VoterAddress] = accountNonce
-				accountNonceMap[vote.VoterAddress] = make([]uint64, 0)
-			}
-			accountNonceMap[vote.VoterAddress] = append(accountNonceMap[vote.VoterAddress], vote.Nonce)
-		}
-
-		// Verify signature
-		go func(vote *action.Vote, correctVote *uint64) {
-			defer wg.Done()
-			selfPublicKey, err := vote.SelfPublicKey()
-			if err != nil {
-				return
-			}
-			address, err := iotxaddress.GetAddress(selfPublicKey, iotxaddress.IsTestnet, iotxaddress.ChainID)
-			if err != nil {
-				return
-			}
-			if err := vote.Verify(address); err != nil {
+			if err := action.Verify(act); err != nil {
 				return
 			}
-			atomic.AddUint64(correctVote, uint64(1))
-		}(vote, &correctAction)
+			atomic.AddUint64(correctAct, uint64(1))
+		}(act, &correctAction, &coinbaseCount)
 	}
 	wg.Wait()
 	// Verify coinbase transfer count
@@ -177,7 +201,7 @@ func (v *validator) verifyActions(blk *Block) error {
 			ErrInvalidBlock,
 			"Wrong number of coinbase transfers")
 	}
-	if correctAction+coinbaseCount != uint64(len(blk.Transfers)+len(blk.Votes)) {
+	if correctAction+coinbaseCount != uint64(len(blk.Actions)) {
 		return errors.Wrapf(
 			ErrInvalidBlock,
 			"Failed to verify actions signature")
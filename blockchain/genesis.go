@@ -7,10 +7,13 @@
 package blockchain
 
 import (
+	"bytes"
 	"encoding/hex"
 	"io/ioutil"
 	"math/big"
 
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
 	"gopkg.in/yaml.v2"
 
 	"github.com/iotexproject/iotex-core/blockchain/action"
@@ -19,28 +22,48 @@ import (
 	"github.com/iotexproject/iotex-core/logger"
 	"github.com/iotexproject/iotex-core/pkg/hash"
 	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 	"github.com/iotexproject/iotex-core/pkg/util/fileutil"
 	"github.com/iotexproject/iotex-core/pkg/version"
+	"github.com/iotexproject/iotex-core/state"
 )
 
-const testnetActionPath = "testnet_actions.yaml"
+const testnetGenesisPath = "testnet_genesis.yaml"
 
-// Genesis defines the Genesis default settings
+// Genesis defines the Genesis default settings, which is read from the genesis document along with the genesis actions
+// and the consensus parameters. The hash of the genesis is the previous hash of the genesis block, so the chains of
+// different geneses never share a block, and the nodes of different geneses do not peer with each other
 type Genesis struct {
-	ChainID             uint32
-	TotalSupply         uint64
-	BlockReward         uint64
-	Timestamp           uint64
-	ParentHash          hash.Hash32B
-	GenesisCoinbaseData string
-	CreatorAddr         string
-	CreatorPubKey       string
-}
-
-// GenesisAction is the root action struct, each package's action should be put as its sub struct
-type GenesisAction struct {
+	ChainID             uint32 `yaml:"chainID"`
+	TotalSupply         uint64 `yaml:"totalSupply"`
+	BlockReward         uint64 `yaml:"blockReward"`
+	Timestamp           uint64 `yaml:"timestamp"`
+	GenesisCoinbaseData string `yaml:"genesisCoinbaseData"`
+	CreatorAddr         string `yaml:"creatorAddr"`
+	CreatorPubKey       string `yaml:"creatorPubKey"`
+	// SelfNominators are the initial candidates, and Transfers are the initial allocations from the creator
 	SelfNominators []Nominator `yaml:"selfNominators"`
 	Transfers      []Transfer  `yaml:"transfers"`
+	// EnableStakedVoting counts only the bonded stake as voting weight, instead of all the coins of the voter, and
+	// UnbondingPeriod is the number of blocks before the unstaked coins are released to the balance
+	EnableStakedVoting bool   `yaml:"enableStakedVoting"`
+	UnbondingPeriod    uint64 `yaml:"unbondingPeriod"`
+	// EpochReward is the reward split among the block producers at the end of each epoch, and VoterRewardPercent is
+	// the percentage of each producer's reward passed to its voters
+	EpochReward        uint64 `yaml:"epochReward"`
+	VoterRewardPercent uint64 `yaml:"voterRewardPercent"`
+	// Consensus overrides the consensus parameters of the config, the ones left out of the genesis document take the
+	// values of the config
+	Consensus *ConsensusParams `yaml:"consensus"`
+	// Upgrades are the protocol upgrades of the chain in the order of their heights
+	Upgrades version.Schedule `yaml:"upgrades"`
+}
+
+// ConsensusParams are the parameters all the nodes of a chain must agree on
+type ConsensusParams struct {
+	NumCandidates uint `yaml:"numCandidates"`
+	NumDelegates  uint `yaml:"numDelegates"`
+	NumSubEpochs  uint `yaml:"numSubEpochs"`
 }
 
 // Nominator is the Nominator struct for vote struct
@@ -57,37 +80,182 @@ type Transfer struct {
 	Signature string `yaml:"signature"`
 }
 
-// Gen hardcodes genesis default settings
+// Gen hardcodes genesis default settings, the fields left out of the genesis document keep these values
 var Gen = &Genesis{
 	ChainID:             uint32(1),
 	TotalSupply:         uint64(10000000000),
 	BlockReward:         uint64(5),
 	Timestamp:           uint64(1524676419),
-	ParentHash:          hash.Hash32B{},
 	GenesisCoinbaseData: "Connecting the physical world, block by block",
 	CreatorAddr:         "io1qyqsyqcy222ggazmccgf7dsx9m9vfqtadw82ygwhjnxtmx",
 	CreatorPubKey:       "d01164c3afe47406728d3e17861a3251dcff39e62bdc2b93ccb69a02785a175e195b5605517fd647eb7dd095b3d862dffb087f35eacf10c6859d04a100dbfb7358eeca9d5c37c904",
+	UnbondingPeriod:     uint64(8640),
 }
 
-// NewGenesisBlock creates a new genesis block
-func NewGenesisBlock(cfg *config.Config) *Block {
+// LoadGenesis reads the genesis document of the config, or the testnet genesis if none is configured
+func LoadGenesis(cfg *config.Config) (*Genesis, error) {
 	var filePath string
-	if cfg != nil && cfg.Chain.GenesisActionsPath != "" {
-		filePath = cfg.Chain.GenesisActionsPath
+	if cfg != nil && cfg.Chain.GenesisPath != "" {
+		filePath = cfg.Chain.GenesisPath
 	} else {
-		filePath = fileutil.GetFileAbsPath(testnetActionPath)
+		filePath = fileutil.GetFileAbsPath(testnetGenesisPath)
 	}
 
-	actionsBytes, err := ioutil.ReadFile(filePath)
+	genesisBytes, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Fail to create genesis block")
+		return nil, errors.Wrapf(err, "failed to read genesis file %s", filePath)
+	}
+	genesis := *Gen
+	if err := yaml.Unmarshal(genesisBytes, &genesis); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode genesis file %s", filePath)
+	}
+	if err := genesis.Upgrades.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "failed to validate genesis file %s", filePath)
+	}
+	if genesis.VoterRewardPercent > 100 {
+		return nil, errors.Errorf("voter reward percent %d of genesis file %s is above 100", genesis.VoterRewardPercent,
+			filePath)
+	}
+	genesis.Consensus = effectiveConsensus(genesis.Consensus, cfg)
+	return &genesis, nil
+}
+
+// Hash returns the hash of the genesis, which covers all the settings, actions and consensus parameters
+func (g *Genesis) Hash() hash.Hash32B {
+	var buf bytes.Buffer
+	buf.Write(byteutil.Uint32ToBytes(g.ChainID))
+	buf.Write(byteutil.Uint64ToBytes(g.TotalSupply))
+	buf.Write(byteutil.Uint64ToBytes(g.BlockReward))
+	buf.Write(byteutil.Uint64ToBytes(g.Timestamp))
+	writeString(&buf, g.GenesisCoinbaseData)
+	writeString(&buf, g.CreatorAddr)
+	writeString(&buf, g.CreatorPubKey)
+	buf.Write(byteutil.Uint32ToBytes(uint32(len(g.SelfNominators))))
+	for _, nominator := range g.SelfNominators {
+		writeString(&buf, nominator.PubKey)
+		writeString(&buf, nominator.Address)
+		writeString(&buf, nominator.Signature)
 	}
-	actions := GenesisAction{}
-	if err := yaml.Unmarshal(actionsBytes, &actions); err != nil {
+	buf.Write(byteutil.Uint32ToBytes(uint32(len(g.Transfers))))
+	for _, transfer := range g.Transfers {
+		buf.Write(byteutil.Uint64ToBytes(uint64(transfer.Amount)))
+		writeString(&buf, transfer.Recipient)
+		writeString(&buf, transfer.Signature)
+	}
+	if g.EnableStakedVoting {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	buf.Write(byteutil.Uint64ToBytes(g.UnbondingPeriod))
+	buf.Write(byteutil.Uint64ToBytes(g.EpochReward))
+	buf.Write(byteutil.Uint64ToBytes(g.VoterRewardPercent))
+	// a genesis not loaded from a document has no consensus parameters of its own, which is encoded as all zeros
+	consensus := ConsensusParams{}
+	if g.Consensus != nil {
+		consensus = *g.Consensus
+	}
+	buf.Write(byteutil.Uint64ToBytes(uint64(consensus.NumCandidates)))
+	buf.Write(byteutil.Uint64ToBytes(uint64(consensus.NumDelegates)))
+	buf.Write(byteutil.Uint64ToBytes(uint64(consensus.NumSubEpochs)))
+	buf.Write(byteutil.Uint32ToBytes(uint32(len(g.Upgrades))))
+	for _, upgrade := range g.Upgrades {
+		buf.Write(byteutil.Uint64ToBytes(upgrade.Height))
+		buf.Write(byteutil.Uint32ToBytes(upgrade.Version))
+		buf.Write(byteutil.Uint32ToBytes(uint32(len(upgrade.Features))))
+		for _, feature := range upgrade.Features {
+			writeString(&buf, feature)
+		}
+	}
+	return blake2b.Sum256(buf.Bytes())
+}
+
+// ApplyConsensus overrides the consensus parameters of the config with the ones set by the genesis
+func (g *Genesis) ApplyConsensus(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	params := effectiveConsensus(g.Consensus, cfg)
+	cfg.Chain.NumCandidates = params.NumCandidates
+	cfg.Consensus.RollDPoS.NumDelegates = params.NumDelegates
+	cfg.Consensus.RollDPoS.NumSubEpochs = params.NumSubEpochs
+}
+
+// NewGenesisBlock creates a new genesis block
+func NewGenesisBlock(cfg *config.Config) *Block {
+	genesis, err := LoadGenesis(cfg)
+	if err != nil {
 		logger.Fatal().Err(err).Msg("Fail to create genesis block")
 	}
+	return genesis.newBlock()
+}
+
+// Block creates the genesis block of the genesis along with its state root, which is computed by running the genesis
+// actions on the initial states holding the total supply by the creator. The consensus parameters of the genesis need
+// to be applied to the config beforehand, as a chain does
+func (g *Genesis) Block(cfg *config.Config) (*Block, error) {
+	sf, err := g.newStateFactory(cfg, state.InMemTrieOption())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create state factory")
+	}
+	if _, err := sf.CreateState(g.CreatorAddr, g.TotalSupply); err != nil {
+		return nil, errors.Wrap(err, "failed to add creator into state factory")
+	}
+	blk := g.newBlock()
+	if blk.Header.stateRoot, err = sf.RunActions(0, blk.Actions); err != nil {
+		return nil, errors.Wrap(err, "failed to compute state root of genesis block")
+	}
+	return blk, nil
+}
+
+// newStateFactory creates the state factory of the chain of the genesis on top of the trie set by the option, with the
+// protocol upgrades, the staking rules and the epoch reward of the genesis
+func (g *Genesis) newStateFactory(cfg *config.Config, trieOption state.FactoryOption) (state.Factory, error) {
+	return state.NewFactory(
+		cfg,
+		trieOption,
+		state.ScheduleOption(g.Upgrades),
+		state.StakingOption(g.EnableStakedVoting, g.UnbondingPeriod),
+		state.EpochRewardOption(g.EpochReward, g.VoterRewardPercent),
+	)
+}
+
+// effectiveConsensus returns the consensus parameters set by the genesis document, along with the ones of the config,
+// or of the default config if none, for the parameters left out of the document
+func effectiveConsensus(params *ConsensusParams, cfg *config.Config) *ConsensusParams {
+	if cfg == nil {
+		cfg = &config.Default
+	}
+	effective := &ConsensusParams{
+		NumCandidates: cfg.Chain.NumCandidates,
+		NumDelegates:  cfg.Consensus.RollDPoS.NumDelegates,
+		NumSubEpochs:  cfg.Consensus.RollDPoS.NumSubEpochs,
+	}
+	if params == nil {
+		return effective
+	}
+	if params.NumCandidates != 0 {
+		effective.NumCandidates = params.NumCandidates
+	}
+	if params.NumDelegates != 0 {
+		effective.NumDelegates = params.NumDelegates
+	}
+	if params.NumSubEpochs != 0 {
+		effective.NumSubEpochs = params.NumSubEpochs
+	}
+	return effective
+}
+
+// writeString writes the string prefixed with its length
+func writeString(buf *bytes.Buffer, s string) {
+	buf.Write(byteutil.Uint32ToBytes(uint32(len(s))))
+	buf.WriteString(s)
+}
+
+// newBlock creates the genesis block of the genesis
+func (g *Genesis) newBlock() *Block {
 	votes := []*action.Vote{}
-	for _, nominator := range actions.SelfNominators {
+	for _, nominator := range g.SelfNominators {
 		pubk, err := keypair.DecodePublicKey(nominator.PubKey)
 		if err != nil {
 			logger.Fatal().Err(err).Msg("Fail to create genesis block")
@@ -104,43 +272,52 @@ func NewGenesisBlock(cfg *config.Config) *Block {
 		if err != nil {
 			logger.Fatal().Err(err).Msg("Fail to create genesis block")
 		}
+		// genesis actions are signed without gas and are not charged
+		vote.GasLimit = 0
 		vote.SelfPubkey = address.PublicKey[:]
 		vote.Signature = sign
 		votes = append(votes, vote)
 	}
 
 	transfers := []*action.Transfer{}
-	creatorPK, err := keypair.DecodePublicKey(Gen.CreatorPubKey)
+	creatorPK, err := keypair.DecodePublicKey(g.CreatorPubKey)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Fail to create genesis block")
 	}
-	for _, transfer := range actions.Transfers {
+	for _, transfer := range g.Transfers {
 		signature, err := hex.DecodeString(transfer.Signature)
 		if err != nil {
 			logger.Fatal().Err(err).Msg("Fail to create genesis block")
 		}
-		tsf, err := action.NewTransfer(0, big.NewInt(transfer.Amount), Gen.CreatorAddr, transfer.Recipient)
+		tsf, err := action.NewTransfer(0, big.NewInt(transfer.Amount), g.CreatorAddr, transfer.Recipient)
 		if err != nil {
 			logger.Fatal().Err(err).Msg("Fail to create genesis block")
 		}
+		tsf.GasLimit = 0
 		tsf.SenderPublicKey = creatorPK
 		tsf.Signature = signature
 		transfers = append(transfers, tsf)
 	}
 
+	acts := make([]action.Action, 0, len(transfers)+len(votes))
+	for _, tsf := range transfers {
+		acts = append(acts, tsf)
+	}
+	for _, vote := range votes {
+		acts = append(acts, vote)
+	}
 	block := &Block{
 		Header: &BlockHeader{
-			version:       version.ProtocolVersion,
-			chainID:       Gen.ChainID,
+			version:       g.Upgrades.Version(0),
+			chainID:       g.ChainID,
 			height:        uint64(0),
-			timestamp:     Gen.Timestamp,
-			prevBlockHash: Gen.ParentHash,
+			timestamp:     g.Timestamp,
+			prevBlockHash: g.Hash(),
 			txRoot:        hash.ZeroHash32B,
 			stateRoot:     hash.ZeroHash32B,
 			blockSig:      []byte{},
 		},
-		Transfers: transfers,
-		Votes:     votes,
+		Actions: acts,
 	}
 
 	block.Header.txRoot = block.TxRoot()
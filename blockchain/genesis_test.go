@@ -7,13 +7,19 @@
 package blockchain
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/pkg/hash"
 )
 
+const testGenesisPath = "genesis.test"
+
 func TestGenesis(t *testing.T) {
 	t.Logf("The TotalSupply is %d", Gen.TotalSupply)
 
@@ -28,7 +34,9 @@ func TestGenesis(t *testing.T) {
 
 	assert := assert.New(t)
 
-	expectedParentHash := hash.Hash32B{}
+	genesis, err := LoadGenesis(nil)
+	assert.NoError(err)
+	expectedParentHash := genesis.Hash()
 
 	assert.Equal(uint32(1), genesisBlk.Header.version)
 	assert.Equal(uint32(1), genesisBlk.Header.chainID)
@@ -36,3 +44,96 @@ func TestGenesis(t *testing.T) {
 	assert.Equal(uint64(1524676419), genesisBlk.Header.timestamp)
 	assert.Equal(expectedParentHash, genesisBlk.Header.prevBlockHash)
 }
+
+func TestGenesisBlock(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.Default
+	bc := NewBlockchain(&cfg, InMemDaoOption(), InMemStateFactoryOption())
+	require.NotNil(bc)
+	expected, err := bc.GetBlockByHeight(0)
+	require.NoError(err)
+
+	// the genesis block is the same as the one the chain starts with
+	blk, err := bc.Genesis().Block(&cfg)
+	require.NoError(err)
+	require.Equal(expected.HashBlock(), blk.HashBlock())
+	require.Equal(expected.Header.StateRoot(), blk.Header.StateRoot())
+	require.NotEqual(hash.ZeroHash32B, blk.Header.StateRoot())
+}
+
+func TestLoadGenesis(t *testing.T) {
+	require := require.New(t)
+	doc := `
+chainID: 2
+totalSupply: 1000
+blockReward: 1
+timestamp: 1530000000
+transfers:
+    - amount: 100
+      recipient: "io1qyqsyqcy5cwwjdgautt98dqutc6ppq7y23j30nxqmszpqw"
+consensus:
+    numCandidates: 4
+    numDelegates: 2
+    numSubEpochs: 3
+unbondingPeriod: 10
+`
+	require.NoError(ioutil.WriteFile(testGenesisPath, []byte(doc), 0644))
+	defer os.Remove(testGenesisPath)
+
+	cfg := config.Default
+	cfg.Chain.GenesisPath = testGenesisPath
+	genesis, err := LoadGenesis(&cfg)
+	require.NoError(err)
+	require.Equal(uint32(2), genesis.ChainID)
+	require.Equal(uint64(1000), genesis.TotalSupply)
+	require.Equal(uint64(1), genesis.BlockReward)
+	require.Equal(uint64(1530000000), genesis.Timestamp)
+	// the settings left out of the document are the defaults
+	require.Equal(Gen.CreatorAddr, genesis.CreatorAddr)
+	require.Equal(1, len(genesis.Transfers))
+	require.Equal(0, len(genesis.SelfNominators))
+	require.Equal(uint64(10), genesis.UnbondingPeriod)
+	require.False(genesis.EnableStakedVoting)
+
+	genesis.ApplyConsensus(&cfg)
+	require.Equal(uint(4), cfg.Chain.NumCandidates)
+	require.Equal(uint(2), cfg.Consensus.RollDPoS.NumDelegates)
+	require.Equal(uint(3), cfg.Consensus.RollDPoS.NumSubEpochs)
+
+	// the consensus parameters left out of the document keep the ones of the config, and count in the hash
+	partial := &Genesis{Consensus: &ConsensusParams{NumDelegates: 5}}
+	partial.ApplyConsensus(&cfg)
+	require.Equal(uint(4), cfg.Chain.NumCandidates)
+	require.Equal(uint(5), cfg.Consensus.RollDPoS.NumDelegates)
+	require.Equal(uint(3), cfg.Consensus.RollDPoS.NumSubEpochs)
+	require.Equal(&ConsensusParams{NumCandidates: 4, NumDelegates: 5, NumSubEpochs: 3},
+		effectiveConsensus(partial.Consensus, &cfg))
+	other := config.Default
+	other.Chain.GenesisPath = testGenesisPath
+	other.Chain.NumCandidates = 8
+	same, err := LoadGenesis(&other)
+	require.NoError(err)
+	require.Equal(genesis.Hash(), same.Hash())
+	other.Chain.GenesisPath = ""
+	otherTestnet, err := LoadGenesis(&other)
+	require.NoError(err)
+	require.Equal(uint(8), otherTestnet.Consensus.NumCandidates)
+	testnet, err := LoadGenesis(nil)
+	require.NoError(err)
+	require.Equal(config.Default.Chain.NumCandidates, testnet.Consensus.NumCandidates)
+	require.NotEqual(testnet.Hash(), otherTestnet.Hash())
+
+	// the genesis block links to the hash of the genesis, which differs with any setting
+	blk := genesis.newBlock()
+	require.Equal(uint32(2), blk.Header.chainID)
+	require.Equal(genesis.Hash(), blk.Header.prevBlockHash)
+	require.Equal(1, len(blk.Actions))
+	require.NotEqual(testnet.Hash(), genesis.Hash())
+	genesis.Consensus.NumDelegates = 3
+	require.NotEqual(blk.Header.prevBlockHash, genesis.Hash())
+
+	cfg.Chain.GenesisPath = "not.exist"
+	_, err = LoadGenesis(&cfg)
+	require.Error(err)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+var (
+	// ErrReindexInProgress indicates that the chain DB is being reindexed, and cannot be used until the reindex finishes
+	ErrReindexInProgress = errors.New("reindex of the chain DB is in progress")
+	// ErrStatesAhead indicates that the states are ahead of the reindexed blocks, so the reindex has to be restarted
+	ErrStatesAhead = errors.New("the states are ahead of the reindexed blocks")
+)
+
+// Reindex rebuilds the states at cfg.Chain.TrieDBPath, and the action indexes and the receipts in the chain DB at
+// cfg.Chain.ChainDBPath, by replaying the blocks stored in the chain DB. The node must not be running. An interrupted
+// reindex resumes from the last reindexed block, unless restart is set. The progress is called with the height of each
+// block reindexed, if not nil
+func Reindex(cfg *config.Config, restart bool, progress func(uint64)) error {
+	genesis, err := LoadGenesis(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to load genesis")
+	}
+	genesis.ApplyConsensus(cfg)
+
+	dao := newBlockDAO(db.NewBoltDB(cfg.Chain.ChainDBPath, nil))
+	if err := dao.Start(context.Background()); err != nil {
+		return errors.Wrap(err, "failed to start chain DB")
+	}
+	defer dao.Stop(context.Background())
+
+	// the reindex starts over unless a block is reindexed already, so an interrupted start is redone as a whole
+	if next, ok := dao.getReindexHeight(); restart || !ok || next == 0 {
+		if err := dao.startReindex(); err != nil {
+			return errors.Wrap(err, "failed to start reindex")
+		}
+		if err := os.Remove(cfg.Chain.TrieDBPath); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "failed to delete trie DB")
+		}
+		if err := dao.deleteIndexes(); err != nil {
+			return errors.Wrap(err, "failed to delete indexes")
+		}
+	}
+	trieDB := db.NewBoltDB(cfg.Chain.TrieDBPath, nil)
+	if err := trieDB.Start(context.Background()); err != nil {
+		return errors.Wrap(err, "failed to start trie DB")
+	}
+	defer trieDB.Stop(context.Background())
+	sf, err := genesis.newStateFactory(cfg, state.PrecreatedDBOption(trieDB))
+	if err != nil {
+		return errors.Wrap(err, "failed to create state factory")
+	}
+	return reindexBlocks(dao, sf, genesis, progress)
+}
+
+// reindexBlocks replays the blocks on top of the states, verifying the hash link and the state root of each block, and
+// puts the indexes and the receipts of the blocks not reindexed yet. The states without a persisted height, which are
+// always the case unless the history of the states is kept, are replayed from the genesis block
+func reindexBlocks(dao *blockDAO, sf state.Factory, genesis *Genesis, progress func(uint64)) error {
+	next, ok := dao.getReindexHeight()
+	if !ok {
+		return errors.New("reindex is not started")
+	}
+	topHeight, err := dao.getBlockchainHeight()
+	if err != nil {
+		return err
+	}
+	start := uint64(0)
+	if height, ok := sf.Height(); ok {
+		start = height + 1
+	} else if _, err := sf.CreateState(genesis.CreatorAddr, genesis.TotalSupply); err != nil {
+		return errors.Wrap(err, "failed to add creator into state factory")
+	}
+	if start > next {
+		return errors.Wrapf(ErrStatesAhead, "states of height %d, next block to reindex %d", start-1, next)
+	}
+
+	if err := replayBlocks(dao, sf, genesis, start, topHeight, func(blk *Block, receipts []*state.Receipt) error {
+		height := blk.Height()
+		if height < next {
+			return nil
+		}
+		if err := dao.reindexBlock(blk, receipts); err != nil {
+			return errors.Wrapf(err, "failed to reindex block %d", height)
+		}
+		if progress != nil {
+			progress(height)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return dao.finishReindex()
+}
+
+// replayBlocks replays the blocks from start to end on top of the states, which are of the block before start or of no
+// block if start is 0, verifying the hash link and the state root of each block. Each block replayed is passed to fn
+// along with the receipts of its actions
+func replayBlocks(
+	dao *blockDAO,
+	sf state.Factory,
+	genesis *Genesis,
+	start uint64,
+	end uint64,
+	fn func(*Block, []*state.Receipt) error,
+) error {
+	if pruned := dao.getPrunedHeight(); start < pruned {
+		return errors.Wrapf(ErrBlockPruned, "blocks below height %d are pruned, replay from %d", pruned, start)
+	}
+	prevHash := genesis.Hash()
+	if start > 0 {
+		var err error
+		if prevHash, err = dao.getBlockHash(start - 1); err != nil {
+			return err
+		}
+	}
+	for height := start; height <= end; height++ {
+		blk, err := dao.getBlockByHeight(height)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get block %d", height)
+		}
+		if blk.Height() != height || blk.Header.prevBlockHash != prevHash {
+			return errors.Wrapf(ErrInvalidBlock, "block %d does not link to the previous block", height)
+		}
+		if err := sf.CommitStateChanges(height, blk.Actions); err != nil {
+			return errors.Wrapf(err, "failed to commit states of block %d", height)
+		}
+		receipts := sf.Receipts()
+		if root := sf.RootHash(); root != blk.Header.stateRoot {
+			return errors.Wrapf(
+				ErrInvalidStateRoot,
+				"Wrong state root %x of block %d, expecting %x",
+				root,
+				height,
+				blk.Header.stateRoot)
+		}
+		prevHash = blk.HashBlock()
+		if err := fn(blk, receipts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/blockchain/action"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/state"
+	ta "github.com/iotexproject/iotex-core/test/testaddress"
+	"github.com/iotexproject/iotex-core/testutil"
+)
+
+type daoIndexes struct {
+	transfersFrom  map[string][]hash.Hash32B
+	transfersTo    map[string][]hash.Hash32B
+	votesFrom      map[string][]hash.Hash32B
+	votesTo        map[string][]hash.Hash32B
+	totalTransfers uint64
+	totalVotes     uint64
+	receipts       map[hash.Hash32B]*state.Receipt
+}
+
+func getDaoIndexes(t *testing.T, dao *blockDAO) *daoIndexes {
+	require := require.New(t)
+
+	indexes := &daoIndexes{
+		transfersFrom: make(map[string][]hash.Hash32B),
+		transfersTo:   make(map[string][]hash.Hash32B),
+		votesFrom:     make(map[string][]hash.Hash32B),
+		votesTo:       make(map[string][]hash.Hash32B),
+		receipts:      make(map[hash.Hash32B]*state.Receipt),
+	}
+	for _, addr := range ta.Addrinfo {
+		var err error
+		indexes.transfersFrom[addr.RawAddress], err = dao.getTransfersBySenderAddress(addr.RawAddress)
+		require.NoError(err)
+		indexes.transfersTo[addr.RawAddress], err = dao.getTransfersByRecipientAddress(addr.RawAddress)
+		require.NoError(err)
+		indexes.votesFrom[addr.RawAddress], err = dao.getVotesBySenderAddress(addr.RawAddress)
+		require.NoError(err)
+		indexes.votesTo[addr.RawAddress], err = dao.getVotesByRecipientAddress(addr.RawAddress)
+		require.NoError(err)
+	}
+	var err error
+	indexes.totalTransfers, err = dao.getTotalTransfers()
+	require.NoError(err)
+	indexes.totalVotes, err = dao.getTotalVotes()
+	require.NoError(err)
+
+	topHeight, err := dao.getBlockchainHeight()
+	require.NoError(err)
+	for height := uint64(0); height <= topHeight; height++ {
+		blk, err := dao.getBlockByHeight(height)
+		require.NoError(err)
+		for _, act := range blk.Actions {
+			if receipt, err := dao.getReceiptByActionHash(act.Hash()); err == nil {
+				indexes.receipts[act.Hash()] = receipt
+			}
+		}
+	}
+	return indexes
+}
+
+func TestReindex(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Disable block reward to make bookkeeping easier
+	defer func(reward uint64) { Gen.BlockReward = reward }(Gen.BlockReward)
+	Gen.BlockReward = uint64(0)
+
+	cfg := config.Default
+	dao := newBlockDAO(db.NewMemKVStore())
+	sf := newTestingFactory(t, &cfg, state.InMemTrieOption())
+	bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), PrecreatedDaoOption(dao))
+	require.NotNil(bc)
+	require.NoError(addTestingTsfBlocks(bc))
+	expected := getDaoIndexes(t, dao)
+	require.NotEmpty(expected.receipts)
+	require.NotEmpty(expected.votesTo[ta.Addrinfo["charlie"].RawAddress])
+
+	// the indexes are wiped, and the chain cannot start until the reindex finishes
+	require.Error(dao.deleteIndexes())
+	require.NoError(dao.startReindex())
+	require.NoError(dao.deleteIndexes())
+	wiped := getDaoIndexes(t, dao)
+	require.Equal(uint64(0), wiped.totalTransfers)
+	require.Empty(wiped.receipts)
+	require.Empty(wiped.transfersFrom[ta.Addrinfo["producer"].RawAddress])
+	require.Empty(wiped.votesTo[ta.Addrinfo["charlie"].RawAddress])
+	require.Equal(ErrReindexInProgress, errors.Cause(bc.Start(ctx)))
+
+	// the reindex is interrupted after block 2
+	partial := newTestingFactory(t, &cfg, state.InMemTrieOption())
+	_, err := partial.CreateState(bc.Genesis().CreatorAddr, bc.Genesis().TotalSupply)
+	require.NoError(err)
+	for height := uint64(0); height <= 2; height++ {
+		blk, err := dao.getBlockByHeight(height)
+		require.NoError(err)
+		require.NoError(partial.CommitStateChanges(height, blk.Actions))
+		require.NoError(dao.reindexBlock(blk, partial.Receipts()))
+	}
+	next, ok := dao.getReindexHeight()
+	require.True(ok)
+	require.Equal(uint64(3), next)
+
+	// the states are not persisted, so they are replayed from the genesis block, and the indexes from block 3
+	replayed := newTestingFactory(t, &cfg, state.InMemTrieOption())
+	var reindexed []uint64
+	require.NoError(reindexBlocks(dao, replayed, bc.Genesis(), func(height uint64) {
+		reindexed = append(reindexed, height)
+	}))
+	require.Equal([]uint64{3, 4}, reindexed)
+	require.Equal(sf.RootHash(), replayed.RootHash())
+	require.Equal(expected, getDaoIndexes(t, dao))
+	_, ok = dao.getReindexHeight()
+	require.False(ok)
+
+	// the states already include the blocks to reindex
+	require.NoError(dao.startReindex())
+	require.NoError(dao.deleteIndexes())
+	require.Equal(ErrStatesAhead, errors.Cause(reindexBlocks(dao, replayed, bc.Genesis(), nil)))
+
+	// the replayed states do not match the state roots of the blocks
+	sf, err = state.NewFactory(&cfg, state.InMemTrieOption())
+	require.NoError(err)
+	require.Equal(ErrInvalidStateRoot, errors.Cause(reindexBlocks(dao, sf, bc.Genesis(), nil)))
+
+	require.NoError(reindexBlocks(dao, newTestingFactory(t, &cfg, state.InMemTrieOption()), bc.Genesis(), nil))
+	require.Equal(expected, getDaoIndexes(t, dao))
+	require.NoError(bc.Stop(ctx))
+}
+
+func TestReindexDB(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	testutil.CleanupPath(t, testTriePath)
+	defer testutil.CleanupPath(t, testTriePath)
+	testutil.CleanupPath(t, testDBPath)
+	defer testutil.CleanupPath(t, testDBPath)
+	// the producer creates the genesis, so the states replayed from the genesis fund the testing transfers
+	producer := ta.Addrinfo["producer"]
+	alfa := ta.Addrinfo["alfa"]
+	tsf, err := action.NewTransfer(0, big.NewInt(10), producer.RawAddress, alfa.RawAddress)
+	require.NoError(err)
+	tsf.GasLimit = 0
+	require.NoError(action.Sign(tsf, producer))
+	doc := fmt.Sprintf(`
+blockReward: 0
+creatorAddr: %s
+creatorPubKey: %s
+transfers:
+    - amount: 10
+      recipient: %s
+      signature: %x
+`, producer.RawAddress, keypair.EncodePublicKey(producer.PublicKey), alfa.RawAddress, tsf.Signature)
+	require.NoError(ioutil.WriteFile(testGenesisPath, []byte(doc), 0644))
+	defer os.Remove(testGenesisPath)
+
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTriePath
+	cfg.Chain.ChainDBPath = testDBPath
+	cfg.Chain.GenesisPath = testGenesisPath
+	trieDB := db.NewBoltDB(testTriePath, nil)
+	require.NoError(trieDB.Start(ctx))
+	sf, err := state.NewFactory(&cfg, state.PrecreatedDBOption(trieDB))
+	require.NoError(err)
+	bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), BoltDBDaoOption())
+	require.NotNil(bc)
+	require.NoError(addTestingTsfBlocks(bc))
+	expected := getDaoIndexes(t, bc.(*blockchain).dao)
+	root := sf.RootHash()
+	require.NoError(bc.Stop(ctx))
+	require.NoError(trieDB.Stop(ctx))
+
+	var reindexed []uint64
+	require.NoError(Reindex(&cfg, true, func(height uint64) {
+		reindexed = append(reindexed, height)
+	}))
+	require.Equal([]uint64{0, 1, 2, 3, 4}, reindexed)
+
+	// a reindex interrupted before reindexing any block starts over
+	dao := newBlockDAO(db.NewBoltDB(testDBPath, nil))
+	require.NoError(dao.Start(ctx))
+	require.Equal(expected, getDaoIndexes(t, dao))
+	require.NoError(dao.startReindex())
+	require.NoError(dao.Stop(ctx))
+	require.NoError(Reindex(&cfg, false, nil))
+
+	// the trie DB is released, and holds the rebuilt states
+	trieDB = db.NewBoltDB(testTriePath, nil)
+	require.NoError(trieDB.Start(ctx))
+	defer trieDB.Stop(ctx)
+	report, err := state.CheckStates(trieDB, root)
+	require.NoError(err)
+	require.True(report.OK())
+	require.NotZero(report.Entries)
+	dao = newBlockDAO(db.NewBoltDB(testDBPath, nil))
+	require.NoError(dao.Start(ctx))
+	defer dao.Stop(ctx)
+	require.Equal(expected, getDaoIndexes(t, dao))
+	_, ok := dao.getReindexHeight()
+	require.False(ok)
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/trie"
+)
+
+// repairDBSuffix is appended to cfg.Chain.TrieDBPath for the scratch DB of the states replayed to repair the trie DB
+const repairDBSuffix = ".repair"
+
+// CheckStates checks the nodes of the states at cfg.Chain.TrieDBPath, from the state root of the block of the given
+// height in the chain DB at cfg.Chain.ChainDBPath, or of the top block if the height is negative. Unless the history of
+// the states is kept, only the states of the top block are in the trie DB. The node must not be running
+func CheckStates(cfg *config.Config, height int64) (*trie.CheckReport, error) {
+	dao := newBlockDAO(db.NewBoltDB(cfg.Chain.ChainDBPath, nil))
+	if err := dao.Start(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "failed to start chain DB")
+	}
+	defer dao.Stop(context.Background())
+
+	trieDB := db.NewBoltDB(cfg.Chain.TrieDBPath, nil)
+	if err := trieDB.Start(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "failed to start trie DB")
+	}
+	defer trieDB.Stop(context.Background())
+
+	_, root, err := stateRootAt(dao, height)
+	if err != nil {
+		return nil, err
+	}
+	return state.CheckStates(trieDB, root)
+}
+
+// RepairStates checks the states as CheckStates does, and if any node is faulty, rebuilds the states of the block in a
+// scratch DB by replaying the blocks from the genesis block, and writes the missing and corrupted nodes back into the
+// trie DB. The progress is called with the height of each block replayed, if not nil. It returns the report of the check
+// before the repair, and the number of the nodes written
+func RepairStates(cfg *config.Config, height int64, progress func(uint64)) (*trie.CheckReport, int, error) {
+	genesis, err := LoadGenesis(cfg)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to load genesis")
+	}
+	genesis.ApplyConsensus(cfg)
+
+	dao := newBlockDAO(db.NewBoltDB(cfg.Chain.ChainDBPath, nil))
+	if err := dao.Start(context.Background()); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to start chain DB")
+	}
+	defer dao.Stop(context.Background())
+
+	trieDB := db.NewBoltDB(cfg.Chain.TrieDBPath, nil)
+	if err := trieDB.Start(context.Background()); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to start trie DB")
+	}
+	defer trieDB.Stop(context.Background())
+
+	top, root, err := stateRootAt(dao, height)
+	if err != nil {
+		return nil, 0, err
+	}
+	report, err := state.CheckStates(trieDB, root)
+	if err != nil || report.OK() {
+		return report, 0, err
+	}
+	// the states are replayed from the genesis block, which needs the bodies of all the blocks up to the height
+	if pruned := dao.getPrunedHeight(); pruned > 0 {
+		return nil, 0, errors.Wrapf(ErrBlockPruned, "cannot replay states, blocks below height %d are pruned", pruned)
+	}
+
+	scratchPath := cfg.Chain.TrieDBPath + repairDBSuffix
+	if err := os.Remove(scratchPath); err != nil && !os.IsNotExist(err) {
+		return nil, 0, errors.Wrap(err, "failed to delete scratch DB")
+	}
+	scratch := db.NewBoltDB(scratchPath, nil)
+	if err := scratch.Start(context.Background()); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to start scratch DB")
+	}
+	defer func() {
+		scratch.Stop(context.Background())
+		os.Remove(scratchPath)
+	}()
+	// the replayed states only need to be complete at the block, so the stale nodes are not kept
+	scratchCfg := *cfg
+	scratchCfg.Chain.EnablePruning = false
+	scratchCfg.Chain.EnableArchiveMode = false
+	sf, err := genesis.newStateFactory(&scratchCfg, state.PrecreatedDBOption(scratch))
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to create state factory")
+	}
+	if _, err := sf.CreateState(genesis.CreatorAddr, genesis.TotalSupply); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to add creator into state factory")
+	}
+	written, err := repairStates(dao, sf, trieDB, scratch, genesis, top, progress)
+	if err != nil {
+		return nil, 0, err
+	}
+	return report, written, nil
+}
+
+// stateRootAt returns the height and the state root of the block of the given height, or of the top block if the height
+// is negative
+func stateRootAt(dao *blockDAO, height int64) (uint64, hash.Hash32B, error) {
+	var top uint64
+	if height < 0 {
+		var err error
+		if top, err = dao.getBlockchainHeight(); err != nil {
+			return 0, hash.ZeroHash32B, err
+		}
+	} else {
+		top = uint64(height)
+	}
+	blk, err := dao.getBlockByHeight(top)
+	if err != nil {
+		return 0, hash.ZeroHash32B, errors.Wrapf(err, "failed to get block %d", top)
+	}
+	return top, blk.Header.stateRoot, nil
+}
+
+// repairStates replays the blocks up to the given height on top of the states of sf, which are put in scratch, and
+// writes the nodes of the states of the block missing or corrupted in trieDB from scratch. If trieDB keeps the history
+// of the states, the nodes written are recorded in the history of the block, so they are pruned once made stale. The
+// faults left after the repair, if any, fail the repair
+func repairStates(
+	dao *blockDAO,
+	sf state.Factory,
+	trieDB db.KVStore,
+	scratch db.KVStore,
+	genesis *Genesis,
+	height uint64,
+	progress func(uint64),
+) (int, error) {
+	if err := replayBlocks(dao, sf, genesis, 0, height, func(blk *Block, _ []*state.Receipt) error {
+		if progress != nil {
+			progress(blk.Height())
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	root := sf.RootHash()
+	written, err := state.RepairStates(trieDB, scratch, root, height)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to write replayed states")
+	}
+	report, err := state.CheckStates(trieDB, root)
+	if err != nil {
+		return 0, err
+	}
+	if !report.OK() {
+		return 0, errors.Errorf("%d faulty nodes left after repair", len(report.Faults))
+	}
+	return written, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/trie"
+)
+
+func TestRepairStates(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Disable block reward to make bookkeeping easier
+	defer func(reward uint64) { Gen.BlockReward = reward }(Gen.BlockReward)
+	Gen.BlockReward = uint64(0)
+
+	cfg := config.Default
+	dao := newBlockDAO(db.NewMemKVStore())
+	trieDB := db.NewMemKVStore()
+	sf := newTestingFactory(t, &cfg, state.PrecreatedDBOption(trieDB))
+	bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), PrecreatedDaoOption(dao))
+	require.NotNil(bc)
+	require.NoError(addTestingTsfBlocks(bc))
+	defer bc.Stop(ctx)
+
+	top, root, err := stateRootAt(dao, -1)
+	require.NoError(err)
+	tip, err := bc.TipHeight()
+	require.NoError(err)
+	require.Equal(tip, top)
+	require.Equal(sf.RootHash(), root)
+	_, _, err = stateRootAt(dao, int64(top+1))
+	require.Error(err)
+	report, err := state.CheckStates(trieDB, root)
+	require.NoError(err)
+	require.True(report.OK())
+
+	// the root node of the states is lost
+	require.NoError(trieDB.Delete(trie.AccountKVNameSpace, root[:]))
+	report, err = state.CheckStates(trieDB, root)
+	require.NoError(err)
+	require.Equal(1, len(report.Faults))
+	require.True(report.Faults[0].Missing)
+
+	// the replayed states do not match the state roots of the blocks
+	scratch := db.NewMemKVStore()
+	replayed, err := state.NewFactory(&cfg, state.PrecreatedDBOption(scratch))
+	require.NoError(err)
+	_, err = replayed.CreateState(bc.Genesis().CreatorAddr, bc.Genesis().TotalSupply)
+	require.NoError(err)
+	_, err = repairStates(dao, replayed, trieDB, scratch, bc.Genesis(), top, nil)
+	require.Equal(ErrInvalidStateRoot, errors.Cause(err))
+
+	scratch = db.NewMemKVStore()
+	replayed = newTestingFactory(t, &cfg, state.PrecreatedDBOption(scratch))
+	_, err = replayed.CreateState(bc.Genesis().CreatorAddr, bc.Genesis().TotalSupply)
+	require.NoError(err)
+	var heights []uint64
+	n, err := repairStates(dao, replayed, trieDB, scratch, bc.Genesis(), top, func(height uint64) {
+		heights = append(heights, height)
+	})
+	require.NoError(err)
+	require.Equal(1, n)
+	require.Equal([]uint64{0, 1, 2, 3, 4}, heights)
+	report, err = state.CheckStates(trieDB, root)
+	require.NoError(err)
+	require.True(report.OK())
+
+	// the states cannot be replayed from the genesis block once the blocks are pruned
+	require.NoError(dao.pruneBlocks(top))
+	scratch = db.NewMemKVStore()
+	replayed = newTestingFactory(t, &cfg, state.PrecreatedDBOption(scratch))
+	_, err = repairStates(dao, replayed, trieDB, scratch, bc.Genesis(), top, nil)
+	require.Equal(ErrBlockPruned, errors.Cause(err))
+}
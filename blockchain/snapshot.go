@@ -0,0 +1,242 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	iproto "github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// ErrNoSnapshot indicates the chain does not have a snapshot of the states to serve
+var ErrNoSnapshot = errors.New("no snapshot is available")
+
+// SnapshotManifest describes a snapshot of the states as of a block. The accounts are split into the chunks of the
+// hashes, and the metadata carries the candidate pools. The chunks and the metadata are checked against the state
+// root of the block when the snapshot is restored
+type SnapshotManifest struct {
+	Block  *Block
+	Meta   []byte
+	Chunks []hash.Hash32B
+}
+
+// ConvertToSnapshotManifestPb converts the manifest to protobuf
+func (m *SnapshotManifest) ConvertToSnapshotManifestPb() *iproto.SnapshotManifestPb {
+	chunks := make([][]byte, 0, len(m.Chunks))
+	for _, h := range m.Chunks {
+		chunks = append(chunks, h[:])
+	}
+	return &iproto.SnapshotManifestPb{Block: m.Block.ConvertToBlockPb(), Meta: m.Meta, Chunks: chunks}
+}
+
+// ConvertFromSnapshotManifestPb converts protobuf to the manifest
+func (m *SnapshotManifest) ConvertFromSnapshotManifestPb(pbManifest *iproto.SnapshotManifestPb) error {
+	if pbManifest.Block == nil || pbManifest.Block.Header == nil {
+		return errors.New("snapshot manifest does not have a block")
+	}
+	m.Block = &Block{}
+	m.Block.ConvertFromBlockPb(pbManifest.Block)
+	m.Meta = pbManifest.Meta
+	m.Chunks = make([]hash.Hash32B, 0, len(pbManifest.Chunks))
+	for _, chunk := range pbManifest.Chunks {
+		if len(chunk) != len(hash.ZeroHash32B) {
+			return errors.Errorf("invalid chunk hash %x", chunk)
+		}
+		var h hash.Hash32B
+		copy(h[:], chunk)
+		m.Chunks = append(m.Chunks, h)
+	}
+	return nil
+}
+
+// SnapshotManifest returns the manifest of the latest snapshot taken, if the block of the snapshot is on the chain
+func (bc *blockchain) SnapshotManifest() (*SnapshotManifest, error) {
+	m, err := bc.dao.getSnapshotManifest()
+	if err != nil {
+		return nil, err
+	}
+	// the block of the snapshot could have been reverted by a reorg
+	if h, err := bc.dao.getBlockHash(m.Block.Height()); err != nil || h != m.Block.HashBlock() {
+		return nil, errors.Wrapf(ErrNoSnapshot, "block %d of the snapshot is not on the chain", m.Block.Height())
+	}
+	return m, nil
+}
+
+// SnapshotChunk returns the chunk of the given hash of the latest or the previous snapshot taken, so the fast syncs
+// started before the latest snapshot can finish
+func (bc *blockchain) SnapshotChunk(h hash.Hash32B) ([]byte, error) {
+	return bc.dao.getSnapshotChunk(h)
+}
+
+// RestoreSnapshot restores the states from a snapshot onto a chain which only has the genesis block, and moves the tip
+// to the block of the snapshot. The block has to match the header of its height on a chain of the headers whose
+// producers are verified against the delegates, so the state root of the block can be trusted. The chunks are returned
+// by the function, and each is checked against its hash in the manifest and put into the chunk store before the chain
+// is locked. The restored snapshot is served to the other fast syncing nodes until the chain takes its own, and the
+// chunks are deleted if the restore fails. The blocks below the block of the snapshot are absent from the chain as if
+// they were pruned
+func (bc *blockchain) RestoreSnapshot(m *SnapshotManifest, header *Block, chunk func(hash.Hash32B) ([]byte, error)) error {
+	if bc.sf == nil {
+		return errors.New("blockchain does not have a state factory to restore the snapshot to")
+	}
+	blk := m.Block
+	if blk == nil || blk.Height() == 0 {
+		return errors.Wrap(state.ErrInvalidSnapshot, "snapshot is not of a block above genesis")
+	}
+	if header == nil || header.HashBlock() != blk.HashBlock() {
+		return errors.Wrapf(state.ErrInvalidSnapshot, "block %d of snapshot does not match the verified header", blk.Height())
+	}
+	// the parent block is not available, so only the stateless checks of the block itself can be done
+	if err := (&validator{schedule: bc.genesis.Upgrades}).Validate(blk, blk.Height()-1, blk.Header.prevBlockHash); err != nil {
+		return errors.Wrapf(err, "failed to validate block %d of snapshot", blk.Height())
+	}
+	if height, err := bc.TipHeight(); err != nil || height != 0 {
+		return errors.Errorf("cannot restore snapshot onto chain of height %d", height)
+	}
+	fetched := make([]hash.Hash32B, 0, len(m.Chunks))
+	discard := func() {
+		if err := bc.dao.discardSnapshotChunks(fetched); err != nil {
+			logger.Error().Err(err).Msg("Failed to delete the chunks of the failed restore")
+		}
+	}
+	for _, h := range m.Chunks {
+		data, err := chunk(h)
+		if err != nil {
+			discard()
+			return errors.Wrapf(err, "failed to get chunk %x", h)
+		}
+		if blake2b.Sum256(data) != h {
+			discard()
+			return errors.Wrapf(state.ErrInvalidSnapshot, "chunk %x does not match its hash", h)
+		}
+		if err := bc.dao.putSnapshotChunk(h, data); err != nil {
+			discard()
+			return errors.Wrapf(err, "failed to put chunk %x", h)
+		}
+		fetched = append(fetched, h)
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.tipHeight != 0 {
+		discard()
+		return errors.Errorf("cannot restore snapshot onto chain of height %d", bc.tipHeight)
+	}
+	i := 0
+	next := func() ([]byte, error) {
+		if i == len(m.Chunks) {
+			return nil, nil
+		}
+		i++
+		return bc.dao.getSnapshotChunk(m.Chunks[i-1])
+	}
+	if err := bc.sf.RestoreSnapshot(blk.Header.stateRoot, m.Meta, next); err != nil {
+		discard()
+		return err
+	}
+	if err := bc.dao.putSnapshotBlock(blk); err != nil {
+		return err
+	}
+	if err := bc.dao.putSnapshotManifest(m); err != nil {
+		return err
+	}
+	bc.tipHeight = blk.Height()
+	bc.tipHash = blk.HashBlock()
+	logger.Info().Uint64("height", bc.tipHeight).Msg("restored snapshot")
+	return nil
+}
+
+//======================================
+// private functions
+//======================================
+// pendingSnapshot is the states of a block pinned for a snapshot, waiting for the export in progress
+type pendingSnapshot struct {
+	blk    *Block
+	states state.Snapshot
+}
+
+// startSnapshot pins the states of the block just committed, and exports them in the background. If a snapshot is being
+// exported, the states wait for it, and replace the ones already waiting
+func (bc *blockchain) startSnapshot(blk *Block) {
+	states, err := bc.sf.PinSnapshot()
+	if err != nil {
+		// the previous snapshot is served until the next one
+		logger.Error().Err(err).Uint64("height", blk.Height()).Msg("Failed to take snapshot")
+		return
+	}
+	bc.snapshotMu.Lock()
+	defer bc.snapshotMu.Unlock()
+
+	if bc.snapshotStopped {
+		states.Release()
+		return
+	}
+	if bc.snapshotPending != nil {
+		bc.snapshotPending.states.Release()
+	}
+	bc.snapshotPending = &pendingSnapshot{blk: blk, states: states}
+	if bc.snapshotRunning {
+		return
+	}
+	bc.snapshotRunning = true
+	bc.snapshotWG.Add(1)
+	go bc.exportSnapshots()
+}
+
+// exportSnapshots exports the pending snapshots until none is left
+func (bc *blockchain) exportSnapshots() {
+	defer bc.snapshotWG.Done()
+	for {
+		bc.snapshotMu.Lock()
+		p := bc.snapshotPending
+		bc.snapshotPending = nil
+		if p == nil {
+			bc.snapshotRunning = false
+			bc.snapshotMu.Unlock()
+			return
+		}
+		bc.snapshotMu.Unlock()
+
+		if err := bc.exportSnapshot(p.blk, p.states); err != nil {
+			logger.Error().Err(err).Uint64("height", p.blk.Height()).Msg("Failed to take snapshot")
+		}
+		p.states.Release()
+	}
+}
+
+// exportSnapshot exports the pinned states of the block, and publishes the manifest once all the chunks are put, which
+// keeps the previous snapshot along with it. The chunks put are deleted if the export fails or is aborted
+func (bc *blockchain) exportSnapshot(blk *Block, states state.Snapshot) error {
+	m := &SnapshotManifest{Block: blk, Meta: states.Meta()}
+	err := states.Export(int(bc.config.Chain.SnapshotChunkSize), func(data []byte) error {
+		bc.snapshotMu.Lock()
+		stopped := bc.snapshotStopped
+		bc.snapshotMu.Unlock()
+		if stopped {
+			return errors.New("blockchain is stopped")
+		}
+		h := blake2b.Sum256(data)
+		m.Chunks = append(m.Chunks, h)
+		return bc.dao.putSnapshotChunk(h, data)
+	})
+	if err == nil {
+		err = bc.dao.putSnapshotManifest(m)
+	}
+	if err != nil {
+		if discardErr := bc.dao.discardSnapshotChunks(m.Chunks); discardErr != nil {
+			logger.Error().Err(discardErr).Msg("Failed to delete the chunks of the failed snapshot")
+		}
+		return err
+	}
+	logger.Info().Uint64("height", blk.Height()).Int("chunks", len(m.Chunks)).Msg("took snapshot")
+	return nil
+}
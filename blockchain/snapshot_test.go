@@ -0,0 +1,181 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/blockchain/action"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/state"
+	ta "github.com/iotexproject/iotex-core/test/testaddress"
+)
+
+func TestSnapshot(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	cfg.Chain.SnapshotInterval = 2
+	cfg.Chain.SnapshotChunkSize = 2
+	sf, err := state.NewFactory(&cfg, state.InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, Gen.TotalSupply)
+	require.NoError(err)
+	bc := NewBlockchain(&cfg, PrecreatedStateFactoryOption(sf), InMemDaoOption())
+	require.NotNil(bc)
+	_, err = bc.SnapshotManifest()
+	require.Equal(ErrNoSnapshot, errors.Cause(err))
+
+	// the snapshot of block 2 is replaced by the one of block 4
+	require.NoError(addTestingTsfBlocks(bc))
+	bc.(*blockchain).snapshotWG.Wait()
+	manifest, err := bc.SnapshotManifest()
+	require.NoError(err)
+	require.Equal(uint64(4), manifest.Block.Height())
+	require.True(len(manifest.Chunks) > 1)
+	for _, h := range manifest.Chunks {
+		_, err := bc.SnapshotChunk(h)
+		require.NoError(err)
+	}
+	pbManifest := manifest.ConvertToSnapshotManifestPb()
+	decoded := &SnapshotManifest{}
+	require.NoError(decoded.ConvertFromSnapshotManifestPb(pbManifest))
+	require.Equal(manifest.Block.HashBlock(), decoded.Block.HashBlock())
+	require.Equal(manifest.Chunks, decoded.Chunks)
+
+	// a fresh chain restored from the snapshot starts from block 4
+	cfg2 := config.Default
+	cfg2.Chain.EnablePruning = true
+	bc2 := NewBlockchain(&cfg2, InMemStateFactoryOption(), InMemDaoOption())
+	require.NotNil(bc2)
+	// the block of the snapshot has to match the verified header
+	header, err := bc.GetBlockByHeight(3)
+	require.NoError(err)
+	err = bc2.RestoreSnapshot(decoded, header, bc.SnapshotChunk)
+	require.Equal(state.ErrInvalidSnapshot, errors.Cause(err))
+	header, err = bc.GetBlockByHeight(4)
+	require.NoError(err)
+	require.NoError(bc2.RestoreSnapshot(decoded, header, bc.SnapshotChunk))
+	height, err := bc2.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(4), height)
+	tipHash, err := bc2.TipHash()
+	require.NoError(err)
+	require.Equal(manifest.Block.HashBlock(), tipHash)
+	for _, name := range []string{"producer", "charlie", "foxtrot"} {
+		balance, err := bc.Balance(ta.Addrinfo[name].RawAddress)
+		require.NoError(err)
+		restored, err := bc2.Balance(ta.Addrinfo[name].RawAddress)
+		require.NoError(err)
+		require.Equal(balance, restored)
+	}
+	_, err = bc2.GetBlockByHeight(4)
+	require.NoError(err)
+	_, err = bc2.GetBlockByHeight(3)
+	require.Error(err)
+	require.Error(bc2.RestoreSnapshot(decoded, header, bc.SnapshotChunk))
+	// the restored snapshot is served by the new chain
+	restored, err := bc2.SnapshotManifest()
+	require.NoError(err)
+	require.Equal(manifest.Chunks, restored.Chunks)
+
+	// both chains go on with the same block
+	tsf, err := action.NewTransfer(7, big.NewInt(1), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["alfa"].RawAddress)
+	require.NoError(err)
+	tsf, err = tsf.Sign(ta.Addrinfo["producer"])
+	require.NoError(err)
+	blk, err := bc.MintNewBlock([]action.Action{tsf}, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.NoError(bc.CommitBlock(blk))
+	require.NoError(bc2.ValidateBlock(blk))
+	require.NoError(bc2.CommitBlock(blk))
+	balance, err := bc2.Balance(ta.Addrinfo["alfa"].RawAddress)
+	require.NoError(err)
+	expected, err := bc.Balance(ta.Addrinfo["alfa"].RawAddress)
+	require.NoError(err)
+	require.Equal(expected, balance)
+
+	// the chunks not matching the manifest are rejected
+	bc3 := NewBlockchain(&cfg2, InMemStateFactoryOption(), InMemDaoOption())
+	require.NotNil(bc3)
+	last := manifest.Chunks[len(manifest.Chunks)-1]
+	err = bc3.RestoreSnapshot(manifest, header, func(h hash.Hash32B) ([]byte, error) {
+		data, err := bc.SnapshotChunk(h)
+		if h == last {
+			data = append(data, 0)
+		}
+		return data, err
+	})
+	require.Equal(state.ErrInvalidSnapshot, errors.Cause(err))
+	height, err = bc3.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(0), height)
+	// the chunks fetched before the failure are deleted
+	for _, h := range manifest.Chunks {
+		_, err := bc3.SnapshotChunk(h)
+		require.Error(err)
+	}
+}
+
+func TestSnapshotManifests(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	dao := newBlockDAO(db.NewMemKVStore())
+	require.NoError(dao.Start(ctx))
+	defer func() {
+		require.NoError(dao.Stop(ctx))
+	}()
+
+	chunk := func(i byte) hash.Hash32B {
+		return hash.Hash32B{i}
+	}
+	manifest := func(height uint64, chunks ...hash.Hash32B) *SnapshotManifest {
+		for _, h := range chunks {
+			require.NoError(dao.putSnapshotChunk(h, []byte{h[0]}))
+		}
+		cbTsf := action.NewCoinBaseTransfer(big.NewInt(1), ta.Addrinfo["producer"].RawAddress)
+		blk := NewBlock(0, height, hash.ZeroHash32B, []action.Action{cbTsf})
+		return &SnapshotManifest{Block: blk, Chunks: chunks}
+	}
+	require.NoError(dao.putSnapshotManifest(manifest(2, chunk(1), chunk(2))))
+	require.NoError(dao.putSnapshotManifest(manifest(4, chunk(2), chunk(3))))
+	// the chunks of the previous snapshot are kept for the fast syncs in progress
+	for i := byte(1); i <= 3; i++ {
+		_, err := dao.getSnapshotChunk(chunk(i))
+		require.NoError(err)
+	}
+	require.NoError(dao.putSnapshotManifest(manifest(6, chunk(3), chunk(4))))
+	_, err := dao.getSnapshotChunk(chunk(1))
+	require.Error(err)
+	for i := byte(2); i <= 4; i++ {
+		_, err := dao.getSnapshotChunk(chunk(i))
+		require.NoError(err)
+	}
+	latest, err := dao.getSnapshotManifest()
+	require.NoError(err)
+	require.Equal(uint64(6), latest.Block.Height())
+	prev, err := dao.getPrevSnapshotManifest()
+	require.NoError(err)
+	require.Equal(uint64(4), prev.Block.Height())
+
+	// the chunks of a snapshot not published are deleted, unless they are shared
+	m := manifest(8, chunk(4), chunk(5))
+	require.NoError(dao.discardSnapshotChunks(m.Chunks))
+	_, err = dao.getSnapshotChunk(chunk(4))
+	require.NoError(err)
+	_, err = dao.getSnapshotChunk(chunk(5))
+	require.Error(err)
+}
@@ -7,18 +7,22 @@
 package blocksync
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/iotexproject/iotex-core/actpool"
 	bc "github.com/iotexproject/iotex-core/blockchain"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/logger"
 	"github.com/iotexproject/iotex-core/network"
 	"github.com/iotexproject/iotex-core/network/node"
+	"github.com/iotexproject/iotex-core/pkg/hash"
 	"github.com/iotexproject/iotex-core/pkg/lifecycle"
 	"github.com/iotexproject/iotex-core/pkg/routine"
 	pb "github.com/iotexproject/iotex-core/proto"
@@ -42,6 +46,39 @@ type BlockSync interface {
 	ProcessBlock(blk *bc.Block) error
 	ProcessBlockSync(blk *bc.Block) error
 	SetTarget(net.Addr)
+	// ProcessSnapshotManifestRequest sends the manifest of the latest snapshot to the fast syncing peer
+	ProcessSnapshotManifestRequest(sender string, req *pb.SnapshotManifestReq) error
+	// ProcessSnapshotChunkRequest sends a chunk of the latest snapshot to the fast syncing peer
+	ProcessSnapshotChunkRequest(sender string, req *pb.SnapshotChunkReq) error
+	// ProcessSnapshotManifest hands the manifest of a snapshot over to the pending fast sync
+	ProcessSnapshotManifest(manifest *pb.SnapshotManifestPb) error
+	// ProcessSnapshotChunk hands a chunk of a snapshot over to the pending fast sync
+	ProcessSnapshotChunk(chunk *pb.SnapshotChunkPb) error
+}
+
+// HeaderChain is a chain of the block headers whose producers are verified against the delegates of their epochs, which
+// the fast sync trusts the block of a snapshot by
+type HeaderChain interface {
+	lifecycle.StartStopper
+
+	// TipHeight returns the height of the latest verified header
+	TipHeight() uint64
+	// HeaderByHeight returns the verified header of the given height as a block without actions
+	HeaderByHeight(uint64) (*bc.Block, error)
+	// Sync requests the headers following the tip
+	Sync()
+}
+
+// Option sets block syncer construction parameter
+type Option func(*blockSyncer, *config.Config) error
+
+// HeaderChainOption sets the header chain the fast sync verifies the block of the snapshot with. It is started only for
+// the fast sync, and stopped once the fast sync finishes
+func HeaderChainOption(hc HeaderChain) Option {
+	return func(bs *blockSyncer, cfg *config.Config) error {
+		bs.hc = hc
+		return nil
+	}
 }
 
 // blockSyncer implements BlockSync interface
@@ -56,6 +93,10 @@ type blockSyncer struct {
 	currRcvdHeight uint64               // height of most recent incoming block
 	lastRcvdHeight uint64               // height of last incoming block
 	rcvdBlocks     map[uint64]*bc.Block // buffer of received blocks
+	// the blocks of other forks waiting for their parents, keyed by the parent hash. The parents are requested one by
+	// one back to a known block, as long as the fork does not branch off deeper than the max reorg depth
+	orphans       map[hash.Hash32B]*bc.Block
+	maxReorgDepth uint64
 	actionTime     time.Time
 	sw             *SlidingWindow
 	bc             bc.Blockchain
@@ -63,6 +104,18 @@ type blockSyncer struct {
 	p2p            network.Overlay
 	task           *routine.RecurringTask
 	fnd            string
+	// with fast sync, a fresh node restores the states from the latest snapshot of fnd before syncing blocks, and the
+	// incoming blocks are dropped until the snapshot is restored
+	fastSync        bool
+	fastSyncing     bool
+	fastSyncTimeout time.Duration
+	cancelFastSync  context.CancelFunc
+	hc              HeaderChain
+	// the pending requests of the fast sync
+	snapshotMu sync.Mutex
+	manifestCh chan *pb.SnapshotManifestPb
+	chunkHash  hash.Hash32B
+	chunkCh    chan []byte
 }
 
 // SyncTaskInterval returns the recurring sync task interval, or 0 if this config should not need to run sync task
@@ -86,14 +139,21 @@ func NewBlockSyncer(
 	chain bc.Blockchain,
 	ap actpool.ActPool,
 	p2p network.Overlay,
+	opts ...Option,
 ) (BlockSync, error) {
 	bs := &blockSyncer{
 		state:      Idle,
 		rcvdBlocks: map[uint64]*bc.Block{},
+		orphans:    map[hash.Hash32B]*bc.Block{},
 		sw:         NewSlidingWindow(),
 		bc:         chain,
 		ap:         ap,
-		p2p:        p2p}
+		p2p:        p2p,
+
+		maxReorgDepth:   uint64(cfg.Chain.MaxReorgDepth),
+		fastSync:        cfg.BlockSync.EnableFastSync,
+		fastSyncTimeout: cfg.BlockSync.FastSyncTimeout,
+	}
 
 	bs.ackBlockCommit = cfg.IsDelegate() || cfg.IsFullnode()
 	bs.ackBlockSync = cfg.IsDelegate() || cfg.IsFullnode()
@@ -109,6 +169,14 @@ func NewBlockSyncer(
 			break
 		}
 	}
+	for _, opt := range opts {
+		if err := opt(bs, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if bs.fastSync && bs.hc == nil {
+		return nil, errors.New("fast sync needs a header chain to verify the block of the snapshot")
+	}
 	return bs, nil
 }
 
@@ -120,6 +188,20 @@ func (bs *blockSyncer) P2P() network.Overlay {
 // Start starts a block syncer
 func (bs *blockSyncer) Start(ctx context.Context) error {
 	logger.Debug().Msg("Starting block syncer")
+	if bs.fastSync && bs.fnd != "" {
+		height, err := bs.bc.TipHeight()
+		if err != nil {
+			return err
+		}
+		if height == 0 {
+			fsCtx, cancel := context.WithCancel(context.Background())
+			bs.mu.Lock()
+			bs.fastSyncing = true
+			bs.cancelFastSync = cancel
+			bs.mu.Unlock()
+			go bs.runFastSync(fsCtx)
+		}
+	}
 	if bs.task != nil {
 		bs.task.Start(ctx)
 	}
@@ -129,6 +211,11 @@ func (bs *blockSyncer) Start(ctx context.Context) error {
 // Stop stops a block syncer
 func (bs *blockSyncer) Stop(ctx context.Context) error {
 	logger.Debug().Msg("Stopping block syncer")
+	bs.mu.RLock()
+	if bs.cancelFastSync != nil {
+		bs.cancelFastSync()
+	}
+	bs.mu.RUnlock()
 	if bs.task != nil {
 		bs.task.Stop(ctx)
 	}
@@ -145,7 +232,7 @@ func (bs *blockSyncer) Sync() {
 	bs.mu.RLock()
 	defer bs.mu.RUnlock()
 
-	if bs.state == Idle {
+	if bs.state == Idle || bs.fastSyncing {
 		// simple exit if we haven't received any blocks
 		return
 	}
@@ -235,22 +322,19 @@ func (bs *blockSyncer) ProcessBlock(blk *bc.Block) error {
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
-	if !bs.ackBlockCommit {
-		// node is not meant to handle latest committed block, simply exit
+	if !bs.ackBlockCommit || bs.fastSyncing {
+		// node is not meant to handle latest committed block, or the chain is locked by the fast sync, simply exit
 		return nil
 	}
 	height, err := bs.bc.TipHeight()
 	if err != nil {
 		return err
 	}
-	if bs.currRcvdHeight = blk.Height(); bs.currRcvdHeight <= height {
-		err := fmt.Errorf(
-			"****** [%s] Received block height %d <= Blockchain tip height %d",
-			bs.p2p.Self().String(),
-			bs.currRcvdHeight,
-			height)
-		return err
+	if blk.Height() <= height {
+		// the block of another fork is handed over to the chain, which switches to the fork once it becomes longer
+		return bs.commitForkBlock(blk)
 	}
+	bs.currRcvdHeight = blk.Height()
 
 	if bs.state == Idle && bs.currRcvdHeight == height+1 {
 		// This is the special case where the first incoming block happens to be the next block following current
@@ -295,8 +379,8 @@ func (bs *blockSyncer) ProcessBlockSync(blk *bc.Block) error {
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
-	if !bs.ackBlockSync {
-		// node is not meant to handle sync block, simply exit
+	if !bs.ackBlockSync || bs.fastSyncing {
+		// node is not meant to handle sync block, or the chain is locked by the fast sync, simply exit
 		return nil
 	}
 
@@ -305,13 +389,8 @@ func (bs *blockSyncer) ProcessBlockSync(blk *bc.Block) error {
 		return err
 	}
 	if blk.Height() <= height {
-		//TODO make it structured logging
-		logger.Warn().Msgf(
-			"****** [%s] Received block height %d <= Blockchain tip height %d",
-			bs.p2p.Self().String(),
-			blk.Height(),
-			height)
-		return nil
+		// the block of another fork, or a parent requested for one
+		return bs.commitForkBlock(blk)
 	}
 
 	// check-in incoming block to the buffer
@@ -321,6 +400,61 @@ func (bs *blockSyncer) ProcessBlockSync(blk *bc.Block) error {
 	return bs.commitBlocksInBuffer()
 }
 
+// ProcessSnapshotManifestRequest sends the manifest of the latest snapshot, nothing is sent if there is no snapshot
+func (bs *blockSyncer) ProcessSnapshotManifestRequest(sender string, req *pb.SnapshotManifestReq) error {
+	if !bs.ackSyncReq {
+		// node is not meant to handle sync request, simply exit
+		return nil
+	}
+	manifest, err := bs.bc.SnapshotManifest()
+	if errors.Cause(err) == bc.ErrNoSnapshot {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return bs.p2p.Tell(node.NewTCPNode(sender), manifest.ConvertToSnapshotManifestPb())
+}
+
+// ProcessSnapshotChunkRequest sends the chunk of the requested hash of the latest snapshot
+func (bs *blockSyncer) ProcessSnapshotChunkRequest(sender string, req *pb.SnapshotChunkReq) error {
+	if !bs.ackSyncReq {
+		// node is not meant to handle sync request, simply exit
+		return nil
+	}
+	var h hash.Hash32B
+	copy(h[:], req.Hash)
+	data, err := bs.bc.SnapshotChunk(h)
+	if err != nil {
+		return err
+	}
+	return bs.p2p.Tell(node.NewTCPNode(sender), &pb.SnapshotChunkPb{Hash: h[:], Data: data})
+}
+
+// ProcessSnapshotManifest hands the manifest over to the fast sync waiting for it
+func (bs *blockSyncer) ProcessSnapshotManifest(manifest *pb.SnapshotManifestPb) error {
+	bs.snapshotMu.Lock()
+	defer bs.snapshotMu.Unlock()
+
+	if bs.manifestCh != nil {
+		bs.manifestCh <- manifest
+		bs.manifestCh = nil
+	}
+	return nil
+}
+
+// ProcessSnapshotChunk hands the chunk over to the fast sync waiting for it
+func (bs *blockSyncer) ProcessSnapshotChunk(chunk *pb.SnapshotChunkPb) error {
+	bs.snapshotMu.Lock()
+	defer bs.snapshotMu.Unlock()
+
+	if bs.chunkCh != nil && bytes.Equal(chunk.Hash, bs.chunkHash[:]) {
+		bs.chunkCh <- chunk.Data
+		bs.chunkCh = nil
+	}
+	return nil
+}
+
 // checkBlockIntoBuffer adds a received blocks into the buffer
 func (bs *blockSyncer) checkBlockIntoBuffer(blk *bc.Block) error {
 	height := blk.Height()
@@ -347,7 +481,13 @@ func (bs *blockSyncer) commitBlocksInBuffer() error {
 	next := height + 1
 	for blk := bs.rcvdBlocks[next]; blk != nil; {
 		if err := bs.bc.CommitBlock(blk); err != nil {
-			return err
+			if errors.Cause(err) != bc.ErrUnknownParent {
+				return err
+			}
+			// the block follows another fork than the tip
+			delete(bs.rcvdBlocks, next)
+			bs.requestParent(blk)
+			return nil
 		}
 		delete(bs.rcvdBlocks, next)
 
@@ -363,6 +503,9 @@ func (bs *blockSyncer) commitBlocksInBuffer() error {
 
 		// update sliding window
 		bs.sw.Update(next)
+		if err := bs.commitOrphans(blk.HashBlock()); err != nil {
+			return err
+		}
 		height, err = bs.bc.TipHeight()
 		if err != nil {
 			return err
@@ -372,3 +515,172 @@ func (bs *blockSyncer) commitBlocksInBuffer() error {
 	}
 	return nil
 }
+
+// commitForkBlock hands a block at or below the tip over to the chain, which stores it as a side block and switches to
+// its fork once the fork becomes longer than the current chain. The parent of the block is requested if it is unknown
+func (bs *blockSyncer) commitForkBlock(blk *bc.Block) error {
+	if err := bs.bc.CommitBlock(blk); err != nil {
+		if errors.Cause(err) != bc.ErrUnknownParent {
+			return err
+		}
+		bs.requestParent(blk)
+		return nil
+	}
+	if err := bs.commitOrphans(blk.HashBlock()); err != nil {
+		return err
+	}
+	// the chain may have switched to the fork, so the actions are checked against the new tip
+	bs.ap.Reset()
+	return bs.commitBlocksInBuffer()
+}
+
+// requestParent keeps the block until its parent is committed, and requests the parent from fnd. Nothing is requested
+// once the fork branches off deeper than the max reorg depth, which the chain would refuse to switch to anyway
+func (bs *blockSyncer) requestParent(blk *bc.Block) {
+	height, err := bs.bc.TipHeight()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get tip height")
+		return
+	}
+	if blk.Height() <= 1 || blk.Height()-1+bs.maxReorgDepth < height {
+		logger.Warn().Uint64("height", blk.Height()).Uint64("tip", height).Msg("Dropped a block of a too deep fork")
+		return
+	}
+	parent := blk.PrevHash()
+	if _, ok := bs.orphans[parent]; !ok && uint64(len(bs.orphans)) >= bs.maxReorgDepth {
+		logger.Warn().Uint64("height", blk.Height()).Msg("Dropped a block of a fork, too many blocks wait for parents")
+		return
+	}
+	bs.orphans[parent] = blk
+	bs.p2p.Tell(node.NewTCPNode(bs.fnd), &pb.BlockSync{Start: blk.Height() - 1, End: blk.Height() - 1})
+	logger.Info().
+		Uint64("height", blk.Height()-1).
+		Hex("hash", parent[:]).
+		Str("to", bs.fnd).
+		Msg("Requested the parent of a block of another fork")
+}
+
+// commitOrphans commits the blocks waiting for the block of the given hash, and then the ones waiting for them
+func (bs *blockSyncer) commitOrphans(h hash.Hash32B) error {
+	for blk, ok := bs.orphans[h]; ok; blk, ok = bs.orphans[h] {
+		delete(bs.orphans, h)
+		if err := bs.bc.CommitBlock(blk); err != nil {
+			return err
+		}
+		h = blk.HashBlock()
+	}
+	return nil
+}
+
+// runFastSync restores the states from the latest snapshot of fnd, and falls back to syncing the blocks from genesis if
+// the snapshot cannot be restored. The header chain runs along with the fast sync
+func (bs *blockSyncer) runFastSync(ctx context.Context) {
+	if err := bs.hc.Start(ctx); err != nil {
+		logger.Error().Err(err).Msg("Failed to start header chain, syncing blocks from genesis instead")
+	} else {
+		if err := bs.restoreSnapshot(ctx); err != nil {
+			logger.Error().Err(err).Str("from", bs.fnd).Msg("Failed to fast sync, syncing blocks from genesis instead")
+		}
+		if err := bs.hc.Stop(ctx); err != nil {
+			logger.Error().Err(err).Msg("Failed to stop header chain")
+		}
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.fastSyncing = false
+	bs.cancelFastSync = nil
+}
+
+// restoreSnapshot requests the manifest of the latest snapshot of fnd, syncs the header chain up to the block of the
+// snapshot, and then requests the chunks one by one for the chain to restore the states
+func (bs *blockSyncer) restoreSnapshot(ctx context.Context) error {
+	defer bs.cancelSnapshotRequests()
+
+	manifestCh := make(chan *pb.SnapshotManifestPb, 1)
+	bs.snapshotMu.Lock()
+	bs.manifestCh = manifestCh
+	bs.snapshotMu.Unlock()
+	if err := bs.p2p.Tell(node.NewTCPNode(bs.fnd), &pb.SnapshotManifestReq{}); err != nil {
+		return errors.Wrap(err, "failed to request snapshot manifest")
+	}
+	var pbManifest *pb.SnapshotManifestPb
+	select {
+	case pbManifest = <-manifestCh:
+	case <-time.After(bs.fastSyncTimeout):
+		return errors.New("timed out waiting for snapshot manifest")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	manifest := &bc.SnapshotManifest{}
+	if err := manifest.ConvertFromSnapshotManifestPb(pbManifest); err != nil {
+		return err
+	}
+	logger.Info().
+		Uint64("height", manifest.Block.Height()).
+		Int("chunks", len(manifest.Chunks)).
+		Str("from", bs.fnd).
+		Msg("Restoring snapshot")
+	header, err := bs.waitForHeader(ctx, manifest.Block.Height())
+	if err != nil {
+		return err
+	}
+	return bs.bc.RestoreSnapshot(manifest, header, func(h hash.Hash32B) ([]byte, error) {
+		return bs.requestSnapshotChunk(ctx, h)
+	})
+}
+
+// waitForHeader waits for the header chain to reach the given height, and returns the header of the height. The headers
+// are requested again whenever the header chain stalls, and the wait times out if it makes no progress for the fast
+// sync timeout
+func (bs *blockSyncer) waitForHeader(ctx context.Context, height uint64) (*bc.Block, error) {
+	ticker := time.NewTicker(bs.fastSyncTimeout / 10)
+	defer ticker.Stop()
+	tip := bs.hc.TipHeight()
+	progress := time.Now()
+	bs.hc.Sync()
+	for tip < height {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if h := bs.hc.TipHeight(); h > tip {
+			tip, progress = h, time.Now()
+			continue
+		}
+		if time.Since(progress) > bs.fastSyncTimeout {
+			return nil, errors.Errorf("timed out syncing the headers at height %d", tip)
+		}
+		bs.hc.Sync()
+	}
+	return bs.hc.HeaderByHeight(height)
+}
+
+// requestSnapshotChunk requests a chunk of the snapshot from fnd and waits for it
+func (bs *blockSyncer) requestSnapshotChunk(ctx context.Context, h hash.Hash32B) ([]byte, error) {
+	chunkCh := make(chan []byte, 1)
+	bs.snapshotMu.Lock()
+	bs.chunkHash = h
+	bs.chunkCh = chunkCh
+	bs.snapshotMu.Unlock()
+	if err := bs.p2p.Tell(node.NewTCPNode(bs.fnd), &pb.SnapshotChunkReq{Hash: h[:]}); err != nil {
+		return nil, err
+	}
+	select {
+	case data := <-chunkCh:
+		return data, nil
+	case <-time.After(bs.fastSyncTimeout):
+		return nil, errors.New("timed out waiting for snapshot chunk")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// cancelSnapshotRequests drops the responses to the pending requests of the fast sync
+func (bs *blockSyncer) cancelSnapshotRequests() {
+	bs.snapshotMu.Lock()
+	defer bs.snapshotMu.Unlock()
+
+	bs.manifestCh = nil
+	bs.chunkCh = nil
+}
@@ -8,22 +8,30 @@ package blocksync
 
 import (
 	"context"
+	"math/big"
+	"net"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/iotex-core/actpool"
 	bc "github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/blockchain/action"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/network"
+	"github.com/iotexproject/iotex-core/network/node"
 	"github.com/iotexproject/iotex-core/pkg/hash"
 	pb "github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/test/mock/mock_blockchain"
 	"github.com/iotexproject/iotex-core/test/mock/mock_blocksync"
+	"github.com/iotexproject/iotex-core/test/mock/mock_network"
 	ta "github.com/iotexproject/iotex-core/test/testaddress"
 	"github.com/iotexproject/iotex-core/testutil"
 )
@@ -198,7 +206,7 @@ func TestBlockSyncer_ProcessBlock_TipHeightError(t *testing.T) {
 
 	bs, err := NewBlockSyncer(cfgFullNode, mBc, nil, p2p)
 	assert.Nil(err)
-	blk := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil, nil)
+	blk := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil)
 	bs.(*blockSyncer).ackBlockCommit = false
 	assert.Nil(bs.ProcessBlock(blk))
 
@@ -216,7 +224,7 @@ func TestBlockSyncer_ProcessBlock_TipHeight(t *testing.T) {
 	mBc.EXPECT().TipHeight().AnyTimes().Return(uint64(5), nil)
 	mBc.EXPECT().CommitBlock(gomock.Any()).AnyTimes()
 
-	apConfig := config.ActPool{8192, 256}
+	apConfig := config.ActPool{MaxNumActPerPool: 8192, MaxNumActPerAcct: 256}
 	ap, err := actpool.NewActPool(mBc, apConfig)
 
 	p2p := generateP2P()
@@ -228,23 +236,23 @@ func TestBlockSyncer_ProcessBlock_TipHeight(t *testing.T) {
 
 	bs, err := NewBlockSyncer(cfgFullNode, mBc, ap, p2p)
 	assert.Nil(err)
-	blk := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil, nil)
+	blk := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil)
 
 	bs.(*blockSyncer).ackBlockCommit = true
-	// less than tip height
-	assert.Error(bs.ProcessBlock(blk))
+	// less than tip height, handed over to the chain as a block of another fork
+	assert.Nil(bs.ProcessBlock(blk))
 
 	// special case
 	bs.(*blockSyncer).state = Idle
-	blkHeightSpecial := bc.NewBlock(uint32(123), uint64(6), hash.Hash32B{}, nil, nil)
+	blkHeightSpecial := bc.NewBlock(uint32(123), uint64(6), hash.Hash32B{}, nil)
 	assert.Nil(bs.ProcessBlock(blkHeightSpecial))
 
 	// < block height
-	blkHeightLess := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil, nil)
-	assert.Error(bs.ProcessBlock(blkHeightLess))
+	blkHeightLess := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil)
+	assert.Nil(bs.ProcessBlock(blkHeightLess))
 
 	// > block height
-	blkHeightMore := bc.NewBlock(uint32(123), uint64(7), hash.Hash32B{}, nil, nil)
+	blkHeightMore := bc.NewBlock(uint32(123), uint64(7), hash.Hash32B{}, nil)
 	assert.Nil(bs.ProcessBlock(blkHeightMore))
 }
 
@@ -256,10 +264,11 @@ func TestBlockSyncer_ProcessBlockSync(t *testing.T) {
 
 	mBc := mock_blockchain.NewMockBlockchain(ctrl)
 	mBc.EXPECT().TipHeight().Times(1).Return(uint64(0), errors.New("Error"))
-	mBc.EXPECT().TipHeight().Times(1).Return(uint64(5), nil)
-	mBc.EXPECT().TipHeight().Times(1).Return(uint64(6), nil)
+	mBc.EXPECT().TipHeight().AnyTimes().Return(uint64(5), nil)
+	mBc.EXPECT().CommitBlock(gomock.Any()).Times(1).Return(nil)
+	mBc.EXPECT().CommitBlock(gomock.Any()).Times(1).Return(errors.Wrap(bc.ErrInvalidBlock, "Error"))
 
-	apConfig := config.ActPool{8192, 256}
+	apConfig := config.ActPool{MaxNumActPerPool: 8192, MaxNumActPerAcct: 256}
 	ap, err := actpool.NewActPool(mBc, apConfig)
 
 	p2p := generateP2P()
@@ -271,14 +280,15 @@ func TestBlockSyncer_ProcessBlockSync(t *testing.T) {
 
 	bs, err := NewBlockSyncer(cfgFullNode, mBc, ap, p2p)
 	assert.Nil(err)
-	blk := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil, nil)
+	blk := bc.NewBlock(uint32(123), uint64(4), hash.Hash32B{}, nil)
 	bs.(*blockSyncer).ackBlockSync = false
 	assert.Nil(bs.ProcessBlockSync(blk))
 
 	bs.(*blockSyncer).ackBlockSync = true
 	assert.Error(bs.ProcessBlockSync(blk))
+	// less than tip height, handed over to the chain as a block of another fork
 	assert.Nil(bs.ProcessBlockSync(blk))
-	assert.Nil(bs.ProcessBlockSync(blk))
+	assert.Error(bs.ProcessBlockSync(blk))
 }
 
 func TestBlockSyncer_Sync(t *testing.T) {
@@ -305,16 +315,281 @@ func TestBlockSyncer_Sync(t *testing.T) {
 		testutil.CleanupPath(t, cfg.Chain.TrieDBPath)
 	}()
 
-	blk, err := chain.MintNewBlock(nil, nil, ta.Addrinfo["producer"], "")
+	blk, err := chain.MintNewBlock(nil, ta.Addrinfo["producer"], "")
 	require.NotNil(blk)
 	require.Nil(bs.ProcessBlock(blk))
 
-	blk, err = chain.MintNewBlock(nil, nil, ta.Addrinfo["producer"], "")
+	blk, err = chain.MintNewBlock(nil, ta.Addrinfo["producer"], "")
 	require.NotNil(blk)
 	require.Nil(bs.ProcessBlock(blk))
 	time.Sleep(time.Millisecond << 7)
 }
 
+func TestBlockSyncer_Reorg(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Default
+	cfg.NodeType = config.FullNodeType
+	cfg.BlockSync.Interval = 0
+	// Disable block reward to make bookkeeping easier
+	defer func(reward uint64) { bc.Gen.BlockReward = reward }(bc.Gen.BlockReward)
+	bc.Gen.BlockReward = uint64(0)
+	newChain := func(recipient string, n int) bc.Blockchain {
+		sf, err := state.NewFactory(&cfg, state.InMemTrieOption())
+		require.NoError(err)
+		_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, bc.Gen.TotalSupply)
+		require.NoError(err)
+		chain := bc.NewBlockchain(&cfg, bc.PrecreatedStateFactoryOption(sf), bc.InMemDaoOption())
+		require.NotNil(chain)
+		for i := 1; i <= n; i++ {
+			tsf, err := action.NewTransfer(uint64(i), big.NewInt(1), ta.Addrinfo["producer"].RawAddress, recipient)
+			require.NoError(err)
+			tsf, err = tsf.Sign(ta.Addrinfo["producer"])
+			require.NoError(err)
+			blk, err := chain.MintNewBlock([]action.Action{tsf}, ta.Addrinfo["producer"], "")
+			require.NoError(err)
+			require.NoError(chain.CommitBlock(blk))
+		}
+		return chain
+	}
+
+	// two nodes grow different chains during a partition
+	chain1 := newChain(ta.Addrinfo["alfa"].RawAddress, 2)
+	chain2 := newChain(ta.Addrinfo["bravo"].RawAddress, 4)
+	ap, err := actpool.NewActPool(chain1, cfg.ActPool)
+	require.NoError(err)
+
+	// the overlays pass the sync requests and the synced blocks between the nodes. The blocks are delivered
+	// asynchronously, as the syncer handles them while requesting their parents
+	addr1 := node.NewTCPNode("127.0.0.1:10000")
+	addr2 := node.NewTCPNode("127.0.0.1:10001")
+	p2p1 := mock_network.NewMockOverlay(ctrl)
+	p2p2 := mock_network.NewMockOverlay(ctrl)
+	p2p1.EXPECT().Self().Return(addr1).AnyTimes()
+	p2p2.EXPECT().Self().Return(addr2).AnyTimes()
+	cfg.Network.BootstrapNodes = []string{addr2.String()}
+	bs1, err := NewBlockSyncer(&cfg, chain1, ap, p2p1)
+	require.NoError(err)
+	bs2, err := NewBlockSyncer(&cfg, chain2, nil, p2p2)
+	require.NoError(err)
+	p2p1.EXPECT().Tell(addr2, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		if msg, ok := msg.(*pb.BlockSync); ok {
+			return bs2.ProcessSyncRequest(addr1.String(), msg)
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+	p2p2.EXPECT().Tell(addr1, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		if msg, ok := msg.(*pb.BlockContainer); ok {
+			blk := &bc.Block{}
+			blk.ConvertFromBlockPb(msg.Block)
+			go func() {
+				if err := bs1.ProcessBlockSync(blk); err != nil {
+					t.Error(err)
+				}
+			}()
+			return nil
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+
+	// once the node on the shorter chain receives the tip of the longer one, the sync task requests the blocks above
+	// its own tip. They do not link to its tip, so it requests their parents back to the common genesis, and switches
+	// to the longer fork
+	tip, err := chain2.GetBlockByHeight(4)
+	require.NoError(err)
+	require.NoError(bs1.ProcessBlock(tip))
+	bs1.(*blockSyncer).Sync()
+	require.NoError(testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
+		tipHash, err := chain1.TipHash()
+		return tipHash == tip.HashBlock(), err
+	}))
+	for height := uint64(1); height <= 4; height++ {
+		hash1, err := chain1.GetHashByHeight(height)
+		require.NoError(err)
+		hash2, err := chain2.GetHashByHeight(height)
+		require.NoError(err)
+		require.Equal(hash2, hash1)
+	}
+	balance, err := chain1.Balance(ta.Addrinfo["bravo"].RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(4), balance)
+	bs1.(*blockSyncer).mu.RLock()
+	defer bs1.(*blockSyncer).mu.RUnlock()
+	require.Empty(bs1.(*blockSyncer).orphans)
+}
+
+func TestBlockSyncer_FastSync(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	// the full node takes a snapshot at every block
+	cfg := config.Default
+	cfg.NodeType = config.FullNodeType
+	cfg.BlockSync.Interval = 0
+	cfg.Chain.EnableArchiveMode = true
+	cfg.Chain.SnapshotInterval = 1
+	cfg.Chain.SnapshotChunkSize = 2
+	sf, err := state.NewFactory(&cfg, state.InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(ta.Addrinfo["producer"].RawAddress, bc.Gen.TotalSupply)
+	require.NoError(err)
+	chain := bc.NewBlockchain(&cfg, bc.PrecreatedStateFactoryOption(sf), bc.InMemDaoOption())
+	require.NotNil(chain)
+	tsf, err := action.NewTransfer(1, big.NewInt(3), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["charlie"].RawAddress)
+	require.NoError(err)
+	tsf, err = tsf.Sign(ta.Addrinfo["producer"])
+	require.NoError(err)
+	blk, err := chain.MintNewBlock([]action.Action{tsf}, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.NoError(chain.CommitBlock(blk))
+	blk, err = chain.MintNewBlock(nil, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.NoError(chain.CommitBlock(blk))
+	require.NoError(testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
+		manifest, err := chain.SnapshotManifest()
+		return err == nil && manifest.Block.Height() == 2, nil
+	}))
+
+	// the overlays pass the messages between the fresh node and the full node right away
+	fnAddr := node.NewTCPNode("127.0.0.1:10000")
+	newAddr := node.NewTCPNode("127.0.0.1:10001")
+	fnP2P := mock_network.NewMockOverlay(ctrl)
+	newP2P := mock_network.NewMockOverlay(ctrl)
+	fnP2P.EXPECT().Self().Return(fnAddr).AnyTimes()
+	newP2P.EXPECT().Self().Return(newAddr).AnyTimes()
+	fnBs, err := NewBlockSyncer(&cfg, chain, nil, fnP2P)
+	require.NoError(err)
+	var newBs BlockSync
+	newP2P.EXPECT().Tell(fnAddr, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		switch msg := msg.(type) {
+		case *pb.SnapshotManifestReq:
+			return fnBs.ProcessSnapshotManifestRequest(newAddr.String(), msg)
+		case *pb.SnapshotChunkReq:
+			return fnBs.ProcessSnapshotChunkRequest(newAddr.String(), msg)
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+	fnP2P.EXPECT().Tell(newAddr, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		switch msg := msg.(type) {
+		case *pb.SnapshotManifestPb:
+			return newBs.ProcessSnapshotManifest(msg)
+		case *pb.SnapshotChunkPb:
+			return newBs.ProcessSnapshotChunk(msg)
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+
+	// the fresh node restores the snapshot of block 2, and drops the blocks until then
+	newCfg := config.Default
+	newCfg.NodeType = config.FullNodeType
+	newCfg.BlockSync.Interval = 0
+	newCfg.BlockSync.EnableFastSync = true
+	newCfg.BlockSync.FastSyncTimeout = time.Second
+	newCfg.Chain.EnablePruning = true
+	newCfg.Network.BootstrapNodes = []string{fnAddr.String()}
+	newChain := bc.NewBlockchain(&newCfg, bc.InMemStateFactoryOption(), bc.InMemDaoOption())
+	require.NotNil(newChain)
+	_, err = NewBlockSyncer(&newCfg, newChain, nil, newP2P)
+	require.Error(err)
+	newBs, err = NewBlockSyncer(&newCfg, newChain, nil, newP2P, HeaderChainOption(&testHeaderChain{chain: chain}))
+	require.NoError(err)
+	require.NoError(newBs.Start(ctx))
+	defer func() {
+		require.NoError(newBs.Stop(ctx))
+	}()
+	require.NoError(testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
+		newBs.(*blockSyncer).mu.RLock()
+		defer newBs.(*blockSyncer).mu.RUnlock()
+		return !newBs.(*blockSyncer).fastSyncing, nil
+	}))
+	height, err := newChain.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(2), height)
+	balance, err := newChain.Balance(ta.Addrinfo["charlie"].RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(3), balance)
+
+	// the fresh node falls back to the block sync if the full node does not have a snapshot
+	newChain = bc.NewBlockchain(&newCfg, bc.InMemStateFactoryOption(), bc.InMemDaoOption())
+	require.NotNil(newChain)
+	newCfg.BlockSync.FastSyncTimeout = 10 * time.Millisecond
+	newBs, err = NewBlockSyncer(&newCfg, newChain, nil, newP2P, HeaderChainOption(&testHeaderChain{chain: chain}))
+	require.NoError(err)
+	fnBs.(*blockSyncer).ackSyncReq = false
+	require.NoError(newBs.Start(ctx))
+	require.NoError(testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
+		newBs.(*blockSyncer).mu.RLock()
+		defer newBs.(*blockSyncer).mu.RUnlock()
+		return !newBs.(*blockSyncer).fastSyncing, nil
+	}))
+	height, err = newChain.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(0), height)
+	require.NoError(newBs.Stop(ctx))
+
+	// the fresh node rejects the snapshot whose block does not match the verified header
+	newChain = bc.NewBlockchain(&newCfg, bc.InMemStateFactoryOption(), bc.InMemDaoOption())
+	require.NotNil(newChain)
+	newCfg.BlockSync.FastSyncTimeout = time.Second
+	hc := &testHeaderChain{chain: chain, offset: 1}
+	newBs, err = NewBlockSyncer(&newCfg, newChain, nil, newP2P, HeaderChainOption(hc))
+	require.NoError(err)
+	fnBs.(*blockSyncer).ackSyncReq = true
+	require.NoError(newBs.Start(ctx))
+	require.NoError(testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
+		newBs.(*blockSyncer).mu.RLock()
+		defer newBs.(*blockSyncer).mu.RUnlock()
+		return !newBs.(*blockSyncer).fastSyncing, nil
+	}))
+	height, err = newChain.TipHeight()
+	require.NoError(err)
+	require.Equal(uint64(0), height)
+	require.False(hc.isRunning())
+}
+
+// testHeaderChain serves the blocks of a chain as the verified headers, shifted down by the offset
+type testHeaderChain struct {
+	chain   bc.Blockchain
+	offset  uint64
+	mu      sync.Mutex
+	running bool
+}
+
+func (hc *testHeaderChain) Start(_ context.Context) error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.running = true
+	return nil
+}
+
+func (hc *testHeaderChain) Stop(_ context.Context) error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.running = false
+	return nil
+}
+
+func (hc *testHeaderChain) isRunning() bool {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	return hc.running
+}
+
+func (hc *testHeaderChain) TipHeight() uint64 {
+	height, _ := hc.chain.TipHeight()
+	return height
+}
+
+func (hc *testHeaderChain) HeaderByHeight(height uint64) (*bc.Block, error) {
+	return hc.chain.GetBlockByHeight(height - hc.offset)
+}
+
+func (hc *testHeaderChain) Sync() {}
+
 func newTestConfig() (*config.Config, error) {
 	cfg := config.Default
 	cfg.Chain.TrieDBPath = "trie.test"
@@ -86,16 +86,27 @@ var (
 		Chain: Chain{
 			ChainDBPath:        "/tmp/chain.db",
 			TrieDBPath:         "/tmp/trie.db",
+			HeaderDBPath:       "/tmp/header.db",
 			ProducerPubKey:     keypair.EncodePublicKey(keypair.ZeroPublicKey),
 			ProducerPrivKey:    keypair.EncodePrivateKey(keypair.ZeroPrivateKey),
 			InMemTest:          false,
-			GenesisActionsPath: "",
+			GenesisPath:        "",
 			DelegateLRUSize:    10,
 			NumCandidates:      101,
+			MaxReorgDepth:      64,
+			MaxSideBlocks:      256,
+			EnablePruning:      false,
+			PruneDepth:         1000,
+			PruneInterval:      10 * time.Minute,
+			EnableArchiveMode:  false,
+			SnapshotInterval:   0,
+			SnapshotChunkSize:  1000,
+			TrieNodeCacheSize:  100000,
 		},
 		ActPool: ActPool{
 			MaxNumActPerPool: 32000,
 			MaxNumActPerAcct: 2000,
+			MinGasPrice:      0,
 		},
 		Consensus: Consensus{
 			Scheme: NOOPScheme,
@@ -118,7 +129,9 @@ var (
 			BlockCreationInterval: 10 * time.Second,
 		},
 		BlockSync: BlockSync{
-			Interval: 10 * time.Second,
+			Interval:        10 * time.Second,
+			EnableFastSync:  false,
+			FastSyncTimeout: 30 * time.Second,
 		},
 
 		Delegate: Delegate{
@@ -136,6 +149,8 @@ var (
 		},
 		System: System{
 			HeartbeatInterval: 10 * time.Second,
+			HTTPMetricsHost:   "127.0.0.1",
+			HTTPMetricsPort:   0,
 		},
 	}
 
@@ -191,15 +206,38 @@ type (
 	Chain struct {
 		ChainDBPath string `yaml:"chainDBPath"`
 		TrieDBPath  string `yaml:"trieDBPath"`
+		// HeaderDBPath is where the lightweight node stores the synced block headers
+		HeaderDBPath string `yaml:"headerDBPath"`
 
 		ProducerPubKey  string `yaml:"producerPubKey"`
 		ProducerPrivKey string `yaml:"producerPrivKey"`
 
 		// InMemTest creates in-memory DB file for local testing
-		InMemTest          bool   `yaml:"inMemTest"`
-		GenesisActionsPath string `yaml:"genesisActionsPath"`
-		DelegateLRUSize    uint   `yaml:"delegateLRUSize"`
-		NumCandidates      uint   `yaml:"numCandidates"`
+		InMemTest bool `yaml:"inMemTest"`
+		// GenesisPath is the genesis document of the chain, whose consensus parameters override the ones of the config
+		GenesisPath     string `yaml:"genesisPath"`
+		DelegateLRUSize uint   `yaml:"delegateLRUSize"`
+		NumCandidates   uint   `yaml:"numCandidates"`
+		// MaxReorgDepth is the max number of blocks that can be reverted when switching to a longer fork
+		MaxReorgDepth uint `yaml:"maxReorgDepth"`
+		// MaxSideBlocks is the max number of the blocks off the canonical chain stored for the fork choice
+		MaxSideBlocks uint `yaml:"maxSideBlocks"`
+		// EnablePruning keeps only the most recent PruneDepth blocks and state versions, older block bodies and unreachable
+		// trie nodes are deleted in background every PruneInterval
+		EnablePruning bool          `yaml:"enablePruning"`
+		PruneDepth    uint          `yaml:"pruneDepth"`
+		PruneInterval time.Duration `yaml:"pruneInterval"`
+		// EnableArchiveMode keeps the states of all the blocks, so that the states can be queried at any height
+		EnableArchiveMode bool `yaml:"enableArchiveMode"`
+		// SnapshotInterval is the number of blocks between the snapshots of the states served to the fast syncing nodes,
+		// which are split into chunks of SnapshotChunkSize accounts. No snapshot is taken if it is 0. Once the epoch
+		// reward is in effect, a snapshot can only be taken at the end of an epoch, so the interval needs to be a
+		// multiple of the epoch length
+		SnapshotInterval  uint64 `yaml:"snapshotInterval"`
+		SnapshotChunkSize uint   `yaml:"snapshotChunkSize"`
+		// TrieNodeCacheSize is the max number of the trie nodes of the states cached in memory, no node is cached if it
+		// is 0
+		TrieNodeCacheSize uint `yaml:"trieNodeCacheSize"`
 	}
 
 	// Consensus is the config struct for consensus package
@@ -213,6 +251,10 @@ type (
 	// BlockSync is the config struct for the BlockSync
 	BlockSync struct {
 		Interval time.Duration `yaml:"interval"` // update duration
+		// EnableFastSync restores the states from the latest snapshot of a peer when the chain is fresh, and syncs only
+		// the blocks after the snapshot. Each request to the peer times out after FastSyncTimeout
+		EnableFastSync  bool          `yaml:"enableFastSync"`
+		FastSyncTimeout time.Duration `yaml:"fastSyncTimeout"`
 	}
 
 	// RollDPoS is the config struct for RollDPoS consensus package
@@ -254,12 +296,18 @@ type (
 	// System is the system config
 	System struct {
 		HeartbeatInterval time.Duration `yaml:"heartbeatInterval"`
+		// HTTPMetricsHost is the host the Prometheus metrics server binds to
+		HTTPMetricsHost string `yaml:"httpMetricsHost"`
+		// HTTPMetricsPort is the port serving the Prometheus metrics at /metrics, which are not served if it is 0
+		HTTPMetricsPort int `yaml:"httpMetricsPort"`
 	}
 
 	// ActPool is the actpool config
 	ActPool struct {
 		MaxNumActPerPool uint64 `yaml:"maxNumActPerPool"`
 		MaxNumActPerAcct uint64 `yaml:"maxNumActPerAcct"`
+		// MinGasPrice is the lowest gas price of the actions accepted into the pool
+		MinGasPrice uint64 `yaml:"minGasPrice"`
 	}
 
 	// Config is the root config struct, each package's config should be put as its sub struct
@@ -372,6 +420,33 @@ func ValidateChain(cfg *Config) error {
 	if cfg.Consensus.Scheme == RollDPoSScheme && cfg.Chain.NumCandidates < cfg.Consensus.RollDPoS.NumDelegates {
 		return errors.Wrapf(ErrInvalidCfg, "candidate number should be greater than or equal to delegate number")
 	}
+	if cfg.Chain.EnablePruning {
+		if cfg.Chain.PruneDepth == 0 || cfg.Chain.PruneDepth < cfg.Chain.MaxReorgDepth {
+			return errors.Wrapf(ErrInvalidCfg, "prune depth should be positive and no less than max reorg depth")
+		}
+		if cfg.Chain.PruneInterval <= 0 {
+			return errors.Wrapf(ErrInvalidCfg, "prune interval should be greater than 0")
+		}
+		if cfg.Chain.EnableArchiveMode {
+			return errors.Wrapf(ErrInvalidCfg, "archive mode cannot be enabled along with pruning")
+		}
+	}
+	if cfg.Chain.SnapshotInterval > 0 {
+		if cfg.Chain.SnapshotChunkSize == 0 {
+			return errors.Wrapf(ErrInvalidCfg, "snapshot chunk size should be greater than 0")
+		}
+		if !cfg.Chain.EnablePruning && !cfg.Chain.EnableArchiveMode {
+			return errors.Wrapf(ErrInvalidCfg, "snapshots require pruning or archive mode to keep the states being exported")
+		}
+	}
+	if cfg.BlockSync.EnableFastSync {
+		if !cfg.Chain.EnablePruning && !cfg.Chain.EnableArchiveMode {
+			return errors.Wrapf(ErrInvalidCfg, "fast sync requires pruning or archive mode to persist the restored states")
+		}
+		if cfg.BlockSync.FastSyncTimeout <= 0 {
+			return errors.Wrapf(ErrInvalidCfg, "fast sync timeout should be greater than 0")
+		}
+	}
 	return nil
 }
 
@@ -384,8 +459,9 @@ func ValidateConsensusScheme(cfg *Config) error {
 			return errors.Wrap(ErrInvalidCfg, "consensus scheme of fullnode should be NOOP")
 		}
 	case LightweightType:
-		if cfg.Consensus.Scheme != NOOPScheme {
-			return errors.Wrap(ErrInvalidCfg, "consensus scheme of lightweight node should be NOOP")
+		// a lightweight node runs no consensus, and the roll-DPoS scheme has it check the producers against the delegates
+		if cfg.Consensus.Scheme != NOOPScheme && cfg.Consensus.Scheme != RollDPoSScheme {
+			return errors.Wrap(ErrInvalidCfg, "consensus scheme of lightweight node should be NOOP or ROLLDPOS")
 		}
 	default:
 		return errors.Wrapf(ErrInvalidCfg, "unknown node type %s", cfg.NodeType)
@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -220,6 +221,49 @@ func TestValidateChain(t *testing.T) {
 		t,
 		strings.Contains(err.Error(), "candidate number should be greater than or equal to delegate number"),
 	)
+
+	cfg = Default
+	cfg.Chain.EnablePruning = true
+	cfg.Chain.PruneDepth = cfg.Chain.MaxReorgDepth - 1
+	err = ValidateChain(&cfg)
+	require.Error(t, err)
+	require.Equal(t, ErrInvalidCfg, errors.Cause(err))
+	require.True(
+		t,
+		strings.Contains(err.Error(), "prune depth should be positive and no less than max reorg depth"),
+	)
+
+	cfg.Chain.PruneDepth = cfg.Chain.MaxReorgDepth
+	cfg.Chain.PruneInterval = 0
+	err = ValidateChain(&cfg)
+	require.Error(t, err)
+	require.Equal(t, ErrInvalidCfg, errors.Cause(err))
+	require.True(
+		t,
+		strings.Contains(err.Error(), "prune interval should be greater than 0"),
+	)
+
+	cfg.Chain.PruneInterval = time.Minute
+	cfg.Chain.EnableArchiveMode = true
+	err = ValidateChain(&cfg)
+	require.Error(t, err)
+	require.Equal(t, ErrInvalidCfg, errors.Cause(err))
+	require.True(
+		t,
+		strings.Contains(err.Error(), "archive mode cannot be enabled along with pruning"),
+	)
+
+	cfg = Default
+	cfg.BlockSync.EnableFastSync = true
+	err = ValidateChain(&cfg)
+	require.Error(t, err)
+	require.Equal(t, ErrInvalidCfg, errors.Cause(err))
+	require.True(
+		t,
+		strings.Contains(err.Error(), "fast sync requires pruning or archive mode"),
+	)
+	cfg.Chain.EnableArchiveMode = true
+	require.NoError(t, ValidateChain(&cfg))
 }
 
 func TestValidateConsensusScheme(t *testing.T) {
@@ -235,12 +279,14 @@ func TestValidateConsensusScheme(t *testing.T) {
 	)
 
 	cfg.NodeType = LightweightType
+	require.NoError(t, ValidateConsensusScheme(&cfg))
+	cfg.Consensus.Scheme = StandaloneScheme
 	err = ValidateConsensusScheme(&cfg)
 	assert.NotNil(t, err)
 	require.Equal(t, ErrInvalidCfg, errors.Cause(err))
 	require.True(
 		t,
-		strings.Contains(err.Error(), "consensus scheme of lightweight node should be NOOP"),
+		strings.Contains(err.Error(), "consensus scheme of lightweight node should be NOOP or ROLLDPOS"),
 	)
 
 	cfg.NodeType = "Unknown"
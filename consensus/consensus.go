@@ -55,23 +55,22 @@ func NewConsensus(
 
 	cs := &IotxConsensus{cfg: &cfg.Consensus}
 	mintBlockCB := func() (*blockchain.Block, error) {
-		transfers, votes := ap.PickActs()
+		actions := ap.PickActs()
 		logger.Debug().
-			Int("transfer", len(transfers)).
-			Int("votes", len(votes)).
+			Int("actions", len(actions)).
 			Msg("pick actions")
 		addr, err := cfg.ProducerAddr()
 		if err != nil {
 			return nil, err
 		}
-		blk, err := bc.MintNewBlock(transfers, votes, addr, "")
+		blk, err := bc.MintNewBlock(actions, addr, "")
 		if err != nil {
 			logger.Error().Msg("Failed to mint a block")
 			return nil, err
 		}
 		logger.Info().
 			Uint64("height", blk.Height()).
-			Int("length", len(blk.Transfers)).
+			Int("length", len(blk.Actions)).
 			Msg("created a new block")
 		return blk, nil
 	}
@@ -79,14 +79,14 @@ func NewSim(
 		if err != nil {
 			return nil, err
 		}
-		blk, err := bc.MintNewBlock(nil, nil, addr, "")
+		blk, err := bc.MintNewBlock(nil, addr, "")
 		if err != nil {
 			logger.Error().Msg("Failed to mint a block")
 			return nil, err
 		}
 		logger.Info().
 			Uint64("height", blk.Height()).
-			Int("transfers", len(blk.Transfers)).
+			Int("transfers", len(blk.Transfers())).
 			Msg("created a new block")
 
 		return blk, nil
@@ -185,20 +185,20 @@ func NewSimByzantine(
 		if err != nil {
 			return nil, err
 		}
-		tsf := []*action.Transfer{
+		acts := []action.Action{
 			action.NewCoinBaseTransfer(big.NewInt(100), addr.RawAddress),
 			action.NewCoinBaseTransfer(big.NewInt(200), addr.RawAddress),
 			action.NewCoinBaseTransfer(big.NewInt(300), addr.RawAddress),
 		}
-		// TODO: create sample Transfer and Vote to replace nil, nil below
-		blk, err := bc.MintNewBlock(tsf, nil, addr, "")
+		// TODO: create sample Transfer and Vote to replace the coinbase transfers below
+		blk, err := bc.MintNewBlock(acts, addr, "")
 		if err != nil {
 			logger.Error().Msg("Failed to mint a block")
 			return nil, err
 		}
 		logger.Info().
 			Uint64("height", blk.Height()).
-			Int("transfers", len(blk.Transfers)).
+			Int("transfers", len(blk.Transfers())).
 			Msg("created a new block")
 
 		return blk, nil
@@ -55,14 +55,14 @@ func createTestRollDPoS(
 	bc := mock_blockchain.NewMockBlockchain(ctrl)
 
 	createblockCB := func() (*blockchain.Block, error) {
-		blk, err := bc.MintNewBlock(nil, nil, &iotxaddress.Address{}, "")
+		blk, err := bc.MintNewBlock(nil, &iotxaddress.Address{}, "")
 		if err != nil {
 			logger.Error().Msg("Failed to mint a new block")
 			return nil, err
 		}
 		logger.Info().
 			Uint64("height", blk.Height()).
-			Int("transfers", len(blk.Transfers)).
+			Int("transfers", len(blk.Transfers())).
 			Msg("Created a new block")
 		return blk, nil
 	}
@@ -175,7 +175,7 @@ func testByzantineFault(t *testing.T, proposerNode int) {
 		m := func(mcks mocks) {
 			mcks.dp.EXPECT().AllDelegates().Return(delegates, nil).AnyTimes()
 			mcks.dNet.EXPECT().Self().Return(cur).AnyTimes()
-			mcks.bc.EXPECT().MintNewBlock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(genesis, nil).AnyTimes()
+			mcks.bc.EXPECT().MintNewBlock(gomock.Any(), gomock.Any(), gomock.Any()).Return(genesis, nil).AnyTimes()
 			mcks.bc.EXPECT().TipHeight().Return(uint64(0), nil).AnyTimes()
 			mcks.bc.EXPECT().ValidateBlock(gomock.Any()).Do(func(blk *blockchain.Block) error {
 				if blk == nil {
@@ -318,7 +318,7 @@ func TestRollDPoSFourTrustyNodes(t *testing.T) {
 		m := func(mcks mocks) {
 			mcks.dp.EXPECT().AllDelegates().Return(delegates, nil).AnyTimes()
 			mcks.dNet.EXPECT().Self().Return(cur).AnyTimes()
-			mcks.bc.EXPECT().MintNewBlock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(genesis, nil).AnyTimes()
+			mcks.bc.EXPECT().MintNewBlock(gomock.Any(), gomock.Any(), gomock.Any()).Return(genesis, nil).AnyTimes()
 			mcks.bc.EXPECT().TipHeight().Return(uint64(0), nil).AnyTimes()
 			mcks.bc.EXPECT().ValidateBlock(gomock.Any()).AnyTimes()
 			mcks.bc.EXPECT().CandidatesByHeight(gomock.Any()).Return([]*state.Candidate{
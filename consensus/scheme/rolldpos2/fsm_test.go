@@ -233,8 +233,8 @@ func TestHandleInitBlockEvt(t *testing.T) {
 	pbe, ok := e.(*proposeBlkEvt)
 	require.True(t, ok)
 	require.NotNil(t, pbe.block)
-	require.Equal(t, 1, len(pbe.block.Transfers))
-	require.Equal(t, 1, len(pbe.block.Votes))
+	require.Equal(t, 1, len(pbe.block.Transfers()))
+	require.Equal(t, 1, len(pbe.block.Votes()))
 
 }
 
@@ -637,8 +637,8 @@ func newTestCFSM(
 	vote, err := action.NewVote(2, address.RawAddress, address.RawAddress)
 	require.NoError(t, err)
 	var prevHash hash.Hash32B
-	lastBlk := blockchain.NewBlock(1, 1, prevHash, make([]*action.Transfer, 0), make([]*action.Vote, 0))
-	blkToMint := blockchain.NewBlock(1, 2, lastBlk.HashBlock(), []*action.Transfer{transfer}, []*action.Vote{vote})
+	lastBlk := blockchain.NewBlock(1, 1, prevHash, make([]action.Action, 0))
+	blkToMint := blockchain.NewBlock(1, 2, lastBlk.HashBlock(), []action.Action{transfer, vote})
 	ctx := makeTestRollDPoSCtx(
 		addr,
 		ctrl,
@@ -649,7 +649,7 @@ func newTestCFSM(
 		func(blockchain *mock_blockchain.MockBlockchain) {
 			blockchain.EXPECT().GetBlockByHeight(uint64(1)).Return(lastBlk, nil).AnyTimes()
 			blockchain.EXPECT().
-				MintNewBlock(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				MintNewBlock(gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(blkToMint, nil).
 				AnyTimes()
 			blockchain.EXPECT().CandidatesByHeight(gomock.Any()).Return([]*state.Candidate{
@@ -666,7 +666,7 @@ func newTestCFSM(
 			}
 		},
 		func(actPool *mock_actpool.MockActPool) {
-			actPool.EXPECT().PickActs().Return([]*action.Transfer{transfer}, []*action.Vote{vote}).AnyTimes()
+			actPool.EXPECT().PickActs().Return([]action.Action{transfer, vote}).AnyTimes()
 			actPool.EXPECT().Reset().AnyTimes()
 		},
 		func(p2p *mock_network.MockOverlay) {
@@ -136,20 +136,19 @@ func (ctx *rollDPoSCtx) calcProposer(height uint64, delegates []string) (string,
 
 // mintBlock picks the actions and creates an block to propose
 func (ctx *rollDPoSCtx) mintBlock() (*blockchain.Block, error) {
-	transfers, votes := ctx.actPool.PickActs()
+	actions := ctx.actPool.PickActs()
 	logger.Debug().
-		Int("transfer", len(transfers)).
-		Int("votes", len(votes)).
+		Int("actions", len(actions)).
 		Msg("pick actions from the action pool")
-	blk, err := ctx.chain.MintNewBlock(transfers, votes, ctx.addr, "")
+	blk, err := ctx.chain.MintNewBlock(actions, ctx.addr, "")
 	if err != nil {
 		logger.Error().Msg("error when minting a block")
 		return nil, err
 	}
 	logger.Info().
 		Uint64("height", blk.Height()).
-		Int("transfers", len(blk.Transfers)).
-		Int("votes", len(blk.Votes)).
+		Int("transfers", len(blk.Transfers())).
+		Int("votes", len(blk.Votes())).
 		Msg("minted a new block")
 	return blk, nil
 }
@@ -7,12 +7,23 @@
 package crypto
 
 import (
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/blake2b"
 
 	"github.com/iotexproject/iotex-core/logger"
 	"github.com/iotexproject/iotex-core/pkg/hash"
 )
 
+// ErrInvalidMerkleIndex indicates the leaf index is out of the range of the merkle tree
+var ErrInvalidMerkleIndex = errors.New("invalid merkle leaf index")
+
+// MerkleProof is the inclusion proof of the leaf of the index, which is the sibling hashes on the path from the leaf
+// up to the root
+type MerkleProof struct {
+	Index    int
+	Siblings []hash.Hash32B
+}
+
 // Merkle tree struct
 type Merkle struct {
 	root hash.Hash32B
@@ -83,3 +94,55 @@ func (mk *Merkle) HashTree() hash.Hash32B {
 	mk.root = merkle[0]
 	return mk.root
 }
+
+// Proof returns the inclusion proof of the leaf of the index
+func (mk *Merkle) Proof(index int) (*MerkleProof, error) {
+	if index < 0 || index >= mk.size {
+		return nil, errors.Wrapf(ErrInvalidMerkleIndex, "index %d of %d leaves", index, mk.size)
+	}
+	proof := &MerkleProof{Index: index}
+	if mk.size == 1 {
+		return proof, nil
+	}
+
+	merkle := make([]hash.Hash32B, mk.size)
+	copy(merkle, mk.leaf)
+	for length := len(merkle); length > 1; length >>= 1 {
+		// copy the last hash if the level has odd number of hashes, the same as HashTree
+		if length&1 != 0 {
+			merkle = append(merkle, merkle[length-1])
+			length++
+		}
+		proof.Siblings = append(proof.Siblings, merkle[index^1])
+		for i := 0; i < length>>1; i++ {
+			merkle[i] = hashPair(merkle[i<<1], merkle[i<<1+1])
+		}
+		merkle = merkle[0 : length>>1]
+		index >>= 1
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks the leaf is included in the merkle tree of the root, by hashing the leaf up to the root with
+// the siblings in the proof. It does not need the other leaves of the tree
+func VerifyMerkleProof(root hash.Hash32B, leaf hash.Hash32B, proof *MerkleProof) bool {
+	if proof == nil || proof.Index < 0 || proof.Index>>uint(len(proof.Siblings)) != 0 {
+		return false
+	}
+	h := leaf
+	index := proof.Index
+	for _, sibling := range proof.Siblings {
+		if index&1 == 0 {
+			h = hashPair(h, sibling)
+		} else {
+			h = hashPair(sibling, h)
+		}
+		index >>= 1
+	}
+	return h == root
+}
+
+// hashPair hashes the concatenation of the left and right hashes into their parent hash
+func hashPair(left, right hash.Hash32B) hash.Hash32B {
+	return blake2b.Sum256(append(left[:], right[:]...))
+}
@@ -11,7 +11,10 @@ import (
 	"encoding/hex"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
 
 	"github.com/iotexproject/iotex-core/pkg/hash"
 )
@@ -69,3 +72,35 @@ func TestMerkleTree(t *testing.T) {
 	assert.Equal(t, 0, bytes.Compare(expected[:], actual5[:]))
 	assert.Equal(t, -1, bytes.Compare(actual5[:], actual4[:]))
 }
+
+func TestMerkleProof(t *testing.T) {
+	require := require.New(t)
+
+	var leaves []hash.Hash32B
+	for i := 0; i < 9; i++ {
+		leaves = append(leaves, blake2b.Sum256([]byte{byte(i)}))
+		m := NewMerkleTree(leaves)
+		root := m.HashTree()
+		for index := range leaves {
+			proof, err := m.Proof(index)
+			require.NoError(err)
+			require.True(VerifyMerkleProof(root, leaves[index], proof))
+
+			// the proof does not verify another leaf, or with a tampered sibling
+			require.False(VerifyMerkleProof(root, hash.ZeroHash32B, proof))
+			if len(proof.Siblings) > 0 {
+				tampered := &MerkleProof{Index: index, Siblings: append([]hash.Hash32B{}, proof.Siblings...)}
+				tampered.Siblings[len(tampered.Siblings)-1] = hash.ZeroHash32B
+				require.False(VerifyMerkleProof(root, leaves[index], tampered))
+			}
+			// nor with an index beyond the depth of the proof
+			outOfRange := &MerkleProof{Index: index + 1<<uint(len(proof.Siblings)), Siblings: proof.Siblings}
+			require.False(VerifyMerkleProof(root, leaves[index], outOfRange))
+		}
+		_, err := m.Proof(-1)
+		require.Equal(ErrInvalidMerkleIndex, errors.Cause(err))
+		_, err = m.Proof(len(leaves) + 1)
+		require.Equal(ErrInvalidMerkleIndex, errors.Cause(err))
+	}
+	require.False(VerifyMerkleProof(hash.ZeroHash32B, hash.ZeroHash32B, nil))
+}
@@ -151,7 +151,8 @@ func (b *boltDB) Get(namespace string, key []byte) ([]byte, error) {
 	err := b.db.View(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(namespace))
 		if bucket == nil {
-			return errors.Wrapf(bolt.ErrBucketNotFound, "bucket = %s", namespace)
+			// nothing is ever put into the namespace
+			return errors.Wrapf(ErrNotExist, "bucket = %s", namespace)
 		}
 		value = bucket.Get(key)
 		return nil
@@ -22,6 +22,7 @@ import (
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/consensus"
 	"github.com/iotexproject/iotex-core/dispatch/dispatcher"
+	"github.com/iotexproject/iotex-core/lightclient"
 	"github.com/iotexproject/iotex-core/logger"
 	pb "github.com/iotexproject/iotex-core/proto"
 )
@@ -46,6 +47,39 @@ type actionMsg struct {
 	done   chan bool
 }
 
+// headerSyncMsg packages a proto block header sync request message.
+type headerSyncMsg struct {
+	sender string
+	sync   *pb.BlockHeaderSync
+	done   chan bool
+}
+
+// headersMsg packages a proto block headers message.
+type headersMsg struct {
+	headers []*pb.BlockHeaderPb
+	done    chan bool
+}
+
+// proofReqMsg packages a proto action proof or state proof request message.
+type proofReqMsg struct {
+	sender string
+	req    proto.Message
+	done   chan bool
+}
+
+// proofMsg packages a proto action proof or state proof message.
+type proofMsg struct {
+	proof proto.Message
+	done  chan bool
+}
+
+// snapshotMsg packages a proto snapshot manifest or chunk message, or a request for either.
+type snapshotMsg struct {
+	sender string
+	msg    proto.Message
+	done   chan bool
+}
+
 // IotxDispatcher is the request and event dispatcher for iotx node.
 type IotxDispatcher struct {
 	started   int32
@@ -57,16 +91,22 @@ type IotxDispatcher struct {
 	bs blocksync.BlockSync
 	cs consensus.Consensus
 	ap actpool.ActPool
+	lc lightclient.LightClient
+	ls lightclient.Server
 }
 
-// NewDispatcher creates a new Dispatcher
+// NewDispatcher creates a new Dispatcher. The light client lc is given to lightweight nodes, which run neither the
+// actpool, the block sync nor the consensus, and to the fast syncing nodes, whose block sync runs the light client to
+// verify the snapshot. The server ls of the light clients is only given to the nodes other than the lightweight ones
 func NewDispatcher(
 	cfg *config.Config,
 	ap actpool.ActPool,
 	bs blocksync.BlockSync,
 	cs consensus.Consensus,
+	lc lightclient.LightClient,
+	ls lightclient.Server,
 ) (dispatcher.Dispatcher, error) {
-	if bs == nil {
+	if bs == nil && lc == nil {
 		return nil, errors.New("Try to attach to a nil P2P")
 	}
 	d := &IotxDispatcher{
@@ -75,6 +115,8 @@ func NewDispatcher(
 		ap:        ap,
 		bs:        bs,
 		cs:        cs,
+		lc:        lc,
+		ls:        ls,
 	}
 	return d, nil
 }
@@ -86,12 +128,22 @@ func (d *IotxDispatcher) Start(ctx context.Context) error {
 	}
 
 	logger.Info().Msg("Starting dispatcher")
-	if err := d.cs.Start(ctx); err != nil {
-		return err
+	if d.cs != nil {
+		if err := d.cs.Start(ctx); err != nil {
+			return err
+		}
+	}
+
+	if d.bs != nil {
+		if err := d.bs.Start(ctx); err != nil {
+			return err
+		}
 	}
 
-	if err := d.bs.Start(ctx); err != nil {
-		return err
+	if d.bs == nil && d.lc != nil {
+		if err := d.lc.Start(ctx); err != nil {
+			return err
+		}
 	}
 
 	d.wg.Add(1)
@@ -107,12 +159,22 @@ func (d *IotxDispatcher) Stop(ctx context.Context) error {
 	}
 
 	logger.Info().Msg("Dispatcher is shutting down")
-	if err := d.cs.Stop(ctx); err != nil {
-		return err
+	if d.cs != nil {
+		if err := d.cs.Stop(ctx); err != nil {
+			return err
+		}
 	}
 
-	if err := d.bs.Stop(ctx); err != nil {
-		return err
+	if d.bs != nil {
+		if err := d.bs.Stop(ctx); err != nil {
+			return err
+		}
+	}
+
+	if d.bs == nil && d.lc != nil {
+		if err := d.lc.Stop(ctx); err != nil {
+			return err
+		}
 	}
 
 	close(d.quit)
@@ -141,6 +203,21 @@ loop:
 			case *blockSyncMsg:
 				d.handleBlockSyncMsg(msg)
 
+			case *headerSyncMsg:
+				d.handleHeaderSyncMsg(msg)
+
+			case *headersMsg:
+				d.handleHeadersMsg(msg)
+
+			case *proofReqMsg:
+				d.handleProofReqMsg(msg)
+
+			case *proofMsg:
+				d.handleProofMsg(msg)
+
+			case *snapshotMsg:
+				d.handleSnapshotMsg(msg)
+
 			default:
 				logger.Warn().
 					Str("msg", msg.(string)).
@@ -170,6 +247,18 @@ func (d *IotxDispatcher) handleActionMsg(m *actionMsg) {
 		if err := d.ap.AddVote(vote); err != nil {
 			logger.Error().Err(err)
 		}
+	} else if pbExecution := m.action.GetExecution(); pbExecution != nil {
+		execution := &action.Execution{}
+		execution.ConvertFromExecutionPb(pbExecution)
+		if err := d.ap.AddExecution(execution); err != nil {
+			logger.Error().Err(err)
+		}
+	} else if pbStake := m.action.GetStake(); pbStake != nil {
+		stake := &action.Stake{}
+		stake.ConvertFromStakePb(pbStake)
+		if err := d.ap.AddStake(stake); err != nil {
+			logger.Error().Err(err)
+		}
 	}
 	// signal to let caller know we are done
 	if m.done != nil {
@@ -186,7 +275,15 @@ func (d *IotxDispatcher) handleBlockMsg(m *blockMsg) {
 		Uint64("block", blk.Height()).Hex("hash", hash[:]).Msg("receive blockMsg")
 
 	if m.blkType == pb.MsgBlockProtoMsgType {
-		if err := d.bs.ProcessBlock(blk); err != nil {
+		// the light client of a lightweight node follows the committed blocks with their headers, and catches up by header
+		// sync otherwise
+		if d.bs == nil {
+			if m.block.Header != nil {
+				if err := d.lc.ProcessBlockHeader(m.block.Header); err != nil {
+					logger.Debug().Err(err).Msg("Fail to process the block header")
+				}
+			}
+		} else if err := d.bs.ProcessBlock(blk); err != nil {
 			logger.Error().Err(err).Msg("Fail to process the block")
 		}
 	} else if m.blkType == pb.MsgBlockSyncDataType {
@@ -215,9 +312,96 @@ func (d *IotxDispatcher) handleBlockSyncMsg(m *blockSyncMsg) {
 	}
 }
 
-// dispatchAction adds the passed action message to the news handling queue.
+// handleHeaderSyncMsg handles block header sync requests from light clients.
+func (d *IotxDispatcher) handleHeaderSyncMsg(m *headerSyncMsg) {
+	logger.Info().
+		Str("addr", m.sender).Uint64("start", m.sync.Start).Uint64("end", m.sync.End).
+		Msg("receive headerSyncMsg")
+	if err := d.ls.ProcessHeaderSyncRequest(m.sender, m.sync); err != nil {
+		logger.Error().Err(err).Msg("Fail to serve the header sync")
+	}
+	// signal to let caller know we are done
+	if m.done != nil {
+		m.done <- true
+	}
+}
+
+// handleHeadersMsg handles block headers from the full node.
+func (d *IotxDispatcher) handleHeadersMsg(m *headersMsg) {
+	if err := d.lc.ProcessHeaders(m.headers); err != nil {
+		logger.Error().Err(err).Msg("Fail to sync the block headers")
+	}
+	// signal to let caller know we are done
+	if m.done != nil {
+		m.done <- true
+	}
+}
+
+// handleProofReqMsg handles action proof, state proof and delegates requests from light clients.
+func (d *IotxDispatcher) handleProofReqMsg(m *proofReqMsg) {
+	var err error
+	switch req := m.req.(type) {
+	case *pb.ActionProofReq:
+		err = d.ls.ProcessActionProofRequest(m.sender, req)
+	case *pb.StateProofReq:
+		err = d.ls.ProcessStateProofRequest(m.sender, req)
+	case *pb.DelegatesReq:
+		err = d.ls.ProcessDelegatesRequest(m.sender, req)
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Fail to serve the proof request")
+	}
+	// signal to let caller know we are done
+	if m.done != nil {
+		m.done <- true
+	}
+}
+
+// handleProofMsg handles action proofs, state proofs and delegates from the full node.
+func (d *IotxDispatcher) handleProofMsg(m *proofMsg) {
+	var err error
+	switch proof := m.proof.(type) {
+	case *pb.ActionProofPb:
+		err = d.lc.ProcessActionProof(proof)
+	case *pb.StateProofPb:
+		err = d.lc.ProcessStateProof(proof)
+	case *pb.DelegatesPb:
+		err = d.lc.ProcessDelegates(proof)
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Fail to process the proof")
+	}
+	// signal to let caller know we are done
+	if m.done != nil {
+		m.done <- true
+	}
+}
+
+// handleSnapshotMsg handles snapshot requests from the fast syncing peers, and snapshot data for the fast sync.
+func (d *IotxDispatcher) handleSnapshotMsg(m *snapshotMsg) {
+	var err error
+	switch msg := m.msg.(type) {
+	case *pb.SnapshotManifestReq:
+		err = d.bs.ProcessSnapshotManifestRequest(m.sender, msg)
+	case *pb.SnapshotChunkReq:
+		err = d.bs.ProcessSnapshotChunkRequest(m.sender, msg)
+	case *pb.SnapshotManifestPb:
+		err = d.bs.ProcessSnapshotManifest(msg)
+	case *pb.SnapshotChunkPb:
+		err = d.bs.ProcessSnapshotChunk(msg)
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Fail to process the snapshot message")
+	}
+	// signal to let caller know we are done
+	if m.done != nil {
+		m.done <- true
+	}
+}
+
+// dispatchAction adds the passed action message to the news handling queue, if the node runs the actpool.
 func (d *IotxDispatcher) dispatchAction(msg proto.Message, done chan bool) {
-	if atomic.LoadInt32(&d.shutdown) != 0 {
+	if atomic.LoadInt32(&d.shutdown) != 0 || d.ap == nil {
 		if done != nil {
 			close(done)
 		}
@@ -237,9 +421,9 @@ func (d *IotxDispatcher) dispatchBlockCommit(msg proto.Message, done chan bool)
 	d.enqueueEvent(&blockMsg{(msg).(*pb.BlockPb), pb.MsgBlockProtoMsgType, done})
 }
 
-// dispatchBlockSyncReq adds the passed block sync request to the news handling queue.
+// dispatchBlockSyncReq adds the passed block sync request to the news handling queue, if the node runs the block sync.
 func (d *IotxDispatcher) dispatchBlockSyncReq(sender string, msg proto.Message, done chan bool) {
-	if atomic.LoadInt32(&d.shutdown) != 0 {
+	if atomic.LoadInt32(&d.shutdown) != 0 || d.bs == nil {
 		if done != nil {
 			close(done)
 		}
@@ -248,9 +432,9 @@ func (d *IotxDispatcher) dispatchBlockSyncReq(sender string, msg proto.Message,
 	d.enqueueEvent(&blockSyncMsg{sender, (msg).(*pb.BlockSync), done})
 }
 
-// dispatchBlockSyncData handles block sync data
+// dispatchBlockSyncData handles block sync data, if the node runs the block sync
 func (d *IotxDispatcher) dispatchBlockSyncData(msg proto.Message, done chan bool) {
-	if atomic.LoadInt32(&d.shutdown) != 0 {
+	if atomic.LoadInt32(&d.shutdown) != 0 || d.bs == nil {
 		if done != nil {
 			close(done)
 		}
@@ -260,6 +444,49 @@ func (d *IotxDispatcher) dispatchBlockSyncData(msg proto.Message, done chan bool
 	d.enqueueEvent(&blockMsg{data.Block, pb.MsgBlockSyncDataType, done})
 }
 
+// dispatchLightClientReq adds the passed header sync or proof request to the news handling queue, if the node serves
+// light clients.
+func (d *IotxDispatcher) dispatchLightClientReq(sender string, msg proto.Message, done chan bool) {
+	if atomic.LoadInt32(&d.shutdown) != 0 || d.ls == nil {
+		if done != nil {
+			close(done)
+		}
+		return
+	}
+	if sync, ok := msg.(*pb.BlockHeaderSync); ok {
+		d.enqueueEvent(&headerSyncMsg{sender, sync, done})
+		return
+	}
+	d.enqueueEvent(&proofReqMsg{sender, msg, done})
+}
+
+// dispatchLightClientData adds the passed headers or proof to the news handling queue, if the node is a light client.
+func (d *IotxDispatcher) dispatchLightClientData(msg proto.Message, done chan bool) {
+	if atomic.LoadInt32(&d.shutdown) != 0 || d.lc == nil {
+		if done != nil {
+			close(done)
+		}
+		return
+	}
+	if data, ok := msg.(*pb.BlockHeaderContainer); ok {
+		d.enqueueEvent(&headersMsg{data.Headers, done})
+		return
+	}
+	d.enqueueEvent(&proofMsg{msg, done})
+}
+
+// dispatchSnapshot adds the passed snapshot request or data to the news handling queue, if the node runs the block
+// sync.
+func (d *IotxDispatcher) dispatchSnapshot(sender string, msg proto.Message, done chan bool) {
+	if atomic.LoadInt32(&d.shutdown) != 0 || d.bs == nil {
+		if done != nil {
+			close(done)
+		}
+		return
+	}
+	d.enqueueEvent(&snapshotMsg{sender, msg, done})
+}
+
 // HandleBroadcast handles incoming broadcast message
 func (d *IotxDispatcher) HandleBroadcast(message proto.Message, done chan bool) {
 	msgType, err := pb.GetTypeFromProtoMsg(message)
@@ -271,6 +498,13 @@ func (d *IotxDispatcher) HandleBroadcast(message proto.Message, done chan bool)
 
 	switch msgType {
 	case pb.ViewChangeMsgType:
+		// a lightweight node does not take part in the consensus
+		if d.cs == nil {
+			if done != nil {
+				close(done)
+			}
+			return
+		}
 		err := d.cs.HandleViewChange(message, done)
 		if err != nil {
 			logger.Error().
@@ -302,7 +536,20 @@ func (d *IotxDispatcher) HandleTell(sender net.Addr, message proto.Message, done
 		d.dispatchBlockSyncReq(sender.String(), message, done)
 	case pb.MsgBlockSyncDataType:
 		d.dispatchBlockSyncData(message, done)
+	case pb.MsgBlockHeaderSyncReqType, pb.MsgActionProofReqType, pb.MsgStateProofReqType, pb.MsgDelegatesReqType:
+		d.dispatchLightClientReq(sender.String(), message, done)
+	case pb.MsgBlockHeaderSyncDataType, pb.MsgActionProofType, pb.MsgStateProofType, pb.MsgDelegatesType:
+		d.dispatchLightClientData(message, done)
+	case pb.MsgSnapshotManifestReqType, pb.MsgSnapshotChunkReqType, pb.MsgSnapshotManifestType, pb.MsgSnapshotChunkType:
+		d.dispatchSnapshot(sender.String(), message, done)
 	case pb.MsgBlockProtoMsgType:
+		// a lightweight node does not take part in the consensus
+		if d.cs == nil {
+			if done != nil {
+				close(done)
+			}
+			return
+		}
 		err := d.cs.HandleBlockPropose(message, done)
 		if err != nil {
 			logger.Error().
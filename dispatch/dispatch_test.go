@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/iotexproject/iotex-core/config"
@@ -19,6 +20,7 @@ import (
 	"github.com/iotexproject/iotex-core/proto"
 	"github.com/iotexproject/iotex-core/test/mock/mock_blocksync"
 	"github.com/iotexproject/iotex-core/test/mock/mock_consensus"
+	"github.com/iotexproject/iotex-core/test/mock/mock_lightclient"
 )
 
 func TestNewDispatcher(t *testing.T) {
@@ -120,6 +122,127 @@ func TestDispatchBlockSyncData(t *testing.T) {
 	}
 }
 
+func TestDispatchLightClientReq(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	d, bs, ls := createFullNodeDispatcher(ctrl)
+	assert.NotNil(t, d)
+
+	bs.EXPECT().Start(gomock.Any()).Times(1)
+	bs.EXPECT().Stop(gomock.Any()).Times(1)
+	err := d.Start(ctx)
+	assert.NoError(t, err)
+	defer func() {
+		err := d.Stop(ctx)
+		assert.NoError(t, err)
+	}()
+
+	sender := node.NewTCPNode("192.168.0.0:10000")
+	done := make(chan bool, 4)
+	ls.EXPECT().ProcessHeaderSyncRequest(sender.String(), gomock.Any()).Times(1).Return(nil)
+	ls.EXPECT().ProcessActionProofRequest(sender.String(), gomock.Any()).Times(1).Return(nil)
+	ls.EXPECT().ProcessStateProofRequest(sender.String(), gomock.Any()).Times(1).Return(nil)
+	ls.EXPECT().ProcessDelegatesRequest(sender.String(), gomock.Any()).Times(1).Return(nil)
+	d.HandleTell(sender, &iproto.BlockHeaderSync{Start: 1, End: 10}, done)
+	d.HandleTell(sender, &iproto.ActionProofReq{}, done)
+	d.HandleTell(sender, &iproto.StateProofReq{}, done)
+	d.HandleTell(sender, &iproto.DelegatesReq{}, done)
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	// the data for light clients is dropped by the full node
+	done = make(chan bool)
+	d.HandleTell(sender, &iproto.BlockHeaderContainer{}, done)
+	_, ok := <-done
+	assert.False(t, ok)
+}
+
+func TestDispatchLightClientData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	d, lc := createLightweightDispatcher(ctrl)
+	assert.NotNil(t, d)
+
+	lc.EXPECT().Start(gomock.Any()).Times(1)
+	lc.EXPECT().Stop(gomock.Any()).Times(1)
+	err := d.Start(ctx)
+	assert.NoError(t, err)
+	defer func() {
+		err := d.Stop(ctx)
+		assert.NoError(t, err)
+	}()
+
+	sender := node.NewTCPNode("192.168.0.0:10000")
+	done := make(chan bool, 5)
+	lc.EXPECT().ProcessHeaders(gomock.Any()).Times(1).Return(nil)
+	lc.EXPECT().ProcessActionProof(gomock.Any()).Times(1).Return(nil)
+	lc.EXPECT().ProcessStateProof(gomock.Any()).Times(1).Return(nil)
+	lc.EXPECT().ProcessDelegates(gomock.Any()).Times(1).Return(nil)
+	d.HandleTell(
+		sender,
+		&iproto.BlockHeaderContainer{Headers: []*iproto.BlockHeaderPb{{}, {}}},
+		done,
+	)
+	d.HandleTell(sender, &iproto.ActionProofPb{}, done)
+	d.HandleTell(sender, &iproto.StateProofPb{}, done)
+	d.HandleTell(sender, &iproto.DelegatesPb{}, done)
+	// only the header of the committed block is processed
+	lc.EXPECT().ProcessBlockHeader(gomock.Any()).Times(1).Return(nil)
+	d.HandleBroadcast(&iproto.BlockPb{Header: &iproto.BlockHeaderPb{}}, done)
+	for i := 0; i < 5; i++ {
+		<-done
+	}
+
+	// the requests of light clients, the blocks to sync and the consensus messages are dropped by the lightweight node
+	for _, msg := range []proto.Message{&iproto.StateProofReq{}, &iproto.BlockContainer{}, &iproto.BlockPb{}} {
+		done = make(chan bool)
+		d.HandleTell(sender, msg, done)
+		_, ok := <-done
+		assert.False(t, ok)
+	}
+	done = make(chan bool)
+	d.HandleBroadcast(&iproto.ViewChangeMsg{}, done)
+	_, ok := <-done
+	assert.False(t, ok)
+}
+
+func TestDispatchSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	d, bs := createDispatcher(ctrl)
+	assert.NotNil(t, d)
+
+	bs.EXPECT().Start(gomock.Any()).Times(1)
+	bs.EXPECT().Stop(gomock.Any()).Times(1)
+	err := d.Start(ctx)
+	assert.NoError(t, err)
+	defer func() {
+		err := d.Stop(ctx)
+		assert.NoError(t, err)
+	}()
+
+	sender := node.NewTCPNode("192.168.0.0:10000")
+	done := make(chan bool, 4)
+	bs.EXPECT().ProcessSnapshotManifestRequest(sender.String(), gomock.Any()).Times(1).Return(nil)
+	bs.EXPECT().ProcessSnapshotChunkRequest(sender.String(), gomock.Any()).Times(1).Return(nil)
+	bs.EXPECT().ProcessSnapshotManifest(gomock.Any()).Times(1).Return(nil)
+	bs.EXPECT().ProcessSnapshotChunk(gomock.Any()).Times(1).Return(nil)
+	d.HandleTell(sender, &iproto.SnapshotManifestReq{}, done)
+	d.HandleTell(sender, &iproto.SnapshotChunkReq{}, done)
+	d.HandleTell(sender, &iproto.SnapshotManifestPb{}, done)
+	d.HandleTell(sender, &iproto.SnapshotChunkPb{}, done)
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+}
+
 func createDispatcher(
 	ctrl *gomock.Controller,
 ) (dispatcher.Dispatcher, *mock_blocksync.MockBlockSync) {
@@ -131,7 +254,39 @@ func createDispatcher(
 	cs := mock_consensus.NewMockConsensus(ctrl)
 	cs.EXPECT().Start(gomock.Any()).Times(1)
 	cs.EXPECT().Stop(gomock.Any()).Times(1)
-	dp, _ := NewDispatcher(cfg, nil, bs, cs)
+	dp, _ := NewDispatcher(cfg, nil, bs, cs, nil, nil)
 
 	return dp, bs
 }
+
+func createFullNodeDispatcher(
+	ctrl *gomock.Controller,
+) (dispatcher.Dispatcher, *mock_blocksync.MockBlockSync, *mock_lightclient.MockServer) {
+	cfg := &config.Config{
+		NodeType:   config.FullNodeType,
+		Consensus:  config.Consensus{Scheme: config.NOOPScheme},
+		Dispatcher: config.Dispatcher{EventChanSize: 1024},
+	}
+	bs := mock_blocksync.NewMockBlockSync(ctrl)
+	cs := mock_consensus.NewMockConsensus(ctrl)
+	cs.EXPECT().Start(gomock.Any()).Times(1)
+	cs.EXPECT().Stop(gomock.Any()).Times(1)
+	ls := mock_lightclient.NewMockServer(ctrl)
+	dp, _ := NewDispatcher(cfg, nil, bs, cs, nil, ls)
+
+	return dp, bs, ls
+}
+
+func createLightweightDispatcher(
+	ctrl *gomock.Controller,
+) (dispatcher.Dispatcher, *mock_lightclient.MockLightClient) {
+	cfg := &config.Config{
+		NodeType:   config.LightweightType,
+		Consensus:  config.Consensus{Scheme: config.NOOPScheme},
+		Dispatcher: config.Dispatcher{EventChanSize: 1024},
+	}
+	lc := mock_lightclient.NewMockLightClient(ctrl)
+	dp, _ := NewDispatcher(cfg, nil, nil, nil, lc, nil)
+
+	return dp, lc
+}
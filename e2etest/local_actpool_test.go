@@ -104,8 +104,8 @@ func TestLocalActPool(t *testing.T) {
 		if err := p1.Broadcast(act1); err != nil {
 			return false, err
 		}
-		transfers, _ := ap.PickActs()
-		return len(transfers) == 1, nil
+		acts := ap.PickActs()
+		return len(acts) == 1, nil
 	})
 	require.Nil(err)
 	err = p1.Broadcast(act2)
@@ -126,11 +126,11 @@ func TestLocalActPool(t *testing.T) {
 		var voteCount int
 		for h := height; h > 0; h-- {
 			blk, _ := bc.GetBlockByHeight(h)
-			if len(blk.Transfers) > 1 {
-				tsfCount += len(blk.Transfers) - 1
+			if len(blk.Transfers()) > 1 {
+				tsfCount += len(blk.Transfers()) - 1
 			}
-			if len(blk.Votes) > 0 {
-				voteCount += len(blk.Votes)
+			if len(blk.Votes()) > 0 {
+				voteCount += len(blk.Votes())
 			}
 		}
 		// Excluding coinbase transfers, there should be 2 valid transfers and 1 valid vote in committed blocks
@@ -192,8 +192,8 @@ func TestPressureActPool(t *testing.T) {
 		if err := p1.Broadcast(act1); err != nil {
 			return false, err
 		}
-		transfers, _ := ap.PickActs()
-		return len(transfers) == 1, nil
+		acts := ap.PickActs()
+		return len(acts) == 1, nil
 	})
 	require.Nil(err)
 	for i := 2; i <= 1000; i++ {
@@ -210,8 +210,8 @@ func TestPressureActPool(t *testing.T) {
 		var tsfCount int
 		for h := height; h > 0; h-- {
 			blk, _ := bc.GetBlockByHeight(h)
-			if len(blk.Transfers) >= 1 {
-				tsfCount += len(blk.Transfers) - 1
+			if len(blk.Transfers()) >= 1 {
+				tsfCount += len(blk.Transfers()) - 1
 			}
 		}
 		// Excluding coinbase transfers, there should be 256 valid transfers in committed blocks
@@ -138,33 +138,35 @@ func TestLocalCommit(t *testing.T) {
 		if err := p.Broadcast(act1); err != nil {
 			return false, err
 		}
-		tsf, _ := svr.Ap().PickActs()
-		return len(tsf) == 1, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 1, nil
 	})
 	require.Nil(err)
 
-	tsf, _ := svr.Ap().PickActs()
-	blk1, err := svr.Bc().MintNewBlock(tsf, nil, ta.Addrinfo["producer"], "")
-	hash1 := blk1.HashBlock()
+	acts := svr.Ap().PickActs()
+	blk1, err := svr.Bc().MintNewBlock(acts, ta.Addrinfo["producer"], "")
 	require.Nil(err)
 
+	// the following blocks are minted on a replica, as their state roots depend on the states after blk1
+	replica, err := replicateBlockchain(cfg, svr.Bc())
+	require.Nil(err)
+	require.Nil(replica.CommitBlock(blk1))
+
 	// transfer 2
 	// F --> D
 	s, _ = svr.Bc().StateByAddr(ta.Addrinfo["foxtrot"].RawAddress)
 	tsf2, _ := action.NewTransfer(s.Nonce+1, big.NewInt(1), ta.Addrinfo["foxtrot"].RawAddress, ta.Addrinfo["delta"].RawAddress)
 	tsf2, _ = tsf2.Sign(ta.Addrinfo["foxtrot"])
-	blk2 := blockchain.NewBlock(0, height+2, hash1, []*action.Transfer{tsf2,
-		action.NewCoinBaseTransfer(big.NewInt(int64(blockchain.Gen.BlockReward)), ta.Addrinfo["producer"].RawAddress)}, nil)
-	err = blk2.SignBlock(ta.Addrinfo["producer"])
+	blk2, err := replica.MintNewBlock([]action.Action{tsf2}, ta.Addrinfo["producer"], "")
 	require.Nil(err)
-	hash2 := blk2.HashBlock()
+	require.Nil(replica.CommitBlock(blk2))
 	act2 := &pb.ActionPb{Action: &pb.ActionPb_Transfer{tsf2.ConvertToTransferPb()}}
 	err = testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
 		if err := p.Broadcast(act2); err != nil {
 			return false, err
 		}
-		tsf, _ := svr.Ap().PickActs()
-		return len(tsf) == 2, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 2, nil
 	})
 	require.Nil(err)
 
@@ -173,18 +175,16 @@ func TestLocalCommit(t *testing.T) {
 	s, _ = svr.Bc().StateByAddr(ta.Addrinfo["bravo"].RawAddress)
 	tsf3, _ := action.NewTransfer(s.Nonce+1, big.NewInt(1), ta.Addrinfo["bravo"].RawAddress, ta.Addrinfo["bravo"].RawAddress)
 	tsf3, _ = tsf3.Sign(ta.Addrinfo["bravo"])
-	blk3 := blockchain.NewBlock(0, height+3, hash2, []*action.Transfer{tsf3,
-		action.NewCoinBaseTransfer(big.NewInt(int64(blockchain.Gen.BlockReward)), ta.Addrinfo["producer"].RawAddress)}, nil)
-	err = blk3.SignBlock(ta.Addrinfo["producer"])
+	blk3, err := replica.MintNewBlock([]action.Action{tsf3}, ta.Addrinfo["producer"], "")
 	require.Nil(err)
-	hash3 := blk3.HashBlock()
+	require.Nil(replica.CommitBlock(blk3))
 	act3 := &pb.ActionPb{Action: &pb.ActionPb_Transfer{tsf3.ConvertToTransferPb()}}
 	err = testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
 		if err := p.Broadcast(act3); err != nil {
 			return false, err
 		}
-		tsf, _ := svr.Ap().PickActs()
-		return len(tsf) == 3, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 3, nil
 	})
 	require.Nil(err)
 
@@ -193,17 +193,15 @@ func TestLocalCommit(t *testing.T) {
 	s, _ = svr.Bc().StateByAddr(ta.Addrinfo["producer"].RawAddress)
 	tsf4, _ := action.NewTransfer(s.Nonce+1, big.NewInt(1), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["echo"].RawAddress)
 	tsf4, _ = tsf4.Sign(ta.Addrinfo["producer"])
-	blk4 := blockchain.NewBlock(0, height+4, hash3, []*action.Transfer{tsf4,
-		action.NewCoinBaseTransfer(big.NewInt(int64(blockchain.Gen.BlockReward)), ta.Addrinfo["producer"].RawAddress)}, nil)
-	err = blk4.SignBlock(ta.Addrinfo["producer"])
+	blk4, err := replica.MintNewBlock([]action.Action{tsf4}, ta.Addrinfo["producer"], "")
 	require.Nil(err)
 	act4 := &pb.ActionPb{Action: &pb.ActionPb_Transfer{tsf4.ConvertToTransferPb()}}
 	err = testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
 		if err := p.Broadcast(act4); err != nil {
 			return false, err
 		}
-		tsf, _ := svr.Ap().PickActs()
-		return len(tsf) == 4, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 4, nil
 	})
 	require.Nil(err)
 
@@ -483,14 +481,13 @@ func TestVoteLocalCommit(t *testing.T) {
 		if err := p.Broadcast(acttsf4); err != nil {
 			return false, err
 		}
-		transfer, votes := svr.Ap().PickActs()
-		return len(votes)+len(transfer) == 7, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 7, nil
 	})
 	require.Nil(err)
 
-	transfers, votes := svr.Ap().PickActs()
-	blk1, err := svr.Bc().MintNewBlock(transfers, votes, ta.Addrinfo["producer"], "")
-	hash1 := blk1.HashBlock()
+	acts := svr.Ap().PickActs()
+	blk1, err := svr.Bc().MintNewBlock(acts, ta.Addrinfo["producer"], "")
 	require.Nil(err)
 
 	err = p.Broadcast(blk1.ConvertToBlockPb())
@@ -513,11 +510,7 @@ func TestVoteLocalCommit(t *testing.T) {
 	require.Nil(err)
 	vote5, err := newSignedVote(7, ta.Addrinfo["charlie"], ta.Addrinfo["alfa"])
 	require.Nil(err)
-	blk2 := blockchain.NewBlock(0, height+2, hash1,
-		[]*action.Transfer{action.NewCoinBaseTransfer(big.NewInt(int64(blockchain.Gen.BlockReward)),
-			ta.Addrinfo["producer"].RawAddress)}, []*action.Vote{vote4, vote5})
-	err = blk2.SignBlock(ta.Addrinfo["producer"])
-	hash2 := blk2.HashBlock()
+	blk2, err := svr.Bc().MintNewBlock([]action.Action{vote4, vote5}, ta.Addrinfo["producer"], "")
 	require.Nil(err)
 	act4 := &pb.ActionPb{Action: &pb.ActionPb_Vote{vote4.ConvertToVotePb()}}
 	act5 := &pb.ActionPb{Action: &pb.ActionPb_Vote{vote5.ConvertToVotePb()}}
@@ -528,8 +521,8 @@ func TestVoteLocalCommit(t *testing.T) {
 		if err := p.Broadcast(act5); err != nil {
 			return false, err
 		}
-		_, votes := svr.Ap().PickActs()
-		return len(votes) == 2, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 2, nil
 	})
 	require.Nil(err)
 
@@ -566,19 +559,15 @@ func TestVoteLocalCommit(t *testing.T) {
 	require.NoError(err)
 	vote6, err = vote6.Sign(ta.Addrinfo["delta"])
 	require.Nil(err)
-	blk3 := blockchain.NewBlock(0, height+3, hash2,
-		[]*action.Transfer{action.NewCoinBaseTransfer(big.NewInt(int64(blockchain.Gen.BlockReward)),
-			ta.Addrinfo["producer"].RawAddress)}, []*action.Vote{vote6})
-	err = blk3.SignBlock(ta.Addrinfo["producer"])
-	hash3 := blk3.HashBlock()
+	blk3, err := svr.Bc().MintNewBlock([]action.Action{vote6}, ta.Addrinfo["producer"], "")
 	require.Nil(err)
 	act6 := &pb.ActionPb{Action: &pb.ActionPb_Vote{vote6.ConvertToVotePb()}}
 	err = testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
 		if err := p.Broadcast(act6); err != nil {
 			return false, err
 		}
-		_, votes := svr.Ap().PickActs()
-		return len(votes) == 1, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 1, nil
 	})
 	require.Nil(err)
 
@@ -615,18 +604,15 @@ func TestVoteLocalCommit(t *testing.T) {
 	require.NoError(err)
 	vote7, err = vote7.Sign(ta.Addrinfo["bravo"])
 	require.Nil(err)
-	blk4 := blockchain.NewBlock(0, height+4, hash3,
-		[]*action.Transfer{action.NewCoinBaseTransfer(big.NewInt(int64(blockchain.Gen.BlockReward)),
-			ta.Addrinfo["producer"].RawAddress)}, []*action.Vote{vote7})
-	err = blk4.SignBlock(ta.Addrinfo["producer"])
+	blk4, err := svr.Bc().MintNewBlock([]action.Action{vote7}, ta.Addrinfo["producer"], "")
 	require.Nil(err)
 	act7 := &pb.ActionPb{Action: &pb.ActionPb_Vote{vote7.ConvertToVotePb()}}
 	err = testutil.WaitUntil(10*time.Millisecond, 2*time.Second, func() (bool, error) {
 		if err := p.Broadcast(act7); err != nil {
 			return false, err
 		}
-		_, votes := svr.Ap().PickActs()
-		return len(votes) == 1, nil
+		acts := svr.Ap().PickActs()
+		return len(acts) == 1, nil
 	})
 	require.Nil(err)
 
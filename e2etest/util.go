@@ -7,26 +7,23 @@
 package e2etest
 
 import (
-	"encoding/hex"
 	"math/big"
 
+	"github.com/pkg/errors"
+
 	"github.com/iotexproject/iotex-core/blockchain"
 	"github.com/iotexproject/iotex-core/blockchain/action"
-	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/config"
 	ta "github.com/iotexproject/iotex-core/test/testaddress"
 )
 
 func addTestingTsfBlocks(bc blockchain.Blockchain) error {
-	// Add block 1
-	tsf0, _ := action.NewTransfer(1, big.NewInt(100000000), blockchain.Gen.CreatorAddr, ta.Addrinfo["producer"].RawAddress)
-	pubk, _ := keypair.DecodePublicKey(blockchain.Gen.CreatorPubKey)
-	sign, err := hex.DecodeString("847af98bf2c92873f3f7ed02399c7407d0df35c9a45da6947df43638bf2df32d263e59011fab0e4d5380c4c49c579ccd0a25b1260e586f5f379979f38db91ac5f3c7468a6e389d00")
-	if err != nil {
-		return err
-	}
-	tsf0.SenderPublicKey = pubk
-	tsf0.Signature = sign
-	blk, err := bc.MintNewBlock([]*action.Transfer{tsf0}, nil, ta.Addrinfo["producer"], "")
+	// Add block 1, whose coinbase funds the producer
+	genesis := bc.Genesis()
+	reward := genesis.BlockReward
+	genesis.BlockReward = uint64(100000000)
+	blk, err := bc.MintNewBlock(nil, ta.Addrinfo["producer"], "")
+	genesis.BlockReward = reward
 	if err != nil {
 		return err
 	}
@@ -48,7 +45,7 @@ func addTestingTsfBlocks(bc blockchain.Blockchain) error {
 	tsf6, _ := action.NewTransfer(6, big.NewInt(5<<20), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["foxtrot"].RawAddress)
 	tsf6, _ = tsf6.Sign(ta.Addrinfo["producer"])
 
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6}, nil, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -68,7 +65,7 @@ func addTestingTsfBlocks(bc blockchain.Blockchain) error {
 	tsf4, _ = tsf4.Sign(ta.Addrinfo["charlie"])
 	tsf5, _ = action.NewTransfer(5, big.NewInt(1), ta.Addrinfo["charlie"].RawAddress, ta.Addrinfo["producer"].RawAddress)
 	tsf5, _ = tsf5.Sign(ta.Addrinfo["charlie"])
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4, tsf5}, nil, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4, tsf5}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -86,7 +83,7 @@ func addTestingTsfBlocks(bc blockchain.Blockchain) error {
 	tsf3, _ = tsf3.Sign(ta.Addrinfo["delta"])
 	tsf4, _ = action.NewTransfer(4, big.NewInt(1), ta.Addrinfo["delta"].RawAddress, ta.Addrinfo["producer"].RawAddress)
 	tsf4, _ = tsf4.Sign(ta.Addrinfo["delta"])
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4}, nil, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -108,7 +105,7 @@ func addTestingTsfBlocks(bc blockchain.Blockchain) error {
 	tsf5, _ = tsf5.Sign(ta.Addrinfo["echo"])
 	tsf6, _ = action.NewTransfer(6, big.NewInt(2), ta.Addrinfo["echo"].RawAddress, ta.Addrinfo["producer"].RawAddress)
 	tsf6, _ = tsf6.Sign(ta.Addrinfo["echo"])
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6}, nil, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4, tsf5, tsf6}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -118,3 +115,26 @@ func addTestingTsfBlocks(bc blockchain.Blockchain) error {
 
 	return nil
 }
+
+// replicateBlockchain creates an in-memory blockchain with the same blocks as bc, so that the blocks following the tip
+// of bc could be minted ahead on the replica with the right state roots
+func replicateBlockchain(cfg *config.Config, bc blockchain.Blockchain) (blockchain.Blockchain, error) {
+	replica := blockchain.NewBlockchain(cfg, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
+	if replica == nil {
+		return nil, errors.New("failed to create replica blockchain")
+	}
+	height, err := bc.TipHeight()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(1); i <= height; i++ {
+		blk, err := bc.GetBlockByHeight(i)
+		if err != nil {
+			return nil, err
+		}
+		if err := replica.CommitBlock(blk); err != nil {
+			return nil, errors.Wrapf(err, "failed to replicate block %d", i)
+		}
+	}
+	return replica, nil
+}
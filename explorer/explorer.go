@@ -24,6 +24,7 @@ import (
 	"github.com/iotexproject/iotex-core/pkg/hash"
 	"github.com/iotexproject/iotex-core/pkg/keypair"
 	pb "github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
 )
 
 // ErrInternalServer indicates the internal server error
@@ -75,6 +76,26 @@ func (exp *Service) GetAddressDetails(address string) (explorer.AddressDetails,
 	return details, nil
 }
 
+// GetAddressDetailsByHeight returns the properties of an address as of the block of a given height, the pending nonce
+// is only available for the tip
+func (exp *Service) GetAddressDetailsByHeight(address string, height int64) (explorer.AddressDetails, error) {
+	if height < 0 {
+		return explorer.AddressDetails{}, errors.New("invalid block height")
+	}
+	state, err := exp.bc.StateByHeight(address, uint64(height))
+	if err != nil {
+		return explorer.AddressDetails{}, err
+	}
+	details := explorer.AddressDetails{
+		Address:      address,
+		TotalBalance: state.Balance.Int64(),
+		Nonce:        int64(state.Nonce),
+		IsCandidate:  state.IsCandidate,
+	}
+
+	return details, nil
+}
+
 // GetLastTransfersByRange return transfers in [-(offset+limit-1), -offset] from block
 // with height startBlockHeight
 func (exp *Service) GetLastTransfersByRange(startBlockHeight int64, offset int64, limit int64, showCoinBase bool) ([]explorer.Transfer, error) {
@@ -95,8 +116,9 @@ ChainLoop:
 			return res, err
 		}
 
-		for i := len(blk.Transfers) - 1; i >= 0; i-- {
-			if showCoinBase || !blk.Transfers[i].IsCoinbase {
+		transfers := blk.Transfers()
+		for i := len(transfers) - 1; i >= 0; i-- {
+			if showCoinBase || !transfers[i].IsCoinbase {
 				transferCount++
 			}
 
@@ -105,21 +127,21 @@ ChainLoop:
 			}
 
 			// if showCoinBase is true, add coinbase transfers, else only put non-coinbase transfers
-			if showCoinBase || !blk.Transfers[i].IsCoinbase {
+			if showCoinBase || !transfers[i].IsCoinbase {
 				if int64(len(res)) >= limit {
 					break ChainLoop
 				}
 
-				hash := blk.Transfers[i].Hash()
+				hash := transfers[i].Hash()
 				explorerTransfer := explorer.Transfer{
-					Amount:    blk.Transfers[i].Amount.Int64(),
+					Amount:    transfers[i].Amount.Int64(),
 					Timestamp: int64(blk.ConvertToBlockHeaderPb().Timestamp),
 					ID:        hex.EncodeToString(hash[:]),
-					Nonce:     int64(blk.Transfers[i].Nonce),
+					Nonce:     int64(transfers[i].Nonce),
 					BlockID:   blkID,
-					Sender:    blk.Transfers[i].Sender,
-					Recipient: blk.Transfers[i].Recipient,
-					Fee:       0, // TODO: we need to get the actual fee.
+					Sender:    transfers[i].Sender,
+					Recipient: transfers[i].Recipient,
+					Fee:       transfers[i].Fee().Int64(),
 				}
 				res = append(res, explorerTransfer)
 			}
@@ -190,7 +212,7 @@ func (exp *Service) GetUnconfirmedTransfersByAddress(address string, offset int6
 	acts := exp.ap.GetUnconfirmedActs(address)
 	tsfIndex := int64(0)
 	for _, act := range acts {
-		if act.GetVote() != nil {
+		if act.GetTransfer() == nil {
 			continue
 		}
 
@@ -242,7 +264,7 @@ func (exp *Service) GetTransfersByBlockID(blkID string, offset int64, limit int6
 		return nil, err
 	}
 
-	for i, transfer := range blk.Transfers {
+	for i, transfer := range blk.Transfers() {
 		if int64(i) < offset {
 			continue
 		}
@@ -260,7 +282,7 @@ func (exp *Service) GetTransfersByBlockID(blkID string, offset int64, limit int6
 			BlockID:   blkID,
 			Sender:    transfer.Sender,
 			Recipient: transfer.Recipient,
-			Fee:       0, // TODO: we need to get the actual fee.
+			Fee:       transfer.Fee().Int64(),
 		}
 		res = append(res, explorerTransfer)
 	}
@@ -286,7 +308,8 @@ ChainLoop:
 			return res, err
 		}
 
-		for i := int64(len(blk.Votes) - 1); i >= 0; i-- {
+		votes := blk.Votes()
+		for i := int64(len(votes) - 1); i >= 0; i-- {
 			voteCount++
 
 			if voteCount <= uint64(offset) {
@@ -297,7 +320,7 @@ ChainLoop:
 				break ChainLoop
 			}
 
-			selfPublicKey, err := blk.Votes[i].SelfPublicKey()
+			selfPublicKey, err := votes[i].SelfPublicKey()
 			if err != nil {
 				return res, err
 			}
@@ -306,16 +329,16 @@ ChainLoop:
 				return res, err
 			}
 
-			votee := blk.Votes[i].VoteeAddress
+			votee := votes[i].VoteeAddress
 			if err != nil {
 				return res, err
 			}
 
-			hash := blk.Votes[i].Hash()
+			hash := votes[i].Hash()
 			explorerVote := explorer.Vote{
 				ID:        hex.EncodeToString(hash[:]),
-				Nonce:     int64(blk.Votes[i].Nonce),
-				Timestamp: int64(blk.Votes[i].Timestamp),
+				Nonce:     int64(votes[i].Nonce),
+				Timestamp: int64(votes[i].Timestamp),
 				Voter:     voter,
 				Votee:     votee,
 				BlockID:   blkID,
@@ -388,7 +411,7 @@ func (exp *Service) GetUnconfirmedVotesByAddress(address string, offset int64, l
 	acts := exp.ap.GetUnconfirmedActs(address)
 	voteIndex := int64(0)
 	for _, act := range acts {
-		if act.GetTransfer() != nil {
+		if act.GetVote() == nil {
 			continue
 		}
 
@@ -434,7 +457,7 @@ func (exp *Service) GetVotesByBlockID(blkID string, offset int64, limit int64) (
 		return nil, err
 	}
 
-	for i, vote := range blk.Votes {
+	for i, vote := range blk.Votes() {
 		if int64(i) < offset {
 			continue
 		}
@@ -471,6 +494,62 @@ func (exp *Service) GetVotesByBlockID(blkID string, offset int64, limit int64) (
 	return res, nil
 }
 
+// GetReceiptByActionID returns the receipt of an action by action id
+func (exp *Service) GetReceiptByActionID(actionID string) (explorer.Receipt, error) {
+	bytes, err := hex.DecodeString(actionID)
+	if err != nil {
+		return explorer.Receipt{}, err
+	}
+	var actHash hash.Hash32B
+	copy(actHash[:], bytes)
+
+	receipt, err := exp.bc.GetReceiptByActionHash(actHash)
+	if err != nil {
+		return explorer.Receipt{}, err
+	}
+
+	return explorer.Receipt{
+		ID:            actionID,
+		Status:        int64(receipt.Status),
+		BalanceDeltas: convertDeltas(receipt.BalanceDeltas),
+		WeightDeltas:  convertDeltas(receipt.WeightDeltas),
+	}, nil
+}
+
+// GetActionProofByID returns the merkle proof of a transfer or vote by action id
+func (exp *Service) GetActionProofByID(actionID string) (explorer.ActionProof, error) {
+	bytes, err := hex.DecodeString(actionID)
+	if err != nil {
+		return explorer.ActionProof{}, err
+	}
+	var actHash hash.Hash32B
+	copy(actHash[:], bytes)
+
+	height, proof, err := exp.bc.GetActionProofByActionHash(actHash)
+	if err != nil {
+		return explorer.ActionProof{}, err
+	}
+	blk, err := exp.bc.GetBlockByHeight(height)
+	if err != nil {
+		return explorer.ActionProof{}, err
+	}
+	blkHash := blk.HashBlock()
+	txRoot := blk.Header.TxRoot()
+	siblings := make([]string, len(proof.Siblings))
+	for i, sibling := range proof.Siblings {
+		siblings[i] = hex.EncodeToString(sibling[:])
+	}
+
+	return explorer.ActionProof{
+		ID:          actionID,
+		BlockID:     hex.EncodeToString(blkHash[:]),
+		BlockHeight: int64(height),
+		TxRoot:      hex.EncodeToString(txRoot[:]),
+		Index:       int64(proof.Index),
+		Siblings:    siblings,
+	}, nil
+}
+
 // GetLastBlocksByRange get block with height [offset-limit+1, offset]
 func (exp *Service) GetLastBlocksByRange(offset int64, limit int64) ([]explorer.Block, error) {
 	var res []explorer.Block
@@ -489,7 +568,7 @@ func (exp *Service) GetLastBlocksByRange(offset int64, limit int64) ([]explorer.
 
 		totalAmount := int64(0)
 		totalSize := uint32(0)
-		for _, transfer := range blk.Transfers {
+		for _, transfer := range blk.Transfers() {
 			totalAmount += transfer.Amount.Int64()
 			totalSize += transfer.TotalSize()
 		}
@@ -498,8 +577,8 @@ func (exp *Service) GetLastBlocksByRange(offset int64, limit int64) ([]explorer.
 			ID:        hex.EncodeToString(hash[:]),
 			Height:    int64(blockHeaderPb.Height),
 			Timestamp: int64(blockHeaderPb.Timestamp),
-			Transfers: int64(len(blk.Transfers)),
-			Votes:     int64(len(blk.Votes)),
+			Transfers: int64(len(blk.Transfers())),
+			Votes:     int64(len(blk.Votes())),
 			Amount:    totalAmount,
 			Size:      int64(totalSize),
 			GenerateBy: explorer.BlockGenerator{
@@ -532,7 +611,7 @@ func (exp *Service) GetBlockByID(blkID string) (explorer.Block, error) {
 
 	totalAmount := int64(0)
 	totalSize := uint32(0)
-	for _, transfer := range blk.Transfers {
+	for _, transfer := range blk.Transfers() {
 		totalAmount += transfer.Amount.Int64()
 		totalSize += transfer.TotalSize()
 	}
@@ -541,8 +620,8 @@ func (exp *Service) GetBlockByID(blkID string) (explorer.Block, error) {
 		ID:        blkID,
 		Height:    int64(blkHeaderPb.Height),
 		Timestamp: int64(blkHeaderPb.Timestamp),
-		Transfers: int64(len(blk.Transfers)),
-		Votes:     int64(len(blk.Votes)),
+		Transfers: int64(len(blk.Transfers())),
+		Votes:     int64(len(blk.Votes())),
 		Amount:    totalAmount,
 		Size:      int64(totalSize),
 		GenerateBy: explorer.BlockGenerator{
@@ -604,7 +683,7 @@ func (exp *Service) GetCoinStatistic() (explorer.CoinStatistic, error) {
 
 	explorerCoinStats := explorer.CoinStatistic{
 		Height:    int64(tipHeight),
-		Supply:    int64(blockchain.Gen.TotalSupply),
+		Supply:    int64(exp.bc.Genesis().TotalSupply),
 		Transfers: int64(totalTransfers),
 		Votes:     int64(totalVotes),
 		Aps:       aps,
@@ -678,6 +757,32 @@ func (exp *Service) GetCandidateMetrics() (explorer.CandidateMetrics, error) {
 	}, nil
 }
 
+// GetCandidateMetricsByHeight returns the candidates metrics as of the block of a given height, the delegates and the
+// producer are only available for the latest height
+func (exp *Service) GetCandidateMetricsByHeight(height int64) (explorer.CandidateMetrics, error) {
+	if height < 0 {
+		return explorer.CandidateMetrics{}, errors.New("invalid block height")
+	}
+	allCandidates, ok := exp.bc.CandidatesByHeight(uint64(height))
+	if !ok {
+		return explorer.CandidateMetrics{}, errors.Wrapf(blockchain.ErrCandidates,
+			"Failed to get the candidate metrics of height %d", height)
+	}
+	candidates := make([]explorer.Candidate, len(allCandidates))
+	for i, c := range allCandidates {
+		candidates[i] = explorer.Candidate{
+			Address:          c.Address,
+			TotalVote:        c.Votes.Int64(),
+			CreationHeight:   int64(c.CreationHeight),
+			LastUpdateHeight: int64(c.LastUpdateHeight),
+		}
+	}
+
+	return explorer.CandidateMetrics{
+		Candidates: candidates,
+	}, nil
+}
+
 // SendTransfer sends a transfer
 func (exp *Service) SendTransfer(request explorer.SendTransferRequest) (explorer.SendTransferResponse, error) {
 	logger.Debug().Msg("receive send transfer request")
@@ -695,6 +800,10 @@ func (exp *Service) SendTransfer(request explorer.SendTransferRequest) (explorer
 		return explorer.SendTransferResponse{}, err
 	}
 	amount := big.NewInt(tsfJSON.Amount).Bytes()
+	if tsfJSON.GasPrice < 0 {
+		return explorer.SendTransferResponse{}, errors.New("invalid gas price")
+	}
+	gasPrice := big.NewInt(tsfJSON.GasPrice).Bytes()
 
 	payload, err := hex.DecodeString(tsfJSON.Payload)
 	if err != nil {
@@ -718,6 +827,8 @@ func (exp *Service) SendTransfer(request explorer.SendTransferRequest) (explorer
 		Payload:      payload,
 		SenderPubKey: senderPubKey,
 		IsCoinbase:   tsfJSON.IsCoinbase,
+		GasLimit:     uint64(tsfJSON.GasLimit),
+		GasPrice:     gasPrice,
 	}
 
 	// Wrap TransferPb as an ActionPb
@@ -753,6 +864,9 @@ func (exp *Service) SendVote(request explorer.SendVoteRequest) (explorer.SendVot
 	if err != nil {
 		return explorer.SendVoteResponse{}, err
 	}
+	if voteJSON.GasPrice < 0 {
+		return explorer.SendVoteResponse{}, errors.New("invalid gas price")
+	}
 	votePb := &pb.VotePb{
 		Version:      uint32(voteJSON.Version),
 		Nonce:        uint64(voteJSON.Nonce),
@@ -760,6 +874,8 @@ func (exp *Service) SendVote(request explorer.SendVoteRequest) (explorer.SendVot
 		VoterAddress: voteJSON.Voter,
 		VoteeAddress: voteJSON.Votee,
 		Signature:    signature,
+		GasLimit:     uint64(voteJSON.GasLimit),
+		GasPrice:     big.NewInt(voteJSON.GasPrice).Bytes(),
 	}
 
 	// Wrap VotePb as an ActionPb
@@ -801,7 +917,7 @@ func getTransfer(bc blockchain.Blockchain, transferHash hash.Hash32B) (explorer.
 		BlockID:   hex.EncodeToString(blkHash[:]),
 		Sender:    transfer.Sender,
 		Recipient: transfer.Recipient,
-		Fee:       0, // TODO: we need to get the actual fee.
+		Fee:       transfer.Fee().Int64(),
 	}
 
 	return explorerTransfer, nil
@@ -853,6 +969,14 @@ func getVote(bc blockchain.Blockchain, voteHash hash.Hash32B) (explorer.Vote, er
 	return explorerVote, nil
 }
 
+func convertDeltas(deltas []*state.Delta) []explorer.Delta {
+	res := make([]explorer.Delta, 0, len(deltas))
+	for _, delta := range deltas {
+		res = append(res, explorer.Delta{Address: delta.Address, Amount: delta.Amount.String()})
+	}
+	return res
+}
+
 func getAddrFromPubKey(pubKey keypair.PublicKey) (string, error) {
 	Address, err := iotxaddress.GetAddress(pubKey, iotxaddress.IsTestnet, iotxaddress.ChainID)
 	if err != nil {
@@ -23,7 +23,9 @@ import (
 	"github.com/iotexproject/iotex-core/blockchain/action"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/consensus/scheme"
+	cp "github.com/iotexproject/iotex-core/crypto"
 	"github.com/iotexproject/iotex-core/explorer/idl/explorer"
+	"github.com/iotexproject/iotex-core/pkg/hash"
 	"github.com/iotexproject/iotex-core/state"
 	"github.com/iotexproject/iotex-core/test/mock/mock_blockchain"
 	"github.com/iotexproject/iotex-core/test/mock/mock_consensus"
@@ -49,7 +51,7 @@ func addTestingBlocks(bc blockchain.Blockchain) error {
 	// test --> A, B, C, D, E, F
 	tsf, _ := action.NewTransfer(1, big.NewInt(10), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["charlie"].RawAddress)
 	tsf, _ = tsf.Sign(ta.Addrinfo["producer"])
-	blk, err := bc.MintNewBlock([]*action.Transfer{tsf}, nil, ta.Addrinfo["producer"], "")
+	blk, err := bc.MintNewBlock([]action.Action{tsf}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -69,7 +71,7 @@ func addTestingBlocks(bc blockchain.Blockchain) error {
 	tsf4, _ = tsf4.Sign(ta.Addrinfo["charlie"])
 	vote1, _ := action.NewVote(5, ta.Addrinfo["charlie"].RawAddress, ta.Addrinfo["delta"].RawAddress)
 	vote1, _ = vote1.Sign(ta.Addrinfo["charlie"])
-	blk, err = bc.MintNewBlock([]*action.Transfer{tsf1, tsf2, tsf3, tsf4}, []*action.Vote{vote1}, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{tsf1, tsf2, tsf3, tsf4, vote1}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -78,7 +80,7 @@ func addTestingBlocks(bc blockchain.Blockchain) error {
 	}
 
 	// Add block 3
-	blk, err = bc.MintNewBlock(nil, nil, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock(nil, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -91,7 +93,7 @@ func addTestingBlocks(bc blockchain.Blockchain) error {
 	vote2, _ := action.NewVote(1, ta.Addrinfo["alfa"].RawAddress, ta.Addrinfo["charlie"].RawAddress)
 	vote1, _ = vote1.Sign(ta.Addrinfo["charlie"])
 	vote2, _ = vote2.Sign(ta.Addrinfo["alfa"])
-	blk, err = bc.MintNewBlock(nil, []*action.Vote{vote1, vote2}, ta.Addrinfo["producer"], "")
+	blk, err = bc.MintNewBlock([]action.Action{vote1, vote2}, ta.Addrinfo["producer"], "")
 	if err != nil {
 		return err
 	}
@@ -244,6 +246,43 @@ func TestExplorerApi(t *testing.T) {
 	_, err = svc.GetVoteByID("")
 	require.Error(err)
 
+	receipt, err := svc.GetReceiptByActionID(votes[0].ID)
+	require.Nil(err)
+	require.Equal(votes[0].ID, receipt.ID)
+	require.Equal(int64(state.ReceiptStatusSuccess), receipt.Status)
+
+	// fail
+	_, err = svc.GetReceiptByActionID("")
+	require.Error(err)
+
+	// the proofs of the transfer and the vote verify against the tx roots of their blocks
+	for _, id := range []string{transfers[0].ID, votes[0].ID} {
+		actionProof, err := svc.GetActionProofByID(id)
+		require.Nil(err)
+		require.Equal(id, actionProof.ID)
+		blk, err := bc.GetBlockByHeight(uint64(actionProof.BlockHeight))
+		require.Nil(err)
+		blkHash := blk.HashBlock()
+		require.Equal(hex.EncodeToString(blkHash[:]), actionProof.BlockID)
+		proof := &cp.MerkleProof{Index: int(actionProof.Index)}
+		for _, sibling := range actionProof.Siblings {
+			b, err := hex.DecodeString(sibling)
+			require.Nil(err)
+			var h hash.Hash32B
+			copy(h[:], b)
+			proof.Siblings = append(proof.Siblings, h)
+		}
+		b, err := hex.DecodeString(id)
+		require.Nil(err)
+		var actHash hash.Hash32B
+		copy(actHash[:], b)
+		require.True(cp.VerifyMerkleProof(blk.Header.TxRoot(), actHash, proof))
+	}
+
+	// fail
+	_, err = svc.GetActionProofByID("")
+	require.Error(err)
+
 	blk, err := svc.GetBlockByID(blks[0].ID)
 	require.Nil(err)
 	require.Equal(blks[0].Height, blk.Height)
@@ -340,6 +379,50 @@ func TestService_StateByAddr(t *testing.T) {
 	require.Equal("456", state.Votee)
 }
 
+func TestService_GetByHeight(t *testing.T) {
+	require := require.New(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := state.State{
+		Balance:     big.NewInt(46),
+		Nonce:       uint64(2),
+		IsCandidate: true,
+	}
+	candidate := &state.Candidate{
+		Address:          "123",
+		Votes:            big.NewInt(100),
+		CreationHeight:   uint64(1),
+		LastUpdateHeight: uint64(3),
+	}
+	mBc := mock_blockchain.NewMockBlockchain(ctrl)
+	mBc.EXPECT().StateByHeight("123", uint64(3)).Times(1).Return(&s, nil)
+	mBc.EXPECT().CandidatesByHeight(uint64(3)).Times(1).Return([]*state.Candidate{candidate}, true)
+	mBc.EXPECT().CandidatesByHeight(uint64(4)).Times(1).Return(nil, false)
+
+	svc := Service{bc: mBc}
+
+	details, err := svc.GetAddressDetailsByHeight("123", 3)
+	require.Nil(err)
+	require.Equal("123", details.Address)
+	require.Equal(int64(46), details.TotalBalance)
+	require.Equal(int64(2), details.Nonce)
+	require.True(details.IsCandidate)
+	_, err = svc.GetAddressDetailsByHeight("123", -1)
+	require.Error(err)
+
+	m, err := svc.GetCandidateMetricsByHeight(3)
+	require.Nil(err)
+	require.Equal(1, len(m.Candidates))
+	require.Equal("123", m.Candidates[0].Address)
+	require.Equal(int64(100), m.Candidates[0].TotalVote)
+	require.Equal(int64(1), m.Candidates[0].CreationHeight)
+	require.Equal(int64(3), m.Candidates[0].LastUpdateHeight)
+	_, err = svc.GetCandidateMetricsByHeight(4)
+	require.Error(err)
+}
+
 func TestService_GetConsensusMetrics(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -444,3 +527,15 @@ func TestService_SendVote(t *testing.T) {
 	require.Equal(true, response.VoteSent)
 	require.Nil(err)
 }
+
+func TestConvertDeltas(t *testing.T) {
+	require := require.New(t)
+
+	// the amounts do not fit in int64
+	amount, ok := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	require.True(ok)
+	deltas := convertDeltas([]*state.Delta{{Address: ta.Addrinfo["alfa"].RawAddress, Amount: amount}})
+	require.Equal(1, len(deltas))
+	require.Equal(ta.Addrinfo["alfa"].RawAddress, deltas[0].Address)
+	require.Equal("-123456789012345678901234567890", deltas[0].Amount)
+}
@@ -48,6 +48,8 @@ type Transfer struct {
 	Payload      string `json:"payload"`
 	IsCoinbase   bool   `json:"isCoinbase"`
 	Fee          int64  `json:"fee"`
+	GasLimit     int64  `json:"gasLimit,omitempty"`
+	GasPrice     int64  `json:"gasPrice,omitempty"`
 	Timestamp    int64  `json:"timestamp"`
 	BlockID      string `json:"blockID"`
 }
@@ -61,9 +63,32 @@ type Vote struct {
 	Votee       string `json:"votee"`
 	VoterPubKey string `json:"voterPubKey"`
 	Signature   string `json:"signature"`
+	GasLimit    int64  `json:"gasLimit,omitempty"`
+	GasPrice    int64  `json:"gasPrice,omitempty"`
 	BlockID     string `json:"blockID"`
 }
 
+type Delta struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+}
+
+type Receipt struct {
+	ID            string  `json:"ID"`
+	Status        int64   `json:"status"`
+	BalanceDeltas []Delta `json:"balanceDeltas"`
+	WeightDeltas  []Delta `json:"weightDeltas"`
+}
+
+type ActionProof struct {
+	ID          string   `json:"ID"`
+	BlockID     string   `json:"blockID"`
+	BlockHeight int64    `json:"blockHeight"`
+	TxRoot      string   `json:"txRoot"`
+	Index       int64    `json:"index"`
+	Siblings    []string `json:"siblings"`
+}
+
 type AddressDetails struct {
 	Address      string `json:"address"`
 	TotalBalance int64  `json:"totalBalance"`
@@ -112,6 +137,7 @@ type Explorer interface {
 	GetBlockchainHeight() (int64, error)
 	GetAddressBalance(address string) (int64, error)
 	GetAddressDetails(address string) (AddressDetails, error)
+	GetAddressDetailsByHeight(address string, height int64) (AddressDetails, error)
 	GetLastTransfersByRange(startBlockHeight int64, offset int64, limit int64, showCoinBase bool) ([]Transfer, error)
 	GetTransferByID(transferID string) (Transfer, error)
 	GetTransfersByAddress(address string, offset int64, limit int64) ([]Transfer, error)
@@ -122,11 +148,14 @@ type Explorer interface {
 	GetVotesByAddress(address string, offset int64, limit int64) ([]Vote, error)
 	GetUnconfirmedVotesByAddress(address string, offset int64, limit int64) ([]Vote, error)
 	GetVotesByBlockID(blkID string, offset int64, limit int64) ([]Vote, error)
+	GetReceiptByActionID(actionID string) (Receipt, error)
+	GetActionProofByID(actionID string) (ActionProof, error)
 	GetLastBlocksByRange(offset int64, limit int64) ([]Block, error)
 	GetBlockByID(blkID string) (Block, error)
 	GetCoinStatistic() (CoinStatistic, error)
 	GetConsensusMetrics() (ConsensusMetrics, error)
 	GetCandidateMetrics() (CandidateMetrics, error)
+	GetCandidateMetricsByHeight(height int64) (CandidateMetrics, error)
 	SendTransfer(request SendTransferRequest) (SendTransferResponse, error)
 	SendVote(request SendVoteRequest) (SendVoteResponse, error)
 }
@@ -194,6 +223,24 @@ func (_p ExplorerProxy) GetAddressDetails(address string) (AddressDetails, error
 	return AddressDetails{}, _err
 }
 
+func (_p ExplorerProxy) GetAddressDetailsByHeight(address string, height int64) (AddressDetails, error) {
+	_res, _err := _p.client.Call("Explorer.getAddressDetailsByHeight", address, height)
+	if _err == nil {
+		_retType := _p.idl.Method("Explorer.getAddressDetailsByHeight").Returns
+		_res, _err = barrister.Convert(_p.idl, &_retType, reflect.TypeOf(AddressDetails{}), _res, "")
+	}
+	if _err == nil {
+		_cast, _ok := _res.(AddressDetails)
+		if !_ok {
+			_t := reflect.TypeOf(_res)
+			_msg := fmt.Sprintf("Explorer.getAddressDetailsByHeight returned invalid type: %v", _t)
+			return AddressDetails{}, &barrister.JsonRpcError{Code: -32000, Message: _msg}
+		}
+		return _cast, nil
+	}
+	return AddressDetails{}, _err
+}
+
 func (_p ExplorerProxy) GetLastTransfersByRange(startBlockHeight int64, offset int64, limit int64, showCoinBase bool) ([]Transfer, error) {
 	_res, _err := _p.client.Call("Explorer.getLastTransfersByRange", startBlockHeight, offset, limit, showCoinBase)
 	if _err == nil {
@@ -374,6 +421,42 @@ func (_p ExplorerProxy) GetVotesByBlockID(blkID string, offset int64, limit int6
 	return []Vote{}, _err
 }
 
+func (_p ExplorerProxy) GetReceiptByActionID(actionID string) (Receipt, error) {
+	_res, _err := _p.client.Call("Explorer.getReceiptByActionID", actionID)
+	if _err == nil {
+		_retType := _p.idl.Method("Explorer.getReceiptByActionID").Returns
+		_res, _err = barrister.Convert(_p.idl, &_retType, reflect.TypeOf(Receipt{}), _res, "")
+	}
+	if _err == nil {
+		_cast, _ok := _res.(Receipt)
+		if !_ok {
+			_t := reflect.TypeOf(_res)
+			_msg := fmt.Sprintf("Explorer.getReceiptByActionID returned invalid type: %v", _t)
+			return Receipt{}, &barrister.JsonRpcError{Code: -32000, Message: _msg}
+		}
+		return _cast, nil
+	}
+	return Receipt{}, _err
+}
+
+func (_p ExplorerProxy) GetActionProofByID(actionID string) (ActionProof, error) {
+	_res, _err := _p.client.Call("Explorer.getActionProofByID", actionID)
+	if _err == nil {
+		_retType := _p.idl.Method("Explorer.getActionProofByID").Returns
+		_res, _err = barrister.Convert(_p.idl, &_retType, reflect.TypeOf(ActionProof{}), _res, "")
+	}
+	if _err == nil {
+		_cast, _ok := _res.(ActionProof)
+		if !_ok {
+			_t := reflect.TypeOf(_res)
+			_msg := fmt.Sprintf("Explorer.getActionProofByID returned invalid type: %v", _t)
+			return ActionProof{}, &barrister.JsonRpcError{Code: -32000, Message: _msg}
+		}
+		return _cast, nil
+	}
+	return ActionProof{}, _err
+}
+
 func (_p ExplorerProxy) GetLastBlocksByRange(offset int64, limit int64) ([]Block, error) {
 	_res, _err := _p.client.Call("Explorer.getLastBlocksByRange", offset, limit)
 	if _err == nil {
@@ -464,6 +547,24 @@ func (_p ExplorerProxy) GetCandidateMetrics() (CandidateMetrics, error) {
 	return CandidateMetrics{}, _err
 }
 
+func (_p ExplorerProxy) GetCandidateMetricsByHeight(height int64) (CandidateMetrics, error) {
+	_res, _err := _p.client.Call("Explorer.getCandidateMetricsByHeight", height)
+	if _err == nil {
+		_retType := _p.idl.Method("Explorer.getCandidateMetricsByHeight").Returns
+		_res, _err = barrister.Convert(_p.idl, &_retType, reflect.TypeOf(CandidateMetrics{}), _res, "")
+	}
+	if _err == nil {
+		_cast, _ok := _res.(CandidateMetrics)
+		if !_ok {
+			_t := reflect.TypeOf(_res)
+			_msg := fmt.Sprintf("Explorer.getCandidateMetricsByHeight returned invalid type: %v", _t)
+			return CandidateMetrics{}, &barrister.JsonRpcError{Code: -32000, Message: _msg}
+		}
+		return _cast, nil
+	}
+	return CandidateMetrics{}, _err
+}
+
 func (_p ExplorerProxy) SendTransfer(request SendTransferRequest) (SendTransferResponse, error) {
 	_res, _err := _p.client.Call("Explorer.sendTransfer", request)
 	if _err == nil {
@@ -801,6 +902,20 @@ var IdlJsonRaw = `[
                 "is_array": false,
                 "comment": ""
             },
+            {
+                "name": "gasLimit",
+                "type": "int",
+                "optional": true,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "gasPrice",
+                "type": "int",
+                "optional": true,
+                "is_array": false,
+                "comment": ""
+            },
             {
                 "name": "timestamp",
                 "type": "int",
@@ -885,12 +1000,152 @@ var IdlJsonRaw = `[
                 "is_array": false,
                 "comment": ""
             },
+            {
+                "name": "gasLimit",
+                "type": "int",
+                "optional": true,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "gasPrice",
+                "type": "int",
+                "optional": true,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "blockID",
+                "type": "string",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            }
+        ],
+        "values": null,
+        "functions": null,
+        "barrister_version": "",
+        "date_generated": 0,
+        "checksum": ""
+    },
+    {
+        "type": "struct",
+        "name": "Delta",
+        "comment": "",
+        "value": "",
+        "extends": "",
+        "fields": [
+            {
+                "name": "address",
+                "type": "string",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "amount",
+                "type": "string",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            }
+        ],
+        "values": null,
+        "functions": null,
+        "barrister_version": "",
+        "date_generated": 0,
+        "checksum": ""
+    },
+    {
+        "type": "struct",
+        "name": "Receipt",
+        "comment": "",
+        "value": "",
+        "extends": "",
+        "fields": [
+            {
+                "name": "ID",
+                "type": "string",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "status",
+                "type": "int",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "balanceDeltas",
+                "type": "Delta",
+                "optional": false,
+                "is_array": true,
+                "comment": ""
+            },
+            {
+                "name": "weightDeltas",
+                "type": "Delta",
+                "optional": false,
+                "is_array": true,
+                "comment": ""
+            }
+        ],
+        "values": null,
+        "functions": null,
+        "barrister_version": "",
+        "date_generated": 0,
+        "checksum": ""
+    },
+    {
+        "type": "struct",
+        "name": "ActionProof",
+        "comment": "",
+        "value": "",
+        "extends": "",
+        "fields": [
+            {
+                "name": "ID",
+                "type": "string",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            },
             {
                 "name": "blockID",
                 "type": "string",
                 "optional": false,
                 "is_array": false,
                 "comment": ""
+            },
+            {
+                "name": "blockHeight",
+                "type": "int",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "txRoot",
+                "type": "string",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "index",
+                "type": "int",
+                "optional": false,
+                "is_array": false,
+                "comment": ""
+            },
+            {
+                "name": "siblings",
+                "type": "string",
+                "optional": false,
+                "is_array": true,
+                "comment": ""
             }
         ],
         "values": null,
@@ -1212,6 +1467,33 @@ var IdlJsonRaw = `[
                     "comment": ""
                 }
             },
+            {
+                "name": "getAddressDetailsByHeight",
+                "comment": "get the address detail of an iotex address as of a block height",
+                "params": [
+                    {
+                        "name": "address",
+                        "type": "string",
+                        "optional": false,
+                        "is_array": false,
+                        "comment": ""
+                    },
+                    {
+                        "name": "height",
+                        "type": "int",
+                        "optional": false,
+                        "is_array": false,
+                        "comment": ""
+                    }
+                ],
+                "returns": {
+                    "name": "",
+                    "type": "AddressDetails",
+                    "optional": false,
+                    "is_array": false,
+                    "comment": ""
+                }
+            },
             {
                 "name": "getLastTransfersByRange",
                 "comment": "get list of transfers by start block height, transfer offset and limit",
@@ -1531,6 +1813,46 @@ var IdlJsonRaw = `[
                     "comment": ""
                 }
             },
+            {
+                "name": "getReceiptByActionID",
+                "comment": "get the receipt of an action by action id",
+                "params": [
+                    {
+                        "name": "actionID",
+                        "type": "string",
+                        "optional": false,
+                        "is_array": false,
+                        "comment": ""
+                    }
+                ],
+                "returns": {
+                    "name": "",
+                    "type": "Receipt",
+                    "optional": false,
+                    "is_array": false,
+                    "comment": ""
+                }
+            },
+            {
+                "name": "getActionProofByID",
+                "comment": "get the merkle proof of a transfer or vote by action id, which verifies against the tx root of the block",
+                "params": [
+                    {
+                        "name": "actionID",
+                        "type": "string",
+                        "optional": false,
+                        "is_array": false,
+                        "comment": ""
+                    }
+                ],
+                "returns": {
+                    "name": "",
+                    "type": "ActionProof",
+                    "optional": false,
+                    "is_array": false,
+                    "comment": ""
+                }
+            },
             {
                 "name": "getLastBlocksByRange",
                 "comment": "get list of blocks by block id offset and limit",
@@ -1614,6 +1936,26 @@ var IdlJsonRaw = `[
                     "comment": ""
                 }
             },
+            {
+                "name": "getCandidateMetricsByHeight",
+                "comment": "get candidates metrics as of a block height",
+                "params": [
+                    {
+                        "name": "height",
+                        "type": "int",
+                        "optional": false,
+                        "is_array": false,
+                        "comment": ""
+                    }
+                ],
+                "returns": {
+                    "name": "",
+                    "type": "CandidateMetrics",
+                    "optional": false,
+                    "is_array": false,
+                    "comment": ""
+                }
+            },
             {
                 "name": "sendTransfer",
                 "comment": "send transfer",
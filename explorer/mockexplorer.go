@@ -36,6 +36,11 @@ func (exp *MockExplorer) GetAddressDetails(address string) (explorer.AddressDeta
 	}, nil
 }
 
+// GetAddressDetailsByHeight returns the properties of an address as of a block height
+func (exp *MockExplorer) GetAddressDetailsByHeight(address string, height int64) (explorer.AddressDetails, error) {
+	return exp.GetAddressDetails(address)
+}
+
 // GetLastTransfersByRange return transfers in [-(offset+limit-1), -offset] from block
 // with height startBlockHeight
 func (exp *MockExplorer) GetLastTransfersByRange(startBlockHeight int64, offset int64, limit int64, showCoinBase bool) ([]explorer.Transfer, error) {
@@ -96,6 +101,27 @@ func (exp *MockExplorer) GetVotesByBlockID(blkID string, offset int64, limit int
 	return exp.GetLastVotesByRange(0, offset, limit)
 }
 
+// GetReceiptByActionID returns the receipt of an action by action id
+func (exp *MockExplorer) GetReceiptByActionID(actionID string) (explorer.Receipt, error) {
+	return explorer.Receipt{
+		ID:            actionID,
+		Status:        1,
+		BalanceDeltas: []explorer.Delta{{Address: randString(), Amount: strconv.FormatInt(randInt64(), 10)}},
+	}, nil
+}
+
+// GetActionProofByID returns the merkle proof of a transfer or vote by action id
+func (exp *MockExplorer) GetActionProofByID(actionID string) (explorer.ActionProof, error) {
+	return explorer.ActionProof{
+		ID:          actionID,
+		BlockID:     randString(),
+		BlockHeight: randInt64(),
+		TxRoot:      randString(),
+		Index:       0,
+		Siblings:    []string{randString()},
+	}, nil
+}
+
 // GetLastBlocksByRange get block with height [offset-limit+1, offset]
 func (exp *MockExplorer) GetLastBlocksByRange(offset int64, limit int64) ([]explorer.Block, error) {
 	var blks []explorer.Block
@@ -148,6 +174,11 @@ func (exp *MockExplorer) GetCandidateMetrics() (explorer.CandidateMetrics, error
 	}, nil
 }
 
+// GetCandidateMetricsByHeight returns the fake delegates metrics as of a block height
+func (exp *MockExplorer) GetCandidateMetricsByHeight(height int64) (explorer.CandidateMetrics, error) {
+	return exp.GetCandidateMetrics()
+}
+
 // SendTransfer sends a fake transfer
 func (exp *MockExplorer) SendTransfer(request explorer.SendTransferRequest) (explorer.SendTransferResponse, error) {
 	return explorer.SendTransferResponse{}, nil
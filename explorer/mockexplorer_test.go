@@ -43,6 +43,12 @@ func TestMockExplorerApi(t *testing.T) {
 	_, err = svc.GetVoteByID("")
 	require.Nil(err)
 
+	_, err = svc.GetReceiptByActionID("")
+	require.Nil(err)
+
+	_, err = svc.GetActionProofByID("")
+	require.Nil(err)
+
 	_, err = svc.GetVotesByAddress("", 0, 10)
 	require.Nil(err)
 
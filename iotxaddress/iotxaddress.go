@@ -18,12 +18,15 @@ Address format to be used on IoTeX blockchains is composed of:
 package iotxaddress
 
 import (
+	"bytes"
 	"errors"
+	"sort"
 
 	"golang.org/x/crypto/blake2b"
 
 	cp "github.com/iotexproject/iotex-core/crypto"
 	"github.com/iotexproject/iotex-core/iotxaddress/bech32"
+	"github.com/iotexproject/iotex-core/pkg/enc"
 	"github.com/iotexproject/iotex-core/pkg/keypair"
 	"github.com/iotexproject/iotex-core/pkg/version"
 )
@@ -33,12 +36,21 @@ var (
 	ErrInvalidVersion = errors.New("invalid version")
 	// ErrInvalidChainID is returned when invalid chain ID has been detected.
 	ErrInvalidChainID = errors.New("invalid chain ID")
+	// ErrInvalidAddress is returned when invalid address has been detected.
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrInvalidMultisig is returned when invalid multisig public keys or threshold has been detected.
+	ErrInvalidMultisig = errors.New("invalid multisig public keys or threshold")
 	// IsTestnet is used to get address
 	IsTestnet = false
 	// ChainID is used to get address
 	ChainID = []byte{0x01, 0x02, 0x03, 0x04}
 )
 
+const (
+	// MaxMultisigPubKeys is the maximum number of public keys of a multisig address
+	MaxMultisigPubKeys = 16
+)
+
 const (
 	mainnetPrefix = "io"
 	testnetPrefix = "it"
@@ -71,22 +83,91 @@ func NewAddress(isTestnet bool, chainid []byte) (*Address, error) {
 
 // GetAddress returns the address given a public key and necessary params.
 func GetAddress(pub keypair.PublicKey, isTestnet bool, chainid []byte) (*Address, error) {
+	raddr, err := GetAddressByHash(HashPubKey(pub), isTestnet, chainid)
+	if err != nil {
+		return nil, err
+	}
+	return &Address{PublicKey: pub, RawAddress: raddr}, nil
+}
+
+// GetAddressByHash returns the address given a public key hash and necessary params.
+func GetAddressByHash(pkHash []byte, isTestnet bool, chainid []byte) (string, error) {
 	hrp := mainnetPrefix
 	if isTestnet {
 		hrp = testnetPrefix
 	}
 
-	payload := append([]byte{version.ProtocolVersion}, append(chainid, HashPubKey(pub)...)...)
+	payload := append([]byte{version.ProtocolVersion}, append(chainid, pkHash...)...)
 	// Group the payload into 5 bit groups.
 	grouped, err := bech32.ConvertBits(payload, 8, 5, true)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	return bech32.Encode(hrp, grouped)
+}
+
+// GetMultisigAddress returns the address controlled by the public keys, which requires the signatures of at least
+// threshold of them. The address does not depend on the order of the public keys.
+func GetMultisigAddress(pubKeys []keypair.PublicKey, threshold uint32, isTestnet bool, chainid []byte) (string, error) {
+	if len(pubKeys) == 0 || len(pubKeys) > MaxMultisigPubKeys || threshold == 0 || int(threshold) > len(pubKeys) {
+		return "", ErrInvalidMultisig
+	}
+	sorted := make([]keypair.PublicKey, len(pubKeys))
+	copy(sorted, pubKeys)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	// multisig hash = blake2b(threshold || sorted public keys)
+	stream := make([]byte, 4)
+	enc.MachineEndian.PutUint32(stream, threshold)
+	for i, pubKey := range sorted {
+		if i > 0 && pubKey == sorted[i-1] {
+			return "", ErrInvalidMultisig
+		}
+		stream = append(stream, pubKey[:]...)
+	}
+	digest := blake2b.Sum256(stream)
+
+	hrp := mainnetPrefix
+	if isTestnet {
+		hrp = testnetPrefix
 	}
-	raddr, err := bech32.Encode(hrp, grouped)
+	payload := append([]byte{version.ProtocolVersion}, append(chainid, digest[7:27]...)...)
+	grouped, err := bech32.ConvertBits(payload, 8, 5, true)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return &Address{PublicKey: pub, RawAddress: raddr}, nil
+	return bech32.Encode(hrp, grouped)
+}
+
+// CreateContractAddress returns the address of the contract deployed by the owner with the given nonce, which shares
+// the prefix, version and chain ID of the owner address
+func CreateContractAddress(owner string, nonce uint64) (string, error) {
+	hrp, grouped, err := bech32.Decode(owner)
+	if err != nil {
+		return "", err
+	}
+	if !isValidHrp(hrp) {
+		return "", ErrInvalidAddress
+	}
+	payload, err := bech32.ConvertBits(grouped[:], 5, 8, false)
+	if err != nil {
+		return "", err
+	}
+	if len(payload) != 25 {
+		return "", ErrInvalidAddress
+	}
+	if !isValidVersion(payload[0]) {
+		return "", ErrInvalidVersion
+	}
+	// contract hash = blake2b(owner public key hash || nonce)
+	temp := make([]byte, 8)
+	enc.MachineEndian.PutUint64(temp, nonce)
+	digest := blake2b.Sum256(append(append([]byte{}, payload[5:25]...), temp...))
+	payload = append(payload[:5:5], digest[7:27]...)
+	grouped, err = bech32.ConvertBits(payload, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, grouped)
 }
 
 // GetPubkeyHash extracts public key hash from address
@@ -35,6 +35,9 @@ func TestNewAddress(t *testing.T) {
 	p2pkh := HashPubKey(addr.PublicKey)
 	require.Equal(p2pkh, GetPubkeyHash(addr.RawAddress))
 	t.Logf("P2PKH = %x", p2pkh)
+	raddr, err := GetAddressByHash(p2pkh, true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.NoError(err)
+	require.Equal(addr.RawAddress, raddr)
 
 	rmsg := make([]byte, 2048)
 	_, err = rand.Read(rmsg)
@@ -97,3 +100,57 @@ func TestInvalidAddress(t *testing.T) {
 	require.Nil(GetPubkeyHash(raddr))
 	require.Equal(false, ValidateAddress(raddr))
 }
+
+func TestCreateContractAddress(t *testing.T) {
+	require := require.New(t)
+	owner, err := NewAddress(true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.Nil(err)
+
+	contract, err := CreateContractAddress(owner.RawAddress, 1)
+	require.Nil(err)
+	require.True(ValidateAddress(contract))
+	require.True(strings.HasPrefix(contract, testnetPrefix))
+	require.NotEqual(owner.RawAddress, contract)
+	// deterministic for the same owner and nonce, and differs for another nonce
+	same, err := CreateContractAddress(owner.RawAddress, 1)
+	require.Nil(err)
+	require.Equal(contract, same)
+	other, err := CreateContractAddress(owner.RawAddress, 2)
+	require.Nil(err)
+	require.NotEqual(contract, other)
+
+	_, err = CreateContractAddress("it1invalid", 1)
+	require.Error(err)
+}
+
+func TestGetMultisigAddress(t *testing.T) {
+	require := require.New(t)
+	var pubKeys []keypair.PublicKey
+	for i := 0; i < 3; i++ {
+		pub, _, err := cp.NewKeyPair()
+		require.Nil(err)
+		pubKeys = append(pubKeys, pub)
+	}
+
+	addr, err := GetMultisigAddress(pubKeys, 2, true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.Nil(err)
+	require.True(ValidateAddress(addr))
+	require.True(strings.HasPrefix(addr, testnetPrefix))
+	// the order of the public keys does not matter, but the threshold does
+	reordered, err := GetMultisigAddress([]keypair.PublicKey{pubKeys[2], pubKeys[0], pubKeys[1]}, 2, true,
+		[]byte{0x00, 0x00, 0x00, 0x01})
+	require.Nil(err)
+	require.Equal(addr, reordered)
+	other, err := GetMultisigAddress(pubKeys, 1, true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.Nil(err)
+	require.NotEqual(addr, other)
+
+	_, err = GetMultisigAddress(pubKeys, 0, true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.Equal(ErrInvalidMultisig, err)
+	_, err = GetMultisigAddress(pubKeys, 4, true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.Equal(ErrInvalidMultisig, err)
+	_, err = GetMultisigAddress([]keypair.PublicKey{pubKeys[0], pubKeys[0]}, 1, true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.Equal(ErrInvalidMultisig, err)
+	_, err = GetMultisigAddress(nil, 1, true, []byte{0x00, 0x00, 0x00, 0x01})
+	require.Equal(ErrInvalidMultisig, err)
+}
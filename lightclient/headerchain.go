@@ -0,0 +1,281 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package lightclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/pkg/enc"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/pkg/version"
+	pb "github.com/iotexproject/iotex-core/proto"
+)
+
+// headerKVNameSpace is the bucket name for the block headers
+const headerKVNameSpace = "Header"
+
+var tipHeightKey = []byte("tipHeight")
+
+var (
+	// ErrInvalidHeader indicates the header does not extend the synced headers
+	ErrInvalidHeader = errors.New("invalid block header")
+	// ErrHeaderNotSynced indicates the header of the height is not synced yet
+	ErrHeaderNotSynced = errors.New("block header is not synced yet")
+	// ErrHeaderNotLinked indicates the header does not link to the synced header of the previous height, as the full node
+	// has switched to a fork from an earlier height
+	ErrHeaderNotLinked = errors.New("block header does not link to the synced headers")
+	// ErrDelegatesNotSynced indicates the delegates of the epoch of the header are not verified yet
+	ErrDelegatesNotSynced = errors.New("delegates are not synced yet")
+	// ErrReorgTooDeep indicates switching to the fork of the header needs to revert too many headers
+	ErrReorgTooDeep = errors.New("fork point is too deep")
+)
+
+// headerChain stores the verified block headers, which start from the genesis block and are linked by the hashes. With
+// a non-zero epoch length, the producer of each header needs to be one of the delegates of its epoch
+type headerChain struct {
+	mu            sync.RWMutex
+	kvstore       db.KVStore
+	genesis       *blockchain.Block
+	schedule      version.Schedule
+	epochLen      uint64
+	maxReorgDepth uint64
+	delegates     map[uint64]map[string]bool
+	tipHeight     uint64
+	tipHash       hash.Hash32B
+}
+
+func newHeaderChain(
+	kvstore db.KVStore,
+	genesis *blockchain.Block,
+	schedule version.Schedule,
+	epochLen uint64,
+	maxReorgDepth uint64,
+) *headerChain {
+	return &headerChain{
+		kvstore:       kvstore,
+		genesis:       genesis,
+		schedule:      schedule,
+		epochLen:      epochLen,
+		maxReorgDepth: maxReorgDepth,
+		delegates:     make(map[uint64]map[string]bool),
+	}
+}
+
+// Start loads the tip of the synced headers, or puts the header of the genesis block as the tip
+func (hc *headerChain) Start(ctx context.Context) error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if err := hc.kvstore.Start(ctx); err != nil {
+		return err
+	}
+	value, err := hc.kvstore.Get(headerKVNameSpace, tipHeightKey)
+	if err != nil {
+		// no header is synced yet
+		if err := hc.putTip(hc.genesis, 0); err != nil {
+			return errors.Wrap(err, "failed to put genesis header")
+		}
+		hc.tipHeight, hc.tipHash = 0, hc.genesis.HashBlock()
+		return nil
+	}
+	hc.tipHeight = enc.MachineEndian.Uint64(value)
+	tip, err := hc.getHeader(hc.tipHeight)
+	if err != nil {
+		return err
+	}
+	hc.tipHash = tip.HashBlock()
+	return nil
+}
+
+// Stop stops the DB of the headers
+func (hc *headerChain) Stop(ctx context.Context) error {
+	return hc.kvstore.Stop(ctx)
+}
+
+// TipHeight returns the height of the latest synced header
+func (hc *headerChain) TipHeight() uint64 {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	return hc.tipHeight
+}
+
+// Header returns the header of the given height as a block without actions
+func (hc *headerChain) Header(height uint64) (*blockchain.Block, error) {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	if height > hc.tipHeight {
+		return nil, errors.Wrapf(ErrHeaderNotSynced, "height %d is above the tip height %d", height, hc.tipHeight)
+	}
+	return hc.getHeader(height)
+}
+
+// CandidatesHeight returns the height of the block whose candidates the delegates of the epoch of the given height are
+// elected from, which is the last height of the previous epoch
+func (hc *headerChain) CandidatesHeight(height uint64) uint64 {
+	if height == 0 || hc.epochLen == 0 {
+		return 0
+	}
+	return (height - 1) / hc.epochLen * hc.epochLen
+}
+
+// IndexedBranches returns true if the states as of the given height are hashed with the branch indexes, so that their
+// proofs can be trusted
+func (hc *headerChain) IndexedBranches(height uint64) bool {
+	return hc.schedule.IsActive(version.FeatureBranchIndex, height)
+}
+
+// SetDelegates sets the verified delegates elected from the candidates as of the given height. Only the delegates of
+// the latest two epochs are kept
+func (hc *headerChain) SetDelegates(height uint64, delegates []string) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	set := make(map[string]bool, len(delegates))
+	for _, addr := range delegates {
+		set[addr] = true
+	}
+	hc.delegates[height] = set
+	for h := range hc.delegates {
+		if h+hc.epochLen < height {
+			delete(hc.delegates, h)
+		}
+	}
+}
+
+// AddHeader verifies the header extends the tip, and puts it as the new tip. The header of a synced height is skipped
+// if it is the same as the synced one. If it conflicts with the synced one, it is rejected unless fork is true, in which
+// case the full node has switched to a fork, so the header replaces the synced one as the new tip once verified against
+// the synced header of the previous height
+func (hc *headerChain) AddHeader(header *blockchain.Block, fork bool) error {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	height := header.Height()
+	if height > hc.tipHeight {
+		if err := hc.validate(header, hc.tipHeight, hc.tipHash); err != nil {
+			return err
+		}
+		if err := hc.putTip(header, hc.tipHeight); err != nil {
+			return errors.Wrapf(err, "failed to put header %d", height)
+		}
+		hc.tipHeight, hc.tipHash = height, header.HashBlock()
+		return nil
+	}
+	synced, err := hc.getHeader(height)
+	if err != nil {
+		return err
+	}
+	if synced.HashBlock() == header.HashBlock() {
+		return nil
+	}
+	if !fork || height == 0 {
+		return errors.Wrapf(ErrInvalidHeader, "header %d conflicts with the synced one", height)
+	}
+	if hc.tipHeight-height+1 > hc.maxReorgDepth {
+		return errors.Wrapf(ErrReorgTooDeep, "switching to header %d reverts the tip %d", height, hc.tipHeight)
+	}
+	parent, err := hc.getHeader(height - 1)
+	if err != nil {
+		return err
+	}
+	if err := hc.validate(header, height-1, parent.HashBlock()); err != nil {
+		return err
+	}
+	if err := hc.putTip(header, hc.tipHeight); err != nil {
+		return errors.Wrapf(err, "failed to put header %d", height)
+	}
+	logger.Warn().Uint64("height", height).Uint64("tip", hc.tipHeight).Msg("Switched to a fork of the headers")
+	// the delegates elected from the reverted headers are no longer valid
+	for h := range hc.delegates {
+		if h >= height {
+			delete(hc.delegates, h)
+		}
+	}
+	hc.tipHeight, hc.tipHash = height, header.HashBlock()
+	return nil
+}
+
+// ======================================
+// private functions
+// ======================================
+// validate checks the header links to the parent, which ties it to the chain of the genesis, and is signed by the
+// producer of the public key in the header, who needs to be one of the delegates of the epoch if the epochs are known
+func (hc *headerChain) validate(header *blockchain.Block, parentHeight uint64, parentHash hash.Hash32B) error {
+	if header.Height() != parentHeight+1 {
+		return errors.Wrapf(ErrInvalidHeader, "wrong height %d, expecting %d", header.Height(), parentHeight+1)
+	}
+	if header.PrevHash() != parentHash {
+		return errors.Wrapf(ErrHeaderNotLinked, "wrong prev hash %x, expecting %x", header.PrevHash(), parentHash)
+	}
+	if expected := hc.schedule.Version(header.Height()); header.Header.Version() != expected {
+		return errors.Wrapf(ErrInvalidHeader, "wrong protocol version %d, expecting %d", header.Header.Version(), expected)
+	}
+	if !header.VerifySignature() {
+		return errors.Wrapf(ErrInvalidHeader, "fail to verify signature with public key %x", header.Header.Pubkey)
+	}
+	if hc.epochLen == 0 {
+		return nil
+	}
+	candidatesHeight := hc.CandidatesHeight(header.Height())
+	delegates, ok := hc.delegates[candidatesHeight]
+	if !ok {
+		return errors.Wrapf(ErrDelegatesNotSynced, "candidates height %d", candidatesHeight)
+	}
+	producer, err := iotxaddress.GetAddress(header.Header.Pubkey, iotxaddress.IsTestnet, iotxaddress.ChainID)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidHeader, "fail to get address of public key %x: %v", header.Header.Pubkey, err)
+	}
+	if !delegates[producer.RawAddress] {
+		return errors.Wrapf(ErrInvalidHeader, "producer %s is not a delegate of header %d", producer.RawAddress, header.Height())
+	}
+	return nil
+}
+
+func (hc *headerChain) getHeader(height uint64) (*blockchain.Block, error) {
+	value, err := hc.kvstore.Get(headerKVNameSpace, byteutil.Uint64ToBytes(height))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get header %d", height)
+	}
+	pbHeader := &pb.BlockHeaderPb{}
+	if err := proto.Unmarshal(value, pbHeader); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal header %d", height)
+	}
+	return headerFromPb(pbHeader), nil
+}
+
+// putTip puts the header as the tip, and deletes the synced headers above it up to the old tip height
+func (hc *headerChain) putTip(header *blockchain.Block, oldTipHeight uint64) error {
+	value, err := proto.Marshal(header.ConvertToBlockHeaderPb())
+	if err != nil {
+		return err
+	}
+	batch := hc.kvstore.Batch()
+	batch.Put(headerKVNameSpace, byteutil.Uint64ToBytes(header.Height()), value, "failed to put header")
+	for h := header.Height() + 1; h <= oldTipHeight; h++ {
+		batch.Delete(headerKVNameSpace, byteutil.Uint64ToBytes(h), "failed to delete header %d", h)
+	}
+	batch.Put(headerKVNameSpace, tipHeightKey, byteutil.Uint64ToBytes(header.Height()), "failed to put tip height")
+	return batch.Commit()
+}
+
+// headerFromPb converts a header to a block without actions, whose hash is the hash of the header
+func headerFromPb(pbHeader *pb.BlockHeaderPb) *blockchain.Block {
+	blk := &blockchain.Block{}
+	blk.ConvertFromBlockHeaderPb(&pb.BlockPb{Header: pbHeader})
+	return blk
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package lightclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	ta "github.com/iotexproject/iotex-core/test/testaddress"
+)
+
+func TestHeaderChain(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.Default
+	bc, _ := newTestChain(t, &cfg)
+	genesis, err := bc.Genesis().Block(&cfg)
+	require.NoError(err)
+	blk1, err := bc.GetBlockByHeight(1)
+	require.NoError(err)
+	blk2, err := bc.GetBlockByHeight(2)
+	require.NoError(err)
+
+	ctx := context.Background()
+	kvstore := db.NewMemKVStore()
+	hc := newHeaderChain(kvstore, genesis, bc.Genesis().Upgrades, 0, 64)
+	require.NoError(hc.Start(ctx))
+	require.Equal(uint64(0), hc.TipHeight())
+
+	// the header needs to follow the tip
+	err = hc.AddHeader(headerFromPb(blk2.ConvertToBlockHeaderPb()), false)
+	require.Equal(ErrInvalidHeader, errors.Cause(err))
+	require.NoError(hc.AddHeader(headerFromPb(blk1.ConvertToBlockHeaderPb()), false))
+	require.NoError(hc.AddHeader(headerFromPb(blk1.ConvertToBlockHeaderPb()), false))
+	require.Equal(uint64(1), hc.TipHeight())
+
+	// the header changed after being signed by the producer is rejected
+	forged := blk2.ConvertToBlockHeaderPb()
+	forged.Timestamp++
+	err = hc.AddHeader(headerFromPb(forged), false)
+	require.Equal(ErrInvalidHeader, errors.Cause(err))
+	forged = blk2.ConvertToBlockHeaderPb()
+	forged.ChainID++
+	err = hc.AddHeader(headerFromPb(forged), false)
+	require.Equal(ErrInvalidHeader, errors.Cause(err))
+	require.NoError(hc.AddHeader(headerFromPb(blk2.ConvertToBlockHeaderPb()), false))
+	forged = blk2.ConvertToBlockHeaderPb()
+	forged.Timestamp++
+	err = hc.AddHeader(headerFromPb(forged), false)
+	require.Equal(ErrInvalidHeader, errors.Cause(err))
+	require.NoError(hc.Stop(ctx))
+
+	// the synced headers are loaded after restart
+	hc = newHeaderChain(kvstore, genesis, bc.Genesis().Upgrades, 0, 64)
+	require.NoError(hc.Start(ctx))
+	require.Equal(uint64(2), hc.TipHeight())
+	header, err := hc.Header(2)
+	require.NoError(err)
+	require.Equal(blk2.HashBlock(), header.HashBlock())
+	_, err = hc.Header(3)
+	require.Equal(ErrHeaderNotSynced, errors.Cause(err))
+}
+
+func TestHeaderChainDelegates(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.Default
+	bc, _ := newTestChain(t, &cfg)
+	genesis, err := bc.Genesis().Block(&cfg)
+	require.NoError(err)
+	blk1, err := bc.GetBlockByHeight(1)
+	require.NoError(err)
+	header1 := headerFromPb(blk1.ConvertToBlockHeaderPb())
+
+	// the epochs are of 2 blocks, so the delegates of block 1 are elected from the candidates of the genesis block
+	ctx := context.Background()
+	hc := newHeaderChain(db.NewMemKVStore(), genesis, bc.Genesis().Upgrades, 2, 64)
+	require.NoError(hc.Start(ctx))
+	require.Equal(uint64(0), hc.CandidatesHeight(1))
+	require.Equal(uint64(0), hc.CandidatesHeight(2))
+	require.Equal(uint64(2), hc.CandidatesHeight(3))
+	err = hc.AddHeader(header1, false)
+	require.Equal(ErrDelegatesNotSynced, errors.Cause(err))
+
+	// the producer needs to be one of the delegates
+	hc.SetDelegates(0, []string{ta.Addrinfo["alfa"].RawAddress, ta.Addrinfo["bravo"].RawAddress})
+	err = hc.AddHeader(header1, false)
+	require.Equal(ErrInvalidHeader, errors.Cause(err))
+	hc.SetDelegates(0, []string{ta.Addrinfo["alfa"].RawAddress, ta.Addrinfo["producer"].RawAddress})
+	require.NoError(hc.AddHeader(header1, false))
+	require.Equal(uint64(1), hc.TipHeight())
+}
+
+func TestHeaderChainFork(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.Default
+	bc, _ := newTestChain(t, &cfg)
+	genesis, err := bc.Genesis().Block(&cfg)
+	require.NoError(err)
+	blk1, err := bc.GetBlockByHeight(1)
+	require.NoError(err)
+	blk2, err := bc.GetBlockByHeight(2)
+	require.NoError(err)
+
+	// the fork shares block 1, and has another block 2 and a block 3 on top
+	fork := blockchain.NewBlockchain(&cfg, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
+	require.NotNil(fork)
+	require.NoError(fork.CommitBlock(blk1))
+	for i := 0; i < 2; i++ {
+		blk, err := fork.MintNewBlock(nil, ta.Addrinfo["producer"], "")
+		require.NoError(err)
+		require.NoError(fork.CommitBlock(blk))
+	}
+	forkBlk2, err := fork.GetBlockByHeight(2)
+	require.NoError(err)
+	forkBlk3, err := fork.GetBlockByHeight(3)
+	require.NoError(err)
+	require.NotEqual(blk2.HashBlock(), forkBlk2.HashBlock())
+
+	ctx := context.Background()
+	hc := newHeaderChain(db.NewMemKVStore(), genesis, bc.Genesis().Upgrades, 0, 1)
+	require.NoError(hc.Start(ctx))
+	require.NoError(hc.AddHeader(headerFromPb(blk1.ConvertToBlockHeaderPb()), false))
+	require.NoError(hc.AddHeader(headerFromPb(blk2.ConvertToBlockHeaderPb()), false))
+
+	// the header of the fork does not link to the tip, and only replaces the synced one if the fork is allowed
+	err = hc.AddHeader(headerFromPb(forkBlk3.ConvertToBlockHeaderPb()), false)
+	require.Equal(ErrHeaderNotLinked, errors.Cause(err))
+	err = hc.AddHeader(headerFromPb(forkBlk2.ConvertToBlockHeaderPb()), false)
+	require.Equal(ErrInvalidHeader, errors.Cause(err))
+	require.NoError(hc.AddHeader(headerFromPb(forkBlk2.ConvertToBlockHeaderPb()), true))
+	require.NoError(hc.AddHeader(headerFromPb(forkBlk3.ConvertToBlockHeaderPb()), false))
+	require.Equal(uint64(3), hc.TipHeight())
+	header, err := hc.Header(2)
+	require.NoError(err)
+	require.Equal(forkBlk2.HashBlock(), header.HashBlock())
+
+	// switching back reverts more headers than allowed
+	err = hc.AddHeader(headerFromPb(blk2.ConvertToBlockHeaderPb()), true)
+	require.Equal(ErrReorgTooDeep, errors.Cause(err))
+}
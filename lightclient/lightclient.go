@@ -0,0 +1,421 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package lightclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/config"
+	cp "github.com/iotexproject/iotex-core/crypto"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/network"
+	"github.com/iotexproject/iotex-core/network/node"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/lifecycle"
+	"github.com/iotexproject/iotex-core/pkg/routine"
+	pb "github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
+)
+
+// MaxHeadersPerSync is the max number of headers requested and sent in one header sync
+const MaxHeadersPerSync = 100
+
+var (
+	// ErrActionNotFound indicates the full node does not find the action on its chain
+	ErrActionNotFound = errors.New("action is not found")
+	// ErrInvalidProof indicates the proof does not match the synced header
+	ErrInvalidProof = errors.New("invalid proof")
+)
+
+// LightClient syncs only the block headers and verifies their producer signatures, and checks the actions and the
+// states against the headers with the Merkle proofs requested from a full node
+type LightClient interface {
+	lifecycle.StartStopper
+
+	// TipHeight returns the height of the latest synced header
+	TipHeight() uint64
+	// HeaderByHeight returns the synced header of the given height as a block without actions
+	HeaderByHeight(uint64) (*blockchain.Block, error)
+	// Sync requests the headers following the tip from the full node
+	Sync()
+	// ProcessHeaders verifies and adds the headers sent by the full node
+	ProcessHeaders([]*pb.BlockHeaderPb) error
+	// ProcessBlockHeader verifies and adds the header of a block broadcast by the delegates
+	ProcessBlockHeader(*pb.BlockHeaderPb) error
+	// ProcessDelegates verifies the delegates of an epoch sent by the full node
+	ProcessDelegates(*pb.DelegatesPb) error
+	// ProcessActionProof hands the proof of an action over to the pending VerifyAction
+	ProcessActionProof(*pb.ActionProofPb) error
+	// ProcessStateProof hands the proof of a state over to the pending State
+	ProcessStateProof(*pb.StateProofPb) error
+	// VerifyAction checks the action is included in a synced block, and returns the height of the block
+	VerifyAction(context.Context, hash.Hash32B) (uint64, error)
+	// State returns the state of an address as of the latest synced header, which is checked against the state root
+	State(context.Context, string) (*state.State, error)
+}
+
+// lightClient implements the LightClient interface
+type lightClient struct {
+	mu           sync.Mutex
+	hc           *headerChain
+	numDelegates int
+	p2p          network.Overlay
+	task         *routine.RecurringTask
+	fnd          string
+	actionProofs map[hash.Hash32B][]chan *pb.ActionProofPb
+	stateProofs  map[string][]chan *pb.StateProofPb
+}
+
+// NewLightClient creates a light client of the chain of the genesis, storing the headers in kvstore. The consensus
+// parameters of the genesis need to be applied to the config beforehand. With the roll-DPoS scheme, the producers of the
+// headers are checked against the delegates of the epochs, which are proved with the states as of the last block of
+// the previous epoch, so the full node needs to keep the history of the states
+func NewLightClient(
+	cfg *config.Config,
+	genesis *blockchain.Genesis,
+	kvstore db.KVStore,
+	p2p network.Overlay,
+) (LightClient, error) {
+	genesisBlk, err := genesis.Block(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create genesis block")
+	}
+	var epochLen uint64
+	if cfg.Consensus.Scheme == config.RollDPoSScheme {
+		numSubEpochs := cfg.Consensus.RollDPoS.NumSubEpochs
+		if numSubEpochs == 0 {
+			numSubEpochs = 1
+		}
+		epochLen = uint64(cfg.Consensus.RollDPoS.NumDelegates) * uint64(numSubEpochs)
+	}
+	lc := &lightClient{
+		hc:           newHeaderChain(kvstore, genesisBlk, genesis.Upgrades, epochLen, uint64(cfg.Chain.MaxReorgDepth)),
+		numDelegates: int(cfg.Consensus.RollDPoS.NumDelegates),
+		p2p:          p2p,
+		actionProofs: make(map[hash.Hash32B][]chan *pb.ActionProofPb),
+		stateProofs:  make(map[string][]chan *pb.StateProofPb),
+	}
+	if cfg.BlockSync.Interval != 0 {
+		lc.task = routine.NewRecurringTask(lc.Sync, cfg.BlockSync.Interval)
+	}
+	for _, bootstrapNode := range cfg.Network.BootstrapNodes {
+		if bootstrapNode != p2p.Self().String() {
+			lc.fnd = bootstrapNode
+			break
+		}
+	}
+	return lc, nil
+}
+
+// Start loads the synced headers and starts syncing the headers
+func (lc *lightClient) Start(ctx context.Context) error {
+	if err := lc.hc.Start(ctx); err != nil {
+		return errors.Wrap(err, "failed to start header chain")
+	}
+	logger.Info().Uint64("height", lc.hc.TipHeight()).Msg("Starting light client")
+	if lc.task != nil {
+		return lc.task.Start(ctx)
+	}
+	return nil
+}
+
+// Stop stops syncing the headers
+func (lc *lightClient) Stop(ctx context.Context) error {
+	if lc.task != nil {
+		if err := lc.task.Stop(ctx); err != nil {
+			return err
+		}
+	}
+	return lc.hc.Stop(ctx)
+}
+
+// TipHeight returns the height of the latest synced header
+func (lc *lightClient) TipHeight() uint64 {
+	return lc.hc.TipHeight()
+}
+
+// HeaderByHeight returns the synced header of the given height
+func (lc *lightClient) HeaderByHeight(height uint64) (*blockchain.Block, error) {
+	return lc.hc.Header(height)
+}
+
+// Sync requests the headers following the tip from the full node
+func (lc *lightClient) Sync() {
+	lc.syncFrom(lc.hc.TipHeight() + 1)
+}
+
+// ProcessHeaders verifies and adds the headers in order, and requests the following headers right away if the full
+// node sends a full batch. The full node follows the fork choice of the chain, so a header conflicting with a synced
+// one switches the synced headers to its fork. If a header does not link to the synced headers, the fork point is
+// earlier, and the headers before it are requested. If the delegates of the epoch of a header are not verified yet,
+// they are requested, and the sync resumes once they are verified
+func (lc *lightClient) ProcessHeaders(headers []*pb.BlockHeaderPb) error {
+	for _, pbHeader := range headers {
+		header := headerFromPb(pbHeader)
+		err := lc.hc.AddHeader(header, true)
+		switch errors.Cause(err) {
+		case nil:
+			continue
+		case ErrDelegatesNotSynced:
+			lc.requestDelegates(header.Height())
+			return nil
+		case ErrHeaderNotLinked:
+			start := uint64(1)
+			if header.Height() > MaxHeadersPerSync {
+				start = header.Height() - MaxHeadersPerSync
+			}
+			lc.syncFrom(start)
+			return nil
+		}
+		return err
+	}
+	if len(headers) > 0 {
+		logger.Debug().Uint64("height", lc.hc.TipHeight()).Msg("Synced headers")
+	}
+	if len(headers) == MaxHeadersPerSync {
+		lc.Sync()
+	}
+	return nil
+}
+
+// ProcessBlockHeader adds the header of a block broadcast by the delegates if it extends the tip. The broadcast blocks
+// are not subject to the fork choice yet, so the headers are synced from the full node instead if the header is ahead
+// of the tip or on another fork
+func (lc *lightClient) ProcessBlockHeader(pbHeader *pb.BlockHeaderPb) error {
+	header := headerFromPb(pbHeader)
+	if header.Height() != lc.hc.TipHeight()+1 {
+		lc.Sync()
+		return nil
+	}
+	err := lc.hc.AddHeader(header, false)
+	switch errors.Cause(err) {
+	case ErrDelegatesNotSynced:
+		lc.requestDelegates(header.Height())
+		return nil
+	case ErrHeaderNotLinked:
+		lc.Sync()
+		return nil
+	}
+	return err
+}
+
+// ProcessDelegates checks the delegates of an epoch against the state root in the synced header of the height of the
+// candidates, and resumes the sync once they are verified. Each delegate needs to be a candidate in the states, and the
+// number of the delegates needs to match the config. The states only prove each delegate is a candidate, not that no
+// candidate with more votes is left out, which would need all the candidates
+func (lc *lightClient) ProcessDelegates(msg *pb.DelegatesPb) error {
+	if msg.Height != lc.hc.CandidatesHeight(msg.Height+1) {
+		return errors.Wrapf(ErrInvalidProof, "height %d is not the candidates height of an epoch", msg.Height)
+	}
+	if len(msg.Proofs) != lc.numDelegates {
+		return errors.Wrapf(ErrInvalidProof, "%d delegates, expecting %d", len(msg.Proofs), lc.numDelegates)
+	}
+	header, err := lc.hc.Header(msg.Height)
+	if err != nil {
+		return err
+	}
+	delegates := make([]string, 0, len(msg.Proofs))
+	seen := make(map[string]bool, len(msg.Proofs))
+	for _, proof := range msg.Proofs {
+		if proof.Height != msg.Height || seen[proof.Address] {
+			return errors.Wrapf(ErrInvalidProof, "delegate %s as of height %d", proof.Address, proof.Height)
+		}
+		s, err := state.VerifyStateProof(
+			header.Header.StateRoot(),
+			proof.Address,
+			proof.Nodes,
+			lc.hc.IndexedBranches(msg.Height),
+		)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidProof, "state of delegate %s as of header %d: %v", proof.Address, msg.Height, err)
+		}
+		if !s.IsCandidate {
+			return errors.Wrapf(ErrInvalidProof, "delegate %s is not a candidate as of header %d", proof.Address, msg.Height)
+		}
+		seen[proof.Address] = true
+		delegates = append(delegates, proof.Address)
+	}
+	lc.hc.SetDelegates(msg.Height, delegates)
+	logger.Debug().Uint64("height", msg.Height).Msg("Synced delegates")
+	lc.Sync()
+	return nil
+}
+
+// ProcessActionProof hands the proof of an action over to the pending VerifyAction
+func (lc *lightClient) ProcessActionProof(proof *pb.ActionProofPb) error {
+	var actHash hash.Hash32B
+	copy(actHash[:], proof.ActHash)
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for _, ch := range lc.actionProofs[actHash] {
+		ch <- proof
+	}
+	delete(lc.actionProofs, actHash)
+	return nil
+}
+
+// ProcessStateProof hands the proof of a state over to the pending State
+func (lc *lightClient) ProcessStateProof(proof *pb.StateProofPb) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for _, ch := range lc.stateProofs[proof.Address] {
+		ch <- proof
+	}
+	delete(lc.stateProofs, proof.Address)
+	return nil
+}
+
+// VerifyAction requests the merkle proof of the action from the full node, and checks the action hashes up to the tx
+// root in the synced header of the block with the proof
+func (lc *lightClient) VerifyAction(ctx context.Context, actHash hash.Hash32B) (uint64, error) {
+	ch := make(chan *pb.ActionProofPb, 1)
+	lc.mu.Lock()
+	lc.actionProofs[actHash] = append(lc.actionProofs[actHash], ch)
+	lc.mu.Unlock()
+	defer lc.cancelActionProof(actHash, ch)
+
+	if err := lc.tell(&pb.ActionProofReq{ActHash: actHash[:]}); err != nil {
+		return 0, err
+	}
+	var proof *pb.ActionProofPb
+	select {
+	case proof = <-ch:
+	case <-ctx.Done():
+		return 0, errors.Wrapf(ctx.Err(), "failed to get proof of action %x", actHash)
+	}
+	if !proof.Found {
+		return 0, errors.Wrapf(ErrActionNotFound, "action %x", actHash)
+	}
+	header, err := lc.hc.Header(proof.Height)
+	if err != nil {
+		return 0, err
+	}
+	mkProof := &cp.MerkleProof{Index: int(proof.Index), Siblings: make([]hash.Hash32B, len(proof.Siblings))}
+	for i, sibling := range proof.Siblings {
+		copy(mkProof.Siblings[i][:], sibling)
+	}
+	if !cp.VerifyMerkleProof(header.Header.TxRoot(), actHash, mkProof) {
+		return 0, errors.Wrapf(
+			ErrInvalidProof,
+			"action %x does not match tx root %x of header %d",
+			actHash,
+			header.Header.TxRoot(),
+			proof.Height)
+	}
+	return proof.Height, nil
+}
+
+// State requests the proof of the state of the address as of the latest synced header from the full node, and checks
+// the proof against the state root in the header. A proof as of any other height is rejected. If the address does not
+// exist, state.ErrAccountNotExist is returned once the proof of the absence is checked
+func (lc *lightClient) State(ctx context.Context, address string) (*state.State, error) {
+	ch := make(chan *pb.StateProofPb, 1)
+	lc.mu.Lock()
+	lc.stateProofs[address] = append(lc.stateProofs[address], ch)
+	lc.mu.Unlock()
+	defer lc.cancelStateProof(address, ch)
+
+	tipHeight := lc.hc.TipHeight()
+	if err := lc.tell(&pb.StateProofReq{Address: address, Height: tipHeight}); err != nil {
+		return nil, err
+	}
+	var proof *pb.StateProofPb
+	select {
+	case proof = <-ch:
+	case <-ctx.Done():
+		return nil, errors.Wrapf(ctx.Err(), "failed to get proof of state of %s", address)
+	}
+	if proof.Height != tipHeight {
+		return nil, errors.Wrapf(ErrInvalidProof, "state of %s as of height %d, expecting %d", address, proof.Height, tipHeight)
+	}
+	if len(proof.Nodes) == 0 {
+		return nil, errors.Wrapf(state.ErrStatesNotAvailable, "address %s", address)
+	}
+	header, err := lc.hc.Header(tipHeight)
+	if err != nil {
+		return nil, err
+	}
+	s, err := state.VerifyStateProof(header.Header.StateRoot(), address, proof.Nodes, lc.hc.IndexedBranches(tipHeight))
+	if errors.Cause(err) == state.ErrAccountNotExist {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidProof, "state of %s as of header %d: %v", address, proof.Height, err)
+	}
+	return s, nil
+}
+
+// ======================================
+// private functions
+// ======================================
+func (lc *lightClient) syncFrom(start uint64) {
+	if lc.fnd == "" {
+		logger.Warn().Msg("No full node to sync headers from")
+		return
+	}
+	if err := lc.tell(&pb.BlockHeaderSync{Start: start, End: start + MaxHeadersPerSync - 1}); err != nil {
+		logger.Error().Err(err).Str("to", lc.fnd).Msg("Failed to request headers")
+	}
+}
+
+func (lc *lightClient) requestDelegates(height uint64) {
+	if err := lc.tell(&pb.DelegatesReq{Height: height}); err != nil {
+		logger.Error().Err(err).Str("to", lc.fnd).Msg("Failed to request delegates")
+	}
+}
+
+func (lc *lightClient) tell(msg proto.Message) error {
+	if lc.fnd == "" {
+		return errors.New("no full node to request from")
+	}
+	return lc.p2p.Tell(node.NewTCPNode(lc.fnd), msg)
+}
+
+func (lc *lightClient) cancelActionProof(actHash hash.Hash32B, ch chan *pb.ActionProofPb) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	pending := lc.actionProofs[actHash]
+	for i, c := range pending {
+		if c == ch {
+			pending = append(pending[:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(pending) == 0 {
+		delete(lc.actionProofs, actHash)
+		return
+	}
+	lc.actionProofs[actHash] = pending
+}
+
+func (lc *lightClient) cancelStateProof(address string, ch chan *pb.StateProofPb) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	pending := lc.stateProofs[address]
+	for i, c := range pending {
+		if c == ch {
+			pending = append(pending[:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(pending) == 0 {
+		delete(lc.stateProofs, address)
+		return
+	}
+	lc.stateProofs[address] = pending
+}
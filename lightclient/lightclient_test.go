@@ -0,0 +1,262 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package lightclient
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/blockchain/action"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/network/node"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	pb "github.com/iotexproject/iotex-core/proto"
+	"github.com/iotexproject/iotex-core/state"
+	"github.com/iotexproject/iotex-core/test/mock/mock_network"
+	ta "github.com/iotexproject/iotex-core/test/testaddress"
+)
+
+func TestLightClient(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Default
+	cfg.BlockSync.Interval = 0
+	bc, tsfHash := newTestChain(t, &cfg)
+
+	// the overlays pass the messages between the light client and the full node right away
+	lcAddr := node.NewTCPNode("127.0.0.1:10001")
+	fnAddr := node.NewTCPNode("127.0.0.1:10000")
+	cfg.Network.BootstrapNodes = []string{fnAddr.String()}
+	var lc LightClient
+	var tampered bool
+	lcP2P := mock_network.NewMockOverlay(ctrl)
+	fnP2P := mock_network.NewMockOverlay(ctrl)
+	ls := NewServer(&cfg, bc, fnP2P)
+	lcP2P.EXPECT().Self().Return(lcAddr).AnyTimes()
+	lcP2P.EXPECT().Tell(fnAddr, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		switch msg := msg.(type) {
+		case *pb.BlockHeaderSync:
+			return ls.ProcessHeaderSyncRequest(lcAddr.String(), msg)
+		case *pb.ActionProofReq:
+			return ls.ProcessActionProofRequest(lcAddr.String(), msg)
+		case *pb.StateProofReq:
+			return ls.ProcessStateProofRequest(lcAddr.String(), msg)
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+	fnP2P.EXPECT().Tell(lcAddr, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		switch msg := msg.(type) {
+		case *pb.BlockHeaderContainer:
+			return lc.ProcessHeaders(msg.Headers)
+		case *pb.ActionProofPb:
+			if tampered {
+				msg.Siblings = append(msg.Siblings, hash.ZeroHash32B[:])
+			}
+			return lc.ProcessActionProof(msg)
+		case *pb.StateProofPb:
+			if tampered {
+				msg.Height--
+			}
+			return lc.ProcessStateProof(msg)
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+
+	lc, err := NewLightClient(&cfg, bc.Genesis(), db.NewMemKVStore(), lcP2P)
+	require.NoError(err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(lc.Start(ctx))
+	defer func() {
+		require.NoError(lc.Stop(ctx))
+	}()
+
+	// the headers are synced up to the tip of the full node
+	require.Equal(uint64(0), lc.TipHeight())
+	lc.Sync()
+	require.Equal(uint64(2), lc.TipHeight())
+	for height := uint64(0); height <= 2; height++ {
+		blk, err := bc.GetBlockByHeight(height)
+		require.NoError(err)
+		header, err := lc.HeaderByHeight(height)
+		require.NoError(err)
+		require.Equal(blk.HashBlock(), header.HashBlock())
+		require.Empty(header.Actions)
+	}
+	_, err = lc.HeaderByHeight(3)
+	require.Equal(ErrHeaderNotSynced, errors.Cause(err))
+
+	// the transfer and the coinbase are in the synced blocks
+	height, err := lc.VerifyAction(ctx, tsfHash)
+	require.NoError(err)
+	require.Equal(uint64(2), height)
+	blk, err := bc.GetBlockByHeight(2)
+	require.NoError(err)
+	require.Equal(2, len(blk.Actions))
+	height, err = lc.VerifyAction(ctx, blk.Actions[1].Hash())
+	require.NoError(err)
+	require.Equal(uint64(2), height)
+	_, err = lc.VerifyAction(ctx, hash.ZeroHash32B)
+	require.Equal(ErrActionNotFound, errors.Cause(err))
+
+	// the balance of charlie is checked against the state root of the tip
+	s, err := lc.State(ctx, ta.Addrinfo["charlie"].RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(3), s.Balance)
+	_, err = lc.State(ctx, ta.Addrinfo["alfa"].RawAddress)
+	require.Equal(state.ErrAccountNotExist, errors.Cause(err))
+
+	// the proofs not matching the headers are rejected
+	tampered = true
+	_, err = lc.VerifyAction(ctx, tsfHash)
+	require.Equal(ErrInvalidProof, errors.Cause(err))
+	_, err = lc.State(ctx, ta.Addrinfo["charlie"].RawAddress)
+	require.Equal(ErrInvalidProof, errors.Cause(err))
+}
+
+func TestServer(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Default
+	bc, _ := newTestChain(t, &cfg)
+	lcAddr := node.NewTCPNode("127.0.0.1:10001")
+	p2p := mock_network.NewMockOverlay(ctrl)
+	ls := NewServer(&cfg, bc, p2p)
+
+	// the range of the header sync is capped by the tip
+	p2p.EXPECT().Tell(lcAddr, gomock.Any()).Do(func(_ net.Addr, msg proto.Message) {
+		headers := msg.(*pb.BlockHeaderContainer).Headers
+		require.Equal(2, len(headers))
+		require.Equal(uint64(1), headers[0].Height)
+		require.Equal(uint64(2), headers[1].Height)
+	}).Times(1)
+	require.NoError(ls.ProcessHeaderSyncRequest(lcAddr.String(), &pb.BlockHeaderSync{Start: 1, End: 100}))
+	// nothing is sent to the synced light client
+	require.NoError(ls.ProcessHeaderSyncRequest(lcAddr.String(), &pb.BlockHeaderSync{Start: 3, End: 102}))
+
+	// no node is sent for a height whose states are not kept
+	p2p.EXPECT().Tell(lcAddr, gomock.Any()).Do(func(_ net.Addr, msg proto.Message) {
+		proof := msg.(*pb.StateProofPb)
+		require.Equal(uint64(1), proof.Height)
+		require.Empty(proof.Nodes)
+	}).Times(1)
+	require.NoError(ls.ProcessStateProofRequest(
+		lcAddr.String(),
+		&pb.StateProofReq{Address: ta.Addrinfo["charlie"].RawAddress, Height: 1},
+	))
+}
+
+func TestLightClientDelegates(t *testing.T) {
+	require := require.New(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := config.Default
+	cfg.BlockSync.Interval = 0
+	cfg.Chain.EnableArchiveMode = true
+	cfg.Consensus.Scheme = config.RollDPoSScheme
+	cfg.Consensus.RollDPoS.NumDelegates = 2
+	cfg.Consensus.RollDPoS.NumSubEpochs = 1
+	bc, _ := newTestChain(t, &cfg)
+
+	lcAddr := node.NewTCPNode("127.0.0.1:10001")
+	fnAddr := node.NewTCPNode("127.0.0.1:10000")
+	cfg.Network.BootstrapNodes = []string{fnAddr.String()}
+	var lc LightClient
+	var delegates *pb.DelegatesPb
+	lcP2P := mock_network.NewMockOverlay(ctrl)
+	fnP2P := mock_network.NewMockOverlay(ctrl)
+	ls := NewServer(&cfg, bc, fnP2P)
+	lcP2P.EXPECT().Self().Return(lcAddr).AnyTimes()
+	lcP2P.EXPECT().Tell(fnAddr, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		switch msg := msg.(type) {
+		case *pb.BlockHeaderSync:
+			return ls.ProcessHeaderSyncRequest(lcAddr.String(), msg)
+		case *pb.DelegatesReq:
+			return ls.ProcessDelegatesRequest(lcAddr.String(), msg)
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+	fnP2P.EXPECT().Tell(lcAddr, gomock.Any()).DoAndReturn(func(_ net.Addr, msg proto.Message) error {
+		switch msg := msg.(type) {
+		case *pb.BlockHeaderContainer:
+			return lc.ProcessHeaders(msg.Headers)
+		case *pb.DelegatesPb:
+			delegates = msg
+			return lc.ProcessDelegates(msg)
+		}
+		return errors.New("unexpected message")
+	}).AnyTimes()
+
+	lc, err := NewLightClient(&cfg, bc.Genesis(), db.NewMemKVStore(), lcP2P)
+	require.NoError(err)
+	ctx := context.Background()
+	require.NoError(lc.Start(ctx))
+	defer func() {
+		require.NoError(lc.Stop(ctx))
+	}()
+
+	// the delegates of the first epoch are the top candidates of the genesis block, which the producer of the test chain
+	// is not one of
+	lc.Sync()
+	require.NotNil(delegates)
+	require.Equal(uint64(0), delegates.Height)
+	require.Equal(2, len(delegates.Proofs))
+	require.Equal(uint64(0), lc.TipHeight())
+	hc := lc.(*lightClient).hc
+	require.Equal(2, len(hc.delegates[0]))
+	_, err = lc.HeaderByHeight(1)
+	require.Equal(ErrHeaderNotSynced, errors.Cause(err))
+
+	// the delegates not proved to be candidates, or of a wrong number, are rejected
+	producer := ta.Addrinfo["producer"].RawAddress
+	nodes, err := bc.StateProof(producer, 0)
+	require.NoError(err)
+	forged := &pb.DelegatesPb{Height: 0, Proofs: []*pb.StateProofPb{
+		delegates.Proofs[0],
+		{Address: producer, Height: 0, Nodes: nodes},
+	}}
+	require.Equal(ErrInvalidProof, errors.Cause(lc.ProcessDelegates(forged)))
+	forged.Proofs = forged.Proofs[:1]
+	require.Equal(ErrInvalidProof, errors.Cause(lc.ProcessDelegates(forged)))
+	forged.Proofs = []*pb.StateProofPb{delegates.Proofs[0], delegates.Proofs[0]}
+	require.Equal(ErrInvalidProof, errors.Cause(lc.ProcessDelegates(forged)))
+}
+
+// newTestChain creates a chain with an empty block 1, and a transfer of 3 from the producer to charlie in block 2
+func newTestChain(t *testing.T, cfg *config.Config) (blockchain.Blockchain, hash.Hash32B) {
+	require := require.New(t)
+
+	bc := blockchain.NewBlockchain(cfg, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
+	require.NotNil(bc)
+	// the producer is funded by the coinbase of block 1
+	blk, err := bc.MintNewBlock(nil, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.NoError(bc.CommitBlock(blk))
+	tsf, err := action.NewTransfer(1, big.NewInt(3), ta.Addrinfo["producer"].RawAddress, ta.Addrinfo["charlie"].RawAddress)
+	require.NoError(err)
+	tsf, err = tsf.Sign(ta.Addrinfo["producer"])
+	require.NoError(err)
+	blk, err = bc.MintNewBlock([]action.Action{tsf}, ta.Addrinfo["producer"], "")
+	require.NoError(err)
+	require.NoError(bc.CommitBlock(blk))
+	return bc, tsf.Hash()
+}
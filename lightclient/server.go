@@ -0,0 +1,137 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package lightclient
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/network"
+	"github.com/iotexproject/iotex-core/network/node"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	pb "github.com/iotexproject/iotex-core/proto"
+)
+
+// Server serves the header sync and the proof requests of the light clients from the blockchain of a full node
+type Server interface {
+	// ProcessHeaderSyncRequest sends the headers of the requested range to the light client
+	ProcessHeaderSyncRequest(string, *pb.BlockHeaderSync) error
+	// ProcessActionProofRequest sends the proof of the requested action to the light client
+	ProcessActionProofRequest(string, *pb.ActionProofReq) error
+	// ProcessStateProofRequest sends the proof of the requested state to the light client
+	ProcessStateProofRequest(string, *pb.StateProofReq) error
+	// ProcessDelegatesRequest sends the delegates of the requested epoch to the light client
+	ProcessDelegatesRequest(string, *pb.DelegatesReq) error
+}
+
+// server implements the Server interface
+type server struct {
+	bc           blockchain.Blockchain
+	p2p          network.Overlay
+	epochLen     uint64
+	numDelegates int
+}
+
+// NewServer creates a server of the light clients on top of the blockchain
+func NewServer(cfg *config.Config, bc blockchain.Blockchain, p2p network.Overlay) Server {
+	numSubEpochs := cfg.Consensus.RollDPoS.NumSubEpochs
+	if numSubEpochs == 0 {
+		numSubEpochs = 1
+	}
+	return &server{
+		bc:           bc,
+		p2p:          p2p,
+		epochLen:     uint64(cfg.Consensus.RollDPoS.NumDelegates) * uint64(numSubEpochs),
+		numDelegates: int(cfg.Consensus.RollDPoS.NumDelegates),
+	}
+}
+
+// ProcessHeaderSyncRequest sends at most MaxHeadersPerSync headers of the requested range, up to the tip
+func (s *server) ProcessHeaderSyncRequest(sender string, sync *pb.BlockHeaderSync) error {
+	tipHeight, err := s.bc.TipHeight()
+	if err != nil {
+		return err
+	}
+	if sync.Start > tipHeight || sync.Start > sync.End {
+		// the light client is already synced
+		return nil
+	}
+	end := sync.End
+	if end > tipHeight {
+		end = tipHeight
+	}
+	if end-sync.Start >= MaxHeadersPerSync {
+		end = sync.Start + MaxHeadersPerSync - 1
+	}
+	headers := make([]*pb.BlockHeaderPb, 0, end-sync.Start+1)
+	for height := sync.Start; height <= end; height++ {
+		blk, err := s.bc.GetBlockByHeight(height)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get block %d", height)
+		}
+		headers = append(headers, blk.ConvertToBlockHeaderPb())
+	}
+	return s.p2p.Tell(node.NewTCPNode(sender), &pb.BlockHeaderContainer{Headers: headers})
+}
+
+// ProcessActionProofRequest sends the merkle proof of the requested transfer or vote, or that the action is not found
+// on the chain
+func (s *server) ProcessActionProofRequest(sender string, req *pb.ActionProofReq) error {
+	var actHash hash.Hash32B
+	copy(actHash[:], req.ActHash)
+	proof := &pb.ActionProofPb{ActHash: req.ActHash}
+
+	height, mkProof, err := s.bc.GetActionProofByActionHash(actHash)
+	if err == nil {
+		proof.Found = true
+		proof.Height = height
+		proof.Index = uint32(mkProof.Index)
+		for _, sibling := range mkProof.Siblings {
+			proof.Siblings = append(proof.Siblings, sibling[:])
+		}
+	} else {
+		logger.Debug().Err(err).Hex("hash", actHash[:]).Msg("Action of proof request is not found")
+	}
+	return s.p2p.Tell(node.NewTCPNode(sender), proof)
+}
+
+// ProcessStateProofRequest sends the proof of the state as of the requested height, or no node if the states of the
+// height are not available
+func (s *server) ProcessStateProofRequest(sender string, req *pb.StateProofReq) error {
+	nodes, err := s.bc.StateProof(req.Address, req.Height)
+	if err != nil {
+		logger.Debug().Err(err).Str("address", req.Address).Msg("State of proof request is not available")
+	}
+	return s.p2p.Tell(node.NewTCPNode(sender), &pb.StateProofPb{Address: req.Address, Height: req.Height, Nodes: nodes})
+}
+
+// ProcessDelegatesRequest sends the delegates of the epoch of the requested height, which are the top candidates as of
+// the last block of the previous epoch, along with the proofs of their states as of the block. Nothing is sent if the
+// candidates or their states of the block are not kept
+func (s *server) ProcessDelegatesRequest(sender string, req *pb.DelegatesReq) error {
+	var height uint64
+	if req.Height > 0 && s.epochLen > 0 {
+		height = (req.Height - 1) / s.epochLen * s.epochLen
+	}
+	candidates, ok := s.bc.CandidatesByHeight(height)
+	if !ok || len(candidates) < s.numDelegates {
+		logger.Debug().Uint64("height", height).Msg("Candidates of delegates request are not available")
+		return nil
+	}
+	msg := &pb.DelegatesPb{Height: height}
+	for _, candidate := range candidates[:s.numDelegates] {
+		nodes, err := s.bc.StateProof(candidate.Address, height)
+		if err != nil {
+			logger.Debug().Err(err).Uint64("height", height).Msg("States of delegates request are not available")
+			return nil
+		}
+		msg.Proofs = append(msg.Proofs, &pb.StateProofPb{Address: candidate.Address, Height: height, Nodes: nodes})
+	}
+	return s.p2p.Tell(node.NewTCPNode(sender), msg)
+}
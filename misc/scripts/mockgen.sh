@@ -68,4 +68,14 @@ mkdir -p ./test/mock/mock_actpool
 mockgen -destination=./test/mock/mock_actpool/mock_actpool.go  \
         -source=./actpool/actpool.go \
         -package=mock_actpool \
-        ActPool
\ No newline at end of file
+        ActPool
+mkdir -p ./test/mock/mock_lightclient
+mockgen -destination=./test/mock/mock_lightclient/mock_lightclient.go  \
+        -source=./lightclient/lightclient.go \
+        -package=mock_lightclient \
+        LightClient
+
+mockgen -destination=./test/mock/mock_lightclient/mock_server.go  \
+        -source=./lightclient/server.go \
+        -package=mock_lightclient \
+        Server
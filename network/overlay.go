@@ -7,6 +7,7 @@
 package network
 
 import (
+	"bytes"
 	"context"
 	"net"
 
@@ -26,6 +27,9 @@ import (
 // ErrPeerNotFound means the peer is not found
 var ErrPeerNotFound = errors.New("Peer not found")
 
+// ErrGenesisMismatch means the node runs another genesis
+var ErrGenesisMismatch = errors.New("node runs another genesis")
+
 // Overlay represents the peer-to-peer network
 type Overlay interface {
 	lifecycle.StartStopper
@@ -44,7 +48,8 @@ type IotxOverlay struct {
 	Config     *config.Network
 	Dispatcher dispatcher.Dispatcher
 
-	lifecycle lifecycle.Lifecycle
+	genesisHash []byte
+	lifecycle   lifecycle.Lifecycle
 }
 
 // NewOverlay creates an instance of IotxOverlay
@@ -77,6 +82,16 @@ func (o *IotxOverlay) AttachDispatcher(dispatcher dispatcher.Dispatcher) {
 	o.Gossip.AttachDispatcher(dispatcher)
 }
 
+// SetGenesisHash sets the hash of the genesis the node runs, the node only peers with the nodes of the same genesis
+func (o *IotxOverlay) SetGenesisHash(genesisHash []byte) {
+	o.genesisHash = genesisHash
+}
+
+// matchGenesis checks if a peer runs the same genesis, which always holds if the genesis is not set
+func (o *IotxOverlay) matchGenesis(genesisHash []byte) bool {
+	return len(o.genesisHash) == 0 || bytes.Equal(o.genesisHash, genesisHash)
+}
+
 func (o *IotxOverlay) addPingTask() {
 	ping := NewPinger(o)
 	pingTask := routine.NewRecurringTask(ping.Ping, o.Config.PingInterval)
@@ -258,6 +258,51 @@ func TestOneConnPerIP(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestGenesisMismatch(t *testing.T) {
+	ctx := context.Background()
+	addr1 := randomAddress()
+	addr2 := randomAddress()
+	addr3 := randomAddress()
+	cfg1 := LoadTestConfig(addr1, true)
+	cfg1.BootstrapNodes = []string{}
+	p1 := NewOverlay(cfg1)
+	p1.SetGenesisHash([]byte("genesis"))
+	p1.AttachDispatcher(&MockDispatcher2{T: t})
+	require.NoError(t, p1.Start(ctx))
+	// p2 runs another genesis, and p3 runs the same genesis as p1
+	cfg2 := LoadTestConfig(addr2, true)
+	cfg2.BootstrapNodes = []string{addr1}
+	p2 := NewOverlay(cfg2)
+	p2.SetGenesisHash([]byte("another genesis"))
+	p2.AttachDispatcher(&MockDispatcher2{T: t})
+	require.NoError(t, p2.Start(ctx))
+	cfg3 := LoadTestConfig(addr3, true)
+	cfg3.BootstrapNodes = []string{addr1}
+	p3 := NewOverlay(cfg3)
+	p3.SetGenesisHash([]byte("genesis"))
+	p3.AttachDispatcher(&MockDispatcher2{T: t})
+	require.NoError(t, p3.Start(ctx))
+
+	defer func() {
+		assert.NoError(t, p1.Stop(ctx))
+		assert.NoError(t, p2.Stop(ctx))
+		assert.NoError(t, p3.Stop(ctx))
+	}()
+
+	err := testutil.WaitUntil(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, ok1 := p1.PM.Peers.Load(addr3)
+		_, ok3 := p3.PM.Peers.Load(addr1)
+		return ok1 && ok3, nil
+	})
+	require.Nil(t, err)
+	time.Sleep(2 * cfg1.PingInterval)
+	// the nodes of different geneses fail the handshake with each other
+	_, ok := p1.PM.Peers.Load(addr2)
+	require.False(t, ok)
+	_, ok = p2.PM.Peers.Load(addr1)
+	require.False(t, ok)
+}
+
 func TestConfigBasedTopology(t *testing.T) {
 	ctx := context.Background()
 	addr1 := randomAddress()
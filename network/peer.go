@@ -7,6 +7,7 @@
 package network
 
 import (
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/net/context"
@@ -26,6 +27,8 @@ type Peer struct {
 	Conn        *grpc.ClientConn
 	Ctx         context.Context
 	LastResTime time.Time
+	// handshaken is set once the peer is known to run the same genesis
+	handshaken uint32
 }
 
 // NewTCPPeer creates an instance of Peer with tcp transportation
@@ -111,6 +114,18 @@ func (p *Peer) Tell(req *pb.TellReq) (*pb.TellRes, error) {
 	return res, e
 }
 
+// Handshake implements the client side RPC
+func (p *Peer) Handshake(req *pb.HandshakeReq) (*pb.HandshakeRes, error) {
+	res, e := p.Client.Handshake(p.Ctx, req)
+	p.updateLastResTime()
+	return res, e
+}
+
+// isHandshaken returns true if the peer is known to run the same genesis
+func (p *Peer) isHandshaken() bool {
+	return atomic.LoadUint32(&p.handshaken) == 1
+}
+
 // Update the last time when successfully getting an response from the peer
 func (p *Peer) updateLastResTime() {
 	p.LastResTime = time.Now()
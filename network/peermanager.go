@@ -9,8 +9,12 @@ package network
 import (
 	"net"
 	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
 
 	"github.com/iotexproject/iotex-core/logger"
+	pb "github.com/iotexproject/iotex-core/network/proto"
 )
 
 // PeerManager represents the outgoing neighbor list
@@ -87,6 +91,18 @@ func (pm *PeerManager) AddPeer(addr string) {
 			Str("src", pm.Overlay.RPC.String()).
 			Str("dst", addr).
 			Msg("failed to establish an outgoing connection")
+		return
+	}
+	if err := pm.handshake(p); errors.Cause(err) == ErrGenesisMismatch {
+		logger.Warn().
+			Err(err).
+			Str("src", pm.Overlay.RPC.String()).
+			Str("dst", addr).
+			Msg("refuse to peer with a node of another genesis")
+		if err := p.Close(); err != nil {
+			logger.Error().Err(err).Str("dst", addr).Msg("failed to terminate an outgoing connection")
+		}
+		return
 	}
 	pm.Peers.Store(addr, p)
 	logger.Debug().
@@ -148,3 +164,17 @@ func (pm *PeerManager) GetOrAddPeer(addr string) *Peer {
 	}
 	return nil
 }
+
+// handshake exchanges the genesis hashes with a node connected to, which is not a peer unless it runs the same genesis.
+// A node not reachable yet is handshaken again before it is pinged
+func (pm *PeerManager) handshake(p *Peer) error {
+	res, err := p.Handshake(&pb.HandshakeReq{GenesisHash: pm.Overlay.genesisHash, Addr: pm.Overlay.RPC.String()})
+	if err != nil {
+		return err
+	}
+	if !pm.Overlay.matchGenesis(res.GenesisHash) {
+		return errors.Wrapf(ErrGenesisMismatch, "genesis = %x", res.GenesisHash)
+	}
+	atomic.StoreUint32(&p.handshaken, 1)
+	return nil
+}
@@ -9,6 +9,8 @@ package network
 import (
 	"math/rand"
 
+	"github.com/pkg/errors"
+
 	"github.com/iotexproject/iotex-core/logger"
 	pb "github.com/iotexproject/iotex-core/network/proto"
 )
@@ -32,6 +34,18 @@ func (h *Pinger) Ping() {
 			if !ok {
 				logger.Error().Msg("value is not an instance of Peer")
 			}
+			if !p.isHandshaken() {
+				err := h.Overlay.PM.handshake(p)
+				if errors.Cause(err) == ErrGenesisMismatch {
+					logger.Warn().Err(err).Str("addr", p.String()).Msg("remove the peer of another genesis")
+					h.Overlay.PM.RemovePeer(p.String())
+					return
+				}
+				if err != nil {
+					logger.Error().Err(err).Str("addr", p.String()).Msg("error when handshaking")
+					return
+				}
+			}
 			pong, err := p.Ping(&pb.Ping{Nonce: n, Addr: h.Overlay.RPC.String()})
 			if err != nil {
 				logger.Error().Err(err).Msg("error when getting pong")
@@ -37,7 +37,7 @@ func (m *Ping) Reset()         { *m = Ping{} }
 func (m *Ping) String() string { return proto.CompactTextString(m) }
 func (*Ping) ProtoMessage()    {}
 func (*Ping) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{0}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{0}
 }
 func (m *Ping) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Ping.Unmarshal(m, b)
@@ -82,7 +82,7 @@ func (m *Pong) Reset()         { *m = Pong{} }
 func (m *Pong) String() string { return proto.CompactTextString(m) }
 func (*Pong) ProtoMessage()    {}
 func (*Pong) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{1}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{1}
 }
 func (m *Pong) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Pong.Unmarshal(m, b)
@@ -120,7 +120,7 @@ func (m *GetPeersReq) Reset()         { *m = GetPeersReq{} }
 func (m *GetPeersReq) String() string { return proto.CompactTextString(m) }
 func (*GetPeersReq) ProtoMessage()    {}
 func (*GetPeersReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{2}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{2}
 }
 func (m *GetPeersReq) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GetPeersReq.Unmarshal(m, b)
@@ -158,7 +158,7 @@ func (m *GetPeersRes) Reset()         { *m = GetPeersRes{} }
 func (m *GetPeersRes) String() string { return proto.CompactTextString(m) }
 func (*GetPeersRes) ProtoMessage()    {}
 func (*GetPeersRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{3}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{3}
 }
 func (m *GetPeersRes) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_GetPeersRes.Unmarshal(m, b)
@@ -199,7 +199,7 @@ func (m *BroadcastReq) Reset()         { *m = BroadcastReq{} }
 func (m *BroadcastReq) String() string { return proto.CompactTextString(m) }
 func (*BroadcastReq) ProtoMessage()    {}
 func (*BroadcastReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{4}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{4}
 }
 func (m *BroadcastReq) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BroadcastReq.Unmarshal(m, b)
@@ -258,7 +258,7 @@ func (m *BroadcastRes) Reset()         { *m = BroadcastRes{} }
 func (m *BroadcastRes) String() string { return proto.CompactTextString(m) }
 func (*BroadcastRes) ProtoMessage()    {}
 func (*BroadcastRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{5}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{5}
 }
 func (m *BroadcastRes) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BroadcastRes.Unmarshal(m, b)
@@ -299,7 +299,7 @@ func (m *TellReq) Reset()         { *m = TellReq{} }
 func (m *TellReq) String() string { return proto.CompactTextString(m) }
 func (*TellReq) ProtoMessage()    {}
 func (*TellReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{6}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{6}
 }
 func (m *TellReq) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TellReq.Unmarshal(m, b)
@@ -358,7 +358,7 @@ func (m *TellRes) Reset()         { *m = TellRes{} }
 func (m *TellRes) String() string { return proto.CompactTextString(m) }
 func (*TellRes) ProtoMessage()    {}
 func (*TellRes) Descriptor() ([]byte, []int) {
-	return fileDescriptor_rpc_e452c36976e6f4c9, []int{7}
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{7}
 }
 func (m *TellRes) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TellRes.Unmarshal(m, b)
@@ -385,6 +385,91 @@ func (m *TellRes) GetHeader() uint32 {
 	return 0
 }
 
+type HandshakeReq struct {
+	// Hash of the genesis the node runs, a node does not peer with the nodes of another genesis
+	GenesisHash          []byte   `protobuf:"bytes,1,opt,name=genesis_hash,json=genesisHash" json:"genesis_hash,omitempty"`
+	Addr                 string   `protobuf:"bytes,2,opt,name=addr" json:"addr,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandshakeReq) Reset()         { *m = HandshakeReq{} }
+func (m *HandshakeReq) String() string { return proto.CompactTextString(m) }
+func (*HandshakeReq) ProtoMessage()    {}
+func (*HandshakeReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{8}
+}
+func (m *HandshakeReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandshakeReq.Unmarshal(m, b)
+}
+func (m *HandshakeReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandshakeReq.Marshal(b, m, deterministic)
+}
+func (dst *HandshakeReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandshakeReq.Merge(dst, src)
+}
+func (m *HandshakeReq) XXX_Size() int {
+	return xxx_messageInfo_HandshakeReq.Size(m)
+}
+func (m *HandshakeReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandshakeReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandshakeReq proto.InternalMessageInfo
+
+func (m *HandshakeReq) GetGenesisHash() []byte {
+	if m != nil {
+		return m.GenesisHash
+	}
+	return nil
+}
+
+func (m *HandshakeReq) GetAddr() string {
+	if m != nil {
+		return m.Addr
+	}
+	return ""
+}
+
+type HandshakeRes struct {
+	GenesisHash          []byte   `protobuf:"bytes,1,opt,name=genesis_hash,json=genesisHash" json:"genesis_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandshakeRes) Reset()         { *m = HandshakeRes{} }
+func (m *HandshakeRes) String() string { return proto.CompactTextString(m) }
+func (*HandshakeRes) ProtoMessage()    {}
+func (*HandshakeRes) Descriptor() ([]byte, []int) {
+	return fileDescriptor_rpc_1e140ee6164c1967, []int{9}
+}
+func (m *HandshakeRes) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandshakeRes.Unmarshal(m, b)
+}
+func (m *HandshakeRes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandshakeRes.Marshal(b, m, deterministic)
+}
+func (dst *HandshakeRes) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandshakeRes.Merge(dst, src)
+}
+func (m *HandshakeRes) XXX_Size() int {
+	return xxx_messageInfo_HandshakeRes.Size(m)
+}
+func (m *HandshakeRes) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandshakeRes.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandshakeRes proto.InternalMessageInfo
+
+func (m *HandshakeRes) GetGenesisHash() []byte {
+	if m != nil {
+		return m.GenesisHash
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Ping)(nil), "network.Ping")
 	proto.RegisterType((*Pong)(nil), "network.Pong")
@@ -394,6 +479,8 @@ func init() {
 	proto.RegisterType((*BroadcastRes)(nil), "network.BroadcastRes")
 	proto.RegisterType((*TellReq)(nil), "network.TellReq")
 	proto.RegisterType((*TellRes)(nil), "network.TellRes")
+	proto.RegisterType((*HandshakeReq)(nil), "network.HandshakeReq")
+	proto.RegisterType((*HandshakeRes)(nil), "network.HandshakeRes")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -412,6 +499,7 @@ type PeerClient interface {
 	GetPeers(ctx context.Context, in *GetPeersReq, opts ...grpc.CallOption) (*GetPeersRes, error)
 	Broadcast(ctx context.Context, in *BroadcastReq, opts ...grpc.CallOption) (*BroadcastRes, error)
 	Tell(ctx context.Context, in *TellReq, opts ...grpc.CallOption) (*TellRes, error)
+	Handshake(ctx context.Context, in *HandshakeReq, opts ...grpc.CallOption) (*HandshakeRes, error)
 }
 
 type peerClient struct {
@@ -458,12 +546,22 @@ func (c *peerClient) Tell(ctx context.Context, in *TellReq, opts ...grpc.CallOpt
 	return out, nil
 }
 
+func (c *peerClient) Handshake(ctx context.Context, in *HandshakeReq, opts ...grpc.CallOption) (*HandshakeRes, error) {
+	out := new(HandshakeRes)
+	err := c.cc.Invoke(ctx, "/network.Peer/handshake", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PeerServer is the server API for Peer service.
 type PeerServer interface {
 	Ping(context.Context, *Ping) (*Pong, error)
 	GetPeers(context.Context, *GetPeersReq) (*GetPeersRes, error)
 	Broadcast(context.Context, *BroadcastReq) (*BroadcastRes, error)
 	Tell(context.Context, *TellReq) (*TellRes, error)
+	Handshake(context.Context, *HandshakeReq) (*HandshakeRes, error)
 }
 
 func RegisterPeerServer(s *grpc.Server, srv PeerServer) {
@@ -542,6 +640,24 @@ func _Peer_Tell_Handler(srv interface{}, ctx context.Context, dec func(interface
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Peer_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandshakeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).Handshake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/network.Peer/Handshake",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).Handshake(ctx, req.(*HandshakeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Peer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "network.Peer",
 	HandlerType: (*PeerServer)(nil),
@@ -562,35 +678,42 @@ var _Peer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "tell",
 			Handler:    _Peer_Tell_Handler,
 		},
+		{
+			MethodName: "handshake",
+			Handler:    _Peer_Handshake_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "network/proto/rpc.proto",
 }
 
-func init() { proto.RegisterFile("network/proto/rpc.proto", fileDescriptor_rpc_e452c36976e6f4c9) }
-
-var fileDescriptor_rpc_e452c36976e6f4c9 = []byte{
-	// 348 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x52, 0x4d, 0x4f, 0xf2, 0x40,
-	0x18, 0xa4, 0x6f, 0xf7, 0xe5, 0xe3, 0x11, 0x12, 0xf2, 0x04, 0xb5, 0xd6, 0x4b, 0x59, 0x12, 0xc2,
-	0xc1, 0x80, 0xd1, 0x8b, 0x89, 0x37, 0x2e, 0xde, 0x0c, 0x69, 0xb8, 0x93, 0xd2, 0x6e, 0xaa, 0x69,
-	0xd9, 0xad, 0xbb, 0x6b, 0x4c, 0x7f, 0xa7, 0x7f, 0xc8, 0xec, 0x52, 0x9a, 0x62, 0xc0, 0xdb, 0xce,
-	0xf3, 0x35, 0x93, 0x99, 0x85, 0x6b, 0xce, 0xf4, 0x97, 0x90, 0xd9, 0xa2, 0x90, 0x42, 0x8b, 0x85,
-	0x2c, 0xe2, 0xb9, 0x7d, 0x61, 0xa7, 0x6a, 0xd0, 0x7b, 0x20, 0xab, 0x77, 0x9e, 0xe2, 0x08, 0xfe,
-	0x73, 0xc1, 0x63, 0xe6, 0x39, 0x81, 0x33, 0x23, 0xe1, 0x1e, 0x20, 0x02, 0x89, 0x92, 0x44, 0x7a,
-	0xff, 0x02, 0x67, 0xd6, 0x0b, 0xed, 0x9b, 0x4e, 0x80, 0xac, 0x04, 0x4f, 0xf1, 0x16, 0x7a, 0x51,
-	0x9c, 0x6d, 0x9a, 0x5b, 0xdd, 0x28, 0xce, 0x5e, 0x0d, 0xa6, 0x13, 0xb8, 0x78, 0x61, 0x7a, 0xc5,
-	0x98, 0x54, 0x21, 0xfb, 0x30, 0xd7, 0x63, 0xf1, 0xc9, 0xb5, 0x9d, 0x1b, 0x84, 0x7b, 0x40, 0xc7,
-	0xcd, 0x21, 0x55, 0x93, 0x39, 0x81, 0x5b, 0x93, 0x71, 0xe8, 0x2f, 0xa5, 0x88, 0x92, 0x38, 0x52,
-	0xda, 0x1c, 0xba, 0x82, 0xf6, 0x1b, 0x8b, 0x12, 0x26, 0xab, 0x4b, 0x15, 0xc2, 0x1b, 0xe8, 0xee,
-	0x54, 0xba, 0xd1, 0x65, 0xc1, 0xac, 0xd8, 0x41, 0xd8, 0xd9, 0xa9, 0x74, 0x5d, 0x16, 0xec, 0xd0,
-	0xda, 0x8a, 0xa4, 0xf4, 0xdc, 0xc0, 0x99, 0xf5, 0x6d, 0x6b, 0x29, 0x92, 0x12, 0x87, 0xe0, 0x6a,
-	0x9d, 0x7b, 0xc4, 0x2e, 0x98, 0x27, 0x9d, 0x1e, 0xf1, 0xa9, 0x73, 0x7c, 0x34, 0x83, 0xce, 0x9a,
-	0xe5, 0xf9, 0x5f, 0x92, 0x4e, 0x78, 0x77, 0x24, 0xd3, 0x3d, 0x2f, 0x93, 0x1c, 0xc9, 0xa4, 0xe3,
-	0x03, 0xd9, 0x59, 0x3d, 0x0f, 0xdf, 0x0e, 0x10, 0x63, 0x24, 0x4e, 0x81, 0x14, 0x26, 0xcf, 0xc1,
-	0xbc, 0x4a, 0x78, 0x6e, 0xe2, 0xf5, 0x1b, 0x50, 0xf0, 0x94, 0xb6, 0xf0, 0x09, 0xba, 0x69, 0xe5,
-	0x3d, 0x8e, 0xea, 0x66, 0x23, 0x33, 0xff, 0x54, 0x55, 0xd1, 0x16, 0x3e, 0x43, 0x6f, 0x7b, 0xb0,
-	0x08, 0x2f, 0xeb, 0xa1, 0x66, 0x4c, 0xfe, 0xc9, 0xb2, 0x59, 0xbe, 0x03, 0xa2, 0x59, 0x9e, 0xe3,
-	0xb0, 0x1e, 0xa8, 0x6c, 0xf4, 0x7f, 0x57, 0x14, 0x6d, 0x6d, 0xdb, 0xf6, 0xb3, 0x3e, 0xfe, 0x04,
-	0x00, 0x00, 0xff, 0xff, 0xb2, 0x38, 0xd7, 0x27, 0xc7, 0x02, 0x00, 0x00,
+func init() { proto.RegisterFile("network/proto/rpc.proto", fileDescriptor_rpc_1e140ee6164c1967) }
+
+var fileDescriptor_rpc_1e140ee6164c1967 = []byte{
+	// 400 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x53, 0xdf, 0xab, 0xda, 0x30,
+	0x18, 0xb5, 0xb7, 0xd9, 0x55, 0xbf, 0x5b, 0xe1, 0x12, 0xdc, 0xd6, 0x75, 0x2f, 0x35, 0x82, 0xf8,
+	0x30, 0x74, 0x3f, 0x5e, 0x06, 0x7b, 0x13, 0xc6, 0x7c, 0x1a, 0x52, 0x7c, 0x97, 0xd8, 0x86, 0x56,
+	0x5a, 0x93, 0xae, 0xc9, 0x18, 0xfd, 0x2f, 0xf6, 0x27, 0x8f, 0xc4, 0xb4, 0xb4, 0xa3, 0x8e, 0xbd,
+	0xe5, 0xfb, 0x79, 0x0e, 0xdf, 0x39, 0x81, 0xd7, 0x9c, 0xa9, 0x5f, 0xa2, 0xca, 0xb7, 0x65, 0x25,
+	0x94, 0xd8, 0x56, 0x65, 0xbc, 0x31, 0x2f, 0x3c, 0xb6, 0x05, 0xf2, 0x1e, 0xd0, 0xe1, 0xc2, 0x53,
+	0x3c, 0x87, 0x17, 0x5c, 0xf0, 0x98, 0xf9, 0x4e, 0xe8, 0xac, 0x51, 0x74, 0x0b, 0x30, 0x06, 0x44,
+	0x93, 0xa4, 0xf2, 0x1f, 0x42, 0x67, 0x3d, 0x8d, 0xcc, 0x9b, 0x2c, 0x01, 0x1d, 0x04, 0x4f, 0xf1,
+	0x5b, 0x98, 0xd2, 0x38, 0x3f, 0x75, 0xa7, 0x26, 0x34, 0xce, 0xbf, 0xeb, 0x98, 0x2c, 0xe1, 0xe9,
+	0x1b, 0x53, 0x07, 0xc6, 0x2a, 0x19, 0xb1, 0x1f, 0x7a, 0x7b, 0x2c, 0x7e, 0x72, 0x65, 0xfa, 0x66,
+	0xd1, 0x2d, 0x20, 0x8b, 0x6e, 0x93, 0x6c, 0xc1, 0x9c, 0xd0, 0x6d, 0xc1, 0x38, 0x78, 0xbb, 0x4a,
+	0xd0, 0x24, 0xa6, 0x52, 0xe9, 0x45, 0xaf, 0xe0, 0x31, 0x63, 0x34, 0x61, 0x95, 0xdd, 0x64, 0x23,
+	0xfc, 0x06, 0x26, 0x57, 0x99, 0x9e, 0x54, 0x5d, 0x32, 0x43, 0x76, 0x16, 0x8d, 0xaf, 0x32, 0x3d,
+	0xd6, 0x25, 0x6b, 0x4a, 0x67, 0x91, 0xd4, 0xbe, 0x1b, 0x3a, 0x6b, 0xcf, 0x94, 0x76, 0x22, 0xa9,
+	0xf1, 0x33, 0xb8, 0x4a, 0x15, 0x3e, 0x32, 0x03, 0xfa, 0x49, 0x56, 0x3d, 0x3c, 0x79, 0x0f, 0x8f,
+	0xe4, 0x30, 0x3e, 0xb2, 0xa2, 0xf8, 0x17, 0xa5, 0x81, 0xdb, 0xf5, 0x68, 0xba, 0xf7, 0x69, 0xa2,
+	0x1e, 0x4d, 0xb2, 0x68, 0xc0, 0xee, 0xf3, 0xf9, 0x0a, 0xde, 0x9e, 0xf2, 0x44, 0x66, 0x34, 0x67,
+	0x9a, 0xd4, 0x02, 0xbc, 0x94, 0x71, 0x26, 0x2f, 0xf2, 0x94, 0x51, 0x99, 0x99, 0x6e, 0x2f, 0x7a,
+	0xb2, 0xb9, 0x3d, 0x95, 0xd9, 0xa0, 0xb6, 0x1f, 0x7a, 0x6b, 0xe4, 0x7f, 0xac, 0xf9, 0xf8, 0xfb,
+	0x01, 0x90, 0x96, 0x10, 0xaf, 0x00, 0x95, 0xda, 0x49, 0xb3, 0x8d, 0xf5, 0xd6, 0x46, 0x1b, 0x2b,
+	0xe8, 0x84, 0x82, 0xa7, 0x64, 0x84, 0x3f, 0xc3, 0x24, 0xb5, 0xaa, 0xe3, 0x79, 0x5b, 0xec, 0xb8,
+	0x25, 0x18, 0xca, 0x4a, 0x32, 0xc2, 0x5f, 0x60, 0x7a, 0x6e, 0xc4, 0xc1, 0x2f, 0xdb, 0xa6, 0xae,
+	0x41, 0x82, 0xc1, 0xb4, 0x1e, 0x7e, 0x07, 0x48, 0xb1, 0xa2, 0xc0, 0xcf, 0x6d, 0x83, 0x15, 0x30,
+	0xf8, 0x3b, 0x63, 0xa1, 0xb2, 0xe6, 0x10, 0x1d, 0xa8, 0xee, 0x8d, 0x83, 0xc1, 0xb4, 0x24, 0xa3,
+	0xf3, 0xa3, 0xf9, 0x63, 0x9f, 0xfe, 0x0c, 0x00, 0x41, 0x80, 0x66, 0x60, 0x7e, 0x03, 0x00, 0x00,
 }
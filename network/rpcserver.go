@@ -126,6 +126,25 @@ func (s *RPCServer) Tell(ctx context.Context, req *pb.TellReq) (*pb.TellRes, err
 	return &pb.TellRes{Header: iproto.MagicBroadcastMsgHeader}, nil
 }
 
+// Handshake implements the server side RPC logic, which tells the genesis the node runs to a node connecting to it
+func (s *RPCServer) Handshake(ctx context.Context, req *pb.HandshakeReq) (*pb.HandshakeRes, error) {
+	drop, err := s.shouldDropRequest(ctx)
+	s.updateLastResTime()
+	if err != nil {
+		return nil, err
+	}
+	if drop {
+		return nil, fmt.Errorf("sended requests too frequently")
+	}
+	if !s.Overlay.matchGenesis(req.GenesisHash) {
+		logger.Warn().
+			Str("addr", req.Addr).
+			Hex("genesis", req.GenesisHash).
+			Msg("refuse to peer with a node of another genesis")
+	}
+	return &pb.HandshakeRes{GenesisHash: s.Overlay.genesisHash}, nil
+}
+
 // Start starts the rpc server
 func (s *RPCServer) Start(_ context.Context) error {
 	lis, err := net.Listen(s.Network(), s.String())
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package version
+
+import (
+	"github.com/pkg/errors"
+)
+
+const (
+	// FeatureExecution enables the contract executions
+	FeatureExecution = "execution"
+	// FeatureStake enables the stakes and unstakes
+	FeatureStake = "stake"
+	// FeatureGasLimitFee charges the fee of transfers, votes and stakes against their gas limit instead of their
+	// intrinsic gas
+	FeatureGasLimitFee = "gaslimitfee"
+	// FeatureMultisig enables the transfers from multisig addresses
+	FeatureMultisig = "multisig"
+	// FeatureScheduledTransfer enables the transfers held until a height or a timestamp
+	FeatureScheduledTransfer = "scheduledtransfer"
+	// FeatureStakedVoting counts only the bonded stake as voting weight if the genesis enables staked voting, the
+	// voting weights are recounted by the block activating it
+	FeatureStakedVoting = "stakedvoting"
+	// FeatureEpochReward settles the epoch reward of the genesis among the block producers
+	FeatureEpochReward = "epochreward"
+	// FeatureStateEncoding encodes the states in versioned protobuf instead of gob, the states in gob are converted by
+	// the block activating it
+	FeatureStateEncoding = "stateencoding"
+	// FeatureBranchIndex hashes the branches of the account trie over the slot indexes of their children, so that the
+	// state proofs commit to the absence of an account, the branches are rehashed by the block activating it
+	FeatureBranchIndex = "branchindex"
+)
+
+// ErrInvalidSchedule indicates the upgrades of a schedule are not in order
+var ErrInvalidSchedule = errors.New("invalid upgrade schedule")
+
+type (
+	// Upgrade is a change of the protocol rules taking effect at a height, which activates the features and sets the
+	// protocol version of the blocks since the height
+	Upgrade struct {
+		Height   uint64   `yaml:"height"`
+		Version  uint32   `yaml:"version"`
+		Features []string `yaml:"features"`
+	}
+
+	// Schedule is the upgrades of a chain in the order of their heights. A feature not in any upgrade is part of the
+	// protocol since the genesis
+	Schedule []Upgrade
+)
+
+// Validate checks the upgrades are in the order of their heights, each raising the protocol version
+func (s Schedule) Validate() error {
+	height := uint64(0)
+	version := uint32(ProtocolVersion)
+	for i, upgrade := range s {
+		if i > 0 && upgrade.Height <= height {
+			return errors.Wrapf(ErrInvalidSchedule, "upgrade at height %d is not after height %d", upgrade.Height,
+				height)
+		}
+		if upgrade.Version <= version {
+			return errors.Wrapf(ErrInvalidSchedule, "version %d of upgrade at height %d is not above %d",
+				upgrade.Version, upgrade.Height, version)
+		}
+		height = upgrade.Height
+		version = upgrade.Version
+	}
+	return nil
+}
+
+// Version returns the protocol version of the block at the height
+func (s Schedule) Version(height uint64) uint32 {
+	version := uint32(ProtocolVersion)
+	for _, upgrade := range s {
+		if upgrade.Height > height {
+			break
+		}
+		version = upgrade.Version
+	}
+	return version
+}
+
+// IsActive returns true if the feature is in effect at the height
+func (s Schedule) IsActive(feature string, height uint64) bool {
+	for _, upgrade := range s {
+		for _, f := range upgrade.Features {
+			if f == feature {
+				return height >= upgrade.Height
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package version
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	require := require.New(t)
+
+	var empty Schedule
+	require.NoError(empty.Validate())
+	require.Equal(uint32(ProtocolVersion), empty.Version(100))
+	require.True(empty.IsActive(FeatureStake, 0))
+
+	s := Schedule{
+		{Height: 10, Version: 2, Features: []string{FeatureExecution}},
+		{Height: 20, Version: 3, Features: []string{FeatureStake}},
+	}
+	require.NoError(s.Validate())
+	require.Equal(uint32(ProtocolVersion), s.Version(9))
+	require.Equal(uint32(2), s.Version(10))
+	require.Equal(uint32(2), s.Version(19))
+	require.Equal(uint32(3), s.Version(20))
+	require.False(s.IsActive(FeatureExecution, 9))
+	require.True(s.IsActive(FeatureExecution, 10))
+	require.False(s.IsActive(FeatureStake, 19))
+	require.True(s.IsActive(FeatureStake, 20))
+	require.True(s.IsActive("transfer", 0))
+
+	s = Schedule{{Height: 20, Version: 2}, {Height: 10, Version: 3}}
+	require.Equal(ErrInvalidSchedule, errors.Cause(s.Validate()))
+	s = Schedule{{Height: 10, Version: 2}, {Height: 20, Version: 2}}
+	require.Equal(ErrInvalidSchedule, errors.Cause(s.Validate()))
+	s = Schedule{{Height: 10, Version: ProtocolVersion}}
+	require.Equal(ErrInvalidSchedule, errors.Cause(s.Validate()))
+}
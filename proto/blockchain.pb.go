@@ -44,7 +44,7 @@ func (x ViewChangeMsg_ViewChangeType) String() string {
 	return proto.EnumName(ViewChangeMsg_ViewChangeType_name, int32(x))
 }
 func (ViewChangeMsg_ViewChangeType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{8, 0}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{27, 0}
 }
 
 type TransferPb struct {
@@ -54,12 +54,22 @@ type TransferPb struct {
 	Nonce     uint64 `protobuf:"varint,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
 	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
 	// used by state-based model
-	Amount               []byte   `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
-	Sender               string   `protobuf:"bytes,5,opt,name=sender,proto3" json:"sender,omitempty"`
-	Recipient            string   `protobuf:"bytes,6,opt,name=recipient,proto3" json:"recipient,omitempty"`
-	Payload              []byte   `protobuf:"bytes,7,opt,name=payload,proto3" json:"payload,omitempty"`
-	SenderPubKey         []byte   `protobuf:"bytes,8,opt,name=senderPubKey,proto3" json:"senderPubKey,omitempty"`
-	IsCoinbase           bool     `protobuf:"varint,9,opt,name=isCoinbase,proto3" json:"isCoinbase,omitempty"`
+	Amount       []byte `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	Sender       string `protobuf:"bytes,5,opt,name=sender,proto3" json:"sender,omitempty"`
+	Recipient    string `protobuf:"bytes,6,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	Payload      []byte `protobuf:"bytes,7,opt,name=payload,proto3" json:"payload,omitempty"`
+	SenderPubKey []byte `protobuf:"bytes,8,opt,name=senderPubKey,proto3" json:"senderPubKey,omitempty"`
+	IsCoinbase   bool   `protobuf:"varint,9,opt,name=isCoinbase,proto3" json:"isCoinbase,omitempty"`
+	// fee = gasPrice * gas consumed, which shall not exceed gasLimit
+	GasLimit uint64 `protobuf:"varint,10,opt,name=gasLimit,proto3" json:"gasLimit,omitempty"`
+	GasPrice []byte `protobuf:"bytes,11,opt,name=gasPrice,proto3" json:"gasPrice,omitempty"`
+	// used by multisig sender, whose address derives from the public keys and the threshold
+	MultisigPubKeys    [][]byte `protobuf:"bytes,12,rep,name=multisigPubKeys,proto3" json:"multisigPubKeys,omitempty"`
+	MultisigThreshold  uint32   `protobuf:"varint,13,opt,name=multisigThreshold,proto3" json:"multisigThreshold,omitempty"`
+	MultisigSignatures [][]byte `protobuf:"bytes,14,rep,name=multisigSignatures,proto3" json:"multisigSignatures,omitempty"`
+	// the transfer cannot be included in a block below this height or before this unix timestamp
+	NotBeforeHeight      uint64   `protobuf:"varint,15,opt,name=notBeforeHeight,proto3" json:"notBeforeHeight,omitempty"`
+	NotBeforeTimestamp   uint64   `protobuf:"varint,16,opt,name=notBeforeTimestamp,proto3" json:"notBeforeTimestamp,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -69,7 +79,7 @@ func (m *TransferPb) Reset()         { *m = TransferPb{} }
 func (m *TransferPb) String() string { return proto.CompactTextString(m) }
 func (*TransferPb) ProtoMessage()    {}
 func (*TransferPb) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{0}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{0}
 }
 func (m *TransferPb) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TransferPb.Unmarshal(m, b)
@@ -152,6 +162,55 @@ func (m *TransferPb) GetIsCoinbase() bool {
 	return false
 }
 
+func (m *TransferPb) GetGasLimit() uint64 {
+	if m != nil {
+		return m.GasLimit
+	}
+	return 0
+}
+
+func (m *TransferPb) GetGasPrice() []byte {
+	if m != nil {
+		return m.GasPrice
+	}
+	return nil
+}
+
+func (m *TransferPb) GetMultisigPubKeys() [][]byte {
+	if m != nil {
+		return m.MultisigPubKeys
+	}
+	return nil
+}
+
+func (m *TransferPb) GetMultisigThreshold() uint32 {
+	if m != nil {
+		return m.MultisigThreshold
+	}
+	return 0
+}
+
+func (m *TransferPb) GetMultisigSignatures() [][]byte {
+	if m != nil {
+		return m.MultisigSignatures
+	}
+	return nil
+}
+
+func (m *TransferPb) GetNotBeforeHeight() uint64 {
+	if m != nil {
+		return m.NotBeforeHeight
+	}
+	return 0
+}
+
+func (m *TransferPb) GetNotBeforeTimestamp() uint64 {
+	if m != nil {
+		return m.NotBeforeTimestamp
+	}
+	return 0
+}
+
 type VotePb struct {
 	// VotePb should share these three fields with other Actions
 	// TODO: extract these three fields to ActionPb
@@ -162,6 +221,8 @@ type VotePb struct {
 	SelfPubkey           []byte   `protobuf:"bytes,5,opt,name=selfPubkey,proto3" json:"selfPubkey,omitempty"`
 	VoterAddress         string   `protobuf:"bytes,6,opt,name=voterAddress,proto3" json:"voterAddress,omitempty"`
 	VoteeAddress         string   `protobuf:"bytes,7,opt,name=voteeAddress,proto3" json:"voteeAddress,omitempty"`
+	GasLimit             uint64   `protobuf:"varint,8,opt,name=gasLimit,proto3" json:"gasLimit,omitempty"`
+	GasPrice             []byte   `protobuf:"bytes,9,opt,name=gasPrice,proto3" json:"gasPrice,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -171,7 +232,7 @@ func (m *VotePb) Reset()         { *m = VotePb{} }
 func (m *VotePb) String() string { return proto.CompactTextString(m) }
 func (*VotePb) ProtoMessage()    {}
 func (*VotePb) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{1}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{1}
 }
 func (m *VotePb) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_VotePb.Unmarshal(m, b)
@@ -240,10 +301,244 @@ func (m *VotePb) GetVoteeAddress() string {
 	return ""
 }
 
+func (m *VotePb) GetGasLimit() uint64 {
+	if m != nil {
+		return m.GasLimit
+	}
+	return 0
+}
+
+func (m *VotePb) GetGasPrice() []byte {
+	if m != nil {
+		return m.GasPrice
+	}
+	return nil
+}
+
+type ExecutionPb struct {
+	// ExecutionPb should share these three fields with other Actions
+	// TODO: extract these three fields to ActionPb
+	Version   uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Nonce     uint64 `protobuf:"varint,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	// contract is empty when deploying a new contract, and data is the bytecode to deploy
+	Amount               []byte   `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	Executor             string   `protobuf:"bytes,5,opt,name=executor,proto3" json:"executor,omitempty"`
+	Contract             string   `protobuf:"bytes,6,opt,name=contract,proto3" json:"contract,omitempty"`
+	ExecutorPubKey       []byte   `protobuf:"bytes,7,opt,name=executorPubKey,proto3" json:"executorPubKey,omitempty"`
+	GasLimit             uint64   `protobuf:"varint,8,opt,name=gasLimit,proto3" json:"gasLimit,omitempty"`
+	GasPrice             []byte   `protobuf:"bytes,9,opt,name=gasPrice,proto3" json:"gasPrice,omitempty"`
+	Data                 []byte   `protobuf:"bytes,10,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExecutionPb) Reset()         { *m = ExecutionPb{} }
+func (m *ExecutionPb) String() string { return proto.CompactTextString(m) }
+func (*ExecutionPb) ProtoMessage()    {}
+func (*ExecutionPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{2}
+}
+func (m *ExecutionPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExecutionPb.Unmarshal(m, b)
+}
+func (m *ExecutionPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExecutionPb.Marshal(b, m, deterministic)
+}
+func (dst *ExecutionPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExecutionPb.Merge(dst, src)
+}
+func (m *ExecutionPb) XXX_Size() int {
+	return xxx_messageInfo_ExecutionPb.Size(m)
+}
+func (m *ExecutionPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExecutionPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExecutionPb proto.InternalMessageInfo
+
+func (m *ExecutionPb) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ExecutionPb) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *ExecutionPb) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *ExecutionPb) GetAmount() []byte {
+	if m != nil {
+		return m.Amount
+	}
+	return nil
+}
+
+func (m *ExecutionPb) GetExecutor() string {
+	if m != nil {
+		return m.Executor
+	}
+	return ""
+}
+
+func (m *ExecutionPb) GetContract() string {
+	if m != nil {
+		return m.Contract
+	}
+	return ""
+}
+
+func (m *ExecutionPb) GetExecutorPubKey() []byte {
+	if m != nil {
+		return m.ExecutorPubKey
+	}
+	return nil
+}
+
+func (m *ExecutionPb) GetGasLimit() uint64 {
+	if m != nil {
+		return m.GasLimit
+	}
+	return 0
+}
+
+func (m *ExecutionPb) GetGasPrice() []byte {
+	if m != nil {
+		return m.GasPrice
+	}
+	return nil
+}
+
+func (m *ExecutionPb) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type StakePb struct {
+	// StakePb should share these three fields with other Actions
+	// TODO: extract these three fields to ActionPb
+	Version   uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Nonce     uint64 `protobuf:"varint,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Signature []byte `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	// unstake starts unbonding the amount instead of bonding it
+	Amount               []byte   `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"`
+	Staker               string   `protobuf:"bytes,5,opt,name=staker,proto3" json:"staker,omitempty"`
+	StakerPubKey         []byte   `protobuf:"bytes,6,opt,name=stakerPubKey,proto3" json:"stakerPubKey,omitempty"`
+	Unstake              bool     `protobuf:"varint,7,opt,name=unstake,proto3" json:"unstake,omitempty"`
+	GasLimit             uint64   `protobuf:"varint,8,opt,name=gasLimit,proto3" json:"gasLimit,omitempty"`
+	GasPrice             []byte   `protobuf:"bytes,9,opt,name=gasPrice,proto3" json:"gasPrice,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StakePb) Reset()         { *m = StakePb{} }
+func (m *StakePb) String() string { return proto.CompactTextString(m) }
+func (*StakePb) ProtoMessage()    {}
+func (*StakePb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{3}
+}
+func (m *StakePb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StakePb.Unmarshal(m, b)
+}
+func (m *StakePb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StakePb.Marshal(b, m, deterministic)
+}
+func (dst *StakePb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StakePb.Merge(dst, src)
+}
+func (m *StakePb) XXX_Size() int {
+	return xxx_messageInfo_StakePb.Size(m)
+}
+func (m *StakePb) XXX_DiscardUnknown() {
+	xxx_messageInfo_StakePb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StakePb proto.InternalMessageInfo
+
+func (m *StakePb) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *StakePb) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *StakePb) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *StakePb) GetAmount() []byte {
+	if m != nil {
+		return m.Amount
+	}
+	return nil
+}
+
+func (m *StakePb) GetStaker() string {
+	if m != nil {
+		return m.Staker
+	}
+	return ""
+}
+
+func (m *StakePb) GetStakerPubKey() []byte {
+	if m != nil {
+		return m.StakerPubKey
+	}
+	return nil
+}
+
+func (m *StakePb) GetUnstake() bool {
+	if m != nil {
+		return m.Unstake
+	}
+	return false
+}
+
+func (m *StakePb) GetGasLimit() uint64 {
+	if m != nil {
+		return m.GasLimit
+	}
+	return 0
+}
+
+func (m *StakePb) GetGasPrice() []byte {
+	if m != nil {
+		return m.GasPrice
+	}
+	return nil
+}
+
 type ActionPb struct {
 	// Types that are valid to be assigned to Action:
 	//	*ActionPb_Transfer
 	//	*ActionPb_Vote
+	//	*ActionPb_Execution
+	//	*ActionPb_Stake
 	Action               isActionPb_Action `protobuf_oneof:"action"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
@@ -254,7 +549,7 @@ func (m *ActionPb) Reset()         { *m = ActionPb{} }
 func (m *ActionPb) String() string { return proto.CompactTextString(m) }
 func (*ActionPb) ProtoMessage()    {}
 func (*ActionPb) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{2}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{4}
 }
 func (m *ActionPb) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ActionPb.Unmarshal(m, b)
@@ -286,10 +581,22 @@ type ActionPb_Vote struct {
 	Vote *VotePb `protobuf:"bytes,2,opt,name=vote,proto3,oneof"`
 }
 
+type ActionPb_Execution struct {
+	Execution *ExecutionPb `protobuf:"bytes,3,opt,name=execution,proto3,oneof"`
+}
+
+type ActionPb_Stake struct {
+	Stake *StakePb `protobuf:"bytes,4,opt,name=stake,proto3,oneof"`
+}
+
 func (*ActionPb_Transfer) isActionPb_Action() {}
 
 func (*ActionPb_Vote) isActionPb_Action() {}
 
+func (*ActionPb_Execution) isActionPb_Action() {}
+
+func (*ActionPb_Stake) isActionPb_Action() {}
+
 func (m *ActionPb) GetAction() isActionPb_Action {
 	if m != nil {
 		return m.Action
@@ -311,11 +618,27 @@ func (m *ActionPb) GetVote() *VotePb {
 	return nil
 }
 
+func (m *ActionPb) GetExecution() *ExecutionPb {
+	if x, ok := m.GetAction().(*ActionPb_Execution); ok {
+		return x.Execution
+	}
+	return nil
+}
+
+func (m *ActionPb) GetStake() *StakePb {
+	if x, ok := m.GetAction().(*ActionPb_Stake); ok {
+		return x.Stake
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*ActionPb) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _ActionPb_OneofMarshaler, _ActionPb_OneofUnmarshaler, _ActionPb_OneofSizer, []interface{}{
 		(*ActionPb_Transfer)(nil),
 		(*ActionPb_Vote)(nil),
+		(*ActionPb_Execution)(nil),
+		(*ActionPb_Stake)(nil),
 	}
 }
 
@@ -333,6 +656,16 @@ func _ActionPb_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Vote); err != nil {
 			return err
 		}
+	case *ActionPb_Execution:
+		b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Execution); err != nil {
+			return err
+		}
+	case *ActionPb_Stake:
+		b.EncodeVarint(4<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Stake); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("ActionPb.Action has unexpected type %T", x)
@@ -359,6 +692,22 @@ func _ActionPb_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffe
 		err := b.DecodeMessage(msg)
 		m.Action = &ActionPb_Vote{msg}
 		return true, err
+	case 3: // action.execution
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ExecutionPb)
+		err := b.DecodeMessage(msg)
+		m.Action = &ActionPb_Execution{msg}
+		return true, err
+	case 4: // action.stake
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(StakePb)
+		err := b.DecodeMessage(msg)
+		m.Action = &ActionPb_Stake{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -378,6 +727,16 @@ func _ActionPb_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *ActionPb_Execution:
+		s := proto.Size(x.Execution)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *ActionPb_Stake:
+		s := proto.Size(x.Stake)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -407,7 +766,7 @@ func (m *BlockHeaderPb) Reset()         { *m = BlockHeaderPb{} }
 func (m *BlockHeaderPb) String() string { return proto.CompactTextString(m) }
 func (*BlockHeaderPb) ProtoMessage()    {}
 func (*BlockHeaderPb) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{3}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{5}
 }
 func (m *BlockHeaderPb) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BlockHeaderPb.Unmarshal(m, b)
@@ -518,7 +877,7 @@ func (m *BlockPb) Reset()         { *m = BlockPb{} }
 func (m *BlockPb) String() string { return proto.CompactTextString(m) }
 func (*BlockPb) ProtoMessage()    {}
 func (*BlockPb) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{4}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{6}
 }
 func (m *BlockPb) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BlockPb.Unmarshal(m, b)
@@ -552,65 +911,388 @@ func (m *BlockPb) GetActions() []*ActionPb {
 	return nil
 }
 
-// index of block raw data file
-type BlockIndex struct {
-	Start                uint64   `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
-	End                  uint64   `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
-	Offset               []uint32 `protobuf:"varint,3,rep,packed,name=offset,proto3" json:"offset,omitempty"`
+// change of an amount of an address
+type DeltaPb struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Amount               []byte   `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Negative             bool     `protobuf:"varint,3,opt,name=negative,proto3" json:"negative,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *BlockIndex) Reset()         { *m = BlockIndex{} }
-func (m *BlockIndex) String() string { return proto.CompactTextString(m) }
-func (*BlockIndex) ProtoMessage()    {}
-func (*BlockIndex) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{5}
+func (m *DeltaPb) Reset()         { *m = DeltaPb{} }
+func (m *DeltaPb) String() string { return proto.CompactTextString(m) }
+func (*DeltaPb) ProtoMessage()    {}
+func (*DeltaPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{7}
 }
-func (m *BlockIndex) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BlockIndex.Unmarshal(m, b)
+func (m *DeltaPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeltaPb.Unmarshal(m, b)
 }
-func (m *BlockIndex) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BlockIndex.Marshal(b, m, deterministic)
+func (m *DeltaPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeltaPb.Marshal(b, m, deterministic)
 }
-func (dst *BlockIndex) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BlockIndex.Merge(dst, src)
+func (dst *DeltaPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeltaPb.Merge(dst, src)
 }
-func (m *BlockIndex) XXX_Size() int {
-	return xxx_messageInfo_BlockIndex.Size(m)
+func (m *DeltaPb) XXX_Size() int {
+	return xxx_messageInfo_DeltaPb.Size(m)
 }
-func (m *BlockIndex) XXX_DiscardUnknown() {
-	xxx_messageInfo_BlockIndex.DiscardUnknown(m)
+func (m *DeltaPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeltaPb.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_BlockIndex proto.InternalMessageInfo
+var xxx_messageInfo_DeltaPb proto.InternalMessageInfo
 
-func (m *BlockIndex) GetStart() uint64 {
+func (m *DeltaPb) GetAddress() string {
 	if m != nil {
-		return m.Start
+		return m.Address
 	}
-	return 0
+	return ""
 }
 
-func (m *BlockIndex) GetEnd() uint64 {
+func (m *DeltaPb) GetAmount() []byte {
 	if m != nil {
-		return m.End
+		return m.Amount
 	}
-	return 0
+	return nil
 }
 
-func (m *BlockIndex) GetOffset() []uint32 {
+func (m *DeltaPb) GetNegative() bool {
 	if m != nil {
-		return m.Offset
+		return m.Negative
 	}
-	return nil
+	return false
 }
 
-type BlockSync struct {
-	Start                uint64   `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
-	End                  uint64   `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+// outcome of applying an action to the states
+type ReceiptPb struct {
+	ActHash              []byte     `protobuf:"bytes,1,opt,name=actHash,proto3" json:"actHash,omitempty"`
+	Status               uint64     `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	BalanceDeltas        []*DeltaPb `protobuf:"bytes,3,rep,name=balanceDeltas,proto3" json:"balanceDeltas,omitempty"`
+	WeightDeltas         []*DeltaPb `protobuf:"bytes,4,rep,name=weightDeltas,proto3" json:"weightDeltas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ReceiptPb) Reset()         { *m = ReceiptPb{} }
+func (m *ReceiptPb) String() string { return proto.CompactTextString(m) }
+func (*ReceiptPb) ProtoMessage()    {}
+func (*ReceiptPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{8}
+}
+func (m *ReceiptPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReceiptPb.Unmarshal(m, b)
+}
+func (m *ReceiptPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReceiptPb.Marshal(b, m, deterministic)
+}
+func (dst *ReceiptPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReceiptPb.Merge(dst, src)
+}
+func (m *ReceiptPb) XXX_Size() int {
+	return xxx_messageInfo_ReceiptPb.Size(m)
+}
+func (m *ReceiptPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReceiptPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReceiptPb proto.InternalMessageInfo
+
+func (m *ReceiptPb) GetActHash() []byte {
+	if m != nil {
+		return m.ActHash
+	}
+	return nil
+}
+
+func (m *ReceiptPb) GetStatus() uint64 {
+	if m != nil {
+		return m.Status
+	}
+	return 0
+}
+
+func (m *ReceiptPb) GetBalanceDeltas() []*DeltaPb {
+	if m != nil {
+		return m.BalanceDeltas
+	}
+	return nil
+}
+
+func (m *ReceiptPb) GetWeightDeltas() []*DeltaPb {
+	if m != nil {
+		return m.WeightDeltas
+	}
+	return nil
+}
+
+// voting weight a voter gives to the votee
+type VoterPb struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Weight               []byte   `protobuf:"bytes,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VoterPb) Reset()         { *m = VoterPb{} }
+func (m *VoterPb) String() string { return proto.CompactTextString(m) }
+func (*VoterPb) ProtoMessage()    {}
+func (*VoterPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{9}
+}
+func (m *VoterPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VoterPb.Unmarshal(m, b)
+}
+func (m *VoterPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VoterPb.Marshal(b, m, deterministic)
+}
+func (dst *VoterPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VoterPb.Merge(dst, src)
+}
+func (m *VoterPb) XXX_Size() int {
+	return xxx_messageInfo_VoterPb.Size(m)
+}
+func (m *VoterPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_VoterPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VoterPb proto.InternalMessageInfo
+
+func (m *VoterPb) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *VoterPb) GetWeight() []byte {
+	if m != nil {
+		return m.Weight
+	}
+	return nil
+}
+
+// unstaked coins released at the release height
+type UnbondingPb struct {
+	Amount               []byte   `protobuf:"bytes,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	ReleaseHeight        uint64   `protobuf:"varint,2,opt,name=releaseHeight,proto3" json:"releaseHeight,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UnbondingPb) Reset()         { *m = UnbondingPb{} }
+func (m *UnbondingPb) String() string { return proto.CompactTextString(m) }
+func (*UnbondingPb) ProtoMessage()    {}
+func (*UnbondingPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{10}
+}
+func (m *UnbondingPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnbondingPb.Unmarshal(m, b)
+}
+func (m *UnbondingPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnbondingPb.Marshal(b, m, deterministic)
+}
+func (dst *UnbondingPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnbondingPb.Merge(dst, src)
+}
+func (m *UnbondingPb) XXX_Size() int {
+	return xxx_messageInfo_UnbondingPb.Size(m)
+}
+func (m *UnbondingPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnbondingPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnbondingPb proto.InternalMessageInfo
+
+func (m *UnbondingPb) GetAmount() []byte {
+	if m != nil {
+		return m.Amount
+	}
+	return nil
+}
+
+func (m *UnbondingPb) GetReleaseHeight() uint64 {
+	if m != nil {
+		return m.ReleaseHeight
+	}
+	return 0
+}
+
+// state of an account
+type AccountPb struct {
+	Nonce                uint64         `protobuf:"varint,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Balance              []byte         `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	Root                 []byte         `protobuf:"bytes,3,opt,name=root,proto3" json:"root,omitempty"`
+	CodeHash             []byte         `protobuf:"bytes,4,opt,name=codeHash,proto3" json:"codeHash,omitempty"`
+	IsCandidate          bool           `protobuf:"varint,5,opt,name=isCandidate,proto3" json:"isCandidate,omitempty"`
+	VotingWeight         []byte         `protobuf:"bytes,6,opt,name=votingWeight,proto3" json:"votingWeight,omitempty"`
+	Votee                string         `protobuf:"bytes,7,opt,name=votee,proto3" json:"votee,omitempty"`
+	Voters               []*VoterPb     `protobuf:"bytes,8,rep,name=voters,proto3" json:"voters,omitempty"`
+	Staked               []byte         `protobuf:"bytes,9,opt,name=staked,proto3" json:"staked,omitempty"`
+	Unbonding            []*UnbondingPb `protobuf:"bytes,10,rep,name=unbonding,proto3" json:"unbonding,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *AccountPb) Reset()         { *m = AccountPb{} }
+func (m *AccountPb) String() string { return proto.CompactTextString(m) }
+func (*AccountPb) ProtoMessage()    {}
+func (*AccountPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{11}
+}
+func (m *AccountPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountPb.Unmarshal(m, b)
+}
+func (m *AccountPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountPb.Marshal(b, m, deterministic)
+}
+func (dst *AccountPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountPb.Merge(dst, src)
+}
+func (m *AccountPb) XXX_Size() int {
+	return xxx_messageInfo_AccountPb.Size(m)
+}
+func (m *AccountPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountPb proto.InternalMessageInfo
+
+func (m *AccountPb) GetNonce() uint64 {
+	if m != nil {
+		return m.Nonce
+	}
+	return 0
+}
+
+func (m *AccountPb) GetBalance() []byte {
+	if m != nil {
+		return m.Balance
+	}
+	return nil
+}
+
+func (m *AccountPb) GetRoot() []byte {
+	if m != nil {
+		return m.Root
+	}
+	return nil
+}
+
+func (m *AccountPb) GetCodeHash() []byte {
+	if m != nil {
+		return m.CodeHash
+	}
+	return nil
+}
+
+func (m *AccountPb) GetIsCandidate() bool {
+	if m != nil {
+		return m.IsCandidate
+	}
+	return false
+}
+
+func (m *AccountPb) GetVotingWeight() []byte {
+	if m != nil {
+		return m.VotingWeight
+	}
+	return nil
+}
+
+func (m *AccountPb) GetVotee() string {
+	if m != nil {
+		return m.Votee
+	}
+	return ""
+}
+
+func (m *AccountPb) GetVoters() []*VoterPb {
+	if m != nil {
+		return m.Voters
+	}
+	return nil
+}
+
+func (m *AccountPb) GetStaked() []byte {
+	if m != nil {
+		return m.Staked
+	}
+	return nil
+}
+
+func (m *AccountPb) GetUnbonding() []*UnbondingPb {
+	if m != nil {
+		return m.Unbonding
+	}
+	return nil
+}
+
+// index of block raw data file
+type BlockIndex struct {
+	Start                uint64   `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End                  uint64   `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	Offset               []uint32 `protobuf:"varint,3,rep,packed,name=offset,proto3" json:"offset,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BlockIndex) Reset()         { *m = BlockIndex{} }
+func (m *BlockIndex) String() string { return proto.CompactTextString(m) }
+func (*BlockIndex) ProtoMessage()    {}
+func (*BlockIndex) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{12}
+}
+func (m *BlockIndex) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockIndex.Unmarshal(m, b)
+}
+func (m *BlockIndex) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockIndex.Marshal(b, m, deterministic)
+}
+func (dst *BlockIndex) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockIndex.Merge(dst, src)
+}
+func (m *BlockIndex) XXX_Size() int {
+	return xxx_messageInfo_BlockIndex.Size(m)
+}
+func (m *BlockIndex) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockIndex.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockIndex proto.InternalMessageInfo
+
+func (m *BlockIndex) GetStart() uint64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *BlockIndex) GetEnd() uint64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *BlockIndex) GetOffset() []uint32 {
+	if m != nil {
+		return m.Offset
+	}
+	return nil
+}
+
+type BlockSync struct {
+	Start                uint64   `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	End                  uint64   `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
@@ -619,7 +1301,7 @@ func (m *BlockSync) Reset()         { *m = BlockSync{} }
 func (m *BlockSync) String() string { return proto.CompactTextString(m) }
 func (*BlockSync) ProtoMessage()    {}
 func (*BlockSync) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{6}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{13}
 }
 func (m *BlockSync) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BlockSync.Unmarshal(m, b)
@@ -666,7 +1348,7 @@ func (m *BlockContainer) Reset()         { *m = BlockContainer{} }
 func (m *BlockContainer) String() string { return proto.CompactTextString(m) }
 func (*BlockContainer) ProtoMessage()    {}
 func (*BlockContainer) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{7}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{14}
 }
 func (m *BlockContainer) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_BlockContainer.Unmarshal(m, b)
@@ -693,6 +1375,561 @@ func (m *BlockContainer) GetBlock() *BlockPb {
 	return nil
 }
 
+// block header sync
+// used by light clients to request the headers of a range of blocks
+type BlockHeaderSync struct {
+	Start                uint64   `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End                  uint64   `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BlockHeaderSync) Reset()         { *m = BlockHeaderSync{} }
+func (m *BlockHeaderSync) String() string { return proto.CompactTextString(m) }
+func (*BlockHeaderSync) ProtoMessage()    {}
+func (*BlockHeaderSync) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{15}
+}
+func (m *BlockHeaderSync) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockHeaderSync.Unmarshal(m, b)
+}
+func (m *BlockHeaderSync) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockHeaderSync.Marshal(b, m, deterministic)
+}
+func (dst *BlockHeaderSync) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockHeaderSync.Merge(dst, src)
+}
+func (m *BlockHeaderSync) XXX_Size() int {
+	return xxx_messageInfo_BlockHeaderSync.Size(m)
+}
+func (m *BlockHeaderSync) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockHeaderSync.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockHeaderSync proto.InternalMessageInfo
+
+func (m *BlockHeaderSync) GetStart() uint64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *BlockHeaderSync) GetEnd() uint64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+// block header container
+// used to send the headers of a range of blocks to light clients
+type BlockHeaderContainer struct {
+	Headers              []*BlockHeaderPb `protobuf:"bytes,1,rep,name=headers,proto3" json:"headers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *BlockHeaderContainer) Reset()         { *m = BlockHeaderContainer{} }
+func (m *BlockHeaderContainer) String() string { return proto.CompactTextString(m) }
+func (*BlockHeaderContainer) ProtoMessage()    {}
+func (*BlockHeaderContainer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{16}
+}
+func (m *BlockHeaderContainer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlockHeaderContainer.Unmarshal(m, b)
+}
+func (m *BlockHeaderContainer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlockHeaderContainer.Marshal(b, m, deterministic)
+}
+func (dst *BlockHeaderContainer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlockHeaderContainer.Merge(dst, src)
+}
+func (m *BlockHeaderContainer) XXX_Size() int {
+	return xxx_messageInfo_BlockHeaderContainer.Size(m)
+}
+func (m *BlockHeaderContainer) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlockHeaderContainer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlockHeaderContainer proto.InternalMessageInfo
+
+func (m *BlockHeaderContainer) GetHeaders() []*BlockHeaderPb {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
+// request of the proof of an action
+type ActionProofReq struct {
+	ActHash              []byte   `protobuf:"bytes,1,opt,name=actHash,proto3" json:"actHash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ActionProofReq) Reset()         { *m = ActionProofReq{} }
+func (m *ActionProofReq) String() string { return proto.CompactTextString(m) }
+func (*ActionProofReq) ProtoMessage()    {}
+func (*ActionProofReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{17}
+}
+func (m *ActionProofReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ActionProofReq.Unmarshal(m, b)
+}
+func (m *ActionProofReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ActionProofReq.Marshal(b, m, deterministic)
+}
+func (dst *ActionProofReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ActionProofReq.Merge(dst, src)
+}
+func (m *ActionProofReq) XXX_Size() int {
+	return xxx_messageInfo_ActionProofReq.Size(m)
+}
+func (m *ActionProofReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_ActionProofReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ActionProofReq proto.InternalMessageInfo
+
+func (m *ActionProofReq) GetActHash() []byte {
+	if m != nil {
+		return m.ActHash
+	}
+	return nil
+}
+
+// proof of an action, which is the merkle path from the action of the index up to the tx root of the block of the
+// given height
+type ActionProofPb struct {
+	ActHash              []byte   `protobuf:"bytes,1,opt,name=actHash,proto3" json:"actHash,omitempty"`
+	Found                bool     `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Height               uint64   `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	Index                uint32   `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+	Siblings             [][]byte `protobuf:"bytes,5,rep,name=siblings,proto3" json:"siblings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ActionProofPb) Reset()         { *m = ActionProofPb{} }
+func (m *ActionProofPb) String() string { return proto.CompactTextString(m) }
+func (*ActionProofPb) ProtoMessage()    {}
+func (*ActionProofPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{18}
+}
+func (m *ActionProofPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ActionProofPb.Unmarshal(m, b)
+}
+func (m *ActionProofPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ActionProofPb.Marshal(b, m, deterministic)
+}
+func (dst *ActionProofPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ActionProofPb.Merge(dst, src)
+}
+func (m *ActionProofPb) XXX_Size() int {
+	return xxx_messageInfo_ActionProofPb.Size(m)
+}
+func (m *ActionProofPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_ActionProofPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ActionProofPb proto.InternalMessageInfo
+
+func (m *ActionProofPb) GetActHash() []byte {
+	if m != nil {
+		return m.ActHash
+	}
+	return nil
+}
+
+func (m *ActionProofPb) GetFound() bool {
+	if m != nil {
+		return m.Found
+	}
+	return false
+}
+
+func (m *ActionProofPb) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *ActionProofPb) GetIndex() uint32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *ActionProofPb) GetSiblings() [][]byte {
+	if m != nil {
+		return m.Siblings
+	}
+	return nil
+}
+
+// request of the proof of the state of an address as of the block of the given height
+type StateProofReq struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Height               uint64   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StateProofReq) Reset()         { *m = StateProofReq{} }
+func (m *StateProofReq) String() string { return proto.CompactTextString(m) }
+func (*StateProofReq) ProtoMessage()    {}
+func (*StateProofReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{19}
+}
+func (m *StateProofReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateProofReq.Unmarshal(m, b)
+}
+func (m *StateProofReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateProofReq.Marshal(b, m, deterministic)
+}
+func (dst *StateProofReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateProofReq.Merge(dst, src)
+}
+func (m *StateProofReq) XXX_Size() int {
+	return xxx_messageInfo_StateProofReq.Size(m)
+}
+func (m *StateProofReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateProofReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateProofReq proto.InternalMessageInfo
+
+func (m *StateProofReq) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *StateProofReq) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+// proof of the state of an address, which is the trie nodes on the path to the state
+type StateProofPb struct {
+	Address              string   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Height               uint64   `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Nodes                [][]byte `protobuf:"bytes,3,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StateProofPb) Reset()         { *m = StateProofPb{} }
+func (m *StateProofPb) String() string { return proto.CompactTextString(m) }
+func (*StateProofPb) ProtoMessage()    {}
+func (*StateProofPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{20}
+}
+func (m *StateProofPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateProofPb.Unmarshal(m, b)
+}
+func (m *StateProofPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateProofPb.Marshal(b, m, deterministic)
+}
+func (dst *StateProofPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateProofPb.Merge(dst, src)
+}
+func (m *StateProofPb) XXX_Size() int {
+	return xxx_messageInfo_StateProofPb.Size(m)
+}
+func (m *StateProofPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateProofPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateProofPb proto.InternalMessageInfo
+
+func (m *StateProofPb) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *StateProofPb) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *StateProofPb) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+// request of the delegates of the epoch of the block of the given height
+type DelegatesReq struct {
+	Height               uint64   `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DelegatesReq) Reset()         { *m = DelegatesReq{} }
+func (m *DelegatesReq) String() string { return proto.CompactTextString(m) }
+func (*DelegatesReq) ProtoMessage()    {}
+func (*DelegatesReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{21}
+}
+func (m *DelegatesReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DelegatesReq.Unmarshal(m, b)
+}
+func (m *DelegatesReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DelegatesReq.Marshal(b, m, deterministic)
+}
+func (dst *DelegatesReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DelegatesReq.Merge(dst, src)
+}
+func (m *DelegatesReq) XXX_Size() int {
+	return xxx_messageInfo_DelegatesReq.Size(m)
+}
+func (m *DelegatesReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_DelegatesReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DelegatesReq proto.InternalMessageInfo
+
+func (m *DelegatesReq) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+// delegates of an epoch, with the proofs of their states as of the block of the height of the candidates of the epoch
+type DelegatesPb struct {
+	Height               uint64          `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	Proofs               []*StateProofPb `protobuf:"bytes,2,rep,name=proofs,proto3" json:"proofs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *DelegatesPb) Reset()         { *m = DelegatesPb{} }
+func (m *DelegatesPb) String() string { return proto.CompactTextString(m) }
+func (*DelegatesPb) ProtoMessage()    {}
+func (*DelegatesPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{22}
+}
+func (m *DelegatesPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DelegatesPb.Unmarshal(m, b)
+}
+func (m *DelegatesPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DelegatesPb.Marshal(b, m, deterministic)
+}
+func (dst *DelegatesPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DelegatesPb.Merge(dst, src)
+}
+func (m *DelegatesPb) XXX_Size() int {
+	return xxx_messageInfo_DelegatesPb.Size(m)
+}
+func (m *DelegatesPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_DelegatesPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DelegatesPb proto.InternalMessageInfo
+
+func (m *DelegatesPb) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *DelegatesPb) GetProofs() []*StateProofPb {
+	if m != nil {
+		return m.Proofs
+	}
+	return nil
+}
+
+type SnapshotManifestReq struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotManifestReq) Reset()         { *m = SnapshotManifestReq{} }
+func (m *SnapshotManifestReq) String() string { return proto.CompactTextString(m) }
+func (*SnapshotManifestReq) ProtoMessage()    {}
+func (*SnapshotManifestReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{23}
+}
+func (m *SnapshotManifestReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotManifestReq.Unmarshal(m, b)
+}
+func (m *SnapshotManifestReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotManifestReq.Marshal(b, m, deterministic)
+}
+func (dst *SnapshotManifestReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotManifestReq.Merge(dst, src)
+}
+func (m *SnapshotManifestReq) XXX_Size() int {
+	return xxx_messageInfo_SnapshotManifestReq.Size(m)
+}
+func (m *SnapshotManifestReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotManifestReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotManifestReq proto.InternalMessageInfo
+
+type SnapshotManifestPb struct {
+	Block                *BlockPb `protobuf:"bytes,1,opt,name=block,proto3" json:"block,omitempty"`
+	Meta                 []byte   `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	Chunks               [][]byte `protobuf:"bytes,3,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotManifestPb) Reset()         { *m = SnapshotManifestPb{} }
+func (m *SnapshotManifestPb) String() string { return proto.CompactTextString(m) }
+func (*SnapshotManifestPb) ProtoMessage()    {}
+func (*SnapshotManifestPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{24}
+}
+func (m *SnapshotManifestPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotManifestPb.Unmarshal(m, b)
+}
+func (m *SnapshotManifestPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotManifestPb.Marshal(b, m, deterministic)
+}
+func (dst *SnapshotManifestPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotManifestPb.Merge(dst, src)
+}
+func (m *SnapshotManifestPb) XXX_Size() int {
+	return xxx_messageInfo_SnapshotManifestPb.Size(m)
+}
+func (m *SnapshotManifestPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotManifestPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotManifestPb proto.InternalMessageInfo
+
+func (m *SnapshotManifestPb) GetBlock() *BlockPb {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *SnapshotManifestPb) GetMeta() []byte {
+	if m != nil {
+		return m.Meta
+	}
+	return nil
+}
+
+func (m *SnapshotManifestPb) GetChunks() [][]byte {
+	if m != nil {
+		return m.Chunks
+	}
+	return nil
+}
+
+type SnapshotChunkReq struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotChunkReq) Reset()         { *m = SnapshotChunkReq{} }
+func (m *SnapshotChunkReq) String() string { return proto.CompactTextString(m) }
+func (*SnapshotChunkReq) ProtoMessage()    {}
+func (*SnapshotChunkReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{25}
+}
+func (m *SnapshotChunkReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotChunkReq.Unmarshal(m, b)
+}
+func (m *SnapshotChunkReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotChunkReq.Marshal(b, m, deterministic)
+}
+func (dst *SnapshotChunkReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotChunkReq.Merge(dst, src)
+}
+func (m *SnapshotChunkReq) XXX_Size() int {
+	return xxx_messageInfo_SnapshotChunkReq.Size(m)
+}
+func (m *SnapshotChunkReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotChunkReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotChunkReq proto.InternalMessageInfo
+
+func (m *SnapshotChunkReq) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type SnapshotChunkPb struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Data                 []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SnapshotChunkPb) Reset()         { *m = SnapshotChunkPb{} }
+func (m *SnapshotChunkPb) String() string { return proto.CompactTextString(m) }
+func (*SnapshotChunkPb) ProtoMessage()    {}
+func (*SnapshotChunkPb) Descriptor() ([]byte, []int) {
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{26}
+}
+func (m *SnapshotChunkPb) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SnapshotChunkPb.Unmarshal(m, b)
+}
+func (m *SnapshotChunkPb) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SnapshotChunkPb.Marshal(b, m, deterministic)
+}
+func (dst *SnapshotChunkPb) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotChunkPb.Merge(dst, src)
+}
+func (m *SnapshotChunkPb) XXX_Size() int {
+	return xxx_messageInfo_SnapshotChunkPb.Size(m)
+}
+func (m *SnapshotChunkPb) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotChunkPb.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SnapshotChunkPb proto.InternalMessageInfo
+
+func (m *SnapshotChunkPb) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *SnapshotChunkPb) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
 type ViewChangeMsg struct {
 	Vctype               ViewChangeMsg_ViewChangeType `protobuf:"varint,1,opt,name=vctype,proto3,enum=iproto.ViewChangeMsg_ViewChangeType" json:"vctype,omitempty"`
 	Block                *BlockPb                     `protobuf:"bytes,2,opt,name=block,proto3" json:"block,omitempty"`
@@ -708,7 +1945,7 @@ func (m *ViewChangeMsg) Reset()         { *m = ViewChangeMsg{} }
 func (m *ViewChangeMsg) String() string { return proto.CompactTextString(m) }
 func (*ViewChangeMsg) ProtoMessage()    {}
 func (*ViewChangeMsg) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{8}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{27}
 }
 func (m *ViewChangeMsg) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ViewChangeMsg.Unmarshal(m, b)
@@ -777,7 +2014,7 @@ func (m *TestPayload) Reset()         { *m = TestPayload{} }
 func (m *TestPayload) String() string { return proto.CompactTextString(m) }
 func (*TestPayload) ProtoMessage()    {}
 func (*TestPayload) Descriptor() ([]byte, []int) {
-	return fileDescriptor_blockchain_dfe12ce6c6f0af19, []int{9}
+	return fileDescriptor_blockchain_406e5eaf6967db8d, []int{28}
 }
 func (m *TestPayload) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TestPayload.Unmarshal(m, b)
@@ -807,67 +2044,133 @@ func (m *TestPayload) GetMsgBody() []byte {
 func init() {
 	proto.RegisterType((*TransferPb)(nil), "iproto.TransferPb")
 	proto.RegisterType((*VotePb)(nil), "iproto.VotePb")
+	proto.RegisterType((*ExecutionPb)(nil), "iproto.ExecutionPb")
+	proto.RegisterType((*StakePb)(nil), "iproto.StakePb")
 	proto.RegisterType((*ActionPb)(nil), "iproto.ActionPb")
 	proto.RegisterType((*BlockHeaderPb)(nil), "iproto.BlockHeaderPb")
 	proto.RegisterType((*BlockPb)(nil), "iproto.BlockPb")
+	proto.RegisterType((*DeltaPb)(nil), "iproto.DeltaPb")
+	proto.RegisterType((*ReceiptPb)(nil), "iproto.ReceiptPb")
+	proto.RegisterType((*VoterPb)(nil), "iproto.VoterPb")
+	proto.RegisterType((*UnbondingPb)(nil), "iproto.UnbondingPb")
+	proto.RegisterType((*AccountPb)(nil), "iproto.AccountPb")
 	proto.RegisterType((*BlockIndex)(nil), "iproto.BlockIndex")
 	proto.RegisterType((*BlockSync)(nil), "iproto.BlockSync")
 	proto.RegisterType((*BlockContainer)(nil), "iproto.BlockContainer")
+	proto.RegisterType((*BlockHeaderSync)(nil), "iproto.BlockHeaderSync")
+	proto.RegisterType((*BlockHeaderContainer)(nil), "iproto.BlockHeaderContainer")
+	proto.RegisterType((*ActionProofReq)(nil), "iproto.ActionProofReq")
+	proto.RegisterType((*ActionProofPb)(nil), "iproto.ActionProofPb")
+	proto.RegisterType((*StateProofReq)(nil), "iproto.StateProofReq")
+	proto.RegisterType((*StateProofPb)(nil), "iproto.StateProofPb")
+	proto.RegisterType((*DelegatesReq)(nil), "iproto.DelegatesReq")
+	proto.RegisterType((*DelegatesPb)(nil), "iproto.DelegatesPb")
+	proto.RegisterType((*SnapshotManifestReq)(nil), "iproto.SnapshotManifestReq")
+	proto.RegisterType((*SnapshotManifestPb)(nil), "iproto.SnapshotManifestPb")
+	proto.RegisterType((*SnapshotChunkReq)(nil), "iproto.SnapshotChunkReq")
+	proto.RegisterType((*SnapshotChunkPb)(nil), "iproto.SnapshotChunkPb")
 	proto.RegisterType((*ViewChangeMsg)(nil), "iproto.ViewChangeMsg")
 	proto.RegisterType((*TestPayload)(nil), "iproto.TestPayload")
 	proto.RegisterEnum("iproto.ViewChangeMsg_ViewChangeType", ViewChangeMsg_ViewChangeType_name, ViewChangeMsg_ViewChangeType_value)
 }
 
-func init() { proto.RegisterFile("blockchain.proto", fileDescriptor_blockchain_dfe12ce6c6f0af19) }
-
-var fileDescriptor_blockchain_dfe12ce6c6f0af19 = []byte{
-	// 765 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xdd, 0x6a, 0xeb, 0x46,
-	0x10, 0x8e, 0x64, 0xc7, 0x92, 0xc7, 0x56, 0x6a, 0x96, 0xb6, 0xa8, 0x25, 0x14, 0x23, 0x52, 0x10,
-	0x85, 0x86, 0x92, 0x73, 0xd1, 0x9b, 0xde, 0xe4, 0xc7, 0x34, 0xa6, 0xa7, 0x89, 0xd8, 0x18, 0x97,
-	0x5e, 0x85, 0x95, 0x34, 0xb1, 0xc5, 0x89, 0x77, 0x85, 0x76, 0x9d, 0x46, 0x7d, 0x80, 0x3e, 0x48,
-	0x5f, 0xa8, 0x2f, 0xd0, 0x87, 0x29, 0xbb, 0x5a, 0xd9, 0x16, 0x1c, 0x9a, 0x9b, 0x73, 0x65, 0x7f,
-	0xdf, 0x8e, 0x66, 0x67, 0xbe, 0xf9, 0x66, 0x61, 0x92, 0x3e, 0x8b, 0xec, 0x43, 0xb6, 0x66, 0x05,
-	0x3f, 0x2f, 0x2b, 0xa1, 0x04, 0x19, 0x14, 0xe6, 0x37, 0xfa, 0xcb, 0x05, 0x58, 0x54, 0x8c, 0xcb,
-	0x27, 0xac, 0x92, 0x94, 0x84, 0xe0, 0xbd, 0x60, 0x25, 0x0b, 0xc1, 0x43, 0x67, 0xea, 0xc4, 0x01,
-	0x6d, 0x21, 0xf9, 0x1c, 0x8e, 0xb9, 0xe0, 0x19, 0x86, 0xee, 0xd4, 0x89, 0xfb, 0xb4, 0x01, 0xe4,
-	0x14, 0x86, 0xb2, 0x58, 0x71, 0xa6, 0xb6, 0x15, 0x86, 0xbd, 0xa9, 0x13, 0x8f, 0xe9, 0x9e, 0x20,
-	0x5f, 0xc2, 0x80, 0x6d, 0xc4, 0x96, 0xab, 0xb0, 0x6f, 0x8e, 0x2c, 0xd2, 0xbc, 0x44, 0x9e, 0x63,
-	0x15, 0x1e, 0x4f, 0x9d, 0x78, 0x48, 0x2d, 0xd2, 0xd9, 0x2a, 0xcc, 0x8a, 0xb2, 0x40, 0xae, 0xc2,
-	0x81, 0x39, 0xda, 0x13, 0xba, 0xb6, 0x92, 0xd5, 0xcf, 0x82, 0xe5, 0xa1, 0x67, 0xd2, 0xb5, 0x90,
-	0x44, 0x30, 0x6e, 0x32, 0x24, 0xdb, 0xf4, 0x17, 0xac, 0x43, 0xdf, 0x1c, 0x77, 0x38, 0xf2, 0x0d,
-	0x40, 0x21, 0xaf, 0x45, 0xc1, 0x53, 0x26, 0x31, 0x1c, 0x4e, 0x9d, 0xd8, 0xa7, 0x07, 0x4c, 0xf4,
-	0xaf, 0x03, 0x83, 0xa5, 0x50, 0xf8, 0xc9, 0x45, 0x38, 0x85, 0xa1, 0x2a, 0x36, 0x28, 0x15, 0xdb,
-	0x94, 0x46, 0x87, 0x3e, 0xdd, 0x13, 0xba, 0x2c, 0x89, 0xcf, 0x4f, 0xc9, 0x36, 0xfd, 0x80, 0xb5,
-	0x91, 0x63, 0x4c, 0x0f, 0x18, 0xdd, 0xda, 0x8b, 0x50, 0x58, 0x5d, 0xe6, 0x79, 0x85, 0x52, 0x5a,
-	0x55, 0x3a, 0x5c, 0x1b, 0x83, 0x6d, 0x8c, 0xb7, 0x8f, 0x69, 0xb9, 0xe8, 0x19, 0xfc, 0xcb, 0x4c,
-	0x15, 0x82, 0x27, 0x29, 0xf9, 0x01, 0x7c, 0x65, 0x47, 0x6e, 0x1a, 0x1c, 0x5d, 0x90, 0xf3, 0xc6,
-	0x0e, 0xe7, 0x7b, 0x2b, 0xdc, 0x1e, 0xd1, 0x5d, 0x14, 0x39, 0x83, 0xbe, 0xce, 0x66, 0xda, 0x1e,
-	0x5d, 0x9c, 0xb4, 0xd1, 0x8d, 0x5e, 0xb7, 0x47, 0xd4, 0x9c, 0x5e, 0xf9, 0x30, 0x60, 0xe6, 0x8e,
-	0xe8, 0x1f, 0x17, 0x82, 0x2b, 0x6d, 0xb9, 0x5b, 0x64, 0xf9, 0x1b, 0xc6, 0x0a, 0xc1, 0x33, 0xc6,
-	0x9c, 0xdf, 0x98, 0xf4, 0x01, 0x6d, 0xa1, 0xb6, 0xc9, 0x1a, 0x8b, 0xd5, 0x5a, 0x19, 0x51, 0xfb,
-	0xd4, 0xa2, 0x37, 0x14, 0x3d, 0x83, 0xa0, 0xac, 0xf0, 0xa5, 0xb9, 0x9e, 0xc9, 0xb5, 0x15, 0xb5,
-	0x4b, 0xea, 0xdc, 0xea, 0x95, 0x0a, 0xd1, 0xf8, 0x6c, 0x4c, 0x2d, 0x32, 0xb3, 0x54, 0x4c, 0xa1,
-	0x39, 0xf2, 0xec, 0x2c, 0x5b, 0x42, 0x4f, 0x4b, 0x55, 0xfc, 0xf5, 0x6e, 0xbb, 0x49, 0xb1, 0x32,
-	0x36, 0x0b, 0xe8, 0x01, 0xa3, 0x27, 0xa1, 0xd1, 0x0d, 0x53, 0xec, 0xa1, 0xf8, 0xb3, 0xb1, 0x59,
-	0x40, 0x3b, 0x5c, 0xd7, 0x2d, 0xf0, 0x91, 0x95, 0x29, 0x1b, 0x2f, 0x8c, 0x9a, 0xba, 0x1a, 0x14,
-	0xe5, 0xe0, 0x99, 0xe2, 0x93, 0x94, 0x7c, 0xaf, 0x65, 0xd1, 0xb2, 0xda, 0xe1, 0x7d, 0xd1, 0x8e,
-	0xa3, 0xa3, 0x38, 0xb5, 0x41, 0xe4, 0x3b, 0xf0, 0x9a, 0xa9, 0xc8, 0xd0, 0x9d, 0xf6, 0xe2, 0xd1,
-	0xc5, 0xa4, 0x8d, 0x6f, 0x0d, 0x41, 0xdb, 0x80, 0xe8, 0x3d, 0x80, 0x49, 0x32, 0xe7, 0x39, 0xbe,
-	0x6a, 0xb7, 0x4b, 0xc5, 0x2a, 0x65, 0xee, 0xe9, 0xd3, 0x06, 0x90, 0x09, 0xf4, 0x90, 0xe7, 0x76,
-	0x03, 0xf4, 0x5f, 0x5d, 0xb3, 0x78, 0x7a, 0x92, 0xa8, 0xe7, 0xd4, 0x8b, 0x03, 0x6a, 0x51, 0xf4,
-	0x0e, 0x86, 0x26, 0xdb, 0x43, 0xcd, 0xb3, 0x7d, 0x32, 0xf7, 0x23, 0xc9, 0x7a, 0xbb, 0x64, 0xd1,
-	0x8f, 0x70, 0x62, 0x3e, 0xba, 0x16, 0x5c, 0xb1, 0x82, 0x63, 0x45, 0xbe, 0x85, 0x63, 0xf3, 0x7c,
-	0xd9, 0x76, 0x3f, 0xeb, 0xb4, 0x9b, 0xa4, 0xb4, 0x39, 0x8d, 0xfe, 0x76, 0x21, 0x58, 0x16, 0xf8,
-	0xc7, 0xf5, 0x9a, 0xf1, 0x15, 0xfe, 0x2a, 0x57, 0xe4, 0x27, 0x18, 0xbc, 0x64, 0xaa, 0x2e, 0xd1,
-	0x7c, 0x79, 0x72, 0x71, 0xb6, 0xf3, 0xed, 0x61, 0xd8, 0x01, 0x5a, 0xd4, 0x25, 0x52, 0xfb, 0xcd,
-	0xfe, 0x5a, 0xf7, 0xff, 0xae, 0xd5, 0xe3, 0x4c, 0x77, 0x56, 0xb3, 0xcb, 0xbf, 0x23, 0x9a, 0xf5,
-	0xd6, 0xaf, 0x90, 0xde, 0x43, 0xe3, 0xd5, 0x21, 0x3d, 0x60, 0xc8, 0xd7, 0xe0, 0xe7, 0x98, 0x15,
-	0x66, 0x2f, 0x8e, 0xcd, 0x9b, 0xb4, 0xc3, 0x11, 0x85, 0x93, 0x6e, 0x69, 0xe4, 0x14, 0xc2, 0xf9,
-	0xdd, 0xf2, 0xf2, 0xfd, 0xfc, 0xe6, 0x71, 0x39, 0x9f, 0xfd, 0xf6, 0x78, 0x7d, 0x7b, 0x79, 0xf7,
-	0xf3, 0xec, 0x71, 0xf1, 0x7b, 0x32, 0x9b, 0x1c, 0x91, 0x11, 0x78, 0x09, 0xbd, 0x4f, 0xee, 0x1f,
-	0x66, 0x13, 0xa7, 0x01, 0xb3, 0xe5, 0xfd, 0x62, 0x36, 0x71, 0x89, 0x0f, 0x7d, 0xf3, 0xaf, 0x17,
-	0xc5, 0x30, 0x5a, 0xa0, 0x54, 0x89, 0x7d, 0x38, 0xbf, 0x02, 0x7f, 0x23, 0x57, 0x8f, 0xa9, 0xc8,
-	0x6b, 0xa3, 0xd1, 0x98, 0x7a, 0x1b, 0xb9, 0xba, 0x12, 0x79, 0x9d, 0x0e, 0x4c, 0xb7, 0xef, 0xfe,
-	0x0b, 0x00, 0x00, 0xff, 0xff, 0xf4, 0xed, 0x37, 0xda, 0x3b, 0x06, 0x00, 0x00,
+func init() { proto.RegisterFile("blockchain.proto", fileDescriptor_blockchain_406e5eaf6967db8d) }
+
+var fileDescriptor_blockchain_406e5eaf6967db8d = []byte{
+	// 1509 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0xcb, 0x6e, 0x1b, 0xb7,
+	0x1a, 0xf6, 0x48, 0xb2, 0x2e, 0xbf, 0x24, 0x5b, 0x87, 0x71, 0x0e, 0xe6, 0x04, 0xc6, 0x81, 0x30,
+	0xf0, 0x49, 0x84, 0x20, 0xc7, 0x6d, 0x6d, 0x14, 0x45, 0xd1, 0x6e, 0x7c, 0x43, 0x64, 0xe4, 0x26,
+	0x50, 0xae, 0x83, 0xae, 0x0c, 0xce, 0x0c, 0x25, 0x0d, 0x2c, 0x71, 0x94, 0x21, 0xe5, 0x58, 0x7d,
+	0x83, 0xee, 0xbb, 0x6f, 0xd1, 0x5d, 0x9f, 0xa1, 0x6f, 0xd0, 0x4d, 0x9f, 0xa8, 0x40, 0xc1, 0xdb,
+	0x5c, 0x5c, 0xd9, 0x2e, 0x82, 0x22, 0x2b, 0xf1, 0xfb, 0xf8, 0x93, 0xfc, 0xf9, 0x5f, 0x3e, 0x8e,
+	0xa0, 0xe3, 0x4f, 0xe3, 0xe0, 0x32, 0x98, 0x90, 0x88, 0xed, 0xce, 0x93, 0x58, 0xc4, 0xa8, 0x1a,
+	0xa9, 0x5f, 0xef, 0xa7, 0x0a, 0xc0, 0x59, 0x42, 0x18, 0x1f, 0xd1, 0x64, 0xe0, 0x23, 0x17, 0x6a,
+	0x57, 0x34, 0xe1, 0x51, 0xcc, 0x5c, 0xa7, 0xeb, 0xf4, 0xda, 0xd8, 0x42, 0xb4, 0x05, 0xeb, 0x2c,
+	0x66, 0x01, 0x75, 0x4b, 0x5d, 0xa7, 0x57, 0xc1, 0x1a, 0xa0, 0x6d, 0x68, 0xf0, 0x68, 0xcc, 0x88,
+	0x58, 0x24, 0xd4, 0x2d, 0x77, 0x9d, 0x5e, 0x0b, 0x67, 0x04, 0xfa, 0x37, 0x54, 0xc9, 0x2c, 0x5e,
+	0x30, 0xe1, 0x56, 0xd4, 0x94, 0x41, 0x92, 0xe7, 0x94, 0x85, 0x34, 0x71, 0xd7, 0xbb, 0x4e, 0xaf,
+	0x81, 0x0d, 0x92, 0xbb, 0x25, 0x34, 0x88, 0xe6, 0x11, 0x65, 0xc2, 0xad, 0xaa, 0xa9, 0x8c, 0x90,
+	0xbe, 0xcd, 0xc9, 0x72, 0x1a, 0x93, 0xd0, 0xad, 0xa9, 0xed, 0x2c, 0x44, 0x1e, 0xb4, 0xf4, 0x0e,
+	0x83, 0x85, 0xff, 0x82, 0x2e, 0xdd, 0xba, 0x9a, 0x2e, 0x70, 0xe8, 0xbf, 0x00, 0x11, 0x3f, 0x8a,
+	0x23, 0xe6, 0x13, 0x4e, 0xdd, 0x46, 0xd7, 0xe9, 0xd5, 0x71, 0x8e, 0x41, 0x8f, 0xa0, 0x3e, 0x26,
+	0xfc, 0x65, 0x34, 0x8b, 0x84, 0x0b, 0xea, 0x8a, 0x29, 0x36, 0x73, 0x83, 0x24, 0x0a, 0xa8, 0xdb,
+	0x54, 0x7b, 0xa7, 0x18, 0xf5, 0x60, 0x73, 0xb6, 0x98, 0x8a, 0x88, 0x47, 0x63, 0x7d, 0x12, 0x77,
+	0x5b, 0xdd, 0x72, 0xaf, 0x85, 0x6f, 0xd2, 0xe8, 0x19, 0xfc, 0xcb, 0x52, 0x67, 0x93, 0x84, 0xf2,
+	0x49, 0x3c, 0x0d, 0xdd, 0xb6, 0x8a, 0xf2, 0x5f, 0x27, 0xd0, 0x2e, 0x20, 0x4b, 0x0e, 0x6d, 0x40,
+	0xb9, 0xbb, 0xa1, 0xb6, 0x5e, 0x31, 0x23, 0xfd, 0x60, 0xb1, 0x38, 0xa4, 0xa3, 0x38, 0xa1, 0x7d,
+	0x1a, 0x8d, 0x27, 0xc2, 0xdd, 0x54, 0xd7, 0xb8, 0x49, 0xcb, 0x9d, 0x53, 0xea, 0x2c, 0x9a, 0x51,
+	0x2e, 0xc8, 0x6c, 0xee, 0x76, 0x94, 0xf1, 0x8a, 0x19, 0xef, 0x87, 0x12, 0x54, 0xcf, 0x63, 0x41,
+	0xff, 0xf1, 0xf2, 0xd8, 0x86, 0x86, 0x48, 0xcf, 0xaf, 0xa8, 0x75, 0x19, 0x21, 0x13, 0xc6, 0xe9,
+	0x74, 0x34, 0x58, 0xf8, 0x97, 0x74, 0xa9, 0x0a, 0xa5, 0x85, 0x73, 0x8c, 0x4c, 0xfa, 0x55, 0x2c,
+	0x68, 0x72, 0x10, 0x86, 0x09, 0xe5, 0xdc, 0xd4, 0x4b, 0x81, 0xb3, 0x36, 0xd4, 0xda, 0xd4, 0x32,
+	0x1b, 0xcb, 0x15, 0x12, 0x5f, 0xbf, 0x23, 0xf1, 0x8d, 0x62, 0xe2, 0xbd, 0x1f, 0x4b, 0xd0, 0x3c,
+	0xb9, 0xa6, 0xc1, 0x42, 0x44, 0x31, 0xfb, 0x68, 0xad, 0xf3, 0x08, 0xea, 0x54, 0x1d, 0x1a, 0xdb,
+	0xe6, 0x49, 0xb1, 0x9c, 0x0b, 0x62, 0x26, 0x12, 0x12, 0xd8, 0xee, 0x49, 0x31, 0x7a, 0x0c, 0x1b,
+	0xd6, 0xce, 0x34, 0x89, 0xee, 0xa1, 0x1b, 0xec, 0x87, 0x46, 0x03, 0x21, 0xa8, 0x84, 0x44, 0x10,
+	0xd5, 0x3a, 0x2d, 0xac, 0xc6, 0xde, 0x1f, 0x0e, 0xd4, 0x86, 0x82, 0x5c, 0xd2, 0x8f, 0x2a, 0x2c,
+	0xf2, 0xc0, 0x4c, 0x58, 0x14, 0x52, 0x02, 0xa1, 0x46, 0xe6, 0xee, 0x55, 0x23, 0x10, 0x39, 0x4e,
+	0x7a, 0xb8, 0x60, 0x8a, 0x51, 0xa1, 0xa9, 0x63, 0x0b, 0x3f, 0xb8, 0x42, 0x7e, 0x73, 0xa0, 0x7e,
+	0x10, 0x98, 0xf2, 0xf8, 0x14, 0xea, 0xc2, 0xe8, 0xac, 0x8a, 0x40, 0x73, 0x0f, 0xed, 0x6a, 0x0d,
+	0xde, 0xcd, 0xf4, 0xb7, 0xbf, 0x86, 0x53, 0x2b, 0xb4, 0x03, 0x15, 0x59, 0xa8, 0x2a, 0x2e, 0xcd,
+	0xbd, 0x0d, 0x6b, 0xad, 0x5b, 0xb1, 0xbf, 0x86, 0xd5, 0x2c, 0xda, 0x87, 0x06, 0xb5, 0x55, 0xa8,
+	0x02, 0xd5, 0xdc, 0x7b, 0x60, 0x4d, 0x73, 0xe5, 0xd9, 0x5f, 0xc3, 0x99, 0x1d, 0x7a, 0x02, 0xeb,
+	0xfa, 0xa6, 0x15, 0xb5, 0x60, 0xd3, 0x2e, 0x30, 0xd9, 0xea, 0xaf, 0x61, 0x3d, 0x7f, 0x58, 0x87,
+	0x2a, 0x51, 0x37, 0xf0, 0x7e, 0x2f, 0x41, 0xfb, 0x50, 0xbe, 0x22, 0x7d, 0x4a, 0xc2, 0x7b, 0xde,
+	0x0a, 0x17, 0x6a, 0xea, 0xad, 0x39, 0x3d, 0x56, 0xce, 0xb7, 0xb1, 0x85, 0x32, 0x41, 0x13, 0x2d,
+	0x4e, 0x65, 0x15, 0x48, 0x83, 0xee, 0x91, 0x82, 0x1d, 0x68, 0xcf, 0x13, 0x7a, 0xa5, 0x8f, 0x27,
+	0x7c, 0x62, 0xd4, 0xa0, 0x48, 0xca, 0xbd, 0xc5, 0x35, 0x8e, 0x63, 0x61, 0xd2, 0x6b, 0x90, 0x2a,
+	0x25, 0x41, 0x04, 0x55, 0x53, 0x35, 0x53, 0x4a, 0x96, 0x90, 0x32, 0x23, 0x12, 0x76, 0xfd, 0x7a,
+	0x31, 0xf3, 0x69, 0xa2, 0xd2, 0xdb, 0xc6, 0x39, 0x46, 0x96, 0x8e, 0x44, 0xc7, 0x44, 0x90, 0x61,
+	0xf4, 0x9d, 0x4e, 0x72, 0x1b, 0x17, 0xb8, 0x62, 0xb1, 0xc2, 0x8a, 0x62, 0x9d, 0x6b, 0x11, 0xd3,
+	0x6f, 0x87, 0x41, 0x5e, 0x08, 0x35, 0xe5, 0xfc, 0xc0, 0x47, 0xff, 0x97, 0x61, 0x91, 0x61, 0x35,
+	0xa5, 0xf1, 0xd0, 0x26, 0xa4, 0x10, 0x71, 0x6c, 0x8c, 0xd0, 0x53, 0xa8, 0xe9, 0xac, 0x70, 0xb7,
+	0xd4, 0x2d, 0xf7, 0x9a, 0x7b, 0x1d, 0x6b, 0x6f, 0xcb, 0x0d, 0x5b, 0x03, 0xef, 0x2d, 0xd4, 0x8e,
+	0xe9, 0x54, 0x10, 0x9d, 0x30, 0x62, 0x84, 0xd0, 0x51, 0xed, 0x61, 0x61, 0xae, 0x9f, 0x4a, 0x37,
+	0xd5, 0x86, 0xd1, 0x31, 0x11, 0xd1, 0x95, 0x6e, 0xc2, 0x3a, 0x4e, 0xb1, 0xf7, 0x8b, 0x03, 0x0d,
+	0x4c, 0x03, 0x1a, 0xcd, 0x85, 0xd9, 0x3b, 0x10, 0x2a, 0x39, 0x8e, 0x7e, 0x9c, 0x0d, 0x34, 0x3d,
+	0x29, 0x16, 0xdc, 0x34, 0xb8, 0x41, 0xe8, 0x73, 0x68, 0xfb, 0x64, 0x4a, 0x58, 0x40, 0x95, 0x7f,
+	0xdc, 0x2d, 0x77, 0xcb, 0xf9, 0x5a, 0x34, 0x5e, 0xe3, 0xa2, 0x15, 0xda, 0x87, 0xd6, 0x7b, 0x55,
+	0x33, 0x66, 0x55, 0x65, 0xf5, 0xaa, 0x82, 0x91, 0xf7, 0x15, 0xd4, 0x64, 0xdb, 0x24, 0xf7, 0x05,
+	0x41, 0x2f, 0xb2, 0x41, 0xd0, 0xc8, 0x7b, 0x01, 0xcd, 0x6f, 0x98, 0x1f, 0xb3, 0x30, 0x62, 0xe3,
+	0x81, 0x9f, 0x8b, 0x95, 0x53, 0x88, 0xd5, 0x0e, 0xb4, 0x13, 0x3a, 0xa5, 0x84, 0xd3, 0x7e, 0xb6,
+	0x4b, 0x05, 0x17, 0x49, 0xef, 0xd7, 0x12, 0x34, 0x0e, 0x82, 0x40, 0xae, 0x18, 0xf8, 0x99, 0xf6,
+	0x39, 0x79, 0xed, 0x73, 0xa1, 0x66, 0xee, 0x6c, 0x3c, 0xb1, 0x50, 0xaa, 0x6c, 0x22, 0xab, 0x58,
+	0x0b, 0xa2, 0x1a, 0x6b, 0xd5, 0x0f, 0xa9, 0x0a, 0xbd, 0x56, 0xc3, 0x14, 0xa3, 0x2e, 0x34, 0x23,
+	0x7e, 0x44, 0x58, 0x18, 0x85, 0x44, 0x50, 0xd5, 0x36, 0x75, 0x9c, 0xa7, 0xcc, 0x0b, 0x19, 0xb1,
+	0xf1, 0x5b, 0xed, 0xb4, 0x51, 0xc6, 0x3c, 0x27, 0xbd, 0x54, 0x2f, 0xa6, 0x79, 0x3e, 0x35, 0x40,
+	0x4f, 0xa0, 0x2a, 0x07, 0x09, 0x77, 0xeb, 0xc5, 0x14, 0x98, 0x48, 0x63, 0x33, 0x9d, 0x8a, 0x72,
+	0x68, 0x04, 0xd2, 0x20, 0xf4, 0x19, 0x34, 0x16, 0x36, 0xae, 0x2e, 0x74, 0xcb, 0x79, 0xe5, 0xca,
+	0x05, 0x1c, 0x67, 0x56, 0xde, 0x4b, 0x00, 0xd5, 0x11, 0xa7, 0x2c, 0xa4, 0xd7, 0xd2, 0x2f, 0x2e,
+	0x48, 0x22, 0x6c, 0xf4, 0x14, 0x40, 0x1d, 0x28, 0x53, 0x16, 0x9a, 0xe8, 0xcb, 0xa1, 0x74, 0x20,
+	0x1e, 0x8d, 0x38, 0x15, 0xaa, 0xc4, 0xda, 0xd8, 0x20, 0x6f, 0x1f, 0x1a, 0x6a, 0xb7, 0xe1, 0x92,
+	0x05, 0xd9, 0x66, 0xa5, 0x15, 0x9b, 0x95, 0xd3, 0xcd, 0xbc, 0x2f, 0x60, 0x43, 0x2d, 0x3a, 0x8a,
+	0x99, 0x20, 0x11, 0xa3, 0x09, 0xfa, 0x1f, 0xac, 0xab, 0xcf, 0x6b, 0xd3, 0xbb, 0x9b, 0x85, 0xde,
+	0x1d, 0xf8, 0x58, 0xcf, 0x7a, 0x5f, 0xc2, 0x66, 0xae, 0x9b, 0x8b, 0x67, 0xde, 0x7d, 0x01, 0xef,
+	0x39, 0x6c, 0xe5, 0x96, 0x66, 0x27, 0x7f, 0x02, 0x35, 0xad, 0x08, 0xb2, 0x96, 0xcb, 0xb7, 0xeb,
+	0x86, 0xb5, 0xf2, 0x9e, 0xc2, 0x86, 0x51, 0x88, 0x24, 0x8e, 0x47, 0x98, 0xbe, 0xbb, 0xbd, 0x6f,
+	0xbd, 0xef, 0x1d, 0x68, 0xe7, 0x8c, 0xef, 0xec, 0xf1, 0x2d, 0x58, 0x1f, 0xc5, 0x0b, 0xe3, 0x74,
+	0x1d, 0x6b, 0x70, 0xab, 0xd8, 0x6f, 0xc1, 0x7a, 0x24, 0x13, 0xa8, 0xca, 0xb5, 0x8d, 0x35, 0x90,
+	0x75, 0xcc, 0x23, 0x7f, 0x1a, 0xb1, 0x31, 0x77, 0xd7, 0xd5, 0x67, 0x6e, 0x8a, 0xbd, 0x03, 0x68,
+	0x0f, 0x05, 0x11, 0xb4, 0xe0, 0xf6, 0xad, 0x5d, 0x3c, 0xc9, 0xf7, 0x9f, 0x41, 0xde, 0x39, 0xb4,
+	0xb2, 0x2d, 0xee, 0xd3, 0x81, 0x55, 0x3b, 0xe8, 0x66, 0x0d, 0xa9, 0x16, 0xaa, 0x16, 0xd6, 0xc0,
+	0x7b, 0x0c, 0xad, 0x63, 0x3a, 0x95, 0xa2, 0x48, 0xb9, 0xf4, 0x2c, 0x5b, 0xed, 0x14, 0xce, 0x1f,
+	0x42, 0x33, 0xb5, 0xd3, 0x2a, 0xb2, 0xca, 0x0c, 0x3d, 0x83, 0xea, 0x5c, 0x7a, 0x68, 0x95, 0x7d,
+	0x2b, 0xf7, 0x34, 0xa7, 0xce, 0x63, 0x63, 0xe3, 0x3d, 0x84, 0x07, 0x43, 0x46, 0xe6, 0x7c, 0x12,
+	0x8b, 0x57, 0x84, 0x45, 0x23, 0xca, 0x05, 0xa6, 0xef, 0xbc, 0x31, 0xa0, 0x9b, 0xf4, 0xc0, 0xff,
+	0x9b, 0x75, 0x2a, 0x35, 0x66, 0x46, 0x05, 0x31, 0xd2, 0xa3, 0xc6, 0xd2, 0xdb, 0x60, 0xb2, 0x60,
+	0x97, 0xf6, 0xee, 0x06, 0x79, 0x8f, 0xa1, 0x63, 0x0f, 0x3a, 0x92, 0x8c, 0x0c, 0x00, 0x82, 0xca,
+	0x24, 0x2b, 0x11, 0x35, 0x96, 0xb5, 0x5f, 0xb0, 0x1b, 0xf8, 0xab, 0xcc, 0xd2, 0x8f, 0xc8, 0x52,
+	0xee, 0x23, 0xf2, 0xe7, 0x12, 0xb4, 0xcf, 0x23, 0xfa, 0xfe, 0x68, 0x42, 0xd8, 0x98, 0xbe, 0xe2,
+	0x63, 0xf4, 0x35, 0x54, 0xaf, 0x02, 0xb1, 0x9c, 0x6b, 0xd5, 0xdc, 0xd8, 0xdb, 0x49, 0x85, 0x27,
+	0x6f, 0x96, 0x43, 0x67, 0xcb, 0x39, 0xc5, 0x66, 0x4d, 0x16, 0x85, 0xd2, 0x9d, 0x51, 0xd8, 0x86,
+	0x86, 0x9f, 0x7e, 0x6e, 0x98, 0xef, 0xcf, 0x94, 0xd0, 0xff, 0x4d, 0xe4, 0x9f, 0x4b, 0xf9, 0x27,
+	0x42, 0x95, 0x71, 0x03, 0xe7, 0x18, 0x59, 0xcb, 0x21, 0x0d, 0x22, 0xf5, 0x6d, 0xa4, 0x45, 0x37,
+	0xc5, 0x1e, 0x86, 0x8d, 0xa2, 0x6b, 0x68, 0x1b, 0xdc, 0xd3, 0xd7, 0xe7, 0x07, 0x2f, 0x4f, 0x8f,
+	0x2f, 0xce, 0x4f, 0x4f, 0xde, 0x5e, 0x1c, 0xf5, 0x0f, 0x5e, 0x3f, 0x3f, 0xb9, 0x38, 0xfb, 0x76,
+	0x70, 0xd2, 0x59, 0x43, 0x4d, 0xa8, 0x0d, 0xf0, 0x9b, 0xc1, 0x9b, 0xe1, 0x49, 0xc7, 0xd1, 0xe0,
+	0xe4, 0xfc, 0xcd, 0xd9, 0x49, 0xa7, 0x84, 0xea, 0x50, 0x51, 0xa3, 0xb2, 0xd7, 0x83, 0xe6, 0x99,
+	0x4c, 0xb2, 0xf9, 0x3f, 0xfc, 0x1f, 0xa8, 0xcf, 0xf8, 0xf8, 0xc2, 0x8f, 0xc3, 0xa5, 0xed, 0xd4,
+	0x19, 0x1f, 0x1f, 0xc6, 0xe1, 0xd2, 0xaf, 0xaa, 0xdb, 0xee, 0xff, 0x39, 0x00, 0x03, 0xa0, 0xe9,
+	0x90, 0x12, 0x10, 0x00, 0x00,
 }
@@ -32,6 +32,30 @@ const (
 	MsgBlockSyncDataType uint32 = 5
 	// MsgActionType is the action message
 	MsgActionType uint32 = 6
+	// MsgBlockHeaderSyncReqType is for requests of light clients to sync block headers
+	MsgBlockHeaderSyncReqType uint32 = 7
+	// MsgBlockHeaderSyncDataType is the response to messages of type MsgBlockHeaderSyncReqType
+	MsgBlockHeaderSyncDataType uint32 = 8
+	// MsgActionProofReqType is for requests of light clients for the proof of an action
+	MsgActionProofReqType uint32 = 9
+	// MsgActionProofType is the response to messages of type MsgActionProofReqType
+	MsgActionProofType uint32 = 10
+	// MsgStateProofReqType is for requests of light clients for the proof of the state of an address
+	MsgStateProofReqType uint32 = 11
+	// MsgStateProofType is the response to messages of type MsgStateProofReqType
+	MsgStateProofType uint32 = 12
+	// MsgSnapshotManifestReqType is for requests of new nodes for the latest state snapshot
+	MsgSnapshotManifestReqType uint32 = 13
+	// MsgSnapshotManifestType is the response to messages of type MsgSnapshotManifestReqType
+	MsgSnapshotManifestType uint32 = 14
+	// MsgSnapshotChunkReqType is for requests of new nodes for a chunk of the state snapshot
+	MsgSnapshotChunkReqType uint32 = 15
+	// MsgSnapshotChunkType is the response to messages of type MsgSnapshotChunkReqType
+	MsgSnapshotChunkType uint32 = 16
+	// MsgDelegatesReqType is for requests of light clients for the delegates of an epoch
+	MsgDelegatesReqType uint32 = 17
+	// MsgDelegatesType is the response to messages of type MsgDelegatesReqType
+	MsgDelegatesType uint32 = 18
 	// TestPayloadType is a test payload message type
 	TestPayloadType uint32 = 10001
 )
@@ -49,6 +73,30 @@ func GetTypeFromProtoMsg(msg proto.Message) (uint32, error) {
 		return MsgBlockSyncDataType, nil
 	case *ActionPb:
 		return MsgActionType, nil
+	case *BlockHeaderSync:
+		return MsgBlockHeaderSyncReqType, nil
+	case *BlockHeaderContainer:
+		return MsgBlockHeaderSyncDataType, nil
+	case *ActionProofReq:
+		return MsgActionProofReqType, nil
+	case *ActionProofPb:
+		return MsgActionProofType, nil
+	case *StateProofReq:
+		return MsgStateProofReqType, nil
+	case *StateProofPb:
+		return MsgStateProofType, nil
+	case *SnapshotManifestReq:
+		return MsgSnapshotManifestReqType, nil
+	case *SnapshotManifestPb:
+		return MsgSnapshotManifestType, nil
+	case *SnapshotChunkReq:
+		return MsgSnapshotChunkReqType, nil
+	case *SnapshotChunkPb:
+		return MsgSnapshotChunkType, nil
+	case *DelegatesReq:
+		return MsgDelegatesReqType, nil
+	case *DelegatesPb:
+		return MsgDelegatesType, nil
 	case *TestPayload:
 		return TestPayloadType, nil
 	default:
@@ -70,6 +118,30 @@ func TypifyProtoMsg(tp uint32, msg []byte) (proto.Message, error) {
 		m = &BlockContainer{}
 	case MsgActionType:
 		m = &ActionPb{}
+	case MsgBlockHeaderSyncReqType:
+		m = &BlockHeaderSync{}
+	case MsgBlockHeaderSyncDataType:
+		m = &BlockHeaderContainer{}
+	case MsgActionProofReqType:
+		m = &ActionProofReq{}
+	case MsgActionProofType:
+		m = &ActionProofPb{}
+	case MsgStateProofReqType:
+		m = &StateProofReq{}
+	case MsgStateProofType:
+		m = &StateProofPb{}
+	case MsgSnapshotManifestReqType:
+		m = &SnapshotManifestReq{}
+	case MsgSnapshotManifestType:
+		m = &SnapshotManifestPb{}
+	case MsgSnapshotChunkReqType:
+		m = &SnapshotChunkReq{}
+	case MsgSnapshotChunkType:
+		m = &SnapshotChunkPb{}
+	case MsgDelegatesReqType:
+		m = &DelegatesReq{}
+	case MsgDelegatesType:
+		m = &DelegatesPb{}
 	case TestPayloadType:
 		m = &TestPayload{}
 	default:
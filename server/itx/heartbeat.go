@@ -61,27 +61,33 @@ func (h *HeartbeatHandler) Log() {
 	}
 	numDPEvts := len(*dp.EventChan())
 
-	// Consensus metrics
-	cs, ok := h.s.cs.(*consensus.IotxConsensus)
-	if !ok {
-		logger.Error().Msg("consensus is not the instance of IotxConsensus")
-		return
-	}
-	rolldpos, ok := cs.Scheme().(*rolldpos.RollDPoS)
+	// Consensus metrics, of which a lightweight node has none
 	numCSEvts := 0
 	var state fsm.State
-	if ok {
-		numCSEvts = len(*rolldpos.EventChan())
-		state = rolldpos.FSM().CurrentState()
-	} else {
-		logger.Debug().Msg("scheme is not the instance of RollDPoS")
+	if h.s.cs != nil {
+		cs, ok := h.s.cs.(*consensus.IotxConsensus)
+		if !ok {
+			logger.Error().Msg("consensus is not the instance of IotxConsensus")
+			return
+		}
+		if rolldpos, ok := cs.Scheme().(*rolldpos.RollDPoS); ok {
+			numCSEvts = len(*rolldpos.EventChan())
+			state = rolldpos.FSM().CurrentState()
+		} else {
+			logger.Debug().Msg("scheme is not the instance of RollDPoS")
+		}
 	}
 
-	// Block metrics
-	height, err := h.s.Bc().TipHeight()
-	if err != nil {
-		logger.Error().Err(err).Msg("error one getting the the blockchain height")
-		height = 0
+	// Block metrics, which are of the synced headers on a lightweight node
+	var height uint64
+	if h.s.lc != nil {
+		height = h.s.lc.TipHeight()
+	} else {
+		var err error
+		if height, err = h.s.Bc().TipHeight(); err != nil {
+			logger.Error().Err(err).Msg("error one getting the the blockchain height")
+			height = 0
+		}
 	}
 
 	logger.Info().
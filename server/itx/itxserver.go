@@ -15,9 +15,11 @@ import (
 	"github.com/iotexproject/iotex-core/blocksync"
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/consensus"
+	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/delegate"
 	"github.com/iotexproject/iotex-core/dispatch"
 	"github.com/iotexproject/iotex-core/dispatch/dispatcher"
+	"github.com/iotexproject/iotex-core/lightclient"
 	"github.com/iotexproject/iotex-core/logger"
 	"github.com/iotexproject/iotex-core/network"
 )
@@ -30,19 +32,26 @@ type Server struct {
 	dp  dispatcher.Dispatcher
 	cfg *config.Config
 	cs  consensus.Consensus
+	lc  lightclient.LightClient
 }
 
 // NewServer creates a new server
 func NewServer(cfg *config.Config) *Server {
+	if cfg.IsLightweight() {
+		return newLightweightServer(cfg, db.NewBoltDB(cfg.Chain.HeaderDBPath, nil))
+	}
 	// create Blockchain
 	bc := blockchain.NewBlockchain(cfg, blockchain.DefaultStateFactoryOption(), blockchain.BoltDBDaoOption())
-	return newServer(cfg, bc)
+	return newServer(cfg, bc, db.NewBoltDB(cfg.Chain.HeaderDBPath, nil))
 }
 
 // NewInMemTestServer creates a test server in memory
 func NewInMemTestServer(cfg *config.Config) *Server {
+	if cfg.IsLightweight() {
+		return newLightweightServer(cfg, db.NewMemKVStore())
+	}
 	bc := blockchain.NewBlockchain(cfg, blockchain.InMemStateFactoryOption(), blockchain.InMemDaoOption())
-	return newServer(cfg, bc)
+	return newServer(cfg, bc, db.NewMemKVStore())
 }
 
 // Start starts the server
@@ -62,7 +71,9 @@ func (s *Server) Start(ctx context.Context) error {
 func (s *Server) Stop(ctx context.Context) error {
 	s.o.Stop(ctx)
 	s.dp.Stop(ctx)
-	s.bc.Stop(ctx)
+	if s.bc != nil {
+		s.bc.Stop(ctx)
+	}
 	os.Remove(s.cfg.Chain.ChainDBPath)
 	return nil
 }
@@ -92,17 +103,37 @@ func (s *Server) Cs() consensus.Consensus {
 	return s.cs
 }
 
-func newServer(cfg *config.Config, bc blockchain.Blockchain) *Server {
+// Lc returns the light client, which is nil unless the node is lightweight
+func (s *Server) Lc() lightclient.LightClient {
+	return s.lc
+}
+
+// newServer creates a node of the chain. With fast sync, the light client syncing the headers into headerStore verifies
+// the snapshot the fresh node restores
+func newServer(cfg *config.Config, bc blockchain.Blockchain, headerStore db.KVStore) *Server {
 
 	// create P2P network and BlockSync
 	o := network.NewOverlay(&cfg.Network)
+	genesisHash := bc.Genesis().Hash()
+	o.SetGenesisHash(genesisHash[:])
 	// Create ActPool
 	ap, err := actpool.NewActPool(bc, cfg.ActPool)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Fail to create actpool")
 	}
 	pool := delegate.NewConfigBasedPool(&cfg.Delegate)
-	bs, err := blocksync.NewBlockSyncer(cfg, bc, ap, o)
+	var lc lightclient.LightClient
+	var opts []blocksync.Option
+	if cfg.BlockSync.EnableFastSync {
+		// the snapshot is only trusted by the headers whose producers are checked against the delegates
+		lcCfg := *cfg
+		lcCfg.Consensus.Scheme = config.RollDPoSScheme
+		if lc, err = lightclient.NewLightClient(&lcCfg, bc.Genesis(), headerStore, o); err != nil {
+			logger.Fatal().Err(err).Msg("Fail to create light client")
+		}
+		opts = append(opts, blocksync.HeaderChainOption(lc))
+	}
+	bs, err := blocksync.NewBlockSyncer(cfg, bc, ap, o, opts...)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Fail to create blockSyncer")
 	}
@@ -111,8 +142,11 @@ func newServer(cfg *config.Config, bc blockchain.Blockchain) *Server {
 		logger.Fatal().Msg("Failed to create Consensus")
 	}
 
+	// serve the light clients
+	ls := lightclient.NewServer(cfg, bc, o)
+
 	// create dispatcher instance
-	dp, err := dispatch.NewDispatcher(cfg, ap, bs, cs)
+	dp, err := dispatch.NewDispatcher(cfg, ap, bs, cs, lc, ls)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Fail to create dispatcher")
 	}
@@ -127,3 +161,35 @@ func newServer(cfg *config.Config, bc blockchain.Blockchain) *Server {
 		cs:  cs,
 	}
 }
+
+// newLightweightServer creates a node which syncs only the block headers into kvstore with the light client, so it has
+// no chain, actpool or consensus
+func newLightweightServer(cfg *config.Config, kvstore db.KVStore) *Server {
+	genesis, err := blockchain.LoadGenesis(cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Fail to load genesis")
+	}
+	genesis.ApplyConsensus(cfg)
+
+	o := network.NewOverlay(&cfg.Network)
+	genesisHash := genesis.Hash()
+	o.SetGenesisHash(genesisHash[:])
+	lc, err := lightclient.NewLightClient(cfg, genesis, kvstore, o)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Fail to create light client")
+	}
+
+	// create dispatcher instance
+	dp, err := dispatch.NewDispatcher(cfg, nil, nil, nil, lc, nil)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Fail to create dispatcher")
+	}
+	o.AttachDispatcher(dp)
+
+	return &Server{
+		o:   o,
+		dp:  dp,
+		cfg: cfg,
+		lc:  lc,
+	}
+}
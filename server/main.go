@@ -15,9 +15,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/iotexproject/iotex-core/config"
 	"github.com/iotexproject/iotex-core/explorer"
@@ -85,7 +89,20 @@ func main() {
 		}()
 	}
 
-	if cfg.Explorer.Enabled {
+	if cfg.System.HTTPMetricsPort > 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.Handler())
+		addr := net.JoinHostPort(cfg.System.HTTPMetricsHost, strconv.Itoa(cfg.System.HTTPMetricsPort))
+		logger.Info().Msg("Starting metrics server on " + addr)
+		go func() {
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				logger.Error().Err(err).Msg("Failed to serve metrics")
+			}
+		}()
+	}
+
+	// a lightweight node has no chain to explore
+	if cfg.Explorer.Enabled && !cfg.IsLightweight() {
 		isTest := cfg.Explorer.IsTest
 		env := os.Getenv("APP_ENV")
 		if env == "development" {
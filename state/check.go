@@ -0,0 +1,115 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/trie"
+)
+
+// CheckStates checks the nodes of the account trie of the given root in DB, along with the storage trie and the code of
+// every contract reached from it
+func CheckStates(dao db.KVStore, root hash.Hash32B) (*trie.CheckReport, error) {
+	var contracts trie.CheckReport
+	report, err := trie.Check(dao, trie.AccountKVNameSpace, root, func(key, value []byte) error {
+		state, err := bytesToState(value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode state of %x", key)
+		}
+		if state.Root != hash.ZeroHash32B && state.Root != trie.EmptyRoot {
+			storage, err := trie.Check(dao, trie.ContractKVNameSpace, state.Root, nil)
+			if err != nil {
+				return err
+			}
+			contracts.Merge(storage)
+		}
+		if len(state.CodeHash) > 0 {
+			contracts.Nodes++
+			if fault := checkCode(dao, key, state.CodeHash); fault != nil {
+				contracts.Faults = append(contracts.Faults, fault)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Merge(&contracts)
+	return report, nil
+}
+
+// RepairStates writes the nodes of the account trie of the given root in src, along with the storage trie and the code
+// of every contract reached from it, into dst wherever they are missing or corrupted in dst. The states in src must be
+// complete. If the history of the states is kept in dst, the nodes written are recorded as put by the block of the given
+// height, so Prune reclaims them once they are made stale. It returns the number of the nodes and the codes written
+func RepairStates(dst, src db.KVStore, root hash.Hash32B, height uint64) (int, error) {
+	written, err := trie.RepairVersion(dst, src, trie.AccountKVNameSpace, root, height)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to repair account trie")
+	}
+	report, err := trie.Check(src, trie.AccountKVNameSpace, root, func(key, value []byte) error {
+		state, err := bytesToState(value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode state of %x", key)
+		}
+		if state.Root != hash.ZeroHash32B && state.Root != trie.EmptyRoot {
+			n, err := trie.RepairVersion(dst, src, trie.ContractKVNameSpace, state.Root, height)
+			if err != nil {
+				return errors.Wrapf(err, "failed to repair storage trie of %x", key)
+			}
+			written += n
+		}
+		if len(state.CodeHash) == 0 || checkCode(dst, key, state.CodeHash) == nil {
+			return nil
+		}
+		if fault := checkCode(src, key, state.CodeHash); fault != nil {
+			return errors.Wrapf(fault.Err, "code of %x in source is faulty", key)
+		}
+		code, err := src.Get(trie.CodeKVNameSpace, state.CodeHash)
+		if err != nil {
+			return err
+		}
+		if err := dst.Put(trie.CodeKVNameSpace, state.CodeHash, code); err != nil {
+			return errors.Wrapf(err, "failed to put code of hash %x", state.CodeHash)
+		}
+		written++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !report.OK() {
+		return 0, errors.Errorf("account trie of root %x in source is faulty", root[:8])
+	}
+	return written, nil
+}
+
+//======================================
+// private functions
+//======================================
+// checkCode checks the code of the account key against its code hash
+func checkCode(dao db.KVStore, key, codeHash []byte) *trie.NodeFault {
+	fault := &trie.NodeFault{Bucket: trie.CodeKVNameSpace, Path: key}
+	copy(fault.Hash[:], codeHash)
+	code, err := dao.Get(trie.CodeKVNameSpace, codeHash)
+	if err != nil {
+		fault.Missing = true
+		fault.Err = err
+		return fault
+	}
+	if h := blake2b.Sum256(code); !bytes.Equal(h[:], codeHash) {
+		fault.Err = errors.Errorf("code does not match hash %x", codeHash)
+		return fault
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/blockchain/action"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/trie"
+	"github.com/iotexproject/iotex-core/txvm"
+)
+
+func TestCheckAndRepairStates(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	// the contract stores the input under key 0x01
+	code := []byte{txvm.OpData1, 0x01, txvm.OpSStore}
+	deploy, err := action.NewExecution(1, big.NewInt(10), a.RawAddress, "", code, 1000, big.NewInt(0))
+	require.NoError(err)
+	contractAddress, err := iotxaddress.CreateContractAddress(a.RawAddress, 1)
+	require.NoError(err)
+	invoke, err := action.NewExecution(2, big.NewInt(5), a.RawAddress, contractAddress, []byte{txvm.OpData1, 0x12}, 1000,
+		big.NewInt(0))
+	require.NoError(err)
+	newStates := func() (db.KVStore, Factory) {
+		dao := db.NewMemKVStore()
+		sf, err := NewFactory(&config.Default, PrecreatedDBOption(dao))
+		require.NoError(err)
+		_, err = sf.CreateState(a.RawAddress, uint64(1000))
+		require.NoError(err)
+		require.NoError(sf.CommitStateChanges(1, []action.Action{deploy}))
+		require.NoError(sf.CommitStateChanges(2, []action.Action{invoke}))
+		return dao, sf
+	}
+	dao, sf := newStates()
+	src, _ := newStates()
+	root := sf.RootHash()
+
+	report, err := CheckStates(dao, root)
+	require.NoError(err)
+	require.True(report.OK())
+	require.Equal(3, report.Entries)
+	n, err := RepairStates(dao, src, root, 2)
+	require.NoError(err)
+	require.Equal(0, n)
+
+	// the storage root and the code of the contract are lost
+	s, err := sf.State(contractAddress)
+	require.NoError(err)
+	require.NoError(dao.Delete(trie.ContractKVNameSpace, s.Root[:]))
+	require.NoError(dao.Delete(trie.CodeKVNameSpace, s.CodeHash))
+	report, err = CheckStates(dao, root)
+	require.NoError(err)
+	require.Equal(2, len(report.Faults))
+	for _, fault := range report.Faults {
+		require.True(fault.Missing)
+	}
+	require.Equal(trie.ContractKVNameSpace, report.Faults[0].Bucket)
+	require.Equal(s.Root, report.Faults[0].Hash)
+	require.Equal(trie.CodeKVNameSpace, report.Faults[1].Bucket)
+	require.Equal(s.CodeHash, report.Faults[1].Hash[:])
+
+	// the faulty states cannot be the source of a repair
+	_, err = RepairStates(src, dao, root, 2)
+	require.Error(err)
+	n, err = RepairStates(dao, src, root, 2)
+	require.NoError(err)
+	require.Equal(2, n)
+	report, err = CheckStates(dao, root)
+	require.NoError(err)
+	require.True(report.OK())
+	c, err := newContract(s, dao, nil)
+	require.NoError(err)
+	value, err := c.GetState([]byte{0x01})
+	require.NoError(err)
+	require.Equal([]byte{0x12}, value)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/trie"
+)
+
+// ErrContractNotExist is the error that the account does not have contract code
+var ErrContractNotExist = errors.New("the contract does not exist")
+
+type (
+	// Contract is the code and the storage of a contract account
+	Contract interface {
+		// Code returns the code of the contract
+		Code() ([]byte, error)
+		// SetCode sets the code of the contract
+		SetCode(code []byte)
+		// GetState returns the value of a key in the storage, which is nil if the key is not set
+		GetState(key []byte) ([]byte, error)
+		// SetState sets the value of a key in the storage
+		SetState(key []byte, value []byte) error
+	}
+
+	// contract keeps the storage of a contract in its own trie, whose root is the Root of the account state. The trie
+	// keeps the stale nodes, so the storage of an earlier state remains accessible after rollback or for history queries,
+	// until the nodes are pruned along with the states
+	contract struct {
+		*State
+		dao  db.KVStore
+		code []byte    // code set but not committed yet
+		trie trie.Trie // storage trie, in batch mode until committed
+	}
+)
+
+// newContract loads the contract of an account state from the DB, caching the storage nodes in the node cache if any
+func newContract(state *State, dao db.KVStore, cache *trie.NodeCache) (*contract, error) {
+	root := state.Root
+	if root == hash.ZeroHash32B {
+		root = trie.EmptyRoot
+	}
+	tr, err := trie.NewTrieWithHistory(dao, trie.ContractKVNameSpace, root, trie.NodeCacheOption(cache))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load contract storage of root %x", root[:8])
+	}
+	if err := tr.EnableBatch(); err != nil {
+		return nil, err
+	}
+	return &contract{State: state, dao: dao, trie: tr}, nil
+}
+
+// Code returns the code of the contract
+func (c *contract) Code() ([]byte, error) {
+	if c.code != nil {
+		return c.code, nil
+	}
+	if len(c.CodeHash) == 0 {
+		return nil, ErrContractNotExist
+	}
+	return c.dao.Get(trie.CodeKVNameSpace, c.CodeHash)
+}
+
+// SetCode sets the code of the contract, the code hash of the account state is updated accordingly
+func (c *contract) SetCode(code []byte) {
+	c.code = code
+	codeHash := blake2b.Sum256(code)
+	c.CodeHash = codeHash[:]
+}
+
+// GetState returns the value of a key in the storage of the contract
+func (c *contract) GetState(key []byte) ([]byte, error) {
+	k := blake2b.Sum256(key)
+	value, err := c.trie.Get(k[:])
+	if errors.Cause(err) == trie.ErrNotExist {
+		return nil, nil
+	}
+	return value, err
+}
+
+// SetState sets the value of a key in the storage of the contract, the root of the account state is updated accordingly
+func (c *contract) SetState(key []byte, value []byte) error {
+	k := blake2b.Sum256(key)
+	if err := c.trie.Upsert(k[:], value); err != nil {
+		return err
+	}
+	c.Root = c.trie.RootHash()
+	return nil
+}
+
+// commit writes the code and the storage of the contract to the DB
+func (c *contract) commit() error {
+	if c.code != nil {
+		if err := c.dao.Put(trie.CodeKVNameSpace, c.CodeHash, c.code); err != nil {
+			return errors.Wrapf(err, "failed to put code of hash %x", c.CodeHash)
+		}
+		c.code = nil
+	}
+	return c.trie.Flush()
+}
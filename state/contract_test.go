@@ -0,0 +1,157 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/blockchain/action"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/testutil"
+	"github.com/iotexproject/iotex-core/trie"
+	"github.com/iotexproject/iotex-core/txvm"
+)
+
+func TestExecution(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	p, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	sf, err := NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(1000))
+	require.NoError(err)
+
+	// the contract stores the input under key 0x01, and the caller under key 0x02
+	code := []byte{txvm.OpData1, 0x01, txvm.OpSStore, txvm.OpCaller, txvm.OpData1, 0x02, txvm.OpSStore}
+	deploy, err := action.NewExecution(1, big.NewInt(10), a.RawAddress, "", code, 1000, big.NewInt(0))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{deploy}))
+	contractAddress, err := iotxaddress.CreateContractAddress(a.RawAddress, 1)
+	require.NoError(err)
+	s, err := sf.State(contractAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(10), s.Balance)
+	require.NotEmpty(s.CodeHash)
+	require.Equal(hash.ZeroHash32B, s.Root)
+
+	// running the contract does not change the committed states
+	input := []byte{txvm.OpData2, 0x12, 0x34}
+	invoke, err := action.NewExecution(2, big.NewInt(5), a.RawAddress, contractAddress, input, 1000, big.NewInt(0))
+	require.NoError(err)
+	root := sf.RootHash()
+	newRoot, err := sf.RunActions(2, []action.Action{invoke})
+	require.NoError(err)
+	require.NotEqual(root, newRoot)
+	require.Equal(root, sf.RootHash())
+
+	require.NoError(sf.CommitStateChanges(2, []action.Action{invoke}))
+	require.Equal(newRoot, sf.RootHash())
+	s, err = sf.State(contractAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(15), s.Balance)
+	require.NotEqual(hash.ZeroHash32B, s.Root)
+	c, err := newContract(s, sf.(*factory).dao, sf.(*factory).nodeCache)
+	require.NoError(err)
+	storedCode, err := c.Code()
+	require.NoError(err)
+	require.Equal(code, storedCode)
+	value, err := c.GetState([]byte{0x01})
+	require.NoError(err)
+	require.Equal([]byte{0x12, 0x34}, value)
+	value, err = c.GetState([]byte{0x02})
+	require.NoError(err)
+	require.Equal([]byte(a.RawAddress), value)
+	value, err = c.GetState([]byte{0x03})
+	require.NoError(err)
+	require.Nil(value)
+
+	// running out of gas costs the whole gas limit, and leaves the contract untouched
+	input = []byte{txvm.OpData2, 0x56, 0x78}
+	invoke, err = action.NewExecution(3, big.NewInt(5), a.RawAddress, contractAddress, input, 0, big.NewInt(1))
+	require.NoError(err)
+	invoke.GasLimit = invoke.IntrinsicGas() + 5
+	cb := action.NewCoinBaseTransfer(big.NewInt(0), p.RawAddress)
+	require.NoError(sf.CommitStateChanges(3, []action.Action{cb, invoke}))
+	contractState, err := sf.State(contractAddress)
+	require.NoError(err)
+	require.Equal(s.Root, contractState.Root)
+	require.Equal(big.NewInt(15), contractState.Balance)
+	executorState, err := sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(uint64(3), executorState.Nonce)
+	require.Equal(new(big.Int).Sub(big.NewInt(985), invoke.Fee()), executorState.Balance)
+	balance, err := sf.Balance(p.RawAddress)
+	require.NoError(err)
+	require.Equal(invoke.Fee(), balance)
+
+	// invoking an account without code only costs the fee
+	invoke, err = action.NewExecution(4, big.NewInt(5), a.RawAddress, p.RawAddress, input, 1000, big.NewInt(0))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(4, []action.Action{invoke}))
+	balance, err = sf.Balance(p.RawAddress)
+	require.NoError(err)
+	require.Equal(new(big.Int).SetUint64(invoke.IntrinsicGas()+5), balance)
+}
+
+func TestContractPruning(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	testutil.CleanupPath(t, testTriePath)
+	defer testutil.CleanupPath(t, testTriePath)
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTriePath
+	cfg.Chain.EnablePruning = true
+	sf, err := NewFactory(&cfg, DefaultTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(1000))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, nil))
+
+	// the contract stores the input under key 0x01
+	code := []byte{txvm.OpData1, 0x01, txvm.OpSStore}
+	deploy, err := action.NewExecution(1, big.NewInt(0), a.RawAddress, "", code, 1000, big.NewInt(0))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{deploy}))
+	contractAddress, err := iotxaddress.CreateContractAddress(a.RawAddress, 1)
+	require.NoError(err)
+	roots := make(map[uint64]hash.Hash32B)
+	for i := uint64(2); i <= 4; i++ {
+		input := []byte{txvm.OpData1, byte(i)}
+		invoke, err := action.NewExecution(i, big.NewInt(0), a.RawAddress, contractAddress, input, 1000, big.NewInt(0))
+		require.NoError(err)
+		require.NoError(sf.CommitStateChanges(i, []action.Action{invoke}))
+		s, err := sf.State(contractAddress)
+		require.NoError(err)
+		roots[i] = s.Root
+	}
+
+	// the storage of the contract before height 3 is dropped along with the states
+	dao := sf.(*factory).dao
+	require.NoError(sf.Prune(2))
+	_, err = trie.NewTrieSharedDB(dao, trie.ContractKVNameSpace, roots[2])
+	require.NoError(err)
+	require.NoError(sf.Prune(3))
+	_, err = trie.NewTrieSharedDB(dao, trie.ContractKVNameSpace, roots[2])
+	require.Error(err)
+	for i := uint64(3); i <= 4; i++ {
+		tr, err := trie.NewTrieSharedDB(dao, trie.ContractKVNameSpace, roots[i])
+		require.NoError(err)
+		k := blake2b.Sum256([]byte{0x01})
+		value, err := tr.Get(k[:])
+		require.NoError(err)
+		require.Equal([]byte{byte(i)}, value)
+	}
+	require.NoError(sf.(*factory).trie.Close())
+}
@@ -7,19 +7,28 @@
 package state
 
 import (
+	"bytes"
 	"container/heap"
+	"context"
+	"encoding/gob"
 	"math/big"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/golang/groupcache/lru"
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
 
 	"github.com/iotexproject/iotex-core/blockchain/action"
 	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/iotxaddress"
 	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/pkg/enc"
 	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
+	"github.com/iotexproject/iotex-core/pkg/version"
 	"github.com/iotexproject/iotex-core/trie"
 )
 
@@ -32,6 +41,17 @@ const (
 
 const candidateBufferSize = 100
 
+// stateMetaKVNameSpace is the bucket name for the metadata of the states, stored in the same DB as the state trie
+const stateMetaKVNameSpace = "StateMeta"
+
+var (
+	checkpointKey    = []byte("checkpoint")
+	candidatesPrefix = []byte("candidates.")
+	undoPrefix       = []byte("undo.")
+	// the candidates of the heights below it are pruned
+	candidatesPrunedKey = []byte("candidates-pruned")
+)
+
 var (
 	// ErrInvalidAddr is the error that the address format is invalid, cannot be decoded
 	ErrInvalidAddr = errors.New("address format is invalid")
@@ -39,6 +59,9 @@ var (
 	// ErrNotEnoughBalance is the error that the balance is not enough
 	ErrNotEnoughBalance = errors.New("not enough balance")
 
+	// ErrNotEnoughStake is the error that the bonded stake is not enough
+	ErrNotEnoughStake = errors.New("not enough stake")
+
 	// ErrAccountNotExist is the error that the account does not exist
 	ErrAccountNotExist = errors.New("the account does not exist")
 
@@ -47,6 +70,12 @@ var (
 
 	// ErrFailedToUnmarshalState is the error that the state un-marshaling is failed
 	ErrFailedToUnmarshalState = errors.New("failed to unmarshal state")
+
+	// ErrRollbackTooDeep is the error that the undo history does not reach back to the requested height
+	ErrRollbackTooDeep = errors.New("rollback height is beyond the undo history")
+
+	// ErrStatesNotAvailable is the error that the states of a root are not kept
+	ErrStatesNotAvailable = errors.New("the states are not available")
 )
 
 type (
@@ -54,19 +83,52 @@ type (
 	Factory interface {
 		CreateState(string, uint64) (*State, error)
 		Balance(string) (*big.Int, error)
-		CommitStateChanges(uint64, []*action.Transfer, []*action.Vote) error
+		CommitStateChanges(uint64, []action.Action) error
+		// RunActions returns the root hash of the states after applying the actions, without committing them
+		RunActions(uint64, []action.Action) (hash.Hash32B, error)
+		// AddActionHandlers registers the handlers applying the state changes of the actions, which are tried after the
+		// ones already registered
+		AddActionHandlers(...ActionHandler)
+		// Rollback reverts the states to the given height, the height must be within the undo history
+		Rollback(uint64) error
 		// Note that nonce starts with 1.
 		Nonce(string) (uint64, error)
 		State(string) (*State, error)
 		RootHash() hash.Hash32B
 		Candidates() (uint64, []*Candidate)
 		CandidatesByHeight(uint64) ([]*Candidate, bool)
+		// Height returns the height of the latest block committed to the states, false if no block is committed yet
+		Height() (uint64, bool)
+		// Prune drops the states before the given height, it does nothing unless pruning is enabled
+		Prune(uint64) error
+		// StateByRoot returns the state of an address in the states of the given root
+		StateByRoot(string, hash.Hash32B) (*State, error)
+		// StateProof returns the proof of the state of an address in the states of the given root
+		StateProof(string, hash.Hash32B) ([][]byte, error)
+		// AccountsByRoot calls the function with the address and the state of every account in the states of the given
+		// root, in the order of the public key hashes
+		AccountsByRoot(hash.Hash32B, func(string, *State) error) error
+		// CandidatesByRoot returns all the candidates in the states of the given root
+		CandidatesByRoot(hash.Hash32B) ([]*Candidate, error)
+		// PinSnapshot pins the states of the latest committed height for a snapshot, which is exported afterwards
+		PinSnapshot() (Snapshot, error)
+		// RestoreSnapshot replaces the states with the ones of a snapshot of the given root and metadata, whose chunks
+		// are returned by the function in order until it returns nil
+		RestoreSnapshot(hash.Hash32B, []byte, func() ([]byte, error)) error
+		// Receipts returns the receipts of the actions of the latest block committed by CommitStateChanges
+		Receipts() []*Receipt
+		// ActionReceipts returns the receipts of the actions applied on top of the current states, without committing
+		// them
+		ActionReceipts(uint64, []action.Action) ([]*Receipt, error)
+		// NodeCacheStats returns the size and the hit, miss and eviction counters of the cache of the trie nodes
+		NodeCacheStats() trie.CacheStats
 	}
 
 	// factory implements StateFactory interface, tracks changes in a map and batch-commits to trie/db
 	factory struct {
 		// candidate pool
 		currentChainHeight     uint64
+		committed              bool
 		candidatesLRU          *lru.Cache
 		candidateHeap          CandidateMinPQ
 		candidateBufferMinHeap CandidateMinPQ
@@ -75,6 +137,120 @@ type (
 		// accounts
 		cachedAccount map[string]*State // accounts being modified in this Tx
 		trie          trie.Trie         // global state trie
+		dao           db.KVStore        // the underlying DB of the state trie
+		nodeCache     *trie.NodeCache   // nodes of the state and the contract tries, nil if not cached
+		// contracts being modified in this Tx, whose code and storage are written to dao along with the accounts
+		cachedContract map[string]*contract
+		// with history enabled, the nodes put and made stale by the storage tries of the contracts are collected in
+		// contractTrie, which checkpoints and prunes them along with the state trie
+		contractTrie trie.Trie
+		// with history enabled, the states are persisted at each block and reopened on restart, and the states of the
+		// earlier blocks are kept until they are pruned
+		history bool
+		pruning bool
+		// undo history of the recent blocks
+		maxUndo     int
+		undoHistory []*undoRecord
+		pendingUndo *undoRecord
+		// handlers applying the state changes of the actions
+		handlers []ActionHandler
+		// with staked voting, only the bonded stake counts as voting weight instead of all the coins of the voter, since
+		// the activation of FeatureStakedVoting
+		stakedVoting bool
+		// the unstaked coins are released unbondingPeriod blocks after the unstake, at the beginning of the block of
+		// the release height. unbondings is the accounts with unstaked coins by release height
+		unbondingPeriod uint64
+		unbondings      map[uint64]map[string]struct{}
+		// blocks produced by each producer in the current epoch, whose reward is settled at the last block of the epoch
+		productivity       map[string]uint64
+		epochLength        uint64
+		epochReward        *big.Int
+		voterRewardPercent uint64
+		// receipts of the actions of the block being committed, and the accounts touched by the action being handled
+		// along with their balances and candidate weights before the action, at the height of the block
+		receipts      []*Receipt
+		touched       map[string]*accountChange
+		touchedHeight uint64
+		// protocol upgrades of the chain, an action requiring a feature is rejected before the feature is activated
+		schedule version.Schedule
+		// working set of the latest actions run by RunActions, which CommitStateChanges takes over when committing the
+		// same actions on top of the same states, instead of applying them again
+		validatedMu sync.Mutex
+		validated   *workingSet
+		// heights of the states pinned by the snapshots being exported, which are not pruned
+		pinMu  sync.Mutex
+		pinned map[uint64]int
+		// what the readers see of the latest committed block other than the trie, published along with the version of
+		// the trie. viewMu also guards candidatesLRU, which the readers access
+		viewMu sync.Mutex
+		view   *stateView
+	}
+
+	// stateView is the height, the candidates and the receipts of a committed block, which are not changed afterwards
+	stateView struct {
+		height     uint64
+		committed  bool
+		candidates []*Candidate
+		receipts   []*Receipt
+	}
+
+	// workingSet is the result of applying the actions of a block on top of the states of a root
+	workingSet struct {
+		height     uint64       // height of the block
+		prevHeight uint64       // chain height the actions are applied on
+		prevRoot   hash.Hash32B // trie root the actions are applied on
+		actsHash   hash.Hash32B // hash of the actions in order
+		ws         *factory
+	}
+
+	// accountChange keeps the balance and the candidate weight of an account before an action
+	accountChange struct {
+		balance *big.Int
+		weight  *big.Int
+	}
+
+	// undoRecord keeps what is needed to revert the state changes made by one block
+	undoRecord struct {
+		height     uint64            // height of the block that made the changes
+		prevHeight uint64            // chain height before the block
+		prevRoot   hash.Hash32B      // trie root before the block
+		accounts   map[string][]byte // serialized state before the block, nil if the account did not exist
+		candidates *candidateSnapshot
+		// productivity of the epoch before the block
+		productivity map[string]uint64
+	}
+
+	// undoEntry is the persisted undo record of a block, with the states before the block other than the accounts in a
+	// checkpoint
+	undoEntry struct {
+		Height   uint64
+		Accounts map[string][]byte
+		Prev     *checkpoint
+	}
+
+	// checkpoint is the persisted states of the latest committed block other than the trie, with the candidates in the
+	// pools referred by address
+	checkpoint struct {
+		Height     uint64
+		Root       hash.Hash32B
+		Candidates []*Candidate
+		Heap       []string
+		BufferMin  []string
+		BufferMax  []string
+		// Productivity is the blocks produced by each producer in the current epoch
+		Productivity map[string]uint64
+		// Unbonding is the accounts with unstaked coins by release height, which the earlier checkpoints do not have
+		// as UnbondingIndexed is not set
+		Unbonding        map[uint64][]string
+		UnbondingIndexed bool
+	}
+
+	// candidateSnapshot is a deep copy of the candidate pools
+	candidateSnapshot struct {
+		cached    map[string]*Candidate
+		heap      []*Candidate
+		bufferMin []*Candidate
+		bufferMax []*Candidate
 	}
 )
 
@@ -97,11 +273,13 @@ func DefaultTrieOption() FactoryOption {
 		if len(dbPath) == 0 {
 			return errors.New("Invalid empty trie db path")
 		}
-		tr, err := trie.NewTrie(dbPath, trie.AccountKVNameSpace, trie.EmptyRoot, false)
-		if err != nil {
+		dao := db.NewBoltDB(dbPath, nil)
+		if err := dao.Start(context.Background()); err != nil {
+			return errors.Wrapf(err, "Failed to start trie db")
+		}
+		if err := sf.openTrie(dao); err != nil {
 			return errors.Wrapf(err, "Failed to generate trie from config")
 		}
-		sf.trie = tr
 
 		return nil
 	}
@@ -110,11 +288,54 @@ func DefaultTrieOption() FactoryOption {
 // InMemTrieOption creates in memory trie for state factory
 func InMemTrieOption() FactoryOption {
 	return func(sf *factory, cfg *config.Config) error {
-		tr, err := trie.NewTrie("", trie.AccountKVNameSpace, trie.EmptyRoot, true)
-		if err != nil {
+		if err := sf.openTrie(db.NewMemKVStore()); err != nil {
 			return errors.Wrapf(err, "Failed to initialize in-memory trie")
 		}
-		sf.trie = tr
+
+		return nil
+	}
+}
+
+// PrecreatedDBOption creates trie on top of a pre-created and started DB for state factory
+func PrecreatedDBOption(dao db.KVStore) FactoryOption {
+	return func(sf *factory, cfg *config.Config) error {
+		if err := sf.openTrie(dao); err != nil {
+			return errors.Wrapf(err, "Failed to generate trie on pre-created db")
+		}
+
+		return nil
+	}
+}
+
+// ScheduleOption sets the protocol upgrade schedule of the chain
+func ScheduleOption(schedule version.Schedule) FactoryOption {
+	return func(sf *factory, cfg *config.Config) error {
+		sf.schedule = schedule
+
+		return nil
+	}
+}
+
+// StakingOption sets whether only the bonded stake counts as voting weight, and the number of blocks before the
+// unstaked coins are released
+func StakingOption(stakedVoting bool, unbondingPeriod uint64) FactoryOption {
+	return func(sf *factory, cfg *config.Config) error {
+		sf.stakedVoting = stakedVoting
+		sf.unbondingPeriod = unbondingPeriod
+
+		return nil
+	}
+}
+
+// EpochRewardOption sets the reward split among the block producers at the end of each epoch, and the percentage of
+// each producer's reward passed to its voters
+func EpochRewardOption(epochReward uint64, voterRewardPercent uint64) FactoryOption {
+	return func(sf *factory, cfg *config.Config) error {
+		if voterRewardPercent > 100 {
+			return errors.Errorf("voter reward percent %d is above 100", voterRewardPercent)
+		}
+		sf.epochReward = new(big.Int).SetUint64(epochReward)
+		sf.voterRewardPercent = voterRewardPercent
 
 		return nil
 	}
@@ -130,7 +351,24 @@ func NewFactory(cfg *config.Config, opts ...FactoryOption) (Factory, error) {
 		candidateBufferMaxHeap: CandidateMaxPQ{candidateBufferSize, make([]*Candidate, 0)},
 		cachedCandidate:        make(map[string]*Candidate),
 		cachedAccount:          make(map[string]*State),
+		cachedContract:         make(map[string]*contract),
+		maxUndo:                int(cfg.Chain.MaxReorgDepth),
+		history:                cfg.Chain.EnablePruning || cfg.Chain.EnableArchiveMode,
+		pruning:                cfg.Chain.EnablePruning,
+		unbondings:             make(map[uint64]map[string]struct{}),
+		productivity:           make(map[string]uint64),
+		pinned:                 make(map[uint64]int),
+		view:                   &stateView{},
 	}
+	if cfg.Chain.TrieNodeCacheSize > 0 {
+		sf.nodeCache = trie.NewNodeCache(int(cfg.Chain.TrieNodeCacheSize))
+	}
+	// the epochs are aligned with the ones of the delegates in RollDPoS
+	numSubEpochs := uint64(cfg.Consensus.RollDPoS.NumSubEpochs)
+	if numSubEpochs == 0 {
+		numSubEpochs = 1
+	}
+	sf.epochLength = uint64(cfg.Consensus.RollDPoS.NumDelegates) * numSubEpochs
 
 	for _, opt := range opts {
 		if err := opt(sf, cfg); err != nil {
@@ -138,6 +376,13 @@ func NewFactory(cfg *config.Config, opts ...FactoryOption) (Factory, error) {
 			return nil, err
 		}
 	}
+	voting := sf.votingRule()
+	sf.handlers = []ActionHandler{
+		transferHandler{voting: voting},
+		voteHandler{voting: voting},
+		executionHandler{voting: voting},
+		stakeHandler{voting: voting, unbondingPeriod: sf.unbondingPeriod},
+	}
 	return sf, nil
 }
 
@@ -151,17 +396,23 @@ func (sf *factory) CreateState(addr string, init uint64) (*State, error) {
 	weight := big.NewInt(0)
 	balance.SetUint64(init)
 	s := State{Balance: balance, VotingWeight: weight}
-	mstate, err := stateToBytes(&s)
+	mstate, err := sf.encodeState(sf.currentChainHeight, &s)
 	if err != nil {
 		return nil, err
 	}
+	if err := sf.indexBranches(sf.trie, sf.currentChainHeight); err != nil {
+		return nil, err
+	}
 	if err := sf.trie.Upsert(pubKeyHash, mstate); err != nil {
 		return nil, err
 	}
+	// drop the stale cached copy so it won't overwrite the new state at next commit
+	delete(sf.cachedAccount, addr)
+	sf.dropWorkingSet()
 	return &s, nil
 }
 
-// Balance returns balance
+// Balance returns the balance of the account, to which the unstaked coins are added at their release height
 func (sf *factory) Balance(addr string) (*big.Int, error) {
 	state, err := sf.getState(addr)
 	if err != nil {
@@ -190,19 +441,32 @@ func (sf *factory) RootHash() hash.Hash32B {
 }
 
 // CommitStateChanges updates a State from the given actions
-func (sf *factory) CommitStateChanges(blockHeight uint64, tsf []*action.Transfer, vote []*action.Vote) error {
-	if err := sf.handleTsf(tsf); err != nil {
-		return err
-	}
-	if err := sf.handleVote(blockHeight, vote); err != nil {
-		return err
+func (sf *factory) CommitStateChanges(blockHeight uint64, acts []action.Action) error {
+	defer func() { sf.pendingUndo = nil }()
+	prevRoot := sf.trie.RootHash()
+	if ws := sf.takeWorkingSet(blockHeight, acts); ws != nil {
+		// the actions are validated by RunActions on top of the same states, so their state changes are taken over
+		sf.adoptWorkingSet(ws)
+	} else {
+		sf.pendingUndo = sf.newUndoRecord(blockHeight)
+		sf.receipts = nil
+		if err := sf.handleActions(blockHeight, acts); err != nil {
+			return err
+		}
 	}
 
+	// the coins released by the block are no longer unbonding
+	for height := range sf.unbondings {
+		if height <= blockHeight {
+			delete(sf.unbondings, height)
+		}
+	}
 	// construct <k, v> list of pending state
 	transferK := [][]byte{}
 	transferV := [][]byte{}
 	for address, state := range sf.cachedAccount {
-		ss, err := stateToBytes(state)
+		sf.indexUnbondings(address, state)
+		ss, err := sf.encodeState(blockHeight, state)
 		if err != nil {
 			return err
 		}
@@ -221,58 +485,240 @@ func (sf *factory) CommitStateChanges(blockHeight uint64, tsf []*action.Transfer
 			sf.removeCandidate(address)
 			continue
 		}
-		totalWeight := big.NewInt(0)
-		totalWeight.Add(totalWeight, state.VotingWeight)
-		if state.Votee == address {
-			totalWeight.Add(totalWeight, state.Balance)
-		}
-		sf.updateCandidate(address, totalWeight, blockHeight)
+		sf.updateCandidate(address, candidateWeight(address, state, sf.votingRule().stakedAt(blockHeight)), blockHeight)
+	}
+	legacyK, legacyV, err := sf.convertLegacyStates(blockHeight, prevRoot)
+	if err != nil {
+		return err
 	}
+	transferK = append(transferK, legacyK...)
+	transferV = append(transferV, legacyV...)
 	sf.currentChainHeight = blockHeight
-	candidates := sf.candidateHeap.CandidateList()
-	sort.Slice(candidates, func(i, j int) bool {
-		if candidates[i].Votes.Cmp(candidates[j].Votes) == 0 {
-			return strings.Compare(candidates[i].Address, candidates[j].Address) < 0
+	sf.committed = true
+
+	// commit the code and storage of the contracts before the accounts referring to them. The contracts are dropped
+	// even if the commit fails, and reloaded from DB next time they are used
+	contracts := sf.cachedContract
+	sf.cachedContract = make(map[string]*contract)
+	for address, c := range contracts {
+		if err := c.commit(); err != nil {
+			return errors.Wrapf(err, "failed to commit contract %s", address)
 		}
-		return candidates[i].Votes.Cmp(candidates[j].Votes) < 0
-	})
-	sf.candidatesLRU.Add(sf.currentChainHeight, candidates)
+		if sf.contractTrie == nil {
+			continue
+		}
+		if err := trie.TakeHistory(sf.contractTrie, c.trie); err != nil {
+			return errors.Wrapf(err, "failed to take storage history of contract %s", address)
+		}
+	}
+	// commit the state changes to Trie in a batch, with the branches hashed the way of the block
+	if err := sf.indexBranches(sf.trie, blockHeight); err != nil {
+		return err
+	}
+	if err := sf.trie.Commit(transferK, transferV); err != nil {
+		return err
+	}
+	if sf.pendingUndo != nil {
+		sf.undoHistory = append(sf.undoHistory, sf.pendingUndo)
+		if len(sf.undoHistory) > sf.maxUndo {
+			sf.undoHistory = sf.undoHistory[len(sf.undoHistory)-sf.maxUndo:]
+		}
+	}
+	if sf.history {
+		if err := sf.checkpointTries(blockHeight); err != nil {
+			return err
+		}
+		if err := sf.saveCheckpoint(); err != nil {
+			return err
+		}
+	}
+	sf.publishView()
+	return nil
+}
+
+// Rollback reverts the states to the given height using the undo history of the recent blocks
+func (sf *factory) Rollback(height uint64) error {
+	sf.dropWorkingSet()
+	if height > sf.currentChainHeight {
+		return errors.Errorf("cannot rollback to height %d above current height %d", height, sf.currentChainHeight)
+	}
+	// find the oldest block to revert
+	i := len(sf.undoHistory)
+	for i > 0 && sf.undoHistory[i-1].height > height {
+		i--
+	}
+	if i == 0 {
+		oldest := sf.currentChainHeight
+		if len(sf.undoHistory) > 0 {
+			oldest = sf.undoHistory[0].prevHeight
+		}
+		if oldest > height {
+			return errors.Wrapf(ErrRollbackTooDeep, "oldest height = %d, rollback height = %d", oldest, height)
+		}
+	}
+	for len(sf.undoHistory) > i {
+		last := len(sf.undoHistory) - 1
+		if err := sf.undo(sf.undoHistory[last]); err != nil {
+			return errors.Wrapf(err, "failed to revert block %d", sf.undoHistory[last].height)
+		}
+		sf.undoHistory = sf.undoHistory[:last]
+	}
+	if !sf.history {
+		return nil
+	}
+	if err := sf.checkpointTries(sf.currentChainHeight); err != nil {
+		return err
+	}
+	return sf.saveCheckpoint()
+}
+
+// RunActions applies the actions on top of the current states in a scratch trie sharing the same DB, and returns the
+// root hash of the resulting states. Neither the trie nor the cached accounts and candidates of sf are changed. The
+// resulting working set is kept, so running the same actions again or committing them does not apply them again
+func (sf *factory) RunActions(blockHeight uint64, acts []action.Action) (hash.Hash32B, error) {
+	ws, err := sf.runActions(blockHeight, acts)
+	if err != nil {
+		return hash.ZeroHash32B, err
+	}
+	return ws.trie.RootHash(), nil
+}
+
+// ActionReceipts returns the receipts of the actions run on top of the current states as RunActions does
+func (sf *factory) ActionReceipts(blockHeight uint64, acts []action.Action) ([]*Receipt, error) {
+	ws, err := sf.runActions(blockHeight, acts)
+	if err != nil {
+		return nil, err
+	}
+	return ws.receipts, nil
+}
+
+// Receipts returns the receipts of the actions of the latest committed block
+func (sf *factory) Receipts() []*Receipt {
+	return sf.latestView().receipts
+}
+
+// NodeCacheStats returns the size and the counters of the cache of the trie nodes, which are empty if not cached
+func (sf *factory) NodeCacheStats() trie.CacheStats {
+	return sf.nodeCache.Stats()
+}
+
+// AddActionHandlers registers the handlers applying the state changes of the actions
+func (sf *factory) AddActionHandlers(handlers ...ActionHandler) {
+	sf.handlers = append(sf.handlers, handlers...)
+	sf.dropWorkingSet()
+}
+
+// CachedState returns the state of an address being modified by the actions
+func (sf *factory) CachedState(addr string) (*State, error) {
+	return sf.cache(addr)
+}
+
+// CachedContract returns the contract of an address being modified by the actions
+func (sf *factory) CachedContract(addr string) (Contract, error) {
+	if c, ok := sf.cachedContract[addr]; ok {
+		return c, nil
+	}
+	if sf.dao == nil {
+		return nil, errors.New("state trie does not have an underlying DB to store contracts")
+	}
+	state, err := sf.cache(addr)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newContract(state, sf.dao, sf.nodeCache)
+	if err != nil {
+		return nil, err
+	}
+	sf.cachedContract[addr] = c
+	return c, nil
+}
 
-	// commit the state changes to Trie in a batch
-	return sf.trie.Commit(transferK, transferV)
+// AddCandidate adds an address as a candidate nominated at the given height, if it is not a candidate yet
+func (sf *factory) AddCandidate(addr string, pubKey []byte, blockHeight uint64) {
+	if _, ok := sf.cachedCandidate[addr]; ok {
+		return
+	}
+	sf.cachedCandidate[addr] = &Candidate{
+		Address:        addr,
+		PubKey:         pubKey,
+		CreationHeight: blockHeight,
+		minIndex:       0,
+		maxIndex:       0,
+	}
 }
 
-// Candidates returns array of candidates in candidate pool
+// Candidates returns array of candidates in candidate pool as of the latest committed block
 func (sf *factory) Candidates() (uint64, []*Candidate) {
-	return sf.currentChainHeight, sf.candidateHeap.CandidateList()
+	v := sf.latestView()
+	return v.height, append([]*Candidate{}, v.candidates...)
 }
 
 // CandidatesByHeight returns array of candidates in candidate pool of a given height
 func (sf *factory) CandidatesByHeight(height uint64) ([]*Candidate, bool) {
-	if candidates, ok := sf.candidatesLRU.Get(height); ok {
+	sf.viewMu.Lock()
+	candidates, ok := sf.candidatesLRU.Get(height)
+	v := sf.view
+	sf.viewMu.Unlock()
+	if ok {
 		return candidates.([]*Candidate), ok
 	}
+	if sf.history && height <= v.height {
+		// the candidates of the heights evicted from the cache are persisted along with the states
+		if candidates, err := sf.loadCandidates(height); err == nil {
+			return candidates, true
+		}
+	}
 	return []*Candidate{}, false
 }
 
-//======================================
-// private functions
-//=====================================
-func (sf *factory) candidatesBuffer() (uint64, []*Candidate) {
-	return sf.currentChainHeight, sf.candidateBufferMinHeap.CandidateList()
+// Height returns the height of the latest block committed to the states
+func (sf *factory) Height() (uint64, bool) {
+	v := sf.latestView()
+	return v.height, v.committed
 }
 
-// getState pulls an existing State
-func (sf *factory) getState(addr string) (*State, error) {
-	pubKeyHash := iotxaddress.GetPubkeyHash(addr)
-	return sf.getStateFromPKHash(pubKeyHash)
+// Prune drops the states and the candidates before the given height, which is a no-op unless pruning is enabled. The
+// states pinned by a snapshot and the ones after them are kept until the snapshot is released
+func (sf *factory) Prune(height uint64) error {
+	if !sf.pruning {
+		return nil
+	}
+	sf.pinMu.Lock()
+	for pinned := range sf.pinned {
+		if pinned < height {
+			height = pinned
+		}
+	}
+	sf.pinMu.Unlock()
+	if err := sf.trie.Prune(height); err != nil {
+		return err
+	}
+	if sf.contractTrie != nil {
+		if err := sf.contractTrie.Prune(height); err != nil {
+			return errors.Wrap(err, "failed to prune the storage tries of the contracts")
+		}
+	}
+	return sf.pruneCandidates(height)
 }
 
-func (sf *factory) getStateFromPKHash(pubKeyHash []byte) (*State, error) {
+// StateByRoot returns the state of an address in the states of the given root, the states of an earlier block are only
+// available with history enabled, until they are pruned
+func (sf *factory) StateByRoot(addr string, root hash.Hash32B) (*State, error) {
+	if root == sf.trie.RootHash() {
+		return sf.getState(addr)
+	}
+	if !sf.history {
+		return nil, errors.Wrapf(ErrStatesNotAvailable, "root = %x", root[:8])
+	}
+	tr, err := trie.NewTrieSharedDB(sf.dao, trie.AccountKVNameSpace, root, trie.NodeCacheOption(sf.nodeCache))
+	if err != nil {
+		return nil, errors.Wrapf(ErrStatesNotAvailable, "root = %x: %v", root[:8], err)
+	}
+	pubKeyHash := iotxaddress.GetPubkeyHash(addr)
 	if pubKeyHash == nil {
 		return nil, ErrInvalidAddr
 	}
-	mstate, err := sf.trie.Get(pubKeyHash)
+	mstate, err := tr.Get(pubKeyHash)
 	if errors.Cause(err) == trie.ErrNotExist {
 		return nil, ErrAccountNotExist
 	}
@@ -282,216 +728,1328 @@ func (sf *factory) getStateFromPKHash(pubKeyHash []byte) (*State, error) {
 	return bytesToState(mstate)
 }
 
-func (sf *factory) updateCandidate(address string, totalWeight *big.Int, blockHeight uint64) {
-	// Candidate was added when self-nomination, always exist in cached candidate
-	candidate, _ := sf.cachedCandidate[address]
-	candidate.Votes = totalWeight
-	candidate.LastUpdateHeight = blockHeight
-	_, level := sf.inPool(candidate.Address)
-	switch level {
-	case candidatePool:
-		// if candidate is already in candidate pool
-		sf.candidateHeap.update(candidate, candidate.Votes)
-	case candidateBufferPool:
-		// if candidate is already in candidate buffer pool
-		sf.candidateBufferMinHeap.update(candidate, candidate.Votes)
-		sf.candidateBufferMaxHeap.update(candidate, candidate.Votes)
-	default:
-		// candidate is not in any of two pools
-		transitCandidate := candidate
-		if sf.candidateHeap.shouldTake(transitCandidate.Votes) {
-			// Push candidate into candidate pool
-			heap.Push(&sf.candidateHeap, transitCandidate)
-			transitCandidate = nil
-			if sf.candidateHeap.Len() > sf.candidateHeap.Capacity {
-				transitCandidate = heap.Pop(&sf.candidateHeap).(*Candidate)
-			}
-		}
-		if transitCandidate != nil && sf.candidateBufferMinHeap.shouldTake(transitCandidate.Votes) {
-			// Push candidate into candidate pool
-			heap.Push(&sf.candidateBufferMinHeap, transitCandidate)
-			heap.Push(&sf.candidateBufferMaxHeap, transitCandidate)
-			transitCandidate = nil
-			if sf.candidateBufferMinHeap.Len() > sf.candidateBufferMinHeap.Capacity {
-				transitCandidate = heap.Pop(&sf.candidateBufferMinHeap).(*Candidate)
-				heap.Remove(&sf.candidateBufferMaxHeap, transitCandidate.maxIndex)
-			}
-		}
+// StateProof returns the trie nodes on the path from the given root to the state of an address, or proving that the
+// address does not exist, which can be verified by VerifyStateProof against the state root of a block header. Like
+// StateByRoot, the proof of an earlier block is only available with history enabled
+func (sf *factory) StateProof(addr string, root hash.Hash32B) ([][]byte, error) {
+	if root != sf.trie.RootHash() && !sf.history {
+		return nil, errors.Wrapf(ErrStatesNotAvailable, "root = %x", root[:8])
 	}
-	sf.balance()
-
-	// Temporarily leave it here to check the algorithm is correct
-	if sf.candidateBufferMinHeap.Len() != sf.candidateBufferMaxHeap.Len() {
-		logger.Warn().Msg("candidateBuffer min and max heap not sync")
+	if sf.dao == nil {
+		return nil, errors.Wrap(ErrStatesNotAvailable, "the state trie has no DB")
+	}
+	pubKeyHash := iotxaddress.GetPubkeyHash(addr)
+	if pubKeyHash == nil {
+		return nil, ErrInvalidAddr
 	}
+	return trie.Prove(sf.dao, trie.AccountKVNameSpace, root, pubKeyHash)
 }
 
-func (sf *factory) removeCandidate(address string) {
-	c, level := sf.inPool(address)
-	switch level {
-	case candidatePool:
-		heap.Remove(&sf.candidateHeap, c.minIndex)
-		if sf.candidateBufferMinHeap.Len() > 0 {
-			promoteCandidate := heap.Pop(&sf.candidateBufferMaxHeap).(*Candidate)
-			heap.Remove(&sf.candidateBufferMinHeap, promoteCandidate.minIndex)
-			heap.Push(&sf.candidateHeap, promoteCandidate)
-		}
-	case candidateBufferPool:
-		heap.Remove(&sf.candidateBufferMinHeap, c.minIndex)
-		heap.Remove(&sf.candidateBufferMaxHeap, c.maxIndex)
-	default:
-		break
+// AccountsByRoot iterates over the accounts in the states of the given root. The addresses are derived from the public
+// key hashes in the trie with the address params of the chain. Like StateByRoot, the states of an earlier block are only
+// available with history enabled
+func (sf *factory) AccountsByRoot(root hash.Hash32B, fn func(string, *State) error) error {
+	if root != sf.trie.RootHash() && !sf.history {
+		return errors.Wrapf(ErrStatesNotAvailable, "root = %x", root[:8])
 	}
-	sf.balance()
-
-	// Temporarily leave it here to check the algorithm is correct
-	if sf.candidateBufferMinHeap.Len() != sf.candidateBufferMaxHeap.Len() {
-		logger.Warn().Msg("candidateBuffer min and max heap not sync")
+	if sf.dao == nil {
+		return errors.Wrap(ErrStatesNotAvailable, "the state trie has no DB")
+	}
+	it, err := trie.NewIterator(sf.dao, trie.AccountKVNameSpace, root, nil)
+	if err != nil {
+		return errors.Wrapf(ErrStatesNotAvailable, "root = %x: %v", root[:8], err)
+	}
+	for it.Next() {
+		addr, err := iotxaddress.GetAddressByHash(it.Key(), iotxaddress.IsTestnet, iotxaddress.ChainID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get address of %x", it.Key())
+		}
+		state, err := bytesToState(it.Value())
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode state of %s", addr)
+		}
+		if err := fn(addr, state); err != nil {
+			return err
+		}
 	}
+	return it.Error()
 }
 
-func (sf *factory) balance() {
-	if sf.candidateHeap.Len() > 0 && sf.candidateBufferMaxHeap.Len() > 0 && sf.candidateHeap.Top().(*Candidate).Votes.Cmp(sf.candidateBufferMaxHeap.Top().(*Candidate).Votes) < 0 {
-		cFromCandidatePool := heap.Pop(&sf.candidateHeap).(*Candidate)
-		cFromCandidateBufferPool := heap.Pop(&sf.candidateBufferMaxHeap).(*Candidate)
-		heap.Remove(&sf.candidateBufferMinHeap, cFromCandidateBufferPool.minIndex)
-		heap.Push(&sf.candidateHeap, cFromCandidateBufferPool)
-		heap.Push(&sf.candidateBufferMinHeap, cFromCandidatePool)
-		heap.Push(&sf.candidateBufferMaxHeap, cFromCandidatePool)
+// CandidatesByRoot returns all the candidates in the states of the given root sorted by votes and then address, not
+// only the ones in the candidate pool. The public keys and the heights of the candidates are not kept in the states, and
+// the voting rule in effect is the one of the current height
+func (sf *factory) CandidatesByRoot(root hash.Hash32B) ([]*Candidate, error) {
+	stakedVoting := sf.votingRule().stakedAt(sf.currentChainHeight)
+	candidates := []*Candidate{}
+	if err := sf.AccountsByRoot(root, func(addr string, state *State) error {
+		if !state.IsCandidate {
+			return nil
+		}
+		candidates = append(candidates, &Candidate{
+			Address: addr,
+			Votes:   candidateWeight(addr, state, stakedVoting),
+		})
+		return nil
+	}); err != nil {
+		return nil, err
 	}
+	sortCandidates(candidates)
+	return candidates, nil
 }
 
-func (sf *factory) inPool(address string) (*Candidate, int) {
-	if c := sf.candidateHeap.exist(address); c != nil {
-		return c, candidatePool // The candidate exists in the Candidate pool
+// VerifyStateProof checks the proof of the state of an address against a state root, and returns the state, or
+// ErrAccountNotExist if the proof is of the absence of the address. indexed tells whether FeatureBranchIndex is in
+// effect at the height of the state root, a proof of the states before it can be forged
+func VerifyStateProof(root hash.Hash32B, addr string, proof [][]byte, indexed bool) (*State, error) {
+	pubKeyHash := iotxaddress.GetPubkeyHash(addr)
+	if pubKeyHash == nil {
+		return nil, ErrInvalidAddr
 	}
-	if c := sf.candidateBufferMinHeap.exist(address); c != nil {
-		return c, candidateBufferPool // The candidate exists in the Candidate buffer pool
+	mstate, err := trie.VerifyProof(root, pubKeyHash, proof, indexed)
+	if errors.Cause(err) == trie.ErrNotExist {
+		return nil, errors.Wrapf(ErrAccountNotExist, "address = %s", addr)
 	}
-	return nil, 0
-}
-
+	if err != nil {
+		return nil, err
+	}
+	return bytesToState(mstate)
+}
+
+//======================================
+// private functions
+//=====================================
+// openTrie creates the state trie on top of dao. With history enabled, the trie keeps the stale nodes until they are
+// pruned, and is reopened at the states persisted by the last run
+func (sf *factory) openTrie(dao db.KVStore) error {
+	sf.dao = dao
+	if !sf.history {
+		// the states are replayed from genesis into the trie, which puts back every node still needed, so the stale
+		// nodes a crash left behind are deleted when the trie is opened
+		tr, err := trie.NewTrieSharedDB(
+			dao,
+			trie.AccountKVNameSpace,
+			trie.EmptyRoot,
+			trie.NodeCacheOption(sf.nodeCache),
+			trie.RecordDeferredOption(),
+		)
+		if err != nil {
+			return err
+		}
+		sf.trie = tr
+		return nil
+	}
+	cp, err := sf.loadCheckpoint()
+	if err != nil {
+		return err
+	}
+	root := trie.EmptyRoot
+	if cp != nil {
+		root = cp.Root
+	}
+	tr, err := trie.NewTrieWithHistory(dao, trie.AccountKVNameSpace, root, trie.NodeCacheOption(sf.nodeCache))
+	if err != nil {
+		return err
+	}
+	sf.trie = tr
+	// the trie only collects the histories of the storage tries, so it is never written to
+	if sf.contractTrie, err = trie.NewTrieWithHistory(
+		dao,
+		trie.ContractKVNameSpace,
+		trie.EmptyRoot,
+		trie.NodeCacheOption(sf.nodeCache),
+	); err != nil {
+		return err
+	}
+	if cp == nil {
+		return nil
+	}
+	if err := sf.restoreCheckpoint(cp); err != nil {
+		return err
+	}
+	return sf.loadUndoHistory()
+}
+
+// checkpointTries saves the histories of the state trie and the storage tries of the contracts at the given height
+func (sf *factory) checkpointTries(height uint64) error {
+	if err := sf.trie.Checkpoint(height); err != nil {
+		return errors.Wrapf(err, "failed to checkpoint the state trie at height %d", height)
+	}
+	if sf.contractTrie == nil {
+		return nil
+	}
+	if err := sf.contractTrie.Checkpoint(height); err != nil {
+		return errors.Wrapf(err, "failed to checkpoint the storage tries at height %d", height)
+	}
+	return nil
+}
+
+// saveCheckpoint persists the height and the candidates along with the root of the states, and the undo record of the
+// latest block
+func (sf *factory) saveCheckpoint() error {
+	if sf.dao == nil {
+		return nil
+	}
+	cp := sf.checkpoint()
+	var stream bytes.Buffer
+	if err := gob.NewEncoder(&stream).Encode(cp); err != nil {
+		return errors.Wrap(err, "failed to encode state checkpoint")
+	}
+	var candidates bytes.Buffer
+	if err := gob.NewEncoder(&candidates).Encode(sf.sortedCandidates()); err != nil {
+		return errors.Wrap(err, "failed to encode candidates")
+	}
+	batch := sf.dao.Batch()
+	batch.Put(stateMetaKVNameSpace, checkpointKey, stream.Bytes(), "failed to put state checkpoint")
+	batch.Put(stateMetaKVNameSpace, candidatesKey(cp.Height), candidates.Bytes(), "failed to put candidates of height %d", cp.Height)
+	if err := sf.putUndoHistory(batch); err != nil {
+		return err
+	}
+	if err := batch.Commit(); err != nil {
+		return errors.Wrap(err, "failed to save state checkpoint")
+	}
+	return nil
+}
+
+// putUndoHistory puts the undo record of the latest block into the batch, and deletes the one falling out of the undo
+// history, so the states can still be reverted after restart
+func (sf *factory) putUndoHistory(batch db.KVStoreBatch) error {
+	if sf.maxUndo == 0 {
+		return nil
+	}
+	if n := len(sf.undoHistory); n > 0 && sf.undoHistory[n-1].height == sf.currentChainHeight {
+		record := sf.undoHistory[n-1]
+		entry := undoEntry{
+			Height:   record.height,
+			Accounts: record.accounts,
+			Prev: &checkpoint{
+				Height:       record.prevHeight,
+				Root:         record.prevRoot,
+				Heap:         candidateAddresses(record.candidates.heap),
+				BufferMin:    candidateAddresses(record.candidates.bufferMin),
+				BufferMax:    candidateAddresses(record.candidates.bufferMax),
+				Productivity: record.productivity,
+			},
+		}
+		for _, c := range record.candidates.cached {
+			entry.Prev.Candidates = append(entry.Prev.Candidates, c)
+		}
+		var stream bytes.Buffer
+		if err := gob.NewEncoder(&stream).Encode(&entry); err != nil {
+			return errors.Wrapf(err, "failed to encode undo record of height %d", record.height)
+		}
+		batch.Put(stateMetaKVNameSpace, undoKey(record.height), stream.Bytes(), "failed to put undo record of height %d", record.height)
+	}
+	if sf.currentChainHeight >= uint64(sf.maxUndo) {
+		height := sf.currentChainHeight - uint64(sf.maxUndo)
+		batch.Delete(stateMetaKVNameSpace, undoKey(height), "failed to delete undo record of height %d", height)
+	}
+	return nil
+}
+
+// loadUndoHistory loads the persisted undo records of the blocks up to the current height
+func (sf *factory) loadUndoHistory() error {
+	sf.undoHistory = nil
+	height := sf.currentChainHeight
+	for len(sf.undoHistory) < sf.maxUndo {
+		value, err := sf.dao.Get(stateMetaKVNameSpace, undoKey(height))
+		if errors.Cause(err) == db.ErrNotExist {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to get undo record of height %d", height)
+		}
+		entry := &undoEntry{}
+		if err := gob.NewDecoder(bytes.NewBuffer(value)).Decode(entry); err != nil {
+			return errors.Wrapf(err, "failed to decode undo record of height %d", height)
+		}
+		candidates, err := entry.Prev.candidates()
+		if err != nil {
+			return errors.Wrapf(err, "failed to restore candidates of undo record of height %d", height)
+		}
+		record := &undoRecord{
+			height:       entry.Height,
+			prevHeight:   entry.Prev.Height,
+			prevRoot:     entry.Prev.Root,
+			accounts:     entry.Accounts,
+			candidates:   candidates,
+			productivity: entry.Prev.Productivity,
+		}
+		if record.accounts == nil {
+			record.accounts = make(map[string][]byte)
+		}
+		if record.productivity == nil {
+			record.productivity = make(map[string]uint64)
+		}
+		sf.undoHistory = append([]*undoRecord{record}, sf.undoHistory...)
+		if record.height == 0 || record.prevHeight != record.height-1 {
+			break
+		}
+		height = record.prevHeight
+	}
+	return nil
+}
+
+func undoKey(height uint64) []byte {
+	return append(undoPrefix, byteutil.Uint64ToBytes(height)...)
+}
+
+// candidateAddresses returns the addresses of the candidates
+func candidateAddresses(candidates []*Candidate) []string {
+	addresses := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		addresses = append(addresses, c.Address)
+	}
+	return addresses
+}
+
+// checkpoint returns the height, the root and the candidates of the current states
+func (sf *factory) checkpoint() *checkpoint {
+	cp := checkpoint{
+		Height:           sf.currentChainHeight,
+		Root:             sf.trie.RootHash(),
+		Productivity:     sf.productivity,
+		Unbonding:        make(map[uint64][]string),
+		UnbondingIndexed: true,
+	}
+	for height, accounts := range sf.unbondings {
+		for address := range accounts {
+			cp.Unbonding[height] = append(cp.Unbonding[height], address)
+		}
+	}
+	for _, c := range sf.cachedCandidate {
+		cp.Candidates = append(cp.Candidates, c)
+	}
+	for _, c := range sf.candidateHeap.pq {
+		cp.Heap = append(cp.Heap, c.Address)
+	}
+	for _, c := range sf.candidateBufferMinHeap.pq {
+		cp.BufferMin = append(cp.BufferMin, c.Address)
+	}
+	for _, c := range sf.candidateBufferMaxHeap.pq {
+		cp.BufferMax = append(cp.BufferMax, c.Address)
+	}
+	return &cp
+}
+
+// loadCandidates returns the persisted candidate list of the given height
+func (sf *factory) loadCandidates(height uint64) ([]*Candidate, error) {
+	value, err := sf.dao.Get(stateMetaKVNameSpace, candidatesKey(height))
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*Candidate
+	if err := gob.NewDecoder(bytes.NewBuffer(value)).Decode(&candidates); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode candidates of height %d", height)
+	}
+	if candidates == nil {
+		candidates = []*Candidate{}
+	}
+	return candidates, nil
+}
+
+// pruneCandidates deletes the persisted candidate lists of the heights before the given one
+func (sf *factory) pruneCandidates(height uint64) error {
+	start := uint64(0)
+	value, err := sf.dao.Get(stateMetaKVNameSpace, candidatesPrunedKey)
+	switch {
+	case err == nil && len(value) == 8:
+		start = enc.MachineEndian.Uint64(value)
+	case err != nil && errors.Cause(err) != db.ErrNotExist:
+		return errors.Wrap(err, "failed to get pruned height of candidates")
+	}
+	if start >= height {
+		return nil
+	}
+	batch := sf.dao.Batch()
+	sf.viewMu.Lock()
+	for h := start; h < height; h++ {
+		batch.Delete(stateMetaKVNameSpace, candidatesKey(h), "failed to delete candidates of height %d", h)
+		sf.candidatesLRU.Remove(h)
+	}
+	sf.viewMu.Unlock()
+	batch.Put(stateMetaKVNameSpace, candidatesPrunedKey, byteutil.Uint64ToBytes(height), "failed to put pruned height of candidates")
+	return batch.Commit()
+}
+
+func candidatesKey(height uint64) []byte {
+	return append(candidatesPrefix, byteutil.Uint64ToBytes(height)...)
+}
+
+// loadCheckpoint returns the persisted checkpoint, or nil if there is none
+func (sf *factory) loadCheckpoint() (*checkpoint, error) {
+	value, err := sf.dao.Get(stateMetaKVNameSpace, checkpointKey)
+	if errors.Cause(err) == db.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get state checkpoint")
+	}
+	cp := &checkpoint{}
+	if err := gob.NewDecoder(bytes.NewBuffer(value)).Decode(cp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode state checkpoint")
+	}
+	return cp, nil
+}
+
+// restoreCheckpoint restores the height and the candidates from a checkpoint
+func (sf *factory) restoreCheckpoint(cp *checkpoint) error {
+	candidates, err := cp.candidates()
+	if err != nil {
+		return err
+	}
+	for address, c := range candidates.cached {
+		sf.cachedCandidate[address] = c
+	}
+	sf.candidateHeap.pq = candidates.heap
+	sf.candidateBufferMinHeap.pq = candidates.bufferMin
+	sf.candidateBufferMaxHeap.pq = candidates.bufferMax
+	if cp.Productivity != nil {
+		sf.productivity = cp.Productivity
+	}
+	sf.unbondings = make(map[uint64]map[string]struct{})
+	if cp.UnbondingIndexed {
+		for height, accounts := range cp.Unbonding {
+			for _, address := range accounts {
+				sf.indexUnbonding(address, height)
+			}
+		}
+	} else if err := sf.AccountsByRoot(sf.trie.RootHash(), func(address string, state *State) error {
+		sf.indexUnbondings(address, state)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "failed to index the unbonding accounts")
+	}
+	sf.currentChainHeight = cp.Height
+	sf.committed = true
+	sf.publishView()
+	return nil
+}
+
+// candidates returns the candidate pools in the checkpoint, with the candidates in the pools referred by address
+func (cp *checkpoint) candidates() (*candidateSnapshot, error) {
+	snapshot := &candidateSnapshot{cached: make(map[string]*Candidate)}
+	for _, c := range cp.Candidates {
+		snapshot.cached[c.Address] = c
+	}
+	pool := func(addresses []string, setIndex func(*Candidate, int)) ([]*Candidate, error) {
+		pq := make([]*Candidate, 0, len(addresses))
+		for i, address := range addresses {
+			c, ok := snapshot.cached[address]
+			if !ok {
+				return nil, errors.Errorf("candidate %s in the pool is missing", address)
+			}
+			setIndex(c, i)
+			pq = append(pq, c)
+		}
+		return pq, nil
+	}
+	var err error
+	if snapshot.heap, err = pool(cp.Heap, func(c *Candidate, i int) { c.minIndex = i }); err != nil {
+		return nil, err
+	}
+	if snapshot.bufferMin, err = pool(cp.BufferMin, func(c *Candidate, i int) { c.minIndex = i }); err != nil {
+		return nil, err
+	}
+	if snapshot.bufferMax, err = pool(cp.BufferMax, func(c *Candidate, i int) { c.maxIndex = i }); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// sortedCandidates returns the candidates in the pool sorted by votes and then address
+func (sf *factory) sortedCandidates() []*Candidate {
+	candidates := sf.candidateHeap.CandidateList()
+	sortCandidates(candidates)
+	return candidates
+}
+
+// sortCandidates sorts the candidates by votes and then address
+func sortCandidates(candidates []*Candidate) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Votes.Cmp(candidates[j].Votes) == 0 {
+			return strings.Compare(candidates[i].Address, candidates[j].Address) < 0
+		}
+		return candidates[i].Votes.Cmp(candidates[j].Votes) < 0
+	})
+}
+
+func (sf *factory) candidatesBuffer() (uint64, []*Candidate) {
+	return sf.currentChainHeight, sf.candidateBufferMinHeap.CandidateList()
+}
+
+// getState pulls an existing State from a pinned version of the trie, so it reads the latest committed states without
+// waiting for the states being committed
+func (sf *factory) getState(addr string) (*State, error) {
+	r := sf.trie.Pin()
+	defer r.Release()
+	return loadState(r.Get, iotxaddress.GetPubkeyHash(addr))
+}
+
+// getStateFromPKHash pulls an existing State from the trie, including the changes not committed yet
+func (sf *factory) getStateFromPKHash(pubKeyHash []byte) (*State, error) {
+	return loadState(sf.trie.Get, pubKeyHash)
+}
+
+func loadState(get func([]byte) ([]byte, error), pubKeyHash []byte) (*State, error) {
+	if pubKeyHash == nil {
+		return nil, ErrInvalidAddr
+	}
+	mstate, err := get(pubKeyHash)
+	if errors.Cause(err) == trie.ErrNotExist {
+		return nil, ErrAccountNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytesToState(mstate)
+}
+
+func (sf *factory) updateCandidate(address string, totalWeight *big.Int, blockHeight uint64) {
+	// Candidate was added when self-nomination, always exist in cached candidate
+	candidate, _ := sf.cachedCandidate[address]
+	candidate.Votes = totalWeight
+	candidate.LastUpdateHeight = blockHeight
+	_, level := sf.inPool(candidate.Address)
+	switch level {
+	case candidatePool:
+		// if candidate is already in candidate pool
+		sf.candidateHeap.update(candidate, candidate.Votes)
+	case candidateBufferPool:
+		// if candidate is already in candidate buffer pool
+		sf.candidateBufferMinHeap.update(candidate, candidate.Votes)
+		sf.candidateBufferMaxHeap.update(candidate, candidate.Votes)
+	default:
+		// candidate is not in any of two pools
+		transitCandidate := candidate
+		if sf.candidateHeap.shouldTake(transitCandidate.Votes) {
+			// Push candidate into candidate pool
+			heap.Push(&sf.candidateHeap, transitCandidate)
+			transitCandidate = nil
+			if sf.candidateHeap.Len() > sf.candidateHeap.Capacity {
+				transitCandidate = heap.Pop(&sf.candidateHeap).(*Candidate)
+			}
+		}
+		if transitCandidate != nil && sf.candidateBufferMinHeap.shouldTake(transitCandidate.Votes) {
+			// Push candidate into candidate pool
+			heap.Push(&sf.candidateBufferMinHeap, transitCandidate)
+			heap.Push(&sf.candidateBufferMaxHeap, transitCandidate)
+			transitCandidate = nil
+			if sf.candidateBufferMinHeap.Len() > sf.candidateBufferMinHeap.Capacity {
+				transitCandidate = heap.Pop(&sf.candidateBufferMinHeap).(*Candidate)
+				heap.Remove(&sf.candidateBufferMaxHeap, transitCandidate.maxIndex)
+			}
+		}
+	}
+	sf.balance()
+
+	// Temporarily leave it here to check the algorithm is correct
+	if sf.candidateBufferMinHeap.Len() != sf.candidateBufferMaxHeap.Len() {
+		logger.Warn().Msg("candidateBuffer min and max heap not sync")
+	}
+}
+
+func (sf *factory) removeCandidate(address string) {
+	c, level := sf.inPool(address)
+	switch level {
+	case candidatePool:
+		heap.Remove(&sf.candidateHeap, c.minIndex)
+		if sf.candidateBufferMinHeap.Len() > 0 {
+			promoteCandidate := heap.Pop(&sf.candidateBufferMaxHeap).(*Candidate)
+			heap.Remove(&sf.candidateBufferMinHeap, promoteCandidate.minIndex)
+			heap.Push(&sf.candidateHeap, promoteCandidate)
+		}
+	case candidateBufferPool:
+		heap.Remove(&sf.candidateBufferMinHeap, c.minIndex)
+		heap.Remove(&sf.candidateBufferMaxHeap, c.maxIndex)
+	default:
+		break
+	}
+	sf.balance()
+
+	// Temporarily leave it here to check the algorithm is correct
+	if sf.candidateBufferMinHeap.Len() != sf.candidateBufferMaxHeap.Len() {
+		logger.Warn().Msg("candidateBuffer min and max heap not sync")
+	}
+}
+
+func (sf *factory) balance() {
+	if sf.candidateHeap.Len() > 0 && sf.candidateBufferMaxHeap.Len() > 0 && sf.candidateHeap.Top().(*Candidate).Votes.Cmp(sf.candidateBufferMaxHeap.Top().(*Candidate).Votes) < 0 {
+		cFromCandidatePool := heap.Pop(&sf.candidateHeap).(*Candidate)
+		cFromCandidateBufferPool := heap.Pop(&sf.candidateBufferMaxHeap).(*Candidate)
+		heap.Remove(&sf.candidateBufferMinHeap, cFromCandidateBufferPool.minIndex)
+		heap.Push(&sf.candidateHeap, cFromCandidateBufferPool)
+		heap.Push(&sf.candidateBufferMinHeap, cFromCandidatePool)
+		heap.Push(&sf.candidateBufferMaxHeap, cFromCandidatePool)
+	}
+}
+
+func (sf *factory) inPool(address string) (*Candidate, int) {
+	if c := sf.candidateHeap.exist(address); c != nil {
+		return c, candidatePool // The candidate exists in the Candidate pool
+	}
+	if c := sf.candidateBufferMinHeap.exist(address); c != nil {
+		return c, candidateBufferPool // The candidate exists in the Candidate buffer pool
+	}
+	return nil, 0
+}
+
 func (sf *factory) cache(address string) (*State, error) {
 	if state, exist := sf.cachedAccount[address]; exist {
-		return state, nil
+		sf.touch(address, state)
+		return state, sf.journal(address, state)
 	}
-	state, err := sf.getState(address)
+	state, err := sf.getStateFromPKHash(iotxaddress.GetPubkeyHash(address))
 	switch {
 	case err == ErrAccountNotExist:
-		if state, err = sf.CreateState(address, 0); err != nil {
+		if err := sf.journal(address, nil); err != nil {
 			return nil, err
 		}
+		// the new account is put into the trie along with the other accounts modified, when the block is committed
+		state = &State{Balance: big.NewInt(0), VotingWeight: big.NewInt(0)}
 	case err != nil:
 		return nil, err
+	default:
+		if err := sf.journal(address, state); err != nil {
+			return nil, err
+		}
 	}
 	sf.cachedAccount[address] = state
+	sf.touch(address, state)
 	return state, nil
 }
 
-func (sf *factory) handleTsf(tsf []*action.Transfer) error {
-	for _, tx := range tsf {
-		if !tx.IsCoinbase {
-			// check sender
-			sender, err := sf.cache(tx.Sender)
+// touch records the balance and the candidate weight of an account before it is first modified by the action being
+// handled
+func (sf *factory) touch(address string, state *State) {
+	if sf.touched == nil {
+		return
+	}
+	if _, ok := sf.touched[address]; ok {
+		return
+	}
+	sf.touched[address] = &accountChange{
+		balance: new(big.Int).Set(state.Balance),
+		weight:  candidateWeight(address, state, sf.votingRule().stakedAt(sf.touchedHeight)),
+	}
+}
+
+// receipt returns the receipt of an action with the changes made to the accounts touched by it, and stops tracking them
+func (sf *factory) receipt(act action.Action, status uint64) *Receipt {
+	addresses := make([]string, 0, len(sf.touched))
+	for address := range sf.touched {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	receipt := &Receipt{ActionHash: act.Hash(), Status: status}
+	for _, address := range addresses {
+		state := sf.cachedAccount[address]
+		if state == nil {
+			continue
+		}
+		before := sf.touched[address]
+		if delta := new(big.Int).Sub(state.Balance, before.balance); delta.Sign() != 0 {
+			receipt.BalanceDeltas = append(receipt.BalanceDeltas, &Delta{Address: address, Amount: delta})
+		}
+		weight := candidateWeight(address, state, sf.votingRule().stakedAt(sf.touchedHeight))
+		if delta := weight.Sub(weight, before.weight); delta.Sign() != 0 {
+			receipt.WeightDeltas = append(receipt.WeightDeltas, &Delta{Address: address, Amount: delta})
+		}
+	}
+	sf.touched = nil
+	return receipt
+}
+
+// votingRule returns the rule of the voting weight of the chain
+func (sf *factory) votingRule() votingRule {
+	return votingRule{staked: sf.stakedVoting, schedule: sf.schedule}
+}
+
+// encodeState encodes the state in the encoding of the states of the given height
+func (sf *factory) encodeState(height uint64, state *State) ([]byte, error) {
+	if sf.schedule.IsActive(version.FeatureStateEncoding, height) {
+		return stateToBytes(state)
+	}
+	return legacyStateToBytes(state)
+}
+
+// indexBranches hashes the branches of the account trie with the slot indexes of their children if FeatureBranchIndex is
+// in effect at the height, the trie is rehashed by the block activating it or reverting to a height before it
+func (sf *factory) indexBranches(tr trie.Trie, height uint64) error {
+	if err := tr.IndexBranches(sf.schedule.IsActive(version.FeatureBranchIndex, height)); err != nil {
+		return errors.Wrapf(err, "failed to hash the branches of height %d", height)
+	}
+	return nil
+}
+
+// convertLegacyStates returns the keys and the converted values of the states in gob in the states of the previous root,
+// if the block of the given height activates FeatureStateEncoding. The cached accounts are left out, which are encoded
+// along with the block anyway
+func (sf *factory) convertLegacyStates(blockHeight uint64, prevRoot hash.Hash32B) ([][]byte, [][]byte, error) {
+	if blockHeight == 0 || !sf.schedule.IsActive(version.FeatureStateEncoding, blockHeight) ||
+		sf.schedule.IsActive(version.FeatureStateEncoding, blockHeight-1) {
+		return nil, nil, nil
+	}
+	cached := make(map[string]bool, len(sf.cachedAccount))
+	for address := range sf.cachedAccount {
+		cached[string(iotxaddress.GetPubkeyHash(address))] = true
+	}
+	var keys, values [][]byte
+	if err := trie.Walk(sf.dao, trie.AccountKVNameSpace, prevRoot, func(key, value []byte) error {
+		if cached[string(key)] || (len(value) > 0 && value[0] == stateEncodingVersion) {
+			return nil
+		}
+		state, err := bytesToState(value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode state of %x", key)
+		}
+		ss, err := stateToBytes(state)
+		if err != nil {
+			return err
+		}
+		if sf.pendingUndo != nil {
+			address, err := iotxaddress.GetAddressByHash(key, iotxaddress.IsTestnet, iotxaddress.ChainID)
 			if err != nil {
+				return errors.Wrapf(err, "failed to get address of %x", key)
+			}
+			if _, ok := sf.pendingUndo.accounts[address]; !ok {
+				sf.pendingUndo.accounts[address] = append([]byte{}, value...)
+			}
+		}
+		keys = append(keys, append([]byte{}, key...))
+		values = append(values, ss)
+		return nil
+	}); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to convert the states")
+	}
+	if len(keys) > 0 {
+		logger.Info().
+			Int("accounts", len(keys)).
+			Uint64("height", blockHeight).
+			Msg("converted the states to the current encoding")
+	}
+	return keys, values, nil
+}
+
+// journal records the state of an account before it is first modified by the block being committed
+func (sf *factory) journal(address string, state *State) error {
+	if sf.pendingUndo == nil {
+		return nil
+	}
+	if _, ok := sf.pendingUndo.accounts[address]; ok {
+		return nil
+	}
+	if state == nil {
+		sf.pendingUndo.accounts[address] = nil
+		return nil
+	}
+	ss, err := sf.encodeState(sf.pendingUndo.prevHeight, state)
+	if err != nil {
+		return err
+	}
+	sf.pendingUndo.accounts[address] = ss
+	return nil
+}
+
+// undo reverts the state changes made by one block. The reverted root is checked on a scratch trie first, so the trie
+// is left untouched if the record does not match the states
+func (sf *factory) undo(record *undoRecord) error {
+	if sf.dao == nil {
+		return errors.New("state trie does not have an underlying DB to revert states on")
+	}
+	tr, err := trie.NewTrieSharedDB(sf.dao, trie.AccountKVNameSpace, sf.trie.RootHash(), trie.NodeCacheOption(sf.nodeCache))
+	if err != nil {
+		return errors.Wrap(err, "failed to create scratch trie")
+	}
+	// batched writes are never flushed, so nothing reaches the DB
+	if err := tr.EnableBatch(); err != nil {
+		return errors.Wrap(err, "failed to enable batch mode of scratch trie")
+	}
+	if err := sf.indexBranches(tr, record.prevHeight); err != nil {
+		return err
+	}
+	if err := revertAccounts(tr, record); err != nil {
+		return err
+	}
+	if root := tr.RootHash(); root != record.prevRoot {
+		return errors.Errorf("wrong root %x after revert, expecting %x", root, record.prevRoot)
+	}
+	// the block is reverted in a batch, so the trie publishes the reverted version once for the readers
+	if err := sf.trie.EnableBatch(); err != nil {
+		return errors.Wrap(err, "failed to enable batch mode of trie")
+	}
+	defer sf.trie.DisableBatch()
+	if err := sf.indexBranches(sf.trie, record.prevHeight); err != nil {
+		return err
+	}
+	if err := revertAccounts(sf.trie, record); err != nil {
+		return err
+	}
+	if err := sf.trie.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush reverted states")
+	}
+	for address, ss := range record.accounts {
+		// the account will be reloaded from trie next time it is used
+		delete(sf.cachedAccount, address)
+		if len(ss) == 0 {
+			continue
+		}
+		// the coins released by the reverted blocks are unbonding again
+		state, err := bytesToState(ss)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode state of %s", address)
+		}
+		sf.indexUnbondings(address, state)
+	}
+	// the storage of the contracts is kept in DB, and reloaded from the reverted roots next time it is used
+	sf.cachedContract = make(map[string]*contract)
+	sf.restoreCandidates(record.candidates)
+	sf.productivity = record.productivity
+	sf.viewMu.Lock()
+	sf.candidatesLRU.Remove(record.height)
+	sf.viewMu.Unlock()
+	sf.currentChainHeight = record.prevHeight
+	sf.receipts = nil
+	sf.publishView()
+	return nil
+}
+
+// revertAccounts puts the accounts in the undo record back into the trie
+func revertAccounts(tr trie.Trie, record *undoRecord) error {
+	for address, ss := range record.accounts {
+		pkhash := iotxaddress.GetPubkeyHash(address)
+		if len(ss) == 0 {
+			if err := tr.Delete(pkhash); err != nil {
 				return err
 			}
-			if tx.Amount.Cmp(sender.Balance) == 1 {
-				return ErrNotEnoughBalance
+			continue
+		}
+		if err := tr.Upsert(pkhash, ss); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newUndoRecord returns the undo record of the block to commit on top of the current states, nil if no undo history is
+// kept
+func (sf *factory) newUndoRecord(blockHeight uint64) *undoRecord {
+	if sf.maxUndo == 0 {
+		return nil
+	}
+	return &undoRecord{
+		height:       blockHeight,
+		prevHeight:   sf.currentChainHeight,
+		prevRoot:     sf.trie.RootHash(),
+		accounts:     make(map[string][]byte),
+		candidates:   sf.snapshotCandidates(),
+		productivity: copyProductivity(sf.productivity),
+	}
+}
+
+// runActions returns the working set of the actions applied on top of the current states, which is kept until the
+// states or the actions change
+func (sf *factory) runActions(blockHeight uint64, acts []action.Action) (*factory, error) {
+	if sf.dao == nil {
+		return nil, errors.New("state trie does not have an underlying DB to run actions on")
+	}
+	prevRoot := sf.trie.RootHash()
+	actsHash := hashActions(acts)
+	sf.validatedMu.Lock()
+	validated := sf.validated
+	sf.validatedMu.Unlock()
+	if validated.matches(blockHeight, sf.currentChainHeight, prevRoot, actsHash) {
+		return validated.ws, nil
+	}
+	tr, err := trie.NewTrieSharedDB(sf.dao, trie.AccountKVNameSpace, prevRoot, trie.NodeCacheOption(sf.nodeCache))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create scratch trie")
+	}
+	// batched writes are never flushed and contracts are never committed, so nothing reaches the DB
+	if err := tr.EnableBatch(); err != nil {
+		return nil, err
+	}
+	if err := sf.indexBranches(tr, blockHeight); err != nil {
+		return nil, err
+	}
+	ws := &factory{
+		cachedCandidate:    make(map[string]*Candidate),
+		cachedAccount:      make(map[string]*State),
+		cachedContract:     make(map[string]*contract),
+		trie:               tr,
+		dao:                sf.dao,
+		nodeCache:          sf.nodeCache,
+		handlers:           sf.handlers,
+		stakedVoting:       sf.stakedVoting,
+		unbondings:         sf.unbondings,
+		productivity:       copyProductivity(sf.productivity),
+		epochLength:        sf.epochLength,
+		epochReward:        sf.epochReward,
+		voterRewardPercent: sf.voterRewardPercent,
+		schedule:           sf.schedule,
+		// the changes are journaled against the states of sf, in case the working set is committed
+		pendingUndo: sf.newUndoRecord(blockHeight),
+	}
+	if err := ws.handleActions(blockHeight, acts); err != nil {
+		return nil, err
+	}
+	for address, state := range ws.cachedAccount {
+		ss, err := ws.encodeState(blockHeight, state)
+		if err != nil {
+			return nil, err
+		}
+		if err := tr.Upsert(iotxaddress.GetPubkeyHash(address), ss); err != nil {
+			return nil, err
+		}
+	}
+	legacyK, legacyV, err := ws.convertLegacyStates(blockHeight, prevRoot)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range legacyK {
+		if err := tr.Upsert(key, legacyV[i]); err != nil {
+			return nil, err
+		}
+	}
+	sf.validatedMu.Lock()
+	sf.validated = &workingSet{
+		height:     blockHeight,
+		prevHeight: sf.currentChainHeight,
+		prevRoot:   prevRoot,
+		actsHash:   actsHash,
+		ws:         ws,
+	}
+	sf.validatedMu.Unlock()
+	return ws, nil
+}
+
+// takeWorkingSet returns the working set kept by RunActions if it is of the same actions on top of the current states,
+// and drops it anyway, since the states are about to change
+func (sf *factory) takeWorkingSet(blockHeight uint64, acts []action.Action) *factory {
+	sf.validatedMu.Lock()
+	validated := sf.validated
+	sf.validated = nil
+	sf.validatedMu.Unlock()
+	if !validated.matches(blockHeight, sf.currentChainHeight, sf.trie.RootHash(), hashActions(acts)) {
+		return nil
+	}
+	return validated.ws
+}
+
+// dropWorkingSet drops the working set kept by RunActions, which no longer applies once the states are changed
+func (sf *factory) dropWorkingSet() {
+	sf.validatedMu.Lock()
+	sf.validated = nil
+	sf.validatedMu.Unlock()
+}
+
+// adoptWorkingSet takes over the accounts, the contracts, the new candidates, the receipts and the productivity changed
+// by the actions in the working set, as if the actions were applied on sf
+func (sf *factory) adoptWorkingSet(ws *factory) {
+	for address, state := range ws.cachedAccount {
+		sf.cachedAccount[address] = state
+	}
+	for address, c := range ws.cachedContract {
+		sf.cachedContract[address] = c
+	}
+	for address, c := range ws.cachedCandidate {
+		if _, ok := sf.cachedCandidate[address]; !ok {
+			sf.cachedCandidate[address] = c
+		}
+	}
+	sf.productivity = ws.productivity
+	sf.receipts = ws.receipts
+	sf.pendingUndo = ws.pendingUndo
+}
+
+// matches returns true if the working set is of the actions of the block of the given height, applied on top of the
+// states of the given height and root
+func (w *workingSet) matches(height, prevHeight uint64, prevRoot, actsHash hash.Hash32B) bool {
+	return w != nil && w.height == height && w.prevHeight == prevHeight && w.prevRoot == prevRoot &&
+		w.actsHash == actsHash
+}
+
+// hashActions returns the hash of the hashes of the actions in order
+func hashActions(acts []action.Action) hash.Hash32B {
+	h, _ := blake2b.New256(nil)
+	for _, act := range acts {
+		actHash := act.Hash()
+		h.Write(actHash[:])
+	}
+	var actsHash hash.Hash32B
+	copy(actsHash[:], h.Sum(nil))
+	return actsHash
+}
+
+// publishView publishes the height, the candidates and the receipts of the block just committed, reverted to or restored
+// for the readers, and caches the candidates of the height
+func (sf *factory) publishView() {
+	v := &stateView{height: sf.currentChainHeight, committed: sf.committed, receipts: sf.receipts}
+	for _, c := range sf.candidateHeap.pq {
+		cc := *c
+		cc.Votes = new(big.Int).Set(c.Votes)
+		v.candidates = append(v.candidates, &cc)
+	}
+	sorted := append([]*Candidate{}, v.candidates...)
+	sortCandidates(sorted)
+
+	sf.viewMu.Lock()
+	defer sf.viewMu.Unlock()
+	sf.view = v
+	sf.candidatesLRU.Add(v.height, sorted)
+}
+
+// latestView returns what the readers see of the latest committed block
+func (sf *factory) latestView() *stateView {
+	sf.viewMu.Lock()
+	defer sf.viewMu.Unlock()
+	return sf.view
+}
+
+// snapshotCandidates makes a deep copy of the candidate pools
+func (sf *factory) snapshotCandidates() *candidateSnapshot {
+	copies := make(map[string]*Candidate)
+	copyOf := func(c *Candidate) *Candidate {
+		if cc, ok := copies[c.Address]; ok {
+			return cc
+		}
+		cc := *c
+		cc.Votes = new(big.Int).Set(c.Votes)
+		copies[c.Address] = &cc
+		return &cc
+	}
+	snapshot := &candidateSnapshot{cached: make(map[string]*Candidate)}
+	for address, c := range sf.cachedCandidate {
+		snapshot.cached[address] = copyOf(c)
+	}
+	for _, c := range sf.candidateHeap.pq {
+		snapshot.heap = append(snapshot.heap, copyOf(c))
+	}
+	for _, c := range sf.candidateBufferMinHeap.pq {
+		snapshot.bufferMin = append(snapshot.bufferMin, copyOf(c))
+	}
+	for _, c := range sf.candidateBufferMaxHeap.pq {
+		snapshot.bufferMax = append(snapshot.bufferMax, copyOf(c))
+	}
+	return snapshot
+}
+
+// restoreCandidates restores the candidate pools from a snapshot
+func (sf *factory) restoreCandidates(snapshot *candidateSnapshot) {
+	sf.cachedCandidate = snapshot.cached
+	sf.candidateHeap.pq = snapshot.heap
+	sf.candidateBufferMinHeap.pq = snapshot.bufferMin
+	sf.candidateBufferMaxHeap.pq = snapshot.bufferMax
+}
+
+// handleActions applies the state changes of the actions. The fee and the nonce of the sender are handled here, and the
+// rest is dispatched to the first handler accepting the action.
+func (sf *factory) handleActions(blockHeight uint64, acts []action.Action) error {
+	producer := coinbaseRecipient(acts)
+	stakedVoting := sf.votingRule().stakedAt(blockHeight)
+	if err := sf.recountVotes(blockHeight); err != nil {
+		return err
+	}
+	if err := sf.releaseUnbondings(blockHeight); err != nil {
+		return err
+	}
+	for _, act := range acts {
+		for _, feature := range action.RequiredFeatures(act) {
+			if !sf.schedule.IsActive(feature, blockHeight) {
+				return errors.Wrapf(ErrInactiveFeature, "action %x requires %s at height %d", act.Hash(), feature,
+					blockHeight)
+			}
+		}
+		sf.touched = make(map[string]*accountChange)
+		sf.touchedHeight = blockHeight
+		if sender := act.SrcAddr(); sender != "" {
+			state, err := sf.cache(sender)
+			if err != nil {
+				return err
 			}
-			// update sender balance
-			if err := sender.SubBalance(tx.Amount); err != nil {
+			fee := act.Fee()
+			if !sf.schedule.IsActive(version.FeatureGasLimitFee, blockHeight) {
+				fee = action.IntrinsicFee(act)
+			}
+			if err := sf.chargeFee(sender, state, fee, producer, stakedVoting); err != nil {
 				return err
 			}
 			// update sender nonce
-			if tx.Nonce > sender.Nonce {
-				sender.Nonce = tx.Nonce
+			if act.GetNonce() > state.Nonce {
+				state.Nonce = act.GetNonce()
+			}
+		}
+		handled := false
+		status := ReceiptStatusSuccess
+		for _, handler := range sf.handlers {
+			ok, err := handler.Handle(blockHeight, act, sf)
+			if errors.Cause(err) == ErrActionFailed {
+				hash := act.Hash()
+				logger.Warn().Err(err).Hex("action", hash[:]).Msg("Action failed")
+				ok, err = true, nil
+				status = ReceiptStatusFailure
+			}
+			if err != nil {
+				return err
 			}
-			// Update sender votes
-			if len(sender.Votee) > 0 && sender.Votee != tx.Sender {
-				// sender already voted to a different person
-				voteeOfSender, err := sf.cache(sender.Votee)
-				if err != nil {
-					return err
-				}
-				voteeOfSender.VotingWeight.Sub(voteeOfSender.VotingWeight, tx.Amount)
+			if ok {
+				handled = true
+				break
 			}
 		}
-		// check recipient
-		recipient, err := sf.cache(tx.Recipient)
+		if !handled {
+			return errors.Wrapf(ErrUnhandledAction, "action %x", act.Hash())
+		}
+		sf.receipts = append(sf.receipts, sf.receipt(act, status))
+	}
+	if !sf.schedule.IsActive(version.FeatureEpochReward, blockHeight) {
+		return nil
+	}
+	if blockHeight > 0 && producer != "" && sf.productivity != nil {
+		sf.productivity[producer]++
+	}
+	if sf.epochReward == nil || sf.epochReward.Sign() == 0 || sf.epochLength == 0 {
+		return nil
+	}
+	if blockHeight == 0 || blockHeight%sf.epochLength != 0 {
+		return nil
+	}
+	return sf.settleEpochReward(stakedVoting)
+}
+
+// recountVotes recounts the voting weights given by the voters as their bonded stake, if the block of the given height
+// activates FeatureStakedVoting with staked voting enabled. The candidates are cached along with the votees, so their
+// weights are updated by the commit
+func (sf *factory) recountVotes(blockHeight uint64) error {
+	if !sf.stakedVoting || blockHeight == 0 || !sf.schedule.IsActive(version.FeatureStakedVoting, blockHeight) ||
+		sf.schedule.IsActive(version.FeatureStakedVoting, blockHeight-1) {
+		return nil
+	}
+	type vote struct {
+		voter string
+		votee string
+		stake *big.Int
+	}
+	var votes []vote
+	var candidates []string
+	if err := trie.Walk(sf.dao, trie.AccountKVNameSpace, sf.trie.RootHash(), func(key, value []byte) error {
+		state, err := bytesToState(value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode state of %x", key)
+		}
+		address, err := iotxaddress.GetAddressByHash(key, iotxaddress.IsTestnet, iotxaddress.ChainID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get address of %x", key)
+		}
+		if state.IsCandidate {
+			candidates = append(candidates, address)
+		}
+		if len(state.Votee) > 0 && state.Votee != address {
+			votes = append(votes, vote{voter: address, votee: state.Votee, stake: state.StakedAmount()})
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "failed to recount the votes")
+	}
+	for _, v := range votes {
+		votee, err := sf.cache(v.votee)
 		if err != nil {
 			return err
 		}
-		// update recipient balance
-		if err := recipient.AddBalance(tx.Amount); err != nil {
+		weight, ok := votee.Voters[v.voter]
+		if !ok {
+			weight = big.NewInt(0)
+		}
+		votee.addVotes(v.voter, new(big.Int).Sub(v.stake, weight))
+	}
+	for _, address := range candidates {
+		if _, err := sf.cache(address); err != nil {
 			return err
 		}
-		// Update recipient votes
-		if len(recipient.Votee) > 0 && recipient.Votee != tx.Recipient {
-			// recipient already voted to a different person
-			voteeOfRecipient, err := sf.cache(recipient.Votee)
+	}
+	logger.Info().
+		Int("votes", len(votes)).
+		Uint64("height", blockHeight).
+		Msg("recounted the votes as the bonded stakes")
+	return nil
+}
+
+// releaseUnbondings releases the unstaked coins of the accounts whose release height is reached by the block of the
+// given height
+func (sf *factory) releaseUnbondings(blockHeight uint64) error {
+	var addresses []string
+	for height, accounts := range sf.unbondings {
+		if height > blockHeight {
+			continue
+		}
+		for address := range accounts {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		state, ok := sf.cachedAccount[address]
+		if !ok {
+			var err error
+			state, err = sf.getStateFromPKHash(iotxaddress.GetPubkeyHash(address))
+			if err == ErrAccountNotExist {
+				// the account is gone with the blocks reverted
+				continue
+			}
 			if err != nil {
 				return err
 			}
-			voteeOfRecipient.VotingWeight.Add(voteeOfRecipient.VotingWeight, tx.Amount)
 		}
+		if state.ReleasableAmount(blockHeight).Sign() == 0 {
+			continue
+		}
+		state, err := sf.cache(address)
+		if err != nil {
+			return err
+		}
+		state.Release(blockHeight)
 	}
 	return nil
 }
 
-func (sf *factory) handleVote(blockHeight uint64, vote []*action.Vote) error {
-	for _, v := range vote {
-		voterAddress := v.VoterAddress
-		voteFrom, err := sf.cache(voterAddress)
+// indexUnbondings adds the account to the index of the unbonding accounts at the release heights of its unstaked coins
+func (sf *factory) indexUnbondings(address string, state *State) {
+	for _, u := range state.Unbonding {
+		sf.indexUnbonding(address, u.ReleaseHeight)
+	}
+}
+
+// indexUnbonding adds the account to the index of the unbonding accounts at the release height
+func (sf *factory) indexUnbonding(address string, height uint64) {
+	accounts, ok := sf.unbondings[height]
+	if !ok {
+		accounts = make(map[string]struct{})
+		sf.unbondings[height] = accounts
+	}
+	accounts[address] = struct{}{}
+}
+
+// settleEpochReward splits the epoch reward among the producers of the epoch by the blocks they produced, and passes
+// the voter share of each producer's reward to its voters in proportion to their voting weight. The productivity is
+// reset for the next epoch
+func (sf *factory) settleEpochReward(stakedVoting bool) error {
+	producers := make([]string, 0, len(sf.productivity))
+	totalBlocks := uint64(0)
+	for producer, blocks := range sf.productivity {
+		producers = append(producers, producer)
+		totalBlocks += blocks
+	}
+	sort.Strings(producers)
+	// the remainder of the split goes to the most productive producer, the first one in address order if tied
+	rewards := make(map[string]*big.Int, len(producers))
+	remainder := new(big.Int).Set(sf.epochReward)
+	top := ""
+	for _, producer := range producers {
+		reward := new(big.Int).Mul(sf.epochReward, new(big.Int).SetUint64(sf.productivity[producer]))
+		reward.Div(reward, new(big.Int).SetUint64(totalBlocks))
+		rewards[producer] = reward
+		remainder.Sub(remainder, reward)
+		if top == "" || sf.productivity[producer] > sf.productivity[top] {
+			top = producer
+		}
+	}
+	if top != "" {
+		rewards[top].Add(rewards[top], remainder)
+	}
+	for _, producer := range producers {
+		reward := rewards[producer]
+		state, err := sf.cache(producer)
 		if err != nil {
 			return err
 		}
-
-		// update voteFrom nonce
-		if v.Nonce > voteFrom.Nonce {
-			voteFrom.Nonce = v.Nonce
+		// the voters and their weights are taken before paying any of them, since paying changes the weights
+		voters := make([]string, 0, len(state.Voters))
+		weights := make(map[string]*big.Int, len(state.Voters))
+		totalWeight := big.NewInt(0)
+		for voter, weight := range state.Voters {
+			voters = append(voters, voter)
+			weights[voter] = new(big.Int).Set(weight)
+			totalWeight.Add(totalWeight, weight)
 		}
-		// Update old votee's weight
-		if len(voteFrom.Votee) > 0 && voteFrom.Votee != voterAddress {
-			// voter already voted
-			oldVotee, err := sf.cache(voteFrom.Votee)
-			if err != nil {
+		sort.Strings(voters)
+		voterReward := new(big.Int).Mul(reward, new(big.Int).SetUint64(sf.voterRewardPercent))
+		voterReward.Div(voterReward, big.NewInt(100))
+		producerReward := new(big.Int).Set(reward)
+		for _, voter := range voters {
+			share := new(big.Int).Mul(voterReward, weights[voter])
+			share.Div(share, totalWeight)
+			if err := deposit(sf, voter, share, stakedVoting); err != nil {
 				return err
 			}
-			oldVotee.VotingWeight.Sub(oldVotee.VotingWeight, voteFrom.Balance)
-			voteFrom.Votee = ""
+			producerReward.Sub(producerReward, share)
 		}
-
-		voteeAddress := v.VoteeAddress
-		if voteeAddress == "" {
-			// unvote operation
-			voteFrom.IsCandidate = false
-			continue
+		// the producer keeps the rest, including the voter share if nobody votes for it
+		if err := deposit(sf, producer, producerReward, stakedVoting); err != nil {
+			return err
 		}
+	}
+	sf.productivity = make(map[string]uint64)
+	return nil
+}
 
-		voteTo, err := sf.cache(voteeAddress)
+// chargeFee moves the fee of an action from the payer to the block producer, the fee is burnt if there is no producer
+func (sf *factory) chargeFee(
+	payerAddress string,
+	payer *State,
+	fee *big.Int,
+	producer string,
+	stakedVoting bool,
+) error {
+	if fee.Sign() == 0 {
+		return nil
+	}
+	if fee.Cmp(payer.Balance) == 1 {
+		return ErrNotEnoughBalance
+	}
+	if err := payer.SubBalance(fee); err != nil {
+		return err
+	}
+	if !stakedVoting && len(payer.Votee) > 0 && payer.Votee != payerAddress {
+		voteeOfPayer, err := sf.cache(payer.Votee)
 		if err != nil {
 			return err
 		}
-
-		if voterAddress != voteeAddress {
-			// Voter votes to a different person
-			voteTo.VotingWeight.Add(voteTo.VotingWeight, voteFrom.Balance)
-			voteFrom.Votee = voteeAddress
-		} else {
-			// Vote to self: self-nomination or cancel the previous vote case
-			voteFrom.Votee = voterAddress
-			voteFrom.IsCandidate = true
-			if _, ok := sf.cachedCandidate[voterAddress]; !ok {
-				sf.cachedCandidate[voterAddress] = &Candidate{
-					Address:        voterAddress,
-					PubKey:         v.SelfPubkey[:],
-					CreationHeight: blockHeight,
-					minIndex:       0,
-					maxIndex:       0,
-				}
-			}
+		voteeOfPayer.addVotes(payerAddress, new(big.Int).Neg(fee))
+	}
+	if producer == "" {
+		return nil
+	}
+	recipient, err := sf.cache(producer)
+	if err != nil {
+		return err
+	}
+	if err := recipient.AddBalance(fee); err != nil {
+		return err
+	}
+	if !stakedVoting && len(recipient.Votee) > 0 && recipient.Votee != producer {
+		voteeOfRecipient, err := sf.cache(recipient.Votee)
+		if err != nil {
+			return err
 		}
+		voteeOfRecipient.addVotes(producer, fee)
 	}
 	return nil
 }
+
+// copyProductivity returns a copy of the blocks produced by each producer
+func copyProductivity(productivity map[string]uint64) map[string]uint64 {
+	cp := make(map[string]uint64, len(productivity))
+	for producer, blocks := range productivity {
+		cp[producer] = blocks
+	}
+	return cp
+}
+
+// coinbaseRecipient returns the recipient of the coinbase transfer, who is the producer of the block
+func coinbaseRecipient(acts []action.Action) string {
+	for _, act := range acts {
+		if tsf, ok := act.(*action.Transfer); ok && tsf.IsCoinbase {
+			return tsf.Recipient
+		}
+	}
+	return ""
+}
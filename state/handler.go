@@ -0,0 +1,358 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/blockchain/action"
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/pkg/version"
+	"github.com/iotexproject/iotex-core/txvm"
+)
+
+var (
+	// ErrUnhandledAction is the error that no handler applies the state changes of an action
+	ErrUnhandledAction = errors.New("no handler for the action")
+
+	// ErrActionFailed is the error that an action fails to take effect, which still goes into the block and costs the
+	// sender the fee
+	ErrActionFailed = errors.New("action failed")
+
+	// ErrInactiveFeature is the error that an action requires a protocol feature not in effect at the block height
+	ErrInactiveFeature = errors.New("feature is not active")
+)
+
+type (
+	// ActionHandler applies the state changes of one or more types of actions
+	ActionHandler interface {
+		// Handle applies the state changes of the action to the working set, and returns false if the action is not of
+		// the types handled by it. The fee and the nonce of the sender are already taken care of by the factory. An
+		// ErrActionFailed error marks the action as failed without rejecting the block, in which case no state change
+		// should be made
+		Handle(blockHeight uint64, act action.Action, ws WorkingSet) (bool, error)
+	}
+
+	// WorkingSet is the states being modified by the actions of a block, which are committed together
+	WorkingSet interface {
+		// CachedState returns the state of an address being modified, an empty state is created if it does not exist
+		CachedState(address string) (*State, error)
+		// AddCandidate adds an address as a candidate nominated at the given height, if it is not a candidate yet
+		AddCandidate(address string, pubKey []byte, blockHeight uint64)
+		// CachedContract returns the contract of an address being modified, whose account state is CachedState(address)
+		CachedContract(address string) (Contract, error)
+	}
+
+	// votingRule tells whether only the bonded stake counts as voting weight at a height, which is once staked voting is
+	// both enabled by the genesis and activated by the upgrade schedule
+	votingRule struct {
+		staked   bool
+		schedule version.Schedule
+	}
+
+	transferHandler struct {
+		voting votingRule
+	}
+
+	voteHandler struct {
+		voting votingRule
+	}
+
+	executionHandler struct {
+		voting votingRule
+	}
+
+	stakeHandler struct {
+		voting          votingRule
+		unbondingPeriod uint64
+	}
+
+	// contractStorage buffers the writes of a contract run, which are applied to the contract only if the run succeeds
+	contractStorage struct {
+		contract Contract
+		writes   map[string][]byte
+	}
+)
+
+// stakedAt returns true if only the bonded stake counts as voting weight at the height
+func (r votingRule) stakedAt(height uint64) bool {
+	return r.staked && r.schedule.IsActive(version.FeatureStakedVoting, height)
+}
+
+// Handle moves the amount of a transfer from the sender to the recipient, along with the voting weight
+func (h transferHandler) Handle(blockHeight uint64, act action.Action, ws WorkingSet) (bool, error) {
+	tsf, ok := act.(*action.Transfer)
+	if !ok {
+		return false, nil
+	}
+	if !tsf.IsCoinbase {
+		if err := withdraw(ws, tsf.Sender, tsf.Amount, h.voting.stakedAt(blockHeight)); err != nil {
+			return true, err
+		}
+	}
+	return true, deposit(ws, tsf.Recipient, tsf.Amount, h.voting.stakedAt(blockHeight))
+}
+
+// Handle moves the voting weight of the voter to the votee, or nominates the voter if voting to self
+func (h voteHandler) Handle(blockHeight uint64, act action.Action, ws WorkingSet) (bool, error) {
+	v, ok := act.(*action.Vote)
+	if !ok {
+		return false, nil
+	}
+	voterAddress := v.VoterAddress
+	voteFrom, err := ws.CachedState(voterAddress)
+	if err != nil {
+		return true, err
+	}
+	// Update old votee's weight
+	if len(voteFrom.Votee) > 0 && voteFrom.Votee != voterAddress {
+		// voter already voted
+		oldVotee, err := ws.CachedState(voteFrom.Votee)
+		if err != nil {
+			return true, err
+		}
+		oldVotee.addVotes(voterAddress, new(big.Int).Neg(votingPower(voteFrom, h.voting.stakedAt(blockHeight))))
+		voteFrom.Votee = ""
+	}
+
+	voteeAddress := v.VoteeAddress
+	if voteeAddress == "" {
+		// unvote operation
+		voteFrom.IsCandidate = false
+		return true, nil
+	}
+
+	voteTo, err := ws.CachedState(voteeAddress)
+	if err != nil {
+		return true, err
+	}
+
+	if voterAddress != voteeAddress {
+		// Voter votes to a different person
+		voteTo.addVotes(voterAddress, votingPower(voteFrom, h.voting.stakedAt(blockHeight)))
+		voteFrom.Votee = voteeAddress
+	} else {
+		// Vote to self: self-nomination or cancel the previous vote case
+		voteFrom.Votee = voterAddress
+		voteFrom.IsCandidate = true
+		ws.AddCandidate(voterAddress, v.SelfPubkey[:], blockHeight)
+	}
+	return true, nil
+}
+
+// Handle deploys the data of an execution as the code of a new contract, or runs the code of an existing contract with
+// the data as the input, and moves the amount of the execution from the executor to the contract. A failed run only
+// costs the executor the fee, the states of the contract are untouched
+func (h executionHandler) Handle(blockHeight uint64, act action.Action, ws WorkingSet) (bool, error) {
+	ex, ok := act.(*action.Execution)
+	if !ok {
+		return false, nil
+	}
+	executor, err := ws.CachedState(ex.Executor)
+	if err != nil {
+		return true, err
+	}
+	amount := ex.Amount
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	if amount.Cmp(executor.Balance) == 1 {
+		return true, ErrNotEnoughBalance
+	}
+
+	contractAddress := ex.Contract
+	if ex.IsDeployment() {
+		if contractAddress, err = iotxaddress.CreateContractAddress(ex.Executor, ex.Nonce); err != nil {
+			return true, err
+		}
+		if _, err := txvm.ParseRaw(ex.Data); err != nil {
+			return true, errors.Wrapf(ErrActionFailed, "invalid contract code: %v", err)
+		}
+		contract, err := ws.CachedContract(contractAddress)
+		if err != nil {
+			return true, err
+		}
+		contract.SetCode(ex.Data)
+	} else if err := runContract(ex, ws); err != nil {
+		return true, err
+	}
+
+	if err := withdraw(ws, ex.Executor, amount, h.voting.stakedAt(blockHeight)); err != nil {
+		return true, err
+	}
+	return true, deposit(ws, contractAddress, amount, h.voting.stakedAt(blockHeight))
+}
+
+// Handle bonds the amount of a stake from the balance of the staker, or starts unbonding the amount of an unstake, which
+// is released after the unbonding period. An unstake of more than the bonded stake only costs the staker the fee
+func (h stakeHandler) Handle(blockHeight uint64, act action.Action, ws WorkingSet) (bool, error) {
+	st, ok := act.(*action.Stake)
+	if !ok {
+		return false, nil
+	}
+	staker, err := ws.CachedState(st.Staker)
+	if err != nil {
+		return true, err
+	}
+	amount := big.NewInt(0)
+	if st.Amount != nil {
+		amount.Set(st.Amount)
+	}
+	if st.Unstake {
+		if err := staker.Unbond(amount, blockHeight+h.unbondingPeriod); err != nil {
+			return true, errors.Wrapf(ErrActionFailed, "failed to unstake: %v", err)
+		}
+		amount.Neg(amount)
+	} else if err := staker.Bond(amount); err != nil {
+		return true, err
+	}
+	if !h.voting.stakedAt(blockHeight) || len(staker.Votee) == 0 || staker.Votee == st.Staker {
+		// the weight of a self-nominated candidate is counted from its own stake
+		return true, nil
+	}
+	votee, err := ws.CachedState(staker.Votee)
+	if err != nil {
+		return true, err
+	}
+	votee.addVotes(st.Staker, amount)
+	return true, nil
+}
+
+// runContract runs the code of the contract with the data of the execution as the input, within the gas left after the
+// intrinsic gas of the execution. Only an invalid input or code, and a failed run are reported as ErrActionFailed, any
+// other error fails the block
+func runContract(ex *action.Execution, ws WorkingSet) error {
+	contract, err := ws.CachedContract(ex.Contract)
+	if err != nil {
+		return err
+	}
+	code, err := contract.Code()
+	if errors.Cause(err) == ErrContractNotExist {
+		return errors.Wrapf(ErrActionFailed, "failed to run contract %s: %v", ex.Contract, err)
+	}
+	if err != nil {
+		return err
+	}
+	gasLimit := uint64(0)
+	if ex.GasLimit > ex.IntrinsicGas() {
+		gasLimit = ex.GasLimit - ex.IntrinsicGas()
+	}
+	storage := &contractStorage{contract: contract, writes: make(map[string][]byte)}
+	ctx := &txvm.Context{Caller: []byte(ex.Executor), Storage: storage, GasLimit: gasLimit}
+	vm, err := txvm.NewContractIVM(ctx, ex.Data, code)
+	if err != nil {
+		return errors.Wrapf(ErrActionFailed, "invalid input or code of contract %s: %v", ex.Contract, err)
+	}
+	if err := vm.Execute(); err != nil {
+		return errors.Wrapf(ErrActionFailed, "failed to run contract %s: %v", ex.Contract, err)
+	}
+	return storage.commit()
+}
+
+// Load returns the value of the key written by the run, or the value in the storage of the contract
+func (s *contractStorage) Load(key []byte) ([]byte, error) {
+	if value, ok := s.writes[string(key)]; ok {
+		return value, nil
+	}
+	return s.contract.GetState(key)
+}
+
+// Store buffers the value of the key
+func (s *contractStorage) Store(key []byte, value []byte) error {
+	s.writes[string(key)] = value
+	return nil
+}
+
+// commit applies the buffered writes to the contract in the order of the keys, so the storage root is deterministic
+func (s *contractStorage) commit() error {
+	keys := make([]string, 0, len(s.writes))
+	for key := range s.writes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := s.contract.SetState([]byte(key), s.writes[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withdraw subtracts the amount from the balance of an address, along with the voting weight of its votee unless only
+// the stake counts
+func withdraw(ws WorkingSet, address string, amount *big.Int, stakedVoting bool) error {
+	sender, err := ws.CachedState(address)
+	if err != nil {
+		return err
+	}
+	if amount.Cmp(sender.Balance) == 1 {
+		return ErrNotEnoughBalance
+	}
+	// update sender balance
+	if err := sender.SubBalance(amount); err != nil {
+		return err
+	}
+	// Update sender votes
+	if !stakedVoting && len(sender.Votee) > 0 && sender.Votee != address {
+		// sender already voted to a different person
+		voteeOfSender, err := ws.CachedState(sender.Votee)
+		if err != nil {
+			return err
+		}
+		voteeOfSender.addVotes(address, new(big.Int).Neg(amount))
+	}
+	return nil
+}
+
+// deposit adds the amount to the balance of an address, along with the voting weight of its votee unless only the stake
+// counts
+func deposit(ws WorkingSet, address string, amount *big.Int, stakedVoting bool) error {
+	recipient, err := ws.CachedState(address)
+	if err != nil {
+		return err
+	}
+	// update recipient balance
+	if err := recipient.AddBalance(amount); err != nil {
+		return err
+	}
+	// Update recipient votes
+	if !stakedVoting && len(recipient.Votee) > 0 && recipient.Votee != address {
+		// recipient already voted to a different person
+		voteeOfRecipient, err := ws.CachedState(recipient.Votee)
+		if err != nil {
+			return err
+		}
+		voteeOfRecipient.addVotes(address, amount)
+	}
+	return nil
+}
+
+// candidateWeight returns the total voting weight of an account as a candidate, which is zero if it is not a candidate
+func candidateWeight(address string, state *State, stakedVoting bool) *big.Int {
+	weight := big.NewInt(0)
+	if !state.IsCandidate {
+		return weight
+	}
+	weight.Add(weight, state.VotingWeight)
+	if state.Votee == address {
+		weight.Add(weight, votingPower(state, stakedVoting))
+	}
+	return weight
+}
+
+// votingPower returns the voting weight an account gives to its votee, which is the bonded stake with staked voting, or
+// all the coins of the account otherwise
+func votingPower(state *State, stakedVoting bool) *big.Int {
+	if stakedVoting {
+		return new(big.Int).Set(state.StakedAmount())
+	}
+	power := new(big.Int).Add(state.Balance, state.StakedAmount())
+	return power.Add(power, state.UnbondingAmount())
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/proto"
+)
+
+const (
+	// ReceiptStatusFailure is the status of an action which fails to take effect, only the fee and the nonce of the
+	// sender are updated
+	ReceiptStatusFailure = uint64(0)
+	// ReceiptStatusSuccess is the status of an action whose state changes are all applied
+	ReceiptStatusSuccess = uint64(1)
+)
+
+type (
+	// Delta is the change of an amount of an address
+	Delta struct {
+		Address string
+		Amount  *big.Int
+	}
+
+	// Receipt is the outcome of applying an action to the states, with the changes of the balances and the candidate
+	// weights made by the action, the fee included, sorted by address
+	Receipt struct {
+		ActionHash    hash.Hash32B
+		Status        uint64
+		BalanceDeltas []*Delta
+		WeightDeltas  []*Delta
+	}
+)
+
+// ConvertToReceiptPb converts Receipt to protobuf's ReceiptPb
+func (r *Receipt) ConvertToReceiptPb() *iproto.ReceiptPb {
+	return &iproto.ReceiptPb{
+		ActHash:       r.ActionHash[:],
+		Status:        r.Status,
+		BalanceDeltas: convertToDeltaPbs(r.BalanceDeltas),
+		WeightDeltas:  convertToDeltaPbs(r.WeightDeltas),
+	}
+}
+
+// Serialize returns a serialized byte stream for the Receipt
+func (r *Receipt) Serialize() ([]byte, error) {
+	return proto.Marshal(r.ConvertToReceiptPb())
+}
+
+// ConvertFromReceiptPb converts a protobuf's ReceiptPb to Receipt
+func (r *Receipt) ConvertFromReceiptPb(pbReceipt *iproto.ReceiptPb) {
+	copy(r.ActionHash[:], pbReceipt.GetActHash())
+	r.Status = pbReceipt.GetStatus()
+	r.BalanceDeltas = convertFromDeltaPbs(pbReceipt.GetBalanceDeltas())
+	r.WeightDeltas = convertFromDeltaPbs(pbReceipt.GetWeightDeltas())
+}
+
+// Deserialize parse the byte stream into Receipt
+func (r *Receipt) Deserialize(buf []byte) error {
+	pbReceipt := &iproto.ReceiptPb{}
+	if err := proto.Unmarshal(buf, pbReceipt); err != nil {
+		return err
+	}
+	r.ConvertFromReceiptPb(pbReceipt)
+	return nil
+}
+
+func convertToDeltaPbs(deltas []*Delta) []*iproto.DeltaPb {
+	var pbDeltas []*iproto.DeltaPb
+	for _, delta := range deltas {
+		pbDelta := &iproto.DeltaPb{Address: delta.Address, Negative: delta.Amount.Sign() < 0}
+		if abs := new(big.Int).Abs(delta.Amount); len(abs.Bytes()) > 0 {
+			pbDelta.Amount = abs.Bytes()
+		}
+		pbDeltas = append(pbDeltas, pbDelta)
+	}
+	return pbDeltas
+}
+
+func convertFromDeltaPbs(pbDeltas []*iproto.DeltaPb) []*Delta {
+	var deltas []*Delta
+	for _, pbDelta := range pbDeltas {
+		amount := new(big.Int).SetBytes(pbDelta.GetAmount())
+		if pbDelta.GetNegative() {
+			amount.Neg(amount)
+		}
+		deltas = append(deltas, &Delta{Address: pbDelta.GetAddress(), Amount: amount})
+	}
+	return deltas
+}
@@ -0,0 +1,568 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/big"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/keypair"
+	"github.com/iotexproject/iotex-core/pkg/version"
+	"github.com/iotexproject/iotex-core/trie"
+)
+
+// ErrInvalidSnapshot indicates the snapshot does not match the root or is malformed
+var ErrInvalidSnapshot = errors.New("invalid state snapshot")
+
+// snapshotAccount is an account in a snapshot chunk, along with the code and the storage of the contract of the account
+type snapshotAccount struct {
+	PubKeyHash    []byte
+	State         []byte
+	Code          []byte
+	StorageKeys   [][]byte
+	StorageValues [][]byte
+}
+
+// snapshotRestore rebuilds the states of a snapshot, and keeps track of what is written to DB, so a failed restore can
+// be undone
+type snapshotRestore struct {
+	sf          *factory
+	accounts    trie.Trie // the state trie being rebuilt
+	storage     trie.Trie // collects the nodes put by the storage tries of the contracts
+	codes       [][]byte  // hashes of the code put into DB, which was not there before
+	numAccounts int
+}
+
+// Snapshot is the states of a committed height pinned for a snapshot. The accounts are read from DB by the root, so they
+// can be exported while the later blocks are committed, and the states are kept from pruning until the snapshot is
+// released
+type Snapshot interface {
+	// Height returns the height of the states
+	Height() uint64
+	// Meta returns the metadata of the states besides the accounts
+	Meta() []byte
+	// Export calls the function with each chunk of at most the given number of accounts, in the order of the public key
+	// hashes
+	Export(int, func([]byte) error) error
+	// Release unpins the states, the snapshot must not be used afterwards
+	Release()
+}
+
+// pinnedStates implements the Snapshot interface
+type pinnedStates struct {
+	sf       *factory
+	height   uint64
+	root     hash.Hash32B
+	meta     []byte
+	released sync.Once
+}
+
+// PinSnapshot pins the states of the latest committed height for a snapshot, along with the checkpoint of the height as
+// the metadata, which carries the candidate pools. The productivity of the epoch is not in the states, so a snapshot can
+// only be taken where the productivity is empty or not needed
+func (sf *factory) PinSnapshot() (Snapshot, error) {
+	if sf.dao == nil || !sf.history {
+		// without history the stale nodes are deleted by the commits while the snapshot is exported
+		return nil, errors.Wrap(ErrStatesNotAvailable, "snapshots need the states persisted with history enabled")
+	}
+	if !sf.committed {
+		return nil, errors.Wrap(ErrStatesNotAvailable, "no states are committed yet")
+	}
+	if !sf.productivitySettled(sf.currentChainHeight) {
+		return nil, errors.Wrapf(ErrStatesNotAvailable, "height %d is not at the end of an epoch", sf.currentChainHeight)
+	}
+	cp := sf.checkpoint()
+	cp.Productivity = nil
+	var meta bytes.Buffer
+	if err := gob.NewEncoder(&meta).Encode(cp); err != nil {
+		return nil, errors.Wrap(err, "failed to encode state checkpoint")
+	}
+	sf.pinMu.Lock()
+	sf.pinned[cp.Height]++
+	sf.pinMu.Unlock()
+	return &pinnedStates{sf: sf, height: cp.Height, root: cp.Root, meta: meta.Bytes()}, nil
+}
+
+// Height returns the height of the states
+func (s *pinnedStates) Height() uint64 {
+	return s.height
+}
+
+// Meta returns the checkpoint of the height
+func (s *pinnedStates) Meta() []byte {
+	return s.meta
+}
+
+// Export calls the function with each chunk of at most chunkSize accounts in the order of the public key hashes, along
+// with the code and the storage of the contracts of the accounts
+func (s *pinnedStates) Export(chunkSize int, fn func([]byte) error) error {
+	if chunkSize <= 0 {
+		return errors.Errorf("invalid chunk size %d", chunkSize)
+	}
+	it, err := trie.NewIterator(s.sf.dao, trie.AccountKVNameSpace, s.root, nil)
+	if err != nil {
+		return err
+	}
+	var chunk []*snapshotAccount
+	flush := func() error {
+		var stream bytes.Buffer
+		if err := gob.NewEncoder(&stream).Encode(chunk); err != nil {
+			return errors.Wrap(err, "failed to encode snapshot chunk")
+		}
+		chunk = nil
+		return fn(stream.Bytes())
+	}
+	for it.Next() {
+		account, err := s.sf.snapshotAccount(it.Key(), it.Value())
+		if err != nil {
+			return err
+		}
+		if chunk = append(chunk, account); len(chunk) == chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if len(chunk) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+// Release unpins the states, so they can be pruned
+func (s *pinnedStates) Release() {
+	s.released.Do(func() {
+		s.sf.pinMu.Lock()
+		defer s.sf.pinMu.Unlock()
+
+		if s.sf.pinned[s.height]--; s.sf.pinned[s.height] <= 0 {
+			delete(s.sf.pinned, s.height)
+		}
+	})
+}
+
+// RestoreSnapshot rebuilds the states from the chunks of a snapshot, and replaces the current states with them once
+// the rebuilt trie matches the root and the metadata matches the accounts. The root needs to come from a block verified
+// against the delegates, and the metadata is checked against it as far as the accounts tell:
+//   - the candidates are exactly the accounts registered as candidates, with the votes of the accounts
+//   - the candidate pools are well-formed heaps within their capacities, and the pool of the delegates is filled first
+//     and holds the candidates with the most votes
+//   - the productivity is empty, as the snapshot is at the end of an epoch or the productivity is not needed
+//   - the unbonding accounts are indexed from the accounts rather than taken from the metadata
+// Which of the candidates with the fewest votes fell out of the full pools depends on the order of the earlier updates,
+// which the accounts do not tell. The current states are left as they are if the snapshot is invalid, and the nodes
+// written by the failed restore are deleted. The chunks must come in the order they were exported. Only the states with
+// history enabled can be restored
+func (sf *factory) RestoreSnapshot(root hash.Hash32B, meta []byte, next func() ([]byte, error)) error {
+	if sf.dao == nil || !sf.history {
+		// without history the nodes shared by the current and the restored tries could be deleted with either
+		return errors.New("restoring a snapshot requires the states persisted with history enabled")
+	}
+	sf.dropWorkingSet()
+	cp := &checkpoint{}
+	if err := gob.NewDecoder(bytes.NewBuffer(meta)).Decode(cp); err != nil {
+		return errors.Wrapf(ErrInvalidSnapshot, "failed to decode checkpoint: %v", err)
+	}
+	if cp.Root != root {
+		return errors.Wrapf(ErrInvalidSnapshot, "checkpoint root %x does not match root %x", cp.Root[:8], root[:8])
+	}
+	if !sf.productivitySettled(cp.Height) {
+		return errors.Wrapf(ErrInvalidSnapshot, "height %d is not at the end of an epoch", cp.Height)
+	}
+	if len(cp.Productivity) > 0 {
+		return errors.Wrap(ErrInvalidSnapshot, "productivity of the epoch is not empty")
+	}
+	if err := sf.verifyPools(cp); err != nil {
+		return err
+	}
+	repair, err := sf.backupStates()
+	if err != nil {
+		return err
+	}
+	r, err := sf.newSnapshotRestore(cp.Height)
+	if err != nil {
+		return err
+	}
+	if err := r.run(cp, next); err != nil {
+		if discardErr := r.discard(repair); discardErr != nil {
+			logger.Error().Err(discardErr).Msg("Failed to delete the states of the failed restore")
+		}
+		return err
+	}
+
+	sf.trie = r.accounts
+	if err := trie.TakeHistory(sf.contractTrie, r.storage); err != nil {
+		return err
+	}
+	sf.cachedAccount = make(map[string]*State)
+	sf.cachedContract = make(map[string]*contract)
+	sf.cachedCandidate = make(map[string]*Candidate)
+	sf.candidateHeap.pq = make([]*Candidate, 0)
+	sf.candidateBufferMinHeap.pq = make([]*Candidate, 0)
+	sf.candidateBufferMaxHeap.pq = make([]*Candidate, 0)
+	sf.viewMu.Lock()
+	sf.candidatesLRU.Clear()
+	sf.viewMu.Unlock()
+	sf.undoHistory = nil
+	sf.pendingUndo = nil
+	sf.receipts = nil
+	sf.productivity = make(map[string]uint64)
+	cp.UnbondingIndexed = false
+	if err := sf.restoreCheckpoint(cp); err != nil {
+		return errors.Wrapf(ErrInvalidSnapshot, "failed to restore candidates: %v", err)
+	}
+	if err := sf.checkpointTries(cp.Height); err != nil {
+		return err
+	}
+	if err := sf.saveCheckpoint(); err != nil {
+		return err
+	}
+	logger.Info().
+		Int("accounts", r.numAccounts).
+		Uint64("height", cp.Height).
+		Msg("restored the states from snapshot")
+	return nil
+}
+
+//======================================
+// private functions
+//======================================
+// snapshotAccount collects the code and the storage of the contract of an account
+func (sf *factory) snapshotAccount(pubKeyHash, value []byte) (*snapshotAccount, error) {
+	account := &snapshotAccount{PubKeyHash: pubKeyHash, State: value}
+	state, err := bytesToState(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode state of %x", pubKeyHash)
+	}
+	if len(state.CodeHash) > 0 {
+		if account.Code, err = sf.dao.Get(trie.CodeKVNameSpace, state.CodeHash); err != nil {
+			return nil, errors.Wrapf(err, "failed to get code of hash %x", state.CodeHash)
+		}
+	}
+	if state.Root == hash.ZeroHash32B || state.Root == trie.EmptyRoot {
+		return account, nil
+	}
+	if err := trie.Walk(sf.dao, trie.ContractKVNameSpace, state.Root, func(key, value []byte) error {
+		account.StorageKeys = append(account.StorageKeys, key)
+		account.StorageValues = append(account.StorageValues, value)
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to get storage of %x", pubKeyHash)
+	}
+	return account, nil
+}
+
+// newSnapshotRestore creates the tries to rebuild the states of a snapshot of the given height in
+func (sf *factory) newSnapshotRestore(height uint64) (*snapshotRestore, error) {
+	accounts, err := trie.NewTrieWithHistory(sf.dao, trie.AccountKVNameSpace, trie.EmptyRoot, trie.NodeCacheOption(sf.nodeCache))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create state trie")
+	}
+	if err := sf.indexBranches(accounts, height); err != nil {
+		return nil, err
+	}
+	// the trie only collects the nodes put by the storage tries, so it is never written to
+	storage, err := trie.NewTrieWithHistory(sf.dao, trie.ContractKVNameSpace, trie.EmptyRoot, trie.NodeCacheOption(sf.nodeCache))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create contract storage trie")
+	}
+	return &snapshotRestore{sf: sf, accounts: accounts, storage: storage}, nil
+}
+
+// run rebuilds the accounts from the chunks, and checks them against the root and the candidates of the checkpoint
+func (r *snapshotRestore) run(cp *checkpoint, next func() ([]byte, error)) error {
+	var last []byte
+	numCandidates := 0
+	for {
+		data, err := next()
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			break
+		}
+		var chunk []*snapshotAccount
+		if err := gob.NewDecoder(bytes.NewBuffer(data)).Decode(&chunk); err != nil {
+			return errors.Wrapf(ErrInvalidSnapshot, "failed to decode chunk: %v", err)
+		}
+		keys := make([][]byte, 0, len(chunk))
+		values := make([][]byte, 0, len(chunk))
+		for _, account := range chunk {
+			if last != nil && bytes.Compare(account.PubKeyHash, last) <= 0 {
+				return errors.Wrapf(ErrInvalidSnapshot, "account %x is out of order", account.PubKeyHash)
+			}
+			last = account.PubKeyHash
+			state, err := bytesToState(account.State)
+			if err != nil {
+				return errors.Wrapf(ErrInvalidSnapshot, "failed to decode state of %x: %v", account.PubKeyHash, err)
+			}
+			if state.IsCandidate {
+				numCandidates++
+			}
+			if err := r.restoreContract(account, state); err != nil {
+				return err
+			}
+			keys = append(keys, account.PubKeyHash)
+			values = append(values, account.State)
+		}
+		if err := r.accounts.Commit(keys, values); err != nil {
+			return errors.Wrapf(ErrInvalidSnapshot, "failed to put accounts: %v", err)
+		}
+		r.numAccounts += len(chunk)
+	}
+	if r.accounts.RootHash() != cp.Root {
+		return errors.Wrapf(ErrInvalidSnapshot, "restored root %x does not match root %x", r.accounts.RootHash(), cp.Root[:8])
+	}
+	// the candidates are distinct and each matches a candidate account, so all the candidate accounts are in the
+	// metadata if the numbers match
+	if numCandidates != len(cp.Candidates) {
+		return errors.Wrapf(
+			ErrInvalidSnapshot,
+			"%d candidates in the metadata do not match %d candidate accounts",
+			len(cp.Candidates),
+			numCandidates,
+		)
+	}
+	for _, c := range cp.Candidates {
+		if err := verifyCandidate(r.accounts, c, r.sf.votingRule().stakedAt(cp.Height)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreContract writes the code and the storage of the contract of an account, which must match the code hash and
+// the storage root in the state of the account
+func (r *snapshotRestore) restoreContract(account *snapshotAccount, state *State) error {
+	if len(state.CodeHash) > 0 {
+		codeHash := blake2b.Sum256(account.Code)
+		if !bytes.Equal(codeHash[:], state.CodeHash) {
+			return errors.Wrapf(ErrInvalidSnapshot, "code of %x does not match code hash", account.PubKeyHash)
+		}
+		_, err := r.sf.dao.Get(trie.CodeKVNameSpace, state.CodeHash)
+		switch errors.Cause(err) {
+		case nil:
+		case db.ErrNotExist:
+			if err := r.sf.dao.Put(trie.CodeKVNameSpace, state.CodeHash, account.Code); err != nil {
+				return errors.Wrapf(err, "failed to put code of hash %x", state.CodeHash)
+			}
+			r.codes = append(r.codes, state.CodeHash)
+		default:
+			return errors.Wrapf(err, "failed to get code of hash %x", state.CodeHash)
+		}
+	}
+	if state.Root == hash.ZeroHash32B || state.Root == trie.EmptyRoot {
+		return nil
+	}
+	if len(account.StorageKeys) != len(account.StorageValues) {
+		return errors.Wrapf(ErrInvalidSnapshot, "storage of %x has unmatched keys and values", account.PubKeyHash)
+	}
+	tr, err := trie.NewTrieWithHistory(r.sf.dao, trie.ContractKVNameSpace, trie.EmptyRoot, trie.NodeCacheOption(r.sf.nodeCache))
+	if err != nil {
+		return errors.Wrap(err, "failed to create contract storage trie")
+	}
+	if err := tr.EnableBatch(); err != nil {
+		return err
+	}
+	for i, key := range account.StorageKeys {
+		if err := tr.Upsert(key, account.StorageValues[i]); err != nil {
+			return errors.Wrapf(ErrInvalidSnapshot, "failed to put storage of %x: %v", account.PubKeyHash, err)
+		}
+	}
+	if tr.RootHash() != state.Root {
+		return errors.Wrapf(ErrInvalidSnapshot, "storage of %x does not match storage root", account.PubKeyHash)
+	}
+	if err := tr.Flush(); err != nil {
+		return err
+	}
+	return trie.TakeHistory(r.storage, tr)
+}
+
+// discard deletes the nodes and the code written by the restore, and puts back the nodes of the current states with
+// repair, which the restored tries could share
+func (r *snapshotRestore) discard(repair func() error) error {
+	if err := trie.Discard(r.accounts); err != nil {
+		return err
+	}
+	if err := trie.Discard(r.storage); err != nil {
+		return err
+	}
+	if len(r.codes) > 0 {
+		batch := r.sf.dao.Batch()
+		for _, codeHash := range r.codes {
+			batch.Delete(trie.CodeKVNameSpace, codeHash, "failed to delete code of hash %x", codeHash)
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+	}
+	return repair()
+}
+
+// backupStates copies the nodes of the state trie and the storage tries of the contracts into memory, and returns the
+// function putting them back into DB wherever they are missing
+func (sf *factory) backupStates() (func() error, error) {
+	backup := db.NewMemKVStore()
+	root := sf.trie.RootHash()
+	if _, err := trie.Repair(backup, sf.dao, trie.AccountKVNameSpace, root); err != nil {
+		return nil, errors.Wrap(err, "failed to back up the state trie")
+	}
+	var storageRoots []hash.Hash32B
+	if err := trie.Walk(sf.dao, trie.AccountKVNameSpace, root, func(key, value []byte) error {
+		state, err := bytesToState(value)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode state of %x", key)
+		}
+		if state.Root == hash.ZeroHash32B || state.Root == trie.EmptyRoot {
+			return nil
+		}
+		if _, err := trie.Repair(backup, sf.dao, trie.ContractKVNameSpace, state.Root); err != nil {
+			return errors.Wrapf(err, "failed to back up the storage of %x", key)
+		}
+		storageRoots = append(storageRoots, state.Root)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return func() error {
+		if _, err := trie.Repair(sf.dao, backup, trie.AccountKVNameSpace, root); err != nil {
+			return errors.Wrap(err, "failed to repair the state trie")
+		}
+		for _, storageRoot := range storageRoots {
+			if _, err := trie.Repair(sf.dao, backup, trie.ContractKVNameSpace, storageRoot); err != nil {
+				return errors.Wrapf(err, "failed to repair the storage of root %x", storageRoot[:8])
+			}
+		}
+		return nil
+	}, nil
+}
+
+// productivitySettled returns true if the productivity of the epoch is empty at the height or is never used, which is
+// the case at the end of an epoch once the epoch reward is in effect
+func (sf *factory) productivitySettled(height uint64) bool {
+	if sf.epochReward == nil || sf.epochReward.Sign() == 0 || sf.epochLength == 0 {
+		return true
+	}
+	return !sf.schedule.IsActive(version.FeatureEpochReward, height) || height%sf.epochLength == 0
+}
+
+// verifyPools checks the candidates and the pools of a checkpoint are well-formed. The candidates are distinct, the
+// pools are disjoint heaps of the candidates within their capacities, the buffer min and max heaps hold the same
+// candidates, the buffer is only used once the pool of the delegates is full, and no candidate in the buffer has more
+// votes than one in the pool of the delegates
+func (sf *factory) verifyPools(cp *checkpoint) error {
+	candidates := make(map[string]*Candidate, len(cp.Candidates))
+	for _, c := range cp.Candidates {
+		if c == nil || c.Votes == nil {
+			return errors.Wrap(ErrInvalidSnapshot, "candidate without votes")
+		}
+		if _, ok := candidates[c.Address]; ok {
+			return errors.Wrapf(ErrInvalidSnapshot, "candidate %s is duplicated", c.Address)
+		}
+		if c.CreationHeight > c.LastUpdateHeight || c.LastUpdateHeight > cp.Height {
+			return errors.Wrapf(ErrInvalidSnapshot, "candidate %s has invalid heights", c.Address)
+		}
+		candidates[c.Address] = c
+	}
+	pooled := make(map[string]bool)
+	pool := func(name string, addresses []string, capacity int, less func(a, b *big.Int) bool) ([]*Candidate, error) {
+		if len(addresses) > capacity {
+			return nil, errors.Wrapf(ErrInvalidSnapshot, "%s has %d candidates over capacity %d", name, len(addresses), capacity)
+		}
+		pq := make([]*Candidate, 0, len(addresses))
+		for i, address := range addresses {
+			c, ok := candidates[address]
+			if !ok {
+				return nil, errors.Wrapf(ErrInvalidSnapshot, "candidate %s in %s is missing", address, name)
+			}
+			if i > 0 && less(c.Votes, pq[(i-1)/2].Votes) {
+				return nil, errors.Wrapf(ErrInvalidSnapshot, "%s is not a heap", name)
+			}
+			pq = append(pq, c)
+		}
+		return pq, nil
+	}
+	minFirst := func(a, b *big.Int) bool { return a.Cmp(b) < 0 }
+	maxFirst := func(a, b *big.Int) bool { return a.Cmp(b) > 0 }
+	heapPool, err := pool("candidate pool", cp.Heap, sf.candidateHeap.Capacity, minFirst)
+	if err != nil {
+		return err
+	}
+	bufferMin, err := pool("candidate buffer min heap", cp.BufferMin, sf.candidateBufferMinHeap.Capacity, minFirst)
+	if err != nil {
+		return err
+	}
+	bufferMax, err := pool("candidate buffer max heap", cp.BufferMax, sf.candidateBufferMaxHeap.Capacity, maxFirst)
+	if err != nil {
+		return err
+	}
+	for _, c := range append(heapPool, bufferMin...) {
+		if pooled[c.Address] {
+			return errors.Wrapf(ErrInvalidSnapshot, "candidate %s is in the pools more than once", c.Address)
+		}
+		pooled[c.Address] = true
+	}
+	if len(bufferMin) != len(bufferMax) {
+		return errors.Wrap(ErrInvalidSnapshot, "candidate buffer min and max heaps do not match")
+	}
+	inMin := make(map[string]bool, len(bufferMin))
+	for _, c := range bufferMin {
+		inMin[c.Address] = true
+	}
+	for _, c := range bufferMax {
+		if !inMin[c.Address] {
+			return errors.Wrap(ErrInvalidSnapshot, "candidate buffer min and max heaps do not match")
+		}
+		delete(inMin, c.Address)
+	}
+	if len(bufferMin) == 0 {
+		return nil
+	}
+	if len(heapPool) < sf.candidateHeap.Capacity {
+		return errors.Wrap(ErrInvalidSnapshot, "candidate buffer is used before the candidate pool is full")
+	}
+	if heapPool[0].Votes.Cmp(bufferMax[0].Votes) < 0 {
+		return errors.Wrap(ErrInvalidSnapshot, "candidate in the buffer has more votes than one in the candidate pool")
+	}
+	return nil
+}
+
+// verifyCandidate checks a candidate in the metadata of a snapshot against the account of the candidate in the trie
+func verifyCandidate(tr trie.Trie, c *Candidate, stakedVoting bool) error {
+	pubKeyHash := iotxaddress.GetPubkeyHash(c.Address)
+	if pubKeyHash == nil {
+		return errors.Wrapf(ErrInvalidSnapshot, "invalid candidate address %s", c.Address)
+	}
+	pubKey, err := keypair.BytesToPublicKey(c.PubKey)
+	if err != nil || !bytes.Equal(iotxaddress.HashPubKey(pubKey), pubKeyHash) {
+		return errors.Wrapf(ErrInvalidSnapshot, "public key of candidate %s does not match the address", c.Address)
+	}
+	value, err := tr.Get(pubKeyHash)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidSnapshot, "candidate %s does not exist: %v", c.Address, err)
+	}
+	state, err := bytesToState(value)
+	if err != nil {
+		return errors.Wrapf(ErrInvalidSnapshot, "failed to decode state of candidate %s: %v", c.Address, err)
+	}
+	if !state.IsCandidate || c.Votes == nil || candidateWeight(c.Address, state, stakedVoting).Cmp(c.Votes) != 0 {
+		return errors.Wrapf(ErrInvalidSnapshot, "candidate %s does not match its account", c.Address)
+	}
+	return nil
+}
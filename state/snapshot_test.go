@@ -0,0 +1,260 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/big"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotexproject/iotex-core/blockchain/action"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/iotxaddress"
+	"github.com/iotexproject/iotex-core/trie"
+	"github.com/iotexproject/iotex-core/txvm"
+)
+
+func TestSnapshot(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.PinSnapshot()
+	require.Equal(ErrStatesNotAvailable, errors.Cause(err))
+
+	// a and b are candidates, and c deploys a contract storing a value
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	_, err = sf.CreateState(b.RawAddress, uint64(200))
+	require.NoError(err)
+	_, err = sf.CreateState(c.RawAddress, uint64(1000))
+	require.NoError(err)
+	vote1, err := action.NewVote(1, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = a.PublicKey[:]
+	vote2, err := action.NewVote(1, b.RawAddress, b.RawAddress)
+	require.NoError(err)
+	vote2.SelfPubkey = b.PublicKey[:]
+	code := []byte{txvm.OpData1, 0x01, txvm.OpSStore}
+	deploy, err := action.NewExecution(1, big.NewInt(10), c.RawAddress, "", code, 1000, big.NewInt(0))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{vote1, vote2, deploy}))
+	contractAddress, err := iotxaddress.CreateContractAddress(c.RawAddress, 1)
+	require.NoError(err)
+	invoke, err := action.NewExecution(2, big.NewInt(0), c.RawAddress, contractAddress, []byte{txvm.OpData1, 0x42}, 1000, big.NewInt(0))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{invoke}))
+
+	// the 4 accounts are exported in 2 chunks
+	snapshot, err := sf.PinSnapshot()
+	require.NoError(err)
+	require.Equal(uint64(2), snapshot.Height())
+	var chunks [][]byte
+	require.NoError(snapshot.Export(2, func(chunk []byte) error {
+		chunks = append(chunks, chunk)
+		return nil
+	}))
+	snapshot.Release()
+	require.Equal(2, len(chunks))
+	meta := snapshot.Meta()
+	snapshotRoot := sf.RootHash()
+	restoreMeta := func(target Factory, meta []byte, chunks [][]byte) error {
+		i := 0
+		return target.RestoreSnapshot(snapshotRoot, meta, func() ([]byte, error) {
+			if i == len(chunks) {
+				return nil, nil
+			}
+			i++
+			return chunks[i-1], nil
+		})
+	}
+	restore := func(target Factory, chunks [][]byte) error {
+		return restoreMeta(target, meta, chunks)
+	}
+
+	// the restored states are the same as the exported ones
+	sf2, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf2.CreateState(b.RawAddress, uint64(1))
+	require.NoError(err)
+	require.NoError(restore(sf2, chunks))
+	require.Equal(sf.RootHash(), sf2.RootHash())
+	height, ok := sf2.Height()
+	require.True(ok)
+	require.Equal(uint64(2), height)
+	require.Equal(voteForm(sf.Candidates()), voteForm(sf2.Candidates()))
+	candidates, ok := sf2.CandidatesByHeight(2)
+	require.True(ok)
+	require.Equal(2, len(candidates))
+	balance, err := sf2.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(200), balance)
+	s, err := sf2.State(contractAddress)
+	require.NoError(err)
+	contract, err := newContract(s, sf2.(*factory).dao, sf2.(*factory).nodeCache)
+	require.NoError(err)
+	storedCode, err := contract.Code()
+	require.NoError(err)
+	require.Equal(code, storedCode)
+	value, err := contract.GetState([]byte{0x01})
+	require.NoError(err)
+	require.Equal([]byte{0x42}, value)
+
+	// the restored states go on with the following blocks
+	tsf, err := action.NewTransfer(2, big.NewInt(20), a.RawAddress, c.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(3, []action.Action{tsf}))
+	require.NoError(sf2.CommitStateChanges(3, []action.Action{tsf}))
+	require.Equal(sf.RootHash(), sf2.RootHash())
+
+	// the states are left as they are if the chunks do not match the root
+	sf3, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	root := sf3.RootHash()
+	err = restore(sf3, chunks[:1])
+	require.Equal(ErrInvalidSnapshot, errors.Cause(err))
+	err = restore(sf3, [][]byte{chunks[1], chunks[0]})
+	require.Equal(ErrInvalidSnapshot, errors.Cause(err))
+	err = restore(sf3, [][]byte{chunks[0], []byte("chunk")})
+	require.Equal(ErrInvalidSnapshot, errors.Cause(err))
+	require.Equal(root, sf3.RootHash())
+	_, ok = sf3.Height()
+	require.False(ok)
+
+	// the metadata is checked against the accounts
+	tamper := func(fn func(*checkpoint)) []byte {
+		cp := &checkpoint{}
+		require.NoError(gob.NewDecoder(bytes.NewBuffer(meta)).Decode(cp))
+		fn(cp)
+		var stream bytes.Buffer
+		require.NoError(gob.NewEncoder(&stream).Encode(cp))
+		return stream.Bytes()
+	}
+	byVotes := func(cp *checkpoint, desc bool) []string {
+		candidates := append([]*Candidate{}, cp.Candidates...)
+		sortCandidates(candidates)
+		var addresses []string
+		for _, c := range candidates {
+			if desc {
+				addresses = append([]string{c.Address}, addresses...)
+			} else {
+				addresses = append(addresses, c.Address)
+			}
+		}
+		return addresses
+	}
+	for _, invalid := range [][]byte{
+		// a candidate account is missing from the candidates
+		tamper(func(cp *checkpoint) {
+			cp.Candidates = cp.Candidates[:1]
+			cp.Heap = []string{cp.Candidates[0].Address}
+		}),
+		// the candidate with fewer votes is not on top of the pool
+		tamper(func(cp *checkpoint) { cp.Heap = byVotes(cp, true) }),
+		// a candidate is in both the pool and the buffer
+		tamper(func(cp *checkpoint) {
+			cp.BufferMin = cp.Heap[:1]
+			cp.BufferMax = cp.Heap[:1]
+		}),
+		// the votes of a candidate do not match its account
+		tamper(func(cp *checkpoint) {
+			for _, c := range cp.Candidates {
+				c.Votes = new(big.Int).Add(c.Votes, big.NewInt(1))
+			}
+		}),
+		// the productivity is not checked against the accounts
+		tamper(func(cp *checkpoint) { cp.Productivity = map[string]uint64{a.RawAddress: 1} }),
+	} {
+		err = restoreMeta(sf3, invalid, chunks)
+		require.Equal(ErrInvalidSnapshot, errors.Cause(err))
+	}
+	require.NoError(restoreMeta(sf3, tamper(func(cp *checkpoint) { cp.Heap = byVotes(cp, false) }), chunks))
+	require.Equal(snapshotRoot, sf3.RootHash())
+
+	// a failed restore deletes the nodes and the code it wrote, and keeps the current states
+	sf5, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf5.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	root = sf5.RootHash()
+	err = restoreMeta(sf5, tamper(func(cp *checkpoint) {
+		cp.Candidates = cp.Candidates[:1]
+		cp.Heap = []string{cp.Candidates[0].Address}
+	}), chunks)
+	require.Equal(ErrInvalidSnapshot, errors.Cause(err))
+	dao := sf5.(*factory).dao
+	_, err = trie.NewTrieSharedDB(dao, trie.AccountKVNameSpace, snapshotRoot)
+	require.Error(err)
+	codeHash := blake2b.Sum256(code)
+	_, err = dao.Get(trie.CodeKVNameSpace, codeHash[:])
+	require.Error(err)
+	report, err := trie.Check(dao, trie.AccountKVNameSpace, root, nil)
+	require.NoError(err)
+	require.True(report.OK())
+	balance, err = sf5.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(100), balance)
+
+	// the states without history cannot be restored
+	sf4, err := NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	require.Error(restore(sf4, chunks))
+}
+
+func TestSnapshotPin(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnablePruning = true
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	tsf, err := action.NewTransfer(1, big.NewInt(10), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{tsf}))
+	root := sf.RootHash()
+
+	// the pinned states are not pruned until the snapshot is released
+	snapshot, err := sf.PinSnapshot()
+	require.NoError(err)
+	for height := uint64(2); height <= 3; height++ {
+		tsf, err := action.NewTransfer(height, big.NewInt(10), a.RawAddress, b.RawAddress)
+		require.NoError(err)
+		require.NoError(sf.CommitStateChanges(height, []action.Action{tsf}))
+	}
+	require.NoError(sf.Prune(3))
+	_, err = sf.StateByRoot(b.RawAddress, root)
+	require.NoError(err)
+	require.NoError(snapshot.Export(1, func([]byte) error { return nil }))
+	snapshot.Release()
+	snapshot.Release()
+	require.NoError(sf.Prune(3))
+	_, err = sf.StateByRoot(b.RawAddress, root)
+	require.Error(err)
+
+	// the productivity of the epoch is not in the states, so no snapshot is taken in the middle of an epoch
+	sf, err = NewFactory(&cfg, InMemTrieOption(), EpochRewardOption(100, 0))
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, nil))
+	_, err = sf.PinSnapshot()
+	require.Equal(ErrStatesNotAvailable, errors.Cause(err))
+}
@@ -10,10 +10,20 @@ import (
 	"bytes"
 	"encoding/gob"
 	"math/big"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
 
 	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/proto"
 )
 
+// Unbonding is an amount of unstaked coins, which is released to the balance at the release height
+type Unbonding struct {
+	Amount        *big.Int
+	ReleaseHeight uint64
+}
+
 // State is the canonical representation of an account.
 type State struct {
 	// 0 is reserved from actions in genesis block and coinbase transfers nonces
@@ -26,26 +36,119 @@ type State struct {
 	VotingWeight *big.Int
 	Votee        string
 	Voters       map[string]*big.Int
+	// Staked is the bonded stake locked from spending, and Unbonding is the unstaked coins not released yet
+	Staked    *big.Int
+	Unbonding []*Unbonding
 }
 
+// stateEncodingVersion is the version of the encoding of the states, which is the first byte of the encoded bytes
+const stateEncodingVersion = byte(1)
+
+// stateToBytes encodes the state in protobuf, prefixed with the encoding version. The voters are sorted by address and
+// zero amounts are left out, so the bytes of a state are canonical
 func stateToBytes(s *State) ([]byte, error) {
+	pbAccount := &iproto.AccountPb{
+		Nonce:        s.Nonce,
+		Balance:      bigIntBytes(s.Balance),
+		CodeHash:     s.CodeHash,
+		IsCandidate:  s.IsCandidate,
+		VotingWeight: bigIntBytes(s.VotingWeight),
+		Votee:        s.Votee,
+		Staked:       bigIntBytes(s.Staked),
+	}
+	if s.Root != hash.ZeroHash32B {
+		pbAccount.Root = s.Root[:]
+	}
+	voters := make([]string, 0, len(s.Voters))
+	for address := range s.Voters {
+		voters = append(voters, address)
+	}
+	sort.Strings(voters)
+	for _, address := range voters {
+		pbAccount.Voters = append(pbAccount.Voters, &iproto.VoterPb{
+			Address: address,
+			Weight:  bigIntBytes(s.Voters[address]),
+		})
+	}
+	for _, u := range s.Unbonding {
+		pbAccount.Unbonding = append(pbAccount.Unbonding, &iproto.UnbondingPb{
+			Amount:        bigIntBytes(u.Amount),
+			ReleaseHeight: u.ReleaseHeight,
+		})
+	}
+	ss, err := proto.Marshal(pbAccount)
+	if err != nil {
+		return nil, ErrFailedToMarshalState
+	}
+	return append([]byte{stateEncodingVersion}, ss...), nil
+}
+
+// legacyStateToBytes encodes the state in gob, which is how the states are encoded before FeatureStateEncoding is active
+func legacyStateToBytes(s *State) ([]byte, error) {
 	var ss bytes.Buffer
-	e := gob.NewEncoder(&ss)
-	if err := e.Encode(s); err != nil {
+	if err := gob.NewEncoder(&ss).Encode(s); err != nil {
 		return nil, ErrFailedToMarshalState
 	}
 	return ss.Bytes(), nil
 }
 
+// bytesToState decodes the state, in either the current encoding or the gob encoding used before it was versioned
 func bytesToState(ss []byte) (*State, error) {
+	if len(ss) == 0 {
+		return nil, ErrFailedToUnmarshalState
+	}
+	if ss[0] != stateEncodingVersion {
+		return legacyBytesToState(ss)
+	}
+	pbAccount := &iproto.AccountPb{}
+	if err := proto.Unmarshal(ss[1:], pbAccount); err != nil {
+		return nil, ErrFailedToUnmarshalState
+	}
+	state := &State{
+		Nonce:        pbAccount.GetNonce(),
+		Balance:      new(big.Int).SetBytes(pbAccount.GetBalance()),
+		CodeHash:     pbAccount.GetCodeHash(),
+		IsCandidate:  pbAccount.GetIsCandidate(),
+		VotingWeight: new(big.Int).SetBytes(pbAccount.GetVotingWeight()),
+		Votee:        pbAccount.GetVotee(),
+	}
+	copy(state.Root[:], pbAccount.GetRoot())
+	if len(pbAccount.GetStaked()) > 0 {
+		state.Staked = new(big.Int).SetBytes(pbAccount.GetStaked())
+	}
+	if len(pbAccount.GetVoters()) > 0 {
+		state.Voters = make(map[string]*big.Int, len(pbAccount.GetVoters()))
+		for _, v := range pbAccount.GetVoters() {
+			state.Voters[v.GetAddress()] = new(big.Int).SetBytes(v.GetWeight())
+		}
+	}
+	for _, u := range pbAccount.GetUnbonding() {
+		state.Unbonding = append(state.Unbonding, &Unbonding{
+			Amount:        new(big.Int).SetBytes(u.GetAmount()),
+			ReleaseHeight: u.GetReleaseHeight(),
+		})
+	}
+	return state, nil
+}
+
+// legacyBytesToState decodes the state in gob. A gob stream never starts with the encoding version byte, since it
+// starts with the length of a type definition
+func legacyBytesToState(ss []byte) (*State, error) {
 	var state State
-	e := gob.NewDecoder(bytes.NewBuffer(ss))
-	if err := e.Decode(&state); err != nil {
+	if err := gob.NewDecoder(bytes.NewBuffer(ss)).Decode(&state); err != nil {
 		return nil, ErrFailedToUnmarshalState
 	}
 	return &state, nil
 }
 
+// bigIntBytes returns the bytes of an amount, which is nil if the amount is zero
+func bigIntBytes(amount *big.Int) []byte {
+	if amount == nil || amount.Sign() == 0 {
+		return nil
+	}
+	return amount.Bytes()
+}
+
 // AddBalance adds balance for state
 func (st *State) AddBalance(amount *big.Int) error {
 	st.Balance.Add(st.Balance, amount)
@@ -61,3 +164,81 @@ func (st *State) SubBalance(amount *big.Int) error {
 	st.Balance.Sub(st.Balance, amount)
 	return nil
 }
+
+// addVotes adds the voting weight given by a voter, which is subtracted if the amount is negative
+func (st *State) addVotes(voter string, amount *big.Int) {
+	st.VotingWeight.Add(st.VotingWeight, amount)
+	if st.Voters == nil {
+		st.Voters = make(map[string]*big.Int)
+	}
+	weight, ok := st.Voters[voter]
+	if !ok {
+		weight = big.NewInt(0)
+	}
+	weight = new(big.Int).Add(weight, amount)
+	if weight.Sign() <= 0 {
+		delete(st.Voters, voter)
+		return
+	}
+	st.Voters[voter] = weight
+}
+
+// StakedAmount returns the bonded stake of the state
+func (st *State) StakedAmount() *big.Int {
+	if st.Staked == nil {
+		return big.NewInt(0)
+	}
+	return st.Staked
+}
+
+// UnbondingAmount returns the total amount being unbonded, including the part that can be released already
+func (st *State) UnbondingAmount() *big.Int {
+	total := big.NewInt(0)
+	for _, u := range st.Unbonding {
+		total.Add(total, u.Amount)
+	}
+	return total
+}
+
+// ReleasableAmount returns the unbonding amount that can be released at the given height
+func (st *State) ReleasableAmount(height uint64) *big.Int {
+	total := big.NewInt(0)
+	for _, u := range st.Unbonding {
+		if u.ReleaseHeight <= height {
+			total.Add(total, u.Amount)
+		}
+	}
+	return total
+}
+
+// Bond moves the amount from the balance to the bonded stake
+func (st *State) Bond(amount *big.Int) error {
+	if err := st.SubBalance(amount); err != nil {
+		return err
+	}
+	st.Staked = new(big.Int).Add(st.StakedAmount(), amount)
+	return nil
+}
+
+// Unbond moves the amount from the bonded stake to unbonding, which is released at the given height
+func (st *State) Unbond(amount *big.Int, releaseHeight uint64) error {
+	if amount.Cmp(st.StakedAmount()) == 1 {
+		return ErrNotEnoughStake
+	}
+	st.Staked = new(big.Int).Sub(st.StakedAmount(), amount)
+	st.Unbonding = append(st.Unbonding, &Unbonding{Amount: new(big.Int).Set(amount), ReleaseHeight: releaseHeight})
+	return nil
+}
+
+// Release moves the unbonding amount that can be released at the given height to the balance
+func (st *State) Release(height uint64) {
+	var pending []*Unbonding
+	for _, u := range st.Unbonding {
+		if u.ReleaseHeight <= height {
+			st.Balance.Add(st.Balance, u.Amount)
+			continue
+		}
+		pending = append(pending, u)
+	}
+	st.Unbonding = pending
+}
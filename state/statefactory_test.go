@@ -9,16 +9,20 @@ package state
 import (
 	"math/big"
 	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/golang/groupcache/lru"
 	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/iotexproject/iotex-core/blockchain/action"
 	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/iotxaddress"
 	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/version"
 	"github.com/iotexproject/iotex-core/test/mock/mock_trie"
 	"github.com/iotexproject/iotex-core/testutil"
 	"github.com/iotexproject/iotex-core/trie"
@@ -37,10 +41,57 @@ func TestEncodeDecode(t *testing.T) {
 	require.NotEmpty(ss)
 
 	state, _ := bytesToState(ss)
-	require.Nil(state.Balance)
+	require.Equal(0, state.Balance.Sign())
 	require.Equal(uint64(0x10), state.Nonce)
 	require.Equal(hash.ZeroHash32B, state.Root)
 	require.Nil(state.CodeHash)
+
+	// the voters are encoded in the same order however the map is iterated
+	voters := map[string]*big.Int{"a": big.NewInt(1), "b": big.NewInt(2), "c": big.NewInt(3), "d": big.NewInt(4)}
+	ss, err := stateToBytes(&State{Balance: big.NewInt(10), Voters: voters})
+	require.NoError(err)
+	for i := 0; i < 10; i++ {
+		next, err := stateToBytes(&State{Balance: big.NewInt(10), Voters: voters})
+		require.NoError(err)
+		require.Equal(ss, next)
+	}
+	state, err = bytesToState(ss)
+	require.NoError(err)
+	require.Equal(voters, state.Voters)
+	require.Equal(big.NewInt(10), state.Balance)
+}
+
+func TestDecodeLegacyState(t *testing.T) {
+	require := require.New(t)
+	voters := map[string]*big.Int{"a": big.NewInt(1), "b": big.NewInt(2)}
+	legacy := &State{
+		Nonce:        3,
+		Balance:      big.NewInt(10),
+		IsCandidate:  true,
+		VotingWeight: big.NewInt(3),
+		Votee:        "a",
+		Voters:       voters,
+	}
+	ss, err := legacyStateToBytes(legacy)
+	require.NoError(err)
+	require.NotEqual(stateEncodingVersion, ss[0])
+
+	state, err := bytesToState(ss)
+	require.NoError(err)
+	require.Equal(legacy, state)
+
+	// the legacy state is encoded the same as a state created in the current encoding
+	current, err := stateToBytes(state)
+	require.NoError(err)
+	require.Equal(stateEncodingVersion, current[0])
+	state, err = bytesToState(current)
+	require.NoError(err)
+	require.Equal(uint64(3), state.Nonce)
+	require.Equal(big.NewInt(10), state.Balance)
+	require.Equal(voters, state.Voters)
+
+	_, err = bytesToState(nil)
+	require.Equal(ErrFailedToUnmarshalState, errors.Cause(err))
 }
 
 func TestRootHash(t *testing.T) {
@@ -63,6 +114,7 @@ func TestCreateState(t *testing.T) {
 	trie := mock_trie.NewMockTrie(ctrl)
 	sf, err := NewFactory(&config.Default, PrecreatedTrieOption(trie))
 	require.Nil(err)
+	trie.EXPECT().IndexBranches(true).Times(1)
 	trie.EXPECT().Upsert(gomock.Any(), gomock.Any()).Times(1)
 	addr, err := iotxaddress.NewAddress(true, []byte{0xa4, 0x00, 0x00, 0x00})
 	require.Nil(err)
@@ -97,14 +149,17 @@ func TestNonce(t *testing.T) {
 	addr, err := iotxaddress.NewAddress(true, []byte{0xa4, 0x00, 0x00, 0x00})
 	require.Nil(err)
 	mstate, _ := stateToBytes(&State{Nonce: 0x10})
-	trie.EXPECT().Get(gomock.Any()).Times(1).Return(mstate, nil)
+	reader := mock_trie.NewMockReader(ctrl)
+	trie.EXPECT().Pin().Times(2).Return(reader)
+	reader.EXPECT().Release().Times(2)
+	reader.EXPECT().Get(gomock.Any()).Times(1).Return(mstate, nil)
 	addr, err = iotxaddress.NewAddress(true, []byte{0xa4, 0x00, 0x00, 0x00})
 	require.Nil(err)
 	n, err := sf.Nonce(addr.RawAddress)
 	require.Equal(uint64(0x10), n)
 	require.Nil(err)
 
-	trie.EXPECT().Get(gomock.Any()).Times(1).Return(nil, nil)
+	reader.EXPECT().Get(gomock.Any()).Times(1).Return(nil, nil)
 	_, err = sf.Nonce(addr.RawAddress)
 	require.Equal(ErrFailedToUnmarshalState, err)
 }
@@ -239,6 +294,7 @@ func TestCandidate(t *testing.T) {
 		candidateBufferMaxHeap: CandidateMaxPQ{10, make([]*Candidate, 0)},
 		cachedCandidate:        make(map[string]*Candidate),
 		cachedAccount:          make(map[string]*State),
+		handlers:               []ActionHandler{transferHandler{}, voteHandler{}},
 	}
 	_, err := sf.CreateState(a.RawAddress, uint64(100))
 	require.NoError(t, err)
@@ -256,7 +312,7 @@ func TestCandidate(t *testing.T) {
 	// a:100(0) b:200(0) c:300(0)
 	tx1 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(1), Amount: big.NewInt(10)}
 	tx2 := action.Transfer{Sender: a.RawAddress, Recipient: c.RawAddress, Nonce: uint64(2), Amount: big.NewInt(20)}
-	err = sf.CommitStateChanges(0, []*action.Transfer{&tx1, &tx2}, []*action.Vote{})
+	err = sf.CommitStateChanges(0, []action.Action{&tx1, &tx2})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -265,7 +321,7 @@ func TestCandidate(t *testing.T) {
 	vote, err := action.NewVote(0, a.RawAddress, a.RawAddress)
 	vote.SelfPubkey = a.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote})
+	err = sf.CommitStateChanges(0, []action.Action{vote})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":70"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -274,7 +330,7 @@ func TestCandidate(t *testing.T) {
 	vote2, err := action.NewVote(0, b.RawAddress, b.RawAddress)
 	vote2.SelfPubkey = b.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote2})
+	err = sf.CommitStateChanges(0, []action.Action{vote2})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":70", b.RawAddress + ":210"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -283,21 +339,21 @@ func TestCandidate(t *testing.T) {
 	vote3, err := action.NewVote(1, a.RawAddress, b.RawAddress)
 	vote3.SelfPubkey = a.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote3})
+	err = sf.CommitStateChanges(0, []action.Action{vote3})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":0", b.RawAddress + ":280"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
 	// a(b):70(0) b(b):210(+70=280) !c:320
 
 	tx3 := action.Transfer{Sender: b.RawAddress, Recipient: a.RawAddress, Nonce: uint64(2), Amount: big.NewInt(20)}
-	err = sf.CommitStateChanges(0, []*action.Transfer{&tx3}, []*action.Vote{})
+	err = sf.CommitStateChanges(0, []action.Action{&tx3})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":0", b.RawAddress + ":280"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
 	// a(b):90(0) b(b):190(+90=280) !c:320
 
 	tx4 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(2), Amount: big.NewInt(20)}
-	err = sf.CommitStateChanges(0, []*action.Transfer{&tx4}, []*action.Vote{})
+	err = sf.CommitStateChanges(0, []action.Action{&tx4})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":0", b.RawAddress + ":280"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -306,7 +362,7 @@ func TestCandidate(t *testing.T) {
 	vote4, err := action.NewVote(1, b.RawAddress, a.RawAddress)
 	vote4.SelfPubkey = b.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote4})
+	err = sf.CommitStateChanges(0, []action.Action{vote4})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":210", b.RawAddress + ":70"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -315,7 +371,7 @@ func TestCandidate(t *testing.T) {
 	vote5, err := action.NewVote(2, b.RawAddress, b.RawAddress)
 	vote5.SelfPubkey = b.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote5})
+	err = sf.CommitStateChanges(0, []action.Action{vote5})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":0", b.RawAddress + ":280"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -324,14 +380,14 @@ func TestCandidate(t *testing.T) {
 	vote6, err := action.NewVote(3, b.RawAddress, b.RawAddress)
 	vote6.SelfPubkey = b.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote6})
+	err = sf.CommitStateChanges(0, []action.Action{vote6})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":0", b.RawAddress + ":280"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
 	// a(b):70(0) b(b):210(+70=280) !c:320
 
 	tx5 := action.Transfer{Sender: c.RawAddress, Recipient: a.RawAddress, Nonce: uint64(2), Amount: big.NewInt(20)}
-	err = sf.CommitStateChanges(0, []*action.Transfer{&tx5}, []*action.Vote{})
+	err = sf.CommitStateChanges(0, []action.Action{&tx5})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":0", b.RawAddress + ":300"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -340,7 +396,7 @@ func TestCandidate(t *testing.T) {
 	vote7, err := action.NewVote(0, c.RawAddress, a.RawAddress)
 	vote7.SelfPubkey = c.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote7})
+	err = sf.CommitStateChanges(0, []action.Action{vote7})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":300", b.RawAddress + ":300"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -349,7 +405,7 @@ func TestCandidate(t *testing.T) {
 	vote8, err := action.NewVote(4, b.RawAddress, c.RawAddress)
 	vote8.SelfPubkey = b.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote8})
+	err = sf.CommitStateChanges(0, []action.Action{vote8})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":300", b.RawAddress + ":90"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -358,7 +414,7 @@ func TestCandidate(t *testing.T) {
 	vote9, err := action.NewVote(1, c.RawAddress, c.RawAddress)
 	vote9.SelfPubkey = c.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote9})
+	err = sf.CommitStateChanges(0, []action.Action{vote9})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":510", b.RawAddress + ":90"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{a.RawAddress + ":0"}))
@@ -367,7 +423,7 @@ func TestCandidate(t *testing.T) {
 	vote10, err := action.NewVote(0, d.RawAddress, e.RawAddress)
 	vote10.SelfPubkey = d.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote10})
+	err = sf.CommitStateChanges(0, []action.Action{vote10})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":510", b.RawAddress + ":90"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{a.RawAddress + ":0"}))
@@ -376,7 +432,7 @@ func TestCandidate(t *testing.T) {
 	vote11, err := action.NewVote(1, d.RawAddress, d.RawAddress)
 	vote11.SelfPubkey = d.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote11})
+	err = sf.CommitStateChanges(0, []action.Action{vote11})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":510", d.RawAddress + ":100"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{a.RawAddress + ":0", b.RawAddress + ":90"}))
@@ -385,7 +441,7 @@ func TestCandidate(t *testing.T) {
 	vote12, err := action.NewVote(2, d.RawAddress, a.RawAddress)
 	vote12.SelfPubkey = d.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote12})
+	err = sf.CommitStateChanges(0, []action.Action{vote12})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":510", a.RawAddress + ":100"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{d.RawAddress + ":0", b.RawAddress + ":90"}))
@@ -394,7 +450,7 @@ func TestCandidate(t *testing.T) {
 	vote13, err := action.NewVote(2, c.RawAddress, d.RawAddress)
 	vote13.SelfPubkey = c.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote13})
+	err = sf.CommitStateChanges(0, []action.Action{vote13})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":210", d.RawAddress + ":300"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{a.RawAddress + ":100", b.RawAddress + ":90"}))
@@ -403,7 +459,7 @@ func TestCandidate(t *testing.T) {
 	vote14, err := action.NewVote(3, c.RawAddress, c.RawAddress)
 	vote14.SelfPubkey = c.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote14})
+	err = sf.CommitStateChanges(0, []action.Action{vote14})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":510", a.RawAddress + ":100"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{d.RawAddress + ":0", b.RawAddress + ":90"}))
@@ -411,7 +467,7 @@ func TestCandidate(t *testing.T) {
 
 	tx6 := action.Transfer{Sender: c.RawAddress, Recipient: e.RawAddress, Nonce: uint64(1), Amount: big.NewInt(200)}
 	tx7 := action.Transfer{Sender: b.RawAddress, Recipient: e.RawAddress, Nonce: uint64(2), Amount: big.NewInt(200)}
-	err = sf.CommitStateChanges(0, []*action.Transfer{&tx6, &tx7}, []*action.Vote{})
+	err = sf.CommitStateChanges(0, []action.Action{&tx6, &tx7})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":110", a.RawAddress + ":100"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{d.RawAddress + ":0", b.RawAddress + ":90"}))
@@ -420,7 +476,7 @@ func TestCandidate(t *testing.T) {
 	vote15, err := action.NewVote(0, e.RawAddress, e.RawAddress)
 	vote15.SelfPubkey = e.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote15})
+	err = sf.CommitStateChanges(0, []action.Action{vote15})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":110", e.RawAddress + ":500"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{d.RawAddress + ":0", b.RawAddress + ":90", a.RawAddress + ":100"}))
@@ -429,7 +485,7 @@ func TestCandidate(t *testing.T) {
 	vote16, err := action.NewVote(0, f.RawAddress, f.RawAddress)
 	vote16.SelfPubkey = f.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote16})
+	err = sf.CommitStateChanges(0, []action.Action{vote16})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{f.RawAddress + ":300", e.RawAddress + ":500"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{c.RawAddress + ":110", b.RawAddress + ":90", a.RawAddress + ":100", d.RawAddress + ":0"}))
@@ -441,14 +497,14 @@ func TestCandidate(t *testing.T) {
 	vote18, err := action.NewVote(1, f.RawAddress, d.RawAddress)
 	vote18.SelfPubkey = f.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote17, vote18})
+	err = sf.CommitStateChanges(0, []action.Action{vote17, vote18})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{d.RawAddress + ":300", e.RawAddress + ":500"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{c.RawAddress + ":110", b.RawAddress + ":90", a.RawAddress + ":100", f.RawAddress + ":0"}))
 	// a(b):90(100) b(c):10(90) c(c):100(+10=110) d(a): 100(300) e(e):500(+0=500) f(d):300(0)
 
 	tx8 := action.Transfer{Sender: f.RawAddress, Recipient: b.RawAddress, Nonce: uint64(1), Amount: big.NewInt(200)}
-	err = sf.CommitStateChanges(0, []*action.Transfer{&tx8}, []*action.Vote{})
+	err = sf.CommitStateChanges(0, []action.Action{&tx8})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":310", e.RawAddress + ":500"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{d.RawAddress + ":100", b.RawAddress + ":90", a.RawAddress + ":100", f.RawAddress + ":0"}))
@@ -456,14 +512,14 @@ func TestCandidate(t *testing.T) {
 	//fmt.Printf("%v \n", voteForm(sf.candidatesBuffer()))
 
 	tx9 := action.Transfer{Sender: b.RawAddress, Recipient: a.RawAddress, Nonce: uint64(1), Amount: big.NewInt(10)}
-	err = sf.CommitStateChanges(0, []*action.Transfer{&tx9}, []*action.Vote{})
+	err = sf.CommitStateChanges(0, []action.Action{&tx9})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":300", e.RawAddress + ":500"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{d.RawAddress + ":100", b.RawAddress + ":100", a.RawAddress + ":100", f.RawAddress + ":0"}))
 	// a(b):100(100) b(c):200(100) c(c):100(+200=300) d(a): 100(100) e(e):500(+0=500) f(d):100(0)
 
 	tx10 := action.Transfer{Sender: e.RawAddress, Recipient: d.RawAddress, Nonce: uint64(1), Amount: big.NewInt(300)}
-	err = sf.CommitStateChanges(1, []*action.Transfer{&tx10}, []*action.Vote{})
+	err = sf.CommitStateChanges(1, []action.Action{&tx10})
 	require.Nil(t, err)
 	height, _ := sf.Candidates()
 	require.True(t, height == 1)
@@ -477,7 +533,7 @@ func TestCandidate(t *testing.T) {
 	vote20, err := action.NewVote(3, d.RawAddress, b.RawAddress)
 	vote20.SelfPubkey = d.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(2, []*action.Transfer{}, []*action.Vote{vote19, vote20})
+	err = sf.CommitStateChanges(2, []action.Action{vote19, vote20})
 	require.Nil(t, err)
 	height, _ = sf.Candidates()
 	require.True(t, height == 2)
@@ -488,7 +544,7 @@ func TestCandidate(t *testing.T) {
 	vote21, err := action.NewVote(4, c.RawAddress, "")
 	vote21.SelfPubkey = c.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(3, []*action.Transfer{}, []*action.Vote{vote21})
+	err = sf.CommitStateChanges(3, []action.Action{vote21})
 	require.Nil(t, err)
 	height, _ = sf.Candidates()
 	require.True(t, height == 3)
@@ -499,7 +555,7 @@ func TestCandidate(t *testing.T) {
 	vote22, err := action.NewVote(4, f.RawAddress, "")
 	vote22.SelfPubkey = f.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(3, []*action.Transfer{}, []*action.Vote{vote22})
+	err = sf.CommitStateChanges(3, []action.Action{vote22})
 	require.Nil(t, err)
 	height, _ = sf.Candidates()
 	require.True(t, height == 3)
@@ -524,6 +580,7 @@ func TestUnvote(t *testing.T) {
 		candidateBufferMaxHeap: CandidateMaxPQ{10, make([]*Candidate, 0)},
 		cachedCandidate:        make(map[string]*Candidate),
 		cachedAccount:          make(map[string]*State),
+		handlers:               []ActionHandler{transferHandler{}, voteHandler{}},
 	}
 	_, err := sf.CreateState(a.RawAddress, uint64(100))
 	require.NoError(t, err)
@@ -533,7 +590,7 @@ func TestUnvote(t *testing.T) {
 	vote1, err := action.NewVote(0, a.RawAddress, "")
 	vote1.SelfPubkey = a.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote1})
+	err = sf.CommitStateChanges(0, []action.Action{vote1})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -541,7 +598,7 @@ func TestUnvote(t *testing.T) {
 	vote2, err := action.NewVote(0, a.RawAddress, a.RawAddress)
 	vote2.SelfPubkey = a.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote2})
+	err = sf.CommitStateChanges(0, []action.Action{vote2})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{a.RawAddress + ":100"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -549,7 +606,7 @@ func TestUnvote(t *testing.T) {
 	vote3, err := action.NewVote(0, a.RawAddress, "")
 	vote3.SelfPubkey = a.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote3})
+	err = sf.CommitStateChanges(0, []action.Action{vote3})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
@@ -563,12 +620,438 @@ func TestUnvote(t *testing.T) {
 	vote6, err := action.NewVote(0, a.RawAddress, "")
 	vote6.SelfPubkey = a.PublicKey[:]
 	require.NoError(t, err)
-	err = sf.CommitStateChanges(0, []*action.Transfer{}, []*action.Vote{vote4, vote5, vote6})
+	err = sf.CommitStateChanges(0, []action.Action{vote4, vote5, vote6})
 	require.Nil(t, err)
 	require.True(t, compareStrings(voteForm(sf.Candidates()), []string{b.RawAddress + ":200"}))
 	require.True(t, compareStrings(voteForm(sf.candidatesBuffer()), []string{}))
 }
 
+func TestRunActions(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	sf, err := NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	root := sf.RootHash()
+
+	tx1 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(1), Amount: big.NewInt(10)}
+	vote1, err := action.NewVote(2, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = a.PublicKey[:]
+	newRoot, err := sf.RunActions(1, []action.Action{&tx1, vote1})
+	require.NoError(err)
+	require.NotEqual(root, newRoot)
+
+	// running actions does not change the committed states
+	require.Equal(root, sf.RootHash())
+	balance, err := sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(100), balance)
+	_, err = sf.State(b.RawAddress)
+	require.Equal(ErrAccountNotExist, err)
+	h, candidates := sf.Candidates()
+	require.Equal(uint64(0), h)
+	require.Empty(candidates)
+
+	// committing the same actions results in the same root
+	require.NoError(sf.CommitStateChanges(1, []action.Action{&tx1, vote1}))
+	require.Equal(newRoot, sf.RootHash())
+
+	// not enough balance
+	tx2 := action.Transfer{Sender: b.RawAddress, Recipient: a.RawAddress, Nonce: uint64(1), Amount: big.NewInt(20)}
+	_, err = sf.RunActions(2, []action.Action{&tx2})
+	require.Equal(ErrNotEnoughBalance, err)
+	require.Equal(newRoot, sf.RootHash())
+}
+
+// burnAction is a transfer not handled by the default handlers, for testing the custom action handlers
+type burnAction struct {
+	*action.Transfer
+}
+
+type burnHandler struct{}
+
+func (burnHandler) Handle(_ uint64, act action.Action, ws WorkingSet) (bool, error) {
+	burn, ok := act.(*burnAction)
+	if !ok {
+		return false, nil
+	}
+	sender, err := ws.CachedState(burn.Sender)
+	if err != nil {
+		return true, err
+	}
+	return true, sender.SubBalance(burn.Amount)
+}
+
+func TestActionHandlers(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	sf, err := NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+
+	burn := &burnAction{&action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(1), Amount: big.NewInt(30)}}
+	_, err = sf.RunActions(1, []action.Action{burn})
+	require.Equal(ErrUnhandledAction, errors.Cause(err))
+
+	sf.AddActionHandlers(burnHandler{})
+	tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(2), Amount: big.NewInt(10)}
+	require.NoError(sf.CommitStateChanges(1, []action.Action{burn, &tx}))
+	state, err := sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(60), state.Balance)
+	require.Equal(uint64(2), state.Nonce)
+	balance, err := sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(10), balance)
+}
+
+// countingHandler counts the burn actions it handles
+type countingHandler struct {
+	burnHandler
+	count int
+}
+
+func (h *countingHandler) Handle(blockHeight uint64, act action.Action, ws WorkingSet) (bool, error) {
+	ok, err := h.burnHandler.Handle(blockHeight, act, ws)
+	if ok {
+		h.count++
+	}
+	return ok, err
+}
+
+func TestCommitValidatedActions(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.MaxReorgDepth = 2
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	counter := &countingHandler{}
+	sf.AddActionHandlers(counter)
+	root := sf.RootHash()
+
+	burn1 := &burnAction{&action.Transfer{Sender: a.RawAddress, Nonce: uint64(1), Amount: big.NewInt(30)}}
+	tx1 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(2), Amount: big.NewInt(10)}
+	vote1, err := action.NewVote(3, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = a.PublicKey[:]
+	acts := []action.Action{burn1, &tx1, vote1}
+	newRoot, err := sf.RunActions(1, acts)
+	require.NoError(err)
+	require.Equal(1, counter.count)
+
+	// validating and committing the same actions reuse the working set
+	validated, err := sf.RunActions(1, acts)
+	require.NoError(err)
+	require.Equal(newRoot, validated)
+	require.NoError(sf.CommitStateChanges(1, acts))
+	require.Equal(1, counter.count)
+	require.Equal(newRoot, sf.RootHash())
+	balance, err := sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(60), balance)
+	balance, err = sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(10), balance)
+	h, candidates := sf.Candidates()
+	require.Equal(uint64(1), h)
+	require.Equal(1, len(candidates))
+
+	// the working set of other actions is not committed
+	burn2 := &burnAction{&action.Transfer{Sender: a.RawAddress, Nonce: uint64(4), Amount: big.NewInt(10)}}
+	burn3 := &burnAction{&action.Transfer{Sender: a.RawAddress, Nonce: uint64(4), Amount: big.NewInt(20)}}
+	_, err = sf.RunActions(2, []action.Action{burn2})
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{burn3}))
+	require.Equal(3, counter.count)
+	balance, err = sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(40), balance)
+
+	// the adopted working set is undone as if the actions were applied
+	require.NoError(sf.Rollback(0))
+	require.Equal(root, sf.RootHash())
+	_, err = sf.State(b.RawAddress)
+	require.Equal(ErrAccountNotExist, err)
+	h, candidates = sf.Candidates()
+	require.Equal(uint64(0), h)
+	require.Empty(candidates)
+}
+
+func TestFees(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	p, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	sf, err := NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+
+	// c self-nominates and a votes for c, free of charge
+	vote1, err := action.NewVote(1, c.RawAddress, c.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = c.PublicKey[:]
+	vote2, err := action.NewVote(1, a.RawAddress, c.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{vote1, vote2}))
+	s, err := sf.State(c.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(100), s.VotingWeight)
+
+	// the fees are paid by the senders to the recipient of the coinbase transfer
+	cb := action.NewCoinBaseTransfer(big.NewInt(5), p.RawAddress)
+	tx1, err := action.NewTransfer(2, big.NewInt(10), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	tx1.GasPrice = big.NewInt(2)
+	vote3, err := action.NewVote(1, b.RawAddress, b.RawAddress)
+	require.NoError(err)
+	vote3.SelfPubkey = b.PublicKey[:]
+	vote3.GasPrice = big.NewInt(1).Bytes()
+	require.NoError(sf.CommitStateChanges(2, []action.Action{cb, tx1, vote3}))
+
+	balance, err := sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(70), balance)
+	balance, err = sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(0), balance)
+	balance, err = sf.Balance(p.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(35), balance)
+	// the voting weight of a's votee drops by both the amount and the fee
+	s, err = sf.State(c.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(70), s.VotingWeight)
+
+	// the balance has to cover both the amount and the fee
+	tx2, err := action.NewTransfer(3, big.NewInt(60), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	tx2.GasPrice = big.NewInt(2)
+	_, err = sf.RunActions(3, []action.Action{tx2})
+	require.Equal(ErrNotEnoughBalance, err)
+	tx2.GasPrice = big.NewInt(1)
+	_, err = sf.RunActions(3, []action.Action{tx2})
+	require.NoError(err)
+}
+
+func TestRollback(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.MaxReorgDepth = 2
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	root0 := sf.RootHash()
+
+	// a transfers to b and self-nominates
+	tx1 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(1), Amount: big.NewInt(10)}
+	vote1, err := action.NewVote(2, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = a.PublicKey[:]
+	require.NoError(sf.CommitStateChanges(1, []action.Action{&tx1, vote1}))
+	root1 := sf.RootHash()
+	_, candidates1 := sf.Candidates()
+	require.Equal(1, len(candidates1))
+	votes1 := new(big.Int).Set(candidates1[0].Votes)
+
+	// b transfers to c and votes for a
+	tx2 := action.Transfer{Sender: b.RawAddress, Recipient: c.RawAddress, Nonce: uint64(1), Amount: big.NewInt(5)}
+	vote2, err := action.NewVote(2, b.RawAddress, a.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{&tx2, vote2}))
+	_, candidates2 := sf.Candidates()
+	require.Equal(1, len(candidates2))
+	require.NotEqual(votes1, candidates2[0].Votes)
+
+	// cannot rollback to a height above the current one
+	require.Error(sf.Rollback(3))
+
+	// revert block 2
+	require.NoError(sf.Rollback(1))
+	require.Equal(root1, sf.RootHash())
+	h, candidates := sf.Candidates()
+	require.Equal(uint64(1), h)
+	require.Equal(1, len(candidates))
+	require.Equal(votes1, candidates[0].Votes)
+	_, ok := sf.CandidatesByHeight(2)
+	require.False(ok)
+	_, err = sf.State(c.RawAddress)
+	require.Equal(ErrAccountNotExist, err)
+	balance, err := sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(10), balance)
+
+	// commit a different block 2 on top of the reverted states
+	tx3 := action.Transfer{Sender: a.RawAddress, Recipient: c.RawAddress, Nonce: uint64(3), Amount: big.NewInt(20)}
+	root2, err := sf.RunActions(2, []action.Action{&tx3})
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{&tx3}))
+	require.Equal(root2, sf.RootHash())
+	balance, err = sf.Balance(c.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(20), balance)
+
+	// revert both blocks
+	require.NoError(sf.Rollback(0))
+	require.Equal(root0, sf.RootHash())
+	h, candidates = sf.Candidates()
+	require.Equal(uint64(0), h)
+	require.Empty(candidates)
+	_, err = sf.State(b.RawAddress)
+	require.Equal(ErrAccountNotExist, err)
+
+	// only the last 2 blocks can be reverted
+	for i := uint64(1); i <= 3; i++ {
+		tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: i, Amount: big.NewInt(1)}
+		require.NoError(sf.CommitStateChanges(i, []action.Action{&tx}))
+	}
+	err = sf.Rollback(0)
+	require.Equal(ErrRollbackTooDeep, errors.Cause(err))
+	require.NoError(sf.Rollback(1))
+	balance, err = sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(1), balance)
+}
+
+func TestRollbackReaders(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.MaxReorgDepth = 2
+	f, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	sf := f.(*factory)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	tx1 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(1), Amount: big.NewInt(10)}
+	vote1, err := action.NewVote(2, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = a.PublicKey[:]
+	require.NoError(sf.CommitStateChanges(1, []action.Action{&tx1, vote1}))
+	root1 := sf.RootHash()
+	tx2 := action.Transfer{Sender: b.RawAddress, Recipient: c.RawAddress, Nonce: uint64(1), Amount: big.NewInt(5)}
+	vote2, err := action.NewVote(2, b.RawAddress, a.RawAddress)
+	require.NoError(err)
+	block2 := []action.Action{&tx2, vote2}
+	require.NoError(sf.CommitStateChanges(2, block2))
+	root2 := sf.RootHash()
+	_, candidates := sf.Candidates()
+	votes2 := new(big.Int).Set(candidates[0].Votes)
+
+	// the readers only see the states of block 1 or block 2 while block 2 is reverted and committed again
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	errs := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			r := sf.trie.Pin()
+			root := r.RootHash()
+			r.Release()
+			height, _ := sf.Candidates()
+			if (root != root1 && root != root2) || height == 0 {
+				errs <- errors.Errorf("read root %x at height %d", root, height)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		require.NoError(sf.Rollback(1))
+		require.NoError(sf.CommitStateChanges(2, block2))
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	require.NoError(<-errs)
+	// the candidates returned are not changed by the later blocks
+	require.NoError(sf.Rollback(1))
+	require.Equal(votes2, candidates[0].Votes)
+}
+
+func TestRollbackAfterRestart(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	cfg.Chain.MaxReorgDepth = 2
+	dao := db.NewMemKVStore()
+	sf, err := NewFactory(&cfg, PrecreatedDBOption(dao))
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, nil))
+	root0 := sf.RootHash()
+	tx1 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(1), Amount: big.NewInt(10)}
+	vote1, err := action.NewVote(2, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = a.PublicKey[:]
+	require.NoError(sf.CommitStateChanges(1, []action.Action{&tx1, vote1}))
+	root1 := sf.RootHash()
+	tx2 := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: uint64(3), Amount: big.NewInt(20)}
+	require.NoError(sf.CommitStateChanges(2, []action.Action{&tx2}))
+
+	// the undo history is reloaded along with the states
+	sf, err = NewFactory(&cfg, PrecreatedDBOption(dao))
+	require.NoError(err)
+	require.NoError(sf.Rollback(1))
+	require.Equal(root1, sf.RootHash())
+	balance, err := sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(10), balance)
+	h, candidates := sf.Candidates()
+	require.Equal(uint64(1), h)
+	require.Equal(1, len(candidates))
+
+	sf, err = NewFactory(&cfg, PrecreatedDBOption(dao))
+	require.NoError(err)
+	h, ok := sf.Height()
+	require.True(ok)
+	require.Equal(uint64(1), h)
+	require.NoError(sf.Rollback(0))
+	require.Equal(root0, sf.RootHash())
+	_, err = sf.State(b.RawAddress)
+	require.Equal(ErrAccountNotExist, err)
+	h, candidates = sf.Candidates()
+	require.Equal(uint64(0), h)
+	require.Empty(candidates)
+
+	// the reverted block can be replaced
+	require.NoError(sf.CommitStateChanges(1, []action.Action{&tx2}))
+	balance, err = sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(20), balance)
+}
+
 func compareStrings(actual []string, expected []string) bool {
 	act := make(map[string]bool)
 	for i := 0; i < len(actual); i++ {
@@ -584,3 +1067,785 @@ func compareStrings(actual []string, expected []string) bool {
 	}
 	return len(act) == 0
 }
+
+func TestPruning(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	testutil.CleanupPath(t, testTriePath)
+	defer testutil.CleanupPath(t, testTriePath)
+	cfg := config.Default
+	cfg.Chain.TrieDBPath = testTriePath
+	cfg.Chain.EnablePruning = true
+	sf, err := NewFactory(&cfg, DefaultTrieOption())
+	require.NoError(err)
+	_, ok := sf.Height()
+	require.False(ok)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, nil))
+	root0 := sf.RootHash()
+
+	vote, err := action.NewVote(1, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote.SelfPubkey = a.PublicKey[:]
+	require.NoError(sf.CommitStateChanges(1, []action.Action{vote}))
+	root1 := sf.RootHash()
+	for i := uint64(2); i <= 3; i++ {
+		tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: i, Amount: big.NewInt(1)}
+		require.NoError(sf.CommitStateChanges(i, []action.Action{&tx}))
+	}
+	root3 := sf.RootHash()
+	_, candidates := sf.Candidates()
+	require.Equal(1, len(candidates))
+
+	// the states before height 2 are dropped
+	require.NoError(sf.Prune(2))
+	dao := sf.(*factory).dao
+	_, err = trie.NewTrieSharedDB(dao, trie.AccountKVNameSpace, root0)
+	require.Error(err)
+	_, err = trie.NewTrieSharedDB(dao, trie.AccountKVNameSpace, root1)
+	require.Error(err)
+	// so are the candidates
+	for h := uint64(0); h < 2; h++ {
+		_, err = dao.Get(stateMetaKVNameSpace, candidatesKey(h))
+		require.Equal(db.ErrNotExist, errors.Cause(err))
+	}
+	_, ok = sf.CandidatesByHeight(1)
+	require.False(ok)
+	_, ok = sf.CandidatesByHeight(2)
+	require.True(ok)
+	require.NoError(sf.(*factory).trie.Close())
+
+	// the states are reopened at the last committed height
+	sf, err = NewFactory(&cfg, DefaultTrieOption())
+	require.NoError(err)
+	height, ok := sf.Height()
+	require.True(ok)
+	require.Equal(uint64(3), height)
+	require.Equal(root3, sf.RootHash())
+	h, restored := sf.Candidates()
+	require.Equal(uint64(3), h)
+	require.Equal(candidates, restored)
+	balance, err := sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(2), balance)
+	require.NoError(sf.(*factory).trie.Close())
+}
+
+func TestStateEncodingUpgrade(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	schedule := version.Schedule{{Height: 3, Version: 2, Features: []string{version.FeatureStateEncoding}}}
+	tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: 1, Amount: big.NewInt(10)}
+	blocks := [][]action.Action{nil, {&tx}, nil, nil}
+
+	// the states in the current encoding since the genesis
+	sf, err := NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	var expected []hash.Hash32B
+	for h, acts := range blocks {
+		require.NoError(sf.CommitStateChanges(uint64(h), acts))
+		expected = append(expected, sf.RootHash())
+	}
+
+	testutil.CleanupPath(t, testTriePath)
+	defer testutil.CleanupPath(t, testTriePath)
+	for _, history := range []bool{false, true} {
+		testutil.CleanupPath(t, testTriePath)
+		cfg := config.Default
+		cfg.Chain.TrieDBPath = testTriePath
+		cfg.Chain.EnablePruning = history
+		sf, err := NewFactory(&cfg, DefaultTrieOption(), ScheduleOption(schedule))
+		require.NoError(err)
+		_, err = sf.CreateState(a.RawAddress, uint64(100))
+		require.NoError(err)
+		f := sf.(*factory)
+		var roots []hash.Hash32B
+		for h, acts := range blocks {
+			if h == 3 {
+				root, err := sf.RunActions(uint64(h), acts)
+				require.NoError(err)
+				require.Equal(expected[h], root)
+			}
+			require.NoError(sf.CommitStateChanges(uint64(h), acts))
+			roots = append(roots, sf.RootHash())
+			// the states are in gob before the upgrade, and all converted by the block of the upgrade
+			require.NoError(trie.Walk(f.dao, trie.AccountKVNameSpace, sf.RootHash(), func(key, value []byte) error {
+				require.Equal(h >= 3, value[0] == stateEncodingVersion)
+				return nil
+			}))
+		}
+		require.NotEqual(expected[2], roots[2])
+		require.Equal(expected[3], roots[3])
+		balance, err := sf.Balance(a.RawAddress)
+		require.NoError(err)
+		require.Equal(big.NewInt(90), balance)
+
+		// reverting the block of the upgrade brings back the states in gob
+		require.NoError(sf.Rollback(2))
+		require.Equal(roots[2], sf.RootHash())
+		state, err := sf.State(b.RawAddress)
+		require.NoError(err)
+		require.Equal(big.NewInt(10), state.Balance)
+		require.NoError(f.trie.Close())
+	}
+}
+
+func TestBranchIndexUpgrade(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	schedule := version.Schedule{{Height: 3, Version: 2, Features: []string{version.FeatureBranchIndex}}}
+	tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: 1, Amount: big.NewInt(10)}
+	blocks := [][]action.Action{nil, {&tx}, nil, nil}
+
+	// the branches are indexed since the genesis
+	sf, err := NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	var expected []hash.Hash32B
+	for h, acts := range blocks {
+		require.NoError(sf.CommitStateChanges(uint64(h), acts))
+		expected = append(expected, sf.RootHash())
+	}
+
+	testutil.CleanupPath(t, testTriePath)
+	defer testutil.CleanupPath(t, testTriePath)
+	for _, history := range []bool{false, true} {
+		testutil.CleanupPath(t, testTriePath)
+		cfg := config.Default
+		cfg.Chain.TrieDBPath = testTriePath
+		cfg.Chain.EnablePruning = history
+		sf, err := NewFactory(&cfg, DefaultTrieOption(), ScheduleOption(schedule))
+		require.NoError(err)
+		_, err = sf.CreateState(a.RawAddress, uint64(100))
+		require.NoError(err)
+		f := sf.(*factory)
+		var roots []hash.Hash32B
+		for h, acts := range blocks {
+			if h == 3 {
+				root, err := sf.RunActions(uint64(h), acts)
+				require.NoError(err)
+				require.Equal(expected[h], root)
+			}
+			require.NoError(sf.CommitStateChanges(uint64(h), acts))
+			roots = append(roots, sf.RootHash())
+			// the proofs are of the branches hashed the way of the height
+			proof, err := sf.StateProof(a.RawAddress, sf.RootHash())
+			require.NoError(err)
+			_, err = VerifyStateProof(sf.RootHash(), a.RawAddress, proof, h >= 3)
+			require.NoError(err)
+			if h > 0 {
+				_, err = VerifyStateProof(sf.RootHash(), a.RawAddress, proof, h < 3)
+				require.Equal(trie.ErrInvalidProof, errors.Cause(err))
+			}
+		}
+		require.NotEqual(expected[2], roots[2])
+		require.Equal(expected[3], roots[3])
+
+		// reverting the block of the upgrade brings back the branches without the indexes
+		require.NoError(sf.Rollback(2))
+		require.Equal(roots[2], sf.RootHash())
+		state, err := sf.State(b.RawAddress)
+		require.NoError(err)
+		require.Equal(big.NewInt(10), state.Balance)
+		require.NoError(sf.CommitStateChanges(3, nil))
+		require.Equal(expected[3], sf.RootHash())
+		require.NoError(f.trie.Close())
+	}
+}
+
+func TestHistoricalStates(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	cfg.Chain.DelegateLRUSize = 2
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	vote, err := action.NewVote(1, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote.SelfPubkey = a.PublicKey[:]
+	require.NoError(sf.CommitStateChanges(0, []action.Action{vote}))
+	roots := []hash.Hash32B{sf.RootHash()}
+	for i := uint64(1); i <= 4; i++ {
+		tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: i + 1, Amount: big.NewInt(10)}
+		require.NoError(sf.CommitStateChanges(i, []action.Action{&tx}))
+		roots = append(roots, sf.RootHash())
+	}
+
+	// the states of every height are kept
+	_, err = sf.StateByRoot(b.RawAddress, roots[0])
+	require.Equal(ErrAccountNotExist, errors.Cause(err))
+	for i, root := range roots {
+		s, err := sf.StateByRoot(a.RawAddress, root)
+		require.NoError(err)
+		require.Equal(big.NewInt(int64(100-10*i)), s.Balance)
+		if i > 0 {
+			s, err = sf.StateByRoot(b.RawAddress, root)
+			require.NoError(err)
+			require.Equal(big.NewInt(int64(10*i)), s.Balance)
+		}
+	}
+
+	// the candidates of the heights evicted from the cache are loaded from DB
+	for i := uint64(0); i <= 4; i++ {
+		candidates, ok := sf.CandidatesByHeight(i)
+		require.True(ok)
+		require.Equal(1, len(candidates))
+		require.Equal(a.RawAddress, candidates[0].Address)
+	}
+	_, ok := sf.CandidatesByHeight(5)
+	require.False(ok)
+
+	// without history, only the states of the current root are available
+	sf, err = NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, nil))
+	root := sf.RootHash()
+	s, err := sf.StateByRoot(a.RawAddress, root)
+	require.NoError(err)
+	require.Equal(big.NewInt(100), s.Balance)
+	tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: 1, Amount: big.NewInt(10)}
+	require.NoError(sf.CommitStateChanges(1, []action.Action{&tx}))
+	_, err = sf.StateByRoot(a.RawAddress, root)
+	require.Equal(ErrStatesNotAvailable, errors.Cause(err))
+}
+
+func TestStateProof(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, nil))
+	root0 := sf.RootHash()
+	tx := action.Transfer{Sender: a.RawAddress, Recipient: b.RawAddress, Nonce: 1, Amount: big.NewInt(10)}
+	require.NoError(sf.CommitStateChanges(1, []action.Action{&tx}))
+	root1 := sf.RootHash()
+
+	proof, err := sf.StateProof(b.RawAddress, root1)
+	require.NoError(err)
+	s, err := VerifyStateProof(root1, b.RawAddress, proof, true)
+	require.NoError(err)
+	require.Equal(big.NewInt(10), s.Balance)
+	// the proof of an earlier root has the balance as of that root
+	proof, err = sf.StateProof(a.RawAddress, root0)
+	require.NoError(err)
+	s, err = VerifyStateProof(root0, a.RawAddress, proof, true)
+	require.NoError(err)
+	require.Equal(big.NewInt(100), s.Balance)
+	_, err = VerifyStateProof(root1, a.RawAddress, proof, true)
+	require.Equal(trie.ErrInvalidProof, errors.Cause(err))
+
+	// the address not existing is proven too
+	proof, err = sf.StateProof(c.RawAddress, root1)
+	require.NoError(err)
+	_, err = VerifyStateProof(root1, c.RawAddress, proof, true)
+	require.Equal(ErrAccountNotExist, errors.Cause(err))
+	proof, err = sf.StateProof(b.RawAddress, root0)
+	require.NoError(err)
+	_, err = VerifyStateProof(root0, b.RawAddress, proof, true)
+	require.Equal(ErrAccountNotExist, errors.Cause(err))
+	_, err = sf.StateProof("invalid", root1)
+	require.Equal(ErrInvalidAddr, errors.Cause(err))
+
+	// without history, only the proofs of the current root are available
+	cfg.Chain.EnableArchiveMode = false
+	sf, err = NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, nil))
+	_, err = sf.StateProof(a.RawAddress, root1)
+	require.Equal(ErrStatesNotAvailable, errors.Cause(err))
+	proof, err = sf.StateProof(a.RawAddress, sf.RootHash())
+	require.NoError(err)
+	s, err = VerifyStateProof(sf.RootHash(), a.RawAddress, proof, true)
+	require.NoError(err)
+	require.Equal(big.NewInt(100), s.Balance)
+}
+
+func TestAccountsByRoot(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	candidates, err := sf.CandidatesByRoot(sf.RootHash())
+	require.NoError(err)
+	require.Empty(candidates)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	_, err = sf.CreateState(b.RawAddress, uint64(200))
+	require.NoError(err)
+	_, err = sf.CreateState(c.RawAddress, uint64(300))
+	require.NoError(err)
+	vote1, err := action.NewVote(1, a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = a.PublicKey[:]
+	vote2, err := action.NewVote(1, b.RawAddress, b.RawAddress)
+	require.NoError(err)
+	vote2.SelfPubkey = b.PublicKey[:]
+	require.NoError(sf.CommitStateChanges(1, []action.Action{vote1, vote2}))
+	root1 := sf.RootHash()
+	vote3, err := action.NewVote(1, c.RawAddress, a.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{vote3}))
+
+	// all the accounts are listed with their addresses
+	balances := make(map[string]*big.Int)
+	require.NoError(sf.AccountsByRoot(root1, func(addr string, s *State) error {
+		balances[addr] = s.Balance
+		return nil
+	}))
+	require.Equal(map[string]*big.Int{
+		a.RawAddress: big.NewInt(100),
+		b.RawAddress: big.NewInt(200),
+		c.RawAddress: big.NewInt(300),
+	}, balances)
+	stop := errors.New("stop")
+	var n int
+	err = sf.AccountsByRoot(root1, func(string, *State) error {
+		n++
+		return stop
+	})
+	require.Equal(stop, err)
+	require.Equal(1, n)
+
+	// the candidates of each root are the ones in the pool as of the root
+	candidates, err = sf.CandidatesByRoot(root1)
+	require.NoError(err)
+	require.Equal([]string{a.RawAddress + ":100", b.RawAddress + ":200"}, voteForm(0, candidates))
+	candidates, err = sf.CandidatesByRoot(sf.RootHash())
+	require.NoError(err)
+	require.Equal(voteForm(sf.Candidates()), voteForm(0, candidates))
+	require.Equal([]string{b.RawAddress + ":200", a.RawAddress + ":400"}, voteForm(0, candidates))
+
+	// without history, only the current root can be listed
+	cfg.Chain.EnableArchiveMode = false
+	sf, err = NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	err = sf.AccountsByRoot(root1, func(string, *State) error { return nil })
+	require.Equal(ErrStatesNotAvailable, errors.Cause(err))
+	_, err = sf.CandidatesByRoot(root1)
+	require.Equal(ErrStatesNotAvailable, errors.Cause(err))
+}
+
+func TestStaking(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	sf, err := NewFactory(&config.Default, InMemTrieOption(), StakingOption(true, 2))
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+
+	// c self-nominates and a votes for c, but the liquid balance of a does not count
+	vote1, err := action.NewVote(1, c.RawAddress, c.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = c.PublicKey[:]
+	vote2, err := action.NewVote(1, a.RawAddress, c.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, []action.Action{vote1, vote2}))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":0"}))
+
+	// the bonded stake counts as voting weight and is locked from spending
+	stake, err := action.NewStake(2, big.NewInt(60), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{stake}))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":60"}))
+	tx1, err := action.NewTransfer(3, big.NewInt(10), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{tx1}))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":60"}))
+	tx2, err := action.NewTransfer(4, big.NewInt(40), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	_, err = sf.RunActions(3, []action.Action{tx2})
+	require.Equal(ErrNotEnoughBalance, errors.Cause(err))
+
+	// the unstaked coins stop counting at once, but are released only after the unbonding period
+	unstake, err := action.NewUnstake(4, big.NewInt(20), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(3, []action.Action{unstake}))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":40"}))
+	s, err := sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(30), s.Balance)
+	require.Equal(big.NewInt(40), s.StakedAmount())
+	require.Equal(big.NewInt(20), s.UnbondingAmount())
+	balance, err := sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(30), balance)
+	require.NoError(sf.CommitStateChanges(4, nil))
+	balance, err = sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(30), balance)
+	// the coins are released by the block of the release height, whether the account acts or not
+	require.NoError(sf.CommitStateChanges(5, nil))
+	s, err = sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(50), s.Balance)
+	require.Equal(0, s.UnbondingAmount().Sign())
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":40"}))
+	tx3, err := action.NewTransfer(5, big.NewInt(45), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(6, []action.Action{tx3}))
+	balance, err = sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(5), balance)
+
+	// unstaking more than the bonded stake changes nothing but the nonce
+	unstake, err = action.NewUnstake(6, big.NewInt(100), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(7, []action.Action{unstake}))
+	s, err = sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(40), s.StakedAmount())
+	require.Equal(uint64(6), s.Nonce)
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":40"}))
+
+	// without staked voting, all the coins of the voter count whether bonded or not
+	sf, err = NewFactory(&config.Default, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	stake, err = action.NewStake(2, big.NewInt(60), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, []action.Action{vote1, vote2, stake}))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":100"}))
+	tx1, err = action.NewTransfer(3, big.NewInt(10), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{tx1}))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":90"}))
+}
+
+func TestUnbondingRelease(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	dao := db.NewMemKVStore()
+	sf, err := NewFactory(&cfg, PrecreatedDBOption(dao), StakingOption(false, 2))
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, nil))
+	stake, err := action.NewStake(1, big.NewInt(60), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{stake}))
+	unstake, err := action.NewUnstake(2, big.NewInt(20), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{unstake}))
+
+	// the unbonding accounts are reloaded along with the states
+	sf, err = NewFactory(&cfg, PrecreatedDBOption(dao), StakingOption(false, 2))
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(3, nil))
+	balance, err := sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(40), balance)
+	require.NoError(sf.CommitStateChanges(4, nil))
+	balance, err = sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(60), balance)
+
+	// the coins are unbonding again once the release is reverted, and released again by the replacing block
+	require.NoError(sf.Rollback(3))
+	s, err := sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(40), s.Balance)
+	require.Equal(big.NewInt(20), s.UnbondingAmount())
+	require.NoError(sf.CommitStateChanges(4, nil))
+	s, err = sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(60), s.Balance)
+	require.Equal(0, s.UnbondingAmount().Sign())
+}
+
+func TestScheduledFeature(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	schedule := version.Schedule{{Height: 2, Version: 2, Features: []string{version.FeatureStake}}}
+	sf, err := NewFactory(&config.Default, InMemTrieOption(), ScheduleOption(schedule))
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	stake, err := action.NewStake(1, big.NewInt(60), a.RawAddress, 10, nil)
+	require.NoError(err)
+
+	// the stake is rejected until the feature is activated
+	_, err = sf.RunActions(1, []action.Action{stake})
+	require.Equal(ErrInactiveFeature, errors.Cause(err))
+	tx, err := action.NewTransfer(1, big.NewInt(10), a.RawAddress, a.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{tx}))
+	stake.Nonce = 2
+	require.NoError(sf.CommitStateChanges(2, []action.Action{stake}))
+	state, err := sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(60), state.Staked)
+}
+
+func TestScheduledRules(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	schedule := version.Schedule{{
+		Height:   2,
+		Version:  2,
+		Features: []string{version.FeatureStakedVoting, version.FeatureGasLimitFee},
+	}}
+	sf, err := NewFactory(&config.Default, InMemTrieOption(), ScheduleOption(schedule), StakingOption(true, 2))
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+
+	// all the coins of the voter count until staked voting is activated
+	vote1, err := action.NewVote(1, c.RawAddress, c.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = c.PublicKey[:]
+	vote2, err := action.NewVote(1, a.RawAddress, c.RawAddress)
+	require.NoError(err)
+	stake, err := action.NewStake(2, big.NewInt(60), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, []action.Action{vote1, vote2, stake}))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":100"}))
+
+	// the fee is charged against the intrinsic gas until the gas limit fee is activated
+	tx1, err := action.NewTransfer(3, big.NewInt(10), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	tx1.GasLimit = 20
+	tx1.GasPrice = big.NewInt(1)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{tx1}))
+	balance, err := sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(20), balance)
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":80"}))
+
+	// the block activating staked voting recounts the votes as the bonded stakes
+	require.NoError(sf.CommitStateChanges(2, nil))
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":60"}))
+	s, err := sf.State(c.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(60), s.Voters[a.RawAddress])
+
+	tx2, err := action.NewTransfer(4, big.NewInt(1), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	tx2.GasLimit = 15
+	tx2.GasPrice = big.NewInt(1)
+	require.NoError(sf.CommitStateChanges(3, []action.Action{tx2}))
+	balance, err = sf.Balance(a.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(4), balance)
+	require.True(compareStrings(voteForm(sf.Candidates()), []string{c.RawAddress + ":60"}))
+}
+
+func TestEpochReward(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	p1, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	p2, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Consensus.RollDPoS.NumDelegates = 2
+	cfg.Consensus.RollDPoS.NumSubEpochs = 1
+	_, err := NewFactory(&cfg, InMemTrieOption(), EpochRewardOption(100, 101))
+	require.Error(err)
+	sf, err := NewFactory(&cfg, InMemTrieOption(), EpochRewardOption(101, 50))
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	_, err = sf.CreateState(b.RawAddress, uint64(300))
+	require.NoError(err)
+
+	// p1 and p2 self-nominate, a and b vote for p1
+	var votes []action.Action
+	for _, p := range []*iotxaddress.Address{p1, p2} {
+		vote, err := action.NewVote(1, p.RawAddress, p.RawAddress)
+		require.NoError(err)
+		vote.SelfPubkey = p.PublicKey[:]
+		votes = append(votes, vote)
+	}
+	for _, voter := range []*iotxaddress.Address{a, b} {
+		vote, err := action.NewVote(1, voter.RawAddress, p1.RawAddress)
+		require.NoError(err)
+		votes = append(votes, vote)
+	}
+	require.NoError(sf.CommitStateChanges(0, votes))
+	s, err := sf.State(p1.RawAddress)
+	require.NoError(err)
+	require.Equal(map[string]*big.Int{a.RawAddress: big.NewInt(100), b.RawAddress: big.NewInt(300)}, s.Voters)
+
+	// nothing is paid before the last block of the epoch
+	cb1 := action.NewCoinBaseTransfer(big.NewInt(0), p1.RawAddress)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{cb1}))
+	balance, err := sf.Balance(p1.RawAddress)
+	require.NoError(err)
+	require.Equal(0, balance.Sign())
+
+	// p1 and p2 produced a block each, and half of p1's reward goes to a and b by 1:3. The remainder of the split
+	// goes to the first of them in address order
+	first, second := p1.RawAddress, p2.RawAddress
+	if second < first {
+		first, second = second, first
+	}
+	cb2 := action.NewCoinBaseTransfer(big.NewInt(0), p2.RawAddress)
+	root, err := sf.RunActions(2, []action.Action{cb2})
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(2, []action.Action{cb2}))
+	require.Equal(root, sf.RootHash())
+	expected := map[string]int64{
+		a.RawAddress:  106,
+		b.RawAddress:  318,
+		p1.RawAddress: 26,
+		p2.RawAddress: 50,
+	}
+	expected[first]++
+	for addr, amount := range expected {
+		balance, err := sf.Balance(addr)
+		require.NoError(err)
+		require.Equal(big.NewInt(amount), balance)
+	}
+	// the rewards of the voters add to the weight of p1
+	s, err = sf.State(p1.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(424), s.VotingWeight)
+	require.Equal(big.NewInt(106), s.Voters[a.RawAddress])
+
+	// the productivity restarts with the next epoch
+	require.NoError(sf.CommitStateChanges(3, []action.Action{cb1}))
+	require.NoError(sf.CommitStateChanges(4, []action.Action{cb1}))
+	balance, err = sf.Balance(p2.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(expected[p2.RawAddress]), balance)
+	// a and b get 12 and 37 of the voter share rounded down, and p1 keeps the rest
+	balance, err = sf.Balance(p1.RawAddress)
+	require.NoError(err)
+	require.Equal(big.NewInt(expected[p1.RawAddress]+52), balance)
+}
+
+func TestReceipts(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	c, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+
+	// c self-nominates and b votes for c
+	vote1, err := action.NewVote(1, c.RawAddress, c.RawAddress)
+	require.NoError(err)
+	vote1.SelfPubkey = c.PublicKey[:]
+	vote2, err := action.NewVote(1, b.RawAddress, c.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(0, []action.Action{vote1, vote2}))
+	receipts := sf.Receipts()
+	require.Equal(2, len(receipts))
+	require.Equal(vote1.Hash(), receipts[0].ActionHash)
+	require.Equal(ReceiptStatusSuccess, receipts[0].Status)
+	require.Equal(0, len(receipts[0].BalanceDeltas))
+	require.Equal(0, len(receipts[0].WeightDeltas))
+
+	// the coins a transfers to b add to the weight of c, and an unstake of more than the stake fails
+	tsf, err := action.NewTransfer(2, big.NewInt(30), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	unstake, err := action.NewUnstake(3, big.NewInt(10), a.RawAddress, 10, nil)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{tsf, unstake}))
+	receipts = sf.Receipts()
+	require.Equal(2, len(receipts))
+	require.Equal(tsf.Hash(), receipts[0].ActionHash)
+	require.Equal(ReceiptStatusSuccess, receipts[0].Status)
+	require.Equal(map[string]int64{a.RawAddress: -30, b.RawAddress: 30}, deltaForm(receipts[0].BalanceDeltas))
+	require.Equal(map[string]int64{c.RawAddress: 30}, deltaForm(receipts[0].WeightDeltas))
+	require.Equal(unstake.Hash(), receipts[1].ActionHash)
+	require.Equal(ReceiptStatusFailure, receipts[1].Status)
+	require.Equal(0, len(receipts[1].BalanceDeltas))
+	s, err := sf.State(a.RawAddress)
+	require.NoError(err)
+	require.Equal(uint64(3), s.Nonce)
+
+	// the receipt survives serialization, negative deltas included
+	ser, err := receipts[0].Serialize()
+	require.NoError(err)
+	receipt := &Receipt{}
+	require.NoError(receipt.Deserialize(ser))
+	require.Equal(receipts[0], receipt)
+}
+
+func TestNodeCacheStats(t *testing.T) {
+	require := require.New(t)
+	a, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+	b, _ := iotxaddress.NewAddress(iotxaddress.IsTestnet, iotxaddress.ChainID)
+
+	cfg := config.Default
+	cfg.Chain.EnableArchiveMode = true
+	cfg.Chain.TrieNodeCacheSize = 1000
+	sf, err := NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	tsf, err := action.NewTransfer(1, big.NewInt(10), a.RawAddress, b.RawAddress)
+	require.NoError(err)
+	require.NoError(sf.CommitStateChanges(1, []action.Action{tsf}))
+	stats := sf.NodeCacheStats()
+	require.Equal(1000, stats.Capacity)
+	require.True(stats.Size > 0)
+	_, err = sf.Balance(b.RawAddress)
+	require.NoError(err)
+	require.True(sf.NodeCacheStats().Hits > stats.Hits)
+
+	// no node is cached with the cache size of 0
+	cfg.Chain.TrieNodeCacheSize = 0
+	sf, err = NewFactory(&cfg, InMemTrieOption())
+	require.NoError(err)
+	_, err = sf.CreateState(a.RawAddress, uint64(100))
+	require.NoError(err)
+	require.Equal(trie.CacheStats{}, sf.NodeCacheStats())
+}
+
+// deltaForm returns the amounts of the deltas by address, or nil if the deltas are not sorted by address
+func deltaForm(deltas []*Delta) map[string]int64 {
+	form := make(map[string]int64)
+	for i, delta := range deltas {
+		if i > 0 && deltas[i-1].Address >= delta.Address {
+			return nil
+		}
+		form[delta.Address] = delta.Amount.Int64()
+	}
+	return form
+}
@@ -45,11 +45,10 @@ func (mr *MockActPoolMockRecorder) Reset() *gomock.Call {
 }
 
 // PickActs mocks base method
-func (m *MockActPool) PickActs() ([]*action.Transfer, []*action.Vote) {
+func (m *MockActPool) PickActs() []action.Action {
 	ret := m.ctrl.Call(m, "PickActs")
-	ret0, _ := ret[0].([]*action.Transfer)
-	ret1, _ := ret[1].([]*action.Vote)
-	return ret0, ret1
+	ret0, _ := ret[0].([]action.Action)
+	return ret0
 }
 
 // PickActs indicates an expected call of PickActs
@@ -81,6 +80,30 @@ func (mr *MockActPoolMockRecorder) AddVote(vote interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVote", reflect.TypeOf((*MockActPool)(nil).AddVote), vote)
 }
 
+// AddExecution mocks base method
+func (m *MockActPool) AddExecution(execution *action.Execution) error {
+	ret := m.ctrl.Call(m, "AddExecution", execution)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddExecution indicates an expected call of AddExecution
+func (mr *MockActPoolMockRecorder) AddExecution(execution interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddExecution", reflect.TypeOf((*MockActPool)(nil).AddExecution), execution)
+}
+
+// AddStake mocks base method
+func (m *MockActPool) AddStake(stake *action.Stake) error {
+	ret := m.ctrl.Call(m, "AddStake", stake)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddStake indicates an expected call of AddStake
+func (mr *MockActPoolMockRecorder) AddStake(stake interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStake", reflect.TypeOf((*MockActPool)(nil).AddStake), stake)
+}
+
 // GetPendingNonce mocks base method
 func (m *MockActPool) GetPendingNonce(addr string) (uint64, error) {
 	ret := m.ctrl.Call(m, "GetPendingNonce", addr)
@@ -9,6 +9,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	blockchain "github.com/iotexproject/iotex-core/blockchain"
 	action "github.com/iotexproject/iotex-core/blockchain/action"
+	crypto "github.com/iotexproject/iotex-core/crypto"
 	iotxaddress "github.com/iotexproject/iotex-core/iotxaddress"
 	hash "github.com/iotexproject/iotex-core/pkg/hash"
 	state "github.com/iotexproject/iotex-core/state"
@@ -103,15 +104,15 @@ func (mr *MockBlockchainMockRecorder) CreateState(addr, init interface{}) *gomoc
 }
 
 // CommitStateChanges mocks base method
-func (m *MockBlockchain) CommitStateChanges(chainHeight uint64, tsf []*action.Transfer, vote []*action.Vote) error {
-	ret := m.ctrl.Call(m, "CommitStateChanges", chainHeight, tsf, vote)
+func (m *MockBlockchain) CommitStateChanges(chainHeight uint64, acts []action.Action) error {
+	ret := m.ctrl.Call(m, "CommitStateChanges", chainHeight, acts)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CommitStateChanges indicates an expected call of CommitStateChanges
-func (mr *MockBlockchainMockRecorder) CommitStateChanges(chainHeight, tsf, vote interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitStateChanges", reflect.TypeOf((*MockBlockchain)(nil).CommitStateChanges), chainHeight, tsf, vote)
+func (mr *MockBlockchainMockRecorder) CommitStateChanges(chainHeight, acts interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitStateChanges", reflect.TypeOf((*MockBlockchain)(nil).CommitStateChanges), chainHeight, acts)
 }
 
 // Candidates mocks base method
@@ -322,6 +323,45 @@ func (mr *MockBlockchainMockRecorder) GetBlockHashByVoteHash(h interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlockHashByVoteHash", reflect.TypeOf((*MockBlockchain)(nil).GetBlockHashByVoteHash), h)
 }
 
+// GetReceiptByActionHash mocks base method
+func (m *MockBlockchain) GetReceiptByActionHash(arg0 hash.Hash32B) (*state.Receipt, error) {
+	ret := m.ctrl.Call(m, "GetReceiptByActionHash", arg0)
+	ret0, _ := ret[0].(*state.Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReceiptByActionHash indicates an expected call of GetReceiptByActionHash
+func (mr *MockBlockchainMockRecorder) GetReceiptByActionHash(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReceiptByActionHash", reflect.TypeOf((*MockBlockchain)(nil).GetReceiptByActionHash), arg0)
+}
+
+// GetActionProofByActionHash mocks base method
+func (m *MockBlockchain) GetActionProofByActionHash(arg0 hash.Hash32B) (uint64, *crypto.MerkleProof, error) {
+	ret := m.ctrl.Call(m, "GetActionProofByActionHash", arg0)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(*crypto.MerkleProof)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetActionProofByActionHash indicates an expected call of GetActionProofByActionHash
+func (mr *MockBlockchainMockRecorder) GetActionProofByActionHash(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActionProofByActionHash", reflect.TypeOf((*MockBlockchain)(nil).GetActionProofByActionHash), arg0)
+}
+
+// Genesis mocks base method
+func (m *MockBlockchain) Genesis() *blockchain.Genesis {
+	ret := m.ctrl.Call(m, "Genesis")
+	ret0, _ := ret[0].(*blockchain.Genesis)
+	return ret0
+}
+
+// Genesis indicates an expected call of Genesis
+func (mr *MockBlockchainMockRecorder) Genesis() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Genesis", reflect.TypeOf((*MockBlockchain)(nil).Genesis))
+}
+
 // TipHash mocks base method
 func (m *MockBlockchain) TipHash() (hash.Hash32B, error) {
 	ret := m.ctrl.Call(m, "TipHash")
@@ -361,17 +401,81 @@ func (mr *MockBlockchainMockRecorder) StateByAddr(address interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateByAddr", reflect.TypeOf((*MockBlockchain)(nil).StateByAddr), address)
 }
 
+// StateByHeight mocks base method
+func (m *MockBlockchain) StateByHeight(address string, height uint64) (*state.State, error) {
+	ret := m.ctrl.Call(m, "StateByHeight", address, height)
+	ret0, _ := ret[0].(*state.State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateByHeight indicates an expected call of StateByHeight
+func (mr *MockBlockchainMockRecorder) StateByHeight(address, height interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateByHeight", reflect.TypeOf((*MockBlockchain)(nil).StateByHeight), address, height)
+}
+
+// StateProof mocks base method
+func (m *MockBlockchain) StateProof(address string, height uint64) ([][]byte, error) {
+	ret := m.ctrl.Call(m, "StateProof", address, height)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateProof indicates an expected call of StateProof
+func (mr *MockBlockchainMockRecorder) StateProof(address, height interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateProof", reflect.TypeOf((*MockBlockchain)(nil).StateProof), address, height)
+}
+
+// SnapshotManifest mocks base method
+func (m *MockBlockchain) SnapshotManifest() (*blockchain.SnapshotManifest, error) {
+	ret := m.ctrl.Call(m, "SnapshotManifest")
+	ret0, _ := ret[0].(*blockchain.SnapshotManifest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnapshotManifest indicates an expected call of SnapshotManifest
+func (mr *MockBlockchainMockRecorder) SnapshotManifest() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotManifest", reflect.TypeOf((*MockBlockchain)(nil).SnapshotManifest))
+}
+
+// SnapshotChunk mocks base method
+func (m *MockBlockchain) SnapshotChunk(h hash.Hash32B) ([]byte, error) {
+	ret := m.ctrl.Call(m, "SnapshotChunk", h)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnapshotChunk indicates an expected call of SnapshotChunk
+func (mr *MockBlockchainMockRecorder) SnapshotChunk(h interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotChunk", reflect.TypeOf((*MockBlockchain)(nil).SnapshotChunk), h)
+}
+
+// RestoreSnapshot mocks base method
+func (m *MockBlockchain) RestoreSnapshot(manifest *blockchain.SnapshotManifest, header *blockchain.Block, chunk func(hash.Hash32B) ([]byte, error)) error {
+	ret := m.ctrl.Call(m, "RestoreSnapshot", manifest, header, chunk)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreSnapshot indicates an expected call of RestoreSnapshot
+func (mr *MockBlockchainMockRecorder) RestoreSnapshot(manifest, header, chunk interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreSnapshot", reflect.TypeOf((*MockBlockchain)(nil).RestoreSnapshot), manifest, header, chunk)
+}
+
 // MintNewBlock mocks base method
-func (m *MockBlockchain) MintNewBlock(tsf []*action.Transfer, vote []*action.Vote, address *iotxaddress.Address, data string) (*blockchain.Block, error) {
-	ret := m.ctrl.Call(m, "MintNewBlock", tsf, vote, address, data)
+func (m *MockBlockchain) MintNewBlock(acts []action.Action, address *iotxaddress.Address, data string) (*blockchain.Block, error) {
+	ret := m.ctrl.Call(m, "MintNewBlock", acts, address, data)
 	ret0, _ := ret[0].(*blockchain.Block)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // MintNewBlock indicates an expected call of MintNewBlock
-func (mr *MockBlockchainMockRecorder) MintNewBlock(tsf, vote, address, data interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MintNewBlock", reflect.TypeOf((*MockBlockchain)(nil).MintNewBlock), tsf, vote, address, data)
+func (mr *MockBlockchainMockRecorder) MintNewBlock(acts, address, data interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MintNewBlock", reflect.TypeOf((*MockBlockchain)(nil).MintNewBlock), acts, address, data)
 }
 
 // MintNewDummyBlock mocks base method
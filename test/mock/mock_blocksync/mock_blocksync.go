@@ -118,3 +118,51 @@ func (m *MockBlockSync) SetTarget(arg0 net.Addr) {
 func (mr *MockBlockSyncMockRecorder) SetTarget(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTarget", reflect.TypeOf((*MockBlockSync)(nil).SetTarget), arg0)
 }
+
+// ProcessSnapshotManifestRequest mocks base method
+func (m *MockBlockSync) ProcessSnapshotManifestRequest(sender string, req *proto.SnapshotManifestReq) error {
+	ret := m.ctrl.Call(m, "ProcessSnapshotManifestRequest", sender, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessSnapshotManifestRequest indicates an expected call of ProcessSnapshotManifestRequest
+func (mr *MockBlockSyncMockRecorder) ProcessSnapshotManifestRequest(sender, req interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessSnapshotManifestRequest", reflect.TypeOf((*MockBlockSync)(nil).ProcessSnapshotManifestRequest), sender, req)
+}
+
+// ProcessSnapshotChunkRequest mocks base method
+func (m *MockBlockSync) ProcessSnapshotChunkRequest(sender string, req *proto.SnapshotChunkReq) error {
+	ret := m.ctrl.Call(m, "ProcessSnapshotChunkRequest", sender, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessSnapshotChunkRequest indicates an expected call of ProcessSnapshotChunkRequest
+func (mr *MockBlockSyncMockRecorder) ProcessSnapshotChunkRequest(sender, req interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessSnapshotChunkRequest", reflect.TypeOf((*MockBlockSync)(nil).ProcessSnapshotChunkRequest), sender, req)
+}
+
+// ProcessSnapshotManifest mocks base method
+func (m *MockBlockSync) ProcessSnapshotManifest(manifest *proto.SnapshotManifestPb) error {
+	ret := m.ctrl.Call(m, "ProcessSnapshotManifest", manifest)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessSnapshotManifest indicates an expected call of ProcessSnapshotManifest
+func (mr *MockBlockSyncMockRecorder) ProcessSnapshotManifest(manifest interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessSnapshotManifest", reflect.TypeOf((*MockBlockSync)(nil).ProcessSnapshotManifest), manifest)
+}
+
+// ProcessSnapshotChunk mocks base method
+func (m *MockBlockSync) ProcessSnapshotChunk(chunk *proto.SnapshotChunkPb) error {
+	ret := m.ctrl.Call(m, "ProcessSnapshotChunk", chunk)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessSnapshotChunk indicates an expected call of ProcessSnapshotChunk
+func (mr *MockBlockSyncMockRecorder) ProcessSnapshotChunk(chunk interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessSnapshotChunk", reflect.TypeOf((*MockBlockSync)(nil).ProcessSnapshotChunk), chunk)
+}
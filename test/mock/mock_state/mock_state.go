@@ -9,6 +9,7 @@ import (
 	action "github.com/iotexproject/iotex-core/blockchain/action"
 	hash "github.com/iotexproject/iotex-core/pkg/hash"
 	state "github.com/iotexproject/iotex-core/state"
+	trie "github.com/iotexproject/iotex-core/trie"
 	big "math/big"
 	reflect "reflect"
 )
@@ -63,15 +64,55 @@ func (mr *MockFactoryMockRecorder) Balance(arg0 interface{}) *gomock.Call {
 }
 
 // CommitStateChanges mocks base method
-func (m *MockFactory) CommitStateChanges(arg0 uint64, arg1 []*action.Transfer, arg2 []*action.Vote) error {
-	ret := m.ctrl.Call(m, "CommitStateChanges", arg0, arg1, arg2)
+func (m *MockFactory) CommitStateChanges(arg0 uint64, arg1 []action.Action) error {
+	ret := m.ctrl.Call(m, "CommitStateChanges", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CommitStateChanges indicates an expected call of CommitStateChanges
-func (mr *MockFactoryMockRecorder) CommitStateChanges(arg0, arg1, arg2 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitStateChanges", reflect.TypeOf((*MockFactory)(nil).CommitStateChanges), arg0, arg1, arg2)
+func (mr *MockFactoryMockRecorder) CommitStateChanges(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CommitStateChanges", reflect.TypeOf((*MockFactory)(nil).CommitStateChanges), arg0, arg1)
+}
+
+// RunActions mocks base method
+func (m *MockFactory) RunActions(arg0 uint64, arg1 []action.Action) (hash.Hash32B, error) {
+	ret := m.ctrl.Call(m, "RunActions", arg0, arg1)
+	ret0, _ := ret[0].(hash.Hash32B)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunActions indicates an expected call of RunActions
+func (mr *MockFactoryMockRecorder) RunActions(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunActions", reflect.TypeOf((*MockFactory)(nil).RunActions), arg0, arg1)
+}
+
+// AddActionHandlers mocks base method
+func (m *MockFactory) AddActionHandlers(arg0 ...state.ActionHandler) {
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "AddActionHandlers", varargs...)
+}
+
+// AddActionHandlers indicates an expected call of AddActionHandlers
+func (mr *MockFactoryMockRecorder) AddActionHandlers(arg0 ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{}, arg0...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddActionHandlers", reflect.TypeOf((*MockFactory)(nil).AddActionHandlers), varargs...)
+}
+
+// Rollback mocks base method
+func (m *MockFactory) Rollback(arg0 uint64) error {
+	ret := m.ctrl.Call(m, "Rollback", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback
+func (mr *MockFactoryMockRecorder) Rollback(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockFactory)(nil).Rollback), arg0)
 }
 
 // Nonce mocks base method
@@ -137,3 +178,141 @@ func (m *MockFactory) CandidatesByHeight(arg0 uint64) ([]*state.Candidate, bool)
 func (mr *MockFactoryMockRecorder) CandidatesByHeight(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CandidatesByHeight", reflect.TypeOf((*MockFactory)(nil).CandidatesByHeight), arg0)
 }
+
+// Height mocks base method
+func (m *MockFactory) Height() (uint64, bool) {
+	ret := m.ctrl.Call(m, "Height")
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Height indicates an expected call of Height
+func (mr *MockFactoryMockRecorder) Height() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Height", reflect.TypeOf((*MockFactory)(nil).Height))
+}
+
+// Prune mocks base method
+func (m *MockFactory) Prune(arg0 uint64) error {
+	ret := m.ctrl.Call(m, "Prune", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Prune indicates an expected call of Prune
+func (mr *MockFactoryMockRecorder) Prune(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockFactory)(nil).Prune), arg0)
+}
+
+// StateByRoot mocks base method
+func (m *MockFactory) StateByRoot(arg0 string, arg1 hash.Hash32B) (*state.State, error) {
+	ret := m.ctrl.Call(m, "StateByRoot", arg0, arg1)
+	ret0, _ := ret[0].(*state.State)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateByRoot indicates an expected call of StateByRoot
+func (mr *MockFactoryMockRecorder) StateByRoot(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateByRoot", reflect.TypeOf((*MockFactory)(nil).StateByRoot), arg0, arg1)
+}
+
+// StateProof mocks base method
+func (m *MockFactory) StateProof(arg0 string, arg1 hash.Hash32B) ([][]byte, error) {
+	ret := m.ctrl.Call(m, "StateProof", arg0, arg1)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StateProof indicates an expected call of StateProof
+func (mr *MockFactoryMockRecorder) StateProof(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StateProof", reflect.TypeOf((*MockFactory)(nil).StateProof), arg0, arg1)
+}
+
+// AccountsByRoot mocks base method
+func (m *MockFactory) AccountsByRoot(arg0 hash.Hash32B, arg1 func(string, *state.State) error) error {
+	ret := m.ctrl.Call(m, "AccountsByRoot", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AccountsByRoot indicates an expected call of AccountsByRoot
+func (mr *MockFactoryMockRecorder) AccountsByRoot(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountsByRoot", reflect.TypeOf((*MockFactory)(nil).AccountsByRoot), arg0, arg1)
+}
+
+// CandidatesByRoot mocks base method
+func (m *MockFactory) CandidatesByRoot(arg0 hash.Hash32B) ([]*state.Candidate, error) {
+	ret := m.ctrl.Call(m, "CandidatesByRoot", arg0)
+	ret0, _ := ret[0].([]*state.Candidate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CandidatesByRoot indicates an expected call of CandidatesByRoot
+func (mr *MockFactoryMockRecorder) CandidatesByRoot(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CandidatesByRoot", reflect.TypeOf((*MockFactory)(nil).CandidatesByRoot), arg0)
+}
+
+// PinSnapshot mocks base method
+func (m *MockFactory) PinSnapshot() (state.Snapshot, error) {
+	ret := m.ctrl.Call(m, "PinSnapshot")
+	ret0, _ := ret[0].(state.Snapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PinSnapshot indicates an expected call of PinSnapshot
+func (mr *MockFactoryMockRecorder) PinSnapshot() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PinSnapshot", reflect.TypeOf((*MockFactory)(nil).PinSnapshot))
+}
+
+// RestoreSnapshot mocks base method
+func (m *MockFactory) RestoreSnapshot(arg0 hash.Hash32B, arg1 []byte, arg2 func() ([]byte, error)) error {
+	ret := m.ctrl.Call(m, "RestoreSnapshot", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreSnapshot indicates an expected call of RestoreSnapshot
+func (mr *MockFactoryMockRecorder) RestoreSnapshot(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreSnapshot", reflect.TypeOf((*MockFactory)(nil).RestoreSnapshot), arg0, arg1, arg2)
+}
+
+// Receipts mocks base method
+func (m *MockFactory) Receipts() []*state.Receipt {
+	ret := m.ctrl.Call(m, "Receipts")
+	ret0, _ := ret[0].([]*state.Receipt)
+	return ret0
+}
+
+// Receipts indicates an expected call of Receipts
+func (mr *MockFactoryMockRecorder) Receipts() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receipts", reflect.TypeOf((*MockFactory)(nil).Receipts))
+}
+
+// ActionReceipts mocks base method
+func (m *MockFactory) ActionReceipts(arg0 uint64, arg1 []action.Action) ([]*state.Receipt, error) {
+	ret := m.ctrl.Call(m, "ActionReceipts", arg0, arg1)
+	ret0, _ := ret[0].([]*state.Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActionReceipts indicates an expected call of ActionReceipts
+func (mr *MockFactoryMockRecorder) ActionReceipts(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActionReceipts", reflect.TypeOf((*MockFactory)(nil).ActionReceipts), arg0, arg1)
+}
+
+// NodeCacheStats mocks base method
+func (m *MockFactory) NodeCacheStats() trie.CacheStats {
+	ret := m.ctrl.Call(m, "NodeCacheStats")
+	ret0, _ := ret[0].(trie.CacheStats)
+	return ret0
+}
+
+// NodeCacheStats indicates an expected call of NodeCacheStats
+func (mr *MockFactoryMockRecorder) NodeCacheStats() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeCacheStats", reflect.TypeOf((*MockFactory)(nil).NodeCacheStats))
+}
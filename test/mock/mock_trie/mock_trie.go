@@ -7,6 +7,7 @@ package mock_trie
 import (
 	gomock "github.com/golang/mock/gomock"
 	hash "github.com/iotexproject/iotex-core/pkg/hash"
+	trie "github.com/iotexproject/iotex-core/trie"
 	reflect "reflect"
 )
 
@@ -70,6 +71,19 @@ func (mr *MockTrieMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTrie)(nil).Get), arg0)
 }
 
+// Prove mocks base method
+func (m *MockTrie) Prove(arg0 []byte) ([][]byte, error) {
+	ret := m.ctrl.Call(m, "Prove", arg0)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prove indicates an expected call of Prove
+func (mr *MockTrieMockRecorder) Prove(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prove", reflect.TypeOf((*MockTrie)(nil).Prove), arg0)
+}
+
 // Delete mocks base method
 func (m *MockTrie) Delete(arg0 []byte) error {
 	ret := m.ctrl.Call(m, "Delete", arg0)
@@ -138,3 +152,122 @@ func (m *MockTrie) Flush() error {
 func (mr *MockTrieMockRecorder) Flush() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockTrie)(nil).Flush))
 }
+
+// Checkpoint mocks base method
+func (m *MockTrie) Checkpoint(arg0 uint64) error {
+	ret := m.ctrl.Call(m, "Checkpoint", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Checkpoint indicates an expected call of Checkpoint
+func (mr *MockTrieMockRecorder) Checkpoint(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkpoint", reflect.TypeOf((*MockTrie)(nil).Checkpoint), arg0)
+}
+
+// Prune mocks base method
+func (m *MockTrie) Prune(arg0 uint64) error {
+	ret := m.ctrl.Call(m, "Prune", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Prune indicates an expected call of Prune
+func (mr *MockTrieMockRecorder) Prune(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockTrie)(nil).Prune), arg0)
+}
+
+// Pin mocks base method
+func (m *MockTrie) Pin() trie.Reader {
+	ret := m.ctrl.Call(m, "Pin")
+	ret0, _ := ret[0].(trie.Reader)
+	return ret0
+}
+
+// Pin indicates an expected call of Pin
+func (mr *MockTrieMockRecorder) Pin() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pin", reflect.TypeOf((*MockTrie)(nil).Pin))
+}
+
+// IndexBranches mocks base method
+func (m *MockTrie) IndexBranches(arg0 bool) error {
+	ret := m.ctrl.Call(m, "IndexBranches", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IndexBranches indicates an expected call of IndexBranches
+func (mr *MockTrieMockRecorder) IndexBranches(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexBranches", reflect.TypeOf((*MockTrie)(nil).IndexBranches), arg0)
+}
+
+// MockReader is a mock of Reader interface
+type MockReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockReaderMockRecorder
+}
+
+// MockReaderMockRecorder is the mock recorder for MockReader
+type MockReaderMockRecorder struct {
+	mock *MockReader
+}
+
+// NewMockReader creates a new mock instance
+func NewMockReader(ctrl *gomock.Controller) *MockReader {
+	mock := &MockReader{ctrl: ctrl}
+	mock.recorder = &MockReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockReader) EXPECT() *MockReaderMockRecorder {
+	return m.recorder
+}
+
+// RootHash mocks base method
+func (m *MockReader) RootHash() hash.Hash32B {
+	ret := m.ctrl.Call(m, "RootHash")
+	ret0, _ := ret[0].(hash.Hash32B)
+	return ret0
+}
+
+// RootHash indicates an expected call of RootHash
+func (mr *MockReaderMockRecorder) RootHash() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RootHash", reflect.TypeOf((*MockReader)(nil).RootHash))
+}
+
+// Get mocks base method
+func (m *MockReader) Get(arg0 []byte) ([]byte, error) {
+	ret := m.ctrl.Call(m, "Get", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockReaderMockRecorder) Get(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockReader)(nil).Get), arg0)
+}
+
+// Prove mocks base method
+func (m *MockReader) Prove(arg0 []byte) ([][]byte, error) {
+	ret := m.ctrl.Call(m, "Prove", arg0)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prove indicates an expected call of Prove
+func (mr *MockReaderMockRecorder) Prove(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prove", reflect.TypeOf((*MockReader)(nil).Prove), arg0)
+}
+
+// Release mocks base method
+func (m *MockReader) Release() {
+	m.ctrl.Call(m, "Release")
+}
+
+// Release indicates an expected call of Release
+func (mr *MockReaderMockRecorder) Release() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockReader)(nil).Release))
+}
@@ -97,11 +97,11 @@ func TestActioninjector(t *testing.T) {
 		var voteCount int
 		for h := height; h > 0; h-- {
 			blk, _ := bc.GetBlockByHeight(h)
-			if len(blk.Transfers) > 1 {
-				tsfCount += len(blk.Transfers) - 1
+			if len(blk.Transfers()) > 1 {
+				tsfCount += len(blk.Transfers()) - 1
 			}
-			if len(blk.Votes) > 0 {
-				voteCount += len(blk.Votes)
+			if len(blk.Votes()) > 0 {
+				voteCount += len(blk.Votes())
 			}
 		}
 		// Excluding coinbase transfers, there should be at least 6 injected actions
@@ -122,11 +122,11 @@ func TestActioninjector(t *testing.T) {
 		var voteCount int
 		for h := height; h > 0; h-- {
 			blk, _ := bc.GetBlockByHeight(h)
-			if len(blk.Transfers) > 1 {
-				tsfCount += len(blk.Transfers) - 1
+			if len(blk.Transfers()) > 1 {
+				tsfCount += len(blk.Transfers()) - 1
 			}
-			if len(blk.Votes) > 0 {
-				voteCount += len(blk.Votes)
+			if len(blk.Votes()) > 0 {
+				voteCount += len(blk.Votes())
 			}
 		}
 		// Excluding coinbase transfers, there should be at least 9 injected actions
@@ -0,0 +1,159 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// This is a tool to export the blocks of the chain DB to an archive file, or to import the blocks of an archive file
+// into the chain DB. The chain DB is the one in the config of the node, which must not be running
+// To use, run "make build" and
+// " ./bin/blockarchive -config-path=config.yaml -mode=export -file=blocks.archive -start=0 -end=1000" or
+// " ./bin/blockarchive -config-path=config.yaml -mode=import -file=blocks.archive"
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/logger"
+)
+
+func main() {
+	// export or import
+	var mode string
+	// path of the archive file
+	var file string
+	// first height to export. Default is 0
+	var start uint64
+	// last height to export. Default is the tip height
+	var end int64
+	// resume an interrupted export by appending to the archive file. Default is false
+	var resume bool
+	// number of blocks between two progress logs. Default is 1000
+	var logInterval uint64
+
+	flag.StringVar(&mode, "mode", "", "export or import")
+	flag.StringVar(&file, "file", "", "path of the archive file")
+	flag.Uint64Var(&start, "start", 0, "first height to export")
+	flag.Int64Var(&end, "end", -1, "last height to export, the tip height if negative")
+	flag.BoolVar(&resume, "resume", false, "resume an interrupted export of the archive file")
+	flag.Uint64Var(&logInterval, "log-interval", 1000, "number of blocks between two progress logs")
+	flag.Parse()
+
+	if file == "" {
+		logger.Fatal().Msg("Path of the archive file is not set")
+	}
+	if logInterval == 0 {
+		logInterval = 1
+	}
+	cfg, err := config.New()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to new config")
+	}
+	bc := blockchain.NewBlockchain(cfg, blockchain.DefaultStateFactoryOption(), blockchain.BoltDBDaoOption())
+	if bc == nil {
+		logger.Fatal().Msg("Failed to create blockchain")
+	}
+
+	switch mode {
+	case "export":
+		err = exportBlocks(bc, file, start, end, resume, logInterval)
+	case "import":
+		err = importBlocks(bc, file, logInterval)
+	default:
+		err = errors.Errorf("unknown mode %s", mode)
+	}
+	// the chain is stopped before exiting either way, so the blocks imported are flushed
+	if stopErr := bc.Stop(context.Background()); stopErr != nil {
+		logger.Error().Err(stopErr).Msg("Failed to stop blockchain")
+		if err == nil {
+			os.Exit(1)
+		}
+	}
+	if err != nil {
+		logger.Fatal().Err(err).Str("mode", mode).Str("file", file).Msg("Failed to process the archive")
+	}
+}
+
+func exportBlocks(bc blockchain.Blockchain, file string, start uint64, end int64, resume bool, logInterval uint64) error {
+	tipHeight, err := bc.TipHeight()
+	if err != nil {
+		return err
+	}
+	last := tipHeight
+	if end >= 0 {
+		last = uint64(end)
+	}
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if resume {
+		flags = os.O_RDWR | os.O_CREATE
+	}
+	f, err := os.OpenFile(file, flags, 0644)
+	if err != nil {
+		return errors.Wrap(err, "failed to open archive file")
+	}
+	defer f.Close()
+	if resume {
+		height, offset, err := blockchain.LastArchivedBlock(f)
+		switch {
+		case errors.Cause(err) == blockchain.ErrEmptyArchive:
+			offset = 0
+		case err != nil:
+			return err
+		default:
+			start = height + 1
+		}
+		if err := f.Truncate(offset); err != nil {
+			return errors.Wrap(err, "failed to truncate archive file")
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return errors.Wrap(err, "failed to seek archive file")
+		}
+		if start > last {
+			logger.Info().Uint64("height", last).Msg("Archive is already complete")
+			return nil
+		}
+	}
+
+	logger.Info().Uint64("start", start).Uint64("end", last).Msg("Exporting blocks")
+	if err := blockchain.ExportBlocks(bc, f, start, last, func(height uint64) {
+		if (height-start+1)%logInterval == 0 || height == last {
+			logger.Info().Uint64("height", height).Uint64("end", last).Msg("Exported blocks")
+		}
+	}); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func importBlocks(bc blockchain.Blockchain, file string, logInterval uint64) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to open archive file")
+	}
+	defer f.Close()
+
+	tipHeight, err := bc.TipHeight()
+	if err != nil {
+		return err
+	}
+	logger.Info().Uint64("tipHeight", tipHeight).Msg("Importing blocks")
+	height, err := blockchain.ImportBlocks(bc, f, func(height uint64) {
+		if (height-tipHeight)%logInterval == 0 {
+			logger.Info().Uint64("height", height).Msg("Imported blocks")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info().Uint64("height", height).Msg("Imported all blocks of the archive")
+	return nil
+}
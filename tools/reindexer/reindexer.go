@@ -0,0 +1,54 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// This is a tool to rebuild the states and the indexes of the node from the blocks stored in the chain DB. The chain
+// DB and the trie DB are the ones in the config of the node, which must not be running
+// To use, run "make build" and " ./bin/reindexer -config-path=config.yaml"
+
+package main
+
+import (
+	"flag"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/logger"
+)
+
+func main() {
+	// restart an interrupted reindex from the genesis block instead of resuming it. Default is false
+	var restart bool
+	// number of blocks between two progress logs. Default is 1000
+	var logInterval uint64
+
+	flag.BoolVar(&restart, "restart", false, "restart an interrupted reindex from the genesis block")
+	flag.Uint64Var(&logInterval, "log-interval", 1000, "number of blocks between two progress logs")
+	flag.Parse()
+
+	if logInterval == 0 {
+		logInterval = 1
+	}
+	cfg, err := config.New()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to new config")
+	}
+
+	logger.Info().
+		Str("chainDBPath", cfg.Chain.ChainDBPath).
+		Str("trieDBPath", cfg.Chain.TrieDBPath).
+		Bool("restart", restart).
+		Msg("Reindexing blocks")
+	var last uint64
+	if err := blockchain.Reindex(cfg, restart, func(height uint64) {
+		last = height
+		if height%logInterval == 0 {
+			logger.Info().Uint64("height", height).Msg("Reindexed blocks")
+		}
+	}); err != nil {
+		logger.Fatal().Err(err).Msg("Failed to reindex blocks")
+	}
+	logger.Info().Uint64("height", last).Msg("Reindexed all blocks")
+}
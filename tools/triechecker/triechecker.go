@@ -0,0 +1,91 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// This is a tool to check the trie nodes of the states in the trie DB against their hashes, and to repair the missing
+// and corrupted nodes by replaying the blocks stored in the chain DB. The chain DB and the trie DB are the ones in the
+// config of the node, which must not be running
+// To use, run "make build" and " ./bin/triechecker -config-path=config.yaml -repair"
+
+package main
+
+import (
+	"flag"
+
+	"github.com/iotexproject/iotex-core/blockchain"
+	"github.com/iotexproject/iotex-core/config"
+	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/trie"
+)
+
+func main() {
+	// height of the block whose states are checked. Default is -1, the top block
+	var height int64
+	// repair the faulty nodes by replaying the blocks. Default is false
+	var repair bool
+	// number of blocks between two progress logs of the repair. Default is 1000
+	var logInterval uint64
+
+	flag.Int64Var(&height, "height", -1, "height of the block whose states are checked, -1 for the top block")
+	flag.BoolVar(&repair, "repair", false, "repair the faulty nodes by replaying the blocks")
+	flag.Uint64Var(&logInterval, "log-interval", 1000, "number of blocks between two progress logs of the repair")
+	flag.Parse()
+
+	if logInterval == 0 {
+		logInterval = 1
+	}
+	cfg, err := config.New()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to new config")
+	}
+
+	logger.Info().
+		Str("chainDBPath", cfg.Chain.ChainDBPath).
+		Str("trieDBPath", cfg.Chain.TrieDBPath).
+		Int64("height", height).
+		Bool("repair", repair).
+		Msg("Checking states")
+	if !repair {
+		report, err := blockchain.CheckStates(cfg, height)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to check states")
+		}
+		logReport(report)
+		if !report.OK() {
+			logger.Fatal().Int("faults", len(report.Faults)).Msg("Found faulty nodes, rerun with -repair to repair them")
+		}
+		return
+	}
+	report, written, err := blockchain.RepairStates(cfg, height, func(height uint64) {
+		if height%logInterval == 0 {
+			logger.Info().Uint64("height", height).Msg("Replayed blocks")
+		}
+	})
+	if report != nil {
+		logReport(report)
+	}
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to repair states")
+	}
+	logger.Info().Int("written", written).Msg("Repaired states")
+}
+
+func logReport(report *trie.CheckReport) {
+	for _, fault := range report.Faults {
+		logger.Error().
+			Err(fault.Err).
+			Str("bucket", fault.Bucket).
+			Hex("hash", fault.Hash[:]).
+			Hex("parent", fault.Parent[:]).
+			Hex("path", fault.Path).
+			Bool("missing", fault.Missing).
+			Msg("Faulty node")
+	}
+	logger.Info().
+		Int("nodes", report.Nodes).
+		Int("entries", report.Entries).
+		Int("faults", len(report.Faults)).
+		Msg("Checked states")
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/iotexproject/iotex-core/pkg/hash"
+)
+
+type (
+	// NodeCache is a least recently used cache of the serialized patricia nodes in DB, holding at most the capacity of
+	// nodes. It is safe for concurrent use, so it can be shared by the tries and the readers on the same DB
+	NodeCache struct {
+		mutex     sync.Mutex
+		lru       *lru.Cache
+		capacity  int
+		hits      uint64
+		misses    uint64
+		evictions uint64
+	}
+
+	// CacheStats is the size and the counters of a node cache
+	CacheStats struct {
+		Capacity  int
+		Size      int
+		Hits      uint64
+		Misses    uint64
+		Evictions uint64
+	}
+
+	// nodeKey is the key of a node in the cache, the same node could be kept in the buckets of different tries
+	nodeKey struct {
+		bucket string
+		hash   hash.Hash32B
+	}
+)
+
+// nodeCacheMtc counts the hits, the misses and the evictions of the node caches, in the same way as the counters of
+// CacheStats
+var nodeCacheMtc = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "iotex_trie_node_cache",
+		Help: "Hits, misses and evictions of the trie node cache",
+	},
+	[]string{"type"},
+)
+
+func init() {
+	prometheus.MustRegister(nodeCacheMtc)
+}
+
+// NewNodeCache creates a node cache of the given capacity, which must be positive
+func NewNodeCache(capacity int) *NodeCache {
+	return &NodeCache{lru: lru.New(capacity), capacity: capacity}
+}
+
+// Stats returns the size and the counters of the cache, a nil cache has empty stats
+func (c *NodeCache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return CacheStats{
+		Capacity:  c.capacity,
+		Size:      c.lru.Len(),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+//======================================
+// private functions
+//======================================
+// get returns the node of the key in the bucket, and counts the hit or the miss
+func (c *NodeCache) get(bucket string, key []byte) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	node, ok := c.lru.Get(newNodeKey(bucket, key))
+	if !ok {
+		c.misses++
+		nodeCacheMtc.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	c.hits++
+	nodeCacheMtc.WithLabelValues("hit").Inc()
+	return node.([]byte), true
+}
+
+// put adds the node of the key in the bucket, evicting the least recently used node if the cache is full
+func (c *NodeCache) put(bucket string, key []byte, node []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	k := newNodeKey(bucket, key)
+	if _, ok := c.lru.Get(k); !ok && c.lru.Len() >= c.capacity {
+		c.lru.RemoveOldest()
+		c.evictions++
+		nodeCacheMtc.WithLabelValues("eviction").Inc()
+	}
+	c.lru.Add(k, node)
+}
+
+// remove drops the node of the key in the bucket, which is deleted from DB
+func (c *NodeCache) remove(bucket string, key []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.lru.Remove(newNodeKey(bucket, key))
+}
+
+func newNodeKey(bucket string, key []byte) nodeKey {
+	k := nodeKey{bucket: bucket}
+	copy(k.hash[:], key)
+	return k
+}
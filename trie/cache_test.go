@@ -0,0 +1,143 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/db"
+)
+
+func TestNodeCache(t *testing.T) {
+	require := require.New(t)
+
+	var nilCache *NodeCache
+	require.Equal(CacheStats{}, nilCache.Stats())
+	hits, misses, evictions := nodeCacheMetric(t, "hit"), nodeCacheMetric(t, "miss"), nodeCacheMetric(t, "eviction")
+
+	c := NewNodeCache(2)
+	_, ok := c.get("test", ham)
+	require.False(ok)
+	c.put("test", ham, testV[0])
+	c.put("test", car, testV[1])
+	node, ok := c.get("test", ham)
+	require.True(ok)
+	require.Equal(testV[0], node)
+	// the same key in another bucket is a different node
+	_, ok = c.get("other", ham)
+	require.False(ok)
+	// car is the least recently used
+	c.put("test", cat, testV[2])
+	_, ok = c.get("test", car)
+	require.False(ok)
+	// updating a cached node does not evict
+	c.put("test", cat, testV[3])
+	node, ok = c.get("test", cat)
+	require.True(ok)
+	require.Equal(testV[3], node)
+	c.remove("test", ham)
+	_, ok = c.get("test", ham)
+	require.False(ok)
+	require.Equal(CacheStats{Capacity: 2, Size: 1, Hits: 2, Misses: 4, Evictions: 1}, c.Stats())
+	// the metrics count the same way
+	require.Equal(hits+2, nodeCacheMetric(t, "hit"))
+	require.Equal(misses+4, nodeCacheMetric(t, "miss"))
+	require.Equal(evictions+1, nodeCacheMetric(t, "eviction"))
+}
+
+func TestTrieNodeCache(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	cache := NewNodeCache(1000)
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot, NodeCacheOption(cache))
+	require.Nil(err)
+
+	// the nodes not flushed yet are not cached, and the flushed ones are
+	require.Nil(tr.EnableBatch())
+	for i, key := range [][]byte{ham, car, cat, dog} {
+		require.Nil(tr.Upsert(key, testV[i]))
+	}
+	size := cache.Stats().Size
+	require.Nil(tr.Flush())
+	tr.DisableBatch()
+	require.True(cache.Stats().Size > size)
+
+	// the trie and its readers read the nodes from the cache
+	stats := cache.Stats()
+	v, err := tr.Get(cat)
+	require.Nil(err)
+	require.Equal(testV[2], v)
+	r := tr.Pin()
+	v, err = r.Get(dog)
+	require.Nil(err)
+	require.Equal(testV[3], v)
+	r.Release()
+	require.True(cache.Stats().Hits > stats.Hits)
+	require.Equal(stats.Misses, cache.Stats().Misses)
+
+	// a trie opened at the root reads the nodes from DB once
+	tr2, err := NewTrieSharedDB(dao, "test", tr.RootHash(), NodeCacheOption(NewNodeCache(1000)))
+	require.Nil(err)
+	for i := 0; i < 2; i++ {
+		v, err = tr2.Get(ham)
+		require.Nil(err)
+		require.Equal(testV[0], v)
+	}
+	stats = tr2.(*trie).nodeCache.Stats()
+	require.True(stats.Hits > 0)
+	require.True(stats.Misses > 0)
+
+	// the nodes deleted from DB are dropped from the cache
+	root := tr.RootHash()
+	require.Nil(tr.Upsert(egg, testV[4]))
+	_, ok := cache.get("test", root[:])
+	require.False(ok)
+	_, err = dao.Get("test", root[:])
+	require.NotNil(err)
+
+	// the cache is bounded by its capacity
+	small := NewNodeCache(2)
+	tr3, err := NewTrieSharedDB(db.NewMemKVStore(), "test", EmptyRoot, NodeCacheOption(small))
+	require.Nil(err)
+	require.Nil(tr3.Commit([][]byte{ham, car, cat, dog, egg}, [][]byte{testV[0], testV[1], testV[2], testV[3], testV[4]}))
+	stats = small.Stats()
+	require.Equal(2, stats.Size)
+	require.True(stats.Evictions > 0)
+}
+
+func TestHistoryNodeCache(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	cache := NewNodeCache(1000)
+	tr, err := NewTrieWithHistory(dao, "test", EmptyRoot, NodeCacheOption(cache))
+	require.Nil(err)
+	require.Nil(tr.Commit([][]byte{ham, car}, [][]byte{testV[0], testV[1]}))
+	require.Nil(tr.Checkpoint(1))
+	root := tr.RootHash()
+	require.Nil(tr.Commit([][]byte{cat}, [][]byte{testV[2]}))
+	require.Nil(tr.Checkpoint(2))
+
+	// the stale nodes stay cached until they are pruned
+	_, ok := cache.get("test", root[:])
+	require.True(ok)
+	require.Nil(tr.Prune(2))
+	_, ok = cache.get("test", root[:])
+	require.False(ok)
+	_, err = dao.Get("test", root[:])
+	require.NotNil(err)
+}
+
+func nodeCacheMetric(t *testing.T, label string) float64 {
+	m := &dto.Metric{}
+	require.NoError(t, nodeCacheMtc.WithLabelValues(label).Write(m))
+	return m.GetCounter().GetValue()
+}
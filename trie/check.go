@@ -0,0 +1,212 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+)
+
+type (
+	// NodeFault is a node missing from DB, or whose content does not match its hash
+	NodeFault struct {
+		Bucket  string
+		Hash    hash.Hash32B
+		Parent  hash.Hash32B // hash of the node referring to the node, zero for the root
+		Path    []byte       // path of the keys under the node
+		Missing bool
+		Err     error
+	}
+
+	// CheckReport is the result of checking the nodes of tries
+	CheckReport struct {
+		Nodes   int // number of the nodes checked
+		Entries int // number of the entries reached
+		Faults  []*NodeFault
+	}
+
+	// checkFrame is a node to check, along with its parent and the path of the keys under it
+	checkFrame struct {
+		hash   hash.Hash32B
+		parent hash.Hash32B
+		path   []byte
+	}
+)
+
+// OK returns true if no faulty node is found
+func (r *CheckReport) OK() bool {
+	return len(r.Faults) == 0
+}
+
+// Merge adds the result of checking another trie to the report
+func (r *CheckReport) Merge(other *CheckReport) {
+	r.Nodes += other.Nodes
+	r.Entries += other.Entries
+	r.Faults = append(r.Faults, other.Faults...)
+}
+
+// Check walks the trie of the given root in DB, and re-hashes every node to find the nodes missing from DB or not
+// matching their hashes. The nodes under a faulty node cannot be reached, so they are not checked. fn is called with
+// the key and the value of every entry reached, if not nil, and an error returned by fn stops the check
+func Check(dao db.KVStore, name string, root hash.Hash32B, fn func(key, value []byte) error) (*CheckReport, error) {
+	report := &CheckReport{}
+	stack := []checkFrame{{hash: root}}
+	for len(stack) > 0 {
+		frame := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		report.Nodes++
+		ptr, _, fault := checkNode(dao, name, frame.hash)
+		if fault == nil {
+			var next []checkFrame
+			if next, fault = frame.children(name, ptr); fault == nil {
+				if l, ok := ptr.(*leaf); ok && l.Ext == 0 {
+					report.Entries++
+					if fn != nil {
+						if err := fn(append(append([]byte{}, frame.path...), l.Path...), l.Value); err != nil {
+							return report, err
+						}
+					}
+				}
+				stack = append(stack, next...)
+				continue
+			}
+		}
+		fault.Parent = frame.parent
+		fault.Path = frame.path
+		report.Faults = append(report.Faults, fault)
+	}
+	return report, nil
+}
+
+// Repair writes the nodes of the trie of the given root in src into dst, wherever the nodes are missing from dst or do
+// not match their hashes in dst. The trie in src must be complete. It returns the number of the nodes written
+func Repair(dst, src db.KVStore, name string, root hash.Hash32B) (int, error) {
+	written, batch, err := repairNodes(dst, src, name, root)
+	if err != nil || len(written) == 0 {
+		return 0, err
+	}
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+	return len(written), nil
+}
+
+// RepairVersion repairs the trie of the given version as Repair does. If the trie in dst keeps its history, the nodes
+// written are recorded as put by the version, so Prune deletes them once a later version makes them stale. A pruned
+// version cannot be repaired
+func RepairVersion(dst, src db.KVStore, name string, root hash.Hash32B, version uint64) (int, error) {
+	t := &trie{dao: dst, bucket: name}
+	oldest, history, err := t.getVersion(oldestVersionKey)
+	if err != nil {
+		return 0, err
+	}
+	if history && version < oldest {
+		return 0, errors.Errorf("version %d of trie %s is pruned, oldest version %d", version, name, oldest)
+	}
+	written, batch, err := repairNodes(dst, src, name, root)
+	if err != nil || len(written) == 0 {
+		return 0, err
+	}
+	if history {
+		h, err := t.getHistory(version)
+		if err != nil {
+			return 0, err
+		}
+		if h == nil {
+			h = &nodeHistory{}
+		}
+		h.Created = append(h.Created, written...)
+		value, err := h.serialize()
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to serialize history of version %d", version)
+		}
+		batch.Put(HistoryKVNameSpace, t.historyKey(version), value, "failed to put history of version %d", version)
+	}
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+	return len(written), nil
+}
+
+//======================================
+// private functions
+//======================================
+// repairNodes puts the nodes of the trie of the given root in src missing or corrupted in dst into a batch of dst, and
+// returns the hashes of the nodes put
+func repairNodes(dst, src db.KVStore, name string, root hash.Hash32B) ([]hash.Hash32B, db.KVStoreBatch, error) {
+	var written []hash.Hash32B
+	batch := dst.Batch()
+	stack := []checkFrame{{hash: root}}
+	for len(stack) > 0 {
+		frame := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		ptr, node, fault := checkNode(src, name, frame.hash)
+		if fault != nil {
+			return nil, nil, errors.Wrapf(fault.Err, "node %x of source trie is faulty", frame.hash[:8])
+		}
+		next, fault := frame.children(name, ptr)
+		if fault != nil {
+			return nil, nil, errors.Wrapf(fault.Err, "node %x of source trie is faulty", frame.hash[:8])
+		}
+		if _, _, fault := checkNode(dst, name, frame.hash); fault != nil {
+			batch.Put(name, frame.hash[:], node, "failed to put key = %x", frame.hash[:8])
+			written = append(written, frame.hash)
+		}
+		stack = append(stack, next...)
+	}
+	return written, batch, nil
+}
+
+// checkNode loads the node of the hash from DB, and checks the content of the node against the hash
+func checkNode(dao db.KVStore, name string, h hash.Hash32B) (patricia, []byte, *NodeFault) {
+	node, err := dao.Get(name, h[:])
+	if err != nil {
+		return nil, nil, &NodeFault{Bucket: name, Hash: h, Missing: true, Err: err}
+	}
+	ptr, err := deserializePatricia(node)
+	if err != nil {
+		return nil, nil, &NodeFault{Bucket: name, Hash: h, Err: err}
+	}
+	if ptr.hash() != h {
+		return nil, nil, &NodeFault{Bucket: name, Hash: h, Err: errors.Wrap(ErrInvalidPatricia, "hash does not match")}
+	}
+	return ptr, node, nil
+}
+
+// children returns the frames of the children of the node in the reverse order of the keys under them, so the entries
+// are visited in the order of the keys when the frames are popped
+func (f checkFrame) children(name string, ptr patricia) ([]checkFrame, *NodeFault) {
+	var hashes [][]byte
+	var paths [][]byte
+	switch node := ptr.(type) {
+	case *branch:
+		for i := RADIX - 1; i >= 0; i-- {
+			if len(node.Path[i]) == 0 {
+				continue
+			}
+			hashes = append(hashes, node.Path[i])
+			paths = append(paths, append(append([]byte{}, f.path...), byte(i)))
+		}
+	case *leaf:
+		if node.Ext == 1 {
+			hashes = append(hashes, node.Value)
+			paths = append(paths, append(append([]byte{}, f.path...), node.Path...))
+		}
+	}
+	frames := make([]checkFrame, 0, len(hashes))
+	for i, h := range hashes {
+		if len(h) != len(hash.ZeroHash32B) {
+			return nil, &NodeFault{Bucket: name, Hash: f.hash, Err: errors.Wrapf(ErrInvalidPatricia, "invalid child hash %x", h)}
+		}
+		frame := checkFrame{parent: f.hash, path: paths[i]}
+		copy(frame.hash[:], h)
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
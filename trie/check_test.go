@@ -0,0 +1,144 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+)
+
+func TestCheckAndRepair(t *testing.T) {
+	require := require.New(t)
+
+	keys := [][]byte{ham, car, cat, egg, dog}
+	values := [][]byte{testV[0], testV[1], testV[2], testV[4], testV[3]}
+	newDB := func() (db.KVStore, hash.Hash32B) {
+		dao := db.NewMemKVStore()
+		tr, err := NewTrieSharedDB(dao, "test", EmptyRoot)
+		require.Nil(err)
+		require.Nil(tr.Commit(keys, values))
+		return dao, tr.RootHash()
+	}
+	dao, root := newDB()
+	src, srcRoot := newDB()
+	require.Equal(root, srcRoot)
+
+	// a complete trie has no fault, and its entries are visited in the order of the keys
+	var visited [][]byte
+	report, err := Check(dao, "test", root, func(key, value []byte) error {
+		visited = append(visited, key)
+		return nil
+	})
+	require.Nil(err)
+	require.True(report.OK())
+	require.Equal(5, report.Entries)
+	require.Equal(keys, visited)
+	nodes := report.Nodes
+	n, err := Repair(dao, src, "test", root)
+	require.Nil(err)
+	require.Equal(0, n)
+
+	// an error of fn stops the check
+	errStop := errors.New("stop")
+	_, err = Check(dao, "test", root, func(key, value []byte) error { return errStop })
+	require.Equal(errStop, err)
+
+	// the node under the root is missing, so the entries under it cannot be reached
+	node, err := dao.Get("test", root[:])
+	require.Nil(err)
+	ptr, err := deserializePatricia(node)
+	require.Nil(err)
+	var child hash.Hash32B
+	copy(child[:], ptr.(*branch).Path[1])
+	childNode, err := dao.Get("test", child[:])
+	require.Nil(err)
+	require.Nil(dao.Delete("test", child[:]))
+	report, err = Check(dao, "test", root, nil)
+	require.Nil(err)
+	require.False(report.OK())
+	require.Equal(2, report.Nodes)
+	require.Equal(0, report.Entries)
+	require.Equal(1, len(report.Faults))
+	fault := report.Faults[0]
+	require.True(fault.Missing)
+	require.Equal(child, fault.Hash)
+	require.Equal(root, fault.Parent)
+	require.Equal([]byte{1}, fault.Path)
+
+	// the faulty trie cannot be the source of a repair
+	_, err = Repair(src, dao, "test", root)
+	require.NotNil(err)
+	n, err = Repair(dao, src, "test", root)
+	require.Nil(err)
+	require.Equal(1, n)
+	report, err = Check(dao, "test", root, nil)
+	require.Nil(err)
+	require.True(report.OK())
+	require.Equal(nodes, report.Nodes)
+
+	// the content of the root does not match its hash
+	require.Nil(dao.Put("test", root[:], childNode))
+	report, err = Check(dao, "test", root, nil)
+	require.Nil(err)
+	require.Equal(1, len(report.Faults))
+	require.False(report.Faults[0].Missing)
+	require.Equal(ErrInvalidPatricia, errors.Cause(report.Faults[0].Err))
+	n, err = Repair(dao, src, "test", root)
+	require.Nil(err)
+	require.Equal(1, n)
+	report, err = Check(dao, "test", root, nil)
+	require.Nil(err)
+	require.True(report.OK())
+	require.Equal(5, report.Entries)
+}
+
+func TestRepairVersion(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieWithHistory(dao, "test", EmptyRoot)
+	require.Nil(err)
+	require.Nil(tr.Upsert(cat, testV[2]))
+	require.Nil(tr.Upsert(fox, testV[5]))
+	require.Nil(tr.Checkpoint(1))
+	require.Nil(tr.Upsert(cat, testV[3]))
+	require.Nil(tr.Upsert(dog, testV[4]))
+	require.Nil(tr.Checkpoint(2))
+	root := tr.RootHash()
+	src := db.NewMemKVStore()
+	srcTrie, err := NewTrieSharedDB(src, "test", EmptyRoot)
+	require.Nil(err)
+	require.Nil(srcTrie.Commit([][]byte{cat, fox, dog}, [][]byte{testV[3], testV[5], testV[4]}))
+	require.Equal(root, srcTrie.RootHash())
+
+	// the node written is recorded as put by the version
+	require.Nil(dao.Delete("test", root[:]))
+	n, err := RepairVersion(dao, src, "test", root, 2)
+	require.Nil(err)
+	require.Equal(1, n)
+	h, err := tr.(*trie).getHistory(2)
+	require.Nil(err)
+	require.Contains(h.Created, root)
+
+	// the node is pruned once a later version makes it stale
+	require.Nil(tr.Delete(fox))
+	require.Nil(tr.Checkpoint(3))
+	require.Nil(tr.Prune(3))
+	_, err = dao.Get("test", root[:])
+	require.NotNil(err)
+
+	// a pruned version cannot be repaired
+	_, err = RepairVersion(dao, src, "test", root, 2)
+	require.NotNil(err)
+	_, err = dao.Get("test", root[:])
+	require.NotNil(err)
+}
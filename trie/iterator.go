@@ -0,0 +1,169 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+)
+
+type (
+	// Iterator iterates over the entries of a trie in the order of the keys. The nodes are loaded from the DB as the
+	// iterator moves, so the trie of the root must not be pruned before the iteration is done
+	Iterator interface {
+		// Next moves to the next entry, it returns false when there is no more entry or an error happens
+		Next() bool
+		// Key returns the key of the current entry
+		Key() []byte
+		// Value returns the value of the current entry
+		Value() []byte
+		// Error returns the error stopping the iteration, if any
+		Error() error
+	}
+
+	// iterator implements the Iterator interface by a depth-first traversal of the patricia nodes. It only reads the
+	// nodes in DB
+	iterator struct {
+		dao    db.KVStore
+		bucket string
+		start  []byte // the entries before the start key are skipped
+		prefix []byte // the iteration ends at the first entry without the prefix
+		stack  []iteratorFrame
+		key    []byte
+		value  []byte
+		err    error
+	}
+
+	// iteratorFrame is a node on the path being visited, along with the path to the node and the next child to visit
+	iteratorFrame struct {
+		ptr   patricia
+		path  []byte
+		child int
+	}
+)
+
+// NewIterator creates an iterator over the entries of the trie of the given root, starting from the first key not less
+// than the start key. A nil start key iterates over all the entries
+func NewIterator(dao db.KVStore, name string, root hash.Hash32B, start []byte) (Iterator, error) {
+	return newIterator(dao, name, root, start, nil)
+}
+
+// NewPrefixIterator creates an iterator over the entries whose keys have the given prefix, in the trie of the given root
+func NewPrefixIterator(dao db.KVStore, name string, root hash.Hash32B, prefix []byte) (Iterator, error) {
+	return newIterator(dao, name, root, prefix, prefix)
+}
+
+// Next moves to the next entry
+func (it *iterator) Next() bool {
+	it.key, it.value = nil, nil
+	for it.err == nil && len(it.stack) > 0 {
+		top := len(it.stack) - 1
+		frame := it.stack[top]
+		switch node := frame.ptr.(type) {
+		case *branch:
+			if frame.child >= RADIX {
+				it.stack = it.stack[:top]
+				continue
+			}
+			i := frame.child
+			it.stack[top].child++
+			if len(node.Path[i]) == 0 {
+				continue
+			}
+			it.descend(node.Path[i], append(append([]byte{}, frame.path...), byte(i)))
+		case *leaf:
+			it.stack = it.stack[:top]
+			path := append(append([]byte{}, frame.path...), node.Path...)
+			if node.Ext == 1 {
+				// extension stores the hash of the next patricia node
+				it.descend(node.Value, path)
+				continue
+			}
+			if bytes.Compare(path, it.start) < 0 {
+				continue
+			}
+			if !bytes.HasPrefix(path, it.prefix) {
+				// the keys after are not of the prefix either
+				it.stack = nil
+				return false
+			}
+			it.key, it.value = path, node.Value
+			return true
+		default:
+			it.err = errors.Wrapf(ErrInvalidPatricia, "unknown patricia node %T", frame.ptr)
+		}
+	}
+	return false
+}
+
+// Key returns the key of the current entry
+func (it *iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value of the current entry
+func (it *iterator) Value() []byte {
+	return it.value
+}
+
+// Error returns the error stopping the iteration
+func (it *iterator) Error() error {
+	return it.err
+}
+
+//======================================
+// private functions
+//======================================
+func newIterator(dao db.KVStore, name string, root hash.Hash32B, start, prefix []byte) (*iterator, error) {
+	it := iterator{
+		dao:    dao,
+		bucket: name,
+		start:  start,
+		prefix: prefix,
+	}
+	if root == EmptyRoot {
+		// the empty trie has no entry, and its root may not be in DB
+		return &it, nil
+	}
+	ptr, err := loadPatricia(dao, name, nil, root[:])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load trie of root %x", root[:8])
+	}
+	it.stack = []iteratorFrame{{ptr: ptr}}
+	return &it, nil
+}
+
+// descend pushes the node of the hash onto the stack, unless none of the keys under the path can be iterated over
+func (it *iterator) descend(hash []byte, path []byte) {
+	n := len(path)
+	if n > len(it.start) {
+		n = len(it.start)
+	}
+	if bytes.Compare(path[:n], it.start[:n]) < 0 {
+		// the keys under the path are all before the start key
+		return
+	}
+	n = len(path)
+	if n > len(it.prefix) {
+		n = len(it.prefix)
+	}
+	if !bytes.Equal(path[:n], it.prefix[:n]) {
+		// the keys under the path are all after the prefix
+		it.stack = nil
+		return
+	}
+	ptr, err := loadPatricia(it.dao, it.bucket, nil, hash)
+	if err != nil {
+		it.err = err
+		return
+	}
+	it.stack = append(it.stack, iteratorFrame{ptr: ptr, path: path})
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/db"
+)
+
+func TestIterator(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot)
+	require.Nil(err)
+	it, err := NewIterator(dao, "test", EmptyRoot, nil)
+	require.Nil(err)
+	require.False(it.Next())
+	require.Nil(it.Error())
+	// iterating over an empty trie does not write its root
+	empty := db.NewMemKVStore()
+	it, err = NewIterator(empty, "test", EmptyRoot, nil)
+	require.Nil(err)
+	require.False(it.Next())
+	_, err = empty.Get("test", EmptyRoot[:])
+	require.Error(err)
+
+	// the keys in the order of iteration
+	keys := [][]byte{ham, car, cat, rat, egg, dog, fox, cow, ant}
+	values := make(map[string][]byte)
+	for i, key := range keys {
+		values[string(key)] = []byte{byte(i)}
+	}
+	// insert in a different order
+	for _, i := range []int{8, 2, 0, 6, 4, 1, 7, 3, 5} {
+		require.Nil(tr.Upsert(keys[i], values[string(keys[i])]))
+	}
+	root := tr.RootHash()
+
+	collect := func(it Iterator) [][]byte {
+		var got [][]byte
+		for it.Next() {
+			require.Equal(values[string(it.Key())], it.Value())
+			got = append(got, it.Key())
+		}
+		require.Nil(it.Error())
+		return got
+	}
+	it, err = NewIterator(dao, "test", root, nil)
+	require.Nil(err)
+	require.Equal(keys, collect(it))
+
+	// from a start key in the trie, or between the keys
+	it, err = NewIterator(dao, "test", root, cat)
+	require.Nil(err)
+	require.Equal(keys[2:], collect(it))
+	it, err = NewIterator(dao, "test", root, []byte{1, 2, 3, 4, 5, 6, 8})
+	require.Nil(err)
+	require.Equal(keys[4:], collect(it))
+	it, err = NewIterator(dao, "test", root, []byte{3})
+	require.Nil(err)
+	require.Empty(collect(it))
+
+	// of a prefix
+	it, err = NewPrefixIterator(dao, "test", root, []byte{1, 2, 3, 4, 5})
+	require.Nil(err)
+	require.Equal(keys[1:5], collect(it))
+	it, err = NewPrefixIterator(dao, "test", root, []byte{1, 2})
+	require.Nil(err)
+	require.Equal(keys[:8], collect(it))
+	it, err = NewPrefixIterator(dao, "test", root, []byte{1, 2, 4})
+	require.Nil(err)
+	require.Empty(collect(it))
+	it, err = NewPrefixIterator(dao, "test", root, cow)
+	require.Nil(err)
+	require.Equal([][]byte{cow}, collect(it))
+
+	// the trie of an earlier root is iterated over as long as its nodes are kept
+	hist, err := NewTrieWithHistory(db.NewMemKVStore(), "test", EmptyRoot)
+	require.Nil(err)
+	require.Nil(hist.Upsert(cat, values[string(cat)]))
+	require.Nil(hist.Upsert(dog, values[string(dog)]))
+	root = hist.RootHash()
+	require.Nil(hist.Upsert(ant, values[string(ant)]))
+	require.Nil(hist.Delete(cat))
+	it, err = NewIterator(hist.(*trie).dao, "test", root, nil)
+	require.Nil(err)
+	require.Equal([][]byte{cat, dog}, collect(it))
+	it, err = NewIterator(hist.(*trie).dao, "test", hist.RootHash(), nil)
+	require.Nil(err)
+	require.Equal([][]byte{dog, ant}, collect(it))
+
+	// the nodes deleted from the DB stop the iteration
+	it, err = NewIterator(dao, "test", tr.RootHash(), nil)
+	require.Nil(err)
+	require.Nil(tr.Delete(rat))
+	require.Nil(tr.Delete(egg))
+	require.NotEqual(keys, func() [][]byte {
+		var got [][]byte
+		for it.Next() {
+			got = append(got, it.Key())
+		}
+		return got
+	}())
+	require.Error(it.Error())
+}
@@ -33,7 +33,7 @@ type (
 	patricia interface {
 		descend([]byte) ([]byte, int, error)
 		ascend([]byte, byte) error
-		insert([]byte, []byte, *list.List) error
+		insert([]byte, []byte, bool, *list.List) error
 		increase([]byte) (int, int, int)
 		collapse([]byte, []byte, byte, bool) ([]byte, []byte, bool)
 		set([]byte, byte) error
@@ -44,11 +44,14 @@ type (
 	}
 	// key of next patricia node
 	ptrcKey []byte
-	// branch is the full node having 256 hashes for next level patricia node + hash of leaf node
+	// branch is the full node having 256 hashes for next level patricia node + hash of leaf node. An indexed branch
+	// is hashed over the slot index of each child as well, and is told apart by its type byte rather than by gob, so
+	// that the other branches keep their encoding
 	branch struct {
-		Split bool
-		Path  [RADIX]ptrcKey
-		Value []byte
+		Split   bool
+		Path    [RADIX]ptrcKey
+		Value   []byte
+		indexed bool
 	}
 	// leaf is squashed path + actual value (or hash of next patricia node for extension)
 	leaf struct {
@@ -82,7 +85,7 @@ func (b *branch) ascend(key []byte, index byte) error {
 }
 
 // insert <k, v> at current patricia node
-func (b *branch) insert(k, v []byte, stack *list.List) error {
+func (b *branch) insert(k, v []byte, indexed bool, stack *list.List) error {
 	node := b.Path[k[0]]
 	if node != nil {
 		return errors.Wrapf(ErrInvalidPatricia, "branch already has path = %d", k[0])
@@ -150,8 +153,24 @@ func (b *branch) blob() ([]byte, []byte, error) {
 	return nil, nil, errors.Wrap(ErrInvalidPatricia, "branch does not store value")
 }
 
-// hash return the hash of this node
+// hash return the hash of this node. An indexed branch is hashed over the number of its children, and the slot index
+// and the hash of each child, after the type byte of a branch which no leaf starts with. An empty branch is hashed the
+// same either way, so the root hash of an empty trie is always EmptyRoot
 func (b *branch) hash() hash.Hash32B {
+	if b.indexed && !b.empty() {
+		children := 0
+		entries := []byte{}
+		for i := 0; i < RADIX; i++ {
+			if len(b.Path[i]) > 0 {
+				children++
+				entries = append(entries, byte(i))
+				entries = append(entries, b.Path[i]...)
+			}
+		}
+		stream := append([]byte{2, byte(children >> 8), byte(children)}, entries...)
+		stream = append(stream, b.Value...)
+		return blake2b.Sum256(stream)
+	}
 	stream := []byte{}
 	for i := 0; i < RADIX; i++ {
 		stream = append(stream, b.Path[i]...)
@@ -160,6 +179,16 @@ func (b *branch) hash() hash.Hash32B {
 	return blake2b.Sum256(stream)
 }
 
+// empty returns true if the branch has neither a child nor a value
+func (b *branch) empty() bool {
+	for i := 0; i < RADIX; i++ {
+		if len(b.Path[i]) > 0 {
+			return false
+		}
+	}
+	return len(b.Value) == 0
+}
+
 // serialize to bytes
 func (b *branch) serialize() ([]byte, error) {
 	var stream bytes.Buffer
@@ -167,7 +196,10 @@ func (b *branch) serialize() ([]byte, error) {
 	if err := enc.Encode(b); err != nil {
 		return nil, err
 	}
-	// first byte denotes the type of patricia: 2-branch, 1-extension, 0-leaf
+	// first byte denotes the type of patricia: 3-indexed branch, 2-branch, 1-extension, 0-leaf
+	if b.indexed {
+		return append([]byte{3}, stream.Bytes()...), nil
+	}
 	return append([]byte{2}, stream.Bytes()...), nil
 }
 
@@ -176,7 +208,11 @@ func (b *branch) deserialize(stream []byte) error {
 	// reset variable
 	*b = branch{}
 	dec := gob.NewDecoder(bytes.NewBuffer(stream[1:]))
-	return dec.Decode(b)
+	if err := dec.Decode(b); err != nil {
+		return err
+	}
+	b.indexed = stream[0] == 3
+	return nil
 }
 
 func (b *branch) print() {
@@ -214,8 +250,8 @@ func (l *leaf) ascend(key []byte, index byte) error {
 	return nil
 }
 
-// insert <k, v> at current patricia node
-func (l *leaf) insert(k, v []byte, stack *list.List) error {
+// insert <k, v> at current patricia node, the new branch is indexed if indexed is true
+func (l *leaf) insert(k, v []byte, indexed bool, stack *list.List) error {
 	// get the matching length
 	match := 0
 	for l.Path[match] == k[match] {
@@ -228,7 +264,7 @@ func (l *leaf) insert(k, v []byte, stack *list.List) error {
 	if l.Ext == 1 {
 		// split the current ext
 		logger.Debug().Hex("new key", k[match:]).Msg("diverge")
-		if err := l.split(match, k[match:], v, stack); err != nil {
+		if err := l.split(match, k[match:], v, indexed, stack); err != nil {
 			return err
 		}
 		n := stack.Front()
@@ -254,7 +290,7 @@ func (l *leaf) insert(k, v []byte, stack *list.List) error {
 	l2 := leaf{0, k[match+1:], v}
 	hashl2 := l2.hash()
 	// add 1 branch to link 2 new leaf
-	b := branch{}
+	b := branch{indexed: indexed}
 	b.Path[l.Path[match]] = hashl1[:]
 	b.Path[k[match]] = hashl2[:]
 	stack.PushBack(&b)
@@ -360,7 +396,7 @@ func (l *leaf) deserialize(stream []byte) error {
 // E -> B[P[0]] -> E <P[1:]], E.value>
 //      B[k[0]] -> Leaf <k[1:], v> this is the <k, v> to be inserted
 //======================================
-func (l *leaf) split(match int, k, v []byte, stack *list.List) error {
+func (l *leaf) split(match int, k, v []byte, indexed bool, stack *list.List) error {
 	var node patricia
 	divPath := l.Path[match:]
 	logger.Debug().Hex("curr key", divPath).Msg("diverge")
@@ -369,7 +405,7 @@ func (l *leaf) split(match int, k, v []byte, stack *list.List) error {
 	hashl := l1.hash()
 	logger.Debug().Hex("L", hashl[:8]).Hex("path", k[1:]).Msg("splitL")
 	// add 1 branch to link new leaf and current ext (which may split as below)
-	b := branch{}
+	b := branch{indexed: indexed}
 	b.Path[k[0]] = hashl[:]
 	switch len(divPath) {
 	case 1:
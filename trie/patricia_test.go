@@ -55,7 +55,17 @@ func TestPatricia(t *testing.T) {
 	assert.Equal(0, bytes.Compare(hash2, b1.Path[11]))
 	assert.Equal(byte(1), b1.Value[0])
 	assert.Equal(byte(6), b1.Value[1])
-	assert.Equal(454, len(stream))
+	assert.Equal(453, len(stream))
+	// an indexed branch only differs in the type byte
+	b.indexed = true
+	indexed, err := b.serialize()
+	assert.Nil(err)
+	assert.Equal(byte(3), indexed[0])
+	assert.Equal(stream[1:], indexed[1:])
+	assert.Nil(b1.deserialize(indexed))
+	assert.True(b1.indexed)
+	assert.Nil(b1.deserialize(stream))
+	assert.False(b1.indexed)
 
 	e := leaf{1, nil, make([]byte, hash.HashSize)}
 	e.Path = []byte{2, 3, 5, 7}
@@ -0,0 +1,174 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotexproject/iotex-core/db"
+	"github.com/iotexproject/iotex-core/pkg/hash"
+)
+
+// ErrInvalidProof indicates the proof does not match the root hash or the key
+var ErrInvalidProof = errors.New("invalid trie proof")
+
+// Prove returns the serialized patricia nodes on the path from the root to the entry of the key, in the trie of the
+// given root. If the key does not exist, the path ends at the node where the key diverges, which proves the absence
+func Prove(dao db.KVStore, name string, root hash.Hash32B, key []byte) ([][]byte, error) {
+	t, err := newTrie(dao, name, root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load trie of root %x", root[:8])
+	}
+	return t.prove(key)
+}
+
+// VerifyProof checks the patricia nodes of the proof link from the root hash to the entry of the key, and returns the
+// value of the entry. If the proof ends at the node where the key diverges, the absence of the key is proven and
+// ErrNotExist is returned. It does not need access to the trie, but the keys of the trie must be of the same length.
+//
+// A branch not hashed over the slot indexes of its children does not commit to their positions, so a prover could move
+// a child to another empty slot between its neighbours, and forge both the absence of a key and the value of another
+// key. Only the proofs of a trie with indexed branches, which is required by indexed, can be trusted
+func VerifyProof(root hash.Hash32B, key []byte, proof [][]byte, indexed bool) ([]byte, error) {
+	expected := root[:]
+	for i, node := range proof {
+		ptr, err := deserializePatricia(node)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidProof, "node %d: %v", i, err)
+		}
+		if h := ptr.hash(); !bytes.Equal(h[:], expected) {
+			return nil, errors.Wrapf(ErrInvalidProof, "hash of node %d does not match, expecting %x", i, expected)
+		}
+		if err := checkProofNode(ptr, key, indexed); err != nil {
+			return nil, errors.Wrapf(ErrInvalidProof, "node %d: %v", i, err)
+		}
+		next, remaining, err := proofStep(ptr, key)
+		if err != nil && errors.Cause(err) != ErrNotExist {
+			return nil, errors.Wrapf(ErrInvalidProof, "node %d: %v", i, err)
+		}
+		if err != nil || next == nil {
+			if i != len(proof)-1 {
+				return nil, errors.Wrapf(ErrInvalidProof, "proof has %d nodes after the end of the path", len(proof)-1-i)
+			}
+			if err != nil {
+				return nil, err
+			}
+			_, value, _ := ptr.blob()
+			return value, nil
+		}
+		expected, key = next, remaining
+	}
+	return nil, errors.Wrap(ErrInvalidProof, "proof ends before the end of the path")
+}
+
+//======================================
+// private functions
+//======================================
+// prove collects the serialized nodes on the path of the key from the root of the trie, up to the leaf of the key or
+// the node where the key diverges
+func (t *trie) prove(key []byte) ([][]byte, error) {
+	return provePath(t.root, key, t.getPatricia)
+}
+
+// provePath collects the serialized nodes on the path of the key from the root node, loading the nodes on the way by
+// getPatricia
+func provePath(ptr patricia, key []byte, getPatricia func([]byte) (patricia, error)) ([][]byte, error) {
+	var proof [][]byte
+	for {
+		node, err := ptr.serialize()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to serialize patricia")
+		}
+		proof = append(proof, node)
+		next, remaining, err := proofStep(ptr, key)
+		if errors.Cause(err) == ErrNotExist {
+			return proof, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return proof, nil
+		}
+		if ptr, err = getPatricia(next); err != nil {
+			return nil, err
+		}
+		key = remaining
+	}
+}
+
+// proofStep descends a patricia node along the key, and returns the hash of the next node and the remaining key, or a
+// nil hash if the node is the leaf of the key. ErrNotExist is returned if the key diverges at the node
+func proofStep(ptr patricia, key []byte) ([]byte, []byte, error) {
+	switch node := ptr.(type) {
+	case *branch:
+		if len(key) == 0 || len(node.Path[key[0]]) == 0 {
+			return nil, nil, errors.Wrapf(ErrNotExist, "key = %x not exist", key)
+		}
+		return node.Path[key[0]], key[1:], nil
+	case *leaf:
+		if node.Ext == 1 {
+			if !bytes.HasPrefix(key, node.Path) {
+				return nil, nil, errors.Wrapf(ErrNotExist, "key = %x not exist", key)
+			}
+			return node.Value, key[len(node.Path):], nil
+		}
+		if !bytes.Equal(key, node.Path) {
+			return nil, nil, errors.Wrapf(ErrNotExist, "key = %x not exist", key)
+		}
+		return nil, nil, nil
+	default:
+		return nil, nil, errors.Wrapf(ErrInvalidPatricia, "unknown patricia node %T", ptr)
+	}
+}
+
+// checkProofNode checks the node of a proof is encoded the only way its hash can be read. A non-empty branch is hashed
+// over the slot indexes if indexed is true, an extension links to a hash, and a leaf holds the entire remaining key
+func checkProofNode(ptr patricia, key []byte, indexed bool) error {
+	switch node := ptr.(type) {
+	case *branch:
+		if node.indexed != indexed && !node.empty() {
+			return errors.Errorf("branch is not hashed as expected, indexed = %t", indexed)
+		}
+	case *leaf:
+		switch node.Ext {
+		case 1:
+			if len(node.Value) != hash.HashSize {
+				return errors.Errorf("extension links to %d bytes", len(node.Value))
+			}
+		case 0:
+			if len(node.Path) != len(key) {
+				return errors.Errorf("leaf has %d bytes of path, expecting %d", len(node.Path), len(key))
+			}
+		default:
+			return errors.Errorf("invalid leaf type = %d", node.Ext)
+		}
+	}
+	return nil
+}
+
+// deserializePatricia decodes a patricia node by the type in its first byte
+func deserializePatricia(node []byte) (patricia, error) {
+	if len(node) == 0 {
+		return nil, errors.Wrap(ErrInvalidPatricia, "empty node")
+	}
+	var ptr patricia
+	switch node[0] {
+	case 3, 2:
+		ptr = &branch{}
+	case 1, 0:
+		ptr = &leaf{}
+	default:
+		return nil, errors.Wrapf(ErrInvalidPatricia, "invalid node type = %v", node[0])
+	}
+	if err := ptr.deserialize(node); err != nil {
+		return nil, err
+	}
+	return ptr, nil
+}
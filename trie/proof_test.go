@@ -0,0 +1,219 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/db"
+)
+
+func TestProve(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot)
+	require.Nil(err)
+	// the keys share prefixes, so the proofs go through branch, extension and leaf nodes
+	keys := [][]byte{ham, car, cat, dog, egg, fox}
+	for i, key := range keys {
+		require.Nil(tr.Upsert(key, testV[i]))
+	}
+	root := tr.RootHash()
+
+	for i, key := range keys {
+		proof, err := Prove(dao, "test", root, key)
+		require.Nil(err)
+		require.True(len(proof) > 1)
+		v, err := VerifyProof(root, key, proof, false)
+		require.Nil(err)
+		require.Equal(testV[i], v)
+
+		// the proof does not verify against another root, or for another key
+		_, err = VerifyProof(EmptyRoot, key, proof, false)
+		require.Equal(ErrInvalidProof, errors.Cause(err))
+		_, err = VerifyProof(root, keys[(i+1)%len(keys)], proof, false)
+		require.Error(err)
+		// nor if a node is missing or tampered with
+		_, err = VerifyProof(root, key, proof[:len(proof)-1], false)
+		require.Equal(ErrInvalidProof, errors.Cause(err))
+		tampered := append([][]byte{}, proof...)
+		tampered[len(tampered)-1] = append([]byte{}, tampered[len(tampered)-1]...)
+		tampered[len(tampered)-1][len(tampered[len(tampered)-1])-2] ^= 1
+		_, err = VerifyProof(root, key, tampered, false)
+		require.Error(err)
+	}
+
+	// the nodes of the earlier root are deleted as the trie does not keep the history
+	require.Nil(tr.Upsert(cow, testV[6]))
+	_, err = Prove(dao, "test", root, cow)
+	require.Error(err)
+	proof, err := Prove(dao, "test", tr.RootHash(), cow)
+	require.Nil(err)
+	v, err := VerifyProof(tr.RootHash(), cow, proof, false)
+	require.Nil(err)
+	require.Equal(testV[6], v)
+
+	// the absence of the keys not in the trie is proven by the path up to where the key diverges
+	root = tr.RootHash()
+	for _, key := range [][]byte{ant, rat, {1, 2, 3, 4, 5, 6, 0, 0}, {1, 2, 3, 4, 6, 7, 1, 1}} {
+		proof, err := Prove(dao, "test", root, key)
+		require.Nil(err)
+		_, err = VerifyProof(root, key, proof, false)
+		require.Equal(ErrNotExist, errors.Cause(err))
+		// the proof of absence cannot be extended or cut short
+		_, err = VerifyProof(root, key, append(proof, proof[0]), false)
+		require.Equal(ErrInvalidProof, errors.Cause(err))
+		if len(proof) > 1 {
+			_, err = VerifyProof(root, key, proof[:len(proof)-1], false)
+			require.Equal(ErrInvalidProof, errors.Cause(err))
+		}
+	}
+	// the proof of an existing key does not prove its absence
+	proof, err = Prove(dao, "test", root, cat)
+	require.Nil(err)
+	_, err = VerifyProof(root, cat, proof, false)
+	require.Nil(err)
+}
+
+func TestTrieProve(t *testing.T) {
+	require := require.New(t)
+
+	tr, err := NewTrie("", "test", EmptyRoot, true)
+	require.Nil(err)
+	proof, err := tr.Prove(cat)
+	require.Nil(err)
+	_, err = VerifyProof(EmptyRoot, cat, proof, false)
+	require.Equal(ErrNotExist, errors.Cause(err))
+
+	// the nodes in the cache of batch mode are proven before they are flushed
+	require.Nil(tr.EnableBatch())
+	for i, key := range [][]byte{cat, car, dog} {
+		require.Nil(tr.Upsert(key, testV[i]))
+	}
+	root := tr.RootHash()
+	for i, key := range [][]byte{cat, car, dog} {
+		proof, err := tr.Prove(key)
+		require.Nil(err)
+		v, err := VerifyProof(root, key, proof, false)
+		require.Nil(err)
+		require.Equal(testV[i], v)
+	}
+	proof, err = tr.Prove(egg)
+	require.Nil(err)
+	_, err = VerifyProof(root, egg, proof, false)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.Nil(tr.Flush())
+	tr.DisableBatch()
+	require.Nil(tr.Close())
+}
+
+func TestProveIndexed(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot)
+	require.Nil(err)
+	keys := [][]byte{ham, car, cat, dog, egg, fox}
+	for i, key := range keys {
+		require.Nil(tr.Upsert(key, testV[i]))
+	}
+	legacyRoot := tr.RootHash()
+
+	// a child of a legacy branch can be moved to an empty slot next to it, which forges the proofs of another key
+	proof, err := Prove(dao, "test", legacyRoot, cat)
+	require.Nil(err)
+	forged, forgedKey := moveChild(t, proof, cat)
+	v, err := VerifyProof(legacyRoot, forgedKey, forged, false)
+	require.Nil(err)
+	require.Equal(testV[2], v)
+
+	// rehashing the branches changes the root, and the proofs are only accepted as of an indexed trie
+	require.Nil(tr.IndexBranches(true))
+	root := tr.RootHash()
+	require.NotEqual(legacyRoot, root)
+	for i, key := range keys {
+		proof, err := Prove(dao, "test", root, key)
+		require.Nil(err)
+		v, err := VerifyProof(root, key, proof, true)
+		require.Nil(err)
+		require.Equal(testV[i], v)
+		_, err = VerifyProof(root, key, proof, false)
+		require.Equal(ErrInvalidProof, errors.Cause(err))
+	}
+	_, err = VerifyProof(legacyRoot, cat, proof, true)
+	require.Equal(ErrInvalidProof, errors.Cause(err))
+	proof, err = Prove(dao, "test", root, cat)
+	require.Nil(err)
+	forged, forgedKey = moveChild(t, proof, cat)
+	_, err = VerifyProof(root, forgedKey, forged, true)
+	require.Equal(ErrInvalidProof, errors.Cause(err))
+	proof, err = Prove(dao, "test", root, forgedKey)
+	require.Nil(err)
+	_, err = VerifyProof(root, forgedKey, proof, true)
+	require.Equal(ErrNotExist, errors.Cause(err))
+
+	// the entries added later are in indexed branches as well, the same as a trie indexed from the start
+	require.Nil(tr.Upsert(cow, testV[6]))
+	require.Nil(tr.Delete(dog))
+	tr1, err := NewTrie("", "test", EmptyRoot, true)
+	require.Nil(err)
+	require.Nil(tr1.IndexBranches(true))
+	for i, key := range [][]byte{ham, car, cat, egg, fox, cow} {
+		require.Nil(tr1.Upsert(key, testV[[]int{0, 1, 2, 4, 5, 6}[i]]))
+	}
+	require.Equal(tr1.RootHash(), tr.RootHash())
+
+	// and rehashing them back gives the root of the legacy trie
+	require.Nil(tr.Upsert(dog, testV[3]))
+	require.Nil(tr.Delete(cow))
+	require.Equal(root, tr.RootHash())
+	require.Nil(tr.IndexBranches(false))
+	require.Equal(legacyRoot, tr.RootHash())
+	for i, key := range keys {
+		v, err := tr.Get(key)
+		require.Nil(err)
+		require.Equal(testV[i], v)
+	}
+	require.Nil(tr1.Close())
+}
+
+// moveChild moves the child on the path of the key in the first branch of the proof to an empty slot next to it, and
+// returns the changed proof and the key of the moved slot
+func moveChild(t *testing.T, proof [][]byte, key []byte) ([][]byte, []byte) {
+	remaining := key
+	for i, node := range proof {
+		ptr, err := deserializePatricia(node)
+		require.Nil(t, err)
+		b, ok := ptr.(*branch)
+		if !ok {
+			_, remaining, err = proofStep(ptr, remaining)
+			require.Nil(t, err)
+			continue
+		}
+		slot := int(remaining[0])
+		for _, moved := range []int{slot + 1, slot - 1} {
+			if moved < 0 || moved >= RADIX || len(b.Path[moved]) > 0 {
+				continue
+			}
+			b.Path[moved], b.Path[slot] = b.Path[slot], nil
+			value, err := b.serialize()
+			require.Nil(t, err)
+			forged := append([][]byte{}, proof...)
+			forged[i] = value
+			forgedKey := append([]byte{}, key...)
+			forgedKey[len(key)-len(remaining)] = byte(moved)
+			return forged, forgedKey
+		}
+		require.FailNow(t, "no empty slot next to the child")
+	}
+	require.FailNow(t, "no branch in the proof")
+	return nil, nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"sync"
+
+	"github.com/iotexproject/iotex-core/pkg/hash"
+)
+
+// reader implements the Reader interface. It only reads the root of the pinned version and the nodes in DB, which the
+// writer does not modify, so it does not lock the trie
+type reader struct {
+	t       *trie
+	v       *version
+	release sync.Once
+}
+
+// RootHash returns the root hash of the version
+func (r *reader) RootHash() hash.Hash32B {
+	return r.v.root.hash()
+}
+
+// Get an existing entry
+func (r *reader) Get(key []byte) ([]byte, error) {
+	ptr := r.v.root
+	for {
+		next, remaining, err := proofStep(ptr, key)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			_, value, err := ptr.blob()
+			return value, err
+		}
+		if ptr, err = r.getPatricia(next); err != nil {
+			return nil, err
+		}
+		key = remaining
+	}
+}
+
+// Prove returns the serialized nodes on the path from the root of the version to the entry of the key, or proving its
+// absence
+func (r *reader) Prove(key []byte) ([][]byte, error) {
+	return provePath(r.v.root, key, r.getPatricia)
+}
+
+// Release unpins the version, so the stale nodes of it can be deleted by the trie
+func (r *reader) Release() {
+	r.release.Do(func() {
+		r.t.unpin(r.v.seq)
+	})
+}
+
+//======================================
+// private functions
+//======================================
+// getPatricia retrieves the patricia node from the node cache or DB, bypassing the nodes not flushed by the writer
+func (r *reader) getPatricia(key []byte) (patricia, error) {
+	return loadPatricia(r.t.dao, r.t.bucket, r.t.nodeCache, key)
+}
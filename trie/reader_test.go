@@ -0,0 +1,189 @@
+// Copyright (c) 2018 IoTeX
+// This is an alpha (internal) release and is not suitable for production. This source code is provided 'as is' and no
+// warranties are given as to title or non-infringement, merchantability or fitness for purpose and, to the extent
+// permitted by law, all liability for your use of the code is disclaimed. This source code is governed by Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotexproject/iotex-core/db"
+)
+
+func TestReader(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot)
+	require.Nil(err)
+	empty := tr.Pin()
+	require.Equal(EmptyRoot, empty.RootHash())
+	_, err = empty.Get(cat)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	empty.Release()
+	for i, key := range [][]byte{ham, car, cat} {
+		require.Nil(tr.Upsert(key, testV[i]))
+	}
+	root := tr.RootHash()
+	r1 := tr.Pin()
+	require.Equal(root, r1.RootHash())
+
+	// the pinned version stays unchanged after the trie moves on
+	require.Nil(tr.Commit([][]byte{cat, dog}, [][]byte{testV[3], testV[4]}))
+	require.Nil(tr.Delete(car))
+	require.NotEqual(root, tr.RootHash())
+	require.Equal(root, r1.RootHash())
+	for i, key := range [][]byte{ham, car, cat} {
+		v, err := r1.Get(key)
+		require.Nil(err)
+		require.Equal(testV[i], v)
+	}
+	_, err = r1.Get(dog)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	proof, err := r1.Prove(cat)
+	require.Nil(err)
+	v, err := VerifyProof(root, cat, proof, false)
+	require.Nil(err)
+	require.Equal(testV[2], v)
+
+	// a new reader pins the latest version
+	r2 := tr.Pin()
+	require.Equal(tr.RootHash(), r2.RootHash())
+	for i, key := range [][]byte{ham, cat, dog} {
+		v, err := r2.Get(key)
+		require.Nil(err)
+		require.Equal([][]byte{testV[0], testV[3], testV[4]}[i], v)
+	}
+	_, err = r2.Get(car)
+	require.Equal(ErrNotExist, errors.Cause(err))
+
+	// the changes in batch mode are not committed until flushed
+	require.Nil(tr.EnableBatch())
+	require.Nil(tr.Upsert(egg, testV[5]))
+	r3 := tr.Pin()
+	require.Equal(r2.RootHash(), r3.RootHash())
+	_, err = r3.Get(egg)
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.Nil(tr.Flush())
+	tr.DisableBatch()
+	r4 := tr.Pin()
+	v, err = r4.Get(egg)
+	require.Nil(err)
+	require.Equal(testV[5], v)
+
+	// the stale nodes are deleted once no reader needs them
+	_, err = dao.Get("test", root[:])
+	require.Nil(err)
+	r1.Release()
+	r1.Release()
+	_, err = dao.Get("test", root[:])
+	require.Nil(err)
+	require.Nil(tr.Upsert(fox, testV[6]))
+	_, err = dao.Get("test", root[:])
+	require.NotNil(err)
+	for _, r := range []Reader{r2, r3, r4} {
+		r.Release()
+	}
+	require.Nil(tr.Close())
+}
+
+func TestReaderConcurrent(t *testing.T) {
+	require := require.New(t)
+
+	keys := [][]byte{ham, car, cat, dog, egg, fox, cow, ant}
+	tr, err := NewTrieSharedDB(db.NewMemKVStore(), "test", EmptyRoot)
+	require.Nil(err)
+	values := make([][]byte, len(keys))
+	for i := range values {
+		values[i] = []byte{0, byte(i)}
+	}
+	require.Nil(tr.Commit(keys, values))
+
+	// each commit sets all the entries to the values of a round, so a reader sees either all or none of the changes
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				r := tr.Pin()
+				first, err := r.Get(keys[0])
+				for i, key := range keys[1:] {
+					if err != nil {
+						break
+					}
+					var v []byte
+					expected := []byte{first[0], byte(i + 1)}
+					if v, err = r.Get(key); err == nil && !bytes.Equal(expected, v) {
+						err = errors.Errorf("key %x has value %x, expecting %x", key, v, expected)
+					}
+				}
+				r.Release()
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for round := 1; round <= 100; round++ {
+		for i := range values {
+			values[i] = []byte{byte(round), byte(i)}
+		}
+		require.Nil(tr.Commit(keys, values))
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.Nil(err)
+	}
+	require.Nil(tr.Close())
+}
+
+func TestRecordDeferred(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot, RecordDeferredOption())
+	require.Nil(err)
+	require.Nil(tr.Commit([][]byte{ham, car, cat}, [][]byte{testV[0], testV[1], testV[2]}))
+	root := tr.RootHash()
+	r := tr.Pin()
+
+	// the node of the pinned root is recorded once the commit making it stale is flushed
+	require.Nil(tr.Commit([][]byte{cat, dog}, [][]byte{testV[3], testV[4]}))
+	_, err = dao.Get("test", root[:])
+	require.Nil(err)
+
+	// the trie is not closed as if the process crashed, the recorded nodes are deleted at next open
+	tr, err = NewTrieSharedDB(dao, "test", EmptyRoot, RecordDeferredOption())
+	require.Nil(err)
+	_, err = dao.Get("test", root[:])
+	require.Equal(db.ErrNotExist, errors.Cause(err))
+	r.Release()
+
+	// the record is emptied once the nodes are reclaimed
+	require.Nil(tr.Commit([][]byte{egg, fox}, [][]byte{testV[5], testV[6]}))
+	r = tr.Pin()
+	require.Nil(tr.Commit([][]byte{fox}, [][]byte{testV[0]}))
+	r.Release()
+	require.Nil(tr.Close())
+	value, err := dao.Get(HistoryKVNameSpace, append([]byte("test"), deferredKey...))
+	require.Nil(err)
+	require.Empty(value)
+}
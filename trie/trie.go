@@ -7,15 +7,20 @@
 package trie
 
 import (
+	"bytes"
 	"container/list"
 	"context"
+	"encoding/gob"
+	"sort"
 	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/iotexproject/iotex-core/db"
 	"github.com/iotexproject/iotex-core/logger"
+	"github.com/iotexproject/iotex-core/pkg/enc"
 	"github.com/iotexproject/iotex-core/pkg/hash"
+	"github.com/iotexproject/iotex-core/pkg/util/byteutil"
 )
 
 var (
@@ -28,6 +33,9 @@ var (
 	// ContractKVNameSpace is the bucket name for contract data storage
 	ContractKVNameSpace = "Contract"
 
+	// HistoryKVNameSpace is the bucket name for the nodes put and made stale by each version of the tries
+	HistoryKVNameSpace = "History"
+
 	// ErrInvalidTrie indicates something wrong causing invalid operation
 	ErrInvalidTrie = errors.New("invalid trie operation")
 
@@ -45,12 +53,26 @@ type (
 		Close() error                    // close the trie DB
 		Upsert([]byte, []byte) error     // insert a new entry
 		Get([]byte) ([]byte, error)      // retrieve an existing entry
+		Prove([]byte) ([][]byte, error)  // returns the nodes on the path to an entry, or proving its absence
 		Delete([]byte) error             // delete an entry
 		Commit([][]byte, [][]byte) error // commit the state changes in a batch
 		RootHash() hash.Hash32B          // returns trie's root hash
 		EnableBatch() error              // enable batch mode
 		DisableBatch()                   // disable batch mode
 		Flush() error                    // flush batched db writes to database
+		Checkpoint(uint64) error         // save the node changes since last checkpoint as the history of a version
+		Prune(uint64) error              // delete the stale nodes of the versions up to the given one
+		Pin() Reader                     // pin the latest committed version for reading without locking the trie
+		IndexBranches(bool) error        // rehash the branches with or without their slot indexes
+	}
+
+	// Reader reads a committed version of a trie. The version stays unchanged and readable while the trie is written
+	// to, until the reader is released. In a trie with history, the version is no longer readable once it is pruned
+	Reader interface {
+		RootHash() hash.Hash32B         // returns the root hash of the version
+		Get([]byte) ([]byte, error)     // retrieve an existing entry
+		Prove([]byte) ([][]byte, error) // returns the nodes on the path to an entry, or proving its absence
+		Release()                       // unpin the version, the reader must not be used afterwards
 	}
 
 	// trie implements the Trie interface
@@ -64,17 +86,73 @@ type (
 		clpsK     []byte     // path if the node can collapse after deleting an entry
 		clpsV     []byte     // value if the node can collapse after deleting an entry
 		numEntry  uint64     // number of entries added to the trie
+		counted   bool       // whether numEntry counts all the entries, which it does not for a trie opened at a root
 		numBranch uint64
 		numExt    uint64
 		numLeaf   uint64
-		cache     map[hash.Hash32B][]byte
+		pending   map[hash.Hash32B][]byte // nodes put in batch mode but not flushed yet
+		nodeCache *NodeCache              // nodes in DB, nil if the nodes are not cached
 		dbBatch   db.KVStoreBatch
 		batchMode bool
+		history   bool                      // keep stale nodes in DB until they are pruned
+		created   map[hash.Hash32B]struct{} // nodes put since the last checkpoint
+		stale     map[hash.Hash32B]struct{} // nodes made stale since the last checkpoint
+		deferred  map[hash.Hash32B]uint64   // stale nodes and the last versions having them, kept for the readers
+		createdAt map[hash.Hash32B]uint64   // latest unpruned versions putting the nodes, nil until the first prune
+		pinMutex  sync.Mutex                // guards latest and pins, which the readers access
+		latest    *version                  // the latest committed version
+		pins      map[uint64]int            // number of readers pinning each version
+		indexed   bool                      // hash the new branches with their slot indexes
+		// record the deferred stale nodes in DB, so the ones left behind by a crash are deleted at next open
+		recordDeferred bool
 	}
+
+	// version is a committed root of the trie. The writer works on its own copy of the root, so the root of a version
+	// is never modified
+	version struct {
+		seq  uint64
+		root patricia
+	}
+
+	// Option sets a trie construction parameter
+	Option func(*trie) error
+
+	// nodeHistory is the nodes put and made stale by a version of the trie
+	nodeHistory struct {
+		Created []hash.Hash32B
+		Stale   []hash.Hash32B
+	}
+)
+
+var (
+	latestVersionKey = []byte(".latest")
+	oldestVersionKey = []byte(".oldest")
+	deferredKey      = []byte(".deferred")
 )
 
+// NodeCacheOption caches the nodes read from and written to DB in the node cache, which can be shared by the tries
+func NodeCacheOption(cache *NodeCache) Option {
+	return func(t *trie) error {
+		t.nodeCache = cache
+
+		return nil
+	}
+}
+
+// RecordDeferredOption records the stale nodes kept for the readers in DB, and deletes the ones recorded by the last
+// run when the trie is opened, so the stale nodes are not left behind by a crash. Only a trie without history whose
+// entries are all put again after it is opened, such as the one the states are replayed into, can record them, as
+// the recorded nodes may be put back after they are recorded
+func RecordDeferredOption() Option {
+	return func(t *trie) error {
+		t.recordDeferred = true
+
+		return nil
+	}
+}
+
 // NewTrie creates a trie with DB filename
-func NewTrie(path string, name string, root hash.Hash32B, inMem bool) (Trie, error) {
+func NewTrie(path string, name string, root hash.Hash32B, inMem bool, opts ...Option) (Trie, error) {
 	var kvStore db.KVStore
 	if inMem {
 		kvStore = db.NewMemKVStore()
@@ -87,15 +165,59 @@ func NewTrie(path string, name string, root hash.Hash32B, inMem bool) (Trie, err
 	if err := kvStore.Start(context.Background()); err != nil {
 		return nil, err
 	}
-	t, err := newTrie(kvStore, name, root)
+	t, err := newTrie(kvStore, name, root, opts...)
 	return &t, err
 }
 
+// NewTrieSharedDB creates a trie on top of an existing KV store, so that multiple tries can share the same DB
+func NewTrieSharedDB(dao db.KVStore, name string, root hash.Hash32B, opts ...Option) (Trie, error) {
+	if dao == nil {
+		return nil, errors.New("Invalid nil KV store for Trie")
+	}
+	t, err := newTrie(dao, name, root, opts...)
+	return &t, err
+}
+
+// NewTrieWithHistory creates a trie on top of an existing KV store, which keeps the stale nodes in the DB so that the
+// trie remains accessible at the roots of earlier versions, until the versions are pruned
+func NewTrieWithHistory(dao db.KVStore, name string, root hash.Hash32B, opts ...Option) (Trie, error) {
+	if dao == nil {
+		return nil, errors.New("Invalid nil KV store for Trie")
+	}
+	t := trie{
+		dao:       dao,
+		rootHash:  root,
+		toRoot:    list.New(),
+		bucket:    name,
+		numEntry:  1,
+		counted:   root == EmptyRoot,
+		numBranch: 1,
+		history:   true,
+		created:   make(map[hash.Hash32B]struct{}),
+		stale:     make(map[hash.Hash32B]struct{}),
+		deferred:  make(map[hash.Hash32B]uint64),
+		pins:      make(map[uint64]int),
+	}
+	for _, opt := range opts {
+		if err := opt(&t); err != nil {
+			return nil, err
+		}
+	}
+	if err := t.loadRoot(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
 // Close close the DB
 func (t *trie) Close() error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
+	// the readers must have been released by now, so none of the stale nodes is needed any more
+	if err := t.reclaim(^uint64(0)); err != nil {
+		return err
+	}
 	return t.dao.Stop(context.Background())
 }
 
@@ -104,7 +226,10 @@ func (t *trie) Upsert(key, value []byte) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
-	return t.upsert(key, value)
+	if err := t.upsert(key, value); err != nil {
+		return err
+	}
+	return t.publishIfNotBatch()
 }
 
 // Get an existing entry
@@ -126,6 +251,15 @@ func (t *trie) Get(key []byte) ([]byte, error) {
 	return t.getValue(ptr, key[size-1])
 }
 
+// Prove returns the serialized nodes on the path from the root to the entry of the key, which can be verified by
+// VerifyProof against the root hash. If the key does not exist, the nodes prove its absence instead
+func (t *trie) Prove(key []byte) ([][]byte, error) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	return t.prove(key)
+}
+
 // Delete an entry
 func (t *trie) Delete(key []byte) error {
 	t.mutex.Lock()
@@ -141,6 +275,9 @@ func (t *trie) Delete(key []byte) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to query")
 	}
+	if err := t.countEntries(); err != nil {
+		return errors.Wrap(err, "failed to count entries")
+	}
 	var index byte
 	var childClps bool
 	var clpsType byte
@@ -168,7 +305,10 @@ func (t *trie) Delete(key []byte) error {
 		clpsType = 0
 	}
 	// update upstream nodes on path ascending to root
-	return t.updateDelete(ptr, childClps, clpsType)
+	if err := t.updateDelete(ptr, childClps, clpsType); err != nil {
+		return err
+	}
+	return t.publishIfNotBatch()
 }
 
 // Commit writes an array <k[], v[]> as a batch
@@ -186,7 +326,9 @@ func (t *trie) Commit(k, v [][]byte) error {
 			return err
 		}
 	}
-	t.Flush()
+	if err := t.Flush(); err != nil {
+		return err
+	}
 	t.DisableBatch()
 	return nil
 }
@@ -199,12 +341,285 @@ func (t *trie) RootHash() hash.Hash32B {
 	return t.root.hash()
 }
 
+// Checkpoint saves the nodes put and made stale since the last checkpoint as the history of the given version. If the
+// trie has been reverted to an earlier version, the histories of the given and later versions are merged into one.
+func (t *trie) Checkpoint(version uint64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.history {
+		return nil
+	}
+	merged := &nodeHistory{}
+	created := make(map[hash.Hash32B]struct{})
+	stale := make(map[hash.Hash32B]struct{})
+	batch := t.dao.Batch()
+	latest, ok, err := t.getVersion(latestVersionKey)
+	if err != nil {
+		return err
+	}
+	if ok {
+		for v := version; v <= latest; v++ {
+			h, err := t.getHistory(v)
+			if err != nil {
+				return err
+			}
+			if h == nil {
+				continue
+			}
+			h.mergeInto(created, stale)
+			batch.Delete(HistoryKVNameSpace, t.historyKey(v), "failed to delete history of version %d", v)
+		}
+	}
+	pending := &nodeHistory{}
+	for key := range t.created {
+		pending.Created = append(pending.Created, key)
+	}
+	for key := range t.stale {
+		pending.Stale = append(pending.Stale, key)
+	}
+	pending.mergeInto(created, stale)
+	for key := range created {
+		merged.Created = append(merged.Created, key)
+	}
+	for key := range stale {
+		merged.Stale = append(merged.Stale, key)
+	}
+	value, err := merged.serialize()
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize history of version %d", version)
+	}
+	batch.Put(HistoryKVNameSpace, t.historyKey(version), value, "failed to put history of version %d", version)
+	batch.Put(HistoryKVNameSpace, t.versionKey(latestVersionKey), byteutil.Uint64ToBytes(version), "failed to put latest version")
+	if _, ok, err := t.getVersion(oldestVersionKey); err != nil {
+		return err
+	} else if !ok {
+		batch.Put(HistoryKVNameSpace, t.versionKey(oldestVersionKey), byteutil.Uint64ToBytes(version), "failed to put oldest version")
+	}
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+	t.created = make(map[hash.Hash32B]struct{})
+	t.stale = make(map[hash.Hash32B]struct{})
+	if ok && version <= latest {
+		// the merged versions are indexed again at next prune
+		t.createdAt = nil
+	} else if t.createdAt != nil {
+		for _, key := range merged.Created {
+			t.createdAt[key] = version
+		}
+	}
+	return nil
+}
+
+// Prune deletes the stale nodes in the histories of the versions up to the given one, so the trie is no longer
+// accessible at the roots before the given version. A stale node is kept if a later version puts it back.
+func (t *trie) Prune(version uint64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.history {
+		return nil
+	}
+	oldest, ok, err := t.getVersion(oldestVersionKey)
+	if err != nil {
+		return err
+	}
+	if !ok || oldest > version {
+		return nil
+	}
+	latest, _, err := t.getVersion(latestVersionKey)
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		version = latest
+	}
+	// the latest versions putting the nodes are indexed once, and kept up to date by the checkpoints and the prunes
+	if t.createdAt == nil {
+		createdAt := make(map[hash.Hash32B]uint64)
+		for v := oldest; v <= latest; v++ {
+			h, err := t.getHistory(v)
+			if err != nil {
+				return err
+			}
+			if h == nil {
+				continue
+			}
+			for _, key := range h.Created {
+				createdAt[key] = v
+			}
+		}
+		t.createdAt = createdAt
+	}
+	var pruned, created []hash.Hash32B
+	batch := t.dao.Batch()
+	for v := oldest; v <= version; v++ {
+		h, err := t.getHistory(v)
+		if err != nil {
+			return err
+		}
+		if h == nil {
+			continue
+		}
+		for _, key := range h.Stale {
+			if at, ok := t.createdAt[key]; ok && at > v {
+				continue
+			}
+			pruned = append(pruned, key)
+			batch.Delete(t.bucket, key[:], "failed to delete key = %x", key[:8])
+		}
+		batch.Delete(HistoryKVNameSpace, t.historyKey(v), "failed to delete history of version %d", v)
+		created = append(created, h.Created...)
+	}
+	batch.Put(HistoryKVNameSpace, t.versionKey(oldestVersionKey), byteutil.Uint64ToBytes(version+1), "failed to put oldest version")
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+	// the nodes last put by the pruned versions cannot be put back by a version later than the ones to prune next
+	for _, key := range created {
+		if t.createdAt[key] <= version {
+			delete(t.createdAt, key)
+		}
+	}
+	for _, key := range pruned {
+		t.uncacheNode(key)
+	}
+	return nil
+}
+
+// Pin returns a reader of the latest committed version, which is the state of the trie after the last Commit or Flush,
+// or after the last Upsert or Delete out of batch mode
+func (t *trie) Pin() Reader {
+	t.pinMutex.Lock()
+	defer t.pinMutex.Unlock()
+
+	t.pins[t.latest.seq]++
+	return &reader{t: t, v: t.latest}
+}
+
+// IndexBranches rehashes the branches of the trie with their slot indexes if indexed is true, or without them
+// otherwise, and hashes the branches added later the same way. The branches of a trie are either all indexed or none,
+// so the trie is rehashed only if its branches are not hashed the given way yet
+func (t *trie) IndexBranches(indexed bool) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.indexed = indexed
+	if _, err := t.indexBranches(t.root, indexed); err != nil {
+		return errors.Wrap(err, "failed to rehash the branches")
+	}
+	t.rootHash = t.root.hash()
+	return t.publishIfNotBatch()
+}
+
+// Walk calls fn with the key and the value of every entry in the trie of the given root, in the order of the keys
+func Walk(dao db.KVStore, name string, root hash.Hash32B, fn func(key, value []byte) error) error {
+	it, err := NewIterator(dao, name, root, nil)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		if err := fn(it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// TakeHistory moves the nodes put and made stale by src since its last checkpoint into dst, so they are saved in the
+// history of dst at its next checkpoint. Both tries must keep history and share the DB and the bucket, which lets many
+// tries of the same bucket, such as the storage tries of the contracts, be checkpointed and pruned as one
+func TakeHistory(dst, src Trie) error {
+	d, ok := dst.(*trie)
+	if !ok {
+		return errors.Wrap(ErrInvalidTrie, "unknown type of destination trie")
+	}
+	s, ok := src.(*trie)
+	if !ok {
+		return errors.Wrap(ErrInvalidTrie, "unknown type of source trie")
+	}
+	if !d.history || !s.history || d.bucket != s.bucket {
+		return errors.Wrapf(ErrInvalidTrie, "cannot take history of bucket %s into bucket %s", s.bucket, d.bucket)
+	}
+	if d == s {
+		return nil
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key := range s.created {
+		d.created[key] = struct{}{}
+		delete(d.stale, key)
+	}
+	for key := range s.stale {
+		d.stale[key] = struct{}{}
+	}
+	s.created = make(map[hash.Hash32B]struct{})
+	s.stale = make(map[hash.Hash32B]struct{})
+	return nil
+}
+
+// Discard deletes the nodes put by a trie with history since its last checkpoint, and drops the unflushed writes, so an
+// unfinished trie leaves nothing behind in DB. The node of the empty root is kept as the empty tries share it. Other
+// nodes could have been in DB before they were put, so the tries sharing the bucket may need to be repaired afterwards
+func Discard(tr Trie) error {
+	t, ok := tr.(*trie)
+	if !ok {
+		return errors.Wrap(ErrInvalidTrie, "unknown type of trie")
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.history {
+		return errors.Wrapf(ErrInvalidTrie, "cannot discard the nodes of bucket %s without history", t.bucket)
+	}
+	delete(t.created, EmptyRoot)
+	if len(t.created) > 0 {
+		batch := t.dao.Batch()
+		for key := range t.created {
+			batch.Delete(t.bucket, key[:], "failed to delete key = %x", key[:8])
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+	}
+	for key := range t.created {
+		t.uncacheNode(key)
+	}
+	t.created = make(map[hash.Hash32B]struct{})
+	t.stale = make(map[hash.Hash32B]struct{})
+	t.pending = make(map[hash.Hash32B][]byte)
+	t.batchMode = false
+	return nil
+}
+
 //======================================
 // private functions
 //======================================
 // newTrie creates a trie
-func newTrie(dao db.KVStore, name string, root hash.Hash32B) (trie, error) {
-	t := trie{dao: dao, rootHash: root, toRoot: list.New(), bucket: name, numEntry: 1, numBranch: 1}
+func newTrie(dao db.KVStore, name string, root hash.Hash32B, opts ...Option) (trie, error) {
+	t := trie{
+		dao:       dao,
+		rootHash:  root,
+		toRoot:    list.New(),
+		bucket:    name,
+		numEntry:  1,
+		counted:   root == EmptyRoot,
+		numBranch: 1,
+		deferred:  make(map[hash.Hash32B]uint64),
+		pins:      make(map[uint64]int),
+	}
+	for _, opt := range opts {
+		if err := opt(&t); err != nil {
+			return t, err
+		}
+	}
+	if err := t.deleteRecordedDeferred(); err != nil {
+		return t, err
+	}
 	if err := t.loadRoot(); err != nil {
 		return t, err
 	}
@@ -218,12 +633,130 @@ func (t *trie) loadRoot() error {
 
 	if t.rootHash != EmptyRoot {
 		var err error
-		t.root, err = t.getPatricia(t.rootHash[:])
+		if t.root, err = t.getPatricia(t.rootHash[:]); err != nil {
+			return err
+		}
+		return t.publish()
+	}
+	// initial empty trie, the empty root may already exist if the DB is shared with another trie
+	t.root = &branch{indexed: t.indexed}
+	if err := t.putPatricia(t.root); err != nil {
 		return err
 	}
-	// initial empty trie
-	t.root = &branch{}
-	return t.putPatriciaNew(t.root)
+	return t.publish()
+}
+
+// indexBranches rehashes the branches under the node and the node itself, and returns whether the hash of the node
+// has changed. The rehashed nodes replace the ones in DB
+func (t *trie) indexBranches(ptr patricia, indexed bool) (bool, error) {
+	switch node := ptr.(type) {
+	case *branch:
+		if node.indexed == indexed {
+			return false, nil
+		}
+		if node.empty() {
+			// an empty branch hashes the same either way
+			node.indexed = indexed
+			return false, nil
+		}
+		rehashed := make(map[int]hash.Hash32B)
+		for i := 0; i < RADIX; i++ {
+			if len(node.Path[i]) == 0 {
+				continue
+			}
+			child, err := t.getPatricia(node.Path[i])
+			if err != nil {
+				return false, err
+			}
+			changed, err := t.indexBranches(child, indexed)
+			if err != nil {
+				return false, err
+			}
+			if changed {
+				rehashed[i] = child.hash()
+			}
+		}
+		if err := t.delPatricia(node); err != nil {
+			return false, err
+		}
+		for i, h := range rehashed {
+			node.Path[i] = append([]byte{}, h[:]...)
+		}
+		node.indexed = indexed
+		return true, t.putPatricia(node)
+	case *leaf:
+		if node.Ext == 0 {
+			return false, nil
+		}
+		child, err := t.getPatricia(node.Value)
+		if err != nil {
+			return false, err
+		}
+		changed, err := t.indexBranches(child, indexed)
+		if err != nil || !changed {
+			return false, err
+		}
+		if err := t.delPatricia(node); err != nil {
+			return false, err
+		}
+		h := child.hash()
+		node.Value = append([]byte{}, h[:]...)
+		return true, t.putPatricia(node)
+	default:
+		return false, errors.Wrapf(ErrInvalidPatricia, "unknown patricia node %T", ptr)
+	}
+}
+
+// countEntries brings numEntry in line with the entries of a trie opened at a root. Deleting an entry only needs to know
+// whether no more than 2 entries are in the trie, so the entries are counted up to 3
+func (t *trie) countEntries() error {
+	if t.counted {
+		return nil
+	}
+	entries, err := t.countEntriesUnder(t.root, 3)
+	if err != nil {
+		return err
+	}
+	if entries < 3 {
+		t.numEntry = entries + 1
+		t.counted = true
+	} else if t.numEntry < 4 {
+		t.numEntry = 4
+	}
+	return nil
+}
+
+// countEntriesUnder returns the number of the entries under the node, or max if there are more
+func (t *trie) countEntriesUnder(ptr patricia, max uint64) (uint64, error) {
+	var children [][]byte
+	switch node := ptr.(type) {
+	case *leaf:
+		if node.Ext == 0 {
+			return 1, nil
+		}
+		children = append(children, node.Value)
+	case *branch:
+		for i := 0; i < RADIX; i++ {
+			if len(node.Path[i]) > 0 {
+				children = append(children, node.Path[i])
+			}
+		}
+	}
+	entries := uint64(0)
+	for _, h := range children {
+		child, err := t.getPatricia(h)
+		if err != nil {
+			return 0, err
+		}
+		n, err := t.countEntriesUnder(child, max-entries)
+		if err != nil {
+			return 0, err
+		}
+		if entries += n; entries >= max {
+			return max, nil
+		}
+	}
+	return entries, nil
 }
 
 // upsert a new entry
@@ -239,7 +772,7 @@ func (t *trie) upsert(key, value []byte) error {
 		// key does not exist, insert at the diverging patricia node
 		nb, ne, nl := ptr.increase(key[size:])
 		addNode := list.New()
-		if err := ptr.insert(key[size:], value, addNode); err != nil {
+		if err := ptr.insert(key[size:], value, t.indexed, addNode); err != nil {
 			return errors.Wrapf(err, "failed to insert key = %x", key)
 		}
 		// update newly added patricia node into DB
@@ -438,7 +971,7 @@ func (t *trie) updateDelete(curr patricia, currClps bool, clpsType byte) error {
 			if isRoot && t.numEntry == 1 {
 				t.delPatricia(t.root)
 				t.root = nil
-				t.root = &branch{}
+				t.root = &branch{indexed: t.indexed}
 				t.rootHash = t.root.hash()
 				logger.Warn().Msg("all entries deleted, trie fallback to empty")
 				return t.putPatriciaNew(t.root)
@@ -475,44 +1008,34 @@ func (t *trie) updateDelete(curr patricia, currClps bool, clpsType byte) error {
 //======================================
 // helper functions to operate patricia
 //======================================
-func (t *trie) getCachedPatricia(key []byte) ([]byte, error) {
-	if !t.batchMode {
-		return nil, nil
+// getPatricia retrieves the patricia node according to key, from the nodes not flushed yet in batch mode, or from DB
+func (t *trie) getPatricia(key []byte) (patricia, error) {
+	if t.batchMode {
+		var hashKey hash.Hash32B
+		copy(hashKey[:], key)
+		if node, ok := t.pending[hashKey]; ok {
+			return deserializePatricia(node)
+		}
 	}
-
-	var hashKey hash.Hash32B
-	copy(hashKey[:], key)
-	node := t.cache[hashKey]
-	return node, nil
+	return loadPatricia(t.dao, t.bucket, t.nodeCache, key)
 }
 
-// getPatricia retrieves the patricia node from DB according to key
-func (t *trie) getPatricia(key []byte) (patricia, error) {
-	node, err := t.getCachedPatricia(key)
-
-	if node == nil {
-		node, err = t.dao.Get(t.bucket, key)
+// loadPatricia retrieves the patricia node from the node cache, or from DB and adds it to the cache
+func loadPatricia(dao db.KVStore, bucket string, cache *NodeCache, key []byte) (patricia, error) {
+	if cache != nil {
+		if node, ok := cache.get(bucket, key); ok {
+			return deserializePatricia(node)
+		}
 	}
-
+	node, err := dao.Get(bucket, key)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get key %x", key[:8])
 	}
-	var ptr patricia
-	// first byte of serialized data is type
-	switch node[0] {
-	case 2:
-		ptr = &branch{}
-	case 1:
-		ptr = &leaf{}
-	case 0:
-		ptr = &leaf{}
-	default:
-		return nil, errors.Wrapf(ErrInvalidPatricia, "invalid node type = %v", node[0])
+	if cache != nil {
+		// the value may be backed by the memory of the DB, which is only valid until the DB is written to
+		cache.put(bucket, key, append([]byte{}, node...))
 	}
-	if err := ptr.deserialize(node); err != nil {
-		return nil, err
-	}
-	return ptr, nil
+	return deserializePatricia(node)
 }
 
 // putPatricia stores the patricia node into DB
@@ -523,15 +1046,18 @@ func (t *trie) putPatricia(ptr patricia) error {
 		return errors.Wrapf(err, "failed to encode node")
 	}
 	key := ptr.hash()
+	t.logNode(key, false)
+	delete(t.deferred, key)
 	if t.batchMode {
 		t.dbBatch.Put(t.bucket, key[:], value, "failed to put key = %x", key[:8])
-		t.cache[key] = value
+		t.pending[key] = value
 		return nil
 	}
 
 	if err := t.dao.Put(t.bucket, key[:], value); err != nil {
 		return errors.Wrapf(err, "failed to put key = %x", key[:8])
 	}
+	t.cacheNode(key, value)
 	logger.Debug().Hex("key", key[:8]).Msg("put")
 	return nil
 }
@@ -539,17 +1065,21 @@ func (t *trie) putPatricia(ptr patricia) error {
 // putPatriciaNew stores a new patricia node into DB
 // it is expected the node does not exist yet, will return error if already exist
 func (t *trie) putPatriciaNew(ptr patricia) error {
+	key := ptr.hash()
+	if _, ok := t.deferred[key]; ok || t.history {
+		// the node may still be kept in DB as a stale node of an earlier version
+		return t.putPatricia(ptr)
+	}
 	value, err := ptr.serialize()
 	if err != nil {
 		return errors.Wrap(err, "failed to serialize patricia")
 	}
-	key := ptr.hash()
 	if t.batchMode {
-		if _, ok := t.cache[key]; ok {
+		if _, ok := t.pending[key]; ok {
 			return errors.New("failed to put non-existing key = %x")
 		}
 
-		t.cache[key] = value
+		t.pending[key] = value
 		t.dbBatch.PutIfNotExists(t.bucket, key[:], value, "failed to put non-existing key = %x", key[:8])
 		return nil
 	}
@@ -557,6 +1087,7 @@ func (t *trie) putPatriciaNew(ptr patricia) error {
 	if err := t.dao.PutIfNotExists(t.bucket, key[:], value); err != nil {
 		return errors.Wrapf(err, "failed to put non-existing key = %x", key[:8])
 	}
+	t.cacheNode(key, value)
 	logger.Debug().Hex("key", key[:8]).Msg("putnew")
 	return nil
 }
@@ -564,21 +1095,238 @@ func (t *trie) putPatriciaNew(ptr patricia) error {
 // delPatricia deletes the patricia node from DB
 func (t *trie) delPatricia(ptr patricia) error {
 	key := ptr.hash()
+	if t.history {
+		// keep the node in DB for the earlier versions, it is deleted when the version is pruned
+		t.logNode(key, true)
+		delete(t.pending, key)
+		return nil
+	}
+	// keep the node in DB for the readers of the versions having it, it is deleted once they are released
+	t.deferred[key] = t.latest.seq
+	delete(t.pending, key)
+	return nil
+}
+
+// cacheNode adds the node written to DB to the node cache
+func (t *trie) cacheNode(key hash.Hash32B, value []byte) {
+	if t.nodeCache != nil {
+		t.nodeCache.put(t.bucket, key[:], value)
+	}
+}
+
+// uncacheNode drops the node deleted from DB from the node cache
+func (t *trie) uncacheNode(key hash.Hash32B) {
+	if t.nodeCache != nil {
+		t.nodeCache.remove(t.bucket, key[:])
+	}
+}
+
+// logNode records the node put or made stale since the last checkpoint
+func (t *trie) logNode(key hash.Hash32B, stale bool) {
+	if !t.history {
+		return
+	}
+	if stale {
+		t.stale[key] = struct{}{}
+		return
+	}
+	t.created[key] = struct{}{}
+	delete(t.stale, key)
+}
+
+// publish makes a copy of the working root the latest version for the readers to pin, and deletes the stale nodes
+// which are no longer needed by any reader
+func (t *trie) publish() error {
+	value, err := t.root.serialize()
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize root")
+	}
+	root, err := deserializePatricia(value)
+	if err != nil {
+		return err
+	}
+	t.pinMutex.Lock()
+	seq := uint64(0)
+	if t.latest != nil {
+		seq = t.latest.seq + 1
+	}
+	t.latest = &version{seq: seq, root: root}
+	// the stale nodes of the versions before the oldest pinned one are not needed
+	oldest := seq + 1
+	for pinned := range t.pins {
+		if pinned < oldest {
+			oldest = pinned
+		}
+	}
+	t.pinMutex.Unlock()
+	return t.reclaim(oldest)
+}
+
+// publishIfNotBatch publishes the working root if the changes are written to DB already
+func (t *trie) publishIfNotBatch() error {
 	if t.batchMode {
-		t.dbBatch.Delete(t.bucket, key[:], "failed to delete key = %x", key[:8])
-		if _, ok := t.cache[key]; ok {
-			delete(t.cache, key)
+		return nil
+	}
+	return t.publish()
+}
+
+// reclaim deletes the deferred stale nodes which are not in the given or later versions
+func (t *trie) reclaim(oldest uint64) error {
+	var keys []hash.Hash32B
+	for key, seq := range t.deferred {
+		if seq < oldest {
+			keys = append(keys, key)
 		}
+	}
+	if len(keys) == 0 {
 		return nil
 	}
+	batch := t.dao.Batch()
+	for _, key := range keys {
+		batch.Delete(t.bucket, key[:], "failed to delete key = %x", key[:8])
+		delete(t.deferred, key)
+	}
+	t.putDeferred(batch)
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		t.uncacheNode(key)
+	}
+	return nil
+}
+
+// putDeferred records the deferred stale nodes in the batch, which is committed along with the nodes written on flush
+// and the nodes deleted on reclaim
+func (t *trie) putDeferred(batch db.KVStoreBatch) {
+	if !t.recordDeferred {
+		return
+	}
+	keys := make([]hash.Hash32B, 0, len(t.deferred))
+	for key := range t.deferred {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+	value := make([]byte, 0, len(keys)*len(hash.ZeroHash32B))
+	for _, key := range keys {
+		value = append(value, key[:]...)
+	}
+	// the record is emptied instead of deleted, as the namespace may not exist in DB yet
+	batch.Put(HistoryKVNameSpace, t.versionKey(deferredKey), value, "failed to put deferred nodes")
+}
 
-	if err := t.dao.Delete(t.bucket, key[:]); err != nil {
-		return errors.Wrapf(err, "failed to delete key = %x", key[:8])
+// deleteRecordedDeferred deletes the stale nodes recorded by the last run, which had no chance to delete them. The nodes
+// made stale since the last flush or reclaim are not recorded yet, so a crash can still leave a few behind
+func (t *trie) deleteRecordedDeferred() error {
+	if !t.recordDeferred {
+		return nil
+	}
+	value, err := t.dao.Get(HistoryKVNameSpace, t.versionKey(deferredKey))
+	if errors.Cause(err) == db.ErrNotExist {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to get deferred nodes")
+	}
+	if len(value)%len(hash.ZeroHash32B) != 0 {
+		return errors.Errorf("invalid deferred nodes of size %d", len(value))
+	}
+	if len(value) == 0 {
+		return nil
+	}
+	batch := t.dao.Batch()
+	for i := 0; i < len(value); i += len(hash.ZeroHash32B) {
+		key := value[i : i+len(hash.ZeroHash32B)]
+		batch.Delete(t.bucket, key, "failed to delete key = %x", key[:8])
+	}
+	batch.Put(HistoryKVNameSpace, t.versionKey(deferredKey), []byte{}, "failed to put deferred nodes")
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+	for i := 0; i < len(value); i += len(hash.ZeroHash32B) {
+		var key hash.Hash32B
+		copy(key[:], value[i:])
+		t.uncacheNode(key)
 	}
-	logger.Debug().Hex("key", key[:8]).Msg("del")
+	logger.Info().Str("bucket", t.bucket).Int("nodes", len(value)/len(hash.ZeroHash32B)).Msg("deleted stale nodes left behind")
 	return nil
 }
 
+// unpin releases a reader of the version
+func (t *trie) unpin(seq uint64) {
+	t.pinMutex.Lock()
+	defer t.pinMutex.Unlock()
+
+	if t.pins[seq]--; t.pins[seq] <= 0 {
+		delete(t.pins, seq)
+	}
+}
+
+//======================================
+// helper functions to operate history
+//======================================
+func (t *trie) historyKey(version uint64) []byte {
+	return append([]byte(t.bucket), byteutil.Uint64ToBytes(version)...)
+}
+
+func (t *trie) versionKey(key []byte) []byte {
+	return append([]byte(t.bucket), key...)
+}
+
+// getVersion returns the latest or oldest version of the histories, and false if there is no history yet
+func (t *trie) getVersion(key []byte) (uint64, bool, error) {
+	value, err := t.dao.Get(HistoryKVNameSpace, t.versionKey(key))
+	if errors.Cause(err) == db.ErrNotExist {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "failed to get version %s", key)
+	}
+	if len(value) != 8 {
+		return 0, false, errors.Errorf("invalid version %s %x", key, value)
+	}
+	return enc.MachineEndian.Uint64(value), true, nil
+}
+
+// getHistory returns the history of the given version, or nil if the version does not have a history
+func (t *trie) getHistory(version uint64) (*nodeHistory, error) {
+	value, err := t.dao.Get(HistoryKVNameSpace, t.historyKey(version))
+	if errors.Cause(err) == db.ErrNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get history of version %d", version)
+	}
+	h := &nodeHistory{}
+	if err := h.deserialize(value); err != nil {
+		return nil, errors.Wrapf(err, "failed to deserialize history of version %d", version)
+	}
+	return h, nil
+}
+
+func (h *nodeHistory) serialize() ([]byte, error) {
+	var stream bytes.Buffer
+	if err := gob.NewEncoder(&stream).Encode(h); err != nil {
+		return nil, err
+	}
+	return stream.Bytes(), nil
+}
+
+func (h *nodeHistory) deserialize(stream []byte) error {
+	return gob.NewDecoder(bytes.NewBuffer(stream)).Decode(h)
+}
+
+// mergeInto applies the history on top of the nodes put and made stale by the earlier histories
+func (h *nodeHistory) mergeInto(created, stale map[hash.Hash32B]struct{}) {
+	for _, key := range h.Created {
+		created[key] = struct{}{}
+		delete(stale, key)
+	}
+	for _, key := range h.Stale {
+		stale[key] = struct{}{}
+	}
+}
+
 // getValue returns the actual value stored in patricia node
 func (t *trie) getValue(ptr patricia, index byte) ([]byte, error) {
 	br, isBranch := ptr.(*branch)
@@ -626,8 +1374,7 @@ func (t *trie) EnableBatch() error {
 	}
 
 	// recreate the map to ensure it's clean
-	t.cache = nil
-	t.cache = make(map[hash.Hash32B][]byte)
+	t.pending = make(map[hash.Hash32B][]byte)
 
 	// recreate the batch to ensure it's clean
 	t.dbBatch = nil
@@ -645,8 +1392,16 @@ func (t *trie) DisableBatch() {
 
 // Flush flush batched db writes to database
 func (t *trie) Flush() error {
-	if t.batchMode {
-		return t.dbBatch.Commit()
+	if !t.batchMode {
+		return nil
 	}
-	return nil
+	t.putDeferred(t.dbBatch)
+	if err := t.dbBatch.Commit(); err != nil {
+		return err
+	}
+	for key, value := range t.pending {
+		t.cacheNode(key, value)
+	}
+	t.pending = make(map[hash.Hash32B][]byte)
+	return t.publish()
 }
@@ -7,6 +7,7 @@
 package trie
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
@@ -446,3 +447,180 @@ func TestQuery(t *testing.T) {
 	require.Equal(0, match)
 	require.Nil(err)
 }
+
+func TestHistoryPrune(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieWithHistory(dao, "test", EmptyRoot)
+	require.Nil(err)
+
+	// version 1
+	require.Nil(tr.Upsert(cat, testV[2]))
+	require.Nil(tr.Upsert(fox, testV[5]))
+	require.Nil(tr.Checkpoint(1))
+	root1 := tr.RootHash()
+	// version 2
+	require.Nil(tr.Upsert(cat, testV[3]))
+	require.Nil(tr.Upsert(dog, testV[4]))
+	require.Nil(tr.Checkpoint(2))
+	root2 := tr.RootHash()
+	// version 3
+	require.Nil(tr.Delete(fox))
+	require.Nil(tr.Checkpoint(3))
+	root3 := tr.RootHash()
+
+	// the earlier versions are still accessible
+	tr1, err := NewTrieSharedDB(dao, "test", root1)
+	require.Nil(err)
+	v, err := tr1.Get(cat)
+	require.Nil(err)
+	require.Equal(testV[2], v)
+	tr2, err := NewTrieSharedDB(dao, "test", root2)
+	require.Nil(err)
+	v, err = tr2.Get(fox)
+	require.Nil(err)
+	require.Equal(testV[5], v)
+
+	// prune the versions before 2
+	require.Nil(tr.Prune(2))
+	_, err = NewTrieSharedDB(dao, "test", root1)
+	require.NotNil(err)
+	tr2, err = NewTrieSharedDB(dao, "test", root2)
+	require.Nil(err)
+	v, err = tr2.Get(fox)
+	require.Nil(err)
+	require.Equal(testV[5], v)
+
+	// prune the versions before 3
+	require.Nil(tr.Prune(3))
+	_, err = NewTrieSharedDB(dao, "test", root2)
+	require.NotNil(err)
+	tr3, err := NewTrieSharedDB(dao, "test", root3)
+	require.Nil(err)
+	v, err = tr3.Get(cat)
+	require.Nil(err)
+	require.Equal(testV[3], v)
+	v, err = tr3.Get(dog)
+	require.Nil(err)
+	require.Equal(testV[4], v)
+	_, err = tr3.Get(fox)
+	require.NotNil(err)
+
+	// a node made stale and put back later is not pruned
+	require.Nil(tr.Upsert(fox, testV[5]))
+	require.Nil(tr.Checkpoint(4))
+	require.Equal(root2, tr.RootHash())
+	require.Nil(tr.Delete(fox))
+	require.Nil(tr.Checkpoint(5))
+	require.Nil(tr.Upsert(fox, testV[5]))
+	require.Nil(tr.Checkpoint(6))
+	require.Nil(tr.Prune(5))
+	require.Nil(tr.Prune(6))
+	tr6, err := NewTrieSharedDB(dao, "test", root2)
+	require.Nil(err)
+	v, err = tr6.Get(fox)
+	require.Nil(err)
+	require.Equal(testV[5], v)
+}
+
+func TestDiscard(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieWithHistory(dao, "test", EmptyRoot)
+	require.Nil(err)
+	require.Nil(tr.Upsert(cat, testV[2]))
+	require.Nil(tr.Upsert(dog, testV[4]))
+	require.Nil(tr.Checkpoint(1))
+	root1 := tr.RootHash()
+
+	// the nodes put after the checkpoint are deleted, and the ones of the checkpoint are kept
+	require.Nil(tr.Upsert(fox, testV[5]))
+	require.Nil(tr.Commit([][]byte{ham}, [][]byte{testV[0]}))
+	root2 := tr.RootHash()
+	require.Nil(Discard(tr))
+	_, err = NewTrieSharedDB(dao, "test", root2)
+	require.NotNil(err)
+	report, err := Check(dao, "test", root1, nil)
+	require.Nil(err)
+	require.True(report.OK())
+	require.Equal(2, report.Entries)
+	_, err = NewTrieSharedDB(dao, "test", EmptyRoot)
+	require.Nil(err)
+
+	// the nodes of a trie without history cannot be told apart from the ones put before
+	tr, err = NewTrieSharedDB(dao, "test", root1)
+	require.Nil(err)
+	require.Equal(ErrInvalidTrie, errors.Cause(Discard(tr)))
+}
+
+func TestDeleteAfterReopen(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot)
+	require.Nil(err)
+	require.Nil(tr.Upsert(cat, testV[2]))
+	require.Nil(tr.Upsert(dog, testV[4]))
+	root2 := tr.RootHash()
+	require.Nil(tr.Upsert(fox, testV[5]))
+	root3 := tr.RootHash()
+	require.Nil(tr.Upsert(ham, testV[0]))
+	root4 := tr.RootHash()
+
+	// the entries added before the trie is reopened are deleted as if they were added to the reopened trie
+	tr, err = NewTrieSharedDB(dao, "test", root4)
+	require.Nil(err)
+	require.Nil(tr.Delete(ham))
+	require.Equal(root3, tr.RootHash())
+	require.Nil(tr.Delete(fox))
+	require.Equal(root2, tr.RootHash())
+	require.Nil(tr.Delete(dog))
+	require.Nil(tr.Delete(cat))
+	require.Equal(EmptyRoot, tr.RootHash())
+	require.NotNil(tr.Delete(cat))
+}
+
+func TestWalk(t *testing.T) {
+	require := require.New(t)
+
+	dao := db.NewMemKVStore()
+	tr, err := NewTrieSharedDB(dao, "test", EmptyRoot)
+	require.Nil(err)
+	// the keys share prefixes, so the trie has branch, extension and leaf nodes
+	keys := [][]byte{ham, car, cat, dog, egg, fox, cow, ant}
+	for i, key := range keys {
+		require.Nil(tr.Upsert(key, testV[i]))
+	}
+
+	var walked [][]byte
+	entries := make(map[string][]byte)
+	require.Nil(Walk(dao, "test", tr.RootHash(), func(key, value []byte) error {
+		walked = append(walked, key)
+		entries[string(key)] = value
+		return nil
+	}))
+	require.Equal(len(keys), len(walked))
+	for i, key := range keys {
+		require.Equal(testV[i], entries[string(key)])
+	}
+	// the entries are visited in the order of the keys
+	for i := 1; i < len(walked); i++ {
+		require.True(bytes.Compare(walked[i-1], walked[i]) < 0)
+	}
+
+	// the walk stops at the first error
+	count := 0
+	err = Walk(dao, "test", tr.RootHash(), func(key, value []byte) error {
+		count++
+		return ErrNotExist
+	})
+	require.Equal(ErrNotExist, errors.Cause(err))
+	require.Equal(1, count)
+
+	// an empty trie has no entry
+	require.Nil(Walk(dao, "test", EmptyRoot, func(key, value []byte) error {
+		return ErrInvalidTrie
+	}))
+}
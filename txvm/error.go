@@ -27,6 +27,10 @@ const (
 	ErrEqualVerify
 	// ErrInvalidStackOperation ...
 	ErrInvalidStackOperation
+	// ErrOutOfGas ...
+	ErrOutOfGas
+	// ErrNoContext ...
+	ErrNoContext
 )
 
 // ScriptError defines the struct of script error
@@ -91,6 +91,13 @@ const (
 	OpOtherSPVVerify
 )
 
+// Contract
+const (
+	OpCaller = iota + 0xc8
+	OpSLoad
+	OpSStore
+)
+
 // Enumerate unused opcodes
 const (
 	OpUnused = iota + 0xd0
@@ -107,6 +114,11 @@ type opinfo struct {
 // ************************************
 var opinfoArray [256]opinfo
 
+// ************************************
+// Mapping from opcode to the gas charged for running it in a contract
+// ************************************
+var opgasArray [256]uint64
+
 func opConstructDefault(bytecodes []byte) (*OpNode, int, error) {
 	opcode := bytecodes[0]
 	node := OpNode{}
@@ -251,6 +263,46 @@ func opcodeCheckSig(node *OpNode, vm *IVM) error {
 	return opcodePushFalse(node, vm)
 }
 
+func opcodeCaller(node *OpNode, vm *IVM) error {
+	if vm.ctx == nil {
+		return scriptError(ErrNoContext, "no contract context, cannot get caller")
+	}
+	vm.dstack = append(vm.dstack, vm.ctx.Caller)
+	return nil
+}
+
+func opcodeSLoad(node *OpNode, vm *IVM) error {
+	if vm.ctx == nil || vm.ctx.Storage == nil {
+		return scriptError(ErrNoContext, "no contract storage, cannot SLoad")
+	}
+	if len(vm.dstack) == 0 {
+		return scriptError(ErrInvalidStackOperation, "empty stack, cannot SLoad")
+	}
+
+	key := vm.dstack[len(vm.dstack)-1]
+	vm.dstack = vm.dstack[:len(vm.dstack)-1] // pop
+	value, err := vm.ctx.Storage.Load(key)
+	if err != nil {
+		return err
+	}
+	vm.dstack = append(vm.dstack, value)
+	return nil
+}
+
+func opcodeSStore(node *OpNode, vm *IVM) error {
+	if vm.ctx == nil || vm.ctx.Storage == nil {
+		return scriptError(ErrNoContext, "no contract storage, cannot SStore")
+	}
+	if len(vm.dstack) < 2 {
+		return scriptError(ErrInvalidStackOperation, "stack has too few entries, cannot SStore")
+	}
+
+	key := vm.dstack[len(vm.dstack)-1]
+	value := vm.dstack[len(vm.dstack)-2]
+	vm.dstack = vm.dstack[:len(vm.dstack)-2] // pop
+	return vm.ctx.Storage.Store(key, value)
+}
+
 func opcodeRunBranch(node *OpNode, vm *IVM) error {
 	return scriptError(ErrUnsupportedOpcode, "Unimplemented")
 }
@@ -282,4 +334,16 @@ func init() {
 	opinfoArray[OpHash160] = opinfo{"OpHash160", opConstructDefault, opcodeHash160}
 	opinfoArray[OpEqualVerify] = opinfo{"OpEqualVerify", opConstructDefault, opcodeEqualVerify}
 	opinfoArray[OpCheckSig] = opinfo{"OpCheckSig", opConstructDefault, opcodeCheckSig}
+
+	opinfoArray[OpCaller] = opinfo{"OpCaller", opConstructDefault, opcodeCaller}
+	opinfoArray[OpSLoad] = opinfo{"OpSLoad", opConstructDefault, opcodeSLoad}
+	opinfoArray[OpSStore] = opinfo{"OpSStore", opConstructDefault, opcodeSStore}
+
+	for i := range opgasArray {
+		opgasArray[i] = 1
+	}
+	opgasArray[OpHash160] = 20
+	opgasArray[OpCheckSig] = 100
+	opgasArray[OpSLoad] = 50
+	opgasArray[OpSStore] = 200
 }
@@ -6,17 +6,49 @@
 
 package txvm
 
+import (
+	"fmt"
+)
+
+// Storage defines the interface of the key-value storage of a contract
+type Storage interface {
+	// Load returns the value of the key, which is empty if the key is not set
+	Load(key []byte) ([]byte, error)
+	// Store sets the value of the key
+	Store(key []byte, value []byte) error
+}
+
+// Context defines the environment of running a contract
+type Context struct {
+	// Caller is the address of the account invoking the contract
+	Caller []byte
+	// Storage is the storage of the contract being invoked
+	Storage Storage
+	// GasLimit is the maximum gas the contract can consume
+	GasLimit uint64
+}
+
 // IVM defines the struct of IoTeX Virtual Machine
 type IVM struct {
-	ast    *IAST
-	dstack [][]byte
-	txin   []byte
+	ast     *IAST
+	dstack  [][]byte
+	txin    []byte
+	ctx     *Context
+	gasUsed uint64
 }
 
 // Execute executes IoTeX Virtual Machine
 func (vm *IVM) Execute() (err error) {
 	// TODO: evaluate AST recursively
 	for _, node := range vm.ast.nodes {
+		if vm.ctx != nil {
+			gas := opgasArray[node.opcode]
+			if vm.gasUsed+gas > vm.ctx.GasLimit {
+				vm.gasUsed = vm.ctx.GasLimit
+				return scriptError(ErrOutOfGas, fmt.Sprintf("out of gas, limit %d", vm.ctx.GasLimit))
+			}
+			vm.gasUsed += gas
+		}
 		if err := opinfoArray[node.opcode].runfunc(&node, vm); err != nil {
 			return err
 		}
@@ -24,6 +56,11 @@ func (vm *IVM) Execute() (err error) {
 	return nil
 }
 
+// GasUsed returns the gas consumed by running the contract, which is always 0 if there is no contract context
+func (vm *IVM) GasUsed() uint64 {
+	return vm.gasUsed
+}
+
 // NewIVM creates a new IoTeX Virtual Machine
 func NewIVM(txin, bytecodes []byte) (*IVM, error) {
 	ast, err := ParseRaw(bytecodes)
@@ -33,3 +70,26 @@ func NewIVM(txin, bytecodes []byte) (*IVM, error) {
 	vm := IVM{ast: ast, txin: txin}
 	return &vm, nil
 }
+
+// NewContractIVM creates a new IoTeX Virtual Machine running the contract code in the given context. The input must
+// only push data, which is left on the stack for the code to consume
+func NewContractIVM(ctx *Context, input, code []byte) (*IVM, error) {
+	inputAST, err := ParseRaw(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range inputAST.nodes {
+		// opcodes up to OpData72 are all push operations
+		if node.opcode > OpData72 {
+			return nil, scriptError(ErrInvalidOpcode,
+				fmt.Sprintf("input is not push-only, opcode %x found", node.opcode))
+		}
+	}
+	ast, err := ParseRaw(code)
+	if err != nil {
+		return nil, err
+	}
+	ast.nodes = append(inputAST.nodes, ast.nodes...)
+	vm := IVM{ast: ast, txin: input, ctx: ctx}
+	return &vm, nil
+}
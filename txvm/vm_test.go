@@ -31,3 +31,58 @@ func TestNewIVM(t *testing.T) {
 	err = vm.Execute()
 	assert.Nil(t, err)
 }
+
+type memStorage map[string][]byte
+
+func (s memStorage) Load(key []byte) ([]byte, error) {
+	return s[string(key)], nil
+}
+
+func (s memStorage) Store(key []byte, value []byte) error {
+	s[string(key)] = value
+	return nil
+}
+
+func TestContractIVM(t *testing.T) {
+	t.Parallel()
+
+	// code stores the input under key 0x01, and copies it to key 0x02
+	code := []byte{OpData1, 0x01, OpSStore, OpData1, 0x01, OpSLoad, OpData1, 0x02, OpSStore}
+	input := []byte{OpData2, 0x12, 0x34}
+	storage := memStorage{}
+	ctx := &Context{Caller: []byte("caller"), Storage: storage, GasLimit: 1000}
+	vm, err := NewContractIVM(ctx, input, code)
+	assert.Nil(t, err)
+	assert.Nil(t, vm.Execute())
+	assert.Equal(t, []byte{0x12, 0x34}, storage[string([]byte{0x01})])
+	assert.Equal(t, []byte{0x12, 0x34}, storage[string([]byte{0x02})])
+	assert.Equal(t, 3*opgasArray[OpData1]+opgasArray[OpData2]+opgasArray[OpSLoad]+2*opgasArray[OpSStore], vm.GasUsed())
+
+	// caller
+	vm, err = NewContractIVM(ctx, nil, []byte{OpCaller, OpData1, 0x03, OpSStore})
+	assert.Nil(t, err)
+	assert.Nil(t, vm.Execute())
+	assert.Equal(t, []byte("caller"), storage[string([]byte{0x03})])
+
+	// out of gas leaves the storage untouched and consumes the whole limit
+	ctx = &Context{Storage: memStorage{}, GasLimit: 100}
+	vm, err = NewContractIVM(ctx, input, code)
+	assert.Nil(t, err)
+	err = vm.Execute()
+	assert.NotNil(t, err)
+	assert.Equal(t, ErrOutOfGas, err.(ScriptError).ErrorCode)
+	assert.Equal(t, uint64(100), vm.GasUsed())
+	assert.Equal(t, 0, len(ctx.Storage.(memStorage)))
+
+	// input must be push-only
+	_, err = NewContractIVM(ctx, []byte{OpData1, 0x01, OpSStore}, code)
+	assert.NotNil(t, err)
+
+	// storage operations require a contract context
+	vm, err = NewIVM([]byte{}, code)
+	assert.Nil(t, err)
+	err = vm.Execute()
+	assert.NotNil(t, err)
+	assert.Equal(t, ErrNoContext, err.(ScriptError).ErrorCode)
+	assert.Equal(t, uint64(0), vm.GasUsed())
+}